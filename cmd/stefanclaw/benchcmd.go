@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/bench"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+const benchUsage = "usage: stefanclaw bench [-m <model>]..."
+
+// runBenchCmd implements `stefanclaw bench [-m <model>]...`. Without -m,
+// which may be repeated, every installed model is benchmarked. Contexts
+// are swept up to provider.ollama.max_num_ctx.
+func runBenchCmd(w io.Writer, ollamaURL string, args []string) error {
+	if config.IsFirstRun() {
+		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+
+	var models []string
+	for i := 0; i < len(args); i++ {
+		if (args[i] == "-m" || args[i] == "--model") && i+1 < len(args) {
+			i++
+			models = append(models, args[i])
+			continue
+		}
+		return errors.New(benchUsage)
+	}
+
+	prov, err := newProvider(cfg)
+	if err != nil {
+		return err
+	}
+	checkCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := checkProvider(checkCtx, prov); err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		installed, err := prov.ListModels(checkCtx)
+		if err != nil {
+			return fmt.Errorf("listing models: %w", err)
+		}
+		for _, m := range installed {
+			models = append(models, m.Name)
+		}
+		if len(models) == 0 {
+			return fmt.Errorf("no models installed (install one with: ollama pull qwen3:4b)")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(w, "Benchmarking %d model(s) with contexts up to %d; this can take a few minutes.\n",
+		len(models), cfg.Provider.Ollama.MaxNumCtx)
+	progress := func(model, step string) {
+		fmt.Fprintf(w, "\r\033[K%s: %s", model, step)
+	}
+	results, err := bench.Benchmark(ctx, prov, models, cfg.Provider.Ollama.MaxNumCtx, progress)
+	fmt.Fprint(w, "\r\033[K")
+	bench.Print(w, results)
+	if err != nil {
+		return fmt.Errorf("interrupted; results so far are shown above")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/stefanclaw/stefanclaw/internal/channel"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+// runDiscordCmd implements `stefanclaw discord`: it answers Discord messages
+// until interrupted.
+func runDiscordCmd(w io.Writer, ollamaURL string) error {
+	cfg, err := loadChannelConfig(ollamaURL)
+	if err != nil {
+		return err
+	}
+	resolve := secrets.New(config.SecretsFile()).Resolve
+	token, err := requireToken(resolve, "discord.token", cfg.Discord.Token)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Discord.AllowedUsers) == 0 {
+		return fmt.Errorf("discord.allowed_users is empty in %s; add your Discord user ID", config.ConfigFile())
+	}
+
+	bridge, err := newBridge(cfg)
+	if err != nil {
+		return err
+	}
+	bot := &channel.Discord{
+		Token:        token,
+		Channels:     cfg.Discord.Channels,
+		AllowedUsers: cfg.Discord.AllowedUsers,
+		Bridge:       bridge,
+		Log:          os.Stderr,
+	}
+	return runChannel(w, bot, "Discord")
+}
+
+// runSlackCmd implements `stefanclaw slack`: it answers Slack messages and
+// slash commands until interrupted.
+func runSlackCmd(w io.Writer, ollamaURL string) error {
+	cfg, err := loadChannelConfig(ollamaURL)
+	if err != nil {
+		return err
+	}
+	resolve := secrets.New(config.SecretsFile()).Resolve
+	appToken, err := requireToken(resolve, "slack.app_token", cfg.Slack.AppToken)
+	if err != nil {
+		return err
+	}
+	botToken, err := requireToken(resolve, "slack.bot_token", cfg.Slack.BotToken)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Slack.AllowedUsers) == 0 {
+		return fmt.Errorf("slack.allowed_users is empty in %s; add your Slack member ID", config.ConfigFile())
+	}
+
+	bridge, err := newBridge(cfg)
+	if err != nil {
+		return err
+	}
+	app := &channel.Slack{
+		AppToken:     appToken,
+		BotToken:     botToken,
+		Channels:     cfg.Slack.Channels,
+		AllowedUsers: cfg.Slack.AllowedUsers,
+		Bridge:       bridge,
+		Log:          os.Stderr,
+	}
+	return runChannel(w, app, "Slack")
+}
+
+// runServeCmd implements `stefanclaw serve --bridge`: it answers browser
+// extensions and editor plugins on a loopback address until interrupted.
+func runServeCmd(w io.Writer, ollamaURL string, args []string) error {
+	if len(args) != 1 || args[0] != "--bridge" {
+		return fmt.Errorf("usage: stefanclaw serve --bridge")
+	}
+	cfg, err := loadChannelConfig(ollamaURL)
+	if err != nil {
+		return err
+	}
+	token, err := requireToken(secrets.New(config.SecretsFile()).Resolve, "bridge.token", cfg.Bridge.Token)
+	if err != nil {
+		return err
+	}
+
+	bridge, err := newBridge(cfg)
+	if err != nil {
+		return err
+	}
+	srv := &channel.HTTP{
+		Addr:   cfg.Bridge.Listen,
+		Token:  token,
+		Bridge: bridge,
+		Log:    os.Stderr,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := srv.Start(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Bridge listening on %s; press Ctrl+C to stop.\n", srv.URL())
+	<-ctx.Done()
+	return srv.Stop()
+}
+
+// loadChannelConfig loads the config for a chat integration.
+func loadChannelConfig(ollamaURL string) (config.Config, error) {
+	if config.IsFirstRun() {
+		return config.Config{}, fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return config.Config{}, fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+	return cfg, nil
+}
+
+// requireToken resolves a token setting, which must not be empty.
+func requireToken(resolve func(string) (string, error), key, value string) (string, error) {
+	token, err := resolve(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	if token == "" {
+		return "", fmt.Errorf("%s is not set in %s", key, config.ConfigFile())
+	}
+	return token, nil
+}
+
+// newBridge builds the Bridge shared by the chat integrations.
+func newBridge(cfg config.Config) (*channel.Bridge, error) {
+	runner, mem, err := newAgentRunner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sessions := session.NewFileStore(config.SessionsDir())
+	pipeline := newPipeline(cfg)
+	pipeline.Sessions = sessions
+	return &channel.Bridge{
+		Runner:   runner,
+		Sessions: sessions,
+		Memory:   mem,
+		Pipeline: pipeline,
+		MapFile:  config.ChannelsFile(),
+	}, nil
+}
+
+// runChannel runs ch until interrupted.
+func runChannel(w io.Writer, ch channel.Channel, service string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := ch.Start(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Connected to %s; press Ctrl+C to stop.\n", service)
+	<-ctx.Done()
+	return ch.Stop()
+}
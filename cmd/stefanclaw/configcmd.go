@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// configPaths lists the resolved locations shown by `config path`, in order.
+func configPaths() []struct{ name, path string } {
+	return []struct{ name, path string }{
+		{"config", config.ConfigFile()},
+		{"dir", config.Dir()},
+		{"personality", config.PersonalityDir()},
+		{"data", config.DataDir()},
+		{"sessions", config.SessionsDir()},
+		{"memory", config.MemoryFile()},
+		{"reminders", config.RemindersFile()},
+		{"tasks", config.TasksFile()},
+		{"heartbeat", config.HeartbeatFile()},
+		{"heartbeat-stats", config.HeartbeatStatsFile()},
+		{"heartbeat-feedback", config.HeartbeatFeedbackFile()},
+		{"inbox", config.InboxFile()},
+		{"release-notes", config.ReleaseNotesFile()},
+		{"context-sizes", config.ContextSizesFile()},
+		{"usage", config.UsageFile()},
+		{"log", config.LogFile()},
+		{"crashes", config.CrashReportsDir()},
+		{"knowledge", config.KnowledgeIndexFile()},
+		{"channels", config.ChannelsFile()},
+		{"cache", config.CacheDir()},
+		{"templates", config.TemplatesDir()},
+		{"plugins", config.PluginsDir()},
+		{"secrets", config.SecretsFile()},
+	}
+}
+
+// runConfigCmd implements `stefanclaw config init|path`.
+func runConfigCmd(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: stefanclaw config init [--force] | path [<name>]")
+	}
+
+	switch args[0] {
+	case "init":
+		force := len(args) > 1 && args[1] == "--force"
+		if err := config.Init(force); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Wrote %s\n", config.ConfigFile())
+		return nil
+
+	case "path":
+		paths := configPaths()
+		if len(args) > 1 {
+			for _, p := range paths {
+				if p.name == args[1] {
+					fmt.Fprintln(w, p.path)
+					return nil
+				}
+			}
+			names := make([]string, len(paths))
+			for i, p := range paths {
+				names[i] = p.name
+			}
+			return fmt.Errorf("unknown path %q (valid: %s)", args[1], strings.Join(names, ", "))
+		}
+
+		profile := config.Profile()
+		if profile == "" {
+			profile = "(default)"
+		}
+		fmt.Fprintf(w, "%-19s %s\n", "profile", profile)
+		profiles, err := config.Profiles()
+		if err != nil {
+			return err
+		}
+		others := "(none)"
+		if len(profiles) > 0 {
+			others = strings.Join(profiles, ", ")
+		}
+		fmt.Fprintf(w, "%-19s %s\n", "profiles", others)
+		for _, p := range paths {
+			fmt.Fprintf(w, "%-19s %s\n", p.name, p.path)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown config command %q (use init or path)", args[0])
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/daemon"
+	"github.com/stefanclaw/stefanclaw/internal/email"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/jobs"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+// runDaemonCmd implements `stefanclaw daemon`: heartbeats, jobs, reminders
+// and calendar alerts without the TUI, until interrupted.
+func runDaemonCmd(w io.Writer, ollamaURL string, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: stefanclaw daemon")
+	}
+	if config.IsFirstRun() {
+		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+	if cfg.Privacy.DisableHeartbeat {
+		cfg.Heartbeat.Enabled = false
+	}
+
+	resolve := secrets.New(config.SecretsFile()).Resolve
+	runner, mem, err := newAgentRunner(cfg)
+	if err != nil {
+		return err
+	}
+	mailer := email.New(cfg.Email, resolve).WithPrivacy(cfg.Privacy)
+	lead, _ := time.ParseDuration(cfg.Calendar.Remind)
+	d := &daemon.Daemon{
+		Runner:            runner,
+		Language:          cfg.Language,
+		Heartbeat:         cfg.Heartbeat,
+		HeartbeatTasks:    readHeartbeatTasks,
+		HeartbeatStore:    heartbeat.NewStore(config.HeartbeatFile()),
+		HeartbeatStats:    heartbeat.NewStatsStore(config.HeartbeatStatsFile()),
+		HeartbeatFeedback: heartbeat.NewFeedbackStore(config.HeartbeatFeedbackFile()),
+		OnBattery:         heartbeat.OnBattery,
+		Jobs:              cfg.Jobs,
+		Targets:           jobs.Targets{Memory: mem, Mail: mailer},
+		Reminders:         reminder.NewStore(config.RemindersFile()),
+		Calendar:          calendar.FromConfig(cfg.Calendar, cfg.Privacy),
+		Lead:              lead,
+		Sessions:          session.NewFileStore(config.SessionsDir()),
+		Inbox:             daemon.NewInbox(config.InboxFile()),
+		Notifier:          notify.New(cfg.Notify, resolve).WithPrivacy(cfg.Privacy),
+		Mailer:            mailer,
+		Log:               w,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(w, "Running %s; press Ctrl+C to stop.\n", describeDaemon(d))
+	return d.Run(ctx)
+}
+
+// readHeartbeatTasks reads the tasks from HEARTBEAT.md, so edits apply to
+// the next check-in.
+func readHeartbeatTasks() []heartbeat.Task {
+	asm := prompt.NewAssembler(config.PersonalityDir())
+	asm.LoadFiles()
+	return heartbeat.Parse(asm.Section(prompt.SectionHeartbeat))
+}
+
+// describeDaemon lists the work d does, e.g. "heartbeats every 4h, 2 job(s),
+// reminders".
+func describeDaemon(d *daemon.Daemon) string {
+	var parts []string
+	if d.Heartbeat.Enabled {
+		parts = append(parts, "heartbeats every "+d.Heartbeat.Interval)
+	}
+	if n := len(d.Jobs); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d job(s)", n))
+	}
+	parts = append(parts, "reminders")
+	if d.Calendar != nil && d.Lead > 0 {
+		parts = append(parts, "calendar alerts")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+// runDryRun assembles the request that would be sent for question and writes
+// it to w without contacting the provider. In pipe mode no history is
+// included; otherwise the current session's transcript is used, matching what
+// the TUI would send.
+func runDryRun(w io.Writer, ollamaURL, question string, pipeMode bool) error {
+	if config.IsFirstRun() {
+		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+
+	question, err := readQuestion(question)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+
+	var history []provider.Message
+	if !pipeMode {
+		sessStore := session.NewFileStore(config.SessionsDir())
+		sess, err := sessStore.Current()
+		if err != nil {
+			return fmt.Errorf("loading current session: %w", err)
+		}
+		if sess != nil {
+			transcript, err := sessStore.LoadTranscript(sess.ID)
+			if err != nil {
+				return fmt.Errorf("loading transcript: %w", err)
+			}
+			for _, m := range sess.Resume(transcript) {
+				if m.Role == "user" || m.Role == "assistant" {
+					history = append(history, m)
+				}
+			}
+		}
+	}
+
+	msgs := newPipeline(cfg).Messages(context.Background(), history, question)
+	printRequest(w, provider.ChatRequest{
+		Model:    cfg.Model.Default,
+		Messages: msgs,
+		Options:  cfg.Sampling.For(cfg.Model.Default).Merge(samplingFlags),
+	}, cfg.Provider.BaseURL())
+	return nil
+}
+
+// printRequest writes a human-readable dump of a chat request.
+func printRequest(w io.Writer, req provider.ChatRequest, endpoint string) {
+	fmt.Fprintf(w, "Model:    %s\n", req.Model)
+	fmt.Fprintf(w, "Endpoint: %s\n", endpoint)
+	if !req.Options.IsZero() {
+		fmt.Fprintf(w, "Sampling: %s\n", req.Options)
+	}
+	fmt.Fprintf(w, "Messages: %d (~%d tokens)\n", len(req.Messages), session.EstimateTokens(req.Messages))
+	for i, m := range req.Messages {
+		fmt.Fprintf(w, "\n===== [%d] %s =====\n", i, strings.ToUpper(m.Role))
+		fmt.Fprintln(w, m.Content)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/email"
+	"github.com/stefanclaw/stefanclaw/internal/jobs"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
+)
+
+// runJobsCmd implements `stefanclaw jobs [list] | run <name> | daemon`.
+func runJobsCmd(w io.Writer, ollamaURL string, args []string) error {
+	if config.IsFirstRun() {
+		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+
+	resolve := secrets.New(config.SecretsFile()).Resolve
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "list"):
+		listJobs(w, cfg.Jobs, time.Now())
+		return nil
+	case len(args) == 2 && args[0] == "run":
+		job, ok := jobs.Find(cfg.Jobs, args[1])
+		if !ok {
+			return fmt.Errorf("no job named %q", args[1])
+		}
+		runner, mem, err := newAgentRunner(cfg)
+		if err != nil {
+			return err
+		}
+		targets := jobs.Targets{Memory: mem, Mail: email.New(cfg.Email, resolve).WithPrivacy(cfg.Privacy)}
+		_, err = runJob(context.Background(), w, runner, targets, job)
+		return err
+	case len(args) == 1 && args[0] == "daemon":
+		if len(cfg.Jobs) == 0 {
+			return fmt.Errorf("no jobs configured in %s", config.ConfigFile())
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runner, mem, err := newAgentRunner(cfg)
+		if err != nil {
+			return err
+		}
+		targets := jobs.Targets{Memory: mem, Mail: email.New(cfg.Email, resolve).WithPrivacy(cfg.Privacy)}
+		notifier := notify.New(cfg.Notify, resolve).WithPrivacy(cfg.Privacy)
+		return runJobsDaemon(ctx, w, runner, targets, notifier, cfg.Jobs)
+	}
+	return fmt.Errorf("usage: stefanclaw jobs [list] | run <name> | daemon")
+}
+
+// listJobs prints each job with its schedule, next run and output.
+func listJobs(w io.Writer, list []config.JobConfig, now time.Time) {
+	if len(list) == 0 {
+		fmt.Fprintf(w, "No jobs configured. Add them under jobs: in %s.\n", config.ConfigFile())
+		return
+	}
+	for _, job := range list {
+		next := "never"
+		if t := jobs.Next(job, now); !t.IsZero() {
+			next = t.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%-16s %-12s next %s → %s\n", job.Name, job.Schedule, next, jobs.Describe(job))
+	}
+}
+
+// runJob runs one job and delivers its answer. Notifications are printed.
+// It returns a summary of the outcome for webhooks.
+func runJob(ctx context.Context, w io.Writer, runner *agent.Runner, targets jobs.Targets, job config.JobConfig) (string, error) {
+	answer, err := jobs.Run(ctx, runner, job)
+	if err != nil {
+		return "", fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	now := time.Now()
+	if err := jobs.Deliver(job, answer, now, targets); err != nil {
+		return "", fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if job.Output == "" || job.Output == jobs.OutputNotification {
+		fmt.Fprintf(w, "===== %s (%s) =====\n%s\n\n", job.Name, now.Format("2006-01-02 15:04"), answer)
+		return answer, nil
+	}
+	summary := jobs.Summary(job)
+	fmt.Fprintf(w, "%s %s\n", now.Format("2006-01-02 15:04"), summary)
+	return summary, nil
+}
+
+// runJobsDaemon runs jobs on their schedules until ctx is cancelled. A
+// failing job is reported and retried at its next scheduled time. Results
+// and failures also go to the job webhooks.
+func runJobsDaemon(ctx context.Context, w io.Writer, runner *agent.Runner, targets jobs.Targets, notifier *notify.Notifier, list []config.JobConfig) error {
+	fmt.Fprintf(w, "Running %d job(s); press Ctrl+C to stop.\n", len(list))
+	next := make([]time.Time, len(list))
+	for i, job := range list {
+		next[i] = jobs.Next(job, time.Now())
+	}
+	for {
+		var wake time.Time
+		for _, t := range next {
+			if !t.IsZero() && (wake.IsZero() || t.Before(wake)) {
+				wake = t
+			}
+		}
+		if wake.IsZero() {
+			return fmt.Errorf("no job has an upcoming run")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(wake)):
+		}
+
+		now := time.Now()
+		for i, job := range list {
+			if next[i].IsZero() || now.Before(next[i]) {
+				continue
+			}
+			next[i] = jobs.Next(job, now)
+			text, err := runJob(ctx, w, runner, targets, job)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				text = err.Error()
+			}
+			ev := notify.Event{Kind: notify.EventJob, Title: "Job " + job.Name, Message: text}
+			if err := notifier.Send(ctx, ev); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}
+	}
+}
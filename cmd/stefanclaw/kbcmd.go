@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/knowledge"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+const kbUsage = "usage: stefanclaw kb [status] | index | rebuild [--path <dir>]"
+
+// runKBCmd implements `stefanclaw kb [status] | index | rebuild [--path <dir>]`.
+// --path, which may be repeated, limits the command to those directories
+// instead of knowledge.dirs.
+func runKBCmd(w io.Writer, ollamaURL string, args []string) error {
+	if config.IsFirstRun() {
+		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
+	}
+	if ollamaURL != "" {
+		cfg.Provider.Ollama.BaseURL = ollamaURL
+	}
+
+	sub := ""
+	var dirs []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--path" && i+1 < len(args):
+			i++
+			dir, err := filepath.Abs(args[i])
+			if err != nil {
+				return err
+			}
+			dirs = append(dirs, dir)
+		case strings.HasPrefix(arg, "-") || sub != "":
+			return errors.New(kbUsage)
+		default:
+			sub = arg
+		}
+	}
+
+	prov, err := newProvider(cfg)
+	if err != nil {
+		return err
+	}
+	emb, ok := prov.(provider.Embedder)
+	if !ok {
+		return fmt.Errorf("%s can't compute embeddings", prov.Name())
+	}
+	kb := knowledge.New(cfg.Knowledge, config.KnowledgeIndexFile(), emb)
+	switch sub {
+	case "", "status":
+		return printKBStatus(w, kb, cfg.Knowledge, dirs)
+	case "index", "rebuild":
+		checkCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := checkProvider(checkCtx, prov); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		run := kb.Index
+		if sub == "rebuild" {
+			run = kb.Rebuild
+		}
+		progress := func(done, total int, path string) {
+			fmt.Fprintf(w, "\r\033[K[%d/%d] %s", done, total, knowledge.ShortPath(path))
+		}
+		r, err := run(ctx, dirs, progress)
+		fmt.Fprintln(w)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("interrupted; the files done so far are saved, run it again to continue")
+			}
+			return fmt.Errorf("%w (the files done so far are saved)", err)
+		}
+		fmt.Fprintln(w, r.Summary())
+		return nil
+	}
+	return errors.New(kbUsage)
+}
+
+// printKBStatus shows what is indexed and what the next index run would do.
+func printKBStatus(w io.Writer, kb *knowledge.Base, cfg config.KnowledgeConfig, dirs []string) error {
+	if dirs == nil {
+		dirs = kb.Dirs()
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintf(w, "No knowledge directories configured. Add them under knowledge.dirs in %s.\n", config.ConfigFile())
+		return nil
+	}
+	for _, d := range dirs {
+		fmt.Fprintf(w, "Directory:  %s\n", knowledge.ShortPath(d))
+	}
+	st, err := kb.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Index:      %s\n", knowledge.ShortPath(config.KnowledgeIndexFile()))
+	if st.Updated.IsZero() {
+		fmt.Fprintln(w, "            not built yet; run stefanclaw kb index")
+		return nil
+	}
+	fmt.Fprintf(w, "            %s, %s, updated %s\n", st.Size(), st.Model, st.Updated.Format("2006-01-02 15:04"))
+
+	r, err := kb.Pending(dirs)
+	if err != nil {
+		return err
+	}
+	switch {
+	case st.Model != cfg.EmbedModel:
+		fmt.Fprintf(w, "The index was made with %s; stefanclaw kb index embeds everything with %s.\n", st.Model, cfg.EmbedModel)
+	case r.Added+r.Changed+r.Removed == 0:
+		fmt.Fprintln(w, "Up to date.")
+	default:
+		fmt.Fprintf(w, "Out of date: %d new, %d changed, %d removed; run stefanclaw kb index.\n", r.Added, r.Changed, r.Removed)
+	}
+	return nil
+}
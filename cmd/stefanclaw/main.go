@@ -2,50 +2,180 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
 
 	"github.com/stefanclaw/stefanclaw/internal/config"
-	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/daemon"
+	"github.com/stefanclaw/stefanclaw/internal/email"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+	"github.com/stefanclaw/stefanclaw/internal/log"
 	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
 	"github.com/stefanclaw/stefanclaw/internal/onboard"
+	"github.com/stefanclaw/stefanclaw/internal/plugin"
 	"github.com/stefanclaw/stefanclaw/internal/prompt"
 	"github.com/stefanclaw/stefanclaw/internal/provider"
-	"github.com/stefanclaw/stefanclaw/internal/provider/ollama"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+	"github.com/stefanclaw/stefanclaw/internal/replycache"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
 	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+	"github.com/stefanclaw/stefanclaw/internal/todo"
 	"github.com/stefanclaw/stefanclaw/internal/tui"
 	"github.com/stefanclaw/stefanclaw/internal/update"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
 )
 
 var version = "dev"
 
+// samplingFlags holds sampling options given on the command line
+// (--temperature, --top-p, ...). They override config and session settings.
+var samplingFlags provider.Options
+
+// providerFlag is the provider given with --provider, used instead of
+// provider.default.
+var providerFlag string
+
+// launch is the binary and arguments stefanclaw was started with, for
+// /restart.
+var launch struct {
+	exe  string
+	args []string
+}
+
 func main() {
-	// Parse --ollama-url and --pipe flags from args
-	var ollamaURL string
-	var pipeMode bool
+	launch.exe, _ = os.Executable()
+	launch.args = os.Args
+
+	// Parse --ollama-url, --provider, --profile, --pipe, --dry-run,
+	// --template, --var, --image, --json, --json-schema, --no-cache,
+	// --log-level, --debug and sampling flags from args
+	var ollamaURL, templateName, profileName, jsonSchema string
+	logLevel := "info"
+	var pipeMode, dryRun, debug, jsonMode, noCache bool
+	var templateVars, images []string
 	filteredArgs := []string{os.Args[0]}
 	for i := 1; i < len(os.Args); i++ {
 		if os.Args[i] == "--ollama-url" && i+1 < len(os.Args) {
 			ollamaURL = os.Args[i+1]
 			i++ // skip the value
+		} else if os.Args[i] == "--provider" && i+1 < len(os.Args) {
+			providerFlag = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--profile" && i+1 < len(os.Args) {
+			profileName = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--log-level" && i+1 < len(os.Args) {
+			logLevel = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--template" && i+1 < len(os.Args) {
+			templateName = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--var" && i+1 < len(os.Args) {
+			templateVars = append(templateVars, os.Args[i+1])
+			i++
+		} else if os.Args[i] == "--image" && i+1 < len(os.Args) {
+			images = append(images, os.Args[i+1])
+			i++
+		} else if os.Args[i] == "--json-schema" && i+1 < len(os.Args) {
+			jsonSchema = os.Args[i+1]
+			i++
+		} else if key, ok := samplingFlag(os.Args[i]); ok && i+1 < len(os.Args) {
+			if err := samplingFlags.Set(key, os.Args[i+1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			i++
 		} else if os.Args[i] == "--pipe" {
 			pipeMode = true
+		} else if os.Args[i] == "--dry-run" {
+			dryRun = true
+		} else if os.Args[i] == "--debug" {
+			debug = true
+		} else if os.Args[i] == "--json" {
+			jsonMode = true
+		} else if os.Args[i] == "--no-cache" {
+			noCache = true
 		} else {
 			filteredArgs = append(filteredArgs, os.Args[i])
 		}
 	}
 	os.Args = filteredArgs
 
+	if profileName != "" {
+		if err := config.SetProfile(profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Move sessions and memory from the config dir into the data dir
+	if moved, err := config.MigrateData(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: migrating data: %v\n", err)
+	} else {
+		for _, m := range moved {
+			fmt.Fprintf(os.Stderr, "Migrated %s\n", m)
+		}
+	}
+
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := log.Open(config.LogFile(), level); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	defer log.Close()
+	log.SetDebug(debug)
+	log.Info("starting", "version", version, "profile", config.Profile())
+
 	// Fall back to OLLAMA_HOST env var
 	if ollamaURL == "" {
 		ollamaURL = os.Getenv("OLLAMA_HOST")
 	}
 
+	if templateName == "list" {
+		if err := listTemplates(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// A template replaces the question and always runs non-interactively
+	if templateName != "" {
+		question, err := renderTemplate(templateName, templateVars, strings.Join(os.Args[1:], " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Args = []string{os.Args[0], question}
+		pipeMode = true
+	}
+
+	if dryRun {
+		question := strings.Join(os.Args[1:], " ")
+		if err := runDryRun(os.Stdout, ollamaURL, question, pipeMode); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if !pipeMode && len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "--version", "-v":
@@ -58,15 +188,87 @@ func main() {
 			runUninstall()
 			return
 		case "--update":
+			if len(os.Args) > 2 && os.Args[2] == "--from" {
+				if len(os.Args) < 4 {
+					fmt.Fprintln(os.Stderr, "usage: stefanclaw --update --from <archive>")
+					os.Exit(1)
+				}
+				runUpdateFrom(os.Args[3])
+				return
+			}
 			runUpdate()
 			return
+		case "config":
+			if err := runConfigCmd(os.Stdout, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "discord":
+			if err := runDiscordCmd(os.Stdout, ollamaURL); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "slack":
+			if err := runSlackCmd(os.Stdout, ollamaURL); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "jobs":
+			if err := runJobsCmd(os.Stdout, ollamaURL, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "serve":
+			if err := runServeCmd(os.Stdout, ollamaURL, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "daemon":
+			if err := runDaemonCmd(os.Stdout, ollamaURL, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "kb":
+			if err := runKBCmd(os.Stdout, ollamaURL, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "bench":
+			if err := runBenchCmd(os.Stdout, ollamaURL, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "usage":
+			if err := runUsageCmd(os.Stdout, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "secret":
+			if err := runSecretCmd(os.Stdout, os.Stdin, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
 	}
 
 	if pipeMode {
 		// Collect remaining args as the question
 		question := strings.Join(os.Args[1:], " ")
-		if err := runPipe(ollamaURL, question); err != nil {
+		format, err := pipeFormat(jsonMode, jsonSchema)
+		if err == nil {
+			err = runPipe(ollamaURL, question, images, format, noCache)
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -81,10 +283,12 @@ func main() {
 
 func run(ollamaURL string) error {
 	// First run: onboarding
-	if config.IsFirstRun() {
+	if needsOnboarding() {
 		runner := onboard.NewRunner()
 		if ollamaURL != "" {
 			runner.BaseURL = ollamaURL
+			runner.Candidates = nil
+			runner.LMStudioURL = ""
 		}
 		result, err := runner.Run()
 		if err != nil {
@@ -94,9 +298,9 @@ func run(ollamaURL string) error {
 	}
 
 	// Load config
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
 	}
 
 	// CLI flag / env var override config file
@@ -104,23 +308,55 @@ func run(ollamaURL string) error {
 		cfg.Provider.Ollama.BaseURL = ollamaURL
 	}
 
-	// Create Ollama provider
-	ollamaProvider := ollama.New(cfg.Provider.Ollama.BaseURL)
+	// Create the provider
+	chatProvider, err := newProvider(cfg)
+	if err != nil {
+		return err
+	}
 
 	// Check availability
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := ollamaProvider.IsAvailable(ctx); err != nil {
-		fmt.Println("\nOllama is not running.")
-		fmt.Println("Start it with: ollama serve")
+	if err := chatProvider.IsAvailable(ctx); err != nil {
+		switch chatProvider.Name() {
+		case "openrouter", "azure", "mock":
+			return err
+		case "lmstudio":
+			fmt.Println("\nLM Studio's server is not running.")
+			fmt.Println("Start it in LM Studio's Developer tab or with: lms server start")
+		case "vllm":
+			fmt.Println("\nvLLM's server is not running.")
+			fmt.Println("Start it with: vllm serve <model>")
+		default:
+			fmt.Println("\nOllama is not running.")
+			fmt.Println("Start it with: ollama serve")
+		}
 		fmt.Println("Then re-run stefanclaw.")
 		return err
 	}
 
+	// Heartbeats, session titles and the like wait in line behind the
+	// user's messages rather than competing with them for the provider
+	var requests *queue.Queue
+	switchTo := func(name string) (provider.Provider, error) { return switchProvider(cfg, name) }
+	if cfg.Provider.Parallel > 0 {
+		requests = queue.New(cfg.Provider.Parallel)
+		chatProvider = requests.Wrap(chatProvider)
+		switchTo = func(name string) (provider.Provider, error) {
+			p, err := switchProvider(cfg, name)
+			if err != nil {
+				return nil, err
+			}
+			return requests.Wrap(p), nil
+		}
+	}
+
 	// Build system prompt
 	personalityDir := config.PersonalityDir()
 	asm := prompt.NewAssembler(personalityDir)
+	asm.SetSectionPath(prompt.SectionMemory, config.MemoryFile())
 	asm.LoadFiles()
+	asm.SetBudget(cfg.SystemPromptBudget())
 	systemPrompt := asm.BuildSystemPromptWithLanguage(cfg.Language)
 
 	// Initialize session store
@@ -132,105 +368,303 @@ func run(ollamaURL string) error {
 		return fmt.Errorf("loading current session: %w", err)
 	}
 	if sess == nil {
-		sess, err = sessStore.Create("New Chat", cfg.Model.Default)
+		sess, err = sessStore.Create(session.DefaultTitle, cfg.Model.Default)
 		if err != nil {
 			return fmt.Errorf("creating session: %w", err)
 		}
 		sessStore.SetCurrent(sess.ID)
 	}
 
-	// Load conversation history from transcript
-	history, _ := sessStore.LoadTranscript(sess.ID)
+	// Load conversation history from transcript, resuming after the last
+	// compaction
+	transcript, _ := sessStore.LoadTranscript(sess.ID)
+	history := sess.Resume(transcript)
 
 	// Initialize memory store
-	memStore := memory.NewStore(config.PersonalityDir() + "/MEMORY.md")
+	memStore := memory.NewStore(config.MemoryFile())
+
+	// Relative directories of the file tools are resolved against the
+	// directory stefanclaw was started in
+	workDir, _ := os.Getwd()
+
+	plugins, pluginErrs := plugin.Discover(config.PluginsDir())
+	for _, err := range pluginErrs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
 	// Start TUI
 	tuiModel := tui.New(tui.Options{
-		Provider:       ollamaProvider,
-		SessionStore:   sessStore,
-		MemoryStore:    memStore,
-		PromptAsm:      asm,
-		SystemPrompt:   systemPrompt,
-		Model:          cfg.Model.Default,
-		Session:        sess,
-		PersonalityDir: personalityDir,
-		Language:       cfg.Language,
-		Heartbeat:      cfg.Heartbeat,
-		MaxNumCtx:      cfg.Provider.Ollama.MaxNumCtx,
-		Version:        version,
-		History:        history,
+		Provider:          chatProvider,
+		OpenProvider:      switchTo,
+		Queue:             requests,
+		SessionStore:      sessStore,
+		MemoryStore:       memStore,
+		PromptAsm:         asm,
+		SystemPrompt:      systemPrompt,
+		Model:             cfg.Model.Default,
+		TaskModels:        cfg.Model.Tasks,
+		Session:           sess,
+		PersonalityDir:    personalityDir,
+		Language:          cfg.Language,
+		Heartbeat:         cfg.Heartbeat,
+		Memory:            cfg.Memory,
+		MaxNumCtx:         cfg.Provider.Ollama.MaxNumCtx,
+		Warm:              cfg.Provider.Ollama.Warm,
+		Version:           version,
+		History:           history,
+		Autosave:          cfg.Settings.Autosave,
+		TUI:               cfg.TUI,
+		Profile:           config.Profile(),
+		Keybindings:       cfg.Keybindings,
+		WatchConfig:       true,
+		Sampling:          cfg.Sampling,
+		SamplingFlags:     samplingFlags,
+		Privacy:           cfg.Privacy,
+		Update:            cfg.Update,
+		Agent:             cfg.Agent,
+		WorkDir:           workDir,
+		Plugins:           plugins,
+		ReminderStore:     reminder.NewStore(config.RemindersFile()),
+		TodoStore:         todo.NewStore(config.TasksFile()),
+		HeartbeatStore:    heartbeat.NewStore(config.HeartbeatFile()),
+		ContextSizes:      session.NewContextSizes(config.ContextSizesFile()),
+		Usage:             usage.NewLog(config.UsageFile()),
+		HeartbeatStats:    heartbeat.NewStatsStore(config.HeartbeatStatsFile()),
+		HeartbeatFeedback: heartbeat.NewFeedbackStore(config.HeartbeatFeedbackFile()),
+		Inbox:             daemon.NewInbox(config.InboxFile()),
+		ReleaseNotes:      update.NewNotes(config.ReleaseNotesFile()),
+		Reminders:         cfg.Reminders,
+		Jobs:              cfg.Jobs,
+		Notify:            cfg.Notify,
+		Calendar:          cfg.Calendar,
+		Home:              homeassistant.New(cfg.Home, secrets.New(config.SecretsFile()).Resolve),
+		Voice:             cfg.Voice,
+		Speech:            cfg.Speech,
+		Knowledge:         cfg.Knowledge,
+		KnowledgeIndex:    config.KnowledgeIndexFile(),
+		Notifier:          notify.New(cfg.Notify, secrets.New(config.SecretsFile()).Resolve).WithPrivacy(cfg.Privacy),
+		Mailer:            email.New(cfg.Email, secrets.New(config.SecretsFile()).Resolve).WithPrivacy(cfg.Privacy),
 	})
 
-	p := tea.NewProgram(tuiModel, tea.WithAltScreen())
-	_, err = p.Run()
-	return err
+	p := tea.NewProgram(tuiModel, tea.WithAltScreen(), tea.WithReportFocus())
+	final, err := p.Run()
+	if crash := tuiModel.Crashed(); crash != "" {
+		tuiModel.Close()
+		fmt.Fprint(os.Stderr, crash)
+		os.Exit(1)
+	}
+	// Quitting with /quit, ctrl+c or SIGTERM ends here; finish the session
+	// with the state the TUI had at the end
+	done := tuiModel
+	switch m := final.(type) {
+	case tui.Model:
+		done = m
+	case *tui.Model:
+		done = *m
+	}
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	shutdownCtx, cancelShutdown := context.WithTimeout(shutdownCtx, 30*time.Second)
+	if err := done.Shutdown(shutdownCtx, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	cancelShutdown()
+	stop()
+	if err != nil {
+		return err
+	}
+	if r, ok := final.(interface{ RestartRequested() bool }); ok && r.RestartRequested() {
+		if launch.exe == "" {
+			return fmt.Errorf("restarting: can't find the stefanclaw binary; start it again yourself")
+		}
+		return restart(launch.exe, launch.args)
+	}
+	return nil
 }
 
-func runPipe(ollamaURL, question string) error {
+func runPipe(ollamaURL, question string, images []string, format *provider.Format, noCache bool) error {
 	// Pipe mode requires config to exist already (no onboarding)
-	if config.IsFirstRun() {
+	if needsOnboarding() {
 		return fmt.Errorf("no config found — run stefanclaw interactively first to complete onboarding")
 	}
 
-	// Read question from stdin if not provided as args
-	question = strings.TrimSpace(question)
-	if question == "" {
-		data, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			return fmt.Errorf("reading stdin: %w", err)
-		}
-		question = strings.TrimSpace(string(data))
+	question, err := readQuestion(question)
+	if err != nil {
+		return err
 	}
-	if question == "" {
-		return fmt.Errorf("no question provided — pass it as arguments or pipe to stdin")
+	encoded := make([]string, len(images))
+	for i, path := range images {
+		if encoded[i], err = ocr.EncodeImage(path); err != nil {
+			return err
+		}
 	}
 
 	// Load config
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return fmt.Errorf("loading %s: %w", config.ConfigFile(), err)
 	}
 	if ollamaURL != "" {
 		cfg.Provider.Ollama.BaseURL = ollamaURL
 	}
 
-	// Create Ollama provider and check availability
-	ollamaProvider := ollama.New(cfg.Provider.Ollama.BaseURL)
+	chatProvider, err := newProvider(cfg)
+	if err != nil {
+		return err
+	}
 	ctx := context.Background()
+	pipeline := newPipeline(cfg)
+	msgs := pipeline.Messages(ctx, nil, question)
+	if len(encoded) > 0 {
+		msgs[len(msgs)-1].Images = encoded
+	}
+	if format != nil {
+		msgs[len(msgs)-1].Content += formatInstruction(format)
+	}
+	req := provider.ChatRequest{
+		Model:    cfg.Model.Default,
+		Messages: msgs,
+		Options:  cfg.Sampling.For(cfg.Model.Default).Merge(samplingFlags),
+		Format:   format,
+	}
+
+	// Answer from the cache if the same request was sent recently;
+	// --no-cache asks the model and refreshes the cached reply
+	var cache *replycache.Cache
+	var key string
+	if ttl, _ := time.ParseDuration(cfg.Pipe.CacheTTL); ttl > 0 {
+		cache = replycache.New(config.ReplyCacheDir(), ttl)
+		key = replycache.Key(cfg.Provider.Default, req)
+		if answer, ok := cache.Get(key); ok && !noCache {
+			fmt.Println(answer)
+			return nil
+		}
+	}
+
 	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	if err := ollamaProvider.IsAvailable(checkCtx); err != nil {
-		return fmt.Errorf("ollama is not running (start with: ollama serve): %w", err)
+	if err := checkProvider(checkCtx, chatProvider); err != nil {
+		return err
 	}
 
-	// Build system prompt
-	personalityDir := config.PersonalityDir()
-	asm := prompt.NewAssembler(personalityDir)
-	asm.LoadFiles()
-	systemPrompt := asm.BuildSystemPromptWithLanguage(cfg.Language)
+	// Call the model (non-streaming, blocking)
+	resp, err := chatProvider.Chat(ctx, req)
+	if err != nil {
+		return fmt.Errorf("chat: %w", err)
+	}
+	if err := pipeline.RecordUsage("", req.Model, resp.Usage); err != nil {
+		log.Warn("recording usage", "err", err)
+	}
+
+	answer := think.Strip(resp.Message.Content)
+	fmt.Println(answer)
+	if format != nil && !json.Valid([]byte(answer)) {
+		return fmt.Errorf("the reply isn't valid JSON")
+	}
+	if cache != nil {
+		if err := cache.Put(key, req.Model, answer); err != nil {
+			log.Warn("caching reply", "err", err)
+		}
+	}
+	return nil
+}
+
+// pipeFormat returns the reply format asked for with --json or
+// --json-schema, whose value is a schema file or the schema itself. It
+// returns nil for free text.
+func pipeFormat(jsonMode bool, schema string) (*provider.Format, error) {
+	if schema == "" {
+		if jsonMode {
+			return &provider.Format{}, nil
+		}
+		return nil, nil
+	}
+	data := []byte(schema)
+	if !strings.HasPrefix(strings.TrimSpace(schema), "{") {
+		var err error
+		if data, err = os.ReadFile(schema); err != nil {
+			return nil, fmt.Errorf("reading JSON schema: %w", err)
+		}
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("JSON schema %s isn't valid JSON", schema)
+	}
+	return &provider.Format{Schema: data}, nil
+}
 
-	// Auto-fetch URLs in the question
-	fetchClient := fetch.New()
-	augmented := fetch.AugmentWithWebContent(ctx, fetchClient, question)
+// formatInstruction tells the model in the prompt to answer in format, which
+// helps it fill in the JSON sensibly.
+func formatInstruction(format *provider.Format) string {
+	if len(format.Schema) == 0 {
+		return "\n\nRespond with JSON only."
+	}
+	return "\n\nRespond with JSON only, matching this JSON schema:\n" + string(format.Schema)
+}
 
-	// Build messages
-	var msgs []provider.Message
-	if systemPrompt != "" {
-		msgs = append(msgs, provider.Message{Role: "system", Content: systemPrompt})
+// samplingFlag reports whether arg is a sampling option flag such as
+// --temperature or --top-p, returning the option name.
+func samplingFlag(arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "--") {
+		return "", false
+	}
+	key := strings.ReplaceAll(arg[2:], "-", "_")
+	for _, k := range provider.OptionKeys {
+		if k == key {
+			return key, true
+		}
 	}
-	msgs = append(msgs, provider.Message{Role: "user", Content: augmented})
+	return "", false
+}
 
-	// Call the model (non-streaming, blocking)
-	resp, err := ollamaProvider.Chat(ctx, provider.ChatRequest{
-		Model:    cfg.Model.Default,
-		Messages: msgs,
+// readQuestion returns the trimmed question, reading it from stdin when it
+// was not passed as arguments.
+func readQuestion(question string) (string, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading stdin: %w", err)
+		}
+		question = strings.TrimSpace(string(data))
+	}
+	if question == "" {
+		return "", fmt.Errorf("no question provided — pass it as arguments or pipe to stdin")
+	}
+	return question, nil
+}
+
+// renderTemplate renders a prompt template from the templates directory.
+// Variables come from key=value assignments; positional arguments are
+// available as {{.input}}.
+func renderTemplate(name string, assignments []string, input string) (string, error) {
+	vars := map[string]string{"input": input}
+	for _, a := range assignments {
+		k, v, err := prompt.ParseVar(a)
+		if err != nil {
+			return "", err
+		}
+		vars[k] = v
+	}
+	return prompt.RenderTemplate(config.TemplatesDir(), name, vars, func() (string, error) {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
 	})
+}
+
+// listTemplates prints the names of the prompt templates, for --template
+// list.
+func listTemplates(w io.Writer) error {
+	dir := config.TemplatesDir()
+	names, err := prompt.ListTemplates(dir)
 	if err != nil {
-		return fmt.Errorf("chat: %w", err)
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(w, "No templates in %s.\n", dir)
+		return nil
+	}
+	for _, name := range names {
+		fmt.Fprintln(w, name)
 	}
-
-	fmt.Println(resp.Message.Content)
 	return nil
 }
 
@@ -239,26 +673,103 @@ func runUpdate() {
 		fmt.Println("Auto-update is not available for development builds.")
 		return
 	}
-	fmt.Println("Checking for updates...")
-	res, err := update.Apply(context.Background(), version)
+	if pm, ok := update.Managed(); ok {
+		fmt.Println(pm.Advice())
+		return
+	}
+	channel := update.ChannelStable
+	if cfg, err := loadConfig(); err == nil {
+		if cfg.Privacy.DisableWeb {
+			fmt.Println("Updates are disabled with web access (privacy.disable_web in config.yaml).")
+			return
+		}
+		channel = cfg.Update.Channel
+	}
+	if channel == update.ChannelStable {
+		fmt.Println("Checking for updates...")
+	} else {
+		fmt.Printf("Checking for updates (%s channel)...\n", channel)
+	}
+	downloading := false
+	res, err := update.Apply(context.Background(), version, channel, func(p update.Progress) {
+		if p.Stage == update.StageDownload {
+			fmt.Printf("\r%s", p)
+			downloading = true
+			return
+		}
+		if downloading {
+			fmt.Println()
+			downloading = false
+		}
+		fmt.Println(p)
+	})
+	if downloading {
+		fmt.Println()
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Update failed: %v\n", err)
 		os.Exit(1)
 	}
 	if res.Applied {
 		fmt.Printf("Updated to v%s. Restart stefanclaw to use the new version.\n", res.LatestVersion)
+		printReleaseNotes(res.LatestVersion, res.ReleaseNotes)
+		// Shown again in the TUI at the first launch of the new version
+		if err := update.NewNotes(config.ReleaseNotesFile()).Save(res.LatestVersion, res.ReleaseNotes); err != nil {
+			fmt.Fprintf(os.Stderr, "Saving the release notes: %v\n", err)
+		}
 	} else {
 		fmt.Println("Already running the latest version.")
 	}
 }
 
+// runUpdateFrom installs a release archive downloaded by hand, for machines
+// that can't reach GitHub.
+func runUpdateFrom(archive string) {
+	if pm, ok := update.Managed(); ok {
+		fmt.Println(pm.Advice())
+		return
+	}
+	fmt.Printf("Verifying %s...\n", archive)
+	if err := update.ApplyFile(archive); err != nil {
+		fmt.Fprintf(os.Stderr, "Update failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Updated. Restart stefanclaw to use the new version.")
+}
+
+// printReleaseNotes renders the markdown release notes of version to the
+// terminal.
+func printReleaseNotes(version, notes string) {
+	if strings.TrimSpace(notes) == "" {
+		return
+	}
+	fmt.Printf("\nWhat's new in v%s:\n", version)
+	r, err := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(80))
+	if err == nil {
+		if out, err := r.Render(notes); err == nil {
+			fmt.Print(out)
+			return
+		}
+	}
+	fmt.Println(notes)
+}
+
 func runUninstall() {
-	configDir := config.Dir()
+	configDir := config.BaseDir()
+	dirs := []string{configDir}
+	for _, d := range []string{config.DataBaseDir(), config.CacheDir()} {
+		if d != configDir && !strings.HasPrefix(d, configDir+string(os.PathSeparator)) {
+			dirs = append(dirs, d)
+		}
+	}
 	fmt.Println("Stefanclaw Uninstall")
 	fmt.Println("====================")
 	fmt.Println("")
 	fmt.Println("This will remove all stefanclaw data:")
-	fmt.Printf("  Config & data: %s\n", configDir)
+	fmt.Printf("  Config: %s\n", configDir)
+	for _, d := range dirs[1:] {
+		fmt.Printf("  Data:   %s\n", d)
+	}
 	fmt.Println("")
 	fmt.Print("Are you sure? (y/N) ")
 
@@ -269,11 +780,15 @@ func runUninstall() {
 		return
 	}
 
-	if err := os.RemoveAll(configDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", configDir, err)
-		os.Exit(1)
+	for _, d := range dirs {
+		if err := os.RemoveAll(d); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", d, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %s\n", d)
 	}
-	fmt.Printf("Removed %s\n", configDir)
+	fmt.Println("\nAPI keys stored in the OS keyring are kept; remove them with")
+	fmt.Println("`stefanclaw secret delete <name>` before deleting the binary.")
 
 	// Find and report binary location
 	exe, err := os.Executable()
@@ -289,10 +804,33 @@ func printHelp() {
 Usage:
   stefanclaw                          Start the TUI chat interface
   stefanclaw --pipe "question"        Non-interactive mode (prints response to stdout)
+  stefanclaw --pipe --image <file> "question"  Send an image with the question (repeatable)
+  stefanclaw --pipe --json "question"         Have the reply be JSON (--json-schema <file|JSON> for a schema)
+  stefanclaw --pipe --no-cache "question"     Ask the model even if the reply is cached (pipe.cache_ttl)
+  stefanclaw --dry-run "question"     Print the assembled request without calling the model
+  stefanclaw --template <name> [--var key=value ...]  Run a prompt template non-interactively
+  stefanclaw --template list          List the prompt templates
   stefanclaw --ollama-url <url>       Use a custom Ollama endpoint
+  stefanclaw --profile <name>         Use a separate profile (own config, personality, sessions, memory)
+  stefanclaw --temperature <n>        Override sampling (also --top-p, --repeat-penalty, --num-predict, --seed)
+  stefanclaw config init [--force]    Write a commented default config.yaml (no onboarding)
+  stefanclaw config path [<name>]     Print resolved config, data and cache locations
+  stefanclaw secret set|delete <name> Store an API key in the OS keyring (value read from stdin)
+  stefanclaw jobs [list]              List automation jobs and their next runs
+  stefanclaw jobs run <name>          Run a job now
+  stefanclaw jobs daemon              Run jobs on their schedules without the TUI
+  stefanclaw daemon                   Run heartbeats, jobs, reminders and calendar alerts without the TUI
+  stefanclaw kb [status]              Show what the knowledge base has indexed
+  stefanclaw kb index|rebuild [--path <dir>]  Index new and changed notes, or all of them
+  stefanclaw bench [-m <model>]...    Compare load time, time to first token and tokens/sec of models
+  stefanclaw usage [--days <n>]       Show the tokens used per day, model and session
+  stefanclaw discord                  Answer Discord DMs and channels as a bot
+  stefanclaw slack                    Answer Slack DMs and channels as an app (Socket Mode)
+  stefanclaw serve --bridge           Answer browser extensions and editor plugins on localhost
   stefanclaw --version                Print version and exit
   stefanclaw --help                   Show this help
   stefanclaw --update                 Update to the latest version
+  stefanclaw --update --from <file>   Update from a downloaded release archive (no GitHub access)
   stefanclaw --uninstall              Remove all stefanclaw data from your system
 
 Slash commands (in TUI):
@@ -306,26 +844,66 @@ Slash commands (in TUI):
   /memory              Show memory entries
   /remember <fact>     Save a fact to memory
   /forget <keyword>    Remove matching memory entries
-  /language [<name>]   Show or change response language
+  /language [<name>|list]  Show, change or list response languages
   /heartbeat [on|off|<interval>]  Manage heartbeat check-ins
+  /sampling [<option> <value>|reset]  Show or override sampling for this session
+  /save                Save model, language and heartbeat settings to config
   /personality edit    Open personality files for editing
+  /prompt stats        Show the tokens each part of the system prompt takes
   /update              Check for updates and upgrade
+  /debug [on|off|pane]  Log (and show) the raw requests to Ollama
 
 Configuration:
   Config is stored in %s
   Override with STEFANCLAW_CONFIG_DIR environment variable.
+  Sessions and memory are stored in %s (STEFANCLAW_DATA_DIR),
+  caches in %s (STEFANCLAW_CACHE_DIR).
+  Named profiles live in <config dir>/profiles/<name>; select one with
+  --profile <name> or STEFANCLAW_PROFILE. A new profile runs onboarding first.
+
+Sampling (priority: flag > session > sampling.models.<model> > sampling):
+  Set temperature, top_p, repeat_penalty, num_predict and seed under
+  sampling: in config.yaml, per model under sampling.models, per session
+  with /sampling, or per run with flags such as --temperature 0.2.
+
+Logging:
+  --log-level <level>  debug, info (default), warn or error
+  --debug              Also log the exact requests to and responses from Ollama
+                       (secrets redacted); /debug on|off|pane in the TUI
+  The log is written to %s and rotated at 5 MB.
+
+Provider:
+  --provider <name>    Use this provider instead of provider.default, e.g.
+                       --provider mock for canned replies (demos, tests)
 
 Ollama endpoint (priority: flag > env > config > default):
   --ollama-url <url>   Override the Ollama base URL
   OLLAMA_HOST          Environment variable (matches Ollama's own convention)
 
 Requires:
-  Ollama running locally or at the specified endpoint (https://ollama.ai)
+  Ollama running locally or at the specified endpoint (https://ollama.ai),
+  LM Studio's local server with provider.default: lmstudio in config.yaml
+  (https://lmstudio.ai), vLLM's server with provider.default: vllm
+  (https://docs.vllm.ai), an OpenRouter API key with provider.default:
+  openrouter (https://openrouter.ai), or an Azure OpenAI resource with
+  provider.default: azure
 
 Pipe mode (non-interactive, for scripting):
   stefanclaw --pipe "What is 2+2?"                          Question as argument
   echo "What is 2+2?" | stefanclaw --pipe                   Question from stdin
   stefanclaw --pipe "Summarize https://example.com" | pbcopy  Pipe into other tools
+  stefanclaw --pipe --image shot.png "What does this error mean?"  Ask a vision model about an image
+  stefanclaw --pipe --json-schema person.json "Who wrote Dune?" | jq .name  Parse the reply in scripts
+
+Prompt templates (Go templates in %s):
+  {{.key}}             Value of --var key=value
+  {{.input}}           Remaining command-line arguments
+  {{stdin}}            Everything piped to stdin
+  git diff --staged | stefanclaw --template commit-message   Reusable scripted prompt
+
+Dry run (debugging prompt assembly):
+  stefanclaw --dry-run "question"                           Request the TUI would send (with session history)
+  stefanclaw --pipe --dry-run "question"                    Request pipe mode would send (no history)
 
 Examples:
   stefanclaw                                                Start chatting
@@ -333,5 +911,6 @@ Examples:
   stefanclaw --ollama-url http://192.168.1.100:11434        Use remote Ollama
   OLLAMA_HOST=http://192.168.1.100:11434 stefanclaw         Same via env var
   STEFANCLAW_CONFIG_DIR=/tmp/test stefanclaw                Use custom config dir
-`, version, config.Dir())
+  stefanclaw --profile work                                 Chat with the "work" persona
+`, version, config.Dir(), config.DataDir(), config.CacheDir(), config.LogFile(), config.TemplatesDir())
 }
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// restart replaces the process with a fresh start of exe, which /update may
+// have replaced with a new version.
+func restart(exe string, args []string) error {
+	if err := syscall.Exec(exe, args, os.Environ()); err != nil {
+		return fmt.Errorf("restarting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// restart runs a fresh start of exe, which /update may have replaced with a
+// new version, and exits with its status. Windows can't replace a running
+// process in place.
+func restart(exe string, args []string) error {
+	cmd := exec.Command(exe, args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		os.Exit(exit.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("restarting: %w", err)
+	}
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/chat"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/plugin"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/azure"
+	"github.com/stefanclaw/stefanclaw/internal/provider/fallback"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/lmstudio"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/mock"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/ollama"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/openrouter"
+	_ "github.com/stefanclaw/stefanclaw/internal/provider/vllm"
+	"github.com/stefanclaw/stefanclaw/internal/proxy"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+	"github.com/stefanclaw/stefanclaw/internal/units"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+// loadConfig loads config.yaml, with the provider chosen by --provider if
+// given, and sends outbound requests through the proxy it sets, if any.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, err
+	}
+	if providerFlag != "" {
+		cfg.Provider.Default = providerFlag
+	}
+	return cfg, proxy.Set(cfg.Proxy.URL, cfg.Proxy.NoProxy)
+}
+
+// needsOnboarding reports whether stefanclaw has yet to be set up. The mock
+// provider needs no setup, so demos and tests with it go without.
+func needsOnboarding() bool {
+	return config.IsFirstRun() && providerFlag != "mock"
+}
+
+// newProvider returns the provider chosen by provider.default, followed by
+// those in provider.fallback if any, retrying transient failures as set in
+// provider.retry.
+func newProvider(cfg config.Config) (provider.Provider, error) {
+	prov, err := openProvider(cfg, cfg.Provider.Default)
+	if err != nil {
+		return nil, err
+	}
+	policy := cfg.Provider.Retry.Policy()
+	if len(cfg.Provider.Fallback) == 0 && policy.Attempts < 2 {
+		return prov, nil
+	}
+	var backups []fallback.Backend
+	for _, f := range cfg.Provider.Fallback {
+		p, err := openProvider(cfg, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, fallback.Backend{Provider: p, Model: f.Model})
+	}
+	return fallback.NewRetrying(policy, prov, backups...), nil
+}
+
+// switchProvider opens the named provider for /provider, retrying
+// transient failures as set in provider.retry.
+func switchProvider(cfg config.Config, name string) (provider.Provider, error) {
+	prov, err := openProvider(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	if policy := cfg.Provider.Retry.Policy(); policy.Attempts > 1 {
+		return fallback.NewRetrying(policy, prov), nil
+	}
+	return prov, nil
+}
+
+// openProvider opens the named provider with its settings from cfg.
+func openProvider(cfg config.Config, name string) (provider.Provider, error) {
+	s := cfg.Provider.Settings(name)
+	s.Resolve = secrets.New(config.SecretsFile()).Resolve
+	return provider.Open(name, s)
+}
+
+// checkProvider returns an error saying how to start prov if it isn't
+// reachable.
+func checkProvider(ctx context.Context, prov provider.Provider) error {
+	err := prov.IsAvailable(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case prov.Name() == "lmstudio":
+		return fmt.Errorf("lm studio is not running (start its server in the Developer tab or with: lms server start): %w", err)
+	case prov.Name() == "vllm":
+		return fmt.Errorf("vllm is not running (start with: vllm serve <model>): %w", err)
+	case prov.Name() == "openrouter", prov.Name() == "azure", prov.Name() == "mock":
+		return err
+	default:
+		return fmt.Errorf("ollama is not running (start with: ollama serve): %w", err)
+	}
+}
+
+// newPipeline builds the request pipeline for pipe mode and the chat
+// bridges, set up like the TUI's: the personality files with MEMORY.md from
+// the data directory and, unless web access is disabled, fetching the pages
+// a message links to.
+func newPipeline(cfg config.Config) *chat.Pipeline {
+	asm := prompt.NewAssembler(config.PersonalityDir())
+	asm.SetSectionPath(prompt.SectionMemory, config.MemoryFile())
+	asm.LoadFiles()
+	asm.SetBudget(cfg.SystemPromptBudget())
+	p := &chat.Pipeline{Prompt: asm, Language: cfg.Language, Usage: usage.NewLog(config.UsageFile())}
+	if !cfg.Privacy.DisableWeb {
+		p.Fetch = fetch.New()
+	}
+	return p
+}
+
+// newAgentRunner builds a runner for jobs and chat bridges with the TUI's
+// tools, of which the runner only offers those that need no approval. Chat
+// bridges limit them further, see channel.Bridge.
+func newAgentRunner(cfg config.Config) (*agent.Runner, *memory.Store, error) {
+	prov, err := newProvider(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	mem := memory.NewStore(config.MemoryFile())
+
+	var registry *tools.Registry
+	if cfg.Agent.Enabled {
+		resolve := secrets.New(config.SecretsFile()).Resolve
+		workDir, _ := os.Getwd()
+		s := tools.Setup{
+			Agent:         cfg.Agent,
+			Privacy:       cfg.Privacy,
+			Notify:        cfg.Notify.Tool,
+			WorkDir:       workDir,
+			Provider:      prov,
+			Memory:        mem,
+			Todos:         todo.NewStore(config.TasksFile()),
+			Calendar:      calendar.FromConfig(cfg.Calendar, cfg.Privacy),
+			Home:          homeassistant.New(cfg.Home, resolve),
+			Notifier:      notify.New(cfg.Notify, resolve).WithPrivacy(cfg.Privacy),
+			NotifyLimiter: &notify.Limiter{},
+		}
+		if !cfg.Privacy.DisableWeb {
+			s.Fetch = fetch.New()
+			s.Rates = units.NewRates(config.RatesFile())
+		}
+		plugins, errs := plugin.Discover(config.PluginsDir())
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, p := range plugins {
+			s.Plugins = append(s.Plugins, p.Tool())
+		}
+		registry = tools.Build(s)
+	}
+
+	return &agent.Runner{
+		Provider:     prov,
+		Model:        cfg.Model.Default,
+		SystemPrompt: newPipeline(cfg).SystemPrompt(),
+		Tools:        registry,
+		NativeTools:  cfg.Agent.NativeTools,
+		MaxSteps:     cfg.Agent.MaxSteps,
+		Options:      cfg.Sampling.For(cfg.Model.Default).Merge(samplingFlags),
+	}, mem, nil
+}
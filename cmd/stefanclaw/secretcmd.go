@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/secrets"
+)
+
+// runSecretCmd implements `stefanclaw secret set|delete <name>`. The value
+// for set is read from stdin so it never appears in shell history.
+func runSecretCmd(w io.Writer, stdin io.Reader, args []string) error {
+	if len(args) != 2 || (args[0] != "set" && args[0] != "delete") {
+		return fmt.Errorf("usage: stefanclaw secret set|delete <name>")
+	}
+	store := secrets.New(config.SecretsFile())
+	name := args[1]
+
+	if args[0] == "delete" {
+		if err := store.Delete(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Deleted secret %q\n", name)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Enter value for %q and press Ctrl-D: ", name)
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("reading secret: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return fmt.Errorf("empty secret value")
+	}
+	where, err := store.Set(name, value)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nStored secret %q in %s. Reference it in config.yaml as %q.\n", name, where, secrets.RefPrefix+name)
+	return nil
+}
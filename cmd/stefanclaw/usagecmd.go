@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+const usageUsage = "usage: stefanclaw usage [--days <n>]"
+
+// runUsageCmd implements `stefanclaw usage [--days <n>]`: the tokens used
+// by the replies of the last days, seven unless given, per day, model and
+// session.
+func runUsageCmd(w io.Writer, args []string) error {
+	days := 7
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--days" && i+1 < len(args) {
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return errors.New(usageUsage)
+			}
+			days = n
+			continue
+		}
+		return errors.New(usageUsage)
+	}
+
+	t := time.Now()
+	since := time.Date(t.Year(), t.Month(), t.Day()-days+1, 0, 0, 0, 0, time.Local)
+	records, err := usage.NewLog(config.UsageFile()).Records(since)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", config.UsageFile(), err)
+	}
+	if len(records) == 0 {
+		fmt.Fprintf(w, "No replies recorded in the last %d days.\n", days)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Last %d days: %s\n\n", days, usage.Sum(records))
+	usage.Print(w, "DAY", usage.ByDay(records))
+	fmt.Fprintln(w)
+	usage.Print(w, "MODEL", usage.ByModel(records))
+	if sessions := usage.BySession(records); len(sessions) > 0 {
+		// Show sessions by title where they still exist
+		store := session.NewFileStore(config.SessionsDir())
+		for i, g := range sessions {
+			if sess, err := store.Get(g.Key); err == nil && sess.Title != "" {
+				sessions[i].Key = sess.Title
+			}
+		}
+		fmt.Fprintln(w)
+		usage.Print(w, "SESSION", sessions)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package agent runs the tool-calling loop without a user in front of the
+// screen, for automation jobs and chat bridges. Tools that need the user's
+// approval are never offered.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// Runner answers conversations with a non-interactive agent loop.
+type Runner struct {
+	Provider     provider.Provider
+	Model        string // used when Run is given no model
+	SystemPrompt string
+	Tools        *tools.Registry // may be nil
+	NativeTools  bool            // pass Tools to providers with native tool calling
+	MaxSteps     int             // tool calls per answer; 5 if unset
+	Options      provider.Options
+	NumCtx       int
+}
+
+// Run sends history (user and assistant turns, without a system prompt) to
+// model, running the tools the model calls, and returns the final answer.
+func (r *Runner) Run(ctx context.Context, model string, history []provider.Message) (string, error) {
+	answer, _, err := r.RunWithUsage(ctx, model, history)
+	return answer, err
+}
+
+// RunWithUsage is Run that also returns the usage of the request that
+// produced the answer.
+func (r *Runner) RunWithUsage(ctx context.Context, model string, history []provider.Message) (string, provider.Usage, error) {
+	registry := r.Tools
+	if registry != nil {
+		registry = registry.Filter(func(t tools.Tool) bool { return !t.Confirm })
+	}
+	system := r.SystemPrompt
+	if registry != nil && registry.Len() > 0 {
+		if system != "" {
+			system += "\n\n"
+		}
+		system += registry.SystemPrompt()
+	}
+
+	var msgs []provider.Message
+	if system != "" {
+		msgs = append(msgs, provider.Message{Role: "system", Content: system})
+	}
+	msgs = append(msgs, history...)
+
+	if model == "" {
+		model = r.Model
+	}
+	maxSteps := r.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 5
+	}
+
+	var native *tools.Registry
+	if r.NativeTools {
+		native = registry
+	}
+	for step := 0; ; step++ {
+		resp, err := native.Chat(ctx, r.Provider, provider.ChatRequest{
+			Model:    model,
+			Messages: msgs,
+			NumCtx:   r.NumCtx,
+			Options:  r.Options,
+		})
+		if err != nil {
+			return "", provider.Usage{}, err
+		}
+		content := think.Strip(resp.Message.Content)
+		if registry == nil || registry.Len() == 0 || !tools.HasCall(content) || step > maxSteps {
+			return strings.TrimSpace(content), resp.Usage, nil
+		}
+
+		call, _, _, err := tools.ParseCall(content)
+		var out string
+		if err == nil && step == maxSteps {
+			err = fmt.Errorf("tool step limit (%d) reached; give your final answer now without calling tools", maxSteps)
+		}
+		if err == nil {
+			out, err = registry.Execute(ctx, call)
+		}
+		// Tool results go back as user turns, as in the TUI
+		msgs = append(msgs,
+			provider.Message{Role: "assistant", Content: content},
+			provider.Message{Role: "user", Content: tools.FormatResult(call.Name, out, err)},
+		)
+	}
+}
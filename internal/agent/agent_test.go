@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+func echoTool(confirm bool) tools.Tool {
+	return tools.Tool{
+		Name:    "echo",
+		Params:  []tools.Param{{Name: "text", Required: true}},
+		Confirm: confirm,
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			return "echo: " + args["text"], nil
+		},
+	}
+}
+
+func TestRun_ToolLoop(t *testing.T) {
+	p := providertest.New(
+		providertest.Text(`<tool_call>{"name": "echo", "arguments": {"text": "news"}}</tool_call>`),
+		providertest.Text("  Here is your digest.  "),
+	)
+	r := &Runner{Provider: p, Model: "default-model", SystemPrompt: "You are helpful.", Tools: tools.NewRegistry(echoTool(false))}
+
+	answer, err := r.Run(context.Background(), "job-model", []provider.Message{{Role: "user", Content: "Summarize the news."}})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if answer != "Here is your digest." {
+		t.Errorf("answer = %q", answer)
+	}
+	if len(p.Requests()) != 2 {
+		t.Fatalf("got %d requests, want 2", len(p.Requests()))
+	}
+	if p.Requests()[0].Model != "job-model" {
+		t.Errorf("model = %q, want the requested model", p.Requests()[0].Model)
+	}
+	first := p.Requests()[0].Messages
+	if first[0].Role != "system" || !strings.Contains(first[0].Content, "You are helpful.") || !strings.Contains(first[0].Content, "- echo(text)") {
+		t.Errorf("system prompt = %q", first[0].Content)
+	}
+	last := p.Requests()[1].Messages[len(p.Requests()[1].Messages)-1]
+	if last.Role != "user" || !strings.Contains(last.Content, "echo: news") {
+		t.Errorf("tool result = %+v", last)
+	}
+}
+
+func TestRun_NativeTools(t *testing.T) {
+	p := &providertest.Fake{NativeTools: true, Replies: []providertest.Reply{
+		{ToolCalls: []provider.ToolCall{{Name: "echo", Arguments: map[string]any{"text": "news"}}}},
+		providertest.Text("Here is your digest."),
+	}}
+	r := &Runner{Provider: p, Model: "m", SystemPrompt: "You are helpful.", Tools: tools.NewRegistry(echoTool(false)), NativeTools: true}
+
+	answer, err := r.Run(context.Background(), "", []provider.Message{{Role: "user", Content: "Summarize the news."}})
+	if err != nil || answer != "Here is your digest." {
+		t.Fatalf("Run() = %q, %v", answer, err)
+	}
+	first := p.Requests()[0]
+	if len(first.Tools) != 1 || first.Messages[0].Content != "You are helpful." {
+		t.Errorf("first request = %+v, want the tools passed natively", first)
+	}
+	msgs := p.Requests()[1].Messages
+	if last := msgs[len(msgs)-1]; last.Role != "tool" || last.ToolName != "echo" || !strings.Contains(last.Content, "echo: news") {
+		t.Errorf("tool result = %+v", last)
+	}
+}
+
+func TestRun_SkipsConfirmTools(t *testing.T) {
+	p := providertest.New(providertest.Text("Nothing to do."))
+	r := &Runner{Provider: p, Model: "m", Tools: tools.NewRegistry(echoTool(true))}
+
+	answer, err := r.Run(context.Background(), "", []provider.Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if answer != "Nothing to do." {
+		t.Errorf("answer = %q", answer)
+	}
+	if msgs := p.Requests()[0].Messages; len(msgs) != 1 || msgs[0].Role != "user" {
+		t.Errorf("messages = %+v, want only the prompt", msgs)
+	}
+}
+
+func TestRun_StepLimit(t *testing.T) {
+	p := providertest.New(providertest.Text(`<tool_call>{"name": "echo", "arguments": {"text": "again"}}</tool_call>`))
+	r := &Runner{Provider: p, Model: "m", Tools: tools.NewRegistry(echoTool(false)), MaxSteps: 2}
+
+	if _, err := r.Run(context.Background(), "", []provider.Message{{Role: "user", Content: "Loop"}}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	// Two tool calls, one refused over the limit, then the reply is taken as-is
+	if len(p.Requests()) != 4 {
+		t.Fatalf("got %d requests, want 4", len(p.Requests()))
+	}
+	last := p.Requests()[3].Messages[len(p.Requests()[3].Messages)-1]
+	if !strings.Contains(last.Content, "step limit") {
+		t.Errorf("last tool result = %q, want the step limit error", last.Content)
+	}
+}
+
+func TestRunWithUsage(t *testing.T) {
+	p := providertest.New(
+		providertest.Reply{Content: `<tool_call>{"name": "echo", "arguments": {"text": "news"}}</tool_call>`, Usage: provider.Usage{PromptTokens: 100}},
+		providertest.Reply{Content: "Done.", Usage: provider.Usage{PromptTokens: 140, CompletionTokens: 2}},
+	)
+	r := &Runner{Provider: p, Model: "m", Tools: tools.NewRegistry(echoTool(false))}
+
+	answer, usage, err := r.RunWithUsage(context.Background(), "", []provider.Message{{Role: "user", Content: "News?"}})
+	if err != nil || answer != "Done." {
+		t.Fatalf("RunWithUsage() = %q, %v", answer, err)
+	}
+	if usage.PromptTokens != 140 || usage.CompletionTokens != 2 {
+		t.Errorf("usage = %+v, want that of the final request", usage)
+	}
+}
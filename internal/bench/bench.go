@@ -0,0 +1,225 @@
+// Package bench measures how fast local models run on this computer: how
+// long they take to load, to start answering and to generate, and how that
+// changes as the context grows.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// shortPrompt is the prompt of the first, small request.
+const shortPrompt = "Explain in three sentences why the sky is blue."
+
+// filler is repeated to make the long prompts of the context sweep.
+const filler = "The committee met on Tuesday to review the quarterly figures, " +
+	"discuss the new warehouse in the north and agree on the budget for the " +
+	"coming year. Several members asked for more detail on shipping costs. "
+
+// longQuestion follows the filler in the long prompts.
+const longQuestion = "\n\nSummarize the text above in one sentence."
+
+// minNumCtx is the first context size of the sweep; it doubles from there.
+const minNumCtx = 4096
+
+// fillRatio is the share of each context size the long prompt takes up,
+// leaving room for the reply.
+const fillRatio = 0.75
+
+// numPredict caps the replies, so every model generates about as much.
+const numPredict = 128
+
+// Run is the measurement of one context size.
+type Run struct {
+	NumCtx       int
+	PromptTokens int
+	FirstToken   time.Duration // from sending the request to the first token
+	PromptRate   float64       // prompt tokens processed per second
+	Rate         float64       // tokens generated per second
+	Err          error
+}
+
+// Result is the benchmark of one model.
+type Result struct {
+	Model      string
+	Load       time.Duration // as reported by the provider; 0 if it was loaded already
+	FirstToken time.Duration // of the short prompt
+	Rate       float64       // tokens generated per second for the short prompt
+	Runs       []Run         // one per context size, smallest first
+	Err        error         // why the model couldn't be benchmarked at all
+}
+
+// PeakNumCtx returns the largest context size that ran without error, or 0.
+func (r Result) PeakNumCtx() int {
+	peak := 0
+	for _, run := range r.Runs {
+		if run.Err == nil {
+			peak = run.NumCtx
+		}
+	}
+	return peak
+}
+
+// Sizes returns the context sizes swept up to maxNumCtx: minNumCtx doubled
+// until it would exceed maxNumCtx.
+func Sizes(maxNumCtx int) []int {
+	var sizes []int
+	for n := minNumCtx; n <= maxNumCtx; n *= 2 {
+		sizes = append(sizes, n)
+	}
+	return sizes
+}
+
+// Benchmark runs the standard prompts against each model in turn. A model
+// that fails, such as an embedding model that can't chat, is reported in
+// its Result and the others still run; the context sweep of a model stops
+// at the first size that fails. progress, if not nil, is called before
+// each request. Benchmark returns early, with the results so far, when ctx
+// is done.
+func Benchmark(ctx context.Context, prov provider.Provider, models []string, maxNumCtx int, progress func(model, step string)) ([]Result, error) {
+	if progress == nil {
+		progress = func(string, string) {}
+	}
+	var results []Result
+	for _, model := range models {
+		res := Result{Model: model}
+		progress(model, "loading")
+		first, err := measure(ctx, prov, model, minNumCtx, shortPrompt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			res.Err = err
+			results = append(results, res)
+			continue
+		}
+		res.Load, res.FirstToken, res.Rate = first.load, first.FirstToken, first.Rate
+
+		for _, n := range Sizes(maxNumCtx) {
+			progress(model, fmt.Sprintf("context %d", n))
+			run, err := measure(ctx, prov, model, n, longPrompt(n))
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			run.Err = err
+			res.Runs = append(res.Runs, run.Run)
+			if err != nil {
+				break
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// measured is a Run with the provider's load time.
+type measured struct {
+	Run
+	load time.Duration
+}
+
+// measure streams one reply to prompt and times it.
+func measure(ctx context.Context, prov provider.Provider, model string, numCtx int, prompt string) (measured, error) {
+	m := measured{Run: Run{NumCtx: numCtx, PromptTokens: tokens.Count(prompt)}}
+	var opts provider.Options
+	opts.Set("num_predict", fmt.Sprint(numPredict))
+	req := provider.ChatRequest{
+		Model:    model,
+		Messages: []provider.Message{{Role: "user", Content: prompt}},
+		NumCtx:   numCtx,
+		Options:  opts,
+	}
+
+	start := time.Now()
+	ch, err := prov.StreamChat(ctx, req)
+	if err != nil {
+		return m, err
+	}
+	var usage *provider.Usage
+	for delta := range ch {
+		if delta.Err != nil {
+			return m, delta.Err
+		}
+		if delta.Content != "" && m.FirstToken == 0 {
+			m.FirstToken = time.Since(start)
+		}
+		if delta.Done {
+			usage = delta.Usage
+		}
+	}
+	if ctx.Err() != nil {
+		return m, ctx.Err()
+	}
+	if m.FirstToken == 0 {
+		return m, errors.New("the model returned no text")
+	}
+	if usage != nil {
+		m.load = usage.LoadDuration
+		if usage.PromptTokens > 0 {
+			m.PromptTokens = usage.PromptTokens
+		}
+		m.PromptRate = rate(usage.PromptTokens, usage.PromptDuration)
+		m.Rate = rate(usage.CompletionTokens, usage.EvalDuration)
+	}
+	return m, nil
+}
+
+// rate returns n per second over d, or 0 if d is unknown.
+func rate(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// longPrompt returns a prompt that fills fillRatio of numCtx.
+func longPrompt(numCtx int) string {
+	want := int(float64(numCtx) * fillRatio)
+	repeat := want/tokens.Count(filler) + 1
+	return tokens.Truncate(strings.Repeat(filler, repeat), want-tokens.Count(longQuestion)) + longQuestion
+}
+
+// Print writes results as a table, one line per model followed by one per
+// context size.
+func Print(w io.Writer, results []Result) {
+	fmt.Fprintf(w, "%-24s %8s %8s %9s  %s\n", "MODEL", "LOAD", "TTFT", "TOK/S", "PEAK CONTEXT")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "%-24s failed: %v\n", r.Model, r.Err)
+			continue
+		}
+		peak := "none"
+		if n := r.PeakNumCtx(); n > 0 {
+			peak = fmt.Sprint(n)
+		}
+		fmt.Fprintf(w, "%-24s %8s %8s %9s  %s\n", r.Model, seconds(r.Load), seconds(r.FirstToken), perSecond(r.Rate), peak)
+		for _, run := range r.Runs {
+			if run.Err != nil {
+				fmt.Fprintf(w, "  ctx %-6d failed: %v\n", run.NumCtx, run.Err)
+				continue
+			}
+			fmt.Fprintf(w, "  ctx %-6d %6d prompt tokens at %s/s, first token after %s, %s tok/s\n",
+				run.NumCtx, run.PromptTokens, perSecond(run.PromptRate), seconds(run.FirstToken), perSecond(run.Rate))
+		}
+	}
+}
+
+// seconds formats d as seconds with two decimals.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// perSecond formats a rate, or "?" if the provider reported no timings.
+func perSecond(r float64) string {
+	if r == 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%.1f", r)
+}
@@ -0,0 +1,119 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// fakeProvider streams a fixed reply and fails for models in fail, or for
+// contexts larger than maxCtx.
+type fakeProvider struct {
+	fail   map[string]bool
+	maxCtx int
+	reqs   []provider.ChatRequest
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+func (p *fakeProvider) Chat(context.Context, provider.ChatRequest) (*provider.ChatResponse, error) {
+	return nil, errors.New("not used")
+}
+func (p *fakeProvider) ListModels(context.Context) ([]provider.ModelInfo, error) { return nil, nil }
+func (p *fakeProvider) IsAvailable(context.Context) error                        { return nil }
+
+func (p *fakeProvider) StreamChat(_ context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	p.reqs = append(p.reqs, req)
+	if p.fail[req.Model] {
+		return nil, errors.New(req.Model + " does not support chat")
+	}
+	ch := make(chan provider.StreamDelta, 3)
+	if req.NumCtx > p.maxCtx {
+		ch <- provider.StreamDelta{Err: errors.New("out of memory")}
+		close(ch)
+		return ch, nil
+	}
+	ch <- provider.StreamDelta{Content: "Blue"}
+	ch <- provider.StreamDelta{Done: true, Usage: &provider.Usage{
+		PromptTokens:     100,
+		CompletionTokens: 50,
+		LoadDuration:     2 * time.Second,
+		PromptDuration:   time.Second,
+		EvalDuration:     2 * time.Second,
+	}}
+	close(ch)
+	return ch, nil
+}
+
+func TestBenchmark(t *testing.T) {
+	prov := &fakeProvider{fail: map[string]bool{"nomic-embed-text": true}, maxCtx: 8192}
+	var steps []string
+	results, err := Benchmark(context.Background(), prov, []string{"qwen3:4b", "nomic-embed-text"}, 32768,
+		func(model, step string) { steps = append(steps, model+" "+step) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	r := results[0]
+	if r.Err != nil || r.Load != 2*time.Second || r.Rate != 25 || r.FirstToken <= 0 {
+		t.Errorf("qwen3:4b = %+v, want 2s load and 25 tok/s", r)
+	}
+	if len(r.Runs) != 3 || r.Runs[2].Err == nil {
+		t.Fatalf("runs = %+v, want 4096 and 8192 to pass and 16384 to fail, then stop", r.Runs)
+	}
+	if r.PeakNumCtx() != 8192 || r.Runs[0].PromptRate != 100 {
+		t.Errorf("peak = %d, prompt rate = %v; want 8192 and 100", r.PeakNumCtx(), r.Runs[0].PromptRate)
+	}
+	if results[1].Err == nil {
+		t.Error("embedding model didn't fail")
+	}
+	if len(steps) != 5 || steps[1] != "qwen3:4b context 4096" {
+		t.Errorf("progress = %q", steps)
+	}
+
+	for _, req := range prov.reqs {
+		if req.Options.NumPredict == nil || *req.Options.NumPredict != numPredict {
+			t.Errorf("request without num_predict %d: %+v", numPredict, req.Options)
+		}
+	}
+	long := prov.reqs[1]
+	if n := tokens.Count(long.Messages[0].Content); n > long.NumCtx*3/4 || n < long.NumCtx/2 {
+		t.Errorf("prompt for context %d has %d tokens, want about three quarters", long.NumCtx, n)
+	}
+
+	var out bytes.Buffer
+	Print(&out, results)
+	for _, want := range []string{"qwen3:4b", "2.00s", "25.0", "8192", "ctx 16384  failed: out of memory", "nomic-embed-text         failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBenchmark_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	prov := &fakeProvider{fail: map[string]bool{"qwen3:4b": true}}
+	results, err := Benchmark(ctx, prov, []string{"qwen3:4b", "qwen3:8b"}, 8192, nil)
+	if !errors.Is(err, context.Canceled) || len(results) != 0 {
+		t.Errorf("Benchmark = %d results, %v; want none and context.Canceled", len(results), err)
+	}
+}
+
+func TestSizes(t *testing.T) {
+	got := Sizes(32768)
+	if len(got) != 4 || got[0] != 4096 || got[3] != 32768 {
+		t.Errorf("Sizes(32768) = %v", got)
+	}
+	if got := Sizes(2048); len(got) != 0 {
+		t.Errorf("Sizes(2048) = %v, want none", got)
+	}
+}
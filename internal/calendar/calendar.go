@@ -0,0 +1,305 @@
+// Package calendar reads iCalendar (ICS) feeds and files so the assistant
+// knows what is on the user's schedule.
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Event is one occurrence of a calendar event.
+type Event struct {
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+}
+
+// String formats e for a prompt or listing, e.g. "09:00–09:30 Standup
+// (Room 1)". Dates are left to the caller.
+func (e Event) String() string {
+	s := "all day"
+	if !e.AllDay {
+		s = e.Start.Format("15:04") + "–" + e.End.Format("15:04")
+	}
+	s += " " + e.Summary
+	if e.Location != "" {
+		s += " (" + e.Location + ")"
+	}
+	return s
+}
+
+// Calendar holds the events of one or more sources.
+type Calendar struct {
+	events []vevent
+}
+
+// Parse reads a calendar from an ICS stream.
+func Parse(r io.Reader) (*Calendar, error) {
+	events, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Calendar{events: events}, nil
+}
+
+// maxPeriods bounds the expansion of a single recurring event: 100,000 days
+// is over 270 years.
+const maxPeriods = 100000
+
+// Between returns the occurrences overlapping [from, to), sorted by start.
+func (c *Calendar) Between(from, to time.Time) []Event {
+	if c == nil {
+		return nil
+	}
+	// Instances that override a recurrence replace the generated one
+	overridden := make(map[string][]time.Time)
+	for _, e := range c.events {
+		if !e.recurID.IsZero() {
+			overridden[e.uid] = append(overridden[e.uid], e.recurID)
+		}
+	}
+
+	var out []Event
+	for _, e := range c.events {
+		skip := append(slices.Clone(e.exdates), overridden[e.uid]...)
+		if !e.recurID.IsZero() {
+			skip = nil
+		}
+		e.each(to, func(start time.Time) {
+			end := start.Add(e.duration)
+			overlaps := end.After(from) || (e.duration == 0 && !start.Before(from))
+			if !overlaps || slices.ContainsFunc(skip, start.Equal) {
+				return
+			}
+			out = append(out, Event{Summary: e.summary, Location: e.location, Start: start, End: end, AllDay: e.allDay})
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// each calls fn with the start of every occurrence of e that begins before
+// limit, in order.
+func (e vevent) each(limit time.Time, fn func(time.Time)) {
+	r := e.rule
+	if r == nil {
+		if e.start.Before(limit) {
+			fn(e.start)
+		}
+		return
+	}
+	loc := e.start.Location()
+	y, m, d := e.start.Date()
+	hh, mm, ss := e.start.Clock()
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, hh, mm, ss, 0, loc) }
+
+	days := r.byDay
+	if r.freq == "WEEKLY" && len(days) == 0 {
+		days = []time.Weekday{e.start.Weekday()}
+	}
+	// Weeks start on Monday (the RFC 5545 default)
+	offset := func(wd time.Weekday) int { return (int(wd) + 6) % 7 }
+	slices.SortFunc(days, func(a, b time.Weekday) int { return offset(a) - offset(b) })
+	weekStart := d - offset(e.start.Weekday())
+
+	emitted := 0
+	for k := 0; k < maxPeriods; k++ {
+		var candidates []time.Time
+		n := k * r.interval
+		switch r.freq {
+		case "DAILY":
+			candidates = []time.Time{at(y, m, d+n)}
+		case "WEEKLY":
+			for _, wd := range days {
+				candidates = append(candidates, at(y, m, weekStart+7*n+offset(wd)))
+			}
+		case "MONTHLY":
+			if t := at(y, m+time.Month(n), d); t.Day() == d {
+				candidates = []time.Time{t}
+			}
+		case "YEARLY":
+			if t := at(y+n, m, d); t.Day() == d {
+				candidates = []time.Time{t}
+			}
+		}
+		for _, t := range candidates {
+			if t.Before(e.start) {
+				continue
+			}
+			if !t.Before(limit) || (!r.until.IsZero() && t.After(r.until)) || (r.count > 0 && emitted >= r.count) {
+				return
+			}
+			fn(t)
+			emitted++
+		}
+	}
+}
+
+// Day returns the events on the day containing t.
+func (c *Calendar) Day(t time.Time) []Event {
+	y, m, d := t.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return c.Between(start, start.AddDate(0, 0, 1))
+}
+
+// Upcoming returns the timed events starting after now and no later than
+// now+lead.
+func (c *Calendar) Upcoming(now time.Time, lead time.Duration) []Event {
+	var out []Event
+	for _, e := range c.Between(now, now.Add(lead+time.Second)) {
+		if !e.AllDay && e.Start.After(now) && !e.Start.After(now.Add(lead)) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// PromptSection describes today's schedule for the system prompt.
+func (c *Calendar) PromptSection(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Calendar\n\nIt is now %s. ", now.Format("Monday, 2 January 2006, 15:04"))
+	events := c.Day(now)
+	if len(events) == 0 {
+		b.WriteString("The user has no events today.")
+		return b.String()
+	}
+	b.WriteString("The user's events today:\n")
+	for _, e := range events {
+		b.WriteString("\n- " + e.String())
+	}
+	return b.String()
+}
+
+// IsURL reports whether a source is fetched over the network rather than
+// read from disk.
+func IsURL(src string) bool {
+	for _, scheme := range []string{"http://", "https://", "webcal://"} {
+		if strings.HasPrefix(strings.ToLower(src), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads every source, which may be an http(s) or webcal URL or a file
+// path. Sources that fail are reported in the joined error; the calendar
+// holds whatever could be read.
+func Load(ctx context.Context, client *http.Client, sources []string) (*Calendar, error) {
+	cal := &Calendar{}
+	var errs []error
+	for _, src := range sources {
+		events, err := loadSource(ctx, client, src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar %s: %w", src, err))
+			continue
+		}
+		cal.events = append(cal.events, events...)
+	}
+	return cal, errors.Join(errs...)
+}
+
+func loadSource(ctx context.Context, client *http.Client, src string) ([]vevent, error) {
+	if !IsURL(src) {
+		home, _ := os.UserHomeDir()
+		f, err := os.Open(config.ExpandPath(src, home))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parse(f)
+	}
+	url := src
+	if strings.HasPrefix(strings.ToLower(url), "webcal://") {
+		url = "https://" + url[len("webcal://"):]
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return parse(io.LimitReader(resp.Body, 10<<20))
+}
+
+// Cache keeps a loaded calendar and reloads it once it is older than TTL.
+// It is safe for concurrent use.
+type Cache struct {
+	sources []string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu     sync.Mutex
+	cal    *Calendar
+	loaded time.Time
+}
+
+// FromConfig returns a cache for the configured calendars, or nil if there
+// are none. URL sources are left out while web access is disabled.
+func FromConfig(cfg config.CalendarConfig, p config.PrivacyConfig) *Cache {
+	var sources []string
+	for _, src := range cfg.Sources {
+		if p.DisableWeb && IsURL(src) {
+			continue
+		}
+		sources = append(sources, src)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	refresh, err := time.ParseDuration(cfg.Refresh)
+	if err != nil || refresh <= 0 {
+		refresh = 15 * time.Minute
+	}
+	return NewCache(sources, refresh)
+}
+
+// NewCache creates a Cache for sources.
+func NewCache(sources []string, ttl time.Duration) *Cache {
+	return &Cache{sources: sources, ttl: ttl, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Get returns the calendar, reloading it first if it is stale. On a failed
+// reload the previous calendar is kept and the error returned with it.
+func (c *Cache) Get(ctx context.Context) (*Calendar, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cal != nil && time.Since(c.loaded) < c.ttl {
+		return c.cal, nil
+	}
+	cal, err := Load(ctx, c.client, c.sources)
+	if err == nil || c.cal == nil {
+		c.cal = cal
+	}
+	c.loaded = time.Now()
+	return c.cal, err
+}
+
+// Current returns the last loaded calendar without reloading; nil if none
+// has been loaded yet.
+func (c *Cache) Current() *Calendar {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cal
+}
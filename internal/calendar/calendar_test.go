@@ -0,0 +1,175 @@
+package calendar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"LOCATION:Room 1\\, 2nd floor\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260302T093000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20260302T094500\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR\r\n" +
+	"EXDATE;TZID=Europe/Berlin:20260304T093000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20260306T093000\r\n" +
+	"SUMMARY:Standup (moved)\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260306T110000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"SUMMARY:Quarterly review with a very long title that is folded acr\r\n" +
+	" oss lines\r\n" +
+	"DTSTART:20260305T130000Z\r\n" +
+	"DTEND:20260305T140000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20260305\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"SUMMARY:Cancelled lunch\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20260305T110000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func mustParse(t *testing.T) *Calendar {
+	t.Helper()
+	cal, err := Parse(strings.NewReader(sampleICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func TestBetween(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	cal := mustParse(t)
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, berlin)
+	var got []string
+	for _, e := range cal.Between(from, from.AddDate(0, 0, 7)) {
+		if e.AllDay {
+			got = append(got, e.Start.Format("Mon")+" all day "+e.Summary)
+			continue
+		}
+		got = append(got, e.Start.In(berlin).Format("Mon 15:04")+" "+e.Summary)
+	}
+	want := []string{
+		"Mon 09:30 Standup",
+		"Thu all day Holiday",
+		"Thu 14:00 Quarterly review with a very long title that is folded across lines",
+		"Fri 11:00 Standup (moved)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The weekly rule keeps going
+	next := cal.Between(from.AddDate(0, 0, 7), from.AddDate(0, 0, 14))
+	if len(next) != 3 {
+		t.Errorf("got %d events the week after, want 3 standups", len(next))
+	}
+	if loc := next[0].Location; loc != "Room 1, 2nd floor" {
+		t.Errorf("location = %q", loc)
+	}
+}
+
+func TestRecurrenceLimits(t *testing.T) {
+	ics := "BEGIN:VEVENT\nSUMMARY:Daily\nDTSTART:20260301T080000Z\nDURATION:PT30M\nRRULE:FREQ=DAILY;INTERVAL=2;COUNT=3\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Monthly\nDTSTART:20260131T080000Z\nRRULE:FREQ=MONTHLY;UNTIL=20260601T000000Z\nEND:VEVENT\n"
+	cal, err := Parse(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range cal.Between(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, e.Start.UTC().Format("01-02")+" "+e.Summary)
+	}
+	want := "01-31 Monthly|03-01 Daily|03-03 Daily|03-05 Daily|03-31 Monthly|05-31 Monthly"
+	if strings.Join(got, "|") != want {
+		t.Errorf("occurrences = %s, want %s", strings.Join(got, "|"), want)
+	}
+}
+
+func TestUpcomingAndPrompt(t *testing.T) {
+	cal := mustParse(t)
+	now := time.Date(2026, 3, 5, 12, 45, 0, 0, time.UTC)
+
+	up := cal.Upcoming(now, 15*time.Minute)
+	if len(up) != 1 || !strings.HasPrefix(up[0].Summary, "Quarterly review") {
+		t.Errorf("Upcoming() = %v, want the review", up)
+	}
+	if up := cal.Upcoming(now, 10*time.Minute); len(up) != 0 {
+		t.Errorf("Upcoming(10m) = %v, want none", up)
+	}
+
+	section := cal.PromptSection(now.In(time.Local))
+	if !strings.Contains(section, "Holiday") || !strings.Contains(section, "Quarterly review") {
+		t.Errorf("PromptSection() = %q", section)
+	}
+	if got := (&Calendar{}).PromptSection(now); !strings.Contains(got, "no events today") {
+		t.Errorf("empty PromptSection() = %q", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/work.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(sampleICS))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "home.ics")
+	os.WriteFile(path, []byte("BEGIN:VEVENT\nSUMMARY:Dentist\nDTSTART:20260305T150000Z\nEND:VEVENT\n"), 0o644)
+
+	cal, err := Load(context.Background(), srv.Client(), []string{srv.URL + "/work.ics", path, srv.URL + "/missing.ics"})
+	if err == nil || !strings.Contains(err.Error(), "missing.ics: HTTP 404") {
+		t.Errorf("Load() error = %v, want the missing source", err)
+	}
+	day := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	if got := cal.Between(day, day.AddDate(0, 0, 1)); len(got) != 3 {
+		t.Errorf("got %d events, want the review, holiday and dentist", len(got))
+	}
+}
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cal.ics")
+	os.WriteFile(path, []byte("BEGIN:VEVENT\nSUMMARY:One\nDTSTART:20260305T150000Z\nEND:VEVENT\n"), 0o644)
+	c := NewCache([]string{path}, time.Hour)
+	if c.Current() != nil {
+		t.Error("Current() before loading should be nil")
+	}
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed reload keeps the last good calendar
+	os.Remove(path)
+	c.loaded = time.Time{}
+	cal, err := c.Get(context.Background())
+	if err == nil || len(cal.events) != 1 {
+		t.Errorf("Get() after the file vanished = %d events, %v", len(cal.events), err)
+	}
+}
@@ -0,0 +1,241 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vevent is a VEVENT as parsed, before recurrences are expanded.
+type vevent struct {
+	uid      string
+	summary  string
+	location string
+	start    time.Time
+	duration time.Duration
+	allDay   bool
+	rule     *rrule
+	exdates  []time.Time
+	recurID  time.Time // set on an instance that overrides a recurrence
+}
+
+// rrule is the subset of RFC 5545 recurrence rules stefanclaw expands:
+// DAILY, WEEKLY (with BYDAY), MONTHLY and YEARLY with INTERVAL, COUNT and
+// UNTIL.
+type rrule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+// property is one content line: NAME;PARAM=VALUE:value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parse reads the VEVENTs in an iCalendar stream. Cancelled events are
+// dropped; malformed events are skipped rather than failing the calendar.
+func parse(r io.Reader) ([]vevent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var events []vevent
+	var cur *vevent
+	var cancelled bool
+	var end time.Time
+	for _, line := range lines {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			cur, cancelled, end = &vevent{}, false, time.Time{}
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if cur != nil && !cancelled && !cur.start.IsZero() {
+				if cur.duration == 0 && !end.IsZero() {
+					cur.duration = end.Sub(cur.start)
+				}
+				if cur.duration == 0 && cur.allDay {
+					cur.duration = 24 * time.Hour
+				}
+				events = append(events, *cur)
+			}
+			cur = nil
+		case cur == nil:
+		case p.name == "UID":
+			cur.uid = p.value
+		case p.name == "SUMMARY":
+			cur.summary = unescape(p.value)
+		case p.name == "LOCATION":
+			cur.location = unescape(p.value)
+		case p.name == "STATUS":
+			cancelled = strings.EqualFold(p.value, "CANCELLED")
+		case p.name == "DTSTART":
+			cur.start, cur.allDay, _ = parseTime(p)
+		case p.name == "DTEND":
+			end, _, _ = parseTime(p)
+		case p.name == "DURATION":
+			cur.duration, _ = parseDuration(p.value)
+		case p.name == "RRULE":
+			cur.rule = parseRule(p.value)
+		case p.name == "EXDATE":
+			for _, v := range strings.Split(p.value, ",") {
+				if t, _, err := parseTime(property{params: p.params, value: v}); err == nil {
+					cur.exdates = append(cur.exdates, t)
+				}
+			}
+		case p.name == "RECURRENCE-ID":
+			cur.recurID, _, _ = parseTime(p)
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+func parseProperty(line string) (property, bool) {
+	// The value starts at the first colon outside a quoted parameter
+	inQuote := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuote = !inQuote
+		} else if c == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, true
+}
+
+// parseTime reads a DATE or DATE-TIME value. UTC times end in Z, others use
+// the TZID parameter or, failing that, local time.
+func parseTime(p property) (t time.Time, allDay bool, err error) {
+	v := strings.TrimSpace(p.value)
+	loc := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case len(v) == 8 || p.params["VALUE"] == "DATE":
+		t, err = time.ParseInLocation("20060102", v, time.Local)
+		return t, true, err
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse("20060102T150405Z", v)
+		return t, false, err
+	}
+	t, err = time.ParseInLocation("20060102T150405", v, loc)
+	return t, false, err
+}
+
+// parseDuration reads an RFC 5545 duration such as PT30M, P1D or P1DT2H.
+func parseDuration(v string) (time.Duration, error) {
+	neg := strings.HasPrefix(v, "-")
+	v = strings.TrimLeft(v, "+-")
+	if !strings.HasPrefix(v, "P") {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	var d time.Duration
+	num := ""
+	for _, c := range v[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+		case c == 'T':
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", v)
+			}
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}[c]
+			if unit == 0 {
+				return 0, fmt.Errorf("invalid duration %q", v)
+			}
+			d += time.Duration(n) * unit
+			num = ""
+		}
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRule reads an RRULE value. Rules stefanclaw can't expand return nil,
+// so only the first occurrence is shown.
+func parseRule(v string) *rrule {
+	r := &rrule{interval: 1}
+	for _, part := range strings.Split(v, ";") {
+		k, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(val); err == nil && n > 0 {
+				r.interval = n
+			}
+		case "COUNT":
+			r.count, _ = strconv.Atoi(val)
+		case "UNTIL":
+			r.until, _, _ = parseTime(property{value: val})
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, ok := weekdays[strings.ToUpper(d)]
+				if !ok {
+					return nil // ordinals such as 1MO are not supported
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY", "BYSETPOS", "BYMONTH", "BYYEARDAY", "BYWEEKNO", "BYHOUR", "BYMINUTE":
+			return nil
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return r
+	}
+	return nil
+}
+
+func unescape(v string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(v)
+}
@@ -0,0 +1,316 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/chat"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// ResetCommand starts a new session for the conversation it is sent in.
+const ResetCommand = "!new"
+
+// Bridge answers messages arriving from a chat service. Each conversation
+// (a DM or channel, identified by a key such as "discord:<channel id>") has
+// its own stefanclaw session, so history never leaks between them. The
+// /remember and /session commands are handled as in the TUI instead of
+// being sent to the model.
+type Bridge struct {
+	Runner    *agent.Runner
+	Sessions  session.Store
+	Memory    *memory.Store  // for /remember; may be nil
+	Pipeline  *chat.Pipeline // prepares messages as the TUI does; may be nil
+	MapFile   string         // JSON file mapping conversation keys to session IDs
+	MaxTokens int            // history sent with each message; 6000 if unset
+
+	mu sync.Mutex
+}
+
+// Reply answers text sent in the conversation key. title names the session
+// created for a new conversation. Messages are answered one at a time.
+func (b *Bridge) Reply(ctx context.Context, key, title, text string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	text = strings.TrimSpace(text)
+	if text == ResetCommand {
+		if err := b.setSession(key, ""); err != nil {
+			return "", err
+		}
+		return "Started a new conversation.", nil
+	}
+	if name, args, ok := parseCommand(text); ok {
+		return b.command(key, title, name, args)
+	}
+
+	sess, err := b.session(key, title)
+	if err != nil {
+		return "", err
+	}
+	return b.replyIn(ctx, sess.ID, text)
+}
+
+// Answer answers text outside any conversation. With inSession, the
+// exchange joins the session last open in the TUI, whose recent history is
+// sent along, and that session's ID is returned.
+func (b *Bridge) Answer(ctx context.Context, text string, inSession bool) (answer, sessionID string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !inSession {
+		answer, usage, err := b.runner().RunWithUsage(ctx, "", []provider.Message{{Role: "user", Content: b.pipeline().Augment(ctx, text)}})
+		if err == nil {
+			if err := b.pipeline().RecordUsage("", b.Runner.Model, usage); err != nil {
+				log.Warn("recording usage failed", "err", err)
+			}
+		}
+		return answer, "", err
+	}
+	sess, err := b.Sessions.Current()
+	if err != nil || sess == nil {
+		if sess, err = b.Sessions.Create(session.DefaultTitle, b.Runner.Model); err != nil {
+			return "", "", fmt.Errorf("creating session: %w", err)
+		}
+		if err := b.Sessions.SetCurrent(sess.ID); err != nil {
+			return "", "", err
+		}
+	}
+	answer, err = b.replyIn(ctx, sess.ID, text)
+	return answer, sess.ID, err
+}
+
+// replyIn answers text in the session id, with its history, and records
+// both messages in it.
+func (b *Bridge) replyIn(ctx context.Context, id, text string) (string, error) {
+	transcript, err := b.Sessions.LoadTranscript(id)
+	if err != nil {
+		return "", fmt.Errorf("loading transcript: %w", err)
+	}
+	var history []provider.Message
+	for _, m := range transcript {
+		if m.Role == "user" || m.Role == "assistant" {
+			history = append(history, m)
+		}
+	}
+	user := provider.Message{Role: "user", Content: text}
+	sent := provider.Message{Role: "user", Content: b.pipeline().Augment(ctx, text)}
+	history = trimHistory(append(history, sent), b.maxTokens())
+
+	if err := b.Sessions.Append(id, user); err != nil {
+		return "", err
+	}
+	answer, usage, err := b.runner().RunWithUsage(ctx, "", history)
+	if err != nil {
+		return "", err
+	}
+	if err := b.Sessions.Append(id, provider.Message{Role: "assistant", Content: answer}); err != nil {
+		return "", err
+	}
+	if err := b.pipeline().RecordUsage(id, b.Runner.Model, usage); err != nil {
+		log.Warn("recording usage failed", "session", id, "err", err)
+	}
+	return answer, nil
+}
+
+// pipeline returns b.Pipeline, or one that leaves messages as they are.
+func (b *Bridge) pipeline() *chat.Pipeline {
+	if b.Pipeline == nil {
+		return &chat.Pipeline{}
+	}
+	return b.Pipeline
+}
+
+// bridgeTools are the only tools the model gets on a bridge. Messages may
+// carry text from web pages or other people, and a prompt injection in them
+// must not read private data, reach the network or switch anything: the
+// answer goes back to where the text came from.
+var bridgeTools = []string{"time", "date_calc"}
+
+// runner returns b.Runner limited to bridgeTools, with the system prompt
+// built by the pipeline so that it includes facts saved since the bridge
+// started.
+func (b *Bridge) runner() *agent.Runner {
+	r := *b.Runner
+	if r.Tools != nil {
+		r.Tools = r.Tools.Filter(func(t tools.Tool) bool { return slices.Contains(bridgeTools, t.Name) })
+	}
+	if b.Pipeline != nil && b.Pipeline.Prompt != nil {
+		r.SystemPrompt = b.Pipeline.SystemPrompt()
+	}
+	return &r
+}
+
+// parseCommand splits text into a passthrough command and its arguments.
+func parseCommand(text string) (name, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(text[1:], " ")
+	if name != "remember" && name != "session" {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(args), true
+}
+
+// command runs /remember or /session for the conversation key.
+func (b *Bridge) command(key, title, name, args string) (string, error) {
+	switch name {
+	case "remember":
+		if args == "" {
+			return "Usage: /remember <fact>", nil
+		}
+		if b.Memory == nil {
+			return "Memory system not configured.", nil
+		}
+		if err := b.Memory.Append([]string{args}); err != nil {
+			return "", fmt.Errorf("saving memory: %w", err)
+		}
+		return "Remembered: " + args, nil
+	case "session":
+		switch args {
+		case "new":
+			sess, err := b.Sessions.Create(title, b.Runner.Model)
+			if err != nil {
+				return "", fmt.Errorf("creating session: %w", err)
+			}
+			if err := b.setSession(key, sess.ID); err != nil {
+				return "", err
+			}
+			return "New session: " + sess.ID, nil
+		case "list":
+			return b.listSessions(key)
+		}
+	}
+	return "Usage: /session new|list", nil
+}
+
+// listSessions lists all sessions, marking the one used by key.
+func (b *Bridge) listSessions(key string) (string, error) {
+	sessions, err := b.Sessions.List()
+	if err != nil {
+		return "", fmt.Errorf("listing sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return "No sessions found.", nil
+	}
+	ids, err := b.loadMap()
+	if err != nil {
+		return "", err
+	}
+	lines := []string{"Sessions:"}
+	for _, s := range sessions {
+		marker := "  "
+		if s.ID == ids[key] {
+			marker = "* "
+		}
+		lines = append(lines, fmt.Sprintf("%s%s - %s (%s)", marker, s.ID, s.Title, s.Model))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (b *Bridge) maxTokens() int {
+	if b.MaxTokens > 0 {
+		return b.MaxTokens
+	}
+	return 6000
+}
+
+// trimHistory drops the oldest messages until the rest fit in maxTokens,
+// always keeping the last one.
+func trimHistory(msgs []provider.Message, maxTokens int) []provider.Message {
+	total := session.EstimateTokens(msgs)
+	for len(msgs) > 1 && total > maxTokens {
+		total -= session.EstimateTokens(msgs[:1])
+		msgs = msgs[1:]
+	}
+	return msgs
+}
+
+// session returns the session for key, creating one if needed.
+func (b *Bridge) session(key, title string) (*session.Session, error) {
+	ids, err := b.loadMap()
+	if err != nil {
+		return nil, err
+	}
+	if id := ids[key]; id != "" {
+		if sess, err := b.Sessions.Get(id); err == nil {
+			return sess, nil
+		}
+	}
+	sess, err := b.Sessions.Create(title, b.Runner.Model)
+	if err != nil {
+		return nil, err
+	}
+	return sess, b.setSession(key, sess.ID)
+}
+
+// setSession records id as the session for key; an empty id forgets it.
+func (b *Bridge) setSession(key, id string) error {
+	ids, err := b.loadMap()
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		delete(ids, key)
+	} else {
+		ids[key] = id
+	}
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.MapFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.MapFile, data, 0o644)
+}
+
+func (b *Bridge) loadMap() (map[string]string, error) {
+	ids := make(map[string]string)
+	data, err := os.ReadFile(b.MapFile)
+	if os.IsNotExist(err) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", b.MapFile, err)
+	}
+	return ids, nil
+}
+
+// splitMessage breaks text into chunks of at most limit bytes for services
+// that cap message length, preferring to split at line breaks.
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n")
+		if cut <= 0 {
+			cut = limit
+			// Don't split a multi-byte character
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
@@ -1,7 +1,9 @@
+// Package channel connects stefanclaw to chat services such as Discord.
+// Messages are answered through a Bridge, which keeps one session per
+// conversation.
 package channel
 
-// Channel defines the interface for message delivery channels (TUI, Telegram, etc).
-// This is a placeholder for future multi-channel support.
+// Channel defines the interface for message delivery channels (TUI, Discord, etc).
 type Channel interface {
 	Name() string
 	Start() error
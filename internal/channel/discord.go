@@ -0,0 +1,106 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordMaxMessage is the longest message Discord accepts.
+const discordMaxMessage = 2000
+
+// Discord connects a bot account to a Bridge. It answers DMs and messages
+// in the configured channels, but only from allowed users.
+type Discord struct {
+	Token        string
+	Channels     []string // channel IDs to answer in besides DMs
+	AllowedUsers []string // user IDs to answer
+	Bridge       *Bridge
+	Log          io.Writer // receives errors; may be nil
+
+	session *discordgo.Session
+}
+
+// Name implements Channel.
+func (d *Discord) Name() string { return "discord" }
+
+// Start connects to Discord and begins answering messages.
+func (d *Discord) Start() error {
+	s, err := discordgo.New("Bot " + d.Token)
+	if err != nil {
+		return err
+	}
+	s.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		d.handle(s, m.Message)
+	})
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("connecting to Discord: %w", err)
+	}
+	d.session = s
+	return nil
+}
+
+// Stop disconnects from Discord.
+func (d *Discord) Stop() error {
+	if d.session == nil {
+		return nil
+	}
+	return d.session.Close()
+}
+
+func (d *Discord) handle(s *discordgo.Session, m *discordgo.Message) {
+	botID := s.State.User.ID
+	text, ok := d.accept(m, botID)
+	if !ok {
+		return
+	}
+	s.ChannelTyping(m.ChannelID)
+
+	title := "Discord DM with " + m.Author.Username
+	if m.GuildID != "" {
+		title = "Discord #" + m.ChannelID
+		if ch, err := s.State.Channel(m.ChannelID); err == nil {
+			title = "Discord #" + ch.Name
+		}
+	}
+	answer, err := d.Bridge.Reply(context.Background(), "discord:"+m.ChannelID, title, text)
+	if err != nil {
+		d.logf("discord: %v", err)
+		answer = "Sorry, something went wrong: " + err.Error()
+	}
+	for _, chunk := range splitMessage(answer, discordMaxMessage) {
+		if _, err := s.ChannelMessageSend(m.ChannelID, chunk); err != nil {
+			d.logf("discord: sending reply: %v", err)
+			return
+		}
+	}
+}
+
+// accept decides whether to answer m and returns its text without mentions
+// of the bot. Bots, strangers and channels that aren't configured are
+// ignored.
+func (d *Discord) accept(m *discordgo.Message, botID string) (string, bool) {
+	if m.Author == nil || m.Author.Bot || m.Author.ID == botID {
+		return "", false
+	}
+	if !slices.Contains(d.AllowedUsers, m.Author.ID) {
+		return "", false
+	}
+	if m.GuildID != "" && !slices.Contains(d.Channels, m.ChannelID) {
+		return "", false
+	}
+	text := strings.NewReplacer("<@"+botID+">", "", "<@!"+botID+">", "").Replace(m.Content)
+	text = strings.TrimSpace(text)
+	return text, text != ""
+}
+
+func (d *Discord) logf(format string, args ...any) {
+	if d.Log != nil {
+		fmt.Fprintf(d.Log, format+"\n", args...)
+	}
+}
@@ -0,0 +1,33 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDiscord_Accept(t *testing.T) {
+	d := &Discord{Channels: []string{"100"}, AllowedUsers: []string{"7"}}
+	user := &discordgo.User{ID: "7", Username: "ana"}
+
+	tests := []struct {
+		name string
+		msg  *discordgo.Message
+		want string
+		ok   bool
+	}{
+		{"dm", &discordgo.Message{Author: user, ChannelID: "5", Content: "hi"}, "hi", true},
+		{"configured channel", &discordgo.Message{Author: user, GuildID: "1", ChannelID: "100", Content: "<@42> what's up?"}, "what's up?", true},
+		{"other channel", &discordgo.Message{Author: user, GuildID: "1", ChannelID: "200", Content: "hi"}, "", false},
+		{"stranger", &discordgo.Message{Author: &discordgo.User{ID: "8"}, ChannelID: "5", Content: "hi"}, "", false},
+		{"bot", &discordgo.Message{Author: &discordgo.User{ID: "7", Bot: true}, ChannelID: "5", Content: "hi"}, "", false},
+		{"itself", &discordgo.Message{Author: &discordgo.User{ID: "42"}, ChannelID: "5", Content: "hi"}, "", false},
+		{"only a mention", &discordgo.Message{Author: user, GuildID: "1", ChannelID: "100", Content: "<@!42>"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := d.accept(tt.msg, "42")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: accept() = %q, %t, want %q, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package channel
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// httpMaxBody caps request bodies; a long web page is well below it.
+	httpMaxBody = 1 << 20
+	// httpMaxText caps the text sent to the model, which has a limited
+	// context; the rest is cut off.
+	httpMaxText = 30000
+)
+
+// HTTP serves a Bridge to browser extensions and editor plugins on a
+// loopback address. Clients POST text to /v1/ask with the token as a bearer
+// token and get the answer back as JSON:
+//
+//	{"text": "...", "question": "Summarize this.", "title": "...", "url": "...", "session": false}
+//
+// With "session": true, the exchange joins the session last open in the TUI.
+type HTTP struct {
+	Addr   string // loopback host:port to listen on
+	Token  string // bearer token clients must send
+	Bridge *Bridge
+	Log    io.Writer // receives errors; may be nil
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+// askRequest is the body of POST /v1/ask.
+type askRequest struct {
+	Text     string `json:"text"`     // selection or page text
+	Question string `json:"question"` // what to do with it; default: summarize
+	Title    string `json:"title"`    // page or file title, if any
+	URL      string `json:"url"`      // page URL or file path, if any
+	Session  bool   `json:"session"`  // add the exchange to the current session
+}
+
+// askResponse is the reply to POST /v1/ask.
+type askResponse struct {
+	Answer  string `json:"answer,omitempty"`
+	Session string `json:"session,omitempty"` // ID of the session the exchange was added to
+	Error   string `json:"error,omitempty"`
+}
+
+// Name implements Channel.
+func (h *HTTP) Name() string { return "http" }
+
+// Start begins listening. It refuses addresses other computers can reach.
+func (h *HTTP) Start() error {
+	if h.Token == "" {
+		return errors.New("the HTTP bridge needs a token")
+	}
+	host, _, err := net.SplitHostPort(h.Addr)
+	if err != nil {
+		return fmt.Errorf("bridge address: %w", err)
+	}
+	if !isLoopback(host) {
+		return fmt.Errorf("bridge address %s is not a loopback address", h.Addr)
+	}
+	h.ln, err = net.Listen("tcp", h.Addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", h.health)
+	mux.HandleFunc("POST /v1/ask", h.ask)
+	h.srv = &http.Server{Handler: h.guard(mux), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := h.srv.Serve(h.ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			h.logf("http bridge: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts the server down, letting running requests finish for a while.
+func (h *HTTP) Stop() error {
+	if h.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.srv.Shutdown(ctx)
+}
+
+// URL returns the address the bridge listens on, once started.
+func (h *HTTP) URL() string {
+	if h.ln == nil {
+		return ""
+	}
+	return "http://" + h.ln.Addr().String()
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// guard checks the token and, against DNS rebinding, that the request was
+// addressed to this machine.
+func (h *HTTP) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopback(strings.Trim(host, "[]")) {
+			writeJSON(w, http.StatusForbidden, askResponse{Error: "requests must be addressed to localhost"})
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, askResponse{Error: "missing or wrong bearer token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *HTTP) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "model": h.Bridge.Runner.Model})
+}
+
+func (h *HTTP) ask(w http.ResponseWriter, r *http.Request) {
+	var req askRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, httpMaxBody)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, askResponse{Error: "invalid JSON: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeJSON(w, http.StatusBadRequest, askResponse{Error: `"text" is empty`})
+		return
+	}
+	answer, id, err := h.Bridge.Answer(r.Context(), askPrompt(req), req.Session)
+	if err != nil {
+		h.logf("http bridge: %v", err)
+		writeJSON(w, http.StatusBadGateway, askResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, askResponse{Answer: answer, Session: id})
+}
+
+// askPrompt builds the message for the model: the question, then the text
+// marked with where it came from.
+func askPrompt(req askRequest) string {
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		question = "Summarize this."
+	}
+	text := strings.TrimSpace(req.Text)
+	if len(text) > httpMaxText {
+		cut := httpMaxText
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "\n[…cut off]"
+	}
+	var source []string
+	for _, s := range []string{req.Title, req.URL} {
+		if s = strings.TrimSpace(s); s != "" {
+			source = append(source, s)
+		}
+	}
+	attr := ""
+	if len(source) > 0 {
+		attr = fmt.Sprintf(" source=%q", strings.Join(source, " — "))
+	}
+	return fmt.Sprintf("%s\n\n<selection%s>\n%s\n</selection>", question, attr, text)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *HTTP) logf(format string, args ...any) {
+	if h.Log != nil {
+		fmt.Fprintf(h.Log, format+"\n", args...)
+	}
+}
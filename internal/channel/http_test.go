@@ -0,0 +1,113 @@
+package channel
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func startHTTP(t *testing.T) (*HTTP, *echoProvider, func(body, token string) (int, askResponse)) {
+	t.Helper()
+	b, p, _ := newTestBridge(t)
+	h := &HTTP{Addr: "127.0.0.1:0", Token: "s3cret", Bridge: b}
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Stop() })
+	post := func(body, token string) (int, askResponse) {
+		req, _ := http.NewRequest(http.MethodPost, h.URL()+"/v1/ask", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out askResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	return h, p, post
+}
+
+func TestHTTP_Ask(t *testing.T) {
+	h, p, post := startHTTP(t)
+
+	code, out := post(`{"text": "Go 1.25 was released.", "title": "Go Blog", "url": "https://go.dev/blog"}`, "s3cret")
+	if code != http.StatusOK || out.Session != "" {
+		t.Fatalf("ask = %d %+v", code, out)
+	}
+	want := "You said: Summarize this.\n\n<selection source=\"Go Blog — https://go.dev/blog\">\nGo 1.25 was released.\n</selection>"
+	if out.Answer != want {
+		t.Errorf("answer = %q, want %q", out.Answer, want)
+	}
+	if sessions, _ := h.Bridge.Sessions.List(); len(sessions) != 0 {
+		t.Errorf("got %d sessions, want none without session: true", len(sessions))
+	}
+
+	// With session, the exchange joins the current session
+	code, out = post(`{"text": "x := 1", "question": "Explain this code", "session": true}`, "s3cret")
+	if code != http.StatusOK || out.Session == "" {
+		t.Fatalf("ask in session = %d %+v", code, out)
+	}
+	if cur, _ := h.Bridge.Sessions.Current(); cur == nil || cur.ID != out.Session {
+		t.Errorf("current session = %+v, want %s", cur, out.Session)
+	}
+	code, out2 := post(`{"text": "y := 2", "session": true}`, "s3cret")
+	if code != http.StatusOK || out2.Session != out.Session {
+		t.Fatalf("second ask = %d %+v, want the same session", code, out2)
+	}
+	if msgs := p.reqs[len(p.reqs)-1].Messages; len(msgs) != 4 {
+		t.Errorf("sent %d messages, want system prompt, history and the new one", len(msgs))
+	}
+	if transcript, _ := h.Bridge.Sessions.LoadTranscript(out.Session); len(transcript) != 4 {
+		t.Errorf("transcript has %d messages, want 4", len(transcript))
+	}
+}
+
+func TestHTTP_Rejects(t *testing.T) {
+	h, _, post := startHTTP(t)
+
+	if code, _ := post(`{"text": "hi"}`, ""); code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d", code)
+	}
+	if code, _ := post(`{"text": "hi"}`, "guess"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d", code)
+	}
+	if code, out := post(`{"text": " "}`, "s3cret"); code != http.StatusBadRequest || out.Error == "" {
+		t.Errorf("empty text: %d %+v", code, out)
+	}
+	if code, _ := post(`{"text": `, "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("bad JSON: status %d", code)
+	}
+
+	// A web page that rebinds its own name to 127.0.0.1
+	req, _ := http.NewRequest(http.MethodPost, h.URL()+"/v1/ask", bytes.NewReader([]byte(`{"text": "hi"}`)))
+	req.Host = "attacker.example:8477"
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign Host: status %d", resp.StatusCode)
+	}
+
+	if err := (&HTTP{Addr: "0.0.0.0:0", Token: "x"}).Start(); err == nil {
+		t.Error("Start on 0.0.0.0 should fail")
+	}
+	if err := (&HTTP{Addr: "127.0.0.1:0"}).Start(); err == nil {
+		t.Error("Start without a token should fail")
+	}
+}
+
+func TestAskPrompt_CutsLongText(t *testing.T) {
+	got := askPrompt(askRequest{Text: strings.Repeat("é", httpMaxText)})
+	if !strings.Contains(got, "[…cut off]") || len(got) > httpMaxText+200 {
+		t.Errorf("prompt is %d bytes, want the text cut off", len(got))
+	}
+}
@@ -0,0 +1,177 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// slackMaxMessage keeps replies well under Slack's limit, which truncates
+// long messages rather than rejecting them.
+const slackMaxMessage = 4000
+
+// Slack connects a Slack app to a Bridge over Socket Mode, so no public
+// endpoint is needed. It answers DMs and messages in the configured
+// channels, but only from allowed users. The /remember and /session slash
+// commands, if registered for the app, are passed through to the Bridge.
+type Slack struct {
+	AppToken     string   // app-level token (xapp-) for Socket Mode
+	BotToken     string   // bot token (xoxb-) for the Web API
+	Channels     []string // channel IDs to answer in besides DMs
+	AllowedUsers []string // user IDs to answer
+	Bridge       *Bridge
+	Log          io.Writer // receives errors; may be nil
+
+	api    *slack.Client
+	client *socketmode.Client
+	botID  string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Name implements Channel.
+func (s *Slack) Name() string { return "slack" }
+
+// Start connects to Slack and begins answering messages.
+func (s *Slack) Start() error {
+	s.api = slack.New(s.BotToken, slack.OptionAppLevelToken(s.AppToken))
+	auth, err := s.api.AuthTest()
+	if err != nil {
+		return fmt.Errorf("connecting to Slack: %w", err)
+	}
+	s.botID = auth.UserID
+	s.client = socketmode.New(s.api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go func() {
+		if err := s.client.RunContext(ctx); err != nil && ctx.Err() == nil {
+			s.logf("slack: %v", err)
+		}
+	}()
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-s.client.Events:
+				s.dispatch(ctx, ev)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop disconnects from Slack.
+func (s *Slack) Stop() error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *Slack) dispatch(ctx context.Context, ev socketmode.Event) {
+	switch ev.Type {
+	case socketmode.EventTypeInvalidAuth:
+		s.logf("slack: invalid app token")
+	case socketmode.EventTypeEventsAPI:
+		s.client.Ack(*ev.Request)
+		outer, ok := ev.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			return
+		}
+		if m, ok := outer.InnerEvent.Data.(*slackevents.MessageEvent); ok {
+			s.handleMessage(ctx, m)
+		}
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := ev.Data.(slack.SlashCommand)
+		if !ok {
+			s.client.Ack(*ev.Request)
+			return
+		}
+		// The answer goes back in the acknowledgement, visible only to the user
+		s.client.Ack(*ev.Request, map[string]any{"text": s.handleCommand(ctx, cmd)})
+	}
+}
+
+func (s *Slack) handleMessage(ctx context.Context, m *slackevents.MessageEvent) {
+	text, ok := s.accept(m)
+	if !ok {
+		return
+	}
+	answer, err := s.Bridge.Reply(ctx, "slack:"+m.Channel, s.title(m.Channel, m.ChannelType == "im"), text)
+	if err != nil {
+		s.logf("slack: %v", err)
+		answer = "Sorry, something went wrong: " + err.Error()
+	}
+	for _, chunk := range splitMessage(answer, slackMaxMessage) {
+		if _, _, err := s.api.PostMessageContext(ctx, m.Channel, slack.MsgOptionText(chunk, false)); err != nil {
+			s.logf("slack: sending reply: %v", err)
+			return
+		}
+	}
+}
+
+func (s *Slack) handleCommand(ctx context.Context, cmd slack.SlashCommand) string {
+	if !slices.Contains(s.AllowedUsers, cmd.UserID) {
+		return "Sorry, you're not allowed to use this assistant."
+	}
+	isDM := strings.HasPrefix(cmd.ChannelID, "D")
+	if !isDM && !slices.Contains(s.Channels, cmd.ChannelID) {
+		return "This channel isn't configured for the assistant."
+	}
+	text := strings.TrimSpace(cmd.Command + " " + cmd.Text)
+	answer, err := s.Bridge.Reply(ctx, "slack:"+cmd.ChannelID, s.title(cmd.ChannelID, isDM), text)
+	if err != nil {
+		s.logf("slack: %v", err)
+		return "Sorry, something went wrong: " + err.Error()
+	}
+	return answer
+}
+
+// accept decides whether to answer m and returns its text without mentions
+// of the bot. Edits, bots, strangers and channels that aren't configured
+// are ignored.
+func (s *Slack) accept(m *slackevents.MessageEvent) (string, bool) {
+	if m.SubType != "" || m.BotID != "" || m.User == "" || m.User == s.botID {
+		return "", false
+	}
+	if !slices.Contains(s.AllowedUsers, m.User) {
+		return "", false
+	}
+	if m.ChannelType != "im" && !slices.Contains(s.Channels, m.Channel) {
+		return "", false
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(m.Text, "<@"+s.botID+">", ""))
+	return text, text != ""
+}
+
+// title names the session created for a conversation.
+func (s *Slack) title(channelID string, isDM bool) string {
+	if isDM {
+		return "Slack DM"
+	}
+	if s.api != nil {
+		ch, err := s.api.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+		if err == nil {
+			return "Slack #" + ch.Name
+		}
+	}
+	return "Slack #" + channelID
+}
+
+func (s *Slack) logf(format string, args ...any) {
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, format+"\n", args...)
+	}
+}
@@ -0,0 +1,32 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestSlack_Accept(t *testing.T) {
+	s := &Slack{Channels: []string{"G100"}, AllowedUsers: []string{"U7"}, botID: "U42"}
+
+	tests := []struct {
+		name string
+		msg  slackevents.MessageEvent
+		want string
+		ok   bool
+	}{
+		{"dm", slackevents.MessageEvent{User: "U7", Channel: "D5", ChannelType: "im", Text: "hi"}, "hi", true},
+		{"configured channel", slackevents.MessageEvent{User: "U7", Channel: "G100", ChannelType: "group", Text: "<@U42> status?"}, "status?", true},
+		{"other channel", slackevents.MessageEvent{User: "U7", Channel: "C200", ChannelType: "channel", Text: "hi"}, "", false},
+		{"stranger", slackevents.MessageEvent{User: "U8", Channel: "D5", ChannelType: "im", Text: "hi"}, "", false},
+		{"bot", slackevents.MessageEvent{User: "U7", BotID: "B1", Channel: "D5", ChannelType: "im", Text: "hi"}, "", false},
+		{"itself", slackevents.MessageEvent{User: "U42", Channel: "D5", ChannelType: "im", Text: "hi"}, "", false},
+		{"edit", slackevents.MessageEvent{User: "U7", SubType: "message_changed", Channel: "D5", ChannelType: "im", Text: "hi"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := s.accept(&tt.msg)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: accept() = %q, %t, want %q, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package chat is the request pipeline shared by the TUI, pipe mode and the
+// chat bridges, so a message is treated the same wherever it is sent from:
+// the system prompt carries the current memory, links in the user's message
+// are fetched and the usage of each reply is recorded with its session and
+// in the usage log.
+package chat
+
+import (
+	"context"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+// Pipeline prepares requests and records their outcome. Any field may be
+// nil to skip its step.
+type Pipeline struct {
+	Prompt   *prompt.Assembler // personality files for the system prompt
+	Language string            // the model is told to answer in it; English if empty
+	Fetch    *fetch.Client     // fetches pages linked in the user's message
+	Sessions session.Store     // where usage is recorded per session
+	Usage    *usage.Log        // where usage is recorded per reply
+}
+
+// SystemPrompt builds the system prompt. MEMORY.md is read again first, so
+// facts saved since the start are included.
+func (p *Pipeline) SystemPrompt() string {
+	if p.Prompt == nil {
+		return ""
+	}
+	p.RefreshMemory()
+	return p.Prompt.BuildSystemPromptWithLanguage(p.Language)
+}
+
+// RefreshMemory reads MEMORY.md again and reports whether it changed, for
+// callers that keep the system prompt and only rebuild it then.
+func (p *Pipeline) RefreshMemory() bool {
+	return p.Prompt != nil && p.Prompt.ReloadSection(prompt.SectionMemory)
+}
+
+// Augment returns text, a message the user wrote, with the content of the
+// web pages it links to appended.
+func (p *Pipeline) Augment(ctx context.Context, text string) string {
+	if p.Fetch == nil {
+		return text
+	}
+	return fetch.AugmentWithWebContent(ctx, p.Fetch, text)
+}
+
+// Messages returns the request for question: the system prompt, history
+// and the augmented question.
+func (p *Pipeline) Messages(ctx context.Context, history []provider.Message, question string) []provider.Message {
+	var msgs []provider.Message
+	if sys := p.SystemPrompt(); sys != "" {
+		msgs = append(msgs, provider.Message{Role: "system", Content: sys})
+	}
+	msgs = append(msgs, history...)
+	return append(msgs, provider.Message{Role: "user", Content: p.Augment(ctx, question)})
+}
+
+// RecordUsage adds the usage of a reply by model to the usage log and to
+// the state of session id, if any. Zero usage, from a provider that doesn't
+// report it, is left out.
+func (p *Pipeline) RecordUsage(id, model string, u provider.Usage) error {
+	if u == (provider.Usage{}) {
+		return nil
+	}
+	if p.Usage != nil {
+		err := p.Usage.Add(usage.Record{
+			Time:             time.Now(),
+			Session:          id,
+			Model:            model,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			EvalDuration:     u.EvalDuration,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if p.Sessions == nil || id == "" {
+		return nil
+	}
+	sess, err := p.Sessions.Get(id)
+	if err != nil {
+		return err
+	}
+	var st session.State
+	if sess.State != nil {
+		st = *sess.State
+	}
+	st.AddUsage(u)
+	return p.Sessions.UpdateState(id, st)
+}
@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+// roundTripFunc answers HTTP requests without a network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTestPipeline(t *testing.T) (*Pipeline, string) {
+	t.Helper()
+	dir := t.TempDir()
+	memFile := filepath.Join(dir, "MEMORY.md")
+	os.WriteFile(memFile, []byte("# Memory\n\n- Likes tea\n"), 0o644)
+	asm := prompt.NewAssembler(dir)
+	asm.SetSectionPath(prompt.SectionMemory, memFile)
+	asm.LoadFiles()
+
+	client := fetch.NewWithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("Opening hours: 9 to 5")), Request: r}, nil
+	})})
+	return &Pipeline{Prompt: asm, Language: "German", Fetch: client}, memFile
+}
+
+func TestPipeline_Messages(t *testing.T) {
+	p, _ := newTestPipeline(t)
+	history := []provider.Message{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hallo"}}
+	msgs := p.Messages(context.Background(), history, "When is https://example.com/shop open?")
+
+	if len(msgs) != 4 || msgs[0].Role != "system" || msgs[1].Content != "Hi" {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	if sys := msgs[0].Content; !strings.Contains(sys, "Likes tea") || !strings.Contains(sys, "respond in German") {
+		t.Errorf("system prompt lacks the memory or language:\n%s", sys)
+	}
+	if last := msgs[3].Content; !strings.HasPrefix(last, "When is") || !strings.Contains(last, "Opening hours: 9 to 5") {
+		t.Errorf("question = %q, want the fetched page appended", last)
+	}
+}
+
+func TestPipeline_RefreshesMemory(t *testing.T) {
+	p, memFile := newTestPipeline(t)
+	p.SystemPrompt()
+	if p.RefreshMemory() {
+		t.Error("RefreshMemory() = true for an unchanged MEMORY.md")
+	}
+
+	os.WriteFile(memFile, []byte("# Memory\n\n- Likes tea\n- Has a cat\n"), 0o644)
+	if !p.RefreshMemory() {
+		t.Error("RefreshMemory() = false after a fact was saved")
+	}
+	if sys := p.SystemPrompt(); !strings.Contains(sys, "Has a cat") {
+		t.Errorf("system prompt lacks the new fact:\n%s", sys)
+	}
+}
+
+func TestPipeline_RecordUsage(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	sess, _ := store.Create("Test", "test-model")
+	log := usage.NewLog(filepath.Join(t.TempDir(), "usage.jsonl"))
+	p := &Pipeline{Sessions: store, Usage: log}
+
+	p.RecordUsage(sess.ID, "test-model", provider.Usage{PromptTokens: 100, CompletionTokens: 20})
+	if err := p.RecordUsage(sess.ID, "test-model", provider.Usage{PromptTokens: 150, CompletionTokens: 30, EvalDuration: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	p.RecordUsage(sess.ID, "test-model", provider.Usage{})
+	got, _ := store.Get(sess.ID)
+	if st := got.State; st == nil || st.LastUsage.PromptTokens != 150 || st.PromptTokens != 250 || st.CompletionTokens != 50 {
+		t.Errorf("state = %+v, want the last usage and the totals", st)
+	}
+	records, err := log.Records(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].Session != sess.ID || records[1].Model != "test-model" || records[1].CompletionTokens != 30 || records[1].EvalDuration != time.Second {
+		t.Errorf("records = %+v, want both replies with usage", records)
+	}
+}
+
+func TestPipeline_Zero(t *testing.T) {
+	var p Pipeline
+	msgs := p.Messages(context.Background(), nil, "See https://example.com")
+	if len(msgs) != 1 || msgs[0].Content != "See https://example.com" {
+		t.Errorf("messages = %+v, want the question as is", msgs)
+	}
+	if err := p.RecordUsage("s", "m", provider.Usage{PromptTokens: 1}); err != nil {
+		t.Errorf("RecordUsage() without sessions = %v", err)
+	}
+}
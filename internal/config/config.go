@@ -2,8 +2,12 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/retry"
 )
 
 // Config holds the application configuration.
@@ -16,28 +20,207 @@ type Config struct {
 	TUI         TUIConfig         `yaml:"tui"`
 	Language    string            `yaml:"language"`
 	Heartbeat   HeartbeatConfig   `yaml:"heartbeat"`
+	Settings    SettingsConfig    `yaml:"settings"`
+	Keybindings KeybindingsConfig `yaml:"keybindings"`
+	Sampling    SamplingConfig    `yaml:"sampling"`
+	Privacy     PrivacyConfig     `yaml:"privacy"`
+	Agent       AgentConfig       `yaml:"agent"`
+	Reminders   RemindersConfig   `yaml:"reminders"`
+	Jobs        []JobConfig       `yaml:"jobs"`
+	Notify      NotifyConfig      `yaml:"notify"`
+	Discord     DiscordConfig     `yaml:"discord"`
+	Slack       SlackConfig       `yaml:"slack"`
+	Bridge      BridgeConfig      `yaml:"bridge"`
+	Email       EmailConfig       `yaml:"email"`
+	Calendar    CalendarConfig    `yaml:"calendar"`
+	Home        HomeConfig        `yaml:"home_assistant"`
+	Voice       VoiceConfig       `yaml:"voice"`
+	Speech      SpeechConfig      `yaml:"speech"`
+	Knowledge   KnowledgeConfig   `yaml:"knowledge"`
+	Update      UpdateConfig      `yaml:"update"`
+	Proxy       ProxyConfig       `yaml:"proxy"`
+	Pipe        PipeConfig        `yaml:"pipe"`
 }
 
 // ProviderConfig holds provider settings.
 type ProviderConfig struct {
-	Default string       `yaml:"default"`
-	Ollama  OllamaConfig `yaml:"ollama"`
+	Default    string           `yaml:"default"`  // "ollama", "lmstudio", "vllm", "openrouter", "azure" or "mock"
+	Fallback   []FallbackConfig `yaml:"fallback"` // tried in order when the default fails
+	Retry      RetryConfig      `yaml:"retry"`
+	Parallel   int              `yaml:"parallel"` // requests sent at once, the rest wait in line; 0 for no limit
+	Ollama     OllamaConfig     `yaml:"ollama"`
+	LMStudio   LMStudioConfig   `yaml:"lmstudio"`
+	VLLM       VLLMConfig       `yaml:"vllm"`
+	OpenRouter OpenRouterConfig `yaml:"openrouter"`
+	Azure      AzureConfig      `yaml:"azure"`
+	Mock       MockConfig       `yaml:"mock"`
+}
+
+// FallbackConfig names a provider to retry a request with when the ones
+// before it are unreachable or fail.
+type FallbackConfig struct {
+	Name  string `yaml:"name"`
+	Model string `yaml:"model,omitempty"` // defaults to model.default
+}
+
+// RetryConfig says how often a chat request that failed for a passing
+// reason, such as a 5xx status or a dropped connection, is repeated before
+// giving up or falling back.
+type RetryConfig struct {
+	Attempts   int    `yaml:"attempts"`    // including the first; 1 disables retries
+	Backoff    string `yaml:"backoff"`     // wait before the first retry, doubled for each further one
+	MaxBackoff string `yaml:"max_backoff"` // longest wait between attempts
+}
+
+// Policy returns the retry policy. Durations that don't parse count as
+// zero; validation reports them.
+func (c RetryConfig) Policy() retry.Policy {
+	backoff, _ := time.ParseDuration(c.Backoff)
+	maxBackoff, _ := time.ParseDuration(c.MaxBackoff)
+	return retry.Policy{Attempts: c.Attempts, Backoff: backoff, MaxBackoff: maxBackoff}
+}
+
+// Uses reports whether the named provider is the default or a fallback.
+func (c ProviderConfig) Uses(name string) bool {
+	if c.Default == name {
+		return true
+	}
+	for _, f := range c.Fallback {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // OllamaConfig holds Ollama-specific settings.
 type OllamaConfig struct {
 	BaseURL   string `yaml:"base_url"`
 	MaxNumCtx int    `yaml:"max_num_ctx"`
+
+	// Loading a model takes Ollama 10 to 30 seconds, which the first message
+	// would otherwise wait.
+	Warm      bool   `yaml:"warm"`       // load the model when the TUI starts and on switching
+	KeepAlive string `yaml:"keep_alive"` // how long a model stays loaded after a request, e.g. "30m"; empty for Ollama's default
+
+	// For an Ollama behind a reverse proxy such as Caddy or Traefik.
+	APIKey   string            `yaml:"api_key"`  // sent as a bearer token; the key or a "keyring:<name>" reference
+	Username string            `yaml:"username"` // for basic auth
+	Password string            `yaml:"password"` // the password or a "keyring:<name>" reference
+	Headers  map[string]string `yaml:"headers"`  // sent with every request; values may be references
+	TLS      TLSConfig         `yaml:"tls"`
+}
+
+// TLSConfig says which certificates a server may present.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`              // PEM certificates to trust besides the system's, e.g. a private CA
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // accept any certificate; for testing only
+}
+
+// Settings returns the settings for opening the named provider with
+// provider.Open.
+func (c ProviderConfig) Settings(name string) provider.Settings {
+	switch name {
+	case "ollama", "":
+		o := c.Ollama
+		s := provider.Settings{
+			BaseURL:  o.BaseURL,
+			APIKey:   o.APIKey,
+			Username: o.Username,
+			Password: o.Password,
+			Headers:  o.Headers,
+			TLS:      provider.TLS{InsecureSkipVerify: o.TLS.InsecureSkipVerify},
+		}
+		if o.TLS.CAFile != "" {
+			s.TLS.CAFile = ExpandPath(o.TLS.CAFile, BaseDir())
+		}
+		s.KeepAlive, _ = time.ParseDuration(o.KeepAlive)
+		return s
+	case "lmstudio":
+		return provider.Settings{BaseURL: c.LMStudio.BaseURL}
+	case "vllm":
+		return provider.Settings{BaseURL: c.VLLM.BaseURL, APIKey: c.VLLM.APIKey}
+	case "openrouter":
+		return provider.Settings{BaseURL: c.OpenRouter.BaseURL, APIKey: c.OpenRouter.APIKey}
+	case "azure":
+		return provider.Settings{
+			BaseURL:    c.Azure.Endpoint,
+			APIKey:     c.Azure.APIKey,
+			APIVersion: c.Azure.APIVersion,
+			Models:     c.Azure.Deployments,
+		}
+	case "mock":
+		s := provider.Settings{}
+		if c.Mock.Dir != "" {
+			s.Fixtures = ExpandPath(c.Mock.Dir, BaseDir())
+		}
+		s.Delay, _ = time.ParseDuration(c.Mock.Delay)
+		return s
+	}
+	return provider.Settings{}
+}
+
+// BaseURL returns the endpoint of the default provider.
+func (c ProviderConfig) BaseURL() string {
+	return c.Settings(c.Default).BaseURL
+}
+
+// LMStudioConfig holds LM Studio-specific settings.
+type LMStudioConfig struct {
+	BaseURL string `yaml:"base_url"`
+}
+
+// VLLMConfig holds vLLM-specific settings.
+type VLLMConfig struct {
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"` // the --api-key vllm serve was started with, or a "keyring:<name>" reference
+}
+
+// OpenRouterConfig holds OpenRouter-specific settings.
+type OpenRouterConfig struct {
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"` // the key or a "keyring:<name>" reference
+}
+
+// AzureConfig holds Azure OpenAI-specific settings.
+type AzureConfig struct {
+	Endpoint    string   `yaml:"endpoint"` // the resource, e.g. https://my-resource.openai.azure.com
+	APIKey      string   `yaml:"api_key"`  // the key or a "keyring:<name>" reference
+	APIVersion  string   `yaml:"api_version"`
+	Deployments []string `yaml:"deployments"` // offered as models; model.default names one
+}
+
+// MockConfig holds settings of the mock provider, which replays canned
+// replies for demos and tests.
+type MockConfig struct {
+	Dir   string `yaml:"dir"`   // fixture directory; empty for the built-in replies
+	Delay string `yaml:"delay"` // between streamed words, e.g. "30ms"
 }
 
 // ModelConfig holds model settings.
 type ModelConfig struct {
-	Default string `yaml:"default"`
+	Default string           `yaml:"default"`
+	Tasks   TaskModelsConfig `yaml:"tasks"`
+}
+
+// TaskModelsConfig routes background tasks to their own models, e.g. a small
+// fast one while chat uses a larger one. Empty ones use the chat model.
+type TaskModelsConfig struct {
+	Compact string `yaml:"compact"` // summarizing the start of long conversations
+	Memory  string `yaml:"memory"`  // extracting facts to remember at exit
+	Title   string `yaml:"title"`   // naming new sessions; unset leaves them "New Chat"
 }
 
 // PersonalityConfig holds personality directory settings.
 type PersonalityConfig struct {
-	Dir string `yaml:"dir"`
+	Dir      string  `yaml:"dir"`
+	MaxShare float64 `yaml:"max_share"` // of max_num_ctx the personality files may take; 0 for no limit
+}
+
+// SystemPromptBudget returns the tokens the personality files may take in
+// the system prompt, or 0 for no limit.
+func (c Config) SystemPromptBudget() int {
+	return int(c.Personality.MaxShare * float64(c.Provider.Ollama.MaxNumCtx))
 }
 
 // SessionConfig holds session directory settings.
@@ -47,56 +230,479 @@ type SessionConfig struct {
 
 // MemoryConfig holds memory settings.
 type MemoryConfig struct {
-	Enabled        bool `yaml:"enabled"`
+	Enabled         bool `yaml:"enabled"`
 	MaxPromptTokens int  `yaml:"max_prompt_tokens"`
 }
 
+// AgentConfig controls the tool-use loop, in which the model may call tools
+// (web search, fetch, memory, files) before giving its final answer.
+type AgentConfig struct {
+	Enabled     bool             `yaml:"enabled"`
+	MaxSteps    int              `yaml:"max_steps"`    // tool calls allowed per user message
+	NativeTools bool             `yaml:"native_tools"` // pass tools natively to providers that support it
+	ReadFile    ReadFileConfig   `yaml:"read_file"`
+	WriteFile   WriteFileConfig  `yaml:"write_file"`
+	RunCommand  RunCommandConfig `yaml:"run_command"`
+	RunCode     RunCodeConfig    `yaml:"run_code"`
+	Terminal    TerminalConfig   `yaml:"terminal"`
+	OCR         OCRConfig        `yaml:"ocr"`
+}
+
+// ReadFileConfig controls which files the read_file tool may read.
+type ReadFileConfig struct {
+	AllowedDirs []string `yaml:"allowed_dirs"` // "~" and paths relative to the start directory are expanded
+	MaxSize     int      `yaml:"max_size"`     // bytes
+}
+
+// WriteFileConfig controls the write_file and edit_file tools. They are off
+// by default and every change is shown as a diff for the user to approve.
+type WriteFileConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	AllowedDirs []string `yaml:"allowed_dirs"` // expanded like ReadFileConfig.AllowedDirs
+}
+
+// RunCodeConfig controls the run_code tool, which runs Python snippets with
+// time and memory limits. It is off by default and every run needs the
+// user's approval.
+type RunCodeConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Timeout string `yaml:"timeout"` // e.g., "10s"
+	Confirm bool   `yaml:"confirm"` // ignored, every run is confirmed; kept so configs that set it still load
+}
+
+// RunCommandConfig controls the run_command tool. It is off by default and
+// every command needs the user's approval before it runs.
+type RunCommandConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Timeout string `yaml:"timeout"` // e.g., "30s", "2m"
+}
+
+// TerminalConfig controls the terminal tool and /terminal, which show the
+// model the output of the last tmux pane or recent shell history. It is off
+// by default.
+type TerminalConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Lines   int  `yaml:"lines"` // lines of pane output or history to capture
+}
+
+// OCRConfig controls the ocr tool and /ocr, which read the text in an image
+// or the latest screenshot. It is off by default.
+type OCRConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	Engine        string `yaml:"engine"`         // "auto", "tesseract" or "vision"
+	Language      string `yaml:"language"`       // tesseract languages, e.g. "eng+deu"
+	VisionModel   string `yaml:"vision_model"`   // Ollama model that can read images, e.g. "llava"
+	ScreenshotDir string `yaml:"screenshot_dir"` // empty looks in the usual places
+}
+
+// PrivacyConfig switches off capabilities that reach outside the machine or
+// act without being asked. All default to false, i.e. everything enabled.
+type PrivacyConfig struct {
+	DisableWeb        bool `yaml:"disable_web"`         // web fetch, search, URL auto-fetch and update checks
+	DisableAutoMemory bool `yaml:"disable_auto_memory"` // automatic fact extraction into MEMORY.md
+	DisableHeartbeat  bool `yaml:"disable_heartbeat"`   // proactive heartbeat check-ins
+}
+
+// SamplingConfig holds the default sampling options sent with every chat
+// request. Entries under Models override the defaults for a single model.
+type SamplingConfig struct {
+	provider.Options `yaml:",inline"`
+	Models           map[string]provider.Options `yaml:"models,omitempty"`
+}
+
+// For returns the sampling options for model: the defaults merged with the
+// model's overrides.
+func (s SamplingConfig) For(model string) provider.Options {
+	return s.Options.Merge(s.Models[model])
+}
+
 // TUIConfig holds TUI settings.
 type TUIConfig struct {
-	Theme string `yaml:"theme"`
+	Theme          string       `yaml:"theme"` // markdown style: "auto" or a glamour style name
+	Colors         ColorsConfig `yaml:"colors"`
+	UserLabel      LabelStyle   `yaml:"user_label"`
+	AssistantLabel LabelStyle   `yaml:"assistant_label"`
+	ShowThinking   bool         `yaml:"show_thinking"` // expand the reasoning of models such as qwen3 instead of one line
+}
+
+// ColorsConfig holds the TUI color palette. Colors are hex values ("#7C3AED")
+// or ANSI color numbers ("205").
+type ColorsConfig struct {
+	Primary   string `yaml:"primary"`
+	Secondary string `yaml:"secondary"`
+	Success   string `yaml:"success"`
+	Error     string `yaml:"error"`
+}
+
+// LabelStyle styles the "You:" and "Assistant:" labels. An empty color uses
+// the palette color for the role.
+type LabelStyle struct {
+	Color     string `yaml:"color"`
+	Bold      bool   `yaml:"bold"`
+	Italic    bool   `yaml:"italic"`
+	Underline bool   `yaml:"underline"`
 }
 
 // HeartbeatConfig holds heartbeat settings.
 type HeartbeatConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	Interval      string `yaml:"interval"`        // e.g., "1h", "30m", "24h"
+	ActiveHours   string `yaml:"active_hours"`    // e.g. "08:00-22:00"; empty allows any time
+	SkipIfActive  string `yaml:"skip_if_active"`  // no check-in if the user was active this recently, e.g. "15m"; empty never skips
+	SkipOnBattery bool   `yaml:"skip_on_battery"` // no check-ins while the machine runs on battery
+	AutoTune      bool   `yaml:"auto_tune"`       // lengthen the interval when check-ins keep having nothing to say
+	// Tools lists the tools check-ins may call. Tools that need approval
+	// are never offered, even if listed.
+	Tools []string `yaml:"tools"`
+	// Schedules are named check-ins with their own timing and prompt, run
+	// besides the idle check-in while heartbeats are enabled.
+	Schedules []HeartbeatSchedule `yaml:"schedules"`
+}
+
+// HeartbeatSchedule is a named check-in, e.g. a morning briefing or a weekly
+// review.
+type HeartbeatSchedule struct {
+	Name        string `yaml:"name"`
+	Schedule    string `yaml:"schedule"`               // interval such as "1h" or cron expression such as "0 8 * * *"
+	ActiveHours string `yaml:"active_hours,omitempty"` // overrides heartbeat.active_hours
+	Prompt      string `yaml:"prompt"`                 // Go template; see heartbeat.RenderPrompt
+}
+
+// RemindersConfig controls how due reminders are delivered.
+type RemindersConfig struct {
+	PhraseWithModel bool `yaml:"phrase_with_model"` // let the model word due reminders
+}
+
+// JobConfig describes an automation job: a prompt run on a cron schedule
+// whose answer is delivered to Output.
+type JobConfig struct {
+	Name     string `yaml:"name"`
+	Schedule string `yaml:"schedule"` // cron expression, e.g. "0 8 * * *"
+	Prompt   string `yaml:"prompt"`
+	Output   string `yaml:"output"`          // "notification" (default), "file" or "memory"
+	Path     string `yaml:"path,omitempty"`  // file to append to for output "file"
+	Model    string `yaml:"model,omitempty"` // defaults to model.default
+}
+
+// NotifyConfig holds outbound webhook notifications.
+type NotifyConfig struct {
+	// LongResponse is how long a reply must take before it triggers a
+	// "response" event, e.g. "1m".
+	LongResponse string `yaml:"long_response"`
+	// OnlyWhenUnfocused holds back notifications while the terminal has
+	// focus (needs a terminal that reports focus changes).
+	OnlyWhenUnfocused bool             `yaml:"only_when_unfocused"`
+	Webhooks          []WebhookConfig  `yaml:"webhooks"`
+	Tool              NotifyToolConfig `yaml:"tool"`
+}
+
+// NotifyToolConfig controls the notify tool, which lets the model send a
+// notification on its own, e.g. from a heartbeat check-in. It is off by
+// default.
+type NotifyToolConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	Categories []string `yaml:"categories"` // what the model may notify about; it must pick one
+	PerHour    int      `yaml:"per_hour"`   // notifications allowed per hour
+	Desktop    bool     `yaml:"desktop"`    // also show an OS notification
+}
+
+// WebhookConfig describes one webhook. Events lists the events it receives
+// (heartbeat, reminder, job, response); empty means all of them.
+type WebhookConfig struct {
+	URL    string   `yaml:"url"`
+	Format string   `yaml:"format"`          // "json" (default), "ntfy" or "gotify"
+	Token  string   `yaml:"token,omitempty"` // bearer token or Gotify app token; may be a keyring: reference
+	Events []string `yaml:"events,omitempty"`
+}
+
+// DiscordConfig configures the Discord bot bridge (stefanclaw discord). Each
+// DM or channel gets its own session.
+type DiscordConfig struct {
+	Token        string   `yaml:"token"`         // bot token; may be a keyring: reference
+	Channels     []string `yaml:"channels"`      // channel IDs to answer in besides DMs
+	AllowedUsers []string `yaml:"allowed_users"` // user IDs the bot answers
+}
+
+// SlackConfig configures the Slack app bridge (stefanclaw slack), which
+// connects over Socket Mode. Each DM or channel gets its own session.
+type SlackConfig struct {
+	AppToken     string   `yaml:"app_token"`     // xapp- token; may be a keyring: reference
+	BotToken     string   `yaml:"bot_token"`     // xoxb- token; may be a keyring: reference
+	Channels     []string `yaml:"channels"`      // channel IDs to answer in besides DMs
+	AllowedUsers []string `yaml:"allowed_users"` // user IDs the app answers
+}
+
+// BridgeConfig configures the local HTTP bridge (stefanclaw serve --bridge)
+// that browser extensions and editor plugins send text to.
+type BridgeConfig struct {
+	Listen string `yaml:"listen"` // loopback address and port
+	Token  string `yaml:"token"`  // bearer token clients must send; may be a keyring: reference
+}
+
+// EmailConfig configures SMTP delivery for jobs with output: email and,
+// optionally, heartbeat check-ins.
+type EmailConfig struct {
+	SMTPHost   string `yaml:"smtp_host"` // empty disables email
+	SMTPPort   int    `yaml:"smtp_port"` // 465 for implicit TLS, otherwise STARTTLS if offered
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"` // may be a keyring: reference
+	From       string `yaml:"from"`
+	To         string `yaml:"to"` // comma-separated recipients
+	Heartbeats bool   `yaml:"heartbeats"`
+}
+
+// CalendarConfig lists the ICS calendars whose events the assistant knows
+// about.
+type CalendarConfig struct {
+	Sources []string `yaml:"sources"` // http(s) or webcal URLs, or file paths
+	Remind  string   `yaml:"remind"`  // announce events this long before they start; "0s" disables
+	Refresh string   `yaml:"refresh"` // how often URLs and files are re-read
+}
+
+// HomeConfig connects the home tools to a Home Assistant instance. Only the
+// listed entities are visible to the model, and only those in Control can
+// be switched, each time after the user approves.
+type HomeConfig struct {
+	URL      string   `yaml:"url"`      // e.g. http://homeassistant.local:8123; empty disables
+	Token    string   `yaml:"token"`    // long-lived access token; may be a keyring: reference
+	Entities []string `yaml:"entities"` // entity IDs the model may read; "light.*" matches a domain
+	Control  []string `yaml:"control"`  // entity IDs the model may switch, matched the same way
+}
+
+// VoiceConfig controls push-to-talk voice input. Speech is recorded with
+// a local recorder (arecord, sox or ffmpeg) and transcribed by a Whisper
+// server.
+type VoiceConfig struct {
 	Enabled  bool   `yaml:"enabled"`
-	Interval string `yaml:"interval"` // e.g., "1h", "30m", "24h"
+	Backend  string `yaml:"backend"`  // "whisper.cpp" or "openai" (any OpenAI-compatible transcription server)
+	URL      string `yaml:"url"`      // base URL of the transcription server
+	Model    string `yaml:"model"`    // model name sent to openai backends
+	Language string `yaml:"language"` // spoken language, e.g. "de"; empty detects it
+	Recorder string `yaml:"recorder"` // recording command with {file} for the WAV path; empty picks one
+}
+
+// SpeechConfig controls reading replies aloud. /speak turns it on and off
+// for the session.
+type SpeechConfig struct {
+	Enabled    bool   `yaml:"enabled"`     // speak replies from startup
+	Backend    string `yaml:"backend"`     // "auto", "piper", "say" or "espeak"
+	Voice      string `yaml:"voice"`       // voice for say and espeak, e.g. "Anna" or "de"
+	PiperModel string `yaml:"piper_model"` // .onnx voice for piper
+}
+
+// KnowledgeConfig sets up the knowledge base: local notes and documents
+// that /kb ask and automatic retrieval search by meaning.
+type KnowledgeConfig struct {
+	Dirs         []string `yaml:"dirs"`          // directories to index; "~" is the home directory
+	Extensions   []string `yaml:"extensions"`    // file types to index, e.g. ".md"
+	EmbedModel   string   `yaml:"embed_model"`   // Ollama embedding model
+	ChunkSize    int      `yaml:"chunk_size"`    // passage length in bytes
+	ChunkOverlap int      `yaml:"chunk_overlap"` // bytes repeated between passages
+	TopK         int      `yaml:"top_k"`         // passages added per question
+	MinScore     float64  `yaml:"min_score"`     // least similarity (0-1) for a passage to be used
+	AutoRetrieve bool     `yaml:"auto_retrieve"` // search for every message, not just /kb ask
+}
+
+// SettingsConfig holds interactive behavior settings.
+type SettingsConfig struct {
+	// Autosave persists changes made via slash commands (/model, /language)
+	// immediately instead of waiting for /save. /heartbeat is always saved.
+	Autosave bool `yaml:"autosave"`
+}
+
+// UpdateConfig controls which releases /update and the startup check offer.
+type UpdateConfig struct {
+	// Channel is "stable" for releases only, "beta" to also get betas and
+	// release candidates, or "nightly" for every build.
+	Channel string `yaml:"channel"`
+	// Check looks for a new release on startup, at most once a day.
+	Check bool `yaml:"check"`
+}
+
+// ProxyConfig routes outbound HTTP requests through a proxy. Without a
+// URL, HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment apply.
+type ProxyConfig struct {
+	URL     string `yaml:"url"`      // http://, https:// or socks5:// proxy, optionally with user:password@
+	NoProxy string `yaml:"no_proxy"` // hosts to reach directly, comma-separated; replaces NO_PROXY
+}
+
+// PipeConfig controls --pipe mode.
+type PipeConfig struct {
+	// CacheTTL is how long a reply is reused for an identical prompt, so
+	// scripts asking the same question again get it at once; "0s" turns the
+	// cache off.
+	CacheTTL string `yaml:"cache_ttl"`
+}
+
+// KeybindingsConfig maps TUI actions to key names as understood by Bubble Tea
+// (e.g. "enter", "ctrl+c", "alt+enter"). Empty actions use the default keys.
+type KeybindingsConfig struct {
+	Submit       []string `yaml:"submit"`
+	Newline      []string `yaml:"newline"`
+	Stop         []string `yaml:"stop"`
+	Palette      []string `yaml:"palette"`
+	Picker       []string `yaml:"picker"`
+	HalfPageUp   []string `yaml:"half_page_up"`
+	HalfPageDown []string `yaml:"half_page_down"`
+	Yank         []string `yaml:"yank"`
+	Voice        []string `yaml:"voice"`
 }
 
 // Defaults returns a Config with sensible defaults.
 func Defaults() Config {
 	return Config{
 		Provider: ProviderConfig{
-			Default: "ollama",
+			Default:  "ollama",
+			Fallback: []FallbackConfig{},
+			Parallel: 1,
+			Retry: RetryConfig{
+				Attempts:   3,
+				Backoff:    "1s",
+				MaxBackoff: "10s",
+			},
 			Ollama: OllamaConfig{
 				BaseURL:   "http://127.0.0.1:11434",
 				MaxNumCtx: 32768,
+				Warm:      true,
+			},
+			LMStudio: LMStudioConfig{
+				BaseURL: "http://127.0.0.1:1234",
+			},
+			VLLM: VLLMConfig{
+				BaseURL: "http://127.0.0.1:8000",
+			},
+			OpenRouter: OpenRouterConfig{
+				BaseURL: "https://openrouter.ai/api/v1",
+			},
+			Azure: AzureConfig{
+				APIVersion:  "2024-10-21",
+				Deployments: []string{},
+			},
+			Mock: MockConfig{
+				Delay: "30ms",
 			},
 		},
 		Model: ModelConfig{
 			Default: "qwen3:8b",
 		},
 		Personality: PersonalityConfig{
-			Dir: "personality",
+			Dir:      "personality",
+			MaxShare: 0.25,
 		},
 		Session: SessionConfig{
 			Dir: "sessions",
 		},
 		Memory: MemoryConfig{
-			Enabled:        true,
+			Enabled:         true,
 			MaxPromptTokens: 2000,
 		},
 		TUI: TUIConfig{
 			Theme: "auto",
+			Colors: ColorsConfig{
+				Primary:   "#7C3AED",
+				Secondary: "#6B7280",
+				Success:   "#10B981",
+				Error:     "#EF4444",
+			},
+			UserLabel:      LabelStyle{Bold: true},
+			AssistantLabel: LabelStyle{Bold: true},
 		},
 		Language: DetectLanguage(),
 		Heartbeat: HeartbeatConfig{
 			Enabled:  false,
 			Interval: "4h",
+			Tools:    []string{"time", "date_calc", "convert", "web_search", "fetch", "memory_search", "todo_list", "calendar", "home_state"},
+		},
+		Agent: AgentConfig{
+			Enabled:     true,
+			MaxSteps:    5,
+			NativeTools: true,
+			ReadFile: ReadFileConfig{
+				AllowedDirs: []string{"."},
+				MaxSize:     64 * 1024,
+			},
+			WriteFile: WriteFileConfig{
+				Enabled:     false,
+				AllowedDirs: []string{"."},
+			},
+			RunCommand: RunCommandConfig{
+				Enabled: false,
+				Timeout: "30s",
+			},
+			RunCode: RunCodeConfig{
+				Enabled: false,
+				Timeout: "10s",
+			},
+			Terminal: TerminalConfig{
+				Enabled: false,
+				Lines:   100,
+			},
+			OCR: OCRConfig{
+				Enabled:  false,
+				Engine:   "auto",
+				Language: "eng",
+			},
+		},
+		Sampling: SamplingConfig{
+			Options: provider.Options{
+				Temperature:   floatPtr(0.8),
+				TopP:          floatPtr(0.9),
+				RepeatPenalty: floatPtr(1.1),
+			},
+		},
+		Notify: NotifyConfig{
+			LongResponse: "1m",
+			Tool: NotifyToolConfig{
+				Enabled:    false,
+				Categories: []string{"reminder", "alert"},
+				PerHour:    4,
+				Desktop:    true,
+			},
+		},
+		Email: EmailConfig{
+			SMTPPort: 587,
+		},
+		Calendar: CalendarConfig{
+			Remind:  "15m",
+			Refresh: "15m",
+		},
+		Voice: VoiceConfig{
+			Enabled: false,
+			Backend: "whisper.cpp",
+			URL:     "http://127.0.0.1:8080",
+			Model:   "whisper-1",
+		},
+		Speech: SpeechConfig{
+			Enabled: false,
+			Backend: "auto",
+		},
+		Bridge: BridgeConfig{
+			Listen: "127.0.0.1:8477",
+		},
+		Knowledge: KnowledgeConfig{
+			Extensions: []string{".md", ".markdown", ".txt", ".org", ".rst",
+				".go", ".py", ".js", ".ts", ".rs", ".java", ".c", ".h", ".sh", ".yaml", ".toml"},
+			EmbedModel:   "nomic-embed-text",
+			ChunkSize:    1500,
+			ChunkOverlap: 200,
+			TopK:         4,
+			MinScore:     0.5,
+		},
+		Update: UpdateConfig{
+			Channel: "stable",
+			Check:   true,
+		},
+		Pipe: PipeConfig{
+			CacheTTL: "0s",
 		},
 	}
 }
 
+func floatPtr(v float64) *float64 { return &v }
+
 // Load reads the config from disk. If the file doesn't exist, returns defaults.
+// Problems in the file are reported as ValidationErrors naming the key and
+// line; the returned config then holds whatever could be decoded.
 func Load() (Config, error) {
 	cfg := Defaults()
 
@@ -108,8 +714,11 @@ func Load() (Config, error) {
 		return cfg, err
 	}
 
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return Defaults(), err
+	if err := parseConfig(data, &cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Language == LanguageAuto {
+		cfg.Language = DetectLanguage()
 	}
 
 	return cfg, nil
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+// defaultConfigTemplate is the commented config.yaml written by `config init`.
+// Values are filled in from Defaults so the two cannot drift apart.
+var defaultConfigTemplate = template.Must(template.New("config").Parse(`# stefanclaw configuration
+# Edits are picked up live while the TUI is running.
+
+provider:
+  # ollama, lmstudio, vllm, openrouter, azure or mock
+  default: {{.Provider.Default}}
+  # Providers to retry a request with, in order, when the default one is
+  # unreachable or fails. model defaults to model.default.
+  fallback: []
+  #  - name: openrouter
+  #    model: qwen/qwen3-8b
+  # Chat requests failing for a passing reason (5xx status, refused or
+  # dropped connection, timeout) are repeated, waiting backoff and then
+  # twice as long each time, up to max_backoff. 1 attempt disables retries.
+  retry:
+    attempts: {{.Provider.Retry.Attempts}}
+    backoff: {{.Provider.Retry.Backoff}}
+    max_backoff: {{.Provider.Retry.MaxBackoff}}
+  # Requests sent to the provider at once; heartbeats, session titles and
+  # the like wait in line behind your messages (see /queue). 1 suits Ollama,
+  # which answers one request at a time by default. 0 sends all at once.
+  parallel: {{.Provider.Parallel}}
+  ollama:
+    # Ollama endpoint. Overridden by --ollama-url and OLLAMA_HOST.
+    base_url: {{.Provider.Ollama.BaseURL}}
+    # Upper bound for adaptive context scaling (tokens).
+    max_num_ctx: {{.Provider.Ollama.MaxNumCtx}}
+    # Load the model when the TUI starts and after switching models, so
+    # the first message doesn't wait for it.
+    warm: {{.Provider.Ollama.Warm}}
+    # How long Ollama keeps a model loaded after a request, e.g. 30m, or
+    # -1s for good. Empty leaves it to Ollama (5m, or OLLAMA_KEEP_ALIVE).
+    keep_alive: "{{.Provider.Ollama.KeepAlive}}"
+    # For an Ollama behind a reverse proxy with authentication: a bearer
+    # token (ideally keyring:ollama, stored with "stefanclaw secret set
+    # ollama"), or username and password for basic auth, and any headers.
+    # api_key: keyring:ollama
+    # username: me
+    # password: keyring:ollama-password
+    # headers:
+    #   CF-Access-Client-Id: keyring:cf-access-id
+    # tls:
+    #   ca_file: ~/.config/stefanclaw/ca.pem   # trust a private CA
+    #   insecure_skip_verify: false            # accept any certificate (testing only)
+  lmstudio:
+    # LM Studio local server, started from its Developer tab.
+    base_url: {{.Provider.LMStudio.BaseURL}}
+  vllm:
+    # vLLM's OpenAI-compatible server, started with "vllm serve <model>".
+    base_url: {{.Provider.VLLM.BaseURL}}
+    # The key given to vllm serve with --api-key, if any, ideally stored
+    # with "stefanclaw secret set vllm" and given as keyring:vllm.
+    api_key: "{{.Provider.VLLM.APIKey}}"
+  openrouter:
+    base_url: {{.Provider.OpenRouter.BaseURL}}
+    # API key from openrouter.ai/keys, ideally stored with
+    # "stefanclaw secret set openrouter" and given as keyring:openrouter.
+    api_key: "{{.Provider.OpenRouter.APIKey}}"
+  azure:
+    # Azure OpenAI resource, e.g. https://my-resource.openai.azure.com
+    endpoint: "{{.Provider.Azure.Endpoint}}"
+    # One of the resource's keys, ideally stored with
+    # "stefanclaw secret set azure" and given as keyring:azure.
+    api_key: "{{.Provider.Azure.APIKey}}"
+    api_version: {{.Provider.Azure.APIVersion}}
+    # Names of the deployments to use as models; model.default names one.
+    deployments: []
+    #  - gpt-4o
+    #  - gpt-4o-mini
+  mock:
+    # Canned replies for demos and tests, used with --provider mock.
+    # Fixture directory (see README); empty for the built-in replies.
+    dir: "{{.Provider.Mock.Dir}}"
+    # Pause between streamed words.
+    delay: {{.Provider.Mock.Delay}}
+
+model:
+  # Model used for chat. Change at runtime with /model.
+  default: {{.Model.Default}}
+  # Models for background tasks, e.g. a small fast one. Empty ones use the
+  # chat model.
+  tasks:
+    # Summarizing the start of long conversations.
+    compact: "{{.Model.Tasks.Compact}}"
+    # Extracting facts to remember when you quit.
+    memory: "{{.Model.Tasks.Memory}}"
+    # Naming new sessions after the first reply; empty leaves them "New Chat".
+    title: "{{.Model.Tasks.Title}}"
+
+personality:
+  dir: {{.Personality.Dir}}
+  # Share of max_num_ctx the personality files may take in the system
+  # prompt; beyond it BOOT.md, then MEMORY.md and so on are trimmed.
+  # /prompt stats shows the sizes. 0 turns trimming off.
+  max_share: {{.Personality.MaxShare}}
+
+session:
+  dir: {{.Session.Dir}}
+
+memory:
+  enabled: {{.Memory.Enabled}}
+  # Maximum tokens of MEMORY.md sent with each heartbeat check-in.
+  max_prompt_tokens: {{.Memory.MaxPromptTokens}}
+
+# Language the assistant responds in.
+language: {{.Language}}
+
+heartbeat:
+  # Proactive check-ins while the TUI is idle.
+  enabled: {{.Heartbeat.Enabled}}
+  interval: {{.Heartbeat.Interval}}
+  # Local hours check-ins may run in, e.g. "08:00-22:00"; empty for any time.
+  active_hours: "{{.Heartbeat.ActiveHours}}"
+  # Skip a check-in if you typed something within this time, e.g. "15m".
+  skip_if_active: "{{.Heartbeat.SkipIfActive}}"
+  # Skip check-ins while the machine runs on battery.
+  skip_on_battery: {{.Heartbeat.SkipOnBattery}}
+  # Double the interval (up to 24h) after 3 check-ins in a row had nothing
+  # to say. /heartbeat stats shows how check-ins fare.
+  auto_tune: {{.Heartbeat.AutoTune}}
+  # Tools check-ins may use, e.g. to look at a page, your calendar or the
+  # home. Keep this to read-only tools; any that need approval are left out.
+  tools:{{range .Heartbeat.Tools}}
+    - {{.}}{{end}}
+  # Named check-ins with their own timing (an interval or a cron expression)
+  # and prompt. The prompt may use {{"{{"}}.date{{"}}"}}, {{"{{"}}.weekday{{"}}"}} and {{"{{"}}.time{{"}}"}}.
+  schedules: []
+  #  - name: morning
+  #    schedule: "0 8 * * *"
+  #    prompt: It's {{"{{"}}.date{{"}}"}}. Give me a short briefing on my day.
+  #  - name: nudge
+  #    schedule: 1h
+  #    active_hours: "09:00-17:00"
+  #    prompt: Nudge me to stretch if I've been at it for a while.
+
+settings:
+  # Persist /model and /language changes without /save (/heartbeat always is).
+  autosave: {{.Settings.Autosave}}
+
+proxy:
+  # Proxy for outbound requests (OpenRouter, web fetches, updates, ...):
+  # an http://, https:// or socks5:// URL, e.g. http://proxy.corp:3128.
+  # Empty uses HTTPS_PROXY and HTTP_PROXY. localhost is never proxied.
+  url: "{{.Proxy.URL}}"
+  # Hosts reached directly, comma-separated, e.g. .corp.example.com,10.0.0.0/8.
+  # Empty uses NO_PROXY.
+  no_proxy: "{{.Proxy.NoProxy}}"
+
+update:
+  # Releases offered by /update: stable, beta (also betas and release
+  # candidates) or nightly (every build).
+  channel: {{.Update.Channel}}
+  # Look for a new release on startup, at most once a day.
+  check: {{.Update.Check}}
+
+pipe:
+  # Reuse the reply to an identical --pipe prompt for this long, e.g. 10m,
+  # so scripts in shell prompts or cron don't ask the model again. 0s is off.
+  cache_ttl: {{.Pipe.CacheTTL}}
+
+tui:
+  # Markdown style: auto, dark, light, dracula, tokyo-night, ...
+  theme: {{.TUI.Theme}}
+  # Hex colors ("#7C3AED") or ANSI color numbers ("205").
+  colors:
+    primary: "{{.TUI.Colors.Primary}}"
+    secondary: "{{.TUI.Colors.Secondary}}"
+    success: "{{.TUI.Colors.Success}}"
+    error: "{{.TUI.Colors.Error}}"
+  user_label:
+    color: "{{.TUI.UserLabel.Color}}"
+    bold: {{.TUI.UserLabel.Bold}}
+    italic: {{.TUI.UserLabel.Italic}}
+    underline: {{.TUI.UserLabel.Underline}}
+  assistant_label:
+    color: "{{.TUI.AssistantLabel.Color}}"
+    bold: {{.TUI.AssistantLabel.Bold}}
+    italic: {{.TUI.AssistantLabel.Italic}}
+    underline: {{.TUI.AssistantLabel.Underline}}
+  # Show the reasoning models such as qwen3 write in <think> tags in full,
+  # dimmed, instead of as one line. Toggle at runtime with /think.
+  show_thinking: {{.TUI.ShowThinking}}
+
+# Key names as understood by Bubble Tea; empty lists use the defaults.
+keybindings:
+  submit: []          # enter
+  newline: []         # alt+enter
+  stop: []            # ctrl+c
+  palette: []         # ctrl+p
+  picker: []          # ctrl+o
+  half_page_up: []    # ctrl+u
+  half_page_down: []  # ctrl+d
+  yank: []            # ctrl+y
+  voice: []           # ctrl+r
+
+# Sampling options sent with every chat request. Precedence: flags >
+# /sampling session overrides > sampling.models.<model> > these defaults.
+sampling:
+  temperature: {{.Sampling.Get "temperature"}}
+  top_p: {{.Sampling.Get "top_p"}}
+  repeat_penalty: {{.Sampling.Get "repeat_penalty"}}
+  # num_predict: -1   # max tokens to generate (-1 = no limit)
+  # seed: 42          # fixed seed for reproducible output
+  # models:
+  #   qwen3:8b:
+  #     temperature: 0.6
+
+agent:
+  # Let the model call tools (web search, fetch, memory, read_file) on its own.
+  enabled: {{.Agent.Enabled}}
+  # Tool calls allowed per message before it must answer.
+  max_steps: {{.Agent.MaxSteps}}
+  # Pass the tools to models with native tool calling (Ollama) instead of
+  # describing them in the system prompt. Models without tool support get
+  # the description either way.
+  native_tools: {{.Agent.NativeTools}}
+  read_file:
+    # Directories the model may read files from. "~" is your home
+    # directory and "." the directory stefanclaw was started in.
+    allowed_dirs:{{range .Agent.ReadFile.AllowedDirs}}
+      - "{{.}}"{{end}}
+    # Larger files are refused (bytes).
+    max_size: {{.Agent.ReadFile.MaxSize}}
+  write_file:
+    # Let the model create and edit files (write_file, edit_file). Each
+    # change is shown as a diff and needs your approval.
+    enabled: {{.Agent.WriteFile.Enabled}}
+    allowed_dirs:{{range .Agent.WriteFile.AllowedDirs}}
+      - "{{.}}"{{end}}
+  run_command:
+    # Let the model propose shell commands. Each one needs your approval
+    # and never runs during heartbeat check-ins.
+    enabled: {{.Agent.RunCommand.Enabled}}
+    # Commands are killed after this long.
+    timeout: {{.Agent.RunCommand.Timeout}}
+  run_code:
+    # Let the model run short Python programs for math, dates and data
+    # munging. Needs python3; runs with CPU, memory and time limits. Python
+    # can still reach your files and the network, so each run needs your
+    # approval and never happens in jobs or heartbeat check-ins.
+    enabled: {{.Agent.RunCode.Enabled}}
+    timeout: {{.Agent.RunCode.Timeout}}
+  terminal:
+    # Let the model and /terminal read the output of your last tmux pane,
+    # or your recent shell history outside tmux, e.g. to explain why a
+    # command failed. History can contain secrets, so this is opt-in.
+    enabled: {{.Agent.Terminal.Enabled}}
+    # How many lines to capture.
+    lines: {{.Agent.Terminal.Lines}}
+  ocr:
+    # Let the model and /ocr read the text in an image or your latest
+    # screenshot ("what does this error dialog say?"). Opt-in, since
+    # screenshots can show anything.
+    enabled: {{.Agent.OCR.Enabled}}
+    # auto (tesseract if installed, else the vision model), tesseract or
+    # vision.
+    engine: {{.Agent.OCR.Engine}}
+    # Tesseract languages, e.g. "eng+deu".
+    language: {{.Agent.OCR.Language}}
+    # Ollama model that can read images, e.g. "llava" or "qwen2.5vl".
+    vision_model: "{{.Agent.OCR.VisionModel}}"
+    # Where screenshots land; empty looks in ~/Pictures/Screenshots,
+    # ~/Pictures and ~/Desktop.
+    screenshot_dir: "{{.Agent.OCR.ScreenshotDir}}"
+
+reminders:
+  # Besides the plain reminder, ask the model to word it (in context).
+  phrase_with_model: {{.Reminders.PhraseWithModel}}
+
+# Prompts run on a cron schedule (minute hour day month weekday), in the
+# TUI or with "stefanclaw jobs daemon". Output: notification, file or memory.
+jobs: []
+#  - name: morning-digest
+#    schedule: "0 8 * * *"
+#    prompt: Search the web for today's Go and AI news and summarize it in five bullets.
+#    output: file
+#    path: ~/notes/digest.md
+
+notify:
+  # Webhooks that reach you when the terminal isn't in view. Events:
+  # heartbeat (a check-in said something), reminder (one fell due), job (a
+  # job finished), response (a reply took at least long_response) and
+  # assistant (the model used the notify tool).
+  long_response: {{.Notify.LongResponse}}
+  # Only notify while the terminal is unfocused (if it reports focus).
+  only_when_unfocused: {{.Notify.OnlyWhenUnfocused}}
+  webhooks: []
+  #  - url: https://ntfy.sh/my-stefanclaw-topic
+  #    format: ntfy           # json, ntfy or gotify
+  #    events: [heartbeat, reminder, job]
+  #  - url: https://gotify.example.com
+  #    format: gotify
+  #    token: keyring:gotify  # see "stefanclaw secret set"
+  tool:
+    # Let the model send notifications itself (event "assistant" for
+    # webhooks), e.g. when a heartbeat finds something urgent.
+    enabled: {{.Notify.Tool.Enabled}}
+    # What it may notify you about; each notification names one of these.
+    categories:{{range .Notify.Tool.Categories}}
+      - "{{.}}"{{end}}
+    # At most this many notifications an hour.
+    per_hour: {{.Notify.Tool.PerHour}}
+    # Also show a desktop notification (notify-send or macOS Notification
+    # Center).
+    desktop: {{.Notify.Tool.Desktop}}
+
+email:
+  # SMTP server for jobs with output: email. Leave smtp_host empty to
+  # disable. Port 465 uses TLS, other ports STARTTLS when offered.
+  smtp_host: "{{.Email.SMTPHost}}"
+  smtp_port: {{.Email.SMTPPort}}
+  username: "{{.Email.Username}}"
+  password: "{{.Email.Password}}"   # e.g. keyring:smtp
+  from: "{{.Email.From}}"
+  to: "{{.Email.To}}"               # comma-separated
+  # Also mail heartbeat check-ins that have something to say.
+  heartbeats: {{.Email.Heartbeats}}
+
+calendar:
+  # ICS calendars (http, https or webcal URLs, or .ics files) whose events
+  # appear in the system prompt and the calendar tool.
+  sources: []
+  # Announce events this long before they start; "0s" turns this off.
+  remind: {{.Calendar.Remind}}
+  # How often calendars are re-read.
+  refresh: {{.Calendar.Refresh}}
+
+home_assistant:
+  # Let the model read (and, with your approval, switch) Home Assistant
+  # entities. Create a long-lived access token in your profile, store it
+  # with "stefanclaw secret set home_assistant" and use keyring:home_assistant.
+  url: "{{.Home.URL}}"
+  token: "{{.Home.Token}}"
+  # Entities the model may read, e.g. binary_sensor.front_door or light.*
+  entities: []
+  # Entities the model may turn on, off or toggle.
+  control: []
+
+voice:
+  # Push-to-talk: press ctrl+r (keybindings.voice) to start and stop
+  # recording; the transcription lands in the input box for review.
+  enabled: {{.Voice.Enabled}}
+  # "whisper.cpp" (its server) or "openai" (any OpenAI-compatible
+  # /v1/audio/transcriptions server, e.g. faster-whisper-server).
+  backend: {{.Voice.Backend}}
+  url: {{.Voice.URL}}
+  # Model name for openai backends.
+  model: {{.Voice.Model}}
+  # Spoken language such as "en" or "de"; empty detects it.
+  language: "{{.Voice.Language}}"
+  # Recording command, {file} being the WAV file to write; it must stop on
+  # Ctrl+C. Empty tries arecord, sox (rec) and ffmpeg.
+  recorder: "{{.Voice.Recorder}}"
+
+speech:
+  # Read replies aloud, sentence by sentence as they stream in. /speak on
+  # and /speak off switch it for the session.
+  enabled: {{.Speech.Enabled}}
+  # auto (say on macOS, else piper if piper_model is set, else espeak),
+  # piper, say or espeak.
+  backend: {{.Speech.Backend}}
+  # Voice name for say or espeak, e.g. "Samantha" or "de".
+  voice: "{{.Speech.Voice}}"
+  # Voice model for piper, e.g. ~/piper/en_US-lessac-medium.onnx
+  piper_model: "{{.Speech.PiperModel}}"
+
+knowledge:
+  # Notes and documents to answer from. Index them with /kb index; /kb ask
+  # <question> answers from the passages that match best.
+  dirs: []
+  # File types to index.
+  extensions:{{range .Knowledge.Extensions}}
+    - "{{.}}"{{end}}
+  # Ollama embedding model; pull it first, e.g. "ollama pull nomic-embed-text".
+  # Changing it, or the passage size, means embedding everything again.
+  embed_model: "{{.Knowledge.EmbedModel}}"
+  # Passage length in bytes, and how much of one passage is repeated at the
+  # start of the next so sentences aren't cut off from their context.
+  chunk_size: {{.Knowledge.ChunkSize}}
+  chunk_overlap: {{.Knowledge.ChunkOverlap}}
+  # Passages added to a question, and how similar (0-1) they must be.
+  top_k: {{.Knowledge.TopK}}
+  min_score: {{.Knowledge.MinScore}}
+  # Search the knowledge base for every message; /kb on and /kb off switch
+  # it for the session.
+  auto_retrieve: {{.Knowledge.AutoRetrieve}}
+
+discord:
+  # Bot bridge, run with "stefanclaw discord". Store the bot token with
+  # "stefanclaw secret set discord" and reference it as keyring:discord.
+  token: "{{.Discord.Token}}"
+  # Channel IDs to answer in; DMs are always answered.
+  channels: []
+  # User IDs the bot answers; nobody else can reach your assistant.
+  allowed_users: []
+
+slack:
+  # App bridge over Socket Mode, run with "stefanclaw slack". Store the
+  # tokens with "stefanclaw secret set" and reference them as keyring:<name>.
+  app_token: "{{.Slack.AppToken}}"
+  bot_token: "{{.Slack.BotToken}}"
+  # Channel IDs to answer in; DMs are always answered.
+  channels: []
+  # User IDs the app answers; nobody else can reach your assistant.
+  allowed_users: []
+
+bridge:
+  # Local HTTP endpoint for browser extensions and editor plugins, run with
+  # "stefanclaw serve --bridge". It only listens on this machine.
+  listen: "{{.Bridge.Listen}}"
+  # Clients send this as a bearer token. Store it with "stefanclaw secret
+  # set bridge" and reference it as keyring:bridge.
+  token: "{{.Bridge.Token}}"
+
+privacy:
+  # No /fetch, /search, URL auto-fetch or update checks.
+  disable_web: {{.Privacy.DisableWeb}}
+  # Never extract facts into MEMORY.md automatically.
+  disable_auto_memory: {{.Privacy.DisableAutoMemory}}
+  # No heartbeat check-ins, regardless of heartbeat.enabled.
+  disable_heartbeat: {{.Privacy.DisableHeartbeat}}
+`))
+
+// DefaultYAML renders the default configuration as commented YAML.
+func DefaultYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := defaultConfigTemplate.Execute(&buf, Defaults()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Init writes a commented default config.yaml to ConfigFile without running
+// onboarding. An existing file is only replaced when force is set.
+func Init(force bool) error {
+	if !force && !IsFirstRun() {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", ConfigFile())
+	}
+	data, err := DefaultYAML()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigFile(), data, 0o644)
+}
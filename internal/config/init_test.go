@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultYAML_MatchesDefaults(t *testing.T) {
+	data, err := DefaultYAML()
+	if err != nil {
+		t.Fatalf("DefaultYAML() error: %v", err)
+	}
+
+	var cfg Config
+	if err := parseConfig(data, &cfg); err != nil {
+		t.Fatalf("default config does not parse cleanly: %v", err)
+	}
+	for _, keys := range [][]string{cfg.Keybindings.Submit, cfg.Keybindings.Yank} {
+		if len(keys) != 0 {
+			t.Errorf("keybindings should be empty, got %v", keys)
+		}
+	}
+	cfg.Keybindings = KeybindingsConfig{}
+	if len(cfg.Jobs) != 0 {
+		t.Errorf("jobs should be empty, got %v", cfg.Jobs)
+	}
+	cfg.Jobs = nil
+	cfg.Heartbeat.Schedules = nil
+	if len(cfg.Notify.Webhooks) != 0 {
+		t.Errorf("webhooks should be empty, got %v", cfg.Notify.Webhooks)
+	}
+	cfg.Notify.Webhooks = nil
+	cfg.Discord.Channels, cfg.Discord.AllowedUsers = nil, nil
+	cfg.Slack.Channels, cfg.Slack.AllowedUsers = nil, nil
+	cfg.Calendar.Sources = nil
+	cfg.Home.Entities = nil
+	cfg.Home.Control = nil
+	cfg.Knowledge.Dirs = nil
+
+	if want := Defaults(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("parsed default config differs from Defaults():\n got %+v\nwant %+v", cfg, want)
+	}
+	if !strings.Contains(string(data), "# ") {
+		t.Error("default config should be commented")
+	}
+}
+
+func TestInit(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+
+	if err := Init(false); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	if IsFirstRun() {
+		t.Error("config.yaml should exist after Init")
+	}
+	if _, err := Load(); err != nil {
+		t.Errorf("Load() after Init error: %v", err)
+	}
+
+	if err := Init(false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second Init() error = %v, want already exists", err)
+	}
+
+	os.WriteFile(ConfigFile(), []byte("model:\n  default: llama3\n"), 0o644)
+	if err := Init(true); err != nil {
+		t.Fatalf("Init(force) error: %v", err)
+	}
+	cfg, _ := Load()
+	if cfg.Model.Default != "qwen3:8b" {
+		t.Errorf("forced Init should overwrite, model = %q", cfg.Model.Default)
+	}
+}
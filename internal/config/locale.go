@@ -5,28 +5,98 @@ import (
 	"strings"
 )
 
-// localeToLanguage maps locale prefixes to human-readable language names.
-var localeToLanguage = map[string]string{
-	"de": "Deutsch",
-	"en": "English",
-	"es": "Español",
-	"fr": "Français",
-	"it": "Italiano",
-	"ja": "日本語",
-	"ko": "한국어",
-	"nl": "Nederlands",
-	"pl": "Polski",
-	"pt": "Português",
-	"ru": "Русский",
-	"tr": "Türkçe",
-	"uk": "Українська",
-	"zh": "中文",
+// Language is a language stefanclaw knows by locale code and name.
+type Language struct {
+	Code    string // ISO 639-1 code used in locales, e.g. "de"
+	Name    string // native name written to config, e.g. "Deutsch"
+	English string // English name, e.g. "German"
+}
+
+// knownLanguages lists the languages recognized from locales and by name.
+// Any other language name is still accepted and passed to the model as-is.
+var knownLanguages = []Language{
+	{"ar", "العربية", "Arabic"},
+	{"bg", "Български", "Bulgarian"},
+	{"ca", "Català", "Catalan"},
+	{"cs", "Čeština", "Czech"},
+	{"da", "Dansk", "Danish"},
+	{"de", "Deutsch", "German"},
+	{"el", "Ελληνικά", "Greek"},
+	{"en", "English", "English"},
+	{"es", "Español", "Spanish"},
+	{"et", "Eesti", "Estonian"},
+	{"fa", "فارسی", "Persian"},
+	{"fi", "Suomi", "Finnish"},
+	{"fr", "Français", "French"},
+	{"he", "עברית", "Hebrew"},
+	{"hi", "हिन्दी", "Hindi"},
+	{"hr", "Hrvatski", "Croatian"},
+	{"hu", "Magyar", "Hungarian"},
+	{"id", "Bahasa Indonesia", "Indonesian"},
+	{"it", "Italiano", "Italian"},
+	{"ja", "日本語", "Japanese"},
+	{"ko", "한국어", "Korean"},
+	{"lt", "Lietuvių", "Lithuanian"},
+	{"lv", "Latviešu", "Latvian"},
+	{"ms", "Bahasa Melayu", "Malay"},
+	{"nb", "Norsk bokmål", "Norwegian Bokmål"},
+	{"nl", "Nederlands", "Dutch"},
+	{"pl", "Polski", "Polish"},
+	{"pt", "Português", "Portuguese"},
+	{"ro", "Română", "Romanian"},
+	{"ru", "Русский", "Russian"},
+	{"sk", "Slovenčina", "Slovak"},
+	{"sl", "Slovenščina", "Slovenian"},
+	{"sr", "Српски", "Serbian"},
+	{"sv", "Svenska", "Swedish"},
+	{"th", "ไทย", "Thai"},
+	{"tr", "Türkçe", "Turkish"},
+	{"uk", "Українська", "Ukrainian"},
+	{"vi", "Tiếng Việt", "Vietnamese"},
+	{"zh", "中文", "Chinese"},
+}
+
+// localeAliases maps legacy or macro-language codes to a known code.
+var localeAliases = map[string]string{
+	"no": "nb",
+	"nn": "nb",
+	"iw": "he",
+	"in": "id",
+}
+
+// Languages returns the known languages, sorted by code.
+func Languages() []Language {
+	return append([]Language(nil), knownLanguages...)
+}
+
+// LanguageAuto in config.yaml means "detect from the locale on every start".
+const LanguageAuto = "auto"
+
+// NormalizeLanguage maps a language code or name (native or English, any
+// case) to the native name used in config, e.g. "german" -> "Deutsch". Input
+// that matches no known language is returned trimmed with known=false; it is
+// still a valid setting and is passed to the model verbatim.
+func NormalizeLanguage(input string) (name string, known bool) {
+	input = strings.TrimSpace(input)
+	for _, l := range knownLanguages {
+		if strings.EqualFold(input, l.Code) || strings.EqualFold(input, l.Name) || strings.EqualFold(input, l.English) {
+			return l.Name, true
+		}
+	}
+	return input, false
 }
 
 // DetectLanguage reads the system locale from environment variables and returns
 // a human-readable language name. Falls back to "English" if unset or unrecognized.
 func DetectLanguage() string {
-	locale := ""
+	name, _, _ := DetectLanguageFromLocale()
+	return name
+}
+
+// DetectLanguageFromLocale is DetectLanguage that also returns the locale it
+// read and whether that locale was recognized, so callers can tell a real
+// match from the English fallback.
+func DetectLanguageFromLocale() (name, locale string, ok bool) {
 	for _, env := range []string{"LC_ALL", "LANG", "LANGUAGE"} {
 		if v := os.Getenv(env); v != "" {
 			locale = v
@@ -34,14 +104,29 @@ func DetectLanguage() string {
 		}
 	}
 	if locale == "" {
-		return "English"
+		return "English", "", false
+	}
+	// LANGUAGE may hold a priority list such as "de:en"; take the first match.
+	for _, part := range strings.Split(locale, ":") {
+		if name, ok := languageForLocale(part); ok {
+			return name, locale, true
+		}
 	}
-	return parseLocale(locale)
+	return "English", locale, false
 }
 
 // parseLocale extracts the language prefix from a locale string like "de_DE.UTF-8"
 // and returns the human-readable language name.
 func parseLocale(locale string) string {
+	if name, ok := languageForLocale(locale); ok {
+		return name
+	}
+	return "English"
+}
+
+// languageForLocale returns the native language name for a locale string and
+// whether it is known.
+func languageForLocale(locale string) (string, bool) {
 	// Strip encoding (e.g., ".UTF-8")
 	if idx := strings.Index(locale, "."); idx != -1 {
 		locale = locale[:idx]
@@ -56,9 +141,26 @@ func parseLocale(locale string) string {
 	}
 
 	locale = strings.ToLower(strings.TrimSpace(locale))
+	if alias, ok := localeAliases[locale]; ok {
+		locale = alias
+	}
 
-	if name, ok := localeToLanguage[locale]; ok {
-		return name
+	for _, l := range knownLanguages {
+		if l.Code == locale {
+			return l.Name, true
+		}
 	}
-	return "English"
+	return "", false
+}
+
+// LanguageCode returns the ISO 639-1 code for a language setting (code or
+// name, native or English), or "" if the language is not known.
+func LanguageCode(language string) string {
+	language = strings.TrimSpace(language)
+	for _, l := range knownLanguages {
+		if strings.EqualFold(language, l.Code) || strings.EqualFold(language, l.Name) || strings.EqualFold(language, l.English) {
+			return l.Code
+		}
+	}
+	return ""
 }
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestDetectLanguage_FromLANG(t *testing.T) {
 	t.Setenv("LC_ALL", "")
@@ -85,3 +88,76 @@ func TestParseLocale(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectLanguage_LanguagePriorityList(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "xx:sv_SE:en")
+
+	name, _, ok := DetectLanguageFromLocale()
+	if name != "Svenska" || !ok {
+		t.Errorf("DetectLanguageFromLocale() = %q, %t; want Svenska, true", name, ok)
+	}
+}
+
+func TestDetectLanguageFromLocale_ReportsFallback(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "xx_XX.UTF-8")
+	t.Setenv("LANGUAGE", "")
+
+	name, locale, ok := DetectLanguageFromLocale()
+	if name != "English" || locale != "xx_XX.UTF-8" || ok {
+		t.Errorf("DetectLanguageFromLocale() = %q, %q, %t; want English fallback", name, locale, ok)
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		known bool
+	}{
+		{"german", "Deutsch", true},
+		{"Deutsch", "Deutsch", true},
+		{"DE", "Deutsch", true},
+		{" swedish ", "Svenska", true},
+		{"Brazilian Portuguese", "Brazilian Portuguese", false},
+		{"Klingon", "Klingon", false},
+	}
+	for _, tt := range tests {
+		got, known := NormalizeLanguage(tt.input)
+		if got != tt.want || known != tt.known {
+			t.Errorf("NormalizeLanguage(%q) = %q, %t; want %q, %t", tt.input, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestLanguageCode(t *testing.T) {
+	tests := map[string]string{
+		"Deutsch":   "de",
+		"german":    "de",
+		"SV":        "sv",
+		" Español ": "es",
+		"Klingon":   "",
+		"":          "",
+	}
+	for input, want := range tests {
+		if got := LanguageCode(input); got != want {
+			t.Errorf("LanguageCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLoad_LanguageAuto(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+	t.Setenv("LC_ALL", "it_IT.UTF-8")
+	os.WriteFile(ConfigFile(), []byte("language: auto\n"), 0o644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Language != "Italiano" {
+		t.Errorf("language = %q, want Italiano from locale", cfg.Language)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MigrateData moves sessions and memory from the legacy location inside the
+// config directory to the data directory. Targets that already exist are left
+// untouched. It returns a description of each move performed.
+func MigrateData() ([]string, error) {
+	if DataDir() == Dir() {
+		return nil, nil
+	}
+
+	moves := []struct{ from, to string }{
+		{filepath.Join(Dir(), "sessions"), SessionsDir()},
+		{filepath.Join(PersonalityDir(), "MEMORY.md"), MemoryFile()},
+	}
+
+	var done []string
+	for _, mv := range moves {
+		if _, err := os.Stat(mv.from); err != nil {
+			continue
+		}
+		if _, err := os.Stat(mv.to); err == nil {
+			continue
+		}
+		if err := movePath(mv.from, mv.to); err != nil {
+			return done, fmt.Errorf("moving %s to %s: %w", mv.from, mv.to, err)
+		}
+		done = append(done, fmt.Sprintf("%s -> %s", mv.from, mv.to))
+	}
+	return done, nil
+}
+
+// movePath renames src to dst, falling back to copy-and-delete when they are
+// on different filesystems.
+func movePath(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyPath(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyPath(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode())
+	}
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if fi.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFile(path, target, fi.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateData(t *testing.T) {
+	cfgDir := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", cfgDir)
+	t.Setenv("STEFANCLAW_DATA_DIR", dataDir)
+
+	// Legacy layout: sessions and memory inside the config dir
+	legacySession := filepath.Join(cfgDir, "sessions", "s1")
+	if err := os.MkdirAll(legacySession, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(legacySession, "meta.json"), []byte(`{"id":"s1"}`), 0o644)
+	os.MkdirAll(PersonalityDir(), 0o755)
+	os.WriteFile(filepath.Join(PersonalityDir(), "MEMORY.md"), []byte("# Memory\n- likes Go\n"), 0o644)
+
+	moved, err := MigrateData()
+	if err != nil {
+		t.Fatalf("MigrateData() error: %v", err)
+	}
+	if len(moved) != 2 {
+		t.Errorf("moved %d paths, want 2: %v", len(moved), moved)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "sessions", "s1", "meta.json")); err != nil {
+		t.Errorf("session not migrated: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dataDir, "MEMORY.md"))
+	if err != nil || string(data) != "# Memory\n- likes Go\n" {
+		t.Errorf("memory not migrated: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(cfgDir, "sessions")); !os.IsNotExist(err) {
+		t.Error("legacy sessions dir should be gone")
+	}
+
+	// Second run is a no-op
+	moved, err = MigrateData()
+	if err != nil || len(moved) != 0 {
+		t.Errorf("second MigrateData() = %v, %v; want no moves", moved, err)
+	}
+}
+
+func TestMigrateData_KeepsExistingTarget(t *testing.T) {
+	cfgDir := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", cfgDir)
+	t.Setenv("STEFANCLAW_DATA_DIR", dataDir)
+
+	os.MkdirAll(PersonalityDir(), 0o755)
+	os.WriteFile(filepath.Join(PersonalityDir(), "MEMORY.md"), []byte("old"), 0o644)
+	os.WriteFile(filepath.Join(dataDir, "MEMORY.md"), []byte("new"), 0o644)
+
+	if _, err := MigrateData(); err != nil {
+		t.Fatalf("MigrateData() error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dataDir, "MEMORY.md"))
+	if string(data) != "new" {
+		t.Errorf("existing memory overwritten: %q", data)
+	}
+}
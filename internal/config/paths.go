@@ -3,11 +3,21 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-// Dir returns the configuration directory path (~/.config/stefanclaw).
-// It can be overridden with the STEFANCLAW_CONFIG_DIR environment variable.
+// Dir returns the configuration directory of the active profile. For the
+// default profile this is BaseDir; named profiles live in BaseDir/profiles/<name>.
 func Dir() string {
+	if p := Profile(); p != "" {
+		return filepath.Join(ProfilesDir(), p)
+	}
+	return BaseDir()
+}
+
+// BaseDir returns the root configuration directory path (~/.config/stefanclaw).
+// It can be overridden with the STEFANCLAW_CONFIG_DIR environment variable.
+func BaseDir() string {
 	if d := os.Getenv("STEFANCLAW_CONFIG_DIR"); d != "" {
 		return d
 	}
@@ -23,12 +33,191 @@ func PersonalityDir() string {
 	return filepath.Join(Dir(), "personality")
 }
 
+// DataDir returns the directory for mutable data (sessions, memory, logs) of
+// the active profile.
+func DataDir() string {
+	if p := Profile(); p != "" {
+		return filepath.Join(DataBaseDir(), "profiles", p)
+	}
+	return DataBaseDir()
+}
+
+// DataBaseDir returns the root data directory ($XDG_DATA_HOME/stefanclaw,
+// default ~/.local/share/stefanclaw). It can be overridden with
+// STEFANCLAW_DATA_DIR. When only STEFANCLAW_CONFIG_DIR is set, data is kept
+// in the config directory so a single directory holds everything.
+func DataBaseDir() string {
+	if d := os.Getenv("STEFANCLAW_DATA_DIR"); d != "" {
+		return d
+	}
+	if os.Getenv("STEFANCLAW_CONFIG_DIR") != "" {
+		return BaseDir()
+	}
+	if d := os.Getenv("XDG_DATA_HOME"); d != "" {
+		return filepath.Join(d, "stefanclaw")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".local", "share", "stefanclaw")
+	}
+	return filepath.Join(home, ".local", "share", "stefanclaw")
+}
+
+// CacheDir returns the cache directory ($XDG_CACHE_HOME/stefanclaw, default
+// ~/.cache/stefanclaw). It can be overridden with STEFANCLAW_CACHE_DIR. When
+// only STEFANCLAW_CONFIG_DIR is set, caches live in its "cache" subdirectory.
+func CacheDir() string {
+	if d := os.Getenv("STEFANCLAW_CACHE_DIR"); d != "" {
+		return d
+	}
+	if d := os.Getenv("STEFANCLAW_CONFIG_DIR"); d != "" {
+		return filepath.Join(d, "cache")
+	}
+	if d := os.Getenv("XDG_CACHE_HOME"); d != "" {
+		return filepath.Join(d, "stefanclaw")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".cache", "stefanclaw")
+	}
+	return filepath.Join(home, ".cache", "stefanclaw")
+}
+
 // SessionsDir returns the path to the sessions directory.
 func SessionsDir() string {
-	return filepath.Join(Dir(), "sessions")
+	return filepath.Join(DataDir(), "sessions")
+}
+
+// MemoryFile returns the path to MEMORY.md. When data and config share a
+// directory, the legacy location inside the personality directory is used.
+func MemoryFile() string {
+	if DataDir() == Dir() {
+		return filepath.Join(PersonalityDir(), "MEMORY.md")
+	}
+	return filepath.Join(DataDir(), "MEMORY.md")
 }
 
 // ConfigFile returns the path to the config.yaml file.
 func ConfigFile() string {
 	return filepath.Join(Dir(), "config.yaml")
 }
+
+// TasksFile returns the path to TASKS.md, the user's to-do list.
+func TasksFile() string {
+	return filepath.Join(DataDir(), "TASKS.md")
+}
+
+// RemindersFile returns the path to the reminders and scheduled tasks file.
+func RemindersFile() string {
+	return filepath.Join(DataDir(), "reminders.json")
+}
+
+// HeartbeatFile returns the path to the file recording when each
+// HEARTBEAT.md task last ran.
+func HeartbeatFile() string {
+	return filepath.Join(DataDir(), "heartbeat.json")
+}
+
+// HeartbeatStatsFile returns the path to the counts of skipped, answered and
+// replied-to heartbeat check-ins.
+func HeartbeatStatsFile() string {
+	return filepath.Join(DataDir(), "heartbeat-stats.json")
+}
+
+// HeartbeatFeedbackFile returns the path to the heartbeat messages the user
+// acknowledged or snoozed.
+func HeartbeatFeedbackFile() string {
+	return filepath.Join(DataDir(), "heartbeat-feedback.json")
+}
+
+// ReleaseNotesFile returns the path to the release notes of an installed
+// update, kept until the new version first starts.
+func ReleaseNotesFile() string {
+	return filepath.Join(DataDir(), "release-notes.json")
+}
+
+// ContextSizesFile returns the path to the context size last used with
+// each model.
+func ContextSizesFile() string {
+	return filepath.Join(DataDir(), "context-sizes.json")
+}
+
+// LogFile returns the path to the diagnostic log. Rotated logs are kept
+// next to it as stefanclaw.log.1 and so on.
+func LogFile() string {
+	return filepath.Join(DataDir(), "logs", "stefanclaw.log")
+}
+
+// CrashReportsDir returns the directory crash reports are written to.
+func CrashReportsDir() string {
+	return filepath.Join(DataDir(), "crashes")
+}
+
+// UsageFile returns the path to the log of the tokens every reply took.
+func UsageFile() string {
+	return filepath.Join(DataDir(), "usage.jsonl")
+}
+
+// InboxFile returns the path to the results of `stefanclaw daemon` that the
+// TUI hasn't shown yet.
+func InboxFile() string {
+	return filepath.Join(DataDir(), "inbox.jsonl")
+}
+
+// ChannelsFile returns the path to channels.json, which maps chat bridge
+// conversations to sessions.
+func ChannelsFile() string {
+	return filepath.Join(DataDir(), "channels.json")
+}
+
+// RatesFile returns the path to the cached currency exchange rates.
+func RatesFile() string {
+	return filepath.Join(CacheDir(), "rates.json")
+}
+
+// ReplyCacheDir returns the directory of cached pipe-mode replies.
+func ReplyCacheDir() string {
+	return filepath.Join(CacheDir(), "replies")
+}
+
+// UpdateCheckFile returns the path to the result of the last startup update
+// check.
+func UpdateCheckFile() string {
+	return filepath.Join(CacheDir(), "update-check.json")
+}
+
+// KnowledgeIndexFile returns the path to the knowledge base index, which
+// holds the embeddings of the indexed notes.
+func KnowledgeIndexFile() string {
+	return filepath.Join(DataDir(), "knowledge.gob")
+}
+
+// TemplatesDir returns the path to the prompt templates directory.
+func TemplatesDir() string {
+	return filepath.Join(Dir(), "templates")
+}
+
+// PluginsDir returns the directory scanned for plugin executables.
+func PluginsDir() string {
+	return filepath.Join(Dir(), "plugins")
+}
+
+// SecretsFile returns the fallback secrets file used when no OS keyring is
+// available. It is shared by all profiles, like the keyring itself.
+func SecretsFile() string {
+	return filepath.Join(BaseDir(), "secrets.json")
+}
+
+// ExpandPath expands a leading "~" to the home directory and makes relative
+// paths absolute against base.
+func ExpandPath(path, base string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path)
+}
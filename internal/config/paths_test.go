@@ -50,3 +50,66 @@ func TestConfigFile(t *testing.T) {
 		t.Errorf("ConfigFile() = %q, want suffix stefanclaw/config.yaml", f)
 	}
 }
+
+func TestTemplatesDir(t *testing.T) {
+	os.Unsetenv("STEFANCLAW_CONFIG_DIR")
+	dir := TemplatesDir()
+	if !strings.HasSuffix(dir, filepath.Join("stefanclaw", "templates")) {
+		t.Errorf("TemplatesDir() = %q, want suffix stefanclaw/templates", dir)
+	}
+}
+
+func TestDataDir_XDG(t *testing.T) {
+	os.Unsetenv("STEFANCLAW_CONFIG_DIR")
+	t.Setenv("STEFANCLAW_DATA_DIR", "")
+	t.Setenv("XDG_DATA_HOME", "/tmp/xdg-data")
+	t.Setenv("STEFANCLAW_CACHE_DIR", "")
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	if DataDir() != filepath.Join("/tmp/xdg-data", "stefanclaw") {
+		t.Errorf("DataDir() = %q, want /tmp/xdg-data/stefanclaw", DataDir())
+	}
+	if SessionsDir() != filepath.Join("/tmp/xdg-data", "stefanclaw", "sessions") {
+		t.Errorf("SessionsDir() = %q, want under data dir", SessionsDir())
+	}
+	if MemoryFile() != filepath.Join("/tmp/xdg-data", "stefanclaw", "MEMORY.md") {
+		t.Errorf("MemoryFile() = %q, want under data dir", MemoryFile())
+	}
+	if CacheDir() != filepath.Join("/tmp/xdg-cache", "stefanclaw") {
+		t.Errorf("CacheDir() = %q, want /tmp/xdg-cache/stefanclaw", CacheDir())
+	}
+}
+
+func TestDataDir_SingleDirectoryOverride(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", "/tmp/test-stefanclaw")
+	t.Setenv("STEFANCLAW_DATA_DIR", "")
+	t.Setenv("STEFANCLAW_CACHE_DIR", "")
+
+	if DataDir() != "/tmp/test-stefanclaw" {
+		t.Errorf("DataDir() = %q, want config dir", DataDir())
+	}
+	if MemoryFile() != filepath.Join("/tmp/test-stefanclaw", "personality", "MEMORY.md") {
+		t.Errorf("MemoryFile() = %q, want legacy personality location", MemoryFile())
+	}
+	if CacheDir() != filepath.Join("/tmp/test-stefanclaw", "cache") {
+		t.Errorf("CacheDir() = %q, want config dir cache subdirectory", CacheDir())
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	tests := []struct {
+		path, base, want string
+	}{
+		{"~", "/work", home},
+		{"~/.zshrc", "/work", filepath.Join(home, ".zshrc")},
+		{"notes", "/work", "/work/notes"},
+		{"../other", "/work/a", "/work/other"},
+		{"/etc/hosts", "/work", "/etc/hosts"},
+	}
+	for _, tt := range tests {
+		if got := ExpandPath(tt.path, tt.base); got != tt.want {
+			t.Errorf("ExpandPath(%q, %q) = %q, want %q", tt.path, tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profile is the active profile selected with SetProfile.
+var profile string
+
+// SetProfile selects a named profile. Each profile lives in its own
+// subdirectory of the base config directory with a separate config.yaml,
+// personality, sessions and memory. An empty name selects the default profile.
+func SetProfile(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the active profile name, falling back to the
+// STEFANCLAW_PROFILE environment variable. Empty means the default profile.
+func Profile() string {
+	if profile != "" {
+		return profile
+	}
+	name := os.Getenv("STEFANCLAW_PROFILE")
+	if validateProfileName(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// Profiles lists the names of all profiles that have been created.
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(ProfilesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && validateProfileName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ProfilesDir returns the directory holding all named profiles.
+func ProfilesDir() string {
+	return filepath.Join(BaseDir(), "profiles")
+}
+
+func validateProfileName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "." || name == ".." || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:`) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
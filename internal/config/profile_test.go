@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetProfile_SeparatesDirectories(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	t.Cleanup(func() { SetProfile("") })
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile() error: %v", err)
+	}
+
+	want := filepath.Join(tmp, "profiles", "work")
+	if Dir() != want {
+		t.Errorf("Dir() = %q, want %q", Dir(), want)
+	}
+	if BaseDir() != tmp {
+		t.Errorf("BaseDir() = %q, want %q", BaseDir(), tmp)
+	}
+	if SessionsDir() != filepath.Join(want, "sessions") {
+		t.Errorf("SessionsDir() = %q, want under profile", SessionsDir())
+	}
+	if ConfigFile() != filepath.Join(want, "config.yaml") {
+		t.Errorf("ConfigFile() = %q, want under profile", ConfigFile())
+	}
+}
+
+func TestProfile_EnvFallback(t *testing.T) {
+	t.Setenv("STEFANCLAW_PROFILE", "home")
+	SetProfile("")
+	if Profile() != "home" {
+		t.Errorf("Profile() = %q, want home", Profile())
+	}
+
+	t.Setenv("STEFANCLAW_PROFILE", "../escape")
+	if Profile() != "" {
+		t.Errorf("Profile() = %q, want empty for invalid env value", Profile())
+	}
+}
+
+func TestSetProfile_RejectsInvalidNames(t *testing.T) {
+	t.Cleanup(func() { SetProfile("") })
+	for _, name := range []string{"..", "a/b", `a\b`, ".hidden"} {
+		if err := SetProfile(name); err == nil {
+			t.Errorf("SetProfile(%q) should fail", name)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+
+	names, err := Profiles()
+	if err != nil || len(names) != 0 {
+		t.Fatalf("Profiles() = %v, %v; want none", names, err)
+	}
+
+	for _, n := range []string{"work", "home"} {
+		if err := os.MkdirAll(filepath.Join(tmp, "profiles", n), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err = Profiles()
+	if err != nil {
+		t.Fatalf("Profiles() error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "home" || names[1] != "work" {
+		t.Errorf("Profiles() = %v, want [home work]", names)
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is a value to write to config.yaml at a dotted key, e.g.
+// "heartbeat.interval".
+type Setting struct {
+	Key   string
+	Value any
+}
+
+// Set writes settings into config.yaml and leaves the rest of the file as
+// it is: comments, keys left at their defaults and "language: auto" stay
+// untouched, unlike with Save. Keys missing from the file are added.
+//
+// Values replacing plain scalars, such as "interval: 4h", are edited in
+// place, keeping the file byte for byte otherwise. Anything else has the
+// whole file encoded again, which keeps comments but not blank lines.
+func Set(settings ...Setting) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	var doc yaml.Node
+	data, err := os.ReadFile(ConfigFile())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s isn't a mapping", ConfigFile())
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	edited := map[int]bool{}
+	inPlace := true
+	values := make([]*yaml.Node, len(settings))
+	for i, s := range settings {
+		value := &yaml.Node{}
+		if err := value.Encode(s.Value); err != nil {
+			return fmt.Errorf("%s: %w", s.Key, err)
+		}
+		old, err := setKey(root, strings.Split(s.Key, "."), value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.Key, err)
+		}
+		values[i] = value
+		inPlace = inPlace && old != nil && !edited[old.Line] && splice(lines, old, value)
+		if old != nil {
+			edited[old.Line] = true
+		}
+	}
+
+	if inPlace {
+		out := bytes.Join(lines, nil)
+		if sameValues(out, settings, values) {
+			return writeFile(out)
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeFile(buf.Bytes())
+}
+
+// writeFile replaces config.yaml with data.
+func writeFile(data []byte) error {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigFile(), data, 0o644)
+}
+
+// setKey sets the value at path below the mapping node m, adding the
+// mappings on the way that don't exist yet. The comment after the old
+// value is kept. It returns the value replaced, nil if the key was added.
+func setKey(m *yaml.Node, path []string, value *yaml.Node) (*yaml.Node, error) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != path[0] {
+			continue
+		}
+		old := m.Content[i+1]
+		if len(path) > 1 {
+			if old.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s isn't a mapping", path[0])
+			}
+			return setKey(old, path[1:], value)
+		}
+		value.LineComment = old.LineComment
+		m.Content[i+1] = value
+		return old, nil
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) > 1 {
+		child := &yaml.Node{Kind: yaml.MappingNode}
+		m.Content = append(m.Content, key, child)
+		return setKey(child, path[1:], value)
+	}
+	m.Content = append(m.Content, key, value)
+	return nil, nil
+}
+
+// splice replaces the text of the plain scalar old in lines with value. It
+// reports whether it could.
+func splice(lines [][]byte, old, value *yaml.Node) bool {
+	if old.Kind != yaml.ScalarNode || old.Style != 0 || value.Kind != yaml.ScalarNode || value.Style != 0 || strings.Contains(value.Value, "\n") {
+		return false
+	}
+	i, j := old.Line-1, old.Column-1
+	if i < 0 || i >= len(lines) || j < 0 || j > len(lines[i]) || !bytes.HasPrefix(lines[i][j:], []byte(old.Value)) {
+		return false
+	}
+	line := lines[i]
+	lines[i] = slices.Concat(line[:j], []byte(value.Value), line[j+len(old.Value):])
+	return true
+}
+
+// sameValues reports whether data parses and has values at the keys of
+// settings, checking that text spliced in reads back as intended.
+func sameValues(data []byte, settings []Setting, values []*yaml.Node) bool {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return false
+	}
+	for i, s := range settings {
+		n := lookup(doc.Content[0], strings.Split(s.Key, "."))
+		if n == nil || n.Value != values[i].Value || n.ShortTag() != values[i].ShortTag() {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the node at path below the mapping node m, nil if there
+// is none.
+func lookup(m *yaml.Node, path []string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return m.Content[i+1]
+		}
+		if m.Content[i+1].Kind != yaml.MappingNode {
+			return nil
+		}
+		return lookup(m.Content[i+1], path[1:])
+	}
+	return nil
+}
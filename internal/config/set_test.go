@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSet_KeepsTheRestOfTheFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	path := filepath.Join(tmp, "config.yaml")
+	os.WriteFile(path, []byte(`# My settings
+language: auto
+heartbeat:
+  # How often to check in
+  interval: 1h # not too often
+`), 0o644)
+
+	err := Set(
+		Setting{"heartbeat.interval", "2h"},
+		Setting{"heartbeat.enabled", true},
+		Setting{"model.default", "llama3"},
+	)
+	if err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{"# My settings", "language: auto", "# How often to check in", "interval: 2h # not too often", "enabled: true", "model:\n  default: llama3"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("config.yaml lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "provider") {
+		t.Errorf("config.yaml got defaults written into it:\n%s", got)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Heartbeat.Interval != "2h" || !cfg.Heartbeat.Enabled || cfg.Model.Default != "llama3" {
+		t.Errorf("loaded %+v / %q", cfg.Heartbeat, cfg.Model.Default)
+	}
+}
+
+func TestSet_InPlace(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	path := filepath.Join(tmp, "config.yaml")
+	const before = "model:\n  default: qwen3:8b   # the chat model\n\nheartbeat:\n  enabled: false\n  interval: 4h\n"
+	os.WriteFile(path, []byte(before), 0o644)
+
+	if err := Set(Setting{"heartbeat.enabled", true}, Setting{"model.default", "llama3.1:8b"}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := strings.NewReplacer("false", "true", "qwen3:8b", "llama3.1:8b").Replace(before); string(data) != want {
+		t.Errorf("config.yaml =\n%s\nwant\n%s", data, want)
+	}
+
+	// A string that would read back as a bool is quoted
+	if err := Set(Setting{"model.default", "true"}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if cfg, err := Load(); err != nil || cfg.Model.Default != "true" {
+		t.Errorf("model.default = %q, %v", cfg.Model.Default, err)
+	}
+}
+
+func TestSet_NewFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", filepath.Join(tmp, "new"))
+	if err := Set(Setting{"heartbeat.schedules", []HeartbeatSchedule{{Name: "morning", Schedule: "0 8 * * *", Prompt: "Plan the day"}}}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if s := cfg.Heartbeat.Schedules; len(s) != 1 || s[0].Name != "morning" {
+		t.Errorf("schedules = %+v", s)
+	}
+}
+
+func TestSet_NotAMapping(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	os.WriteFile(filepath.Join(tmp, "config.yaml"), []byte("heartbeat: on\n"), 0o644)
+	if err := Set(Setting{"heartbeat.enabled", true}); err == nil {
+		t.Error("Set() into a scalar succeeded")
+	}
+}
@@ -0,0 +1,785 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stefanclaw/stefanclaw/internal/cron"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/proxy"
+)
+
+// ValidationError describes a single problem in config.yaml.
+type ValidationError struct {
+	Key        string // dotted path, e.g. "heartbeat.interval"
+	Line       int    // 1-based line in config.yaml, 0 if unknown
+	Message    string
+	Suggestion string
+}
+
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+	if e.Key != "" {
+		b.WriteString(e.Key + ": ")
+	}
+	b.WriteString(e.Message)
+	if e.Suggestion != "" {
+		b.WriteString(" (" + e.Suggestion + ")")
+	}
+	return b.String()
+}
+
+// ValidationErrors collects all problems found in a config file.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return "invalid config: " + errs[0].Error()
+	}
+	lines := []string{fmt.Sprintf("invalid config (%d problems):", len(errs))}
+	for _, e := range errs {
+		lines = append(lines, "  "+e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks a config for semantic problems such as bad durations,
+// invalid URLs, and nonsensical context sizes.
+func Validate(cfg Config) error {
+	if errs := validate(cfg, nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// parseConfig decodes config.yaml data on top of cfg, reporting unknown keys,
+// type mismatches, and semantic problems with their line numbers.
+func parseConfig(data []byte, cfg *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return ValidationErrors{syntaxError(err)}
+	}
+
+	var errs ValidationErrors
+	if len(root.Content) > 0 {
+		errs = append(errs, unknownKeys(root.Content[0], reflect.TypeOf(*cfg), "")...)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		if te, ok := err.(*yaml.TypeError); ok {
+			for _, msg := range te.Errors {
+				errs = append(errs, syntaxError(fmt.Errorf("%s", msg)))
+			}
+		} else {
+			errs = append(errs, syntaxError(err))
+		}
+	}
+
+	errs = append(errs, validate(*cfg, &root)...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var lineRe = regexp.MustCompile(`line (\d+): `)
+
+// syntaxError converts a yaml error into a ValidationError, extracting the
+// line number from messages like "yaml: line 3: ...".
+func syntaxError(err error) ValidationError {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	ve := ValidationError{Message: msg}
+	if m := lineRe.FindStringSubmatch(msg); m != nil {
+		ve.Line, _ = strconv.Atoi(m[1])
+		ve.Message = strings.Replace(msg, m[0], "", 1)
+	}
+	return ve
+}
+
+// unknownKeys walks a mapping node and reports keys that don't correspond to
+// a field of t, suggesting the closest known key.
+func unknownKeys(node *yaml.Node, t reflect.Type, prefix string) ValidationErrors {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs ValidationErrors
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := joinKey(prefix, key.Value)
+			ft, ok := fields[key.Value]
+			if !ok {
+				ve := ValidationError{Key: path, Line: key.Line, Message: "unknown key"}
+				if s := closest(key.Value, fields); s != "" {
+					ve.Suggestion = fmt.Sprintf("did you mean %q?", joinKey(prefix, s))
+				}
+				errs = append(errs, ve)
+				continue
+			}
+			errs = append(errs, unknownKeys(value, ft, path)...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownKeys(node.Content[i+1], t.Elem(), joinKey(prefix, node.Content[i].Value))...)
+		}
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			errs = append(errs, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields maps yaml key names to field types, following inline structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// closest returns the known key with the smallest edit distance to key, if
+// it is close enough to be a plausible typo.
+func closest(key string, known map[string]reflect.Type) string {
+	best, bestDist := "", len(key)/2+2
+	for k := range known {
+		if d := levenshtein(key, k); d < bestDist || (d == bestDist && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// lineOf returns the line of the value at a dotted key path, or 0.
+func lineOf(root *yaml.Node, key string) int {
+	return lineOfPath(root, strings.Split(key, ".")...)
+}
+
+// lineOfPath is lineOf for paths whose parts may themselves contain dots,
+// such as model names under sampling.models. Numeric parts index into lists.
+func lineOfPath(root *yaml.Node, parts ...string) int {
+	if root == nil || len(root.Content) == 0 {
+		return 0
+	}
+	node := root.Content[0]
+	for _, part := range parts {
+		if node.Kind == yaml.SequenceNode {
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[i]
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return 0
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return 0
+		}
+		node = next
+	}
+	return node.Line
+}
+
+// validate performs semantic checks on a decoded config.
+func validate(cfg Config, root *yaml.Node) ValidationErrors {
+	var errs ValidationErrors
+	add := func(key, msg, suggestion string) {
+		errs = append(errs, ValidationError{Key: key, Line: lineOf(root, key), Message: msg, Suggestion: suggestion})
+	}
+
+	if u, err := url.Parse(cfg.Provider.Ollama.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("provider.ollama.base_url", fmt.Sprintf("invalid URL %q", cfg.Provider.Ollama.BaseURL),
+			`use a full URL such as "http://127.0.0.1:11434"`)
+	}
+
+	if o := cfg.Provider.Ollama; o.APIKey != "" && o.Username != "" {
+		add("provider.ollama.username", "both api_key and username are set",
+			"use api_key for a bearer token or username and password for basic auth, not both")
+	} else if o.Password != "" && o.Username == "" {
+		add("provider.ollama.password", "password without username",
+			"set username too, or use api_key for a bearer token")
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Provider.Ollama.Headers)) {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+			add("provider.ollama.headers", fmt.Sprintf("invalid header name %q", name),
+				`use a name such as "X-Api-Key"`)
+		}
+	}
+
+	if !slices.Contains(providerNames, cfg.Provider.Default) {
+		add("provider.default", fmt.Sprintf("unknown provider %q", cfg.Provider.Default), `use "ollama", "lmstudio", "vllm", "openrouter", "azure" or "mock"`)
+	}
+	seen := map[string]bool{cfg.Provider.Default: true}
+	for i, f := range cfg.Provider.Fallback {
+		key := fmt.Sprintf("provider.fallback.%d.name", i)
+		switch {
+		case !slices.Contains(providerNames, f.Name):
+			add(key, fmt.Sprintf("unknown provider %q", f.Name), `use "ollama", "lmstudio", "vllm", "openrouter", "azure" or "mock"`)
+		case seen[f.Name]:
+			add(key, fmt.Sprintf("provider %q is already tried before", f.Name), "list each provider once, after provider.default")
+		}
+		seen[f.Name] = true
+	}
+	if n := cfg.Provider.Retry.Attempts; n < 1 || n > 10 {
+		add("provider.retry.attempts", fmt.Sprintf("%d attempts is out of range", n),
+			"use a value between 1 and 10; 1 disables retries")
+	}
+	if n := cfg.Provider.Parallel; n < 0 || n > 16 {
+		add("provider.parallel", fmt.Sprintf("%d requests at once is out of range", n),
+			"use a value between 1 and 16, or 0 for no limit")
+	}
+	if d, err := time.ParseDuration(cfg.Provider.Retry.Backoff); err != nil || d < 0 {
+		add("provider.retry.backoff", fmt.Sprintf("invalid duration %q", cfg.Provider.Retry.Backoff),
+			`use a Go duration such as "1s" or "500ms"`)
+	}
+	if d, err := time.ParseDuration(cfg.Provider.Retry.MaxBackoff); err != nil || d < 0 {
+		add("provider.retry.max_backoff", fmt.Sprintf("invalid duration %q", cfg.Provider.Retry.MaxBackoff),
+			`use a Go duration such as "10s" or "1m"`)
+	}
+	if cfg.Provider.Uses("openrouter") && strings.TrimSpace(cfg.Provider.OpenRouter.APIKey) == "" {
+		add("provider.openrouter.api_key", "API key is empty",
+			"create one at https://openrouter.ai/keys, store it with `stefanclaw secret set openrouter` and set api_key: keyring:openrouter")
+	}
+
+	if cfg.Provider.Uses("azure") {
+		az := cfg.Provider.Azure
+		if u, err := url.Parse(az.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			add("provider.azure.endpoint", fmt.Sprintf("invalid endpoint %q", az.Endpoint),
+				`use your resource's endpoint, such as "https://my-resource.openai.azure.com"`)
+		}
+		if strings.TrimSpace(az.APIKey) == "" {
+			add("provider.azure.api_key", "API key is empty",
+				"copy one from your resource's Keys and Endpoint page, store it with `stefanclaw secret set azure` and set api_key: keyring:azure")
+		}
+		if !apiVersionRe.MatchString(az.APIVersion) {
+			add("provider.azure.api_version", fmt.Sprintf("invalid API version %q", az.APIVersion),
+				`use a version such as "2024-10-21" or "2025-01-01-preview"`)
+		}
+		if len(az.Deployments) == 0 {
+			add("provider.azure.deployments", "no deployments",
+				"list the names of the deployments to use, e.g. [gpt-4o]")
+		}
+	}
+
+	if d, err := time.ParseDuration(cfg.Provider.Mock.Delay); err != nil || d < 0 {
+		add("provider.mock.delay", fmt.Sprintf("invalid duration %q", cfg.Provider.Mock.Delay),
+			`use a Go duration such as "30ms"; "0s" streams at once`)
+	}
+
+	if u, err := url.Parse(cfg.Provider.LMStudio.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("provider.lmstudio.base_url", fmt.Sprintf("invalid URL %q", cfg.Provider.LMStudio.BaseURL),
+			`use a full URL such as "http://127.0.0.1:1234"`)
+	}
+
+	if u, err := url.Parse(cfg.Provider.VLLM.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("provider.vllm.base_url", fmt.Sprintf("invalid URL %q", cfg.Provider.VLLM.BaseURL),
+			`use a full URL such as "http://127.0.0.1:8000"`)
+	}
+
+	if u, err := url.Parse(cfg.Provider.OpenRouter.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("provider.openrouter.base_url", fmt.Sprintf("invalid URL %q", cfg.Provider.OpenRouter.BaseURL),
+			`use a full URL such as "https://openrouter.ai/api/v1"`)
+	}
+
+	if ka := cfg.Provider.Ollama.KeepAlive; ka != "" {
+		if _, err := time.ParseDuration(ka); err != nil {
+			add("provider.ollama.keep_alive", fmt.Sprintf("invalid duration %q", ka),
+				`use a Go duration such as "30m", or "-1s" to keep the model loaded`)
+		}
+	}
+
+	if n := cfg.Provider.Ollama.MaxNumCtx; n < 2048 || n > 1<<20 {
+		add("provider.ollama.max_num_ctx", fmt.Sprintf("context size %d is out of range", n),
+			"use a value between 2048 and 1048576, e.g. 8192, 16384 or 32768")
+	}
+
+	if strings.TrimSpace(cfg.Model.Default) == "" {
+		add("model.default", "model name is empty", `set it to an installed model, e.g. "qwen3:8b"`)
+	}
+
+	switch lang := strings.TrimSpace(cfg.Language); {
+	case lang == "":
+		add("language", "language is empty", `set a language name such as "English" or "Deutsch", or "auto" to follow the locale`)
+	case strings.ContainsAny(lang, "\n\r") || len([]rune(lang)) > 64:
+		add("language", "language must be a short single-line name", `e.g. "English", "Deutsch" or "Brazilian Portuguese"`)
+	}
+
+	if n := cfg.Personality.MaxShare; n < 0 || n > 1 {
+		add("personality.max_share", fmt.Sprintf("share %g is out of range", n),
+			"use a fraction between 0 and 1, e.g. 0.25, or 0 to turn trimming off")
+	}
+
+	if cfg.Memory.MaxPromptTokens < 0 {
+		add("memory.max_prompt_tokens", fmt.Sprintf("negative token budget %d", cfg.Memory.MaxPromptTokens),
+			"use 0 to disable the limit or a positive number such as 2000")
+	}
+
+	if d, err := time.ParseDuration(cfg.Heartbeat.Interval); err != nil {
+		add("heartbeat.interval", fmt.Sprintf("invalid duration %q", cfg.Heartbeat.Interval),
+			`use a Go duration such as "30m", "4h" or "24h"`)
+	} else if d < time.Minute {
+		add("heartbeat.interval", fmt.Sprintf("interval %s is too short", d), `use at least "1m"`)
+	}
+	if _, err := heartbeat.ParseHours(cfg.Heartbeat.ActiveHours); err != nil {
+		add("heartbeat.active_hours", err.Error(), `use local times such as "08:00-22:00", or leave it empty for any time`)
+	}
+	if s := cfg.Heartbeat.SkipIfActive; s != "" {
+		if d, err := time.ParseDuration(s); err != nil || d < 0 {
+			add("heartbeat.skip_if_active", fmt.Sprintf("invalid duration %q", s),
+				`use a Go duration such as "15m", or leave it empty`)
+		}
+	}
+	for i, name := range cfg.Heartbeat.Tools {
+		if strings.TrimSpace(name) == "" {
+			add(fmt.Sprintf("heartbeat.tools.%d", i), "tool name is empty", `use a tool name such as "fetch" or "calendar"`)
+		}
+	}
+	scheduleNames := make(map[string]bool)
+	for i, sched := range cfg.Heartbeat.Schedules {
+		key := fmt.Sprintf("heartbeat.schedules.%d", i)
+		switch name := sched.Name; {
+		case name == "" || strings.ContainsAny(name, " \t\n"):
+			add(key+".name", fmt.Sprintf("invalid name %q", name), `use a single word such as "morning"`)
+		case scheduleNames[name]:
+			add(key+".name", fmt.Sprintf("duplicate schedule name %q", name), "schedule names must be unique")
+		}
+		scheduleNames[sched.Name] = true
+		if _, err := heartbeat.ParseWhen(sched.Schedule); err != nil {
+			add(key+".schedule", err.Error(), `use an interval such as "1h" or a cron expression such as "0 8 * * *"`)
+		}
+		if _, err := heartbeat.ParseHours(sched.ActiveHours); err != nil {
+			add(key+".active_hours", err.Error(), `use local times such as "09:00-17:00", or leave it empty`)
+		}
+		if strings.TrimSpace(sched.Prompt) == "" {
+			add(key+".prompt", "prompt is empty", "describe what the check-in should bring up")
+		} else if _, err := heartbeat.RenderPrompt(sched.Name, sched.Prompt, time.Time{}); err != nil {
+			add(key+".prompt", err.Error(), "the prompt may use {{.date}}, {{.weekday}}, {{.time}} and {{.name}}")
+		}
+	}
+
+	if n := cfg.Agent.MaxSteps; n < 1 || n > 20 {
+		add("agent.max_steps", fmt.Sprintf("max_steps %d is out of range", n), "use a value between 1 and 20, e.g. 5")
+	}
+	for _, tool := range []string{"read_file", "write_file"} {
+		dirs := cfg.Agent.ReadFile.AllowedDirs
+		if tool == "write_file" {
+			dirs = cfg.Agent.WriteFile.AllowedDirs
+		}
+		for i, dir := range dirs {
+			if strings.TrimSpace(dir) == "" {
+				add("agent."+tool+".allowed_dirs", fmt.Sprintf("entry %d is empty", i+1),
+					`use a directory such as "~/notes" or "." for the start directory`)
+			}
+		}
+	}
+	if n := cfg.Agent.ReadFile.MaxSize; n < 1 || n > 1<<20 {
+		add("agent.read_file.max_size", fmt.Sprintf("max_size %d is out of range", n),
+			"use a size in bytes between 1 and 1048576, e.g. 65536")
+	}
+	if d, err := time.ParseDuration(cfg.Agent.RunCommand.Timeout); err != nil || d <= 0 {
+		add("agent.run_command.timeout", fmt.Sprintf("invalid timeout %q", cfg.Agent.RunCommand.Timeout),
+			`use a Go duration such as "30s" or "2m"`)
+	}
+	if d, err := time.ParseDuration(cfg.Agent.RunCode.Timeout); err != nil || d <= 0 {
+		add("agent.run_code.timeout", fmt.Sprintf("invalid timeout %q", cfg.Agent.RunCode.Timeout),
+			`use a Go duration such as "10s"`)
+	}
+	if n := cfg.Agent.Terminal.Lines; n < 1 || n > 2000 {
+		add("agent.terminal.lines", fmt.Sprintf("lines %d is out of range", n),
+			"use a number between 1 and 2000")
+	}
+	switch ocr := cfg.Agent.OCR; ocr.Engine {
+	case "auto", "tesseract":
+	case "vision":
+		if ocr.VisionModel == "" {
+			add("agent.ocr.vision_model", "the vision engine needs a model",
+				`set vision_model to an Ollama model that reads images, e.g. "llava"`)
+		}
+	default:
+		add("agent.ocr.engine", fmt.Sprintf("unknown engine %q", ocr.Engine),
+			`use "auto", "tesseract" or "vision"`)
+	}
+
+	names := make(map[string]bool)
+	for i, job := range cfg.Jobs {
+		key := fmt.Sprintf("jobs.%d", i)
+		switch name := strings.TrimSpace(job.Name); {
+		case name == "":
+			add(key+".name", "job name is empty", `give the job a short name such as "morning-digest"`)
+		case names[name]:
+			add(key+".name", fmt.Sprintf("duplicate job name %q", name), "job names must be unique")
+		}
+		names[strings.TrimSpace(job.Name)] = true
+		if _, err := cron.Parse(job.Schedule); err != nil {
+			add(key+".schedule", fmt.Sprintf("invalid schedule %q: %v", job.Schedule, err),
+				`use a cron expression such as "0 8 * * *" (daily at 08:00) or "@hourly"`)
+		}
+		if strings.TrimSpace(job.Prompt) == "" {
+			add(key+".prompt", "prompt is empty", "describe what the job should do")
+		}
+		switch job.Output {
+		case "", "notification", "memory":
+		case "file":
+			if strings.TrimSpace(job.Path) == "" {
+				add(key+".path", "output file is missing", `set path, e.g. "~/notes/digest.md"`)
+			}
+		case "email":
+			if cfg.Email.SMTPHost == "" {
+				add(key+".output", "email output needs an SMTP server", "fill in the email: section")
+			}
+		default:
+			add(key+".output", fmt.Sprintf("unknown output %q", job.Output), `use "notification", "file", "memory" or "email"`)
+		}
+	}
+
+	if cfg.Email.SMTPHost != "" {
+		if cfg.Email.SMTPPort < 1 || cfg.Email.SMTPPort > 65535 {
+			add("email.smtp_port", fmt.Sprintf("port %d is out of range", cfg.Email.SMTPPort), "use 587 (STARTTLS) or 465 (TLS)")
+		}
+		for _, f := range []struct{ key, value string }{{"email.from", cfg.Email.From}, {"email.to", cfg.Email.To}} {
+			if !strings.Contains(f.value, "@") {
+				add(f.key, fmt.Sprintf("%q is not an email address", f.value), "use an address like you@example.com")
+			}
+		}
+	}
+
+	for i, src := range cfg.Calendar.Sources {
+		if strings.TrimSpace(src) == "" {
+			add(fmt.Sprintf("calendar.sources.%d", i), "calendar source is empty", "use an ICS URL or the path of an .ics file")
+		}
+	}
+	if d, err := time.ParseDuration(cfg.Calendar.Remind); err != nil || d < 0 {
+		add("calendar.remind", fmt.Sprintf("invalid duration %q", cfg.Calendar.Remind),
+			`use a Go duration such as "15m", or "0s" to turn event reminders off`)
+	}
+	if d, err := time.ParseDuration(cfg.Calendar.Refresh); err != nil || d < time.Minute {
+		add("calendar.refresh", fmt.Sprintf("invalid refresh interval %q", cfg.Calendar.Refresh),
+			`use a Go duration of at least a minute, such as "15m"`)
+	}
+
+	if cfg.Home.URL != "" {
+		if u, err := url.Parse(cfg.Home.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("home_assistant.url", fmt.Sprintf("invalid URL %q", cfg.Home.URL), `use the base URL, e.g. "http://homeassistant.local:8123"`)
+		}
+		if cfg.Home.Token == "" {
+			add("home_assistant.token", "a long-lived access token is required",
+				`create one under your Home Assistant profile and set "keyring:home_assistant" (see stefanclaw secret set)`)
+		}
+	}
+	for _, list := range []string{"entities", "control"} {
+		ids := cfg.Home.Entities
+		if list == "control" {
+			ids = cfg.Home.Control
+		}
+		for i, id := range ids {
+			if !entityRe.MatchString(id) {
+				add(fmt.Sprintf("home_assistant.%s.%d", list, i), fmt.Sprintf("%q is not an entity ID", id),
+					`use an ID such as "light.kitchen", or "light.*" for every light`)
+			}
+		}
+	}
+
+	if cfg.Voice.Enabled {
+		switch cfg.Voice.Backend {
+		case "whisper.cpp", "openai":
+		default:
+			add("voice.backend", fmt.Sprintf("unknown backend %q", cfg.Voice.Backend), `use "whisper.cpp" or "openai"`)
+		}
+		if u, err := url.Parse(cfg.Voice.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("voice.url", fmt.Sprintf("invalid URL %q", cfg.Voice.URL), `use the server's base URL, e.g. "http://127.0.0.1:8080"`)
+		}
+		if cfg.Voice.Recorder != "" && !strings.Contains(cfg.Voice.Recorder, "{file}") {
+			add("voice.recorder", "recorder command has no {file}", `e.g. "arecord -q -f S16_LE -r 16000 {file}"`)
+		}
+	}
+
+	switch cfg.Speech.Backend {
+	case "auto", "say", "espeak":
+	case "piper":
+		if cfg.Speech.PiperModel == "" {
+			add("speech.piper_model", "piper needs a voice model", "download an .onnx voice from https://github.com/rhasspy/piper and set its path")
+		}
+	default:
+		add("speech.backend", fmt.Sprintf("unknown backend %q", cfg.Speech.Backend), `use "auto", "piper", "say" or "espeak"`)
+	}
+
+	if strings.TrimSpace(cfg.Knowledge.EmbedModel) == "" {
+		add("knowledge.embed_model", "no embedding model", `e.g. "nomic-embed-text"`)
+	}
+	if cfg.Knowledge.ChunkSize < 200 || cfg.Knowledge.ChunkSize > 8000 {
+		add("knowledge.chunk_size", fmt.Sprintf("%d is out of range", cfg.Knowledge.ChunkSize), "use 200 to 8000 bytes, e.g. 1500")
+	}
+	if cfg.Knowledge.ChunkOverlap < 0 || cfg.Knowledge.ChunkOverlap > cfg.Knowledge.ChunkSize/2 {
+		add("knowledge.chunk_overlap", fmt.Sprintf("%d is out of range", cfg.Knowledge.ChunkOverlap), "use 0 to half of chunk_size")
+	}
+	if cfg.Knowledge.TopK < 1 || cfg.Knowledge.TopK > 20 {
+		add("knowledge.top_k", fmt.Sprintf("%d is out of range", cfg.Knowledge.TopK), "use 1 to 20")
+	}
+	if cfg.Knowledge.MinScore < 0 || cfg.Knowledge.MinScore >= 1 {
+		add("knowledge.min_score", fmt.Sprintf("%v is out of range", cfg.Knowledge.MinScore), "use a value from 0 to below 1, e.g. 0.5")
+	}
+	if cfg.Proxy.URL != "" {
+		if err := proxy.Validate(cfg.Proxy.URL); err != nil {
+			add("proxy.url", err.Error(),
+				`use an http://, https:// or socks5:// URL, e.g. "http://proxy.example.com:3128"`)
+		}
+	}
+	switch cfg.Update.Channel {
+	case "stable", "beta", "nightly":
+	default:
+		add("update.channel", fmt.Sprintf("unknown channel %q", cfg.Update.Channel), `use "stable", "beta" or "nightly"`)
+	}
+	if d, err := time.ParseDuration(cfg.Pipe.CacheTTL); err != nil || d < 0 {
+		add("pipe.cache_ttl", fmt.Sprintf("invalid duration %q", cfg.Pipe.CacheTTL),
+			`use a Go duration such as "10m" or "1h"; "0s" turns the cache off`)
+	}
+
+	for i, dir := range cfg.Knowledge.Dirs {
+		if strings.TrimSpace(dir) == "" {
+			add(fmt.Sprintf("knowledge.dirs.%d", i), "directory is empty", `use a path such as "~/notes"`)
+		}
+	}
+	for i, ext := range cfg.Knowledge.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			add(fmt.Sprintf("knowledge.extensions.%d", i), fmt.Sprintf("%q does not start with a dot", ext), `write extensions like ".md"`)
+		}
+	}
+
+	if d, err := time.ParseDuration(cfg.Notify.LongResponse); err != nil || d < 0 {
+		add("notify.long_response", fmt.Sprintf("invalid duration %q", cfg.Notify.LongResponse),
+			`use a Go duration such as "1m", or "0s" to notify after every reply`)
+	}
+	for i, hook := range cfg.Notify.Webhooks {
+		key := fmt.Sprintf("notify.webhooks.%d", i)
+		if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(key+".url", fmt.Sprintf("invalid URL %q", hook.URL), `use a full URL such as "https://ntfy.sh/my-topic"`)
+		}
+		switch hook.Format {
+		case "", "json", "ntfy":
+		case "gotify":
+			if hook.Token == "" {
+				add(key+".token", "gotify needs an application token", `set token, ideally as "keyring:gotify" (see stefanclaw secret set)`)
+			}
+		default:
+			add(key+".format", fmt.Sprintf("unknown format %q", hook.Format), `use "json", "ntfy" or "gotify"`)
+		}
+		for _, ev := range hook.Events {
+			switch ev {
+			case "heartbeat", "reminder", "job", "response", "assistant":
+			default:
+				add(key+".events", fmt.Sprintf("unknown event %q", ev), "use heartbeat, reminder, job, response or assistant")
+			}
+		}
+	}
+
+	if t := cfg.Notify.Tool; t.Enabled {
+		if len(t.Categories) == 0 {
+			add("notify.tool.categories", "no categories", `list what the model may notify about, e.g. ["reminder", "alert"]`)
+		}
+		if t.PerHour < 1 || t.PerHour > 60 {
+			add("notify.tool.per_hour", fmt.Sprintf("per_hour %d is out of range", t.PerHour), "use a number between 1 and 60")
+		}
+	}
+	for i, c := range cfg.Notify.Tool.Categories {
+		if strings.TrimSpace(c) == "" {
+			add(fmt.Sprintf("notify.tool.categories.%d", i), "category is empty", `use a word such as "alert"`)
+		}
+	}
+
+	for _, list := range []string{"channels", "allowed_users"} {
+		ids := cfg.Discord.Channels
+		if list == "allowed_users" {
+			ids = cfg.Discord.AllowedUsers
+		}
+		for _, id := range ids {
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				add("discord."+list, fmt.Sprintf("%q is not a Discord ID", id),
+					"copy IDs with Developer Mode on (right-click → Copy ID)")
+			}
+		}
+	}
+
+	for _, tok := range []struct{ key, value, prefix string }{
+		{"slack.app_token", cfg.Slack.AppToken, "xapp-"},
+		{"slack.bot_token", cfg.Slack.BotToken, "xoxb-"},
+	} {
+		if tok.value != "" && !strings.HasPrefix(tok.value, "keyring:") && !strings.HasPrefix(tok.value, tok.prefix) {
+			add(tok.key, fmt.Sprintf("expected a token starting with %q", tok.prefix),
+				"app tokens are under Basic Information, bot tokens under OAuth & Permissions")
+		}
+	}
+	for _, list := range []string{"channels", "allowed_users"} {
+		ids := cfg.Slack.Channels
+		if list == "allowed_users" {
+			ids = cfg.Slack.AllowedUsers
+		}
+		for _, id := range ids {
+			if !slackIDRe.MatchString(id) {
+				add("slack."+list, fmt.Sprintf("%q is not a Slack ID", id),
+					"copy IDs from the channel details or a member's profile (⋮ → Copy member ID)")
+			}
+		}
+	}
+
+	if host, port, err := net.SplitHostPort(cfg.Bridge.Listen); err != nil || port == "" {
+		add("bridge.listen", fmt.Sprintf("invalid address %q", cfg.Bridge.Listen), `use host:port, e.g. "127.0.0.1:8477"`)
+	} else if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		add("bridge.listen", fmt.Sprintf("%s is not a loopback address", host),
+			"the bridge only listens on this machine; use 127.0.0.1 or ::1")
+	}
+
+	errs = append(errs, validateSampling(cfg.Sampling.Options, root, "sampling")...)
+	models := make([]string, 0, len(cfg.Sampling.Models))
+	for name := range cfg.Sampling.Models {
+		models = append(models, name)
+	}
+	sort.Strings(models)
+	for _, name := range models {
+		errs = append(errs, validateSampling(cfg.Sampling.Models[name], root, "sampling", "models", name)...)
+	}
+
+	colors := map[string]string{
+		"tui.colors.primary":        cfg.TUI.Colors.Primary,
+		"tui.colors.secondary":      cfg.TUI.Colors.Secondary,
+		"tui.colors.success":        cfg.TUI.Colors.Success,
+		"tui.colors.error":          cfg.TUI.Colors.Error,
+		"tui.user_label.color":      cfg.TUI.UserLabel.Color,
+		"tui.assistant_label.color": cfg.TUI.AssistantLabel.Color,
+	}
+	keys := make([]string, 0, len(colors))
+	for k := range colors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if c := colors[k]; c != "" && !validColor(c) {
+			add(k, fmt.Sprintf("invalid color %q", c), `use a hex color such as "#7C3AED" or an ANSI number 0-255`)
+		}
+	}
+
+	return errs
+}
+
+// validateSampling range-checks one set of sampling options found at path.
+func validateSampling(o provider.Options, root *yaml.Node, path ...string) ValidationErrors {
+	var errs ValidationErrors
+	add := func(key, msg, suggestion string) {
+		parts := append(append([]string{}, path...), key)
+		errs = append(errs, ValidationError{
+			Key:        strings.Join(parts, "."),
+			Line:       lineOfPath(root, parts...),
+			Message:    msg,
+			Suggestion: suggestion,
+		})
+	}
+
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		add("temperature", fmt.Sprintf("temperature %g is out of range", *o.Temperature), "use a value between 0 and 2, e.g. 0.7")
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		add("top_p", fmt.Sprintf("top_p %g is out of range", *o.TopP), "use a value above 0 and at most 1, e.g. 0.9")
+	}
+	if o.RepeatPenalty != nil && *o.RepeatPenalty <= 0 {
+		add("repeat_penalty", fmt.Sprintf("repeat_penalty %g must be positive", *o.RepeatPenalty), "1.0 disables the penalty; 1.1 is typical")
+	}
+	if o.NumPredict != nil && *o.NumPredict < -2 {
+		add("num_predict", fmt.Sprintf("num_predict %d is out of range", *o.NumPredict), "use -1 for no limit, -2 to fill the context, or a positive token count")
+	}
+	return errs
+}
+
+// providerNames are the providers provider.default and provider.fallback
+// may name.
+var providerNames = []string{"ollama", "lmstudio", "vllm", "openrouter", "azure", "mock"}
+
+// apiVersionRe matches Azure OpenAI API versions such as 2024-10-21 and
+// 2025-01-01-preview.
+var apiVersionRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+
+var slackIDRe = regexp.MustCompile(`^[A-Z][A-Z0-9]+$`)
+
+var entityRe = regexp.MustCompile(`^[a-z0-9_]+\.([a-z0-9_]+|\*)$`)
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether c is a hex color or an ANSI color number.
+func validColor(c string) bool {
+	if hexColorRe.MatchString(c) {
+		return true
+	}
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255
+}
@@ -0,0 +1,729 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	if err := os.WriteFile(filepath.Join(tmp, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func validationErrors(t *testing.T, err error) ValidationErrors {
+	t.Helper()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want ValidationErrors", err)
+	}
+	return errs
+}
+
+func TestLoad_UnknownKeySuggestion(t *testing.T) {
+	writeConfig(t, "model:\n  default: llama3\nheartbeat:\n  enabled: true\n  intreval: 2h\n")
+
+	cfg, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(errs), err)
+	}
+	e := errs[0]
+	if e.Key != "heartbeat.intreval" || e.Line != 5 {
+		t.Errorf("error = %+v, want key heartbeat.intreval on line 5", e)
+	}
+	if !strings.Contains(e.Suggestion, "heartbeat.interval") {
+		t.Errorf("suggestion = %q, want heartbeat.interval", e.Suggestion)
+	}
+	// Valid keys are still decoded
+	if cfg.Model.Default != "llama3" {
+		t.Errorf("model = %q, want llama3", cfg.Model.Default)
+	}
+}
+
+func TestLoad_BadValues(t *testing.T) {
+	writeConfig(t, `provider:
+  ollama:
+    base_url: "localhost:11434"
+    max_num_ctx: 100
+heartbeat:
+  interval: "4 hours"
+personality:
+  max_share: 1.5
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+
+	want := map[string]int{
+		"provider.ollama.base_url":    3,
+		"provider.ollama.max_num_ctx": 4,
+		"heartbeat.interval":          6,
+		"personality.max_share":       8,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), err)
+	}
+	for _, e := range errs {
+		line, ok := want[e.Key]
+		if !ok {
+			t.Errorf("unexpected error: %v", e)
+			continue
+		}
+		if e.Line != line {
+			t.Errorf("%s reported on line %d, want %d", e.Key, e.Line, line)
+		}
+		if e.Suggestion == "" {
+			t.Errorf("%s has no suggestion", e.Key)
+		}
+	}
+}
+
+func TestLoad_LMStudioProvider(t *testing.T) {
+	writeConfig(t, `provider:
+  default: lmstudio
+  lmstudio:
+    base_url: "http://192.168.1.20:1234"
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Provider.Default != "lmstudio" || cfg.Provider.LMStudio.BaseURL != "http://192.168.1.20:1234" {
+		t.Errorf("provider = %+v", cfg.Provider)
+	}
+
+	writeConfig(t, `provider:
+  default: lm-studio
+`)
+	_, err = Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "provider.default" || errs[0].Line != 2 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestLoad_VLLMProvider(t *testing.T) {
+	writeConfig(t, `provider:
+  default: vllm
+  vllm:
+    base_url: "http://gpu-box:8000"
+    api_key: keyring:vllm
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if s := cfg.Provider.Settings("vllm"); s.BaseURL != "http://gpu-box:8000" || s.APIKey != "keyring:vllm" {
+		t.Errorf("Settings = %+v", s)
+	}
+
+	writeConfig(t, `provider:
+  vllm:
+    base_url: gpu-box:8000
+`)
+	_, err = Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "provider.vllm.base_url" || errs[0].Line != 3 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestLoad_AzureProvider(t *testing.T) {
+	writeConfig(t, `provider:
+  default: azure
+  azure:
+    endpoint: https://my-resource.openai.azure.com
+    api_key: keyring:azure
+    deployments: [gpt-4o, gpt-4o-mini]
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	s := cfg.Provider.Settings("azure")
+	if s.BaseURL != "https://my-resource.openai.azure.com" || s.APIVersion != "2024-10-21" || len(s.Models) != 2 {
+		t.Errorf("Settings = %+v", s)
+	}
+
+	writeConfig(t, `provider:
+  default: azure
+  azure:
+    endpoint: my-resource.openai.azure.com
+    api_version: latest
+`)
+	_, err = Load()
+	var keys []string
+	for _, e := range validationErrors(t, err) {
+		keys = append(keys, e.Key)
+	}
+	want := []string{"provider.azure.endpoint", "provider.azure.api_key", "provider.azure.api_version", "provider.azure.deployments"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("errors for %v, want %v", keys, want)
+	}
+}
+
+func TestLoad_ProviderFallback(t *testing.T) {
+	writeConfig(t, `provider:
+  fallback:
+    - name: openrouter
+      model: qwen/qwen3-8b
+  openrouter:
+    api_key: keyring:openrouter
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := []FallbackConfig{{Name: "openrouter", Model: "qwen/qwen3-8b"}}
+	if !reflect.DeepEqual(cfg.Provider.Fallback, want) {
+		t.Errorf("Fallback = %+v, want %+v", cfg.Provider.Fallback, want)
+	}
+
+	writeConfig(t, `provider:
+  fallback:
+    - name: openrouter
+    - name: ollama
+    - name: llamacpp
+`)
+	_, err = Load()
+	var keys []string
+	for _, e := range validationErrors(t, err) {
+		keys = append(keys, e.Key)
+	}
+	wantKeys := []string{"provider.fallback.1.name", "provider.fallback.2.name", "provider.openrouter.api_key"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("error keys = %v, want %v\n%v", keys, wantKeys, err)
+	}
+}
+
+func TestLoad_OpenRouterNeedsAPIKey(t *testing.T) {
+	writeConfig(t, `provider:
+  default: openrouter
+`)
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "provider.openrouter.api_key" {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+
+	writeConfig(t, `provider:
+  default: openrouter
+  openrouter:
+    api_key: keyring:openrouter
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Provider.BaseURL() != "https://openrouter.ai/api/v1" {
+		t.Errorf("BaseURL() = %q", cfg.Provider.BaseURL())
+	}
+	if s := cfg.Provider.Settings("openrouter"); s.APIKey != "keyring:openrouter" {
+		t.Errorf("Settings(openrouter).APIKey = %q", s.APIKey)
+	}
+	if s := cfg.Provider.Settings("ollama"); s.BaseURL != "http://127.0.0.1:11434" || s.APIKey != "" {
+		t.Errorf("Settings(ollama) = %+v", s)
+	}
+}
+
+func TestLoad_BadRunCommandTimeout(t *testing.T) {
+	writeConfig(t, `agent:
+  run_command:
+    enabled: true
+    timeout: "forever"
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "agent.run_command.timeout" || errs[0].Line != 4 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestLoad_BadRetry(t *testing.T) {
+	writeConfig(t, `provider:
+  retry:
+    attempts: 0
+    backoff: soon
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 2 || errs[0].Key != "provider.retry.attempts" || errs[1].Key != "provider.retry.backoff" || errs[1].Line != 4 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestLoad_BadParallel(t *testing.T) {
+	writeConfig(t, `provider:
+  parallel: -1
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "provider.parallel" || errs[0].Line != 2 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestRetryConfig_Policy(t *testing.T) {
+	p := Defaults().Provider.Retry.Policy()
+	if p.Attempts != 3 || p.Backoff != time.Second || p.MaxBackoff != 10*time.Second {
+		t.Errorf("Policy() = %+v", p)
+	}
+}
+
+func TestLoad_BadOllamaAuth(t *testing.T) {
+	writeConfig(t, `provider:
+  ollama:
+    api_key: keyring:ollama
+    username: me
+    headers:
+      "X Token": abc
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 2 || errs[0].Key != "provider.ollama.username" || errs[0].Line != 4 || errs[1].Key != "provider.ollama.headers" {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestProviderSettings_Ollama(t *testing.T) {
+	var c ProviderConfig
+	c.Ollama = OllamaConfig{BaseURL: "https://ollama.example.com", APIKey: "keyring:ollama", TLS: TLSConfig{CAFile: "ca.pem"}, KeepAlive: "30m"}
+	s := c.Settings("ollama")
+	if s.APIKey != "keyring:ollama" || s.TLS.CAFile != filepath.Join(BaseDir(), "ca.pem") || s.KeepAlive != 30*time.Minute {
+		t.Errorf("Settings = %+v", s)
+	}
+}
+
+func TestProviderSettings_Mock(t *testing.T) {
+	c := Defaults().Provider
+	if s := c.Settings("mock"); s.Fixtures != "" || s.Delay != 30*time.Millisecond {
+		t.Errorf("default Settings = %+v", s)
+	}
+	c.Mock.Dir = "demo"
+	if s := c.Settings("mock"); s.Fixtures != filepath.Join(BaseDir(), "demo") {
+		t.Errorf("Settings = %+v", s)
+	}
+}
+
+func TestLoad_BadProxy(t *testing.T) {
+	writeConfig(t, `proxy:
+  url: ftp://proxy.example.com
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "proxy.url" || errs[0].Line != 2 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestLoad_BadJobs(t *testing.T) {
+	writeConfig(t, `jobs:
+  - name: digest
+    schedule: "0 8 * * *"
+    prompt: Summarize the news.
+  - name: digest
+    schedule: "every morning"
+    prompt: Summarize the news.
+    output: file
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	want := []struct {
+		key  string
+		line int
+	}{
+		{"jobs.1.name", 5},
+		{"jobs.1.schedule", 6},
+		{"jobs.1.path", 0},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+	for i, w := range want {
+		if errs[i].Key != w.key || errs[i].Line != w.line {
+			t.Errorf("error %d = %s (line %d), want %s (line %d)", i, errs[i].Key, errs[i].Line, w.key, w.line)
+		}
+	}
+}
+
+func TestLoad_BadWebhooks(t *testing.T) {
+	writeConfig(t, `notify:
+  webhooks:
+    - url: ntfy.sh/topic
+      events: [heartbeat, typing]
+    - url: https://gotify.example.com
+      format: gotify
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, fmt.Sprintf("%s:%d", e.Key, e.Line))
+	}
+	want := "notify.webhooks.0.url:3 notify.webhooks.0.events:4 notify.webhooks.1.token:0"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+}
+
+func TestLoad_BadSlack(t *testing.T) {
+	writeConfig(t, `slack:
+  app_token: xoxb-123
+  bot_token: keyring:slack-bot
+  channels: [C0123ABC, general]
+  allowed_users: [U0123ABC]
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, fmt.Sprintf("%s:%d", e.Key, e.Line))
+	}
+	want := "slack.app_token:2 slack.channels:4"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+}
+
+func TestLoad_BadEmail(t *testing.T) {
+	writeConfig(t, `jobs:
+  - name: digest
+    schedule: "@daily"
+    prompt: Summarize my day.
+    output: email
+email:
+  smtp_port: 0
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "jobs.0.output" || errs[0].Line != 5 {
+		t.Fatalf("errors = %v, want one for jobs.0.output on line 5", err)
+	}
+
+	writeConfig(t, `email:
+  smtp_host: smtp.example.com
+  smtp_port: 0
+  to: me@example.com
+`)
+	_, err = Load()
+	errs = validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.Key)
+	}
+	if got := strings.Join(keys, " "); got != "email.smtp_port email.from" {
+		t.Errorf("errors = %s, want email.smtp_port email.from", got)
+	}
+}
+
+func TestLoad_BadCalendar(t *testing.T) {
+	writeConfig(t, `calendar:
+  sources:
+    - https://example.com/work.ics
+    - ""
+  remind: soon
+  refresh: 10s
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, fmt.Sprintf("%s:%d", e.Key, e.Line))
+	}
+	want := "calendar.sources.1:4 calendar.remind:5 calendar.refresh:6"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+}
+
+func TestLoad_BadHomeAssistant(t *testing.T) {
+	writeConfig(t, `home_assistant:
+  url: homeassistant.local:8123
+  entities:
+    - binary_sensor.front_door
+    - Front Door
+  control:
+    - light.*
+    - lights
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, fmt.Sprintf("%s:%d", e.Key, e.Line))
+	}
+	want := "home_assistant.url:2 home_assistant.token:0 home_assistant.entities.1:5 home_assistant.control.1:8"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+}
+
+func TestLoad_BadBridge(t *testing.T) {
+	for listen, ok := range map[string]bool{
+		"127.0.0.1:8477": true,
+		"localhost:9000": true,
+		"[::1]:8477":     true,
+		"0.0.0.0:8477":   false,
+		"192.168.1.5:80": false,
+		"8477":           false,
+	} {
+		writeConfig(t, "bridge:\n  listen: \""+listen+"\"\n")
+		_, err := Load()
+		if ok && err != nil {
+			t.Errorf("listen %q: unexpected error %v", listen, err)
+		}
+		if !ok {
+			errs := validationErrors(t, err)
+			if len(errs) != 1 || errs[0].Key != "bridge.listen" {
+				t.Errorf("listen %q: errors = %v, want one for bridge.listen", listen, errs)
+			}
+		}
+	}
+}
+
+func TestLoad_BadHeartbeatQuiet(t *testing.T) {
+	writeConfig(t, `heartbeat:
+  active_hours: "22:00-06:00"
+  skip_if_active: "15m"
+`)
+	if _, err := Load(); err != nil {
+		t.Fatalf("overnight active hours: unexpected error %v", err)
+	}
+
+	writeConfig(t, `heartbeat:
+  active_hours: "8 to 22"
+  skip_if_active: "soon"
+`)
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 2 || errs[0].Key != "heartbeat.active_hours" || errs[1].Key != "heartbeat.skip_if_active" {
+		t.Errorf("errors = %v, want active_hours and skip_if_active", errs)
+	}
+}
+
+func TestLoad_HeartbeatSchedules(t *testing.T) {
+	writeConfig(t, `heartbeat:
+  schedules:
+    - name: morning
+      schedule: "0 8 * * *"
+      prompt: "It's {{.date}}. Brief me on my day."
+    - name: nudge
+      schedule: 1h
+      active_hours: "09:00-17:00"
+      prompt: Nudge me to stretch.
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(cfg.Heartbeat.Schedules) != 2 || cfg.Heartbeat.Schedules[1].ActiveHours != "09:00-17:00" {
+		t.Errorf("schedules = %+v", cfg.Heartbeat.Schedules)
+	}
+
+	writeConfig(t, `heartbeat:
+  schedules:
+    - name: morning
+      schedule: "at eight"
+      prompt: "{{.mood}}"
+    - name: morning
+      schedule: 30s
+      active_hours: late
+      prompt: ""
+`)
+	_, err = Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.Key)
+	}
+	want := []string{
+		"heartbeat.schedules.0.schedule", "heartbeat.schedules.0.prompt",
+		"heartbeat.schedules.1.name", "heartbeat.schedules.1.schedule", "heartbeat.schedules.1.active_hours", "heartbeat.schedules.1.prompt",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("error keys = %v, want %v", keys, want)
+	}
+}
+
+func TestLoad_BadKnowledge(t *testing.T) {
+	writeConfig(t, `knowledge:
+  dirs:
+    - ~/notes
+    - ""
+  extensions:
+    - .md
+    - txt
+  embed_model: ""
+  top_k: 0
+  min_score: 1.5
+  chunk_size: 100
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, fmt.Sprintf("%s:%d", e.Key, e.Line))
+	}
+	want := "knowledge.embed_model:8 knowledge.chunk_size:11 knowledge.chunk_overlap:0 knowledge.top_k:9 knowledge.min_score:10 knowledge.dirs.1:4 knowledge.extensions.1:7"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+}
+
+func TestLoad_UpdateChannel(t *testing.T) {
+	writeConfig(t, "update:\n  channel: beta\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Update.Channel != "beta" {
+		t.Errorf("channel = %q, want beta", cfg.Update.Channel)
+	}
+	if !cfg.Update.Check {
+		t.Error("update.check should default to true")
+	}
+
+	writeConfig(t, "update:\n  channel: edge\n")
+	_, err = Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "update.channel" || errs[0].Line != 2 {
+		t.Errorf("errors = %+v, want update.channel on line 2", errs)
+	}
+}
+
+func TestLoad_PipeCacheTTL(t *testing.T) {
+	writeConfig(t, "pipe:\n  cache_ttl: 10m\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Pipe.CacheTTL != "10m" {
+		t.Errorf("cache_ttl = %q, want 10m", cfg.Pipe.CacheTTL)
+	}
+
+	writeConfig(t, "pipe:\n  cache_ttl: forever\n")
+	_, err = Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "pipe.cache_ttl" || errs[0].Line != 2 {
+		t.Errorf("errors = %+v, want pipe.cache_ttl on line 2", errs)
+	}
+}
+
+func TestLoad_BadColor(t *testing.T) {
+	writeConfig(t, `tui:
+  colors:
+    primary: "purple"
+  user_label:
+    color: "#00FF00"
+`)
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "tui.colors.primary" || errs[0].Line != 3 {
+		t.Fatalf("errors = %v, want one for tui.colors.primary on line 3", err)
+	}
+}
+
+func TestLoad_SamplingPerModel(t *testing.T) {
+	writeConfig(t, `sampling:
+  temperature: 0.5
+  models:
+    llama3.1:8b:
+      temperature: 0.1
+      top_p: 1.5
+`)
+
+	cfg, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "sampling.models.llama3.1:8b.top_p" || errs[0].Line != 6 {
+		t.Fatalf("errors = %v, want one for top_p on line 6", err)
+	}
+
+	if got := cfg.Sampling.For("llama3.1:8b").Get("temperature"); got != "0.1" {
+		t.Errorf("model temperature = %s, want 0.1", got)
+	}
+	if got := cfg.Sampling.For("qwen3:8b").Get("temperature"); got != "0.5" {
+		t.Errorf("default temperature = %s, want 0.5", got)
+	}
+	// Unset keys keep the built-in defaults
+	if got := cfg.Sampling.For("qwen3:8b").Get("repeat_penalty"); got != "1.1" {
+		t.Errorf("repeat_penalty = %s, want default 1.1", got)
+	}
+}
+
+func TestLoad_EmptyLanguage(t *testing.T) {
+	writeConfig(t, "language: \"\"\n")
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if len(errs) != 1 || errs[0].Key != "language" || errs[0].Line != 1 {
+		t.Fatalf("errors = %v, want one for language on line 1", err)
+	}
+}
+
+func TestLoad_TypeMismatch(t *testing.T) {
+	writeConfig(t, "memory:\n  max_prompt_tokens: lots\n")
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if errs[0].Line != 2 {
+		t.Errorf("line = %d, want 2 (%v)", errs[0].Line, err)
+	}
+}
+
+func TestLoad_SyntaxError(t *testing.T) {
+	writeConfig(t, "model:\n  default: [unclosed\n")
+
+	_, err := Load()
+	errs := validationErrors(t, err)
+	if errs[0].Line == 0 {
+		t.Errorf("syntax error should carry a line number: %v", err)
+	}
+}
+
+func TestLoad_EmptyFile(t *testing.T) {
+	writeConfig(t, "")
+
+	if _, err := Load(); err != nil {
+		t.Errorf("Load() of empty file error: %v", err)
+	}
+}
+
+func TestValidate_Defaults(t *testing.T) {
+	if err := Validate(Defaults()); err != nil {
+		t.Errorf("Validate(Defaults()) = %v, want nil", err)
+	}
+}
+
+func TestValidationErrorFormat(t *testing.T) {
+	e := ValidationError{Key: "heartbeat.interval", Line: 7, Message: "invalid duration", Suggestion: "use 4h"}
+	want := "line 7: heartbeat.interval: invalid duration (use 4h)"
+	if e.Error() != want {
+		t.Errorf("Error() = %q, want %q", e.Error(), want)
+	}
+}
@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Tuesday
+	from := time.Date(2026, 3, 10, 14, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 8 * * *", time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 10, 14, 15, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2026, 3, 10, 14, 8, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2026, 3, 10, 17, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * *", time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 13th or a Monday)
+		{"0 0 13 * mon", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNext_Impossible(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * * funday", "@reboot",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+// Package daemon runs heartbeats, automation jobs, reminders, scheduled
+// prompts and calendar alerts without the TUI. Results go out as webhook
+// notifications and are written to the Background session and an inbox
+// that the TUI shows on its next launch.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/email"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/jobs"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// BackgroundTitle is the title of the session the daemon writes to.
+const BackgroundTitle = "Background"
+
+// checkInterval is how often the daemon looks for due work.
+const checkInterval = 30 * time.Second
+
+// Daemon holds what the background work needs. Every field but Runner may
+// be left empty to turn that part off.
+type Daemon struct {
+	Runner   *agent.Runner
+	Language string
+
+	Heartbeat         config.HeartbeatConfig
+	HeartbeatTasks    func() []heartbeat.Task // re-reads HEARTBEAT.md
+	HeartbeatStore    *heartbeat.Store
+	HeartbeatStats    *heartbeat.StatsStore
+	HeartbeatFeedback *heartbeat.FeedbackStore // /ack and /snooze from the TUI
+	OnBattery         func() bool
+
+	Jobs      []config.JobConfig
+	Targets   jobs.Targets
+	Reminders *reminder.Store
+	Calendar  *calendar.Cache
+	Lead      time.Duration // calendar alerts this long before an event
+
+	Sessions session.Store
+	Inbox    *Inbox
+	Notifier *notify.Notifier
+	Mailer   *email.Sender // heartbeat mail if email.heartbeats is set
+	Log      io.Writer     // receives results and errors
+	Now      func() time.Time
+
+	sessionID     string
+	interval      time.Duration // of the idle check-in, which auto_tune may lengthen
+	nextHeartbeat time.Time
+	nextSchedule  []time.Time
+	nextJob       []time.Time
+	announced     map[string]bool
+}
+
+// Run does the background work until ctx is cancelled.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.start()
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		d.check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// start schedules the first heartbeat and job runs.
+func (d *Daemon) start() {
+	if d.Now == nil {
+		d.Now = time.Now
+	}
+	now := d.Now()
+	if interval, err := time.ParseDuration(d.Heartbeat.Interval); err == nil && d.Heartbeat.Enabled {
+		d.interval = interval
+		d.nextHeartbeat = now.Add(interval)
+	}
+	d.nextSchedule = make([]time.Time, len(d.Heartbeat.Schedules))
+	for i, s := range d.Heartbeat.Schedules {
+		if when, err := heartbeat.ParseWhen(s.Schedule); err == nil && d.Heartbeat.Enabled {
+			d.nextSchedule[i] = when.Next(now)
+		}
+	}
+	d.nextJob = make([]time.Time, len(d.Jobs))
+	for i, job := range d.Jobs {
+		d.nextJob[i] = jobs.Next(job, now)
+	}
+	d.announced = make(map[string]bool)
+}
+
+// check does the work that is due now. Run calls it every 30 seconds.
+func (d *Daemon) check(ctx context.Context) {
+	now := d.Now()
+	d.checkReminders(ctx, now)
+	d.checkCalendar(ctx, now)
+	d.checkJobs(ctx, now)
+	d.checkHeartbeat(ctx, now)
+	d.checkSchedules(ctx, now)
+}
+
+// checkReminders delivers due reminders and runs due scheduled prompts,
+// including those that fell due while nothing was running.
+func (d *Daemon) checkReminders(ctx context.Context, now time.Time) {
+	if d.Reminders == nil {
+		return
+	}
+	due, err := d.Reminders.TakeDue(now)
+	if err != nil {
+		d.logf("Error reading reminders: %v", err)
+	}
+	for _, r := range due {
+		if r.Kind == reminder.KindTask {
+			answer, err := d.Runner.Run(ctx, "", []provider.Message{{Role: "user", Content: r.Text}})
+			if err != nil {
+				d.fail(ctx, "Scheduled task", fmt.Errorf("scheduled task #%d: %w", r.ID, err))
+				continue
+			}
+			d.publish(ctx, Entry{Kind: notify.EventJob, Title: "Scheduled task: " + r.Text, Text: answer})
+			continue
+		}
+		text := r.Text
+		if late := now.Sub(r.Due); late > time.Minute {
+			text += " (due " + r.Due.Format("Mon Jan 2 15:04") + ")"
+		}
+		d.publish(ctx, Entry{Kind: notify.EventReminder, Title: "Reminder", Text: text})
+	}
+}
+
+// checkCalendar announces events that start within Lead, once each.
+func (d *Daemon) checkCalendar(ctx context.Context, now time.Time) {
+	if d.Calendar == nil || d.Lead <= 0 {
+		return
+	}
+	cal, err := d.Calendar.Get(ctx)
+	if err != nil {
+		d.logf("Calendar: %v", err)
+	}
+	if cal == nil {
+		return
+	}
+	for _, e := range cal.Upcoming(now, d.Lead) {
+		key := e.Summary + "@" + e.Start.String()
+		if d.announced[key] {
+			continue
+		}
+		d.announced[key] = true
+		mins := int(math.Ceil(e.Start.Sub(now).Minutes()))
+		d.publish(ctx, Entry{
+			Kind:  notify.EventReminder,
+			Title: "Upcoming event",
+			Text:  fmt.Sprintf("%s starts in %d min (%s).", e.Summary, mins, e.Start.In(now.Location()).Format("15:04")),
+		})
+	}
+}
+
+// checkJobs runs the jobs whose time has come. A failing job is reported
+// and retried at its next scheduled time.
+func (d *Daemon) checkJobs(ctx context.Context, now time.Time) {
+	for i, job := range d.Jobs {
+		if d.nextJob[i].IsZero() || now.Before(d.nextJob[i]) {
+			continue
+		}
+		d.nextJob[i] = jobs.Next(job, now)
+		answer, err := jobs.Run(ctx, d.Runner, job)
+		if err == nil {
+			err = jobs.Deliver(job, answer, now, d.Targets)
+		}
+		if err != nil {
+			d.fail(ctx, "Job "+job.Name, fmt.Errorf("job %s: %w", job.Name, err))
+			continue
+		}
+		if job.Output != "" && job.Output != jobs.OutputNotification {
+			answer = jobs.Summary(job)
+		}
+		d.publish(ctx, Entry{Kind: notify.EventJob, Title: "Job " + job.Name, Text: answer})
+	}
+}
+
+// checkHeartbeat runs a check-in once the interval has passed, unless it
+// is snoozed, outside the active hours, on battery, or no HEARTBEAT.md task
+// is due.
+func (d *Daemon) checkHeartbeat(ctx context.Context, now time.Time) {
+	if d.nextHeartbeat.IsZero() || now.Before(d.nextHeartbeat) {
+		return
+	}
+	d.nextHeartbeat = now.Add(d.interval)
+
+	if d.snoozed(now) {
+		return
+	}
+	if hours, err := heartbeat.ParseHours(d.Heartbeat.ActiveHours); err == nil && !hours.Contains(now) {
+		return
+	}
+	if d.Heartbeat.SkipOnBattery && d.OnBattery != nil && d.OnBattery() {
+		return
+	}
+	var tasks, due []heartbeat.Task
+	if d.HeartbeatTasks != nil && d.HeartbeatStore != nil {
+		tasks = d.HeartbeatTasks()
+	}
+	if len(tasks) > 0 {
+		var err error
+		if due, err = d.HeartbeatStore.Due(tasks, now); err != nil {
+			d.logf("Error reading heartbeat tasks: %v", err)
+		} else if len(due) == 0 {
+			return
+		}
+	}
+
+	answer, err := d.heartbeatRunner().Run(ctx, "", []provider.Message{{Role: "user", Content: d.checkInPrompt(heartbeat.Prompt(due, d.Language), now)}})
+	if err != nil {
+		d.logf("Error: heartbeat: %v", err)
+		return
+	}
+	if len(due) > 0 {
+		if err := d.HeartbeatStore.Done(due, tasks, now); err != nil {
+			d.logf("Error saving heartbeat tasks: %v", err)
+		}
+	}
+	d.checkedIn(ctx, heartbeat.Idle, answer, now)
+}
+
+// checkSchedules runs the named check-ins whose time has come, unless they
+// are snoozed, outside their active hours or on battery.
+func (d *Daemon) checkSchedules(ctx context.Context, now time.Time) {
+	for i, s := range d.Heartbeat.Schedules {
+		if d.nextSchedule[i].IsZero() || now.Before(d.nextSchedule[i]) {
+			continue
+		}
+		when, _ := heartbeat.ParseWhen(s.Schedule)
+		d.nextSchedule[i] = when.Next(now)
+
+		active := s.ActiveHours
+		if active == "" {
+			active = d.Heartbeat.ActiveHours
+		}
+		if hours, err := heartbeat.ParseHours(active); err == nil && !hours.Contains(now) {
+			continue
+		}
+		if d.Heartbeat.SkipOnBattery && d.OnBattery != nil && d.OnBattery() {
+			continue
+		}
+		if d.snoozed(now) {
+			continue
+		}
+		text, err := heartbeat.RenderPrompt(s.Name, s.Prompt, now)
+		if err == nil {
+			text, err = d.heartbeatRunner().Run(ctx, "", []provider.Message{{Role: "user", Content: d.checkInPrompt(heartbeat.SchedulePrompt(s.Name, text, d.Language), now)}})
+		}
+		if err != nil {
+			d.logf("Error: heartbeat %s: %v", s.Name, err)
+			continue
+		}
+		d.checkedIn(ctx, s.Name, text, now)
+	}
+}
+
+// snoozed reports whether the user paused check-ins with /snooze.
+func (d *Daemon) snoozed(now time.Time) bool {
+	if d.HeartbeatFeedback == nil {
+		return false
+	}
+	until, err := d.HeartbeatFeedback.SnoozedUntil(now)
+	if err != nil {
+		d.logf("Error reading heartbeat feedback: %v", err)
+	}
+	return !until.IsZero()
+}
+
+// checkInPrompt adds to the request of a check-in how the user answered
+// earlier check-in messages with /ack and /snooze.
+func (d *Daemon) checkInPrompt(prompt string, now time.Time) string {
+	if d.HeartbeatFeedback == nil {
+		return prompt
+	}
+	fb, err := d.HeartbeatFeedback.Current(now)
+	if err != nil {
+		d.logf("Error reading heartbeat feedback: %v", err)
+	}
+	if section := heartbeat.FeedbackSection(fb); section != "" {
+		prompt += "\n\n" + section
+	}
+	return prompt
+}
+
+// heartbeatRunner returns the runner for check-ins, which may only use the
+// tools listed in heartbeat.tools.
+func (d *Daemon) heartbeatRunner() *agent.Runner {
+	r := *d.Runner
+	if r.Tools != nil {
+		r.Tools = r.Tools.Filter(func(t tools.Tool) bool { return slices.Contains(d.Heartbeat.Tools, t.Name) })
+	}
+	return &r
+}
+
+// checkedIn publishes the answer of the named check-in, unless the model
+// skipped, and mails it if email.heartbeats is set. The outcome is counted
+// in the heartbeat stats.
+func (d *Daemon) checkedIn(ctx context.Context, name, answer string, now time.Time) {
+	if answer == "" || strings.Contains(answer, heartbeat.Skip) {
+		d.count(name, heartbeat.OutcomeSkipped, now)
+		return
+	}
+	d.count(name, heartbeat.OutcomeProduced, now)
+	title := "stefanclaw check-in"
+	if name != heartbeat.Idle {
+		title += ": " + name
+	}
+	d.publish(ctx, Entry{Kind: notify.EventHeartbeat, Title: title, Text: answer})
+	if d.Mailer.Heartbeats() {
+		if err := d.Mailer.Send(title+" — "+now.Format("Mon 2006-01-02 15:04"), answer); err != nil {
+			d.logf("Error: %v", err)
+		}
+	}
+}
+
+// count counts an outcome of the named check-in. With heartbeat.auto_tune,
+// idle check-ins that keep having nothing to say lengthen the interval until
+// the daemon restarts.
+func (d *Daemon) count(name, outcome string, now time.Time) {
+	if d.HeartbeatStats == nil {
+		return
+	}
+	c, err := d.HeartbeatStats.Record(name, outcome, now)
+	if err != nil {
+		d.logf("Error saving heartbeat stats: %v", err)
+		return
+	}
+	if name != heartbeat.Idle || !d.Heartbeat.AutoTune {
+		return
+	}
+	if longer, ok := heartbeat.Tune(d.interval, c); ok {
+		d.interval = longer
+		d.nextHeartbeat = now.Add(longer)
+		d.HeartbeatStats.ResetStreak(name)
+		d.logf("The last %d check-ins had nothing to say, so heartbeats now run every %s.", heartbeat.TuneSkips,
+			strings.TrimSuffix(strings.TrimSuffix(longer.String(), "0s"), "0m"))
+	}
+}
+
+// publish prints e, sends it to the webhooks, and keeps it in the
+// Background session and the inbox for the TUI.
+func (d *Daemon) publish(ctx context.Context, e Entry) {
+	e.Time = d.Now()
+	fmt.Fprintf(d.log(), "===== %s (%s) =====\n%s\n\n", e.Title, e.Time.Format("2006-01-02 15:04"), e.Text)
+	if err := d.Notifier.Send(ctx, notify.Event{Kind: e.Kind, Title: e.Title, Message: e.Text, Time: e.Time}); err != nil {
+		d.logf("Error: %v", err)
+	}
+	if err := d.record(e); err != nil {
+		d.logf("Error saving to the %s session: %v", BackgroundTitle, err)
+	}
+	if d.Inbox != nil {
+		if err := d.Inbox.Add(e); err != nil {
+			d.logf("Error: %v", err)
+		}
+	}
+}
+
+// fail reports a failed job or task like a result, so it isn't missed.
+func (d *Daemon) fail(ctx context.Context, title string, err error) {
+	d.logf("Error: %v", err)
+	if err := d.Notifier.Send(ctx, notify.Event{Kind: notify.EventJob, Title: title, Message: err.Error(), Time: d.Now()}); err != nil {
+		d.logf("Error: %v", err)
+	}
+}
+
+// record appends e to the Background session, creating it on first use.
+func (d *Daemon) record(e Entry) error {
+	if d.Sessions == nil {
+		return nil
+	}
+	if d.sessionID == "" {
+		s, err := Background(d.Sessions, d.Runner.Model)
+		if err != nil {
+			return err
+		}
+		d.sessionID = s.ID
+	}
+	return d.Sessions.Append(d.sessionID, provider.Message{Role: "assistant", Content: e.String()})
+}
+
+// Background returns the newest session titled BackgroundTitle, creating
+// one if there is none.
+func Background(store session.Store, model string) (*session.Session, error) {
+	list, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		if s.Title == BackgroundTitle {
+			return s, nil
+		}
+	}
+	return store.Create(BackgroundTitle, model)
+}
+
+func (d *Daemon) log() io.Writer {
+	if d.Log == nil {
+		return io.Discard
+	}
+	return d.Log
+}
+
+func (d *Daemon) logf(format string, args ...any) {
+	fmt.Fprintf(d.log(), format+"\n", args...)
+}
@@ -0,0 +1,230 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// echoProvider records the prompts and answers each by repeating it, or
+// check-ins with a fixed question.
+type echoProvider struct {
+	prompts []string
+	skip    bool
+}
+
+func (p *echoProvider) Name() string { return "mock" }
+func (p *echoProvider) Chat(_ context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	p.prompts = append(p.prompts, prompt)
+	reply := "Answer to: " + prompt
+	if strings.HasPrefix(prompt, "[Heartbeat check-in") {
+		reply = "How did you sleep?"
+		if p.skip {
+			reply = heartbeat.Skip
+		}
+	}
+	return &provider.ChatResponse{Message: provider.Message{Role: "assistant", Content: reply}}, nil
+}
+func (p *echoProvider) StreamChat(_ context.Context, _ provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	return nil, nil
+}
+func (p *echoProvider) ListModels(_ context.Context) ([]provider.ModelInfo, error) { return nil, nil }
+func (p *echoProvider) IsAvailable(_ context.Context) error                        { return nil }
+
+func newDaemon(t *testing.T, p *echoProvider, clock *time.Time) (*Daemon, *session.FileStore) {
+	t.Helper()
+	dir := t.TempDir()
+	sessions := session.NewFileStore(filepath.Join(dir, "sessions"))
+	d := &Daemon{
+		Runner:         &agent.Runner{Provider: p, Model: "test-model"},
+		Heartbeat:      config.HeartbeatConfig{Enabled: true, Interval: "1h", ActiveHours: "08:00-22:00"},
+		HeartbeatTasks: func() []heartbeat.Task { return heartbeat.Parse("- daily: ask how I slept") },
+		HeartbeatStore: heartbeat.NewStore(filepath.Join(dir, "heartbeat.json")),
+		HeartbeatStats: heartbeat.NewStatsStore(filepath.Join(dir, "heartbeat-stats.json")),
+		Jobs:           []config.JobConfig{{Name: "digest", Schedule: "0 9 * * *", Prompt: "Summarize the news."}},
+		Reminders:      reminder.NewStore(filepath.Join(dir, "reminders.json")),
+		Sessions:       sessions,
+		Inbox:          NewInbox(filepath.Join(dir, "inbox.jsonl")),
+		Log:            &bytes.Buffer{},
+		Now:            func() time.Time { return *clock },
+	}
+	return d, sessions
+}
+
+func TestDaemon_Check(t *testing.T) {
+	clock := time.Date(2026, 3, 10, 8, 30, 0, 0, time.Local)
+	p := &echoProvider{}
+	d, sessions := newDaemon(t, p, &clock)
+	d.Reminders.Add(reminder.KindReminder, "call mum", clock.Add(-2*time.Hour))
+	d.Reminders.Add(reminder.KindTask, "What's the weather?", clock.Add(10*time.Minute))
+	d.start()
+
+	d.check(context.Background())
+	clock = clock.Add(61 * time.Minute) // 09:31: task, job and heartbeat are due
+	d.check(context.Background())
+
+	entries, err := d.Inbox.Take()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, e := range entries {
+		titles = append(titles, e.Title)
+	}
+	want := []string{"Reminder", "Scheduled task: What's the weather?", "Job digest", "stefanclaw check-in"}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Fatalf("inbox titles = %q, want %q", titles, want)
+	}
+	if !strings.Contains(entries[0].Text, "call mum (due ") {
+		t.Errorf("a late reminder should say when it was due: %q", entries[0].Text)
+	}
+	if last := p.prompts[len(p.prompts)-1]; !strings.Contains(last, "- ask how I slept") {
+		t.Errorf("the check-in should ask about the due task: %q", last)
+	}
+	if rest, _ := d.Inbox.Take(); len(rest) != 0 {
+		t.Errorf("Take() should empty the inbox, %d left", len(rest))
+	}
+
+	// Everything is kept in one Background session
+	list, _ := sessions.List()
+	if len(list) != 1 || list[0].Title != BackgroundTitle {
+		t.Fatalf("sessions = %+v, want one Background session", list)
+	}
+	transcript, _ := sessions.LoadTranscript(list[0].ID)
+	if len(transcript) != 4 || !strings.HasPrefix(transcript[2].Content, "**Job digest** · Tue Mar 10 09:31") {
+		t.Errorf("transcript = %+v", transcript)
+	}
+
+	// The daily task was done, so the next hour has nothing to ask
+	n := len(p.prompts)
+	clock = clock.Add(time.Hour)
+	d.check(context.Background())
+	if len(p.prompts) != n {
+		t.Errorf("no check-in should run without due tasks, got %q", p.prompts[n:])
+	}
+}
+
+func TestDaemon_HeartbeatQuiet(t *testing.T) {
+	clock := time.Date(2026, 3, 10, 22, 30, 0, 0, time.Local)
+	p := &echoProvider{skip: true}
+	d, _ := newDaemon(t, p, &clock)
+	d.Jobs = nil
+	d.HeartbeatTasks = nil
+	d.start()
+
+	clock = clock.Add(time.Hour) // 23:30
+	d.check(context.Background())
+	if len(p.prompts) != 0 {
+		t.Fatalf("no check-in outside active hours, got %q", p.prompts)
+	}
+
+	clock = time.Date(2026, 3, 11, 9, 0, 0, 0, time.Local)
+	d.check(context.Background())
+	if len(p.prompts) != 1 || !strings.Contains(p.prompts[0], "Review the user's memory") {
+		t.Fatalf("prompts = %q, want one generic check-in", p.prompts)
+	}
+	if entries, _ := d.Inbox.Take(); len(entries) != 0 {
+		t.Errorf("a skipped check-in should not be kept: %+v", entries)
+	}
+	if stats, _ := d.HeartbeatStats.Stats(); stats[heartbeat.Idle].Skipped != 1 {
+		t.Errorf("stats = %+v, want one skipped check-in", stats)
+	}
+
+	// With auto-tune, three skips in a row double the interval
+	d.Heartbeat.AutoTune = true
+	for range 2 {
+		clock = clock.Add(time.Hour)
+		d.check(context.Background())
+	}
+	if want := clock.Add(2 * time.Hour); !d.nextHeartbeat.Equal(want) {
+		t.Errorf("next check-in at %s, want %s", d.nextHeartbeat, want)
+	}
+}
+
+func TestDaemon_Schedules(t *testing.T) {
+	clock := time.Date(2026, 3, 10, 7, 50, 0, 0, time.Local)
+	p := &echoProvider{}
+	d, _ := newDaemon(t, p, &clock)
+	d.Jobs = nil
+	d.HeartbeatTasks = nil
+	d.Heartbeat.Interval = "24h"
+	d.Heartbeat.Schedules = []config.HeartbeatSchedule{
+		{Name: "morning", Schedule: "0 8 * * *", Prompt: "It's {{.weekday}}. Brief me on my day."},
+		{Name: "nudge", Schedule: "1h", ActiveHours: "10:00-17:00", Prompt: "Nudge me to stretch."},
+	}
+	d.start()
+
+	clock = clock.Add(time.Hour) // 08:50: the morning briefing is due, the nudge is outside its hours
+	d.check(context.Background())
+	if len(p.prompts) != 1 || !strings.HasPrefix(p.prompts[0], "[Heartbeat check-in: morning] It's Tuesday. Brief me on my day.") {
+		t.Fatalf("prompts = %q, want the morning briefing", p.prompts)
+	}
+	entries, _ := d.Inbox.Take()
+	if len(entries) != 1 || entries[0].Title != "stefanclaw check-in: morning" {
+		t.Errorf("inbox = %+v", entries)
+	}
+
+	clock = clock.Add(time.Hour) // 09:50: neither is due
+	d.check(context.Background())
+	clock = clock.Add(time.Hour) // 10:50: the nudge is
+	d.check(context.Background())
+	if len(p.prompts) != 2 || !strings.Contains(p.prompts[1], "Nudge me to stretch.") {
+		t.Errorf("prompts = %q, want the nudge last", p.prompts)
+	}
+}
+
+func TestDaemon_HeartbeatRunner(t *testing.T) {
+	clock := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	d, _ := newDaemon(t, &echoProvider{}, &clock)
+	if d.heartbeatRunner().Tools != nil {
+		t.Error("a runner without tools should stay without")
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(tools.Time(time.Now))
+	registry.Register(tools.DateCalc(time.Now))
+	d.Runner.Tools = registry
+	d.Heartbeat.Tools = []string{"time", "fetch"}
+	r := d.heartbeatRunner()
+	if got := r.Tools.List(); len(got) != 1 || got[0].Name != "time" {
+		t.Errorf("heartbeat tools = %+v, want time only", got)
+	}
+	if d.Runner.Tools.Len() != 2 || r.Model != "test-model" {
+		t.Error("heartbeatRunner should copy the runner, not change it")
+	}
+}
+
+func TestDaemon_HeartbeatSnooze(t *testing.T) {
+	clock := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	p := &echoProvider{skip: true}
+	d, _ := newDaemon(t, p, &clock)
+	d.Jobs = nil
+	d.HeartbeatTasks = nil
+	d.HeartbeatFeedback = heartbeat.NewFeedbackStore(filepath.Join(t.TempDir(), "heartbeat-feedback.json"))
+	d.HeartbeatFeedback.Add(heartbeat.Feedback{Message: "Drink some water.", At: clock, Until: clock.Add(90 * time.Minute)}, clock)
+	d.start()
+
+	clock = clock.Add(time.Hour) // 10:00: snoozed
+	d.check(context.Background())
+	if len(p.prompts) != 0 {
+		t.Fatalf("no check-in while snoozed, got %q", p.prompts)
+	}
+
+	clock = clock.Add(time.Hour) // 11:00
+	d.check(context.Background())
+	if len(p.prompts) != 1 || !strings.Contains(p.prompts[0], `"Drink some water.": the user snoozed this until`) {
+		t.Errorf("prompts = %q, want a check-in that knows about the snooze", p.prompts)
+	}
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a result of background work: a check-in, reminder, job answer
+// or calendar alert.
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"` // a notify event kind, e.g. "heartbeat"
+	Title string    `json:"title"`
+	Text  string    `json:"text"`
+}
+
+// String formats e for a transcript.
+func (e Entry) String() string {
+	return fmt.Sprintf("**%s** · %s\n\n%s", e.Title, e.Time.Format("Mon Jan 2 15:04"), e.Text)
+}
+
+// Inbox keeps the entries the TUI hasn't shown yet in a JSON lines file. It
+// is safe for concurrent use within a process; the daemon appends and the
+// TUI takes.
+type Inbox struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewInbox creates an inbox backed by the file at path.
+func NewInbox(path string) *Inbox {
+	return &Inbox{path: path}
+}
+
+// Add appends e.
+func (in *Inbox) Add(e Entry) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(in.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(in.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Take returns the entries in the order they were added and empties the
+// inbox. Lines that can't be parsed are skipped.
+func (in *Inbox) Take() ([]Entry, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	// Moving the file away first lets the daemon keep adding to a new one
+	taken := in.path + ".taken"
+	if err := os.Rename(in.path, taken); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(taken)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return entries, os.Remove(taken)
+}
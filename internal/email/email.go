@@ -0,0 +1,154 @@
+// Package email sends plain-text mail over SMTP, for job output and
+// heartbeat summaries.
+package email
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Sender delivers mail to the configured recipients. Sending fails if no
+// SMTP host is configured, the Sender is nil or it is offline.
+type Sender struct {
+	cfg     config.EmailConfig
+	resolve func(string) (string, error)
+	offline bool // privacy.disable_web
+}
+
+// New creates a Sender for cfg. resolve turns the password into a secret
+// (e.g. a keyring: reference); nil uses it as-is.
+func New(cfg config.EmailConfig, resolve func(string) (string, error)) *Sender {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	return &Sender{cfg: cfg, resolve: resolve}
+}
+
+// WithConfig returns a Sender for cfg that resolves passwords like s and
+// stays offline if s is.
+func (s *Sender) WithConfig(cfg config.EmailConfig) *Sender {
+	if s == nil {
+		return New(cfg, nil)
+	}
+	c := New(cfg, s.resolve)
+	c.offline = s.offline
+	return c
+}
+
+// WithPrivacy returns a copy of s that refuses to send while p disables
+// web access.
+func (s *Sender) WithPrivacy(p config.PrivacyConfig) *Sender {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.offline = p.DisableWeb
+	return &c
+}
+
+// Heartbeats reports whether heartbeat check-ins should be mailed.
+func (s *Sender) Heartbeats() bool {
+	return s.Configured() && !s.offline && s.cfg.Heartbeats
+}
+
+// Configured reports whether an SMTP host is set.
+func (s *Sender) Configured() bool {
+	return s != nil && s.cfg.SMTPHost != ""
+}
+
+// Send mails body with the given subject. Port 465 uses implicit TLS; other
+// ports upgrade with STARTTLS when the server offers it.
+func (s *Sender) Send(subject, body string) error {
+	if !s.Configured() {
+		return fmt.Errorf("email is not configured (email.smtp_host in config.yaml)")
+	}
+	if s.offline {
+		return fmt.Errorf("email is off while web access is disabled (privacy.disable_web in config.yaml)")
+	}
+	password, err := s.resolve(s.cfg.Password)
+	if err != nil {
+		return fmt.Errorf("email password: %w", err)
+	}
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, password, s.cfg.SMTPHost)
+	}
+	to := strings.Split(s.cfg.To, ",")
+	for i := range to {
+		to[i] = strings.TrimSpace(to[i])
+	}
+	msg := compose(s.cfg.From, to, subject, body, time.Now())
+
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(s.cfg.SMTPPort))
+	if s.cfg.SMTPPort == 465 {
+		err = sendTLS(addr, s.cfg.SMTPHost, auth, s.cfg.From, to, msg)
+	} else {
+		err = smtp.SendMail(addr, auth, s.cfg.From, to, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("sending email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// sendTLS is smtp.SendMail over an implicit TLS connection.
+func sendTLS(addr, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose builds a plain-text UTF-8 message with CRLF line endings.
+func compose(from string, to []string, subject, body string, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
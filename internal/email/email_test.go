@@ -0,0 +1,116 @@
+package email
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// fakeSMTP accepts one message and returns what the client sent.
+func fakeSMTP(t *testing.T) (port int, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var log strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			log.WriteString(line)
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					log.WriteString(l)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				ch <- log.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestSend(t *testing.T) {
+	port, received := fakeSMTP(t)
+	s := New(config.EmailConfig{
+		SMTPHost: "127.0.0.1",
+		SMTPPort: port,
+		From:     "bot@example.com",
+		To:       "me@example.com, you@example.com",
+	}, nil)
+
+	if err := s.Send("Daily digest — Mon", "Line one.\nLine two."); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	got := <-received
+	for _, want := range []string{
+		"MAIL FROM:<bot@example.com>",
+		"RCPT TO:<me@example.com>",
+		"RCPT TO:<you@example.com>",
+		"To: me@example.com, you@example.com\r\n",
+		"Subject: =?utf-8?q?Daily_digest_=E2=80=94_Mon?=\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nLine one.\r\nLine two.\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("session lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSend_Offline(t *testing.T) {
+	s := New(config.EmailConfig{SMTPHost: "127.0.0.1", SMTPPort: 1, Heartbeats: true}, nil).
+		WithPrivacy(config.PrivacyConfig{DisableWeb: true})
+	for _, s := range []*Sender{s, s.WithConfig(config.EmailConfig{SMTPHost: "127.0.0.1", SMTPPort: 1, Heartbeats: true})} {
+		if err := s.Send("subject", "body"); err == nil || !strings.Contains(err.Error(), "privacy.disable_web") {
+			t.Errorf("Send() error = %v, want web access disabled", err)
+		}
+		if s.Heartbeats() {
+			t.Error("Heartbeats() = true with web access disabled")
+		}
+	}
+}
+
+func TestSend_NotConfigured(t *testing.T) {
+	var nilSender *Sender
+	for _, s := range []*Sender{nilSender, New(config.EmailConfig{}, nil)} {
+		if err := s.Send("subject", "body"); err == nil || !strings.Contains(err.Error(), "not configured") {
+			t.Errorf("Send() error = %v, want not configured", err)
+		}
+		if s.Heartbeats() {
+			t.Error("Heartbeats() = true without an SMTP host")
+		}
+	}
+}
@@ -2,20 +2,27 @@ package fetch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
 )
 
 // MaxBodySize is the maximum number of bytes read from a fetch response.
 const MaxBodySize = 32 * 1024
 
+// ErrDisabled is returned by a Client created with Disabled.
+var ErrDisabled = errors.New("web access is disabled (privacy.disable_web in config.yaml)")
+
 // Client fetches web pages via Jina Reader and returns markdown.
 type Client struct {
-	http *http.Client
+	http     *http.Client
+	disabled bool
 }
 
 // New creates a new fetch Client.
@@ -25,6 +32,12 @@ func New() *Client {
 	}
 }
 
+// Disabled creates a Client that refuses every request with ErrDisabled, so
+// callers holding it cannot reach the network.
+func Disabled() *Client {
+	return &Client{disabled: true}
+}
+
 // NewWithHTTPClient creates a Client with a custom http.Client (for testing).
 func NewWithHTTPClient(c *http.Client) *Client {
 	return &Client{http: c}
@@ -32,6 +45,9 @@ func NewWithHTTPClient(c *http.Client) *Client {
 
 // Fetch retrieves the given URL via Jina Reader and returns the content as markdown.
 func (c *Client) Fetch(ctx context.Context, rawURL string) (string, error) {
+	if c.disabled {
+		return "", ErrDisabled
+	}
 	if rawURL == "" {
 		return "", fmt.Errorf("URL is required")
 	}
@@ -52,13 +68,16 @@ func (c *Client) Fetch(ctx context.Context, rawURL string) (string, error) {
 	}
 	req.Header.Set("Accept", "text/markdown")
 
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
+		log.Warn("fetch failed", "url", rawURL, "err", err)
 		return "", fmt.Errorf("fetching URL: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		log.Warn("fetch failed", "url", rawURL, "status", resp.StatusCode)
 		return "", fmt.Errorf("fetch failed: HTTP %d", resp.StatusCode)
 	}
 
@@ -72,11 +91,15 @@ func (c *Client) Fetch(ctx context.Context, rawURL string) (string, error) {
 		body = body[:MaxBodySize]
 	}
 
+	log.Info("fetched", "url", rawURL, "bytes", len(body), "duration", time.Since(start))
 	return string(body), nil
 }
 
 // Search performs a web search via DuckDuckGo routed through Jina Reader.
 func (c *Client) Search(ctx context.Context, query string) (string, error) {
+	if c.disabled {
+		return "", ErrDisabled
+	}
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return "", fmt.Errorf("search query is required")
@@ -90,13 +113,16 @@ func (c *Client) Search(ctx context.Context, query string) (string, error) {
 	}
 	req.Header.Set("Accept", "text/markdown")
 
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
+		log.Warn("search failed", "err", err)
 		return "", fmt.Errorf("searching: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		log.Warn("search failed", "status", resp.StatusCode)
 		return "", fmt.Errorf("search failed: HTTP %d", resp.StatusCode)
 	}
 
@@ -110,5 +136,6 @@ func (c *Client) Search(ctx context.Context, query string) (string, error) {
 		body = body[:MaxBodySize]
 	}
 
+	log.Info("searched", "bytes", len(body), "duration", time.Since(start))
 	return string(body), nil
 }
@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -150,3 +151,17 @@ func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.URL.Host = strings.TrimPrefix(t.base.URL, "http://")
 	return http.DefaultTransport.RoundTrip(req)
 }
+
+func TestDisabled_RefusesAllRequests(t *testing.T) {
+	c := Disabled()
+	if _, err := c.Fetch(context.Background(), "https://example.com"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Fetch() error = %v, want ErrDisabled", err)
+	}
+	if _, err := c.Search(context.Background(), "golang"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Search() error = %v, want ErrDisabled", err)
+	}
+	input := "Summarize https://example.com"
+	if got := AugmentWithWebContent(context.Background(), c, input); got != input {
+		t.Errorf("AugmentWithWebContent() = %q, want input unchanged", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package git reads the state of a git working tree (status, diffs and
+// history) by running the git command.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MaxOutput caps the characters of a diff or log handed to the model.
+const MaxOutput = 12000
+
+// ErrNotRepo is returned by Open outside a git working tree.
+var ErrNotRepo = errors.New("not inside a git repository")
+
+// Repo is a git working tree.
+type Repo struct {
+	Root string // top-level directory
+}
+
+// Open finds the working tree containing dir.
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	out, err := run(context.Background(), dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, ErrNotRepo
+	}
+	return &Repo{Root: strings.TrimSpace(out)}, nil
+}
+
+// Name returns the repository's directory name.
+func (r *Repo) Name() string {
+	return filepath.Base(r.Root)
+}
+
+// Status returns the branch and changed files in short format.
+func (r *Repo) Status(ctx context.Context) (string, error) {
+	return run(ctx, r.Root, "status", "--short", "--branch")
+}
+
+// Diff returns a summary and the patch of the unstaged changes, or of the
+// staged ones. Long patches are truncated to MaxOutput.
+func (r *Repo) Diff(ctx context.Context, staged bool) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--staged")
+	}
+	stat, err := run(ctx, r.Root, append(args, "--stat")...)
+	if err != nil || stat == "" {
+		return "", err
+	}
+	patch, err := run(ctx, r.Root, args...)
+	if err != nil {
+		return "", err
+	}
+	return truncate(stat + "\n" + patch), nil
+}
+
+// Log returns the last n commits with their dates, authors and changed
+// file counts.
+func (r *Repo) Log(ctx context.Context, n int) (string, error) {
+	out, err := run(ctx, r.Root, "log", "--no-color", fmt.Sprintf("-n%d", n),
+		"--date=short", "--format=%h %ad %an: %s", "--shortstat")
+	if err != nil {
+		return "", err
+	}
+	return truncate(out), nil
+}
+
+func truncate(s string) string {
+	if r := []rune(s); len(r) > MaxOutput {
+		return string(r[:MaxOutput]) + "\n[... truncated]"
+	}
+	return s
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never page or prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_PAGER=cat", "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
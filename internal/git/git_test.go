@@ -0,0 +1,82 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a repository with one commit and returns it.
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Ada"},
+		{"config", "user.email", "ada@example.com"},
+	} {
+		if _, err := run(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("one\n"), 0o644)
+	run(context.Background(), dir, "add", ".")
+	if _, err := run(context.Background(), dir, "commit", "-q", "-m", "Add notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0o755)
+	r, err := Open(sub)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	return r
+}
+
+func TestOpen_NotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	if _, err := Open(t.TempDir()); !errors.Is(err, ErrNotRepo) {
+		t.Errorf("Open() error = %v, want ErrNotRepo", err)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	r := newRepo(t)
+	ctx := context.Background()
+
+	if diff, err := r.Diff(ctx, false); err != nil || diff != "" {
+		t.Errorf("Diff() on a clean tree = %q, %v", diff, err)
+	}
+
+	os.WriteFile(filepath.Join(r.Root, "notes.txt"), []byte("one\ntwo\n"), 0o644)
+	diff, err := r.Diff(ctx, false)
+	if err != nil || !strings.Contains(diff, "notes.txt | 1 +") || !strings.Contains(diff, "+two") {
+		t.Errorf("Diff() = %q, %v", diff, err)
+	}
+	if staged, _ := r.Diff(ctx, true); staged != "" {
+		t.Errorf("Diff(staged) before git add = %q", staged)
+	}
+	run(ctx, r.Root, "add", "notes.txt")
+	if staged, _ := r.Diff(ctx, true); !strings.Contains(staged, "+two") {
+		t.Errorf("Diff(staged) = %q", staged)
+	}
+
+	status, err := r.Status(ctx)
+	if err != nil || !strings.Contains(status, "## main") || !strings.Contains(status, "M  notes.txt") {
+		t.Errorf("Status() = %q, %v", status, err)
+	}
+	log, err := r.Log(ctx, 5)
+	if err != nil || !strings.Contains(log, "Ada: Add notes") || !strings.Contains(log, "1 file changed") {
+		t.Errorf("Log() = %q, %v", log, err)
+	}
+}
@@ -0,0 +1,131 @@
+package heartbeat
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feedback is how the user answered a check-in message with /ack or
+// /snooze. Later check-ins are told about it so they don't repeat the same
+// nag every interval.
+type Feedback struct {
+	Message string    `json:"message"` // the check-in message; empty for a plain snooze
+	At      time.Time `json:"at"`
+	Until   time.Time `json:"until,omitempty"` // end of a snooze; zero for an acknowledgement
+}
+
+// Snoozed reports whether f holds check-ins back at now.
+func (f Feedback) Snoozed(now time.Time) bool {
+	return now.Before(f.Until)
+}
+
+// FeedbackTTL is how long check-ins hear about feedback after it was given,
+// or after its snooze ended.
+const FeedbackTTL = 24 * time.Hour
+
+// feedbackExcerpt bounds how much of a message a check-in is reminded of.
+const feedbackExcerpt = 200
+
+// FeedbackSection tells a check-in how the user answered earlier check-in
+// messages. It is empty if there is nothing to tell.
+func FeedbackSection(fb []Feedback) string {
+	var lines []string
+	for _, f := range fb {
+		if f.Message == "" {
+			continue
+		}
+		msg := strings.Join(strings.Fields(f.Message), " ")
+		if len(msg) > feedbackExcerpt {
+			msg = msg[:feedbackExcerpt] + "…"
+		}
+		if f.Until.IsZero() {
+			lines = append(lines, fmt.Sprintf("- %q: the user acknowledged this at %s. Don't bring it up again.", msg, f.At.Format("15:04")))
+		} else {
+			lines = append(lines, fmt.Sprintf("- %q: the user snoozed this until %s. Bring it up again only if it still matters.", msg, f.Until.Format("Mon 15:04")))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "# Your earlier check-ins\n\n" + strings.Join(lines, "\n")
+}
+
+// FeedbackStore persists Feedback in a JSON file, so that a snooze holds
+// across restarts and for the daemon. It is safe for concurrent use.
+type FeedbackStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFeedbackStore creates a store backed by the JSON file at path.
+func NewFeedbackStore(path string) *FeedbackStore {
+	return &FeedbackStore{path: path}
+}
+
+// Current returns the feedback check-ins should still hear about at now,
+// oldest first.
+func (s *FeedbackStore) Current(now time.Time) ([]Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(now)
+}
+
+// Add records f, forgetting feedback that has expired at now.
+func (s *FeedbackStore) Add(f Feedback, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fb, err := s.load(now)
+	if err != nil {
+		return err
+	}
+	return writeJSON(s.path, append(fb, f))
+}
+
+// SnoozedUntil returns when the latest snooze ends, or the zero time if
+// check-ins aren't snoozed at now.
+func (s *FeedbackStore) SnoozedUntil(now time.Time) (time.Time, error) {
+	fb, err := s.Current(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var until time.Time
+	for _, f := range fb {
+		if f.Snoozed(now) && f.Until.After(until) {
+			until = f.Until
+		}
+	}
+	return until, nil
+}
+
+// Wake ends all snoozes at now.
+func (s *FeedbackStore) Wake(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fb, err := s.load(now)
+	if err != nil {
+		return err
+	}
+	for i, f := range fb {
+		if f.Snoozed(now) {
+			fb[i].Until = now
+		}
+	}
+	return writeJSON(s.path, fb)
+}
+
+// load reads the feedback that hasn't expired at now.
+func (s *FeedbackStore) load(now time.Time) ([]Feedback, error) {
+	var all []Feedback
+	if err := readJSON(s.path, &all); err != nil {
+		return nil, err
+	}
+	var fb []Feedback
+	for _, f := range all {
+		if now.Sub(f.At) < FeedbackTTL || now.Sub(f.Until) < FeedbackTTL {
+			fb = append(fb, f)
+		}
+	}
+	return fb, nil
+}
@@ -0,0 +1,251 @@
+// Package heartbeat reads the check-in tasks listed in HEARTBEAT.md and
+// remembers when each last ran, so a heartbeat only brings up what is due.
+//
+// A task is a list item that starts with an interval, such as
+// "- daily: ask how I slept" or "- [ ] every 3h: remind me to drink water";
+// ticking the box pauses it. The intervals are hourly, daily, weekly,
+// monthly (30 days), "every <n> hours|days|weeks" and "every <duration>"
+// such as "every 90m".
+package heartbeat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	taskRe  = regexp.MustCompile(`^\s*[-*]\s+(?:\[([ xX])\]\s+)?([^:]+):\s*(\S.*)$`)
+	everyRe = regexp.MustCompile(`^every\s+(?:(\d+)\s+)?(minute|hour|day|week)s?$`)
+)
+
+var named = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+}
+
+var units = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// Task is a check-in task from HEARTBEAT.md.
+type Task struct {
+	Text   string        // what to do, e.g. "ask how I slept"
+	Every  time.Duration // how often
+	Label  string        // the interval as written, e.g. "daily"
+	Paused bool          // the checklist box is ticked
+}
+
+// Next returns when the task is due again if it last ran at last; the
+// zero time, so due now, if it never ran.
+func (t Task) Next(last time.Time) time.Time {
+	if last.IsZero() {
+		return time.Time{}
+	}
+	return last.Add(t.Every)
+}
+
+// Parse returns the tasks in the content of HEARTBEAT.md, in file order.
+// Other lines are guidance for the model and are ignored.
+func Parse(content string) []Task {
+	var tasks []Task
+	for _, line := range strings.Split(content, "\n") {
+		if t, ok := parseLine(line); ok {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+func parseLine(line string) (Task, bool) {
+	m := taskRe.FindStringSubmatch(line)
+	if m == nil {
+		return Task{}, false
+	}
+	label := strings.TrimSpace(m[2])
+	every, ok := parseInterval(label)
+	if !ok {
+		return Task{}, false
+	}
+	return Task{
+		Text:   strings.TrimSpace(m[3]),
+		Every:  every,
+		Label:  label,
+		Paused: m[1] == "x" || m[1] == "X",
+	}, true
+}
+
+func parseInterval(s string) (time.Duration, bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if d, ok := named[s]; ok {
+		return d, true
+	}
+	if m := everyRe.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		if n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * units[m[2]], true
+	}
+	if rest, ok := strings.CutPrefix(s, "every "); ok {
+		if d, err := time.ParseDuration(strings.ReplaceAll(rest, " ", "")); err == nil && d >= time.Minute {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Skip is the answer of a check-in that has nothing to say.
+const Skip = "HEARTBEAT_SKIP"
+
+// Prompt builds the check-in request: the due tasks if there are any,
+// otherwise a review of memory, open tasks and conversation. A language
+// other than English is asked for explicitly.
+func Prompt(due []Task, lang string) string {
+	p := "[Heartbeat check-in] Review the user's memory, open tasks and conversation context. If there's something relevant to say, say it briefly. If not, respond with exactly '" + Skip + "'."
+	if len(due) > 0 {
+		var b strings.Builder
+		b.WriteString("[Heartbeat check-in] These check-ins from HEARTBEAT.md are due:\n")
+		for _, t := range due {
+			fmt.Fprintf(&b, "- %s\n", t.Text)
+		}
+		b.WriteString("Do the ones that fit the moment in one short message, using the user's memory and conversation context. If none of them fit, respond with exactly '" + Skip + "'.")
+		p = b.String()
+	}
+	if lang != "" && lang != "English" {
+		p += " Respond in " + lang + "."
+	}
+	return p
+}
+
+// Strip removes the task lines from the content of HEARTBEAT.md, leaving the
+// guidance that belongs in every system prompt.
+func Strip(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if _, ok := parseLine(line); !ok {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Store persists when each task last ran in a JSON file, keyed by its text.
+// It is safe for concurrent use.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a store backed by the JSON file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// LastRuns returns when each task last ran; tasks that never ran are missing.
+func (s *Store) LastRuns() (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Due returns the tasks that are not paused and haven't run within their
+// interval.
+func (s *Store) Due(tasks []Task, now time.Time) ([]Task, error) {
+	last, err := s.LastRuns()
+	if err != nil {
+		return nil, err
+	}
+	var due []Task
+	for _, t := range tasks {
+		if !t.Paused && !now.Before(t.Next(last[t.Text])) {
+			due = append(due, t)
+		}
+	}
+	return due, nil
+}
+
+// Done records that tasks ran at now. Entries for tasks no longer in
+// current are dropped.
+func (s *Store) Done(tasks, current []Task, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, err := s.load()
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(current))
+	for _, t := range current {
+		keep[t.Text] = true
+	}
+	for text := range last {
+		if !keep[text] {
+			delete(last, text)
+		}
+	}
+	for _, t := range tasks {
+		last[t.Text] = now
+	}
+	return s.save(last)
+}
+
+func (s *Store) load() (map[string]time.Time, error) {
+	last := map[string]time.Time{}
+	if err := readJSON(s.path, &last); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+func (s *Store) save(last map[string]time.Time) error {
+	return writeJSON(s.path, last)
+}
+
+// readJSON decodes the file at path into v, leaving v alone if there is no
+// file.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON replaces the file at path with v, through a temporary file so
+// readers never see half of it.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,264 @@
+package heartbeat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `# Heartbeat
+
+- Keep it short
+- Note: ask only once
+- daily: ask how I slept
+- [ ] every 3h: remind me to drink water
+* [x] weekly: ask about the garden
+- Every 2 days: check on the sourdough
+- every hour: stretch
+- every 30s: too often
+- fortnightly: unknown interval
+`
+
+func TestParse(t *testing.T) {
+	got := Parse(sample)
+	want := []Task{
+		{Text: "ask how I slept", Every: 24 * time.Hour, Label: "daily"},
+		{Text: "remind me to drink water", Every: 3 * time.Hour, Label: "every 3h"},
+		{Text: "ask about the garden", Every: 7 * 24 * time.Hour, Label: "weekly", Paused: true},
+		{Text: "check on the sourdough", Every: 48 * time.Hour, Label: "Every 2 days"},
+		{Text: "stretch", Every: time.Hour, Label: "every hour"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Parse() = %+v, want %d tasks", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("task %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	got := Strip(sample)
+	for _, s := range []string{"Keep it short", "Note: ask only once", "every 30s", "fortnightly"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("Strip() dropped %q:\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "slept") || strings.Contains(got, "garden") {
+		t.Errorf("Strip() kept task lines:\n%s", got)
+	}
+}
+
+func TestStore_DueAndDone(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "heartbeat.json"))
+	tasks := Parse(sample)
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	due, err := s.Due(tasks, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 4 {
+		t.Fatalf("first run: %d due, want every task but the paused one", len(due))
+	}
+	if err := s.Done(due, tasks, start); err != nil {
+		t.Fatal(err)
+	}
+
+	due, _ = s.Due(tasks, start.Add(3*time.Hour))
+	if len(due) != 2 || due[0].Text != "remind me to drink water" || due[1].Text != "stretch" {
+		t.Errorf("after 3h: due = %+v, want water and stretch", due)
+	}
+	if due, _ := s.Due(tasks, start.Add(24*time.Hour)); len(due) != 3 {
+		t.Errorf("after a day: %d due, want 3", len(due))
+	}
+
+	// Tasks removed from HEARTBEAT.md are forgotten
+	if err := s.Done(nil, tasks[:1], start); err != nil {
+		t.Fatal(err)
+	}
+	last, _ := s.LastRuns()
+	if len(last) != 1 || !last["ask how I slept"].Equal(start) {
+		t.Errorf("LastRuns() = %v, want only the daily task", last)
+	}
+}
+
+func TestHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, time.Local) }
+
+	day, err := ParseHours("08:00-22:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	night, err := ParseHours("22:00 – 06:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		h    Hours
+		t    time.Time
+		want bool
+	}{
+		{day, at(8, 0), true},
+		{day, at(21, 59), true},
+		{day, at(22, 0), false},
+		{day, at(3, 0), false},
+		{night, at(23, 0), true},
+		{night, at(6, 29), true},
+		{night, at(12, 0), false},
+		{Hours{}, at(3, 0), true},
+	} {
+		if got := tt.h.Contains(tt.t); got != tt.want {
+			t.Errorf("%s contains %s = %t, want %t", tt.h, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+	if night.String() != "22:00–06:30" {
+		t.Errorf("String() = %q", night.String())
+	}
+
+	for _, bad := range []string{"8-22", "08:00", "08:00-08:00", "25:00-26:00"} {
+		if _, err := ParseHours(bad); err == nil {
+			t.Errorf("ParseHours(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLinuxOnBattery(t *testing.T) {
+	supply := func(dir, name string, files map[string]string) {
+		os.MkdirAll(filepath.Join(dir, name), 0o755)
+		for f, v := range files {
+			os.WriteFile(filepath.Join(dir, name, f), []byte(v+"\n"), 0o644)
+		}
+	}
+
+	laptop := t.TempDir()
+	supply(laptop, "AC", map[string]string{"type": "Mains", "online": "0"})
+	supply(laptop, "BAT0", map[string]string{"type": "Battery", "status": "Discharging"})
+	if !linuxOnBattery(laptop) {
+		t.Error("unplugged laptop should be on battery")
+	}
+
+	supply(laptop, "AC", map[string]string{"online": "1"})
+	if linuxOnBattery(laptop) {
+		t.Error("plugged-in laptop should be on mains")
+	}
+
+	if linuxOnBattery(t.TempDir()) {
+		t.Error("desktop without power supplies should be on mains")
+	}
+}
+
+func TestStatsStore(t *testing.T) {
+	s := NewStatsStore(filepath.Join(t.TempDir(), "stats.json"))
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	for _, outcome := range []string{OutcomeSkipped, OutcomeProduced, OutcomeReplied, OutcomeSkipped, OutcomeSkipped} {
+		if _, err := s.Record(Idle, outcome, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Record("morning", OutcomeProduced, at.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Record(Idle, "ignored", at); err == nil {
+		t.Error("an unknown outcome should be an error")
+	}
+
+	stats, err := NewStatsStore(s.path).Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle := stats[Idle]
+	if idle.Skipped != 3 || idle.Produced != 1 || idle.Replied != 1 || idle.Streak != 2 || !idle.Since.Equal(at) {
+		t.Errorf("idle = %+v", idle)
+	}
+	if got, want := idle.String(), "4 check-ins: 3 skipped (75%), 1 with a message, 1 replied to"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if len(stats) != 2 || stats["morning"].Produced != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if err := s.ResetStreak(Idle); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := s.Stats(); stats[Idle].Streak != 0 || stats[Idle].Skipped != 3 {
+		t.Errorf("after ResetStreak: %+v", stats[Idle])
+	}
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := s.Stats(); len(stats) != 0 {
+		t.Errorf("after Reset: %+v", stats)
+	}
+}
+
+func TestTune(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		streak   int
+		want     time.Duration
+		ok       bool
+	}{
+		{4 * time.Hour, 2, 4 * time.Hour, false},
+		{4 * time.Hour, 3, 8 * time.Hour, true},
+		{16 * time.Hour, 5, 24 * time.Hour, true},
+		{24 * time.Hour, 3, 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		got, ok := Tune(tt.interval, Counts{Streak: tt.streak})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Tune(%s, streak %d) = %s, %t; want %s, %t", tt.interval, tt.streak, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFeedbackStore(t *testing.T) {
+	s := NewFeedbackStore(filepath.Join(t.TempDir(), "feedback.json"))
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	if until, err := s.SnoozedUntil(at); err != nil || !until.IsZero() {
+		t.Fatalf("SnoozedUntil without a file = %v, %v", until, err)
+	}
+
+	s.Add(Feedback{Message: "Drink some water.", At: at}, at)
+	s.Add(Feedback{Message: "Time to stretch?", At: at.Add(time.Hour), Until: at.Add(3 * time.Hour)}, at.Add(time.Hour))
+	if until, _ := s.SnoozedUntil(at.Add(2 * time.Hour)); !until.Equal(at.Add(3 * time.Hour)) {
+		t.Errorf("SnoozedUntil = %v, want 12:00", until)
+	}
+	if until, _ := s.SnoozedUntil(at.Add(3 * time.Hour)); !until.IsZero() {
+		t.Errorf("snooze should be over at 12:00, got %v", until)
+	}
+
+	want := "# Your earlier check-ins\n\n" +
+		"- \"Drink some water.\": the user acknowledged this at 09:00. Don't bring it up again.\n" +
+		"- \"Time to stretch?\": the user snoozed this until Tue 12:00. Bring it up again only if it still matters."
+	fb, err := s.Current(at.Add(4 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FeedbackSection(fb); got != want {
+		t.Errorf("FeedbackSection =\n%s\nwant\n%s", got, want)
+	}
+
+	// The acknowledgement expires a day after it was given, the snooze a
+	// day after it ended
+	if fb, _ := s.Current(at.Add(25 * time.Hour)); len(fb) != 1 || fb[0].Message != "Time to stretch?" {
+		t.Errorf("Current a day later = %+v", fb)
+	}
+	if fb, _ := s.Current(at.Add(27 * time.Hour)); len(fb) != 0 {
+		t.Errorf("Current after both expired = %+v", fb)
+	}
+
+	s.Add(Feedback{At: at, Until: at.Add(time.Hour)}, at)
+	if err := s.Wake(at.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if until, _ := s.SnoozedUntil(at.Add(2 * time.Minute)); !until.IsZero() {
+		t.Errorf("Wake should end the snooze, got %v", until)
+	}
+	if fb, _ := s.Current(at); FeedbackSection(fb[len(fb)-1:]) != "" {
+		t.Error("a snooze without a message should not be mentioned")
+	}
+}
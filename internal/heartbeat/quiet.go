@@ -0,0 +1,113 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Hours is a daily window such as 08:00–22:00 in local time. A window that
+// ends before it starts runs past midnight; the zero value is the whole day.
+type Hours struct {
+	start, end int // minutes since midnight
+}
+
+// ParseHours reads a window written as "HH:MM-HH:MM". An empty string is
+// the whole day.
+func ParseHours(s string) (Hours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Hours{}, nil
+	}
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, "–", "-"), "-")
+	if !ok {
+		return Hours{}, fmt.Errorf("%q is not a range like 08:00-22:00", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return Hours{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Hours{}, err
+	}
+	if start == end {
+		return Hours{}, fmt.Errorf("%q starts and ends at the same time", s)
+	}
+	return Hours{start: start, end: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 08:00", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t falls inside the window.
+func (h Hours) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	switch {
+	case h.start == h.end:
+		return true
+	case h.start < h.end:
+		return m >= h.start && m < h.end
+	default:
+		return m >= h.start || m < h.end
+	}
+}
+
+// String returns the window as "08:00–22:00", or "any time" for the whole
+// day.
+func (h Hours) String() string {
+	if h.start == h.end {
+		return "any time"
+	}
+	return fmt.Sprintf("%02d:%02d–%02d:%02d", h.start/60, h.start%60, h.end/60, h.end%60)
+}
+
+// OnBattery reports whether the machine runs on battery power. Desktops,
+// unknown platforms and errors count as mains power.
+func OnBattery() bool {
+	switch runtime.GOOS {
+	case "linux":
+		return linuxOnBattery("/sys/class/power_supply")
+	case "darwin":
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+		return err == nil && strings.Contains(string(out), "'Battery Power'")
+	default:
+		return false
+	}
+}
+
+// linuxOnBattery reads the power supplies under dir: a connected AC adapter
+// means mains power, a discharging battery means battery power.
+func linuxOnBattery(dir string) bool {
+	read := func(supply, name string) string {
+		data, _ := os.ReadFile(filepath.Join(supply, name))
+		return strings.TrimSpace(string(data))
+	}
+	supplies, _ := filepath.Glob(filepath.Join(dir, "*"))
+	discharging := false
+	for _, s := range supplies {
+		switch read(s, "type") {
+		case "Mains", "USB":
+			if read(s, "online") == "1" {
+				return false
+			}
+		case "Battery":
+			if read(s, "status") == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+	return discharging
+}
@@ -0,0 +1,81 @@
+package heartbeat
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/cron"
+)
+
+// When is the timing of a named check-in: a fixed interval such as "1h", or
+// a cron expression such as "0 8 * * *" for a morning briefing.
+type When struct {
+	every time.Duration
+	cron  cron.Schedule
+	expr  string
+}
+
+// ParseWhen reads an interval of at least a minute or a cron expression.
+func ParseWhen(s string) (When, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < time.Minute {
+			return When{}, fmt.Errorf("interval %s is too short", d)
+		}
+		return When{every: d, expr: s}, nil
+	}
+	c, err := cron.Parse(s)
+	if err != nil {
+		return When{}, fmt.Errorf("%q is neither an interval nor a cron expression: %v", s, err)
+	}
+	return When{cron: c, expr: s}, nil
+}
+
+// Next returns when the check-in runs next after t: one interval later, or
+// the next time the cron expression matches. It is the zero time if the
+// expression never matches.
+func (w When) Next(t time.Time) time.Time {
+	if w.every > 0 {
+		return t.Add(w.every)
+	}
+	return w.cron.Next(t)
+}
+
+// String returns the timing as written.
+func (w When) String() string {
+	return w.expr
+}
+
+// RenderPrompt executes the prompt template of the named check-in. Besides
+// {{.name}}, it can use {{.date}} (e.g. "Monday, March 9"), {{.weekday}} and
+// {{.time}} (e.g. "08:00") of now.
+func RenderPrompt(name, text string, now time.Time) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing prompt of %s: %w", name, err)
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, map[string]string{
+		"name":    name,
+		"date":    now.Format("Monday, January 2"),
+		"weekday": now.Weekday().String(),
+		"time":    now.Format("15:04"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("executing prompt of %s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// SchedulePrompt builds the request of the named check-in from its rendered
+// prompt. Like Prompt, it lets the model skip and asks for a language other
+// than English explicitly.
+func SchedulePrompt(name, text, lang string) string {
+	p := "[Heartbeat check-in: " + name + "] " + text + "\nIf there's nothing worth saying right now, respond with exactly '" + Skip + "'."
+	if lang != "" && lang != "English" {
+		p += " Respond in " + lang + "."
+	}
+	return p
+}
@@ -0,0 +1,140 @@
+package heartbeat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Idle is the name stats are kept under for the check-in that runs when the
+// user has been idle; named schedules use their own names.
+const Idle = "idle"
+
+// Outcomes of a check-in, for Record.
+const (
+	OutcomeSkipped  = "skipped"  // the model had nothing to say
+	OutcomeProduced = "produced" // a message was shown or published
+	OutcomeReplied  = "replied"  // the user answered the message
+)
+
+// Counts tallies what came of one kind of check-in.
+type Counts struct {
+	Since    time.Time `json:"since"`
+	Skipped  int       `json:"skipped"`
+	Produced int       `json:"produced"`
+	Replied  int       `json:"replied"`
+	Streak   int       `json:"streak"` // skips in a row since the last message
+}
+
+// Total returns the number of check-ins that reached the model.
+func (c Counts) Total() int {
+	return c.Skipped + c.Produced
+}
+
+// String summarizes c, e.g. "12 check-ins: 8 skipped (67%), 4 with a
+// message, 2 replied to".
+func (c Counts) String() string {
+	skipped := fmt.Sprintf("%d skipped", c.Skipped)
+	if c.Total() > 0 {
+		skipped += fmt.Sprintf(" (%d%%)", c.Skipped*100/c.Total())
+	}
+	return fmt.Sprintf("%d check-ins: %s, %d with a message, %d replied to", c.Total(), skipped, c.Produced, c.Replied)
+}
+
+// StatsStore persists Counts per check-in name in a JSON file. It is safe
+// for concurrent use.
+type StatsStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStatsStore creates a store backed by the JSON file at path.
+func NewStatsStore(path string) *StatsStore {
+	return &StatsStore{path: path}
+}
+
+// Stats returns the counts of every check-in that has run.
+func (s *StatsStore) Stats() (map[string]Counts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Record counts an outcome of the named check-in at now and returns the
+// updated counts.
+func (s *StatsStore) Record(name, outcome string, now time.Time) (Counts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, err := s.load()
+	if err != nil {
+		return Counts{}, err
+	}
+	c := stats[name]
+	if c.Since.IsZero() {
+		c.Since = now
+	}
+	switch outcome {
+	case OutcomeSkipped:
+		c.Skipped++
+		c.Streak++
+	case OutcomeProduced:
+		c.Produced++
+		c.Streak = 0
+	case OutcomeReplied:
+		c.Replied++
+	default:
+		return Counts{}, fmt.Errorf("unknown outcome %q", outcome)
+	}
+	stats[name] = c
+	return c, writeJSON(s.path, stats)
+}
+
+// ResetStreak starts counting skips in a row for the named check-in anew,
+// e.g. after its interval was lengthened.
+func (s *StatsStore) ResetStreak(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, err := s.load()
+	if err != nil {
+		return err
+	}
+	c, ok := stats[name]
+	if !ok {
+		return nil
+	}
+	c.Streak = 0
+	stats[name] = c
+	return writeJSON(s.path, stats)
+}
+
+// Reset forgets all counts.
+func (s *StatsStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, map[string]Counts{})
+}
+
+func (s *StatsStore) load() (map[string]Counts, error) {
+	stats := map[string]Counts{}
+	if err := readJSON(s.path, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// TuneSkips is how many check-ins in a row must be skipped before
+// auto-tuning lengthens the interval.
+const TuneSkips = 3
+
+// MaxTunedInterval is the longest interval auto-tuning goes to.
+const MaxTunedInterval = 24 * time.Hour
+
+// Tune returns the interval to use after a check-in with counts c: twice
+// as long, up to MaxTunedInterval, once TuneSkips check-ins in a row had
+// nothing to say. ok is false if the interval stays.
+func Tune(interval time.Duration, c Counts) (longer time.Duration, ok bool) {
+	if c.Streak < TuneSkips || interval >= MaxTunedInterval {
+		return interval, false
+	}
+	return min(2*interval, MaxTunedInterval), true
+}
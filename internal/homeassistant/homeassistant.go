@@ -0,0 +1,204 @@
+// Package homeassistant reads entity states from, and calls services on, a
+// Home Assistant instance through its REST API. Only allowlisted entities
+// are visible.
+package homeassistant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Services are the service calls the model may make on a controllable
+// entity; Home Assistant rejects those a domain doesn't offer.
+var Services = []string{"turn_on", "turn_off", "toggle", "lock", "unlock", "open_cover", "close_cover"}
+
+// State is the current state of an entity.
+type State struct {
+	EntityID    string         `json:"entity_id"`
+	State       string         `json:"state"`
+	Attributes  map[string]any `json:"attributes"`
+	LastChanged time.Time      `json:"last_changed"`
+}
+
+// Name returns the entity's friendly name, or its ID.
+func (s State) Name() string {
+	if name, ok := s.Attributes["friendly_name"].(string); ok && name != "" {
+		return name
+	}
+	return s.EntityID
+}
+
+// Describe formats s for the model, e.g. "Front Door (binary_sensor.front_door):
+// on for 1h5m".
+func (s State) Describe(now time.Time) string {
+	text := fmt.Sprintf("%s (%s): %s", s.Name(), s.EntityID, s.State)
+	if unit, ok := s.Attributes["unit_of_measurement"].(string); ok {
+		text += " " + unit
+	}
+	if !s.LastChanged.IsZero() {
+		text += " for " + since(now.Sub(s.LastChanged))
+	}
+	return text
+}
+
+// since formats d in whole minutes: "under a minute", "5m", "1h5m", "2h".
+func since(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "under a minute"
+	}
+	text := strings.TrimSuffix(d.String(), "0s")
+	if d%time.Hour == 0 {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// Client talks to Home Assistant.
+type Client struct {
+	cfg     config.HomeConfig
+	resolve func(string) (string, error)
+	http    *http.Client
+}
+
+// New creates a client for cfg. resolve turns the token into a secret
+// (e.g. a keyring: reference); nil uses it as-is.
+func New(cfg config.HomeConfig, resolve func(string) (string, error)) *Client {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	return &Client{cfg: cfg, resolve: resolve, http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// WithConfig returns a client for cfg that resolves tokens like c.
+func (c *Client) WithConfig(cfg config.HomeConfig) *Client {
+	var resolve func(string) (string, error)
+	if c != nil {
+		resolve = c.resolve
+	}
+	return New(cfg, resolve)
+}
+
+// Configured reports whether a URL and at least one entity are set.
+func (c *Client) Configured() bool {
+	return c != nil && c.cfg.URL != "" && len(c.cfg.Entities)+len(c.cfg.Control) > 0
+}
+
+// Controls reports whether any entity may be switched.
+func (c *Client) Controls() bool {
+	return c.Configured() && len(c.cfg.Control) > 0
+}
+
+// CanControl reports whether the model may call services on entity.
+func (c *Client) CanControl(entity string) bool {
+	return c.Configured() && matches(c.cfg.Control, entity)
+}
+
+// canRead reports whether entity is visible; controllable entities are.
+func (c *Client) canRead(entity string) bool {
+	return matches(c.cfg.Entities, entity) || matches(c.cfg.Control, entity)
+}
+
+func matches(patterns []string, entity string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := path.Match(p, entity)
+		return ok
+	})
+}
+
+// States returns the states of all visible entities, sorted by ID.
+func (c *Client) States(ctx context.Context) ([]State, error) {
+	var all []State
+	if err := c.do(ctx, http.MethodGet, "/api/states", nil, &all); err != nil {
+		return nil, err
+	}
+	var out []State
+	for _, s := range all {
+		if c.canRead(s.EntityID) {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EntityID < out[j].EntityID })
+	return out, nil
+}
+
+// State returns the state of one visible entity.
+func (c *Client) State(ctx context.Context, entity string) (State, error) {
+	if !c.canRead(entity) {
+		return State{}, fmt.Errorf("%s is not in home_assistant.entities", entity)
+	}
+	var s State
+	err := c.do(ctx, http.MethodGet, "/api/states/"+entity, nil, &s)
+	return s, err
+}
+
+// Call runs service (e.g. "turn_off") on a controllable entity.
+func (c *Client) Call(ctx context.Context, entity, service string) error {
+	if !c.CanControl(entity) {
+		return fmt.Errorf("%s is not in home_assistant.control", entity)
+	}
+	if !slices.Contains(Services, service) {
+		return fmt.Errorf("unsupported service %q (use %s)", service, strings.Join(Services, ", "))
+	}
+	domain, _, _ := strings.Cut(entity, ".")
+	body, _ := json.Marshal(map[string]string{"entity_id": entity})
+	return c.do(ctx, http.MethodPost, "/api/services/"+domain+"/"+service, body, nil)
+}
+
+// PromptSection describes states for the system prompt.
+func PromptSection(states []State, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("## Home\n\nCurrent state of the user's home:\n")
+	for _, s := range states {
+		b.WriteString("\n- " + s.Describe(now))
+	}
+	return b.String()
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body []byte, out any) error {
+	if !c.Configured() {
+		return fmt.Errorf("Home Assistant is not configured (home_assistant in config.yaml)")
+	}
+	token, err := c.resolve(c.cfg.Token)
+	if err != nil {
+		return fmt.Errorf("home assistant token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.cfg.URL, "/")+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("home assistant: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("home assistant: token rejected")
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("home assistant: %s not found", strings.TrimPrefix(endpoint, "/api/"))
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("home assistant: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(out); err != nil {
+		return fmt.Errorf("home assistant: decoding response: %w", err)
+	}
+	return nil
+}
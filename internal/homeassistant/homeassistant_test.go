@@ -0,0 +1,134 @@
+package homeassistant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// fakeHA serves a few states and records service calls.
+func fakeHA(t *testing.T, calls *[]string) *httptest.Server {
+	t.Helper()
+	changed := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	states := []State{
+		{EntityID: "lock.front_door", State: "unlocked", LastChanged: changed},
+		{EntityID: "binary_sensor.front_door", State: "on", Attributes: map[string]any{"friendly_name": "Front Door"}, LastChanged: changed},
+		{EntityID: "light.kitchen", State: "off", LastChanged: changed},
+		{EntityID: "camera.garden", State: "idle", LastChanged: changed},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/states":
+			json.NewEncoder(w).Encode(states)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/states/"):
+			id := strings.TrimPrefix(r.URL.Path, "/api/states/")
+			for _, s := range states {
+				if s.EntityID == id {
+					json.NewEncoder(w).Encode(s)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/services/"):
+			body, _ := io.ReadAll(r.Body)
+			*calls = append(*calls, strings.TrimPrefix(r.URL.Path, "/api/services/")+" "+string(body))
+			w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	var calls []string
+	srv := fakeHA(t, &calls)
+	c := New(config.HomeConfig{
+		URL:      srv.URL + "/",
+		Token:    "keyring:ha",
+		Entities: []string{"binary_sensor.front_door", "lock.*"},
+		Control:  []string{"light.kitchen"},
+	}, func(v string) (string, error) { return "secret", nil })
+	ctx := context.Background()
+
+	states, err := c.States(ctx)
+	if err != nil {
+		t.Fatalf("States() error: %v", err)
+	}
+	var ids []string
+	for _, s := range states {
+		ids = append(ids, s.EntityID)
+	}
+	if got := strings.Join(ids, " "); got != "binary_sensor.front_door light.kitchen lock.front_door" {
+		t.Errorf("States() = %s, want only the allowed entities", got)
+	}
+
+	now := time.Date(2026, 3, 10, 14, 5, 0, 0, time.UTC)
+	s, err := c.State(ctx, "binary_sensor.front_door")
+	if err != nil {
+		t.Fatalf("State() error: %v", err)
+	}
+	if got, want := s.Describe(now), "Front Door (binary_sensor.front_door): on for 1h5m"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if _, err := c.State(ctx, "camera.garden"); err == nil {
+		t.Error("State() of an unlisted entity should fail")
+	}
+
+	if err := c.Call(ctx, "light.kitchen", "turn_on"); err != nil {
+		t.Fatalf("Call() error: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != `light/turn_on {"entity_id":"light.kitchen"}` {
+		t.Errorf("calls = %q", calls)
+	}
+	if err := c.Call(ctx, "lock.front_door", "unlock"); err == nil {
+		t.Error("Call() on a read-only entity should fail")
+	}
+	if err := c.Call(ctx, "light.kitchen", "reload"); err == nil {
+		t.Error("Call() with an unsupported service should fail")
+	}
+	if len(calls) != 1 {
+		t.Errorf("rejected calls reached Home Assistant: %q", calls)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	var calls []string
+	srv := fakeHA(t, &calls)
+	ctx := context.Background()
+
+	var nilClient *Client
+	if _, err := nilClient.States(ctx); err == nil || nilClient.Configured() {
+		t.Error("a nil client should not be configured")
+	}
+	c := New(config.HomeConfig{URL: srv.URL, Token: "wrong", Entities: []string{"light.*"}}, nil)
+	if _, err := c.States(ctx); err == nil || !strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("States() error = %v, want the token to be rejected", err)
+	}
+	c = c.WithConfig(config.HomeConfig{URL: srv.URL, Token: "secret", Entities: []string{"light.*"}})
+	if _, err := c.State(ctx, "light.hall"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("State() error = %v, want not found", err)
+	}
+}
+
+func TestPromptSection(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	got := PromptSection([]State{
+		{EntityID: "sensor.living_room", State: "21.5", Attributes: map[string]any{"unit_of_measurement": "°C"}, LastChanged: now.Add(-20 * time.Second)},
+	}, now)
+	if !strings.HasPrefix(got, "## Home") || !strings.Contains(got, "- sensor.living_room (sensor.living_room): 21.5 °C for under a minute") {
+		t.Errorf("PromptSection() = %q", got)
+	}
+}
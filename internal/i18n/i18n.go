@@ -0,0 +1,108 @@
+// Package i18n translates the interface text of stefanclaw — system
+// messages, help, labels and onboarding prompts — into the configured
+// language. Catalogs are embedded JSON files named after the language code
+// (catalogs/de.json) that map the English text to its translation. Text
+// missing from a catalog, and every language without one, stays English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+var (
+	loadOnce sync.Once
+	catalogs map[string]map[string]string // by language code
+	loadErr  error
+)
+
+// load parses the embedded catalogs once.
+func load() (map[string]map[string]string, error) {
+	loadOnce.Do(func() {
+		catalogs = make(map[string]map[string]string)
+		entries, err := catalogFS.ReadDir("catalogs")
+		if err != nil {
+			loadErr = err
+			return
+		}
+		for _, e := range entries {
+			data, err := catalogFS.ReadFile(path.Join("catalogs", e.Name()))
+			if err != nil {
+				loadErr = err
+				return
+			}
+			var messages map[string]string
+			if err := json.Unmarshal(data, &messages); err != nil {
+				loadErr = fmt.Errorf("catalog %s: %w", e.Name(), err)
+				return
+			}
+			catalogs[strings.TrimSuffix(e.Name(), ".json")] = messages
+		}
+	})
+	return catalogs, loadErr
+}
+
+// Translator translates English interface text into one language. A nil
+// Translator leaves text in English.
+type Translator struct {
+	code     string
+	messages map[string]string
+}
+
+// For returns the Translator for a language setting (code or name, native or
+// English, as in config.yaml), or nil if there is no catalog for it.
+func For(language string) *Translator {
+	code := config.LanguageCode(language)
+	all, _ := load()
+	messages, ok := all[code]
+	if !ok || code == "en" {
+		return nil
+	}
+	return &Translator{code: code, messages: messages}
+}
+
+// Code returns the language code of the catalog, or "en" for nil.
+func (t *Translator) Code() string {
+	if t == nil {
+		return "en"
+	}
+	return t.code
+}
+
+// T returns the translation of s, or s itself if it has none.
+func (t *Translator) T(s string) string {
+	if t == nil {
+		return s
+	}
+	if tr, ok := t.messages[s]; ok && tr != "" {
+		return tr
+	}
+	return s
+}
+
+// Sprintf translates format and then formats it like fmt.Sprintf.
+// Translations must use the same verbs in the same order as the English.
+func (t *Translator) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(t.T(format), args...)
+}
+
+// Languages returns the codes of the embedded catalogs, sorted.
+func Languages() []string {
+	all, _ := load()
+	codes := make([]string, 0, len(all))
+	for code := range all {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,59 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	for _, lang := range []string{"Deutsch", "german", "de"} {
+		if got := For(lang).Code(); got != "de" {
+			t.Errorf("For(%q).Code() = %q, want de", lang, got)
+		}
+	}
+	for _, lang := range []string{"English", "", "Klingon"} {
+		if tr := For(lang); tr != nil {
+			t.Errorf("For(%q) = %v, want nil", lang, tr)
+		}
+	}
+}
+
+func TestTranslator_T(t *testing.T) {
+	tr := For("Deutsch")
+	if got := tr.T("Goodbye!"); got != "Auf Wiedersehen!" {
+		t.Errorf("T = %q", got)
+	}
+	if got := tr.T("not in any catalog"); got != "not in any catalog" {
+		t.Errorf("missing text should stay English, got %q", got)
+	}
+	if got := tr.Sprintf("Switched to model: %s", "qwen3:8b"); got != "Zu Modell gewechselt: qwen3:8b" {
+		t.Errorf("Sprintf = %q", got)
+	}
+
+	var none *Translator
+	if got := none.Sprintf("Error: %v", "boom"); got != "Error: boom" {
+		t.Errorf("nil Translator should format the English, got %q", got)
+	}
+}
+
+// verb matches fmt verbs and the escaped percent sign, which is matched
+// whole so that "%% of" doesn't read as the verb "% o".
+var verb = regexp.MustCompile(`%(?:%|[-+# 0-9.]*[a-zA-Z])`)
+
+func TestCatalogs_KeepFormatVerbs(t *testing.T) {
+	all, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(Languages(), "de") {
+		t.Fatalf("Languages() = %v, want de", Languages())
+	}
+	for code, messages := range all {
+		for en, tr := range messages {
+			if !slices.Equal(verb.FindAllString(en, -1), verb.FindAllString(tr, -1)) {
+				t.Errorf("%s: %q -> %q changes the format verbs", code, en, tr)
+			}
+		}
+	}
+}
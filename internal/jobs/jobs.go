@@ -0,0 +1,112 @@
+// Package jobs runs automation jobs: prompts configured under jobs: in
+// config.yaml that run on a cron schedule without the user in the loop.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/cron"
+	"github.com/stefanclaw/stefanclaw/internal/email"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Outputs a job's answer can be delivered to.
+const (
+	OutputNotification = "notification"
+	OutputFile         = "file"
+	OutputMemory       = "memory"
+	OutputEmail        = "email"
+)
+
+// Targets are the stores a job's answer may be delivered to besides files.
+// Either may be nil if unavailable.
+type Targets struct {
+	Memory *memory.Store
+	Mail   *email.Sender
+}
+
+// Next returns the next time after t that job is due, or the zero time if its
+// schedule is invalid or never matches.
+func Next(job config.JobConfig, t time.Time) time.Time {
+	s, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return s.Next(t)
+}
+
+// Find returns the job with the given name.
+func Find(jobs []config.JobConfig, name string) (config.JobConfig, bool) {
+	for _, j := range jobs {
+		if j.Name == name {
+			return j, true
+		}
+	}
+	return config.JobConfig{}, false
+}
+
+// Run sends job's prompt to the model with r and returns the final answer.
+func Run(ctx context.Context, r *agent.Runner, job config.JobConfig) (string, error) {
+	return r.Run(ctx, job.Model, []provider.Message{{Role: "user", Content: job.Prompt}})
+}
+
+// Deliver stores a job's answer according to its output setting. Answers for
+// notifications are left to the caller to display. Relative file paths are
+// resolved against the home directory.
+func Deliver(job config.JobConfig, answer string, now time.Time, to Targets) error {
+	switch job.Output {
+	case "", OutputNotification:
+		return nil
+	case OutputEmail:
+		return to.Mail.Send(fmt.Sprintf("stefanclaw: %s — %s", job.Name, now.Format("Mon 2006-01-02")), answer)
+	case OutputMemory:
+		if to.Memory == nil {
+			return fmt.Errorf("memory is not available")
+		}
+		return to.Memory.Append([]string{job.Name + ": " + strings.Join(strings.Fields(answer), " ")})
+	case OutputFile:
+		home, _ := os.UserHomeDir()
+		path := config.ExpandPath(job.Path, home)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(f, "## %s — %s\n\n%s\n\n", job.Name, now.Format("2006-01-02 15:04"), answer)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return fmt.Errorf("unknown output %q", job.Output)
+}
+
+// Describe returns where a job's answer goes, e.g. "file ~/notes/digest.md".
+func Describe(job config.JobConfig) string {
+	switch job.Output {
+	case "", OutputNotification:
+		return OutputNotification
+	case OutputFile:
+		return OutputFile + " " + job.Path
+	}
+	return job.Output
+}
+
+// Summary reports where a finished job's answer went, for outputs other
+// than notification.
+func Summary(job config.JobConfig) string {
+	if job.Output == OutputEmail {
+		return fmt.Sprintf("Job %s finished; output sent by email.", job.Name)
+	}
+	return fmt.Sprintf("Job %s finished; output saved to %s.", job.Name, Describe(job))
+}
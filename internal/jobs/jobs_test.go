@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestRun(t *testing.T) {
+	p := providertest.New(providertest.Text("Here is your digest."))
+	r := &agent.Runner{Provider: p, Model: "default-model"}
+
+	answer, err := Run(context.Background(), r, config.JobConfig{Prompt: "Summarize the news.", Model: "job-model"})
+	if err != nil || answer != "Here is your digest." {
+		t.Fatalf("Run() = %q, %v", answer, err)
+	}
+	if p.LastRequest().Model != "job-model" {
+		t.Errorf("model = %q, want the job's model", p.LastRequest().Model)
+	}
+	if msgs := p.LastRequest().Messages; len(msgs) != 1 || msgs[0].Content != "Summarize the news." {
+		t.Errorf("messages = %+v, want the job's prompt", msgs)
+	}
+}
+
+func TestDeliver_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes", "digest.md")
+	job := config.JobConfig{Name: "digest", Output: OutputFile, Path: path}
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.Local)
+
+	if err := Deliver(job, "First.", now, Targets{}); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+	if err := Deliver(job, "Second.", now.Add(24*time.Hour), Targets{}); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## digest — 2026-03-02 08:00\n\nFirst.\n\n## digest — 2026-03-03 08:00\n\nSecond.\n\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestDeliver_Memory(t *testing.T) {
+	mem := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	job := config.JobConfig{Name: "digest", Output: OutputMemory}
+
+	if err := Deliver(job, "Go 1.26 is out.\nRust too.", time.Now(), Targets{Memory: mem}); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+	entries, err := mem.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0], "digest: Go 1.26 is out. Rust too.") {
+		t.Errorf("entries = %q", entries)
+	}
+}
+
+func TestNext(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 30, 0, 0, time.Local)
+	if got := Next(config.JobConfig{Schedule: "0 8 * * *"}, now); !got.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("Next() = %v", got)
+	}
+	if got := Next(config.JobConfig{Schedule: "bogus"}, now); !got.IsZero() {
+		t.Errorf("Next() with a bad schedule = %v, want zero", got)
+	}
+}
@@ -0,0 +1,623 @@
+// Package knowledge is the knowledge base: the user's notes and documents,
+// split into passages and indexed by embeddings so they can be found by
+// meaning rather than by exact words.
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+const (
+	// maxFileSize skips larger files, which are rarely notes.
+	maxFileSize = 1 << 20
+	// batchSize is the number of passages embedded per request.
+	batchSize = 16
+)
+
+var (
+	// ErrNoDirs is returned by Index when there are no directories to index.
+	ErrNoDirs = errors.New("no knowledge directories configured; add them under knowledge.dirs")
+	// ErrNotIndexed is returned by Search before anything has been indexed.
+	ErrNotIndexed = errors.New("the knowledge base is empty; index it with /kb index")
+	// ErrBusy is returned by Index while another indexing run is going on.
+	ErrBusy = errors.New("indexing is already running")
+)
+
+// Chunk is one passage of a file.
+type Chunk struct {
+	Path string    // absolute path of the file
+	Line int       // first line of the passage, from 1
+	Text string    // the passage
+	Vec  []float32 // normalized embedding
+}
+
+// indexedFile holds the passages of one file.
+type indexedFile struct {
+	ModTime time.Time
+	Chunks  []Chunk
+}
+
+// index is what is saved to the index file.
+type index struct {
+	Model        string // embedding model the vectors were made with
+	ChunkSize    int
+	ChunkOverlap int
+	Updated      time.Time
+	Files        map[string]*indexedFile
+}
+
+// Result is a passage found by Search.
+type Result struct {
+	Chunk
+	Score float64 // cosine similarity to the query
+}
+
+// Stats describes the index.
+type Stats struct {
+	Files, Chunks int
+	Model         string
+	Updated       time.Time // zero if never indexed
+}
+
+// Base is a knowledge base stored in an index file. It is safe for
+// concurrent use; searches keep working while it is being indexed.
+type Base struct {
+	cfg  config.KnowledgeConfig
+	path string
+	emb  provider.Embedder
+
+	building sync.Mutex // held during Index
+
+	mu  sync.Mutex
+	idx *index // loaded on first use
+}
+
+// New creates a knowledge base for cfg, with its index in the file at path.
+// emb computes the embeddings; it may be nil if the provider has none, in
+// which case indexing and searching fail.
+func New(cfg config.KnowledgeConfig, path string, emb provider.Embedder) *Base {
+	return &Base{cfg: cfg, path: path, emb: emb}
+}
+
+// Dirs returns the configured directories, expanded.
+func (b *Base) Dirs() []string {
+	home, _ := os.UserHomeDir()
+	var dirs []string
+	for _, d := range b.cfg.Dirs {
+		dirs = append(dirs, config.ExpandPath(d, home))
+	}
+	return dirs
+}
+
+// Stats returns the size of the index.
+func (b *Base) Stats() (Stats, error) {
+	idx, err := b.load()
+	if err != nil {
+		return Stats{}, err
+	}
+	st := Stats{Model: idx.Model, Updated: idx.Updated}
+	for _, f := range idx.Files {
+		if len(f.Chunks) > 0 {
+			st.Files++
+			st.Chunks += len(f.Chunks)
+		}
+	}
+	return st, nil
+}
+
+// Progress is called while indexing: done of total files have been looked
+// at, and path is the file being embedded.
+type Progress func(done, total int, path string)
+
+// Report describes the files an indexing run embedded, dropped and kept.
+// For Pending, it is what the next run would do.
+type Report struct {
+	Stats
+	Added, Changed, Removed, Unchanged int
+}
+
+// Summary describes the run in one line, e.g. "2 new, 1 changed, 0
+// removed, 40 unchanged; 42 files in 310 passages".
+func (r Report) Summary() string {
+	return fmt.Sprintf("%d new, %d changed, %d removed, %d unchanged; %s",
+		r.Added, r.Changed, r.Removed, r.Unchanged, r.Size())
+}
+
+// Size describes the size of the index, e.g. "42 files in 310 passages".
+func (s Stats) Size() string {
+	return fmt.Sprintf("%d file%s in %d passage%s", s.Files, plural(s.Files), s.Chunks, plural(s.Chunks))
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// Index brings the index up to date: new and modified files are embedded,
+// deleted ones dropped and the rest kept. dirs limits the run to some
+// directories, written as in knowledge.dirs; files elsewhere in the index
+// are left alone. nil means the configured directories. If the embedding
+// model or the chunking changed, everything is embedded again.
+//
+// progress may be nil. When indexing fails or is cancelled, the files done
+// so far are saved, so the next run continues from there.
+func (b *Base) Index(ctx context.Context, dirs []string, progress Progress) (Report, error) {
+	return b.update(ctx, dirs, false, progress)
+}
+
+// Rebuild embeds all files in dirs again, as Index does after a model
+// change.
+func (b *Base) Rebuild(ctx context.Context, dirs []string, progress Progress) (Report, error) {
+	return b.update(ctx, dirs, true, progress)
+}
+
+// Pending reports what Index would do, without embedding anything.
+func (b *Base) Pending(dirs []string) (Report, error) {
+	scope, files, err := b.scan(dirs)
+	if err != nil {
+		return Report{}, err
+	}
+	old, err := b.load()
+	if err != nil {
+		return Report{}, err
+	}
+	var r Report
+	reuse := b.compatible(old)
+	seen := map[string]bool{}
+	for _, f := range files {
+		seen[f.path] = true
+		switch prev := old.Files[f.path]; {
+		case prev == nil:
+			r.Added++
+		case reuse && prev.ModTime.Equal(f.info.ModTime()):
+			r.Unchanged++
+		default:
+			r.Changed++
+		}
+	}
+	for path := range old.Files {
+		if !seen[path] && (within(path, scope) || !reuse) {
+			r.Removed++
+		}
+	}
+	r.Stats, err = b.Stats()
+	return r, err
+}
+
+// compatible reports whether the passages in idx can be kept: they were cut
+// and embedded the way the config says.
+func (b *Base) compatible(idx *index) bool {
+	return idx.Model == b.cfg.EmbedModel && idx.ChunkSize == b.cfg.ChunkSize && idx.ChunkOverlap == b.cfg.ChunkOverlap
+}
+
+// scan expands dirs, or the configured directories if it is nil, and lists
+// the files in them.
+func (b *Base) scan(dirs []string) ([]string, []candidate, error) {
+	scope := b.Dirs()
+	if dirs != nil {
+		home, _ := os.UserHomeDir()
+		scope = nil
+		for _, d := range dirs {
+			scope = append(scope, config.ExpandPath(d, home))
+		}
+	}
+	if len(scope) == 0 {
+		return nil, nil, ErrNoDirs
+	}
+	files, err := b.files(scope)
+	return scope, files, err
+}
+
+// within reports whether path is inside one of dirs.
+func within(path string, dirs []string) bool {
+	for _, d := range dirs {
+		if strings.HasPrefix(path, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Base) update(ctx context.Context, dirs []string, full bool, progress Progress) (Report, error) {
+	if b.emb == nil {
+		return Report{}, errors.New("the provider cannot compute embeddings")
+	}
+	scope, files, err := b.scan(dirs)
+	if err != nil {
+		return Report{}, err
+	}
+	if !b.building.TryLock() {
+		return Report{}, ErrBusy
+	}
+	defer b.building.Unlock()
+
+	// An unreadable index is replaced; an incompatible one is of no use
+	old, err := b.load()
+	if err != nil {
+		old = &index{}
+	}
+	compatible := err == nil && b.compatible(old)
+	idx := &index{
+		Model:        b.cfg.EmbedModel,
+		ChunkSize:    b.cfg.ChunkSize,
+		ChunkOverlap: b.cfg.ChunkOverlap,
+		Files:        map[string]*indexedFile{},
+	}
+	if compatible {
+		for path, f := range old.Files {
+			if !within(path, scope) {
+				idx.Files[path] = f
+			}
+		}
+	}
+
+	var r Report
+	for i, f := range files {
+		prev := old.Files[f.path]
+		if compatible && !full && prev != nil && prev.ModTime.Equal(f.info.ModTime()) {
+			idx.Files[f.path] = prev
+			r.Unchanged++
+			continue
+		}
+		if progress != nil {
+			progress(i, len(files), f.path)
+		}
+		if err = b.indexFile(ctx, idx, f); err != nil {
+			// Keep the old passages of the files not done yet; their
+			// modification time makes the next run look at them again
+			if compatible {
+				for _, rest := range files[i:] {
+					if prev := old.Files[rest.path]; prev != nil {
+						idx.Files[rest.path] = prev
+					}
+				}
+			}
+			break
+		}
+		if prev != nil {
+			r.Changed++
+		} else {
+			r.Added++
+		}
+	}
+	if err == nil && progress != nil {
+		progress(len(files), len(files), "")
+	}
+	for path := range old.Files {
+		if idx.Files[path] == nil {
+			r.Removed++
+		}
+	}
+	idx.Updated = time.Now()
+
+	b.mu.Lock()
+	b.idx = idx
+	b.mu.Unlock()
+	if serr := b.save(idx); err == nil {
+		err = serr
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	r.Stats, err = b.Stats()
+	return r, err
+}
+
+// indexFile reads, splits and embeds one file into idx. Binary files get
+// no passages, but are recorded so they are not read again until they
+// change.
+func (b *Base) indexFile(ctx context.Context, idx *index, f candidate) error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil // skipped; tried again next time
+	}
+	var chunks []Chunk
+	if bytes.IndexByte(data, 0) < 0 && utf8.Valid(data) {
+		chunks = split(f.path, string(data), b.cfg.ChunkSize, b.cfg.ChunkOverlap)
+	}
+	if err := b.embed(ctx, chunks); err != nil {
+		return fmt.Errorf("embedding %s: %w", f.path, err)
+	}
+	idx.Files[f.path] = &indexedFile{ModTime: f.info.ModTime(), Chunks: chunks}
+	return nil
+}
+
+// candidate is a file that may be indexed.
+type candidate struct {
+	path string
+	info fs.FileInfo
+}
+
+// files lists the files to index in dirs. Hidden directories and
+// dependency folders are skipped, as are large files.
+func (b *Base) files(dirs []string) ([]candidate, error) {
+	exts := map[string]bool{}
+	for _, e := range b.cfg.Extensions {
+		exts[strings.ToLower(e)] = true
+	}
+	var files []candidate
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("knowledge directory: %w", err)
+		}
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // unreadable entries are skipped
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if seen[path] || strings.HasPrefix(name, ".") || !exts[strings.ToLower(filepath.Ext(name))] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+				return nil
+			}
+			seen[path] = true
+			files = append(files, candidate{path, info})
+			return nil
+		})
+	}
+	return files, nil
+}
+
+// split cuts text into passages of about size bytes along line breaks,
+// preferring to start a passage at a Markdown heading. Up to overlap bytes
+// of lines at the end of a passage are repeated at the start of the next.
+func split(path, text string, chunkSize, overlap int) []Chunk {
+	type line struct {
+		text string
+		n    int
+	}
+	var lines []line
+	for i, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		// Break up very long lines, e.g. minified code
+		for len(l) > chunkSize {
+			cut := chunkSize
+			for cut > 0 && !utf8.RuneStart(l[cut]) {
+				cut--
+			}
+			lines = append(lines, line{l[:cut], i + 1})
+			l = l[cut:]
+		}
+		lines = append(lines, line{l, i + 1})
+	}
+
+	var chunks []Chunk
+	var cur []line
+	size := 0
+	flush := func() {
+		var b strings.Builder
+		for _, l := range cur {
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		if t := strings.TrimSpace(b.String()); t != "" {
+			chunks = append(chunks, Chunk{Path: path, Line: cur[0].n, Text: t})
+		}
+	}
+	for _, l := range lines {
+		heading := strings.HasPrefix(l.text, "#") && size > chunkSize/3
+		if len(cur) > 0 && (size+len(l.text) > chunkSize || heading) {
+			flush()
+			// Carry the last lines over, unless a new section starts
+			var keep []line
+			kept := 0
+			if !heading {
+				for j := len(cur) - 1; j > 0 && kept+len(cur[j].text) < overlap; j-- {
+					keep = append([]line{cur[j]}, keep...)
+					kept += len(cur[j].text) + 1
+				}
+			}
+			cur, size = keep, kept
+		}
+		cur = append(cur, l)
+		size += len(l.text) + 1
+	}
+	if len(cur) > 0 {
+		flush()
+	}
+	return chunks
+}
+
+// embed fills in the vectors of chunks. The file name is embedded with each
+// passage, since it often says what the passage is about.
+func (b *Base) embed(ctx context.Context, chunks []Chunk) error {
+	for i := 0; i < len(chunks); i += batchSize {
+		batch := chunks[i:min(i+batchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for j, c := range batch {
+			texts[j] = filepath.Base(c.Path) + "\n\n" + c.Text
+		}
+		vecs, err := b.emb.Embed(ctx, b.cfg.EmbedModel, texts)
+		if err != nil {
+			return err
+		}
+		if len(vecs) != len(batch) {
+			return fmt.Errorf("got %d embeddings for %d passages", len(vecs), len(batch))
+		}
+		for j := range batch {
+			batch[j].Vec = normalize(vecs[j])
+		}
+	}
+	return nil
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	n := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / n
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+// Search returns the passages most similar to query, best first: at most
+// top_k of them, each at least min_score similar.
+func (b *Base) Search(ctx context.Context, query string) ([]Result, error) {
+	if b.emb == nil {
+		return nil, errors.New("the provider cannot compute embeddings")
+	}
+	idx, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	if idx.Updated.IsZero() {
+		return nil, ErrNotIndexed
+	}
+	if idx.Model != b.cfg.EmbedModel {
+		return nil, fmt.Errorf("the index was made with %s, not %s; index again with /kb index", idx.Model, b.cfg.EmbedModel)
+	}
+	vecs, err := b.emb.Embed(ctx, b.cfg.EmbedModel, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("got %d embeddings for the question", len(vecs))
+	}
+	q := normalize(vecs[0])
+
+	var results []Result
+	for _, f := range idx.Files {
+		for _, c := range f.Chunks {
+			if score := dot(q, c.Vec); score >= b.cfg.MinScore {
+				results = append(results, Result{Chunk: c, Score: score})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if len(results) > b.cfg.TopK {
+		results = results[:b.cfg.TopK]
+	}
+	return results, nil
+}
+
+// load returns the index, reading the index file on first use.
+func (b *Base) load() (*index, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.idx != nil {
+		return b.idx, nil
+	}
+	idx := &index{Files: map[string]*indexedFile{}}
+	f, err := os.Open(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		b.idx = idx
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(idx); err != nil {
+		return nil, fmt.Errorf("reading knowledge index %s: %w; index again with /kb index", b.path, err)
+	}
+	if idx.Files == nil {
+		idx.Files = map[string]*indexedFile{}
+	}
+	b.idx = idx
+	return idx, nil
+}
+
+// save writes the index file atomically.
+func (b *Base) save(idx *index) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(idx); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// Source names where a passage comes from, e.g. "~/notes/plants.md:12".
+func (r Result) Source() string {
+	return fmt.Sprintf("%s:%d", ShortPath(r.Path), r.Line)
+}
+
+// ShortPath writes a path in the home directory as ~/...
+func ShortPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && path != "" {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+// Augment appends the passages that match question to it, for the model to
+// answer from. Without matches, or if searching fails, question is returned
+// unchanged, with the error.
+func (b *Base) Augment(ctx context.Context, question string) (string, []Result, error) {
+	results, err := b.Search(ctx, question)
+	if err != nil || len(results) == 0 {
+		return question, nil, err
+	}
+	var notes []string
+	for _, r := range results {
+		notes = append(notes, fmt.Sprintf("<note source=\"%s\">\n%s\n</note>", r.Source(), r.Text))
+	}
+	return question + "\n\n" +
+		"The following passages from my notes were found for reference. " +
+		"Answer from them where they are relevant and name the source you used; " +
+		"ignore the ones that are not.\n\n" +
+		strings.Join(notes, "\n\n"), results, nil
+}
@@ -0,0 +1,286 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// wordEmbedder embeds text as a bag of words, so texts sharing words are
+// similar.
+type wordEmbedder struct{}
+
+func (wordEmbedder) Embed(_ context.Context, _ string, texts []string) ([][]float32, error) {
+	var out [][]float32
+	for _, t := range texts {
+		v := make([]float32, 64)
+		for _, w := range strings.FieldsFunc(strings.ToLower(t), func(r rune) bool { return r < 'a' || r > 'z' }) {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%64]++
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// countingEmbedder counts the texts it embeds and fails once limit is
+// reached, if limit is set.
+type countingEmbedder struct {
+	texts, limit int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	if e.limit > 0 && e.texts+len(texts) > e.limit {
+		return nil, errors.New("server went away")
+	}
+	e.texts += len(texts)
+	return wordEmbedder{}.Embed(ctx, model, texts)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testConfig(dirs ...string) config.KnowledgeConfig {
+	cfg := config.Defaults().Knowledge
+	cfg.Dirs = dirs
+	cfg.MinScore = 0.3
+	return cfg
+}
+
+func TestIndexAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "garden.md"), "# Garden\n\nWater the tomatoes every morning in summer.\n")
+	writeFile(t, filepath.Join(dir, "work", "meetings.txt"), "The standup meeting is at nine on weekdays.\n")
+	writeFile(t, filepath.Join(dir, ".git", "config.md"), "water tomatoes\n")
+	writeFile(t, filepath.Join(dir, "node_modules", "x.md"), "water tomatoes\n")
+	writeFile(t, filepath.Join(dir, "photo.png"), "water tomatoes\n")
+	writeFile(t, filepath.Join(dir, "binary.txt"), "water\x00tomatoes\n")
+
+	emb := &wordEmbedder{}
+	path := filepath.Join(t.TempDir(), "knowledge.gob")
+	b := New(testConfig(dir), path, emb)
+	r, err := b.Index(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if st := r.Stats; st.Files != 2 || st.Chunks != 2 || st.Model != "nomic-embed-text" || st.Updated.IsZero() {
+		t.Errorf("Stats = %+v, want 2 files with 2 passages", st)
+	}
+
+	results, err := b.Search(context.Background(), "when do I water the tomatoes")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || filepath.Base(results[0].Path) != "garden.md" || results[0].Line != 1 {
+		t.Fatalf("results = %+v, want garden.md:1 first", results)
+	}
+	for _, r := range results {
+		if r.Score < 0.3 {
+			t.Errorf("result %s has score %v below min_score", r.Source(), r.Score)
+		}
+	}
+
+	// A new instance reads the saved index
+	b = New(testConfig(dir), path, emb)
+	if st, err := b.Stats(); err != nil || st.Files != 2 {
+		t.Errorf("Stats after reload = %+v, %v", st, err)
+	}
+
+	// Changing the model requires indexing again
+	cfg := testConfig(dir)
+	cfg.EmbedModel = "other-model"
+	if _, err := New(cfg, path, emb).Search(context.Background(), "tomatoes"); err == nil || !strings.Contains(err.Error(), "index again") {
+		t.Errorf("Search with another model: err = %v", err)
+	}
+}
+
+func TestIndex_Incremental(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "apples\n")
+	writeFile(t, filepath.Join(dir, "b.md"), "bananas\n")
+	writeFile(t, filepath.Join(dir, "image.txt"), "\x00\x01")
+	path := filepath.Join(t.TempDir(), "knowledge.gob")
+	emb := &countingEmbedder{}
+	b := New(testConfig(dir), path, emb)
+
+	var calls []string
+	progress := func(done, total int, path string) {
+		calls = append(calls, fmt.Sprintf("%d/%d %s", done, total, filepath.Base(path)))
+	}
+	r, err := b.Index(context.Background(), nil, progress)
+	if err != nil || r.Added != 3 || r.Files != 2 || emb.texts != 2 {
+		t.Fatalf("first Index = %+v, %v with %d texts embedded", r, err, emb.texts)
+	}
+	if got := strings.Join(calls, ", "); got != "0/3 a.md, 1/3 b.md, 2/3 image.txt, 3/3 ." {
+		t.Errorf("progress = %s", got)
+	}
+
+	// Nothing changed
+	if r, err := b.Pending(nil); err != nil || r.Unchanged != 3 || r.Added+r.Changed+r.Removed != 0 {
+		t.Errorf("Pending = %+v, %v, want all unchanged", r, err)
+	}
+
+	later := time.Now().Add(time.Minute)
+	writeFile(t, filepath.Join(dir, "a.md"), "apricots\n")
+	os.Chtimes(filepath.Join(dir, "a.md"), later, later)
+	os.Remove(filepath.Join(dir, "b.md"))
+	writeFile(t, filepath.Join(dir, "c.md"), "cherries\n")
+	if r, _ := b.Pending(nil); r.Added != 1 || r.Changed != 1 || r.Removed != 1 || r.Unchanged != 1 {
+		t.Errorf("Pending = %+v, want 1 added, changed, removed and unchanged", r)
+	}
+	emb.texts = 0
+	r, err = New(testConfig(dir), path, emb).Index(context.Background(), nil, nil)
+	if err != nil || r.Added != 1 || r.Changed != 1 || r.Removed != 1 || r.Unchanged != 1 || emb.texts != 2 {
+		t.Errorf("second Index = %+v, %v with %d texts embedded, want only a.md and c.md", r, err, emb.texts)
+	}
+
+	// Other chunking embeds everything again, as does Rebuild
+	cfg := testConfig(dir)
+	cfg.ChunkSize = 800
+	emb.texts = 0
+	if r, err := New(cfg, path, emb).Index(context.Background(), nil, nil); err != nil || r.Changed != 3 || emb.texts != 2 {
+		t.Errorf("Index with new chunk size = %+v, %v with %d texts embedded", r, err, emb.texts)
+	}
+	emb.texts = 0
+	if r, err := New(cfg, path, emb).Rebuild(context.Background(), nil, nil); err != nil || r.Changed != 3 || emb.texts != 2 {
+		t.Errorf("Rebuild = %+v, %v with %d texts embedded", r, err, emb.texts)
+	}
+}
+
+func TestIndex_Scoped(t *testing.T) {
+	notes, docs := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(notes, "a.md"), "apples\n")
+	writeFile(t, filepath.Join(docs, "b.md"), "bananas\n")
+	path := filepath.Join(t.TempDir(), "knowledge.gob")
+	emb := &countingEmbedder{}
+	b := New(testConfig(notes, docs), path, emb)
+	if _, err := b.Index(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Remove(filepath.Join(notes, "a.md"))
+	writeFile(t, filepath.Join(docs, "c.md"), "cherries\n")
+	r, err := b.Index(context.Background(), []string{docs}, nil)
+	if err != nil || r.Added != 1 || r.Unchanged != 1 || r.Removed != 0 || r.Files != 3 {
+		t.Errorf("Index of docs = %+v, %v, want c.md added and notes left alone", r, err)
+	}
+	if r, _ := b.Pending(nil); r.Removed != 1 {
+		t.Errorf("Pending = %+v, want a.md to be removed by a full run", r)
+	}
+}
+
+func TestIndex_ResumesAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		writeFile(t, filepath.Join(dir, name), "notes about "+name+"\n")
+	}
+	path := filepath.Join(t.TempDir(), "knowledge.gob")
+	if _, err := New(testConfig(dir), path, &countingEmbedder{limit: 2}).Index(context.Background(), nil, nil); err == nil {
+		t.Fatal("Index should fail when the embedder does")
+	}
+
+	emb := &countingEmbedder{}
+	r, err := New(testConfig(dir), path, emb).Index(context.Background(), nil, nil)
+	if err != nil || r.Unchanged != 2 || r.Added != 1 || emb.texts != 1 {
+		t.Errorf("Index after failure = %+v, %v with %d texts embedded, want only the last file", r, err, emb.texts)
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "knowledge.gob")
+	if _, err := New(testConfig(), path, &wordEmbedder{}).Search(context.Background(), "x"); !errors.Is(err, ErrNotIndexed) {
+		t.Errorf("Search on empty index: err = %v, want ErrNotIndexed", err)
+	}
+	if _, err := New(testConfig(), path, &wordEmbedder{}).Index(context.Background(), nil, nil); !errors.Is(err, ErrNoDirs) {
+		t.Errorf("Index without dirs: err = %v, want ErrNoDirs", err)
+	}
+	if _, err := New(testConfig(t.TempDir()), path, nil).Index(context.Background(), nil, nil); err == nil {
+		t.Error("Index without an embedder should fail")
+	}
+	if _, err := New(testConfig(filepath.Join(t.TempDir(), "missing")), path, &wordEmbedder{}).Index(context.Background(), nil, nil); err == nil {
+		t.Error("Index of a missing directory should fail")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 50; i++ {
+		b.WriteString("This line is about forty-five characters long.\n")
+	}
+	b.WriteString("## Next section\nShort.\n")
+	chunks := split("/n.md", b.String(), 1500, 200)
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the text split", len(chunks))
+	}
+	for i, c := range chunks {
+		if len(c.Text) > 1500 {
+			t.Errorf("chunk %d is %d bytes, want at most 1500", i, len(c.Text))
+		}
+	}
+	if chunks[1].Line >= 1+strings.Count(chunks[0].Text, "\n")+1 {
+		t.Errorf("chunk 2 starts at line %d, want overlap with chunk 1", chunks[1].Line)
+	}
+	last := chunks[len(chunks)-1]
+	if !strings.HasPrefix(last.Text, "## Next section") || last.Line != 51 {
+		t.Errorf("last chunk = line %d %q, want it to start at the heading on line 51", last.Line, last.Text)
+	}
+
+	long := split("/min.js", strings.Repeat("ä", 1500), 1500, 200)
+	if len(long) != 2 || !strings.HasPrefix(long[1].Text, "ä") {
+		t.Errorf("long line split into %d chunks, want 2 on a rune boundary", len(long))
+	}
+	if got := split("/empty.md", "\n  \n", 1500, 200); len(got) != 0 {
+		t.Errorf("blank file gave %d chunks", len(got))
+	}
+}
+
+func TestAugment(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "garden.md"), "Water the tomatoes every morning.\n")
+	b := New(testConfig(dir), filepath.Join(t.TempDir(), "kb.gob"), &wordEmbedder{})
+	if _, err := b.Index(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, results, err := b.Augment(context.Background(), "water the tomatoes?")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Augment: %d results, err %v", len(results), err)
+	}
+	if !strings.HasPrefix(got, "water the tomatoes?\n\n") || !strings.Contains(got, `garden.md:1">`) ||
+		!strings.Contains(got, "Water the tomatoes every morning.") {
+		t.Errorf("augmented = %q", got)
+	}
+
+	if got, results, _ := b.Augment(context.Background(), "quantum chromodynamics"); got != "quantum chromodynamics" || len(results) != 0 {
+		t.Errorf("Augment without matches = %q, %d results", got, len(results))
+	}
+}
+
+func TestResultSource(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	r := Result{Chunk: Chunk{Path: filepath.Join(home, "notes", "a.md"), Line: 12}}
+	if got := r.Source(); got != "~/notes/a.md:12" {
+		t.Errorf("Source() = %q", got)
+	}
+	r.Path = "/srv/docs/b.txt"
+	if got := r.Source(); got != "/srv/docs/b.txt:12" {
+		t.Errorf("Source() = %q", got)
+	}
+}
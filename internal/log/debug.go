@@ -0,0 +1,80 @@
+package log
+
+import (
+	"log/slog"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// trafficKept is how many Traffic records RecentTraffic returns.
+const trafficKept = 50
+
+var (
+	debugging atomic.Bool
+	trafficMu sync.Mutex
+	traffic   []TrafficRecord // the latest trafficKept records, oldest first
+)
+
+// TrafficRecord is a request sent to or a response received from a
+// provider in debug mode.
+type TrafficRecord struct {
+	Time time.Time
+	What string // e.g. "ollama request /api/chat"
+	Body string // redacted
+}
+
+// SetDebug turns debug mode on or off. In debug mode records of all levels
+// are logged, including the provider traffic passed to Traffic.
+func SetDebug(on bool) {
+	debugging.Store(on)
+	if on {
+		level.Set(slog.LevelDebug)
+	} else {
+		level.Set(opened)
+	}
+}
+
+// Debugging reports whether debug mode is on.
+func Debugging() bool {
+	return debugging.Load()
+}
+
+// Traffic records body, exchanged with a provider, in debug mode. Secrets
+// in it are redacted first.
+func Traffic(what string, body []byte) {
+	if !Debugging() {
+		return
+	}
+	r := TrafficRecord{Time: time.Now(), What: what, Body: Redact(string(body))}
+	Debug(what, "body", r.Body)
+
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	traffic = append(traffic, r)
+	if len(traffic) > trafficKept {
+		traffic = append(traffic[:0], traffic[len(traffic)-trafficKept:]...)
+	}
+}
+
+// RecentTraffic returns the latest records of Traffic, oldest first.
+func RecentTraffic() []TrafficRecord {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	return append([]TrafficRecord(nil), traffic...)
+}
+
+var (
+	// JSON fields named like a credential, e.g. "api_key": "..."
+	secretField = regexp.MustCompile(`(?i)("[a-z_-]*(?:key|token|secret|password|authorization)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// Bearer tokens and the usual API key formats inside text
+	secretValue = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]+|\b(?:sk|pk|ghp|gho|xox[abp])[-_][a-z0-9_-]{16,}`)
+)
+
+// Redact replaces credentials in s, such as API keys in JSON fields or
+// bearer tokens in message text, with [redacted].
+func Redact(s string) string {
+	s = secretField.ReplaceAllString(s, `$1"[redacted]"`)
+	return secretValue.ReplaceAllString(s, "[redacted]")
+}
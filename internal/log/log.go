@@ -0,0 +1,72 @@
+// Package log writes stefanclaw's diagnostic log, so that a report of a
+// hang or failure can come with a record of what stefanclaw was doing. It
+// wraps log/slog: records go to a file under the data directory that is
+// rotated as it grows, and are discarded until Open is called.
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+var (
+	logger atomic.Pointer[slog.Logger]
+	file   atomic.Pointer[rotatingFile] // the file opened by Open
+	level  slog.LevelVar                // level of the log file, lowered by SetDebug
+	opened slog.Level                   // level given to Open
+)
+
+func init() {
+	logger.Store(slog.New(slog.DiscardHandler))
+}
+
+// Open starts logging records of lvl and above to the file at path,
+// creating it and its directory if needed. Records are written as they are
+// logged, so none are lost when stefanclaw exits without calling Close.
+func Open(path string, lvl slog.Level) error {
+	f, err := openRotating(path)
+	if err != nil {
+		return err
+	}
+	opened = lvl
+	if !Debugging() {
+		level.Set(lvl)
+	}
+	logger.Store(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: &level})))
+	if old := file.Swap(f); old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Close stops logging and closes the log file.
+func Close() error {
+	logger.Store(slog.New(slog.DiscardHandler))
+	if f := file.Swap(nil); f != nil {
+		return f.Close()
+	}
+	return nil
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// Debug logs details that help retrace what happened, such as requests
+// being sent.
+func Debug(msg string, args ...any) { logger.Load().Debug(msg, args...) }
+
+// Info logs normal events, such as completed requests.
+func Info(msg string, args ...any) { logger.Load().Info(msg, args...) }
+
+// Warn logs failures stefanclaw recovers from, such as a failed request.
+func Warn(msg string, args ...any) { logger.Load().Warn(msg, args...) }
+
+// Error logs failures that lose data or stop a feature from working.
+func Error(msg string, args ...any) { logger.Load().Error(msg, args...) }
@@ -0,0 +1,137 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "stefanclaw.log")
+	level, err := ParseLevel("info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Open(path, level); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+
+	Debug("hidden detail")
+	Info("request done", "model", "qwen3")
+	Warn("request failed", "status", 500)
+	if err := Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	Info("after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"level=INFO", `msg="request done" model=qwen3`, "level=WARN", "status=500"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log lacks %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"hidden detail", "after close"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("log has %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"debug", "info", "warn", "error", "WARN"} {
+		if _, err := ParseLevel(name); err != nil {
+			t.Errorf("ParseLevel(%q): %v", name, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	old := maxSize
+	maxSize = 100
+	t.Cleanup(func() { maxSize = old })
+
+	path := filepath.Join(t.TempDir(), "stefanclaw.log")
+	f, err := openRotating(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for i := range 10 {
+		fmt.Fprintf(f, "line %d %s\n", i, strings.Repeat("x", 40))
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2", path + ".3"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(name), err)
+		}
+		if info.Size() > maxSize {
+			t.Errorf("%s has %d bytes, more than %d", filepath.Base(name), info.Size(), maxSize)
+		}
+	}
+	if _, err := os.Stat(path + ".4"); err == nil {
+		t.Error("more backups kept than configured")
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "line 8 ") {
+		t.Errorf("current log = %q, want the newest lines", data)
+	}
+}
+
+func TestDebugMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stefanclaw.log")
+	if err := Open(path, slog.LevelWarn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetDebug(false); Close() })
+
+	Traffic("ollama request /api/chat", []byte(`{"model":"qwen3"}`))
+	if len(RecentTraffic()) != 0 {
+		t.Error("traffic recorded outside debug mode")
+	}
+
+	SetDebug(true)
+	Debug("detail")
+	Traffic("ollama request /api/chat", []byte(`{"model":"qwen3","api_key":"s3cret"}`))
+	SetDebug(false)
+	Debug("hidden again")
+
+	records := RecentTraffic()
+	if len(records) != 1 || records[0].Body != `{"model":"qwen3","api_key":"[redacted]"}` {
+		t.Errorf("traffic = %+v, want the redacted request", records)
+	}
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	if !strings.Contains(got, "msg=detail") || !strings.Contains(got, `msg="ollama request /api/chat"`) {
+		t.Errorf("debug records missing:\n%s", got)
+	}
+	if strings.Contains(got, "s3cret") || strings.Contains(got, "hidden again") {
+		t.Errorf("log has records it shouldn't:\n%s", got)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"api_key": "abc", "model": "qwen3"}`, `{"api_key": "[redacted]", "model": "qwen3"}`},
+		{`{"Authorization":"Bearer x.y"}`, `{"Authorization":"[redacted]"}`},
+		{`{"password":"p\"w"}`, `{"password":"[redacted]"}`},
+		{`my key is sk-abcdefghijklmnopqrstuv ok`, `my key is [redacted] ok`},
+		{`use Bearer abc.def-ghi now`, `use [redacted] now`},
+		{`{"prompt_eval_count":12,"num_tokens":3}`, `{"prompt_eval_count":12,"num_tokens":3}`},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
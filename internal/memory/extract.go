@@ -5,10 +5,12 @@ import (
 	"strings"
 
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/think"
 )
 
 const extractPrompt = `Extract key facts, preferences, and decisions from this conversation as bullet points.
 Only include information worth remembering for future conversations.
+Leave out open to-dos; they are kept on the user's to-do list.
 Return ONLY bullet points, one per line, starting with "- ".
 If there's nothing worth remembering, return "NONE".`
 
@@ -45,7 +47,7 @@ func (e *Extractor) Extract(ctx context.Context, messages []provider.Message) ([
 		return nil, err
 	}
 
-	return parseFacts(resp.Message.Content), nil
+	return parseFacts(think.Strip(resp.Message.Content)), nil
 }
 
 func parseFacts(content string) []string {
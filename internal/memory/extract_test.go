@@ -5,36 +5,13 @@ import (
 	"testing"
 
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
 )
 
-type mockProvider struct {
-	resp *provider.ChatResponse
-	err  error
-}
-
-func (m *mockProvider) Name() string { return "mock" }
-func (m *mockProvider) Chat(_ context.Context, _ provider.ChatRequest) (*provider.ChatResponse, error) {
-	return m.resp, m.err
-}
-func (m *mockProvider) StreamChat(_ context.Context, _ provider.ChatRequest) (<-chan provider.StreamDelta, error) {
-	return nil, nil
-}
-func (m *mockProvider) ListModels(_ context.Context) ([]provider.ModelInfo, error) {
-	return nil, nil
-}
-func (m *mockProvider) IsAvailable(_ context.Context) error { return nil }
-
 func TestExtractFacts_MockLLM(t *testing.T) {
-	mp := &mockProvider{
-		resp: &provider.ChatResponse{
-			Message: provider.Message{
-				Role: "assistant",
-				Content: `- User prefers concise responses
+	mp := providertest.New(providertest.Text(`- User prefers concise responses
 - User works with Go
-- User's name is Stefan`,
-			},
-		},
-	}
+- User's name is Stefan`))
 
 	extractor := NewExtractor(mp, "test-model")
 	facts, err := extractor.Extract(context.Background(), []provider.Message{
@@ -54,14 +31,7 @@ func TestExtractFacts_MockLLM(t *testing.T) {
 }
 
 func TestExtractFacts_NoneWorthRemembering(t *testing.T) {
-	mp := &mockProvider{
-		resp: &provider.ChatResponse{
-			Message: provider.Message{
-				Role:    "assistant",
-				Content: "NONE",
-			},
-		},
-	}
+	mp := providertest.New(providertest.Text("NONE"))
 
 	extractor := NewExtractor(mp, "test-model")
 	facts, err := extractor.Extract(context.Background(), []provider.Message{
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
 )
 
 // Store manages the MEMORY.md file.
@@ -109,7 +111,6 @@ func (s *Store) Entries() ([]string, error) {
 }
 
 // ForPrompt returns memory content trimmed to fit within the token budget.
-// Approximates tokens as chars/4.
 func (s *Store) ForPrompt(maxTokens int) (string, error) {
 	entries, err := s.Entries()
 	if err != nil {
@@ -120,14 +121,16 @@ func (s *Store) ForPrompt(maxTokens int) (string, error) {
 		return "", nil
 	}
 
-	maxChars := maxTokens * 4
 	var result strings.Builder
 	result.WriteString("# Memory\n\n")
+	used := tokens.Count(result.String())
 
 	for _, entry := range entries {
-		if result.Len()+len(entry)+1 > maxChars {
+		n := tokens.Count(entry + "\n")
+		if used+n > maxTokens {
 			break
 		}
+		used += n
 		result.WriteString(entry + "\n")
 	}
 
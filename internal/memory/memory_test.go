@@ -102,14 +102,14 @@ func TestMemoryInPrompt_ExceedsBudget(t *testing.T) {
 	os.WriteFile(path, []byte(builder.String()), 0o644)
 
 	store := NewStore(path)
-	content, err := store.ForPrompt(50) // very small budget (200 chars)
+	content, err := store.ForPrompt(50) // very small budget (two entries)
 	if err != nil {
 		t.Fatalf("ForPrompt() error: %v", err)
 	}
 
 	// Should be truncated
-	if len(content) > 250 { // 50 tokens * 4 chars + some header slack
-		t.Errorf("content too long: %d chars, budget was 50 tokens (200 chars)", len(content))
+	if len(content) > 250 { // about 4 chars per token of this English text, plus header slack
+		t.Errorf("content too long: %d chars, budget was 50 tokens", len(content))
 	}
 }
 
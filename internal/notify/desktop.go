@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runCommand runs a notification command; tests replace it.
+var runCommand = func(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Desktop shows an OS notification: notify-send on Linux and the BSDs,
+// Notification Center on macOS.
+func Desktop(ctx context.Context, title, message string) error {
+	if r := []rune(message); len(r) > maxMessage {
+		message = string(r[:maxMessage-3]) + "..."
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleString(message), appleString(title))
+		return runCommand(ctx, "osascript", "-e", script)
+	case "windows":
+		return errors.New("desktop notifications are not supported on Windows; use a webhook")
+	default:
+		return runCommand(ctx, "notify-send", "--app-name=stefanclaw", title, message)
+	}
+}
+
+// appleString quotes s as an AppleScript string literal.
+func appleString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Deliver sends ev to the webhooks and, if desktop is set, shows it as an OS
+// notification as well.
+func (n *Notifier) Deliver(ctx context.Context, ev Event, desktop bool) error {
+	err := n.Send(ctx, ev)
+	if desktop {
+		err = errors.Join(err, Desktop(ctx, ev.Title, ev.Message))
+	}
+	return err
+}
+
+// Limiter caps how many notifications are sent per hour. It is safe for
+// concurrent use.
+type Limiter struct {
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+// Allow records a notification at now and reports whether it is within
+// perHour notifications in the last hour. Refused notifications are not
+// recorded.
+func (l *Limiter) Allow(perHour int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	recent := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	l.sent = recent
+	if len(l.sent) >= perHour {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+func TestDeliver_Desktop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no desktop notifications on Windows")
+	}
+	var ran []string
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+	runCommand = func(ctx context.Context, name string, args ...string) error {
+		ran = append(ran, name+" "+strings.Join(args, " "))
+		return nil
+	}
+
+	srv, got := recorder(t, 200)
+	n := New(config.NotifyConfig{Webhooks: []config.WebhookConfig{
+		{URL: srv.URL, Events: []string{EventAssistant}},
+	}}, nil)
+	ev := Event{Kind: EventAssistant, Title: "Door", Message: `The "front" door is open`}
+	if err := n.Deliver(context.Background(), ev, true); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+	if len(*got) != 1 {
+		t.Errorf("webhook got %d requests, want 1", len(*got))
+	}
+	if len(ran) != 1 || !strings.Contains(ran[0], "front") {
+		t.Errorf("desktop commands = %q", ran)
+	}
+
+	// Without desktop set, and a nil Notifier, nothing is shown or sent
+	ran = nil
+	var none *Notifier
+	if err := none.Deliver(context.Background(), ev, false); err != nil || len(ran) != 0 {
+		t.Errorf("Deliver() = %v, ran %q", err, ran)
+	}
+}
+
+func TestAppleString(t *testing.T) {
+	if got := appleString(`say "hi" \o/`); got != `"say \"hi\" \\o/"` {
+		t.Errorf("appleString() = %s", got)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	var l Limiter
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if !l.Allow(3, start.Add(time.Duration(i)*time.Minute)) {
+			t.Fatalf("notification %d was refused", i+1)
+		}
+	}
+	if l.Allow(3, start.Add(30*time.Minute)) {
+		t.Error("a fourth notification within the hour was allowed")
+	}
+	if !l.Allow(3, start.Add(61*time.Minute)) {
+		t.Error("the first notification should have left the window")
+	}
+}
@@ -0,0 +1,188 @@
+// Package notify sends outbound webhook notifications so the assistant can
+// reach the user when the terminal is not in view. Webhooks speak generic
+// JSON, ntfy or Gotify.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Events a webhook can subscribe to.
+const (
+	EventHeartbeat = "heartbeat" // a heartbeat check-in produced a message
+	EventReminder  = "reminder"  // a reminder fell due
+	EventJob       = "job"       // an automation job finished
+	EventResponse  = "response"  // a long response finished
+	EventAssistant = "assistant" // the model sent a notification with the notify tool
+)
+
+// maxMessage caps the characters of a message sent to a webhook.
+const maxMessage = 2000
+
+// Event is something worth telling the user about.
+type Event struct {
+	Kind    string
+	Title   string
+	Message string
+	Time    time.Time
+}
+
+// Notifier delivers events to the configured webhooks. It is safe for
+// concurrent use; a nil or offline Notifier sends nothing.
+type Notifier struct {
+	hooks   []config.WebhookConfig
+	resolve func(string) (string, error)
+	client  *http.Client
+	offline bool // privacy.disable_web
+}
+
+// New creates a Notifier for the webhooks in cfg. resolve turns token
+// values into secrets (e.g. keyring: references); nil uses them as-is.
+func New(cfg config.NotifyConfig, resolve func(string) (string, error)) *Notifier {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	return &Notifier{
+		hooks:   cfg.Webhooks,
+		resolve: resolve,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithConfig returns a Notifier for cfg that resolves tokens like n and
+// stays offline if n is.
+func (n *Notifier) WithConfig(cfg config.NotifyConfig) *Notifier {
+	if n == nil {
+		return New(cfg, nil)
+	}
+	c := New(cfg, n.resolve)
+	c.offline = n.offline
+	return c
+}
+
+// WithPrivacy returns a copy of n that sends nothing while p disables web
+// access. Desktop notifications from Deliver are local and still shown.
+func (n *Notifier) WithPrivacy(p config.PrivacyConfig) *Notifier {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.offline = p.DisableWeb
+	return &c
+}
+
+// Wants reports whether any webhook receives events of kind.
+func (n *Notifier) Wants(kind string) bool {
+	if n == nil || n.offline {
+		return false
+	}
+	for _, h := range n.hooks {
+		if len(h.Events) == 0 || slices.Contains(h.Events, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send delivers ev to every webhook subscribed to its kind. Errors from
+// individual webhooks are joined.
+func (n *Notifier) Send(ctx context.Context, ev Event) error {
+	if n == nil || n.offline {
+		return nil
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	if r := []rune(ev.Message); len(r) > maxMessage {
+		ev.Message = string(r[:maxMessage-3]) + "..."
+	}
+	var errs []error
+	for _, h := range n.hooks {
+		if len(h.Events) > 0 && !slices.Contains(h.Events, ev.Kind) {
+			continue
+		}
+		if err := n.post(ctx, h, ev); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", redact(h.URL), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, h config.WebhookConfig, ev Event) error {
+	token, err := n.resolve(h.Token)
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+
+	var req *http.Request
+	switch h.Format {
+	case "ntfy":
+		// ntfy takes the message as the body and the rest as headers
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, h.URL, strings.NewReader(ev.Message))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Title", ev.Title)
+		req.Header.Set("Tags", ev.Kind)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	case "gotify":
+		body, _ := json.Marshal(map[string]any{"title": ev.Title, "message": ev.Message, "priority": 5})
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(h.URL, "/")+"/message", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Gotify-Key", token)
+	default:
+		body, _ := json.Marshal(map[string]string{
+			"event":   ev.Kind,
+			"title":   ev.Title,
+			"message": ev.Message,
+			"time":    ev.Time.Format(time.RFC3339),
+		})
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	req.Header.Set("User-Agent", "stefanclaw")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// redact drops the path and query from a webhook URL for error messages;
+// ntfy topics and many webhook URLs are secrets in themselves.
+func redact(u string) string {
+	if i := strings.Index(u, "://"); i != -1 {
+		if j := strings.IndexAny(u[i+3:], "/?"); j != -1 {
+			return u[:i+3+j]
+		}
+	}
+	return u
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+type request struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func recorder(t *testing.T, status int) (*httptest.Server, *[]request) {
+	t.Helper()
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, request{path: r.URL.Path, header: r.Header, body: string(body)})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestSend_Formats(t *testing.T) {
+	srv, got := recorder(t, http.StatusOK)
+	n := New(config.NotifyConfig{Webhooks: []config.WebhookConfig{
+		{URL: srv.URL + "/hook", Token: "keyring:hook"},
+		{URL: srv.URL + "/topic", Format: "ntfy"},
+		{URL: srv.URL + "/", Format: "gotify", Token: "app-token"},
+	}}, func(v string) (string, error) {
+		return strings.Replace(v, "keyring:", "secret-", 1), nil
+	})
+
+	ev := Event{Kind: EventReminder, Title: "Reminder", Message: "stretch", Time: time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)}
+	if err := n.Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if len(*got) != 3 {
+		t.Fatalf("got %d requests, want 3", len(*got))
+	}
+
+	generic := (*got)[0]
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(generic.body), &payload); err != nil {
+		t.Fatalf("generic body %q: %v", generic.body, err)
+	}
+	if payload["event"] != "reminder" || payload["message"] != "stretch" || payload["time"] != "2026-03-10T14:00:00Z" {
+		t.Errorf("generic payload = %v", payload)
+	}
+	if auth := generic.header.Get("Authorization"); auth != "Bearer secret-hook" {
+		t.Errorf("Authorization = %q, want the resolved token", auth)
+	}
+
+	ntfy := (*got)[1]
+	if ntfy.path != "/topic" || ntfy.body != "stretch" || ntfy.header.Get("Title") != "Reminder" || ntfy.header.Get("Tags") != "reminder" {
+		t.Errorf("ntfy request = %+v", ntfy)
+	}
+
+	gotify := (*got)[2]
+	if gotify.path != "/message" || gotify.header.Get("X-Gotify-Key") != "app-token" || !strings.Contains(gotify.body, `"message":"stretch"`) {
+		t.Errorf("gotify request = %+v", gotify)
+	}
+}
+
+func TestSend_EventFilter(t *testing.T) {
+	srv, got := recorder(t, http.StatusOK)
+	n := New(config.NotifyConfig{Webhooks: []config.WebhookConfig{
+		{URL: srv.URL, Events: []string{EventJob}},
+	}}, nil)
+
+	if n.Wants(EventHeartbeat) || !n.Wants(EventJob) {
+		t.Error("Wants() does not follow the webhook's events")
+	}
+	n.Send(context.Background(), Event{Kind: EventHeartbeat, Message: "hi"})
+	n.Send(context.Background(), Event{Kind: EventJob, Message: "done"})
+	if len(*got) != 1 || !strings.Contains((*got)[0].body, "done") {
+		t.Errorf("requests = %+v, want only the job event", *got)
+	}
+}
+
+func TestSend_Error(t *testing.T) {
+	srv, _ := recorder(t, http.StatusForbidden)
+	n := New(config.NotifyConfig{Webhooks: []config.WebhookConfig{{URL: srv.URL + "/secret-topic"}}}, nil)
+
+	err := n.Send(context.Background(), Event{Kind: EventJob, Message: "done"})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Fatalf("Send() error = %v, want HTTP 403", err)
+	}
+	if strings.Contains(err.Error(), "secret-topic") {
+		t.Errorf("error %q leaks the webhook path", err)
+	}
+}
+
+func TestSend_Offline(t *testing.T) {
+	srv, got := recorder(t, http.StatusOK)
+	n := New(config.NotifyConfig{Webhooks: []config.WebhookConfig{{URL: srv.URL}}}, nil).
+		WithPrivacy(config.PrivacyConfig{DisableWeb: true})
+
+	if n.Wants(EventJob) {
+		t.Error("offline Notifier wants events")
+	}
+	if err := n.Send(context.Background(), Event{Kind: EventJob, Message: "done"}); err != nil {
+		t.Errorf("Send() offline = %v", err)
+	}
+	// A reloaded config keeps the privacy setting
+	n.WithConfig(config.NotifyConfig{Webhooks: []config.WebhookConfig{{URL: srv.URL}}}).
+		Send(context.Background(), Event{Kind: EventJob, Message: "done"})
+	if len(*got) != 0 {
+		t.Errorf("requests = %+v, want none with web access disabled", *got)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	if n.Wants(EventJob) {
+		t.Error("nil Notifier wants events")
+	}
+	if err := n.Send(context.Background(), Event{Kind: EventJob}); err != nil {
+		t.Errorf("Send() on nil Notifier = %v", err)
+	}
+}
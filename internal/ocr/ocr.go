@@ -0,0 +1,209 @@
+// Package ocr reads the text in images, such as screenshots of error
+// dialogs, with tesseract or an Ollama vision model.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+// Engines.
+const (
+	EngineAuto      = "auto"
+	EngineTesseract = "tesseract"
+	EngineVision    = "vision"
+)
+
+// maxImageSize limits the images sent to a vision model.
+const maxImageSize = 20 << 20
+
+// ErrNoScreenshot is returned when no screenshot can be found.
+var ErrNoScreenshot = errors.New("no screenshot found")
+
+// imageExts lists the file types that are considered images.
+var imageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".webp": true,
+	".gif": true, ".bmp": true, ".tif": true, ".tiff": true,
+}
+
+// IsImage reports whether path has an image file extension.
+func IsImage(path string) bool {
+	return imageExts[strings.ToLower(filepath.Ext(path))]
+}
+
+const visionPrompt = "Transcribe all text in this image exactly as written, keeping line breaks. " +
+	"Reply with the text only. If there is no text, reply with nothing."
+
+// Reader extracts text from images.
+type Reader struct {
+	cfg      config.OCRConfig
+	provider provider.Provider
+}
+
+// New creates a Reader. p is used for the vision engine and may be nil.
+func New(cfg config.OCRConfig, p provider.Provider) *Reader {
+	return &Reader{cfg: cfg, provider: p}
+}
+
+// Engine returns the engine that Read uses, or an error if none is
+// available.
+func (r *Reader) Engine() (string, error) {
+	hasTesseract := func() bool { _, err := exec.LookPath("tesseract"); return err == nil }
+	vision := r.cfg.VisionModel != "" && r.provider != nil
+	switch r.cfg.Engine {
+	case EngineTesseract:
+		if !hasTesseract() {
+			return "", errors.New("tesseract not found: install tesseract-ocr or use a vision model")
+		}
+		return EngineTesseract, nil
+	case EngineVision:
+		if !vision {
+			return "", errors.New("no vision model configured (agent.ocr.vision_model)")
+		}
+		return EngineVision, nil
+	}
+	switch {
+	case hasTesseract():
+		return EngineTesseract, nil
+	case vision:
+		return EngineVision, nil
+	}
+	return "", errors.New("no OCR engine: install tesseract-ocr or set agent.ocr.vision_model")
+}
+
+// Read returns the text in the image at path, trimmed.
+func (r *Reader) Read(ctx context.Context, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	engine, err := r.Engine()
+	if err != nil {
+		return "", err
+	}
+	if engine == EngineTesseract {
+		return tesseract(ctx, path, r.cfg.Language)
+	}
+	return r.vision(ctx, path)
+}
+
+func tesseract(ctx context.Context, path, lang string) (string, error) {
+	args := []string{path, "stdout"}
+	if lang != "" {
+		args = append(args, "-l", lang)
+	}
+	cmd := exec.CommandContext(ctx, "tesseract", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tesseract: %s", msg)
+		}
+		return "", fmt.Errorf("tesseract: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (r *Reader) vision(ctx context.Context, path string) (string, error) {
+	image, err := EncodeImage(path)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.provider.Chat(ctx, provider.ChatRequest{
+		Model: r.cfg.VisionModel,
+		Messages: []provider.Message{{
+			Role:    "user",
+			Content: visionPrompt,
+			Images:  []string{image},
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("vision model %s: %w", r.cfg.VisionModel, err)
+	}
+	return strings.TrimSpace(think.Strip(resp.Message.Content)), nil
+}
+
+// EncodeImage reads the image at path for a vision model, base64-encoded
+// as provider.Message carries images.
+func EncodeImage(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case info.IsDir():
+		return "", fmt.Errorf("%s is a directory", path)
+	case !IsImage(path):
+		return "", fmt.Errorf("%s is not an image", path)
+	case info.Size() > maxImageSize:
+		return "", fmt.Errorf("%s is too large for the vision model (%d bytes, limit %d)", path, info.Size(), maxImageSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ScreenshotDirs returns the directories searched for screenshots: dir if
+// set, else the places desktops save them by default.
+func ScreenshotDirs(dir string) []string {
+	home, _ := os.UserHomeDir()
+	if dir != "" {
+		return []string{config.ExpandPath(dir, home)}
+	}
+	dirs := []string{
+		filepath.Join(home, "Pictures", "Screenshots"),
+		filepath.Join(home, "Pictures"),
+		filepath.Join(home, "Desktop"),
+	}
+	if runtime.GOOS == "darwin" {
+		// macOS saves to the desktop
+		dirs[0], dirs[2] = dirs[2], dirs[0]
+	}
+	return dirs
+}
+
+// Latest returns the most recently modified image directly in one of dirs.
+func Latest(dirs []string) (string, error) {
+	var newest string
+	var newestMod int64
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !IsImage(e.Name()) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			if mod := info.ModTime().UnixNano(); newest == "" || mod > newestMod {
+				newest, newestMod = filepath.Join(dir, e.Name()), mod
+			}
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("%w in %s", ErrNoScreenshot, strings.Join(dirs, ", "))
+	}
+	return newest, nil
+}
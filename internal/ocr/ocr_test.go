@@ -0,0 +1,128 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func fakeTesseract(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if script != "" {
+		if err := os.WriteFile(filepath.Join(dir, "tesseract"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func writeImage(t *testing.T, dir, name string, mod time.Time) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("\x89PNG"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, mod, mod)
+	return path
+}
+
+func TestRead_Tesseract(t *testing.T) {
+	fakeTesseract(t, "echo \"$@\"\necho\n")
+	img := writeImage(t, t.TempDir(), "error.png", time.Now())
+
+	r := New(config.OCRConfig{Engine: EngineAuto, Language: "eng+deu", VisionModel: "llava"}, providertest.New())
+	got, err := r.Read(context.Background(), img)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if want := img + " stdout -l eng+deu"; got != want {
+		t.Errorf("Read() = %q, want %q", got, want)
+	}
+}
+
+func TestRead_TesseractError(t *testing.T) {
+	fakeTesseract(t, "echo 'Error opening data file' >&2\nexit 1\n")
+	img := writeImage(t, t.TempDir(), "error.png", time.Now())
+
+	_, err := New(config.OCRConfig{Engine: EngineTesseract}, nil).Read(context.Background(), img)
+	if err == nil || !strings.Contains(err.Error(), "Error opening data file") {
+		t.Errorf("Read() error = %v, want tesseract's message", err)
+	}
+}
+
+func TestRead_Vision(t *testing.T) {
+	fakeTesseract(t, "")
+	img := writeImage(t, t.TempDir(), "dialog.jpg", time.Now())
+	p := providertest.New(providertest.Text("  Disk full\n"))
+
+	got, err := New(config.OCRConfig{Engine: EngineAuto, VisionModel: "llava"}, p).Read(context.Background(), img)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if got != "Disk full" {
+		t.Errorf("Read() = %q", got)
+	}
+	if p.LastRequest().Model != "llava" || len(p.LastRequest().Messages) != 1 {
+		t.Fatalf("request = %+v", p.LastRequest())
+	}
+	if imgs := p.LastRequest().Messages[0].Images; len(imgs) != 1 || imgs[0] != base64.StdEncoding.EncodeToString([]byte("\x89PNG")) {
+		t.Errorf("images = %q, want the encoded file", imgs)
+	}
+}
+
+func TestEngine_Unavailable(t *testing.T) {
+	fakeTesseract(t, "")
+	for _, cfg := range []config.OCRConfig{
+		{Engine: EngineAuto},
+		{Engine: EngineTesseract, VisionModel: "llava"},
+	} {
+		if engine, err := New(cfg, providertest.New()).Engine(); err == nil {
+			t.Errorf("Engine(%+v) = %q, want an error", cfg, engine)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	shots, desktop := t.TempDir(), t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	writeImage(t, shots, "Screenshot 1.png", base)
+	want := writeImage(t, desktop, "Screenshot 2.PNG", base.Add(time.Minute))
+	os.WriteFile(filepath.Join(desktop, "notes.txt"), nil, 0o644)
+
+	got, err := Latest([]string{shots, filepath.Join(shots, "missing"), desktop})
+	if err != nil || got != want {
+		t.Errorf("Latest() = %q, %v; want %q", got, err, want)
+	}
+	if _, err := Latest([]string{t.TempDir()}); !errors.Is(err, ErrNoScreenshot) {
+		t.Errorf("Latest(empty) error = %v, want ErrNoScreenshot", err)
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	dir := t.TempDir()
+	img := writeImage(t, dir, "photo.png", time.Now())
+	got, err := EncodeImage(img)
+	if err != nil {
+		t.Fatalf("EncodeImage() error: %v", err)
+	}
+	if want := base64.StdEncoding.EncodeToString([]byte("\x89PNG")); got != want {
+		t.Errorf("EncodeImage() = %q, want %q", got, want)
+	}
+
+	notes := filepath.Join(dir, "notes.txt")
+	os.WriteFile(notes, []byte("hello"), 0o644)
+	for _, path := range []string{dir, notes, filepath.Join(dir, "missing.png")} {
+		if _, err := EncodeImage(path); err == nil {
+			t.Errorf("EncodeImage(%q) succeeded, want an error", path)
+		}
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"time"
 
 	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
 	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/lmstudio"
 	"github.com/stefanclaw/stefanclaw/internal/provider/ollama"
 )
 
@@ -25,53 +28,152 @@ type Runner struct {
 	Stdin   io.Reader
 	Stdout  io.Writer
 	BaseURL string
+
+	// Candidates are endpoints probed alongside BaseURL, which is preferred
+	// if it answers. The first that answers is saved in the config.
+	Candidates []string
+
+	// LMStudioURL is LM Studio's server, probed at the same time and used
+	// if Ollama doesn't answer. Empty skips it.
+	LMStudioURL string
 }
 
 // NewRunner creates a Runner with default stdin/stdout.
 func NewRunner() *Runner {
 	return &Runner{
-		Stdin:   os.Stdin,
-		Stdout:  os.Stdout,
-		BaseURL: "http://127.0.0.1:11434",
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		BaseURL:     "http://127.0.0.1:11434",
+		Candidates:  DefaultCandidates,
+		LMStudioURL: lmstudio.DefaultBaseURL,
 	}
 }
 
+// printer writes indented onboarding text in the user's language.
+type printer struct {
+	w  io.Writer
+	tr *i18n.Translator
+}
+
+// println translates s and prints it as an indented line; "" prints an
+// empty line.
+func (p *printer) println(s string) {
+	if s == "" {
+		fmt.Fprintln(p.w)
+		return
+	}
+	fmt.Fprintln(p.w, "  "+p.tr.T(s))
+}
+
+// linef translates format and prints it as an indented line.
+func (p *printer) linef(format string, args ...any) {
+	fmt.Fprintln(p.w, "  "+p.tr.Sprintf(format, args...))
+}
+
+// printf translates format and prints it indented and followed by a space
+// instead of a newline, for questions and steps that end in a word.
+func (p *printer) printf(format string, args ...any) {
+	fmt.Fprint(p.w, "  "+p.tr.Sprintf(format, args...)+" ")
+}
+
+// word prints a translated word that ends a line started with printf.
+func (p *printer) word(s string) {
+	fmt.Fprintln(p.w, p.tr.T(s))
+}
+
+// modelTip explains the trade-off of model sizes.
+func (p *printer) modelTip() {
+	p.println("Tip: Smaller models (e.g. 1b, 4b) are faster but less capable.")
+	fmt.Fprintln(p.w, "       "+p.tr.T("Larger models (e.g. 8b, 14b) are slower but produce better results."))
+}
+
 // Run executes the first-run onboarding flow.
 func (r *Runner) Run() (*Result, error) {
 	w := r.Stdout
+	// Talk in the locale's language until the user picks one
+	detectedLang, locale, recognized := config.DetectLanguageFromLocale()
+	p := &printer{w: w, tr: i18n.For(detectedLang)}
 
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "  Welcome to stefanclaw!")
-	fmt.Fprintln(w, "  Your personal AI assistant.")
-	fmt.Fprintln(w, "")
+	p.println("")
+	p.println("Welcome to stefanclaw!")
+	p.println("Your personal AI assistant.")
+	p.println("")
 
-	// Step 1: Check Ollama
-	fmt.Fprint(w, "  Checking for Ollama... ")
+	// Step 1: Check Ollama, at every candidate endpoint at once, and LM
+	// Studio in case Ollama isn't there
+	p.printf("Checking for Ollama...")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := ollama.Detect(ctx, r.BaseURL); err != nil {
-		fmt.Fprintln(w, "not found.")
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  Ollama is not running. Please install and start it:")
-		fmt.Fprintln(w, "    1. Install from https://ollama.ai")
-		fmt.Fprintln(w, "    2. Run: ollama serve")
-		fmt.Fprintln(w, "    3. Then re-run stefanclaw")
-		return nil, fmt.Errorf("ollama not running at %s", r.BaseURL)
+	lmStudio := make(chan endpoint, 1)
+	if r.LMStudioURL != "" {
+		go func() {
+			ep, err := probeWith(ctx, []string{r.LMStudioURL}, listLMStudio)
+			ep.err = err
+			lmStudio <- ep
+		}()
 	}
-	fmt.Fprintln(w, "found!")
 
-	// Step 2: List models
-	provider := ollama.New(r.BaseURL)
-	models, err := provider.ListModels(ctx)
+	providerName := "ollama"
+	pullCmd := "ollama pull qwen3:8b"
+	ep, err := probe(ctx, append([]string{r.BaseURL}, r.Candidates...))
 	if err != nil {
-		return nil, fmt.Errorf("listing models: %w", err)
+		p.word("not found.")
+		if r.LMStudioURL != "" {
+			p.printf("Checking for LM Studio...")
+			if lm := <-lmStudio; lm.err == nil {
+				p.word("found!")
+				ep, err = lm, nil
+				providerName = "lmstudio"
+				pullCmd = "lms get qwen3-8b"
+			} else {
+				p.word("not found.")
+			}
+		}
+	} else {
+		p.word("found!")
+	}
+	if err != nil {
+		p.println("")
+		p.println("Ollama is not running. Please install and start it:")
+		fmt.Fprintln(w, "    1. "+p.tr.T("Install from https://ollama.ai"))
+		fmt.Fprintln(w, "    2. "+p.tr.T("Run: ollama serve"))
+		fmt.Fprintln(w, "    3. "+p.tr.T("Then re-run stefanclaw"))
+		if r.LMStudioURL != "" {
+			p.linef("Or start the local server of LM Studio (https://lmstudio.ai) on %s.", r.LMStudioURL)
+		}
+		return nil, fmt.Errorf("ollama not running at %s", r.BaseURL)
+	}
+	baseURL := ep.url
+	if providerName == "lmstudio" {
+		p.linef("Using LM Studio at %s", baseURL)
+	} else if baseURL != r.BaseURL {
+		p.linef("Using Ollama at %s", baseURL)
 	}
 
+	// Step 2: List models, offering to download the recommended one if
+	// Ollama has none
+	scanner := bufio.NewScanner(r.Stdin)
+	models := ep.models
+	if len(models) == 0 && providerName == "ollama" {
+		p.println("")
+		p.printf("No models found. Download qwen3:8b now (about 5 GB)? [Y/n]")
+		if scanner.Scan() && yes(scanner.Text()) {
+			if err := pull(p, ollama.New(baseURL), "qwen3:8b"); err != nil {
+				p.linef("Download failed: %v", err)
+			} else {
+				models = []provider.ModelInfo{{Name: "qwen3:8b"}}
+			}
+		}
+	}
 	if len(models) == 0 {
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  No models found. Pull one with:")
-		fmt.Fprintln(w, "    ollama pull qwen3:8b")
+		p.println("")
+		if providerName == "lmstudio" {
+			p.println("No models found. Download one with:")
+		} else {
+			p.println("Pull a model with:")
+		}
+		fmt.Fprintln(w, "    "+pullCmd)
 		return nil, fmt.Errorf("no models found")
 	}
 
@@ -83,17 +185,31 @@ func (r *Runner) Run() (*Result, error) {
 		}
 	}
 
-	scanner := bufio.NewScanner(r.Stdin)
+	// Describe the models on offer while the user reads the list
+	offered := qwen3Models
+	if len(offered) == 0 {
+		offered = make([]string, len(models))
+		for i, m := range models {
+			offered[i] = m.Name
+		}
+	}
+	// Only Ollama describes its models
+	if providerName != "ollama" {
+		offered = nil
+	}
+	details := prefetchDetails(ollama.New(baseURL), offered)
+	defer details.stop()
+
 	var selectedModel string
 
 	if len(qwen3Models) > 0 {
-		fmt.Fprintf(w, "  Found %d qwen3 model(s):\n", len(qwen3Models))
-		fmt.Fprintln(w, "")
+		p.linef("Found %d qwen3 model(s):", len(qwen3Models))
+		p.println("")
 
 		// Determine default: prefer qwen3:8b, otherwise first qwen3 model
 		defaultModel := qwen3Models[0]
 		for _, name := range qwen3Models {
-			if name == "qwen3:8b" {
+			if name == "qwen3:8b" || strings.HasSuffix(name, "qwen3-8b") {
 				defaultModel = name
 				break
 			}
@@ -106,11 +222,10 @@ func (r *Runner) Run() (*Result, error) {
 			}
 			fmt.Fprintf(w, "  %s%d) %s\n", marker, i+1, name)
 		}
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  Tip: Smaller models (e.g. 1b, 4b) are faster but less capable.")
-		fmt.Fprintln(w, "       Larger models (e.g. 8b, 14b) are slower but produce better results.")
-		fmt.Fprintln(w, "")
-		fmt.Fprintf(w, "  Select a model [%s]: ", defaultModel)
+		p.println("")
+		p.modelTip()
+		p.println("")
+		p.printf("Select a model [%s]:", defaultModel)
 		var choice string
 		if scanner.Scan() {
 			choice = strings.TrimSpace(scanner.Text())
@@ -134,68 +249,84 @@ func (r *Runner) Run() (*Result, error) {
 			}
 		}
 	} else {
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  No qwen3 models found. The recommended model is qwen3:8b.")
-		fmt.Fprintln(w, "  Install it with:")
-		fmt.Fprintln(w, "    ollama pull qwen3:8b")
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  Tip: Smaller models (e.g. 1b, 4b) are faster but less capable.")
-		fmt.Fprintln(w, "       Larger models (e.g. 8b, 14b) are slower but produce better results.")
-		fmt.Fprintln(w, "")
-		fmt.Fprintln(w, "  Available models:")
+		p.println("")
+		p.println("No qwen3 models found. The recommended model is qwen3:8b.")
+		p.println("Install it with:")
+		fmt.Fprintln(w, "    "+pullCmd)
+		p.println("")
+		p.modelTip()
+		p.println("")
+		p.println("Available models:")
 		for _, m := range models {
 			fmt.Fprintf(w, "    - %s\n", m.Name)
 		}
-		fmt.Fprintln(w, "")
-		fmt.Fprint(w, "  Enter a model name to use (or press Enter to abort): ")
+		p.println("")
+		p.printf("Enter a model name to use (or press Enter to abort):")
 		var choice string
 		if scanner.Scan() {
 			choice = strings.TrimSpace(scanner.Text())
 		}
 		if choice == "" {
-			return nil, fmt.Errorf("no qwen3 model available — install one with: ollama pull qwen3:8b")
+			return nil, fmt.Errorf("no qwen3 model available — install one with: %s", pullCmd)
 		}
 		selectedModel = choice
 	}
 
-	fmt.Fprintf(w, "  Using model: %s\n", selectedModel)
+	p.linef("Using model: %s", selectedModel)
+	d := details.get(selectedModel, 3*time.Second)
+	if d != nil && d.String() != "" {
+		fmt.Fprintf(w, "  %s: %s\n", selectedModel, d)
+	}
 
 	// Step 3: Create config directory
-	fmt.Fprint(w, "  Creating config directory... ")
+	p.printf("Creating config directory...")
 	configDir := config.Dir()
 	if err := os.MkdirAll(config.PersonalityDir(), 0o755); err != nil {
-		fmt.Fprintln(w, "failed.")
+		p.word("failed.")
 		return nil, fmt.Errorf("creating config directory: %w", err)
 	}
+	if err := os.MkdirAll(config.DataDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
 	if err := os.MkdirAll(config.SessionsDir(), 0o755); err != nil {
 		return nil, fmt.Errorf("creating sessions directory: %w", err)
 	}
-	fmt.Fprintln(w, "done.")
-	fmt.Fprintf(w, "  Config: %s\n", configDir)
+	p.word("done.")
+	p.linef("Config: %s", configDir)
+	if dataDir := config.DataDir(); dataDir != configDir {
+		p.linef("Data:   %s", dataDir)
+	}
 
 	// Step 4: Copy personality files
-	fmt.Fprint(w, "  Copying personality templates... ")
+	p.printf("Copying personality templates...")
 	for _, name := range prompt.AllSections {
 		content, err := prompt.EmbeddedDefault(name)
 		if err != nil {
 			continue
 		}
 		path := config.PersonalityDir() + "/" + name
+		if name == prompt.SectionMemory {
+			path = config.MemoryFile()
+		}
 		os.WriteFile(path, []byte(content), 0o644)
 	}
-	fmt.Fprintln(w, "done.")
+	p.word("done.")
 
 	// Step 5: Ask preferred language
-	fmt.Fprintln(w, "")
-	detectedLang := config.DetectLanguage()
-	fmt.Fprintf(w, "  What language should I use? [%s] ", detectedLang)
+	p.println("")
+	if locale != "" && !recognized {
+		p.linef("Couldn't map your locale %q to a language; defaulting to %s.", locale, detectedLang)
+		p.println("Any language name works, e.g. \"Svenska\" or \"Brazilian Portuguese\".")
+	}
+	p.printf("What language should I use? [%s]", detectedLang)
 	var language string
 	if scanner.Scan() {
-		language = strings.TrimSpace(scanner.Text())
+		language, _ = config.NormalizeLanguage(scanner.Text())
 	}
 	if language == "" {
 		language = detectedLang
 	}
+	p.tr = i18n.For(language)
 
 	// Update USER.md
 	userContent := fmt.Sprintf("# User\n\n- Language: %s\n", language)
@@ -203,7 +334,16 @@ func (r *Runner) Run() (*Result, error) {
 
 	// Step 7: Save config
 	cfg := config.Defaults()
-	cfg.Provider.Ollama.BaseURL = r.BaseURL
+	cfg.Provider.Default = providerName
+	if providerName == "lmstudio" {
+		cfg.Provider.LMStudio.BaseURL = baseURL
+	} else {
+		cfg.Provider.Ollama.BaseURL = baseURL
+	}
+	if d != nil && d.ContextLength >= 2048 && d.ContextLength < cfg.Provider.Ollama.MaxNumCtx {
+		// Don't grow the context past what the model was trained for
+		cfg.Provider.Ollama.MaxNumCtx = d.ContextLength
+	}
 	cfg.Model.Default = selectedModel
 	cfg.Language = language
 
@@ -211,9 +351,9 @@ func (r *Runner) Run() (*Result, error) {
 		return nil, fmt.Errorf("saving config: %w", err)
 	}
 
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "  Setup complete!")
-	fmt.Fprintln(w, "  Starting stefanclaw...")
+	p.println("")
+	p.println("Setup complete!")
+	p.println("Starting stefanclaw...")
 
 	return &Result{
 		Config: cfg,
@@ -2,13 +2,17 @@ package onboard
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stefanclaw/stefanclaw/internal/config"
 )
@@ -17,6 +21,10 @@ func setupTestEnv(t *testing.T) string {
 	t.Helper()
 	tmp := t.TempDir()
 	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	// Onboarding talks in the locale's language; keep it English
+	t.Setenv("LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANGUAGE", "")
 	return tmp
 }
 
@@ -154,6 +162,46 @@ func TestSetup_NoModels(t *testing.T) {
 	}
 }
 
+func TestSetup_DownloadsModel(t *testing.T) {
+	setupTestEnv(t)
+
+	var pulled string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/pull":
+			var req struct{ Model string }
+			json.NewDecoder(r.Body).Decode(&req)
+			pulled = req.Model
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n" +
+				`{"status":"pulling a3de86cd1c13","total":5200000000,"completed":5200000000}` + "\n" +
+				`{"status":"success"}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{
+		Stdin:   strings.NewReader("\n\n\n"), // download + accept default model + accept default language
+		Stdout:  out,
+		BaseURL: srv.URL,
+	}
+
+	result, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run() error: %v\n%s", err, out)
+	}
+	if pulled != "qwen3:8b" || result.Model != "qwen3:8b" {
+		t.Errorf("pulled %q, using %q; want qwen3:8b", pulled, result.Model)
+	}
+	if !strings.Contains(out.String(), "Downloading qwen3:8b... 100% (5.2 of 5.2 GB)") {
+		t.Errorf("output doesn't show the download:\n%s", out)
+	}
+}
+
 func TestSetup_LanguageDetectedAndStored(t *testing.T) {
 	tmp := setupTestEnv(t)
 	t.Setenv("LANG", "de_DE.UTF-8")
@@ -187,6 +235,35 @@ func TestSetup_LanguageDetectedAndStored(t *testing.T) {
 	}
 }
 
+func TestSetup_TalksInLocaleLanguage(t *testing.T) {
+	setupTestEnv(t)
+	t.Setenv("LANG", "de_DE.UTF-8")
+
+	srv := newMockOllama(t, []string{"qwen3:8b"})
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{
+		Stdin:   strings.NewReader("\nEnglish\n"),
+		Stdout:  out,
+		BaseURL: srv.URL,
+	}
+	if _, err := r.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"Willkommen bei stefanclaw!", "Suche nach Ollama... gefunden!", "Welche Sprache soll ich verwenden? [Deutsch]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	// Once the user picks English the rest is English
+	if !strings.Contains(got, "Setup complete!") {
+		t.Errorf("output should finish in the chosen language:\n%s", got)
+	}
+}
+
 func TestSetup_LanguageCustom(t *testing.T) {
 	tmp := setupTestEnv(t)
 
@@ -214,6 +291,159 @@ func TestSetup_LanguageCustom(t *testing.T) {
 	}
 }
 
+func TestSetup_ProbesCandidates(t *testing.T) {
+	setupTestEnv(t)
+
+	srv := newMockOllama(t, []string{"qwen3:8b"})
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{
+		Stdin:      strings.NewReader("\n\n"),
+		Stdout:     out,
+		BaseURL:    "http://127.0.0.1:1",
+		Candidates: []string{srv.URL},
+	}
+
+	result, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Config.Provider.Ollama.BaseURL != srv.URL {
+		t.Errorf("base URL = %q, want the candidate that answered, %q", result.Config.Provider.Ollama.BaseURL, srv.URL)
+	}
+	if !strings.Contains(out.String(), "Using Ollama at "+srv.URL) {
+		t.Errorf("output should name the endpoint found:\n%s", out.String())
+	}
+}
+
+func TestSetup_FallsBackToLMStudio(t *testing.T) {
+	setupTestEnv(t)
+
+	lms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			fmt.Fprint(w, `{"data":[{"id":"google/gemma-3-4b"},{"id":"qwen/qwen3-4b"},{"id":"qwen/qwen3-8b"}]}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer lms.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{
+		Stdin:       strings.NewReader("\n\n"),
+		Stdout:      out,
+		BaseURL:     "http://127.0.0.1:1",
+		LMStudioURL: lms.URL,
+	}
+
+	result, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run() error: %v\n%s", err, out.String())
+	}
+	cfg := result.Config
+	if cfg.Provider.Default != "lmstudio" || cfg.Provider.LMStudio.BaseURL != lms.URL {
+		t.Errorf("provider = %+v, want lmstudio at %s", cfg.Provider, lms.URL)
+	}
+	if result.Model != "qwen/qwen3-8b" {
+		t.Errorf("model = %q, want the qwen3 8b default", result.Model)
+	}
+	for _, want := range []string{"Checking for Ollama... not found.", "Checking for LM Studio... found!", "Using LM Studio at " + lms.URL} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSetup_PrefersOllamaOverLMStudio(t *testing.T) {
+	setupTestEnv(t)
+
+	srv := newMockOllama(t, []string{"qwen3:8b"})
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{
+		Stdin:       strings.NewReader("\n\n"),
+		Stdout:      out,
+		BaseURL:     srv.URL,
+		LMStudioURL: "http://127.0.0.1:1",
+	}
+
+	result, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Config.Provider.Default != "ollama" || strings.Contains(out.String(), "LM Studio") {
+		t.Errorf("Ollama should be used when it answers, got %q:\n%s", result.Config.Provider.Default, out.String())
+	}
+}
+
+func TestProbe_PrefersBaseURL(t *testing.T) {
+	first := newMockOllama(t, []string{"qwen3:8b"})
+	defer first.Close()
+	second := newMockOllama(t, []string{"llama3"})
+	defer second.Close()
+
+	ep, err := probe(context.Background(), []string{first.URL, second.URL})
+	if err != nil || ep.url != first.URL || ep.models[0].Name != "qwen3:8b" {
+		t.Errorf("probe = %q, %v; want %q", ep.url, err, first.URL)
+	}
+}
+
+func TestProbe_DoesNotWaitForSlowCandidates(t *testing.T) {
+	srv := newMockOllama(t, []string{"qwen3:8b"})
+	defer srv.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := probe(ctx, []string{srv.URL, slow.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("probe took %v, waiting for the slow candidate", d)
+	}
+}
+
+func TestSetup_ShowsModelDetails(t *testing.T) {
+	setupTestEnv(t)
+
+	tags := newMockOllama(t, []string{"qwen3:4b"})
+	defer tags.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/show" {
+			w.Write([]byte(`{"details": {"parameter_size": "4.0B", "quantization_level": "Q4_K_M"},
+				"model_info": {"qwen3.context_length": 16384}}`))
+			return
+		}
+		resp, err := http.Get(tags.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	r := &Runner{Stdin: strings.NewReader("\n\n"), Stdout: out, BaseURL: srv.URL}
+	result, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !strings.Contains(out.String(), "qwen3:4b: 4.0B parameters, Q4_K_M, 16384-token context") {
+		t.Errorf("output should describe the chosen model:\n%s", out.String())
+	}
+	if got := result.Config.Provider.Ollama.MaxNumCtx; got != 16384 {
+		t.Errorf("max_num_ctx = %d, want the model's 16384", got)
+	}
+}
+
 // newMockOllama creates a test server mimicking Ollama's /api/tags endpoint.
 func newMockOllama(t *testing.T, modelNames []string) *httptest.Server {
 	t.Helper()
@@ -0,0 +1,123 @@
+package onboard
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/lmstudio"
+	"github.com/stefanclaw/stefanclaw/internal/provider/ollama"
+)
+
+// DefaultCandidates are the endpoints probed alongside Runner.BaseURL when
+// no endpoint was given: the same server by name, and the host of a
+// container stefanclaw runs in.
+var DefaultCandidates = []string{
+	"http://localhost:11434",
+	"http://host.docker.internal:11434",
+}
+
+// endpoint is the outcome of probing one server URL.
+type endpoint struct {
+	url    string
+	models []provider.ModelInfo
+	err    error
+}
+
+// lister lists the models of the server at url.
+type lister func(ctx context.Context, url string) ([]provider.ModelInfo, error)
+
+func listOllama(ctx context.Context, url string) ([]provider.ModelInfo, error) {
+	return ollama.New(url).ListModels(ctx)
+}
+
+func listLMStudio(ctx context.Context, url string) ([]provider.ModelInfo, error) {
+	return lmstudio.New(url).ListModels(ctx)
+}
+
+// probe lists the models of every Ollama URL at once and returns the first
+// URL, in the order given, that answered. Slow or unreachable URLs later in
+// the list don't hold up an earlier one that answered; an earlier one is
+// always waited for. The error is that of the first URL.
+func probe(ctx context.Context, urls []string) (endpoint, error) {
+	return probeWith(ctx, urls, listOllama)
+}
+
+// probeWith is probe for the servers list talks to.
+func probeWith(ctx context.Context, urls []string, list lister) (endpoint, error) {
+	results := make([]chan endpoint, len(urls))
+	for i, url := range urls {
+		results[i] = make(chan endpoint, 1)
+		go func() {
+			models, err := list(ctx, url)
+			results[i] <- endpoint{url: url, models: models, err: err}
+		}()
+	}
+	var first error
+	for _, ch := range results {
+		ep := <-ch
+		if ep.err == nil {
+			return ep, nil
+		}
+		if first == nil {
+			first = ep.err
+		}
+	}
+	if first == nil {
+		first = errors.New("no endpoint to probe")
+	}
+	return endpoint{}, first
+}
+
+// prefetchConcurrency limits how many models are described at once.
+const prefetchConcurrency = 4
+
+// prefetch fetches the details of models in the background, while the user
+// is still choosing one.
+type prefetch struct {
+	cancel  context.CancelFunc
+	details map[string]chan *provider.ModelDetails
+}
+
+// prefetchDetails starts describing each of models with prov.
+func prefetchDetails(prov *ollama.OllamaProvider, models []string) *prefetch {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &prefetch{cancel: cancel, details: make(map[string]chan *provider.ModelDetails)}
+	sem := make(chan struct{}, prefetchConcurrency)
+	for _, name := range models {
+		ch := make(chan *provider.ModelDetails, 1)
+		p.details[name] = ch
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			d, err := prov.ShowModel(ctx, name)
+			if err != nil {
+				d = nil
+			}
+			ch <- d
+		}()
+	}
+	return p
+}
+
+// get returns the details of model, waiting up to wait for them. It
+// returns nil for a model that wasn't prefetched or couldn't be described.
+func (p *prefetch) get(model string, wait time.Duration) *provider.ModelDetails {
+	ch, ok := p.details[model]
+	if !ok {
+		return nil
+	}
+	select {
+	case d := <-ch:
+		ch <- d
+		return d
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// stop abandons the details not fetched yet.
+func (p *prefetch) stop() {
+	p.cancel()
+}
@@ -0,0 +1,36 @@
+package onboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// yes reports whether answer accepts a question whose default is yes.
+func yes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes", "j", "ja":
+		return true
+	}
+	return false
+}
+
+// pull downloads model, rewriting one line with its progress.
+func pull(p *printer, puller provider.Puller, model string) error {
+	last := ""
+	err := puller.Pull(context.Background(), model, func(pr provider.PullProgress) {
+		line := p.tr.Sprintf("Downloading %s: %s", model, pr.Status)
+		if pr.Total > 0 {
+			line = p.tr.Sprintf("Downloading %s... %d%% (%.1f of %.1f GB)", model, pr.Completed*100/pr.Total, float64(pr.Completed)/1e9, float64(pr.Total)/1e9)
+		}
+		if line != last {
+			// Pad over the rest of a longer previous line
+			fmt.Fprintf(p.w, "\r  %-*s", len(last), line)
+			last = line
+		}
+	})
+	fmt.Fprintln(p.w)
+	return err
+}
@@ -0,0 +1,217 @@
+// Package plugin runs external executables that extend stefanclaw. Each
+// executable in the plugins directory speaks a JSON-over-stdio contract:
+//
+//	<plugin> describe
+//	    prints a Manifest as JSON
+//	<plugin> invoke
+//	    reads {"arguments": {"name": "value", ...}} from stdin and prints
+//	    {"output": "..."} or {"error": "..."}
+//
+// Plugins are native executables, or WebAssembly modules (*.wasm) built for
+// WASI, which run sandboxed without filesystem, network or environment
+// access. Every plugin becomes a slash command and an agent tool.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// DescribeTimeout bounds the describe call made while loading a plugin. It
+// starts once a WASM module is compiled, which may take longer.
+const DescribeTimeout = 5 * time.Second
+
+// InvokeTimeout bounds a single plugin invocation.
+const InvokeTimeout = 60 * time.Second
+
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Param describes one argument a plugin accepts.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// Manifest is what a plugin reports about itself.
+type Manifest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Params      []Param `json:"params"`
+	Confirm     bool    `json:"confirm"` // ask the user before the model may run it
+}
+
+// Plugin is a loaded plugin executable.
+type Plugin struct {
+	Manifest
+	Path string
+}
+
+// Discover loads every executable and .wasm module in dir. Other files and
+// files starting with a dot are ignored. A missing dir yields no plugins; plugins that
+// fail to describe themselves are reported in errs and skipped.
+func Discover(dir string) (plugins []Plugin, errs []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+
+	seen := map[string]string{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path) // follows symlinks
+		if err != nil || info.IsDir() || (!isWasm(path) && info.Mode().Perm()&0o111 == 0) {
+			continue
+		}
+		p, err := Load(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", e.Name(), err))
+			continue
+		}
+		if other, ok := seen[p.Name]; ok {
+			errs = append(errs, fmt.Errorf("plugin %s: name %q already used by %s", e.Name(), p.Name, other))
+			continue
+		}
+		seen[p.Name] = e.Name()
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, errs
+}
+
+// IsWasm reports whether the plugin is a sandboxed WASM module.
+func (p Plugin) IsWasm() bool {
+	return isWasm(p.Path)
+}
+
+func isWasm(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".wasm")
+}
+
+// Load describes the plugin at path and validates its manifest.
+func Load(path string) (Plugin, error) {
+	out, err := run(context.Background(), path, "describe", nil, DescribeTimeout)
+	if err != nil {
+		return Plugin{}, err
+	}
+	var man Manifest
+	if err := json.Unmarshal(out, &man); err != nil {
+		return Plugin{}, fmt.Errorf("invalid describe output: %w", err)
+	}
+	if !nameRe.MatchString(man.Name) {
+		return Plugin{}, fmt.Errorf("invalid name %q (use lowercase letters, digits, - and _)", man.Name)
+	}
+	if man.Description == "" {
+		return Plugin{}, fmt.Errorf("missing description")
+	}
+	for _, p := range man.Params {
+		if p.Name == "" {
+			return Plugin{}, fmt.Errorf("parameter without a name")
+		}
+	}
+	return Plugin{Manifest: man, Path: path}, nil
+}
+
+// Invoke runs the plugin with args and returns its output.
+func (p Plugin) Invoke(ctx context.Context, args map[string]string) (string, error) {
+	input, err := json.Marshal(map[string]any{"arguments": args})
+	if err != nil {
+		return "", err
+	}
+	out, err := run(ctx, p.Path, "invoke", input, InvokeTimeout)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Output string `json:"output"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return "", fmt.Errorf("invalid invoke output: %w", err)
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// Usage returns the slash command usage, e.g. "/weather <city>".
+func (p Plugin) Usage() string {
+	if len(p.Params) == 0 {
+		return "/" + p.Name
+	}
+	if p.Params[0].Required {
+		return fmt.Sprintf("/%s <%s>", p.Name, p.Params[0].Name)
+	}
+	return fmt.Sprintf("/%s [<%s>]", p.Name, p.Params[0].Name)
+}
+
+// CommandArgs maps the text after a slash command to plugin arguments: it
+// becomes the value of the first parameter.
+func (p Plugin) CommandArgs(text string) map[string]string {
+	if len(p.Params) == 0 || text == "" {
+		return map[string]string{}
+	}
+	return map[string]string{p.Params[0].Name: text}
+}
+
+// Tool exposes the plugin as an agent tool.
+func (p Plugin) Tool() tools.Tool {
+	params := make([]tools.Param, len(p.Params))
+	for i, pp := range p.Params {
+		params[i] = tools.Param{Name: pp.Name, Description: pp.Description, Required: pp.Required}
+	}
+	return tools.Tool{
+		Name:        p.Name,
+		Description: p.Description,
+		Params:      params,
+		Confirm:     p.Confirm,
+		Run:         p.Invoke,
+	}
+}
+
+// run executes the plugin with a subcommand and returns its stdout. A failed
+// run is reported with the plugin's stderr. The plugin is stopped after
+// timeout.
+func run(ctx context.Context, path, subcommand string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	if isWasm(path) {
+		return runWasm(ctx, path, subcommand, stdin, timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, subcommand)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out", subcommand)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", subcommand, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", subcommand, err)
+	}
+	return stdout.Bytes(), nil
+}
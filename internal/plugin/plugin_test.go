@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// weatherScript is a plugin that answers with the city it was given.
+const weatherScript = `#!/bin/sh
+case "$1" in
+describe)
+  echo '{"name": "weather", "description": "Current weather for a city", "params": [{"name": "city", "description": "city name", "required": true}]}'
+  ;;
+invoke)
+  input=$(cat)
+  case "$input" in
+  *Nowhere*) echo '{"error": "unknown city"}' ;;
+  *) echo "{\"output\": \"sunny, input was $(echo "$input" | tr -d '"')\"}" ;;
+  esac
+  ;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "weather", weatherScript)
+	writePlugin(t, dir, "broken", "#!/bin/sh\necho nope >&2\nexit 1\n")
+	writePlugin(t, dir, "badname", "#!/bin/sh\necho '{\"name\": \"Bad Name\", \"description\": \"x\"}'\n")
+	writePlugin(t, dir, "copy", weatherScript)
+	writePlugin(t, dir, ".hidden", weatherScript)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not executable"), 0o644)
+
+	plugins, errs := Discover(dir)
+	if len(plugins) != 1 || plugins[0].Name != "weather" {
+		t.Fatalf("plugins = %+v, want only weather", plugins)
+	}
+	// Files are read in name order, so "copy" claims the name first
+	if plugins[0].Path != filepath.Join(dir, "copy") {
+		t.Errorf("Path = %q, want the copy", plugins[0].Path)
+	}
+
+	joined := ""
+	for _, err := range errs {
+		joined += err.Error() + "\n"
+	}
+	for _, want := range []string{"broken: describe failed: nope", "invalid name", "already used"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("errors missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestDiscover_MissingDir(t *testing.T) {
+	plugins, errs := Discover(filepath.Join(t.TempDir(), "plugins"))
+	if plugins != nil || errs != nil {
+		t.Errorf("Discover(missing) = %v, %v", plugins, errs)
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "weather", weatherScript)
+	p, err := Load(filepath.Join(dir, "weather"))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	out, err := p.Invoke(context.Background(), p.CommandArgs("Berlin"))
+	if err != nil || out != "sunny, input was {arguments:{city:Berlin}}" {
+		t.Errorf("Invoke() = %q, %v", out, err)
+	}
+	if _, err := p.Invoke(context.Background(), map[string]string{"city": "Nowhere"}); err == nil || err.Error() != "unknown city" {
+		t.Errorf("Invoke() error = %v, want plugin error", err)
+	}
+}
+
+func TestPluginTool(t *testing.T) {
+	p := Plugin{Manifest: Manifest{
+		Name:        "weather",
+		Description: "Current weather",
+		Params:      []Param{{Name: "city", Required: true}, {Name: "units"}},
+		Confirm:     true,
+	}}
+	tool := p.Tool()
+	if tool.Name != "weather" || len(tool.Params) != 2 || !tool.Params[0].Required || !tool.Confirm {
+		t.Errorf("Tool() = %+v", tool)
+	}
+	if got := p.Usage(); got != "/weather <city>" {
+		t.Errorf("Usage() = %q", got)
+	}
+	if got := p.CommandArgs(""); len(got) != 0 {
+		t.Errorf("CommandArgs(\"\") = %v, want empty", got)
+	}
+}
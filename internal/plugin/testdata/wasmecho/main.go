@@ -0,0 +1,32 @@
+// Command wasmecho is a WASM test plugin. Build it with
+// GOOS=wasip1 GOARCH=wasm.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	switch os.Args[1] {
+	case "describe":
+		fmt.Println(`{"name": "wasmecho", "description": "Echo text from WASM", "params": [{"name": "text", "required": true}]}`)
+	case "invoke":
+		var in struct {
+			Arguments map[string]string `json:"arguments"`
+		}
+		json.NewDecoder(os.Stdin).Decode(&in)
+		text := in.Arguments["text"]
+		switch text {
+		case "spin":
+			for {
+			}
+		case "env":
+			_, err := os.ReadFile("/etc/passwd")
+			json.NewEncoder(os.Stdout).Encode(map[string]string{"output": fmt.Sprintf("%d env vars, read error: %v", len(os.Environ()), err != nil)})
+		default:
+			json.NewEncoder(os.Stdout).Encode(map[string]string{"output": "echo: " + text})
+		}
+	}
+}
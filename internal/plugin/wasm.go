@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WasmMemoryLimitPages caps the memory of a WASM plugin in 64KiB pages (64MiB).
+const WasmMemoryLimitPages = 1024
+
+// wasmRuntime runs .wasm plugins as WASI command modules. Modules get stdin,
+// stdout, stderr, the clock and randomness, but no filesystem, network or
+// environment variables. Compiled modules are cached per path.
+var wasmRuntime struct {
+	once     sync.Once
+	rt       wazero.Runtime
+	err      error
+	mu       sync.Mutex
+	compiled map[string]wazero.CompiledModule
+}
+
+// wasmModule returns the runtime and the compiled module at path. Compiling
+// isn't bound by the timeouts of describe and invoke, which only limit
+// running the module: a large module can take seconds to compile on a slow
+// machine, once.
+func wasmModule(path string) (wazero.Runtime, wazero.CompiledModule, error) {
+	r := &wasmRuntime
+	r.once.Do(func() {
+		r.rt = wazero.NewRuntimeWithConfig(context.Background(), wazero.NewRuntimeConfig().
+			WithCloseOnContextDone(true).
+			WithMemoryLimitPages(WasmMemoryLimitPages))
+		_, r.err = wasi_snapshot_preview1.Instantiate(context.Background(), r.rt)
+		r.compiled = map[string]wazero.CompiledModule{}
+	})
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if mod, ok := r.compiled[path]; ok {
+		return r.rt, mod, nil
+	}
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	mod, err := r.rt.CompileModule(context.Background(), code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("compiling module: %w", err)
+	}
+	r.compiled[path] = mod
+	return r.rt, mod, nil
+}
+
+// runWasm runs a WASM plugin like run does an executable: the subcommand is
+// passed as the first argument and stdout is returned. timeout starts once
+// the module is compiled.
+func runWasm(ctx context.Context, path, subcommand string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	rt, compiled, err := wasmModule(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs("plugin", subcommand).
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+
+	mod, err := rt.InstantiateModule(ctx, compiled, cfg)
+	if mod != nil {
+		mod.Close(context.Background())
+	}
+	if err != nil {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 0 {
+			return stdout.Bytes(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out", subcommand)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", subcommand, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", subcommand, err)
+	}
+	return stdout.Bytes(), nil
+}
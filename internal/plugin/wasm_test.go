@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildWasmPlugin compiles testdata/wasmecho for WASI into dir.
+func buildWasmPlugin(t *testing.T, dir string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building a WASM module is slow")
+	}
+	out := filepath.Join(dir, "wasmecho.wasm")
+	cmd := exec.Command("go", "build", "-o", out, "./testdata/wasmecho")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if b, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building wasm plugin: %v\n%s", err, b)
+	}
+	return out
+}
+
+func TestWasmPlugin(t *testing.T) {
+	dir := t.TempDir()
+	path := buildWasmPlugin(t, dir)
+	os.Chmod(path, 0o644) // .wasm modules need not be executable
+
+	plugins, errs := Discover(dir)
+	if len(errs) != 0 || len(plugins) != 1 {
+		t.Fatalf("Discover() = %v, %v", plugins, errs)
+	}
+	p := plugins[0]
+	if p.Name != "wasmecho" || !p.IsWasm() {
+		t.Errorf("plugin = %+v", p)
+	}
+
+	out, err := p.Invoke(context.Background(), p.CommandArgs("hi"))
+	if err != nil || out != "echo: hi" {
+		t.Errorf("Invoke() = %q, %v", out, err)
+	}
+
+	// No host environment or filesystem inside the sandbox
+	out, err = p.Invoke(context.Background(), p.CommandArgs("env"))
+	if err != nil || out != "0 env vars, read error: true" {
+		t.Errorf("sandbox check = %q, %v", out, err)
+	}
+
+	// Runaway modules are stopped when the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := p.Invoke(ctx, p.CommandArgs("spin")); err == nil || err.Error() != "invoke timed out" {
+		t.Errorf("Invoke(spin) error = %v, want timeout", err)
+	}
+}
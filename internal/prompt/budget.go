@@ -0,0 +1,110 @@
+package prompt
+
+import (
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// trimOrder lists the sections in the order they are trimmed when the
+// prompt is over budget: what the assistant can best do without first.
+var trimOrder = []string{
+	SectionBoot,
+	SectionHeartbeat,
+	SectionMemory,
+	SectionSoul,
+	SectionUser,
+	SectionIdentity,
+	SectionBootstrap,
+}
+
+// trimMarker ends a section that was shortened to fit the budget.
+const trimMarker = "\n[…trimmed to fit the context]"
+
+// minSectionTokens is the least of a section worth keeping; a section
+// that would be cut shorter is left out.
+const minSectionTokens = 50
+
+// SectionStats is the size of one section of the system prompt.
+type SectionStats struct {
+	Name   string
+	Tokens int // as loaded
+	Sent   int // in the prompt after trimming; 0 if it was left out
+}
+
+// Trimmed reports whether the section was shortened or left out.
+func (s SectionStats) Trimmed() bool {
+	return s.Sent < s.Tokens
+}
+
+// SetBudget limits the sections of the system prompt to maxTokens; 0 means
+// no limit. Over budget, sections are shortened in trimOrder: BOOT.md first,
+// IDENTITY.md last. MEMORY.md keeps its newest lines.
+func (a *Assembler) SetBudget(maxTokens int) {
+	a.budget = maxTokens
+}
+
+// Budget returns the limit set with SetBudget.
+func (a *Assembler) Budget() int {
+	return a.budget
+}
+
+// Stats returns the size of each non-empty section, in prompt order.
+func (a *Assembler) Stats() []SectionStats {
+	full := a.contents()
+	sent := trim(full, a.budget)
+	var stats []SectionStats
+	for _, name := range AllSections {
+		if content, ok := full[name]; ok {
+			stats = append(stats, SectionStats{Name: name, Tokens: tokens.Count(content), Sent: tokens.Count(sent[name])})
+		}
+	}
+	return stats
+}
+
+// trim returns sections shortened in trimOrder until they fit maxTokens.
+func trim(sections map[string]string, maxTokens int) map[string]string {
+	if maxTokens <= 0 {
+		return sections
+	}
+	trimmed := make(map[string]string, len(sections))
+	for name, content := range sections {
+		trimmed[name] = content
+	}
+	for _, name := range trimOrder {
+		over := tokens.Count(join(trimmed)) - maxTokens
+		if over <= 0 {
+			break
+		}
+		content, ok := trimmed[name]
+		if !ok {
+			continue
+		}
+		keep := tokens.Count(content) - over - tokens.Count(trimMarker)
+		if keep < minSectionTokens {
+			delete(trimmed, name)
+			continue
+		}
+		if name == SectionMemory {
+			trimmed[name] = keepEnd(content, keep) + trimMarker
+		} else {
+			trimmed[name] = tokens.Truncate(content, keep) + trimMarker
+		}
+	}
+	return trimmed
+}
+
+// keepEnd returns the last whole lines of text that fit maxTokens.
+func keepEnd(text string, maxTokens int) string {
+	lines := strings.Split(text, "\n")
+	n := 0
+	start := len(lines)
+	for start > 0 {
+		n += tokens.Count(lines[start-1]) + 1
+		if n > maxTokens {
+			break
+		}
+		start--
+	}
+	return strings.Join(lines[start:], "\n")
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// budgetAssembler returns an assembler with a short IDENTITY.md, a long
+// SOUL.md and a MEMORY.md of numbered facts.
+func budgetAssembler(t *testing.T) *Assembler {
+	t.Helper()
+	dir := t.TempDir()
+	var memory []string
+	for i := range 100 {
+		memory = append(memory, "- fact number "+strings.Repeat("x", i%5)+" "+string(rune('a'+i%26)))
+	}
+	memory = append(memory, "- the newest fact")
+	files := map[string]string{
+		SectionIdentity: "# Identity\nI am Stefan.",
+		SectionSoul:     "# Soul\n" + strings.Repeat("Be kind and helpful. ", 100),
+		SectionMemory:   strings.Join(memory, "\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := NewAssembler(dir)
+	a.LoadFiles()
+	// Leave the embedded defaults of the other sections out
+	for _, name := range []string{SectionUser, SectionBoot, SectionHeartbeat, SectionBootstrap} {
+		delete(a.sections, name)
+	}
+	return a
+}
+
+func TestBuildSystemPrompt_Budget(t *testing.T) {
+	a := budgetAssembler(t)
+	full := a.BuildSystemPrompt()
+
+	a.SetBudget(tokens.Count(full) - 300)
+	p := a.BuildSystemPrompt()
+	if n := tokens.Count(p); n > a.Budget() {
+		t.Errorf("prompt has %d tokens, budget is %d", n, a.Budget())
+	}
+	if !strings.Contains(p, "the newest fact") || strings.Contains(p, "fact number  a\n") {
+		t.Error("MEMORY.md should keep its newest lines and lose its oldest")
+	}
+	if !strings.Contains(p, strings.TrimSpace(strings.Repeat("Be kind and helpful. ", 100))) {
+		t.Error("SOUL.md was trimmed before MEMORY.md")
+	}
+	if !strings.Contains(p, trimMarker) {
+		t.Error("trimmed section should be marked")
+	}
+
+	a.SetBudget(0)
+	if got := a.BuildSystemPrompt(); got != full {
+		t.Error("no budget should leave the prompt whole")
+	}
+}
+
+func TestStats(t *testing.T) {
+	a := budgetAssembler(t)
+	a.SetBudget(200)
+	stats := a.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("got %d sections, want 3: %+v", len(stats), stats)
+	}
+	byName := map[string]SectionStats{}
+	for _, s := range stats {
+		byName[s.Name] = s
+	}
+	if s := byName[SectionIdentity]; s.Trimmed() || s.Sent == 0 {
+		t.Errorf("IDENTITY.md = %+v, want it whole", s)
+	}
+	if s := byName[SectionMemory]; s.Sent != 0 {
+		t.Errorf("MEMORY.md = %+v, want it left out", s)
+	}
+	if s := byName[SectionSoul]; !s.Trimmed() || s.Sent == 0 {
+		t.Errorf("SOUL.md = %+v, want it shortened", s)
+	}
+	if n := tokens.Count(a.BuildSystemPrompt()); n > 200 {
+		t.Errorf("prompt has %d tokens, budget is 200", n)
+	}
+}
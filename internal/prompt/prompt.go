@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
 )
 
 // Section names for personality files.
@@ -33,6 +35,8 @@ var AllSections = []string{
 type Assembler struct {
 	personalityDir string
 	sections       map[string]string
+	sectionPaths   map[string]string // per-section disk overrides
+	budget         int               // tokens the sections may take; 0 for no limit
 }
 
 // NewAssembler creates an Assembler that reads from the given personality directory.
@@ -40,9 +44,16 @@ func NewAssembler(personalityDir string) *Assembler {
 	return &Assembler{
 		personalityDir: personalityDir,
 		sections:       make(map[string]string),
+		sectionPaths:   make(map[string]string),
 	}
 }
 
+// SetSectionPath makes a section load from path instead of the personality
+// directory, e.g. MEMORY.md kept in the data directory.
+func (a *Assembler) SetSectionPath(name, path string) {
+	a.sectionPaths[name] = path
+}
+
 // LoadFiles reads personality files from disk, falling back to embedded defaults.
 func (a *Assembler) LoadFiles() error {
 	for _, name := range AllSections {
@@ -55,9 +66,24 @@ func (a *Assembler) LoadFiles() error {
 	return nil
 }
 
+// ReloadSection reads one section from disk again, e.g. MEMORY.md after
+// facts were saved, so the next system prompt includes the change. It
+// reports whether the section changed.
+func (a *Assembler) ReloadSection(name string) bool {
+	content, err := a.loadFile(name)
+	if err != nil || content == a.sections[name] {
+		return false
+	}
+	a.sections[name] = content
+	return true
+}
+
 func (a *Assembler) loadFile(name string) (string, error) {
 	// Try disk first
 	diskPath := filepath.Join(a.personalityDir, name)
+	if p, ok := a.sectionPaths[name]; ok {
+		diskPath = p
+	}
 	data, err := os.ReadFile(diskPath)
 	if err == nil {
 		return string(data), nil
@@ -71,28 +97,57 @@ func (a *Assembler) loadFile(name string) (string, error) {
 	return string(data), nil
 }
 
-// BuildSystemPrompt assembles all loaded sections into a single system prompt.
+// BuildSystemPrompt assembles all loaded sections into a single system
+// prompt, trimmed to the budget set with SetBudget.
 func (a *Assembler) BuildSystemPrompt() string {
-	var parts []string
+	return join(trim(a.contents(), a.budget))
+}
+
+// contents returns the non-empty sections as they go into the prompt.
+func (a *Assembler) contents() map[string]string {
+	contents := make(map[string]string)
 	for _, name := range AllSections {
 		content, ok := a.sections[name]
+		if name == SectionHeartbeat {
+			// Tasks are brought up by heartbeats when due, not in every chat
+			content = heartbeat.Strip(content)
+		}
 		if !ok || strings.TrimSpace(content) == "" {
 			continue
 		}
-		parts = append(parts, strings.TrimSpace(content))
+		contents[name] = strings.TrimSpace(content)
+	}
+	return contents
+}
+
+// separator joins the sections of the prompt.
+const separator = "\n\n---\n\n"
+
+// join assembles sections in prompt order.
+func join(sections map[string]string) string {
+	var parts []string
+	for _, name := range AllSections {
+		if content, ok := sections[name]; ok {
+			parts = append(parts, content)
+		}
 	}
-	return strings.Join(parts, "\n\n---\n\n")
+	return strings.Join(parts, separator)
 }
 
 // BuildSystemPromptWithLanguage assembles the system prompt and prepends a
 // language instruction so the LLM responds in the user's preferred language.
 func (a *Assembler) BuildSystemPromptWithLanguage(language string) string {
-	base := a.BuildSystemPrompt()
 	if language == "" {
 		language = "English"
 	}
-	instruction := fmt.Sprintf("IMPORTANT: Always respond in %s. All your messages, questions, and responses must be in %s.", language, language)
-	return instruction + "\n\n---\n\n" + base
+	base := a.BuildSystemPrompt()
+	return LanguageInstruction(language) + separator + base
+}
+
+// LanguageInstruction returns the instruction BuildSystemPromptWithLanguage
+// puts before the sections.
+func LanguageInstruction(language string) string {
+	return fmt.Sprintf("IMPORTANT: Always respond in %s. All your messages, questions, and responses must be in %s.", language, language)
 }
 
 // HasSection returns true if the named section was loaded and is non-empty.
@@ -122,6 +177,42 @@ func (a *Assembler) DeleteBootstrap() error {
 	return nil
 }
 
+// SetUserField sets a "- Key: value" line in USER.md, replacing an existing
+// line for the same key or appending a new one. The in-memory section is
+// updated so the next system prompt reflects the change.
+func (a *Assembler) SetUserField(key, value string) error {
+	path := filepath.Join(a.personalityDir, SectionUser)
+	content, err := a.loadFile(SectionUser)
+	if err != nil {
+		content = "# User\n"
+	}
+
+	prefix := "- " + key + ":"
+	line := prefix + " " + value
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	replaced := false
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), prefix) {
+			lines[i] = line
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+	updated := strings.Join(lines, "\n") + "\n"
+
+	if err := os.MkdirAll(a.personalityDir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return err
+	}
+	a.sections[SectionUser] = updated
+	return nil
+}
+
 // BootstrapExists checks if BOOTSTRAP.md exists on disk.
 func BootstrapExists(personalityDir string) bool {
 	_, err := os.Stat(filepath.Join(personalityDir, SectionBootstrap))
@@ -236,3 +236,51 @@ func TestEmbeddedDefaults_NotEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestSetUserField_ReplacesAndAppends(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SectionUser), []byte("# User\n\n- Language: English\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAssembler(dir)
+	a.LoadFiles()
+
+	if err := a.SetUserField("Language", "Deutsch"); err != nil {
+		t.Fatalf("SetUserField() error: %v", err)
+	}
+	if err := a.SetUserField("Name", "Ada"); err != nil {
+		t.Fatalf("SetUserField() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SectionUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if strings.Contains(got, "English") || !strings.Contains(got, "- Language: Deutsch") {
+		t.Errorf("language line not replaced: %q", got)
+	}
+	if !strings.Contains(got, "- Name: Ada") {
+		t.Errorf("name line not appended: %q", got)
+	}
+	if !strings.Contains(a.BuildSystemPrompt(), "- Language: Deutsch") {
+		t.Error("in-memory section should reflect the change")
+	}
+}
+
+func TestSetSectionPath(t *testing.T) {
+	dir := t.TempDir()
+	memPath := filepath.Join(t.TempDir(), "MEMORY.md")
+	if err := os.WriteFile(memPath, []byte("# Memory\n- lives in data dir"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAssembler(dir)
+	a.SetSectionPath(SectionMemory, memPath)
+	a.LoadFiles()
+
+	if !strings.Contains(a.Section(SectionMemory), "lives in data dir") {
+		t.Errorf("MEMORY.md not loaded from override path: %q", a.Section(SectionMemory))
+	}
+}
@@ -0,0 +1,109 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateExts lists the file extensions tried when resolving a template name.
+var templateExts = []string{"", ".tmpl", ".md", ".txt"}
+
+// FindTemplate resolves a template name to a file in dir. The name may be
+// given with or without one of the supported extensions.
+func FindTemplate(dir, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	for _, ext := range templateExts {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("template %q not found in %s", name, dir)
+}
+
+// ListTemplates returns the names of all templates in dir, without extensions.
+func ListTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RenderTemplate executes the named template from dir with vars available as
+// {{.key}}. The {{stdin}} function returns the result of calling stdin, which
+// is invoked at most once. Referencing an undefined variable is an error.
+func RenderTemplate(dir, name string, vars map[string]string, stdin func() (string, error)) (string, error) {
+	path, err := FindTemplate(dir, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading template: %w", err)
+	}
+
+	var stdinRead bool
+	var stdinContent string
+	funcs := template.FuncMap{
+		"stdin": func() (string, error) {
+			if !stdinRead {
+				stdinRead = true
+				if stdin == nil {
+					return "", nil
+				}
+				s, err := stdin()
+				if err != nil {
+					return "", fmt.Errorf("reading stdin: %w", err)
+				}
+				stdinContent = strings.TrimRight(s, "\n")
+			}
+			return stdinContent, nil
+		},
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Funcs(funcs).
+		Option("missingkey=error").
+		Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("parsing template %s: %w", name, err)
+	}
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("executing template %s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// ParseVar splits a "key=value" command-line assignment.
+func ParseVar(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value", s)
+	}
+	return key, value, nil
+}
@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestRenderTemplate_Vars(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "greet.tmpl", "Say hello to {{.name}} in {{.lang}}.")
+
+	got, err := RenderTemplate(dir, "greet", map[string]string{"name": "Ada", "lang": "French"}, nil)
+	if err != nil {
+		t.Fatalf("RenderTemplate() error: %v", err)
+	}
+	if got != "Say hello to Ada in French." {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRenderTemplate_Stdin(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "commit-message.md", "Write a commit message for:\n{{stdin}}\n\nAgain: {{stdin}}")
+
+	calls := 0
+	stdin := func() (string, error) {
+		calls++
+		return "diff --git a/x b/x\n", nil
+	}
+	got, err := RenderTemplate(dir, "commit-message", nil, stdin)
+	if err != nil {
+		t.Fatalf("RenderTemplate() error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("stdin read %d times, want 1", calls)
+	}
+	if strings.Count(got, "diff --git a/x b/x") != 2 {
+		t.Errorf("stdin content not substituted twice: %q", got)
+	}
+}
+
+func TestRenderTemplate_MissingVar(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "standup.tmpl", "Summarize {{.team}}")
+
+	if _, err := RenderTemplate(dir, "standup", nil, nil); err == nil {
+		t.Error("RenderTemplate() should fail for undefined variable")
+	}
+}
+
+func TestRenderTemplate_NotFound(t *testing.T) {
+	if _, err := RenderTemplate(t.TempDir(), "nope", nil, nil); err == nil {
+		t.Error("RenderTemplate() should fail for missing template")
+	}
+	if _, err := FindTemplate(t.TempDir(), "../etc/passwd"); err == nil {
+		t.Error("FindTemplate() should reject path separators")
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "b.tmpl", "b")
+	writeTemplate(t, dir, "a.md", "a")
+	writeTemplate(t, dir, ".hidden", "x")
+
+	names, err := ListTemplates(dir)
+	if err != nil {
+		t.Fatalf("ListTemplates() error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("ListTemplates() = %v, want [a b]", names)
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	k, v, err := ParseVar("team=platform=core")
+	if err != nil || k != "team" || v != "platform=core" {
+		t.Errorf("ParseVar() = %q, %q, %v", k, v, err)
+	}
+	if _, _, err := ParseVar("novalue"); err == nil {
+		t.Error("ParseVar() should fail without '='")
+	}
+}
@@ -0,0 +1,144 @@
+// Package azure talks to Azure OpenAI, which serves each model as a
+// deployment of an Azure resource at its own URL,
+// /openai/deployments/<deployment>/chat/completions?api-version=<version>,
+// rather than by the model named in the request.
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/openaicompat"
+)
+
+// DefaultAPIVersion is the API version requests are sent with unless
+// configured otherwise: the latest generally available one with usage in
+// streamed replies.
+const DefaultAPIVersion = "2024-10-21"
+
+// ErrNoAPIKey is returned by every request when no API key is configured.
+var ErrNoAPIKey = errors.New("no Azure OpenAI API key: set provider.azure.api_key in config.yaml")
+
+// AzureProvider implements the Provider interface for Azure OpenAI.
+type AzureProvider struct {
+	api         *openaicompat.Client
+	apiVersion  string
+	deployments []string
+
+	apiKey  string
+	resolve func(string) (string, error)
+
+	keyOnce sync.Once
+	key     string
+	keyErr  error
+}
+
+func init() {
+	provider.Register("azure", func(s provider.Settings) (provider.Provider, error) {
+		return New(s.BaseURL, s.APIKey, s.APIVersion, s.Models, s.Resolve), nil
+	})
+}
+
+// New creates a new AzureProvider for the resource at endpoint, e.g.
+// https://my-resource.openai.azure.com, offering deployments as its models.
+// apiKey may be a secret reference, which is looked up with resolve on
+// first use; nil resolve takes it as is. An empty apiVersion uses
+// DefaultAPIVersion.
+func New(endpoint, apiKey, apiVersion string, deployments []string, resolve func(string) (string, error)) *AzureProvider {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	a := &AzureProvider{apiVersion: apiVersion, deployments: deployments, apiKey: apiKey, resolve: resolve}
+	a.api = &openaicompat.Client{
+		Name:    "azure",
+		BaseURL: strings.TrimSuffix(endpoint, "/") + "/openai",
+		Auth:    a.authorize,
+		Prepare: prepare,
+		Route:   a.route,
+		HTTP:    &http.Client{},
+	}
+	return a
+}
+
+func (a *AzureProvider) Name() string {
+	return "azure"
+}
+
+// authorize adds the API key to a request.
+func (a *AzureProvider) authorize(r *http.Request) error {
+	a.keyOnce.Do(func() {
+		a.key, a.keyErr = a.resolve(a.apiKey)
+		if a.keyErr == nil && strings.TrimSpace(a.key) == "" {
+			a.keyErr = ErrNoAPIKey
+		}
+	})
+	if a.keyErr != nil {
+		return fmt.Errorf("azure api key: %w", a.keyErr)
+	}
+	r.Header.Set("api-key", strings.TrimSpace(a.key))
+	return nil
+}
+
+// route sends a request for a model to the deployment of that name.
+func (a *AzureProvider) route(path, model string) string {
+	return "/deployments/" + url.PathEscape(model) + path + a.query()
+}
+
+// query returns the query string every request carries.
+func (a *AzureProvider) query() string {
+	return "?api-version=" + url.QueryEscape(a.apiVersion)
+}
+
+// prepare adapts a chat request to Azure OpenAI, which rejects the
+// parameters OpenAI doesn't know, such as repeat_penalty.
+func prepare(req *openaicompat.ChatRequest) {
+	req.RepeatPenalty = nil
+}
+
+// Chat sends a non-streaming chat request.
+func (a *AzureProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	return a.api.Chat(ctx, req)
+}
+
+// StreamChat sends a streaming chat request and returns a channel of deltas.
+func (a *AzureProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	return a.api.StreamChat(ctx, req)
+}
+
+// ListModels returns the configured deployments. Azure OpenAI's API can
+// list the models a resource may deploy, but not its deployments.
+func (a *AzureProvider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	if len(a.deployments) == 0 {
+		return nil, errors.New("listing models: no deployments configured: list them under provider.azure.deployments in config.yaml")
+	}
+	models := make([]provider.ModelInfo, len(a.deployments))
+	for i, d := range a.deployments {
+		models[i] = provider.ModelInfo{Name: d}
+	}
+	return models, nil
+}
+
+// Embed returns one embedding per text, computed by the deployment model,
+// e.g. one of text-embedding-3-small.
+func (a *AzureProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	return a.api.Embed(ctx, model, texts)
+}
+
+// IsAvailable checks that the resource is reachable and accepts the API
+// key and version.
+func (a *AzureProvider) IsAvailable(ctx context.Context) error {
+	var resp struct{}
+	if err := a.api.Get(ctx, "/models"+a.query(), &resp); err != nil {
+		return fmt.Errorf("azure openai is not reachable at %s: %w", strings.TrimSuffix(a.api.BaseURL, "/openai"), err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestStreamChat(t *testing.T) {
+	penalty := 1.1
+	var got map[string]any
+	var path, version, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, version, key = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key")
+		json.NewDecoder(r.Body).Decode(&got)
+		// Azure opens with the results of its prompt filter and no choices,
+		// and ends with the usage, also without choices.
+		for _, chunk := range []string{
+			`{"choices":[],"created":0,"id":"","model":"","object":"","prompt_filter_results":[{"prompt_index":0,"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}]}`,
+			`{"choices":[{"content_filter_results":{},"delta":{"content":"","role":"assistant"},"finish_reason":null,"index":0}],"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","object":"chat.completion.chunk"}`,
+			`{"choices":[{"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}},"delta":{"content":"Hello"},"finish_reason":null,"index":0}],"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","object":"chat.completion.chunk"}`,
+			`{"choices":[{"content_filter_results":{},"delta":{},"finish_reason":"stop","index":0}],"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","object":"chat.completion.chunk"}`,
+			`{"choices":[],"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","object":"chat.completion.chunk","usage":{"completion_tokens":1,"prompt_tokens":9,"total_tokens":10}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	resolve := func(ref string) (string, error) { return "key-for-" + ref, nil }
+	ch, err := New(srv.URL+"/", "keyring:azure", "", nil, resolve).StreamChat(context.Background(), provider.ChatRequest{
+		Model:    "chat",
+		Messages: []provider.Message{{Role: "user", Content: "Hi"}},
+		Options:  provider.Options{RepeatPenalty: &penalty},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var content string
+	var usage *provider.Usage
+	for d := range ch {
+		if d.Err != nil {
+			t.Fatal(d.Err)
+		}
+		content += d.Content
+		if d.Done {
+			usage = d.Usage
+		}
+	}
+	if content != "Hello" || usage == nil || usage.PromptTokens != 9 {
+		t.Errorf("content = %q, usage = %+v", content, usage)
+	}
+	if path != "/openai/deployments/chat/chat/completions" || version != DefaultAPIVersion {
+		t.Errorf("sent to %s?api-version=%s", path, version)
+	}
+	if key != "key-for-keyring:azure" {
+		t.Errorf("api-key = %q", key)
+	}
+	if _, ok := got["repeat_penalty"]; ok {
+		t.Errorf("sent repeat_penalty, which Azure rejects: %v", got)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/embed/embeddings" || r.URL.Query().Get("api-version") != "2025-01-01-preview" {
+			t.Errorf("sent to %s", r.URL)
+		}
+		fmt.Fprint(w, `{"data":[{"index":0,"embedding":[0.5,1]}]}`)
+	}))
+	defer srv.Close()
+
+	got, err := New(srv.URL, "key", "2025-01-01-preview", nil, nil).Embed(context.Background(), "embed", []string{"hello"})
+	if err != nil || len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("Embed() = %v, %v", got, err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	models, err := New("https://res.openai.azure.com", "key", "", []string{"gpt-4o", "gpt-4o-mini"}, nil).ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[1].Name != "gpt-4o-mini" {
+		t.Errorf("ListModels() = %+v, %v", models, err)
+	}
+	if _, err := New("https://res.openai.azure.com", "key", "", nil, nil).ListModels(context.Background()); err == nil {
+		t.Error("listed models without deployments")
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/models" || r.Header.Get("api-key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"code":"401","message":"Access denied due to invalid subscription key or wrong API endpoint."}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[],"object":"list"}`)
+	}))
+	defer srv.Close()
+
+	if err := New(srv.URL, "key", "", nil, nil).IsAvailable(context.Background()); err != nil {
+		t.Errorf("IsAvailable() = %v", err)
+	}
+	if err := New(srv.URL, "wrong", "", nil, nil).IsAvailable(context.Background()); err == nil {
+		t.Error("IsAvailable() with a wrong key succeeded")
+	}
+	if err := New(srv.URL, "", "", nil, nil).IsAvailable(context.Background()); err == nil {
+		t.Error("IsAvailable() without a key succeeded")
+	}
+}
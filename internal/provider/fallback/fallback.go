@@ -0,0 +1,216 @@
+// Package fallback chains providers, so that a request the first one can't
+// serve, e.g. because the local Ollama isn't running, is retried with the
+// next one, e.g. OpenRouter. Chat requests that fail for a passing reason
+// can first be repeated with the same provider.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/retry"
+)
+
+// Backend is a provider in the chain.
+type Backend struct {
+	Provider provider.Provider
+	Model    string // replaces the requested model; empty keeps it
+}
+
+// Chain implements the Provider interface by trying its backends in order.
+type Chain struct {
+	backends []Backend
+	retry    retry.Policy // for each backend's chat requests
+	answered atomic.Int32 // index of the backend that answered last
+}
+
+// New chains primary with the backups tried after it. If primary can
+// compute embeddings, so can the chain; they never fall back, since vectors
+// from different models can't be compared.
+func New(primary provider.Provider, backups ...Backend) provider.Provider {
+	return NewRetrying(retry.Policy{}, primary, backups...)
+}
+
+// NewRetrying is New with chat requests that fail for a passing reason
+// retried under policy before the next backend is tried.
+func NewRetrying(policy retry.Policy, primary provider.Provider, backups ...Backend) provider.Provider {
+	c := &Chain{backends: append([]Backend{{Provider: primary}}, backups...), retry: policy}
+	if emb, ok := primary.(provider.Embedder); ok {
+		return &embedderChain{Chain: c, emb: emb}
+	}
+	return c
+}
+
+// Name returns the name of the provider that answered last, the primary one
+// until a request was made.
+func (c *Chain) Name() string {
+	return c.backends[c.answered.Load()].Provider.Name()
+}
+
+// Chat sends a non-streaming chat request to the first provider that
+// accepts it.
+func (c *Chain) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	var errs []error
+	for i, b := range c.backends {
+		resp, err := retry.Do(ctx, c.retry, func() (*provider.ChatResponse, error) {
+			return b.Provider.Chat(ctx, b.request(req))
+		})
+		if err == nil {
+			c.answer(i)
+			return resp, nil
+		}
+		if errs = c.failed(ctx, errs, b, err); errs == nil {
+			return nil, err
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// StreamChat starts a streaming chat request with the first provider that
+// accepts it. Once a stream has started it isn't retried elsewhere, since
+// part of the reply may already be shown.
+func (c *Chain) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	var errs []error
+	for i, b := range c.backends {
+		ch, err := retry.Do(ctx, c.retry, func() (<-chan provider.StreamDelta, error) {
+			return b.Provider.StreamChat(ctx, b.request(req))
+		})
+		if err == nil {
+			c.answer(i)
+			return ch, nil
+		}
+		if errs = c.failed(ctx, errs, b, err); errs == nil {
+			return nil, err
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ListModels lists the models of the first provider that answers.
+func (c *Chain) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var errs []error
+	for _, b := range c.backends {
+		models, err := b.Provider.ListModels(ctx)
+		if err == nil {
+			return models, nil
+		}
+		if errs = c.failed(ctx, errs, b, err); errs == nil {
+			return nil, err
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// IsAvailable succeeds if any provider in the chain is reachable, and
+// names the first reachable one as the one expected to answer.
+func (c *Chain) IsAvailable(ctx context.Context) error {
+	var errs []error
+	for i, b := range c.backends {
+		err := b.Provider.IsAvailable(ctx)
+		if err == nil {
+			c.answer(i)
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Provider.Name(), err))
+	}
+	return errors.Join(errs...)
+}
+
+// CallsTools reports whether the primary provider has native tool calling.
+// Backups without it answer without the tools.
+func (c *Chain) CallsTools() bool {
+	tc, ok := c.backends[0].Provider.(provider.ToolCaller)
+	return ok && tc.CallsTools()
+}
+
+// Pull downloads a model with the primary provider, the one that would
+// serve it when reachable.
+func (c *Chain) Pull(ctx context.Context, model string, progress func(provider.PullProgress)) error {
+	primary := c.backends[0].Provider
+	puller, ok := primary.(provider.Puller)
+	if !ok {
+		return fmt.Errorf("%s can't download models: %w", primary.Name(), errors.ErrUnsupported)
+	}
+	return puller.Pull(ctx, model, progress)
+}
+
+// ShowModel describes a model offered by the primary provider.
+func (c *Chain) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	primary := c.backends[0].Provider
+	md, ok := primary.(provider.ModelDescriber)
+	if !ok {
+		return nil, fmt.Errorf("%s can't describe models: %w", primary.Name(), errors.ErrUnsupported)
+	}
+	return md.ShowModel(ctx, model)
+}
+
+// Warm loads a model with the primary provider, the one that would serve
+// the next request when reachable.
+func (c *Chain) Warm(ctx context.Context, model string) error {
+	primary := c.backends[0].Provider
+	w, ok := primary.(provider.Warmer)
+	if !ok {
+		return fmt.Errorf("%s doesn't load models ahead: %w", primary.Name(), errors.ErrUnsupported)
+	}
+	return w.Warm(ctx, model)
+}
+
+// DeleteModel removes a model installed with the primary provider.
+func (c *Chain) DeleteModel(ctx context.Context, model string) error {
+	mm, err := c.manager()
+	if err != nil {
+		return err
+	}
+	return mm.DeleteModel(ctx, model)
+}
+
+// manager returns the primary provider if it manages its models.
+func (c *Chain) manager() (provider.ModelManager, error) {
+	primary := c.backends[0].Provider
+	mm, ok := primary.(provider.ModelManager)
+	if !ok {
+		return nil, fmt.Errorf("%s can't manage models: %w", primary.Name(), errors.ErrUnsupported)
+	}
+	return mm, nil
+}
+
+// request adapts req to b's model.
+func (b Backend) request(req provider.ChatRequest) provider.ChatRequest {
+	if b.Model != "" {
+		req.Model = b.Model
+	}
+	return req
+}
+
+// answer records that the i-th backend answered.
+func (c *Chain) answer(i int) {
+	if prev := c.answered.Swap(int32(i)); prev != int32(i) {
+		log.Info("provider changed", "provider", c.backends[i].Provider.Name())
+	}
+}
+
+// failed adds the error of b to errs, or returns nil if the request was
+// canceled, which no other provider would change, or the model can't call
+// tools, which callers handle by asking again without them.
+func (c *Chain) failed(ctx context.Context, errs []error, b Backend, err error) []error {
+	if ctx.Err() != nil || errors.Is(err, provider.ErrToolsUnsupported) {
+		return nil
+	}
+	log.Warn("provider failed, trying the next one", "provider", b.Provider.Name(), "err", err)
+	return append(errs, fmt.Errorf("%s: %w", b.Provider.Name(), err))
+}
+
+// embedderChain is a Chain whose primary provider computes embeddings.
+type embedderChain struct {
+	*Chain
+	emb provider.Embedder
+}
+
+// Embed computes embeddings with the primary provider.
+func (c *embedderChain) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	return c.emb.Embed(ctx, model, texts)
+}
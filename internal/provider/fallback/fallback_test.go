@@ -0,0 +1,176 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/provider/retry"
+)
+
+var errDown = errors.New("connection refused")
+
+func TestChain_FallsBackToNextProvider(t *testing.T) {
+	local := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: errDown}}}
+	hosted := &providertest.Fake{ProviderName: "openrouter", Replies: []providertest.Reply{providertest.Text("hello")}}
+	c := New(local, Backend{Provider: hosted, Model: "qwen/qwen3-8b"})
+
+	if c.Name() != "ollama" {
+		t.Errorf("Name() before a request = %q, want ollama", c.Name())
+	}
+	resp, err := c.Chat(context.Background(), provider.ChatRequest{Model: "qwen3:8b"})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "hello" {
+		t.Errorf("content = %q", resp.Message.Content)
+	}
+	if got := hosted.LastRequest().Model; got != "qwen/qwen3-8b" {
+		t.Errorf("fallback model = %q, want qwen/qwen3-8b", got)
+	}
+	if c.Name() != "openrouter" {
+		t.Errorf("Name() = %q, want openrouter", c.Name())
+	}
+}
+
+func TestChain_StreamReturnsToPrimary(t *testing.T) {
+	local := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: errDown}, providertest.Text("back")}}
+	hosted := &providertest.Fake{ProviderName: "openrouter", Replies: []providertest.Reply{providertest.Text("hosted")}}
+	c := New(local, Backend{Provider: hosted})
+
+	for _, want := range []string{"openrouter", "ollama"} {
+		ch, err := c.StreamChat(context.Background(), provider.ChatRequest{Model: "qwen3:8b"})
+		if err != nil {
+			t.Fatalf("StreamChat: %v", err)
+		}
+		for range ch {
+		}
+		if c.Name() != want {
+			t.Errorf("Name() = %q, want %q", c.Name(), want)
+		}
+	}
+	if got := hosted.LastRequest().Model; got != "qwen3:8b" {
+		t.Errorf("fallback model = %q, want the requested one", got)
+	}
+}
+
+func TestChain_AllFail(t *testing.T) {
+	local := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: errDown}}, Unavailable: errDown}
+	hosted := &providertest.Fake{ProviderName: "openrouter", Replies: []providertest.Reply{{Err: errors.New("402 insufficient credits")}}, Unavailable: errDown}
+	c := New(local, Backend{Provider: hosted})
+
+	_, err := c.StreamChat(context.Background(), provider.ChatRequest{})
+	if err == nil || !errors.Is(err, errDown) || !strings.Contains(err.Error(), "openrouter: 402 insufficient credits") {
+		t.Errorf("StreamChat error = %v", err)
+	}
+	if err := c.IsAvailable(context.Background()); err == nil {
+		t.Error("IsAvailable succeeded with no provider reachable")
+	}
+	hosted.Unavailable = nil
+	if err := c.IsAvailable(context.Background()); err != nil {
+		t.Errorf("IsAvailable = %v with the fallback reachable", err)
+	}
+	if c.Name() != "openrouter" {
+		t.Errorf("Name() = %q after IsAvailable, want openrouter", c.Name())
+	}
+}
+
+func TestChain_CanceledIsNotRetried(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	local := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: context.Canceled}}}
+	hosted := &providertest.Fake{ProviderName: "openrouter"}
+	c := New(local, Backend{Provider: hosted})
+
+	if _, err := c.Chat(ctx, provider.ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Chat error = %v, want context.Canceled", err)
+	}
+	if n := len(hosted.Requests()); n != 0 {
+		t.Errorf("fallback got %d requests after cancellation", n)
+	}
+}
+
+type embedder struct{ providertest.Fake }
+
+func (e *embedder) Embed(context.Context, string, []string) ([][]float32, error) {
+	return [][]float32{{1}}, nil
+}
+
+func TestChain_EmbedsWithPrimaryOnly(t *testing.T) {
+	if _, ok := New(&providertest.Fake{}).(provider.Embedder); ok {
+		t.Error("chain embeds though its primary provider can't")
+	}
+	emb, ok := New(&embedder{}, Backend{Provider: &providertest.Fake{}}).(provider.Embedder)
+	if !ok {
+		t.Fatal("chain doesn't embed though its primary provider can")
+	}
+	if v, err := emb.Embed(context.Background(), "m", []string{"x"}); err != nil || len(v) != 1 {
+		t.Errorf("Embed = %v, %v", v, err)
+	}
+}
+
+func TestChain_PullNeedsPuller(t *testing.T) {
+	c := New(&providertest.Fake{ProviderName: "lmstudio"}).(provider.Puller)
+	if err := c.Pull(context.Background(), "m", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Pull error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestChain_WarmNeedsWarmer(t *testing.T) {
+	c := New(&providertest.Fake{ProviderName: "lmstudio"}).(provider.Warmer)
+	if err := c.Warm(context.Background(), "m"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Warm error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestChain_ManagingModelsNeedsManager(t *testing.T) {
+	c := New(&providertest.Fake{ProviderName: "lmstudio"}).(provider.ModelManager)
+	if _, err := c.ShowModel(context.Background(), "m"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("ShowModel error = %v, want ErrUnsupported", err)
+	}
+	if err := c.DeleteModel(context.Background(), "m"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("DeleteModel error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestChain_ToolsUnsupportedIsNotRetried(t *testing.T) {
+	primary := &providertest.Fake{NativeTools: true, Replies: []providertest.Reply{{Err: provider.ErrToolsUnsupported}}}
+	backup := providertest.New(providertest.Text("backup"))
+	c := New(primary, Backend{Provider: backup})
+
+	if tc, ok := c.(provider.ToolCaller); !ok || !tc.CallsTools() {
+		t.Error("the chain should call tools like its primary provider")
+	}
+	if _, err := c.Chat(context.Background(), provider.ChatRequest{}); !errors.Is(err, provider.ErrToolsUnsupported) {
+		t.Errorf("Chat error = %v, want ErrToolsUnsupported", err)
+	}
+	if len(backup.Requests()) != 0 {
+		t.Error("the backup was asked; the caller retries without tools instead")
+	}
+}
+
+func TestChain_RetriesBeforeFallingBack(t *testing.T) {
+	unavailable := &provider.StatusError{Provider: "ollama", Code: 503, Body: "loading model"}
+	local := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: unavailable}, providertest.Text("local")}}
+	hosted := providertest.New(providertest.Text("hosted"))
+	c := NewRetrying(retry.Policy{Attempts: 2, Backoff: time.Millisecond}, local, Backend{Provider: hosted})
+
+	ch, err := c.StreamChat(context.Background(), provider.ChatRequest{})
+	if err != nil {
+		t.Fatalf("StreamChat: %v", err)
+	}
+	var got strings.Builder
+	for d := range ch {
+		got.WriteString(d.Content)
+	}
+	if got.String() != "local" {
+		t.Errorf("reply = %q, want the retried local one", got.String())
+	}
+	if len(hosted.Requests()) != 0 {
+		t.Error("fell back although the retry succeeded")
+	}
+}
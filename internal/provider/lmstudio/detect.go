@@ -0,0 +1,28 @@
+package lmstudio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Detect checks if LM Studio's server is running at the given base URL by
+// hitting /v1/models.
+func Detect(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/v1/models", nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("lm studio is not running at %s: %w", baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("lm studio returned status %d", resp.StatusCode)
+	}
+	return nil
+}
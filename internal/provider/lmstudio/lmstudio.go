@@ -0,0 +1,84 @@
+// Package lmstudio talks to the local server of LM Studio through its
+// OpenAI-compatible API (/v1/chat/completions, /v1/models, /v1/embeddings).
+package lmstudio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/openaicompat"
+)
+
+// DefaultBaseURL is where LM Studio's local server listens unless changed
+// in its Developer tab.
+const DefaultBaseURL = "http://127.0.0.1:1234"
+
+// LMStudioProvider implements the Provider interface for LM Studio.
+type LMStudioProvider struct {
+	baseURL string
+	api     *openaicompat.Client
+}
+
+func init() {
+	provider.Register("lmstudio", func(s provider.Settings) (provider.Provider, error) {
+		return New(s.BaseURL), nil
+	})
+}
+
+// New creates a new LMStudioProvider.
+func New(baseURL string) *LMStudioProvider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &LMStudioProvider{
+		baseURL: baseURL,
+		api:     &openaicompat.Client{Name: "lmstudio", BaseURL: baseURL + "/v1", HTTP: &http.Client{}},
+	}
+}
+
+func (l *LMStudioProvider) Name() string {
+	return "lmstudio"
+}
+
+// Chat sends a non-streaming chat request.
+func (l *LMStudioProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	return l.api.Chat(ctx, req)
+}
+
+// StreamChat sends a streaming chat request and returns a channel of deltas.
+func (l *LMStudioProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	return l.api.StreamChat(ctx, req)
+}
+
+// modelsResponse is the response from /v1/models.
+type modelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// ListModels returns the models LM Studio serves: the loaded ones, and with
+// just-in-time loading on (LM Studio's default) every downloaded one.
+func (l *LMStudioProvider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var modelsResp modelsResponse
+	if err := l.api.Get(ctx, "/models", &modelsResp); err != nil {
+		return nil, fmt.Errorf("listing models: %w", err)
+	}
+
+	models := make([]provider.ModelInfo, len(modelsResp.Data))
+	for i, m := range modelsResp.Data {
+		models[i] = provider.ModelInfo{Name: m.ID}
+	}
+	return models, nil
+}
+
+// Embed returns one embedding per text, computed by model.
+func (l *LMStudioProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	return l.api.Embed(ctx, model, texts)
+}
+
+// IsAvailable checks if LM Studio's server is running and reachable.
+func (l *LMStudioProvider) IsAvailable(ctx context.Context) error {
+	return Detect(ctx, l.baseURL)
+}
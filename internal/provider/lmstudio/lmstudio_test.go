@@ -0,0 +1,150 @@
+package lmstudio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/openaicompat"
+)
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("path = %s, want /v1/models", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen/qwen3-8b","object":"model"},{"id":"text-embedding-nomic-embed-text-v1.5","object":"model"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := New(srv.URL).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if len(models) != 2 || models[0].Name != "qwen/qwen3-8b" {
+		t.Errorf("models = %+v", models)
+	}
+}
+
+func TestChat(t *testing.T) {
+	temp := 0.2
+	var got openaicompat.ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"model":"qwen/qwen3-8b","choices":[{"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	resp, err := New(srv.URL).Chat(context.Background(), provider.ChatRequest{
+		Model:    "qwen/qwen3-8b",
+		Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+		NumCtx:   8192,
+		Options:  provider.Options{Temperature: &temp},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if resp.Message.Content != "Hi!" || resp.Message.Role != "assistant" {
+		t.Errorf("message = %+v", resp.Message)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 3 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if got.Stream || got.Temperature == nil || *got.Temperature != 0.2 || got.Model != "qwen/qwen3-8b" {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestChat_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Chat(context.Background(), provider.ChatRequest{Model: "nope"})
+	if err == nil {
+		t.Fatal("Chat() should fail on a non-200 status")
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	var got openaicompat.ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2,\"total_tokens\":9}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := New(srv.URL).StreamChat(context.Background(), provider.ChatRequest{
+		Model:    "qwen/qwen3-8b",
+		Messages: []provider.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+
+	var content string
+	var usage *provider.Usage
+	for d := range ch {
+		if d.Err != nil {
+			t.Fatalf("stream error: %v", d.Err)
+		}
+		content += d.Content
+		if d.Done {
+			usage = d.Usage
+		}
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q, want Hello", content)
+	}
+	if usage == nil || usage.PromptTokens != 7 || usage.CompletionTokens != 2 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if !got.Stream || got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+		t.Errorf("stream request should ask for usage, got %+v", got)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		// Out of order on purpose: the index decides
+		fmt.Fprint(w, `{"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}]}`)
+	}))
+	defer srv.Close()
+
+	got, err := New(srv.URL).Embed(context.Background(), "nomic", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if len(got) != 2 || got[0][0] != 0.1 || got[1][0] != 0.3 {
+		t.Errorf("embeddings = %v", got)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[]}`)
+	}))
+	if err := Detect(context.Background(), srv.URL); err != nil {
+		t.Errorf("Detect() error: %v", err)
+	}
+	srv.Close()
+	if err := Detect(context.Background(), srv.URL); err == nil {
+		t.Error("Detect() should fail once the server is gone")
+	}
+}
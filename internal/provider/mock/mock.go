@@ -0,0 +1,240 @@
+// Package mock replays canned replies from fixture files, streamed with
+// artificial delays, so stefanclaw can be demoed, captured in screenshots
+// and tested end to end without a model server.
+//
+// A fixture directory holds YAML files, read in name order, each a list of
+// fixtures:
+//
+//	# demo.yaml
+//	- match: weather        # text the last message contains, any case; empty matches any
+//	  reply: It's sunny and 22 °C in Berlin.
+//	- model: llama3.1:8b    # only answer requests for this model
+//	  reply: <think>The user greets me.</think>Hello!
+//	- match: fail
+//	  error: model crashed  # fail the request instead
+//
+// A request gets the first fixture that matches it. Files are read again
+// for every request, so edits apply at once.
+package mock
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// DefaultDelay is the pause between streamed chunks unless configured
+// otherwise, about the pace of a small local model.
+const DefaultDelay = 30 * time.Millisecond
+
+// builtin holds the fixtures used when no directory is configured.
+//
+//go:embed fixtures/*.yaml
+var builtin embed.FS
+
+// Fixture is one canned reply.
+type Fixture struct {
+	Match string `yaml:"match"` // text the last message contains, in any case; empty matches any
+	Model string `yaml:"model"` // the model the reply is for; empty for any
+	Reply string `yaml:"reply"`
+	Error string `yaml:"error"` // fails the request with this message instead
+}
+
+// MockProvider implements the Provider interface with fixtures.
+type MockProvider struct {
+	fixtures fs.FS
+	dir      string // for errors; empty for the built-in fixtures
+	delay    time.Duration
+}
+
+func init() {
+	provider.Register("mock", func(s provider.Settings) (provider.Provider, error) {
+		return New(s.Fixtures, s.Delay), nil
+	})
+}
+
+// New creates a MockProvider replaying the fixtures in dir, or the
+// built-in ones if dir is empty, waiting delay between streamed chunks. A
+// negative delay uses DefaultDelay.
+func New(dir string, delay time.Duration) *MockProvider {
+	if delay < 0 {
+		delay = DefaultDelay
+	}
+	m := &MockProvider{dir: dir, delay: delay}
+	if dir == "" {
+		m.fixtures, _ = fs.Sub(builtin, "fixtures")
+	} else {
+		m.fixtures = os.DirFS(dir)
+	}
+	return m
+}
+
+func (m *MockProvider) Name() string {
+	return "mock"
+}
+
+// load reads the fixtures.
+func (m *MockProvider) load() ([]Fixture, error) {
+	names, err := fs.Glob(m.fixtures, "*.yaml")
+	if err == nil && len(names) == 0 {
+		_, err = fs.Stat(m.fixtures, ".")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading fixtures: %w", err)
+	}
+	var all []Fixture
+	for _, name := range names {
+		data, err := fs.ReadFile(m.fixtures, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading fixtures: %w", err)
+		}
+		var fixtures []Fixture
+		if err := yaml.Unmarshal(data, &fixtures); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", path.Join(m.dir, name), err)
+		}
+		all = append(all, fixtures...)
+	}
+	return all, nil
+}
+
+// find returns the fixture to answer req with.
+func (m *MockProvider) find(req provider.ChatRequest) (Fixture, error) {
+	fixtures, err := m.load()
+	if err != nil {
+		return Fixture{}, err
+	}
+	var last string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			last = strings.ToLower(req.Messages[i].Content)
+			break
+		}
+	}
+	for _, f := range fixtures {
+		if (f.Model == "" || f.Model == req.Model) && strings.Contains(last, strings.ToLower(f.Match)) {
+			if f.Error != "" {
+				return f, errors.New("mock: " + f.Error)
+			}
+			return f, nil
+		}
+	}
+	return Fixture{}, fmt.Errorf("mock: no fixture matches %q for %s", last, req.Model)
+}
+
+// usage counts the tokens of a request and its reply like /prompt stats
+// does, so the numbers the mock reports line up with the rest of the UI.
+func usage(req provider.ChatRequest, reply string, elapsed time.Duration) provider.Usage {
+	var prompt int
+	for _, msg := range req.Messages {
+		prompt += tokens.Count(msg.Content)
+	}
+	u := provider.Usage{PromptTokens: prompt, CompletionTokens: tokens.Count(reply), EvalDuration: elapsed}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
+
+// Chat replies at once.
+func (m *MockProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	f, err := m.find(req)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.ChatResponse{
+		Message: provider.Message{Role: "assistant", Content: f.Reply},
+		Model:   req.Model,
+		Usage:   usage(req, f.Reply, 0),
+	}, nil
+}
+
+// StreamChat streams the reply word by word, waiting between the words.
+func (m *MockProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	f, err := m.find(req)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan provider.StreamDelta)
+	go func() {
+		defer close(ch)
+		start := time.Now()
+		for _, chunk := range chunks(f.Reply) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.delay):
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- provider.StreamDelta{Content: chunk}:
+			}
+		}
+		u := usage(req, f.Reply, time.Since(start))
+		select {
+		case <-ctx.Done():
+		case ch <- provider.StreamDelta{Done: true, Usage: &u}:
+		}
+	}()
+	return ch, nil
+}
+
+// chunks splits s into words, each with the whitespace after it.
+func chunks(s string) []string {
+	var out []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if isSpace(s[i-1]) && !isSpace(s[i]) {
+			out = append(out, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
+
+// ListModels returns the models the fixtures are for, or just "mock" if
+// they are for any.
+func (m *MockProvider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	fixtures, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range fixtures {
+		if f.Model != "" && !slices.Contains(names, f.Model) {
+			names = append(names, f.Model)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{"mock"}
+	}
+	slices.Sort(names)
+	models := make([]provider.ModelInfo, len(names))
+	for i, name := range names {
+		models[i] = provider.ModelInfo{Name: name}
+	}
+	return models, nil
+}
+
+// IsAvailable checks that the fixtures can be read.
+func (m *MockProvider) IsAvailable(ctx context.Context) error {
+	_, err := m.load()
+	return err
+}
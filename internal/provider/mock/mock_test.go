@@ -0,0 +1,139 @@
+package mock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+func writeFixtures(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func ask(model, content string) provider.ChatRequest {
+	return provider.ChatRequest{Model: model, Messages: []provider.Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: content},
+	}}
+}
+
+func TestChat(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"a.yaml": `
+- match: Weather
+  reply: Sunny.
+- model: llama3.1:8b
+  reply: Llama here.
+- match: crash
+  error: out of memory
+`,
+		"b.yaml": `
+- reply: Anything else.
+`,
+	})
+	m := New(dir, 0)
+	for _, tt := range []struct{ model, content, want string }{
+		{"qwen3:8b", "How's the weather?", "Sunny."},
+		{"llama3.1:8b", "Hi", "Llama here."},
+		{"qwen3:8b", "Hi", "Anything else."},
+	} {
+		resp, err := m.Chat(context.Background(), ask(tt.model, tt.content))
+		if err != nil || resp.Message.Content != tt.want {
+			t.Errorf("Chat(%s, %q) = %+v, %v, want %q", tt.model, tt.content, resp, err, tt.want)
+		}
+	}
+	if _, err := m.Chat(context.Background(), ask("qwen3:8b", "crash now")); err == nil || !strings.Contains(err.Error(), "out of memory") {
+		t.Errorf("Chat() = %v, want the fixture's error", err)
+	}
+
+	models, err := m.ListModels(context.Background())
+	if err != nil || len(models) != 1 || models[0].Name != "llama3.1:8b" {
+		t.Errorf("ListModels() = %+v, %v", models, err)
+	}
+}
+
+func TestChat_NoMatch(t *testing.T) {
+	m := New(writeFixtures(t, map[string]string{"a.yaml": "- match: weather\n  reply: Sunny.\n"}), 0)
+	if _, err := m.Chat(context.Background(), ask("qwen3:8b", "Hi")); err == nil || !strings.Contains(err.Error(), `no fixture matches "hi"`) {
+		t.Errorf("Chat() = %v", err)
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	m := New(writeFixtures(t, map[string]string{"a.yaml": "- reply: \"Hello there,\\n  friend.\"\n"}), time.Millisecond)
+	ch, err := m.StreamChat(context.Background(), ask("qwen3:8b", "Hi"))
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var got []string
+	var usage *provider.Usage
+	for d := range ch {
+		if d.Done {
+			usage = d.Usage
+			continue
+		}
+		got = append(got, d.Content)
+	}
+	if want := []string{"Hello ", "there,\n  ", "friend."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %q, want %q", got, want)
+	}
+	if usage == nil || usage.CompletionTokens == 0 || usage.EvalDuration < 3*time.Millisecond {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if want := tokens.Count("Hello there,\n  friend."); usage.CompletionTokens != want {
+		t.Errorf("completion tokens = %d, want %d", usage.CompletionTokens, want)
+	}
+	if want := tokens.Count("You are helpful.") + tokens.Count("Hi"); usage.PromptTokens != want {
+		t.Errorf("prompt tokens = %d, want %d", usage.PromptTokens, want)
+	}
+}
+
+func TestStreamChat_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := New("", time.Hour).StreamChat(ctx, ask("qwen3:8b", "Hi"))
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	cancel()
+	for d := range ch {
+		t.Errorf("got %+v after cancelling", d)
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	m := New("", 0)
+	if err := m.IsAvailable(context.Background()); err != nil {
+		t.Fatalf("IsAvailable() = %v", err)
+	}
+	resp, err := m.Chat(context.Background(), ask("qwen3:8b", "Show me some code"))
+	if err != nil || !strings.Contains(resp.Message.Content, "```go") {
+		t.Errorf("Chat() = %+v, %v", resp, err)
+	}
+	if _, err := m.Chat(context.Background(), ask("qwen3:8b", "Something no fixture mentions")); err != nil {
+		t.Errorf("the built-in fixtures don't answer everything: %v", err)
+	}
+}
+
+func TestIsAvailable_BadFixtures(t *testing.T) {
+	if err := New(filepath.Join(t.TempDir(), "missing"), 0).IsAvailable(context.Background()); err == nil {
+		t.Error("IsAvailable() with a missing directory succeeded")
+	}
+	dir := writeFixtures(t, map[string]string{"a.yaml": "reply: not a list\n"})
+	if err := New(dir, 0).IsAvailable(context.Background()); err == nil || !strings.Contains(err.Error(), "a.yaml") {
+		t.Errorf("IsAvailable() = %v, want the broken file named", err)
+	}
+}
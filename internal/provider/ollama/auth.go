@@ -0,0 +1,124 @@
+package ollama
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// NewWithSettings creates an OllamaProvider for a server at s.BaseURL that
+// may sit behind a reverse proxy such as Caddy or Traefik: s.APIKey is sent
+// as a bearer token, s.Username and s.Password as basic auth, s.Headers as
+// they are, and s.TLS says which certificates to accept. s.KeepAlive, if
+// set, is how long Ollama keeps a model loaded after a request.
+func NewWithSettings(s provider.Settings) (*OllamaProvider, error) {
+	o := New(s.BaseURL)
+	if s.KeepAlive != 0 {
+		o.keepAlive = s.KeepAlive.String()
+	}
+	if s.APIKey == "" && s.Username == "" && len(s.Headers) == 0 && s.TLS == (provider.TLS{}) {
+		return o, nil
+	}
+	base, err := transport(s.TLS)
+	if err != nil {
+		return nil, err
+	}
+	resolve := s.Resolve
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	o.client.Transport = &authTransport{base: base, settings: s, resolve: resolve}
+	return o, nil
+}
+
+// transport returns the transport for t: the default one, or a copy
+// trusting the certificates in t.CAFile or any at all.
+func transport(t provider.TLS) (http.RoundTripper, error) {
+	if t == (provider.TLS{}) {
+		return http.DefaultTransport, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ollama ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ollama ca_file: no PEM certificates in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = cfg
+	return tr, nil
+}
+
+// authTransport adds the credentials and headers to every request. Secret
+// references among them are looked up on first use.
+type authTransport struct {
+	base     http.RoundTripper
+	settings provider.Settings
+	resolve  func(string) (string, error)
+
+	once    sync.Once
+	headers http.Header
+	err     error
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { t.headers, t.err = t.resolveHeaders() })
+	if t.err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, t.err
+	}
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req)
+}
+
+// resolveHeaders builds the headers sent with every request.
+func (t *authTransport) resolveHeaders() (http.Header, error) {
+	s := t.settings
+	h := http.Header{}
+	for k, v := range s.Headers {
+		value, err := t.resolve(v)
+		if err != nil {
+			return nil, fmt.Errorf("ollama header %s: %w", k, err)
+		}
+		h.Set(k, strings.TrimSpace(value))
+	}
+	switch {
+	case s.APIKey != "":
+		key, err := t.resolve(s.APIKey)
+		if err == nil && strings.TrimSpace(key) == "" {
+			err = errors.New("empty")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ollama api key: %w", err)
+		}
+		h.Set("Authorization", "Bearer "+strings.TrimSpace(key))
+	case s.Username != "":
+		password, err := t.resolve(s.Password)
+		if err != nil {
+			return nil, fmt.Errorf("ollama password: %w", err)
+		}
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(s.Username+":"+password)))
+	}
+	return h, nil
+}
@@ -0,0 +1,113 @@
+package ollama
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// headerServer answers /api/tags and records the headers it got.
+func headerServer(t *testing.T, tls bool) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"models":[]}`))
+	})
+	srv := httptest.NewUnstartedServer(h)
+	if tls {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestNewWithSettings_Auth(t *testing.T) {
+	srv, got := headerServer(t, false)
+	secrets := map[string]string{"keyring:ollama": "s3cret", "keyring:cf": "cf-id"}
+	resolve := func(v string) (string, error) {
+		if s, ok := secrets[v]; ok {
+			return s, nil
+		}
+		return v, nil
+	}
+
+	tests := []struct {
+		name     string
+		settings provider.Settings
+		header   string
+		want     string
+	}{
+		{"bearer", provider.Settings{APIKey: "keyring:ollama"}, "Authorization", "Bearer s3cret"},
+		{"basic", provider.Settings{Username: "me", Password: "keyring:ollama"}, "Authorization", "Basic bWU6czNjcmV0"},
+		{"header", provider.Settings{Headers: map[string]string{"CF-Access-Client-Id": "keyring:cf"}}, "Cf-Access-Client-Id", "cf-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.settings
+			s.BaseURL, s.Resolve = srv.URL, resolve
+			o, err := NewWithSettings(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.IsAvailable(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if v := got.Get(tt.header); v != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithSettings_SecretError(t *testing.T) {
+	srv, _ := headerServer(t, false)
+	o, err := NewWithSettings(provider.Settings{
+		BaseURL: srv.URL,
+		APIKey:  "keyring:missing",
+		Resolve: func(string) (string, error) { return "", errors.New("not in keyring") },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.IsAvailable(context.Background()); err == nil || !strings.Contains(err.Error(), "ollama api key") {
+		t.Errorf("IsAvailable = %v, want the api key error", err)
+	}
+}
+
+func TestNewWithSettings_TLS(t *testing.T) {
+	srv, _ := headerServer(t, true)
+
+	if err := New(srv.URL).IsAvailable(context.Background()); err == nil {
+		t.Fatal("trusted a self-signed certificate by default")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tls := range []provider.TLS{{CAFile: caFile}, {InsecureSkipVerify: true}} {
+		o, err := NewWithSettings(provider.Settings{BaseURL: srv.URL, TLS: tls})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.IsAvailable(context.Background()); err != nil {
+			t.Errorf("with %+v: %v", tls, err)
+		}
+	}
+
+	if _, err := NewWithSettings(provider.Settings{BaseURL: srv.URL, TLS: provider.TLS{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}); err == nil {
+		t.Error("accepted a missing ca_file")
+	}
+}
@@ -0,0 +1,35 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DeleteModel removes model from the Ollama server, freeing its disk space.
+func (o *OllamaProvider) DeleteModel(ctx context.Context, model string) error {
+	data, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, o.baseURL+"/api/delete", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeleteModel(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Method != http.MethodDelete || r.URL.Path != "/api/delete" || req.Model != "qwen3:8b" {
+			http.Error(w, `{"error":"model 'missing' not found"}`, http.StatusNotFound)
+			return
+		}
+		deleted = append(deleted, req.Model)
+	}))
+	defer srv.Close()
+
+	if err := New(srv.URL).DeleteModel(context.Background(), "qwen3:8b"); err != nil {
+		t.Fatalf("DeleteModel: %v", err)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("deleted = %v", deleted)
+	}
+	if err := New(srv.URL).DeleteModel(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
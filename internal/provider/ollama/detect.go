@@ -8,12 +8,16 @@ import (
 
 // Detect checks if Ollama is running at the given base URL by hitting /api/tags.
 func Detect(ctx context.Context, baseURL string) error {
+	return detect(ctx, http.DefaultClient, baseURL)
+}
+
+func detect(ctx context.Context, client *http.Client, baseURL string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/tags", nil)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("ollama is not running at %s: %w", baseURL, err)
 	}
@@ -0,0 +1,44 @@
+package ollama
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// local reports whether the Ollama server runs on this machine, so that its
+// models are on a disk we can look at.
+func (o *OllamaProvider) local() bool {
+	u, err := url.Parse(o.baseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// modelsDir returns the directory Ollama keeps its models in: the one set
+// with OLLAMA_MODELS, else the first default that exists. It returns "" if
+// there is none.
+func modelsDir() string {
+	if dir := os.Getenv("OLLAMA_MODELS"); dir != "" {
+		return dir
+	}
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".ollama", "models"))
+	}
+	// Where the Linux install script's service keeps them
+	dirs = append(dirs, "/usr/share/ollama/.ollama/models")
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package ollama
+
+import "syscall"
+
+// diskFree returns the space available to unprivileged users on the disk
+// holding dir, or 0 if it can't tell.
+func diskFree(dir string) uint64 {
+	if dir == "" {
+		return 0
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize)
+}
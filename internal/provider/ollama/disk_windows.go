@@ -0,0 +1,27 @@
+//go:build windows
+
+package ollama
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// diskFree returns the space available to the current user on the disk
+// holding dir, or 0 if it can't tell.
+func diskFree(dir string) uint64 {
+	if dir == "" {
+		return 0
+	}
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0
+	}
+	var free uint64
+	if r, _, _ := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&free)), 0, 0); r == 0 {
+		return 0
+	}
+	return free
+}
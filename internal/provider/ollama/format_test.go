@@ -0,0 +1,44 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestChat_Format(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"{\"ok\":true}"},"done":true}`))
+	}))
+	defer srv.Close()
+
+	schema := json.RawMessage(`{"type":"object","properties":{"ok":{"type":"boolean"}}}`)
+	tests := []struct {
+		format *provider.Format
+		want   string
+	}{
+		{nil, `null`},
+		{&provider.Format{}, `"json"`},
+		{&provider.Format{Schema: schema}, `{"properties":{"ok":{"type":"boolean"}},"type":"object"}`},
+	}
+	for _, tt := range tests {
+		_, err := New(srv.URL).Chat(context.Background(), provider.ChatRequest{
+			Model:    "qwen3:8b",
+			Messages: []provider.Message{{Role: "user", Content: "ok?"}},
+			Format:   tt.format,
+		})
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		if b, _ := json.Marshal(got["format"]); string(b) != tt.want {
+			t.Errorf("format %+v sent as %s", tt.format, b)
+		}
+	}
+}
@@ -8,14 +8,27 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/stefanclaw/stefanclaw/internal/log"
 	"github.com/stefanclaw/stefanclaw/internal/provider"
 )
 
 // OllamaProvider implements the Provider interface for Ollama.
 type OllamaProvider struct {
-	baseURL string
-	client  *http.Client
+	baseURL   string
+	client    *http.Client
+	keepAlive string // sent as keep_alive; empty leaves it to the server
+}
+
+func init() {
+	provider.Register("ollama", func(s provider.Settings) (provider.Provider, error) {
+		o, err := NewWithSettings(s)
+		if err != nil {
+			return nil, err
+		}
+		return o, nil
+	})
 }
 
 // New creates a new OllamaProvider.
@@ -30,17 +43,70 @@ func (o *OllamaProvider) Name() string {
 	return "ollama"
 }
 
+// CallsTools reports that Ollama supports native tool calling.
+func (o *OllamaProvider) CallsTools() bool {
+	return true
+}
+
 // ollamaChatRequest is the Ollama API chat request format.
 type ollamaChatRequest struct {
-	Model    string             `json:"model"`
-	Messages []provider.Message `json:"messages"`
-	Stream   bool               `json:"stream"`
-	Options  *ollamaOptions     `json:"options,omitempty"`
+	Model     string             `json:"model"`
+	Messages  []provider.Message `json:"messages"`
+	Stream    bool               `json:"stream"`
+	Options   *ollamaOptions     `json:"options,omitempty"`
+	Tools     []ollamaTool       `json:"tools,omitempty"`
+	Format    json.RawMessage    `json:"format,omitempty"` // "json" or a JSON schema
+	KeepAlive string             `json:"keep_alive,omitempty"`
+}
+
+// ollamaTool is a function the model may call.
+type ollamaTool struct {
+	Type     string        `json:"type"` // always "function"
+	Function provider.Tool `json:"function"`
+}
+
+// requestFormat converts the format of req to Ollama's: "json" for any
+// JSON, or the schema itself.
+func requestFormat(req provider.ChatRequest) json.RawMessage {
+	switch {
+	case req.Format == nil:
+		return nil
+	case len(req.Format.Schema) == 0:
+		return json.RawMessage(`"json"`)
+	}
+	return req.Format.Schema
+}
+
+// requestTools converts the tools of req to Ollama's format.
+func requestTools(req provider.ChatRequest) []ollamaTool {
+	var tools []ollamaTool
+	for _, t := range req.Tools {
+		tools = append(tools, ollamaTool{Type: "function", Function: t})
+	}
+	return tools
+}
+
+// statusError describes a failed chat request. Models without tool
+// support are reported as provider.ErrToolsUnsupported.
+func statusError(req provider.ChatRequest, status int, body []byte) error {
+	if status == http.StatusBadRequest && len(req.Tools) > 0 && bytes.Contains(body, []byte("does not support tools")) {
+		return fmt.Errorf("%s: %w", req.Model, provider.ErrToolsUnsupported)
+	}
+	return &provider.StatusError{Provider: "ollama", Code: status, Body: string(body)}
 }
 
 // ollamaOptions holds Ollama-specific request options.
 type ollamaOptions struct {
 	NumCtx int `json:"num_ctx,omitempty"`
+	provider.Options
+}
+
+// requestOptions builds the options object for req, or nil if nothing is set.
+func requestOptions(req provider.ChatRequest) *ollamaOptions {
+	if req.NumCtx <= 0 && req.Options.IsZero() {
+		return nil
+	}
+	return &ollamaOptions{NumCtx: req.NumCtx, Options: req.Options}
 }
 
 // ollamaChatResponse is a single response/chunk from Ollama's /api/chat.
@@ -51,6 +117,21 @@ type ollamaChatResponse struct {
 	TotalDuration      int64            `json:"total_duration"`
 	PromptEvalCount    int              `json:"prompt_eval_count"`
 	EvalCount          int              `json:"eval_count"`
+	LoadDuration       int64            `json:"load_duration"`
+	PromptEvalDuration int64            `json:"prompt_eval_duration"`
+	EvalDuration       int64            `json:"eval_duration"`
+}
+
+// usage returns the token counts and timings of a final response.
+func (r ollamaChatResponse) usage() provider.Usage {
+	return provider.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		LoadDuration:     time.Duration(r.LoadDuration),
+		PromptDuration:   time.Duration(r.PromptEvalDuration),
+		EvalDuration:     time.Duration(r.EvalDuration),
+	}
 }
 
 // ollamaModelsResponse is the response from /api/tags.
@@ -66,13 +147,14 @@ type ollamaModel struct {
 // Chat sends a non-streaming chat request.
 func (o *OllamaProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
 	body := ollamaChatRequest{
-		Model:    req.Model,
-		Messages: req.Messages,
-		Stream:   false,
-	}
-	if req.NumCtx > 0 {
-		body.Options = &ollamaOptions{NumCtx: req.NumCtx}
+		Model:     req.Model,
+		Messages:  req.Messages,
+		Stream:    false,
+		Tools:     requestTools(req),
+		Format:    requestFormat(req),
+		KeepAlive: o.keepAlive,
 	}
+	body.Options = requestOptions(req)
 
 	data, err := json.Marshal(body)
 	if err != nil {
@@ -85,43 +167,55 @@ func (o *OllamaProvider) Chat(ctx context.Context, req provider.ChatRequest) (*p
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
+	log.Traffic("ollama request /api/chat", data)
+	log.Debug("ollama chat request", "model", req.Model, "messages", len(req.Messages))
 	resp, err := o.client.Do(httpReq)
 	if err != nil {
+		log.Warn("ollama chat request failed", "model", req.Model, "err", err)
 		return nil, fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+		log.Warn("ollama chat request failed", "model", req.Model, "status", resp.StatusCode, "body", string(respBody))
+		return nil, statusError(req, resp.StatusCode, respBody)
 	}
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Warn("ollama chat response unreadable", "model", req.Model, "err", err)
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	log.Traffic("ollama response /api/chat", respBody)
+
 	var ollamaResp ollamaChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+	if err := json.Unmarshal(respBody, &ollamaResp); err != nil {
+		log.Warn("ollama chat response unreadable", "model", req.Model, "err", err)
 		return nil, fmt.Errorf("decoding response: %w", err)
 	}
+	log.Info("ollama chat done", "model", req.Model, "duration", time.Since(start),
+		"prompt_tokens", ollamaResp.PromptEvalCount, "completion_tokens", ollamaResp.EvalCount)
 
 	return &provider.ChatResponse{
 		Message: ollamaResp.Message,
 		Model:   ollamaResp.Model,
-		Usage: provider.Usage{
-			PromptTokens:     ollamaResp.PromptEvalCount,
-			CompletionTokens: ollamaResp.EvalCount,
-			TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
-		},
+		Usage:   ollamaResp.usage(),
 	}, nil
 }
 
 // StreamChat sends a streaming chat request and returns a channel of deltas.
 func (o *OllamaProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
 	body := ollamaChatRequest{
-		Model:    req.Model,
-		Messages: req.Messages,
-		Stream:   true,
-	}
-	if req.NumCtx > 0 {
-		body.Options = &ollamaOptions{NumCtx: req.NumCtx}
+		Model:     req.Model,
+		Messages:  req.Messages,
+		Stream:    true,
+		Tools:     requestTools(req),
+		Format:    requestFormat(req),
+		KeepAlive: o.keepAlive,
 	}
+	body.Options = requestOptions(req)
 
 	data, err := json.Marshal(body)
 	if err != nil {
@@ -134,15 +228,20 @@ func (o *OllamaProvider) StreamChat(ctx context.Context, req provider.ChatReques
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
+	log.Traffic("ollama request /api/chat", data)
+	log.Debug("ollama stream request", "model", req.Model, "messages", len(req.Messages))
 	resp, err := o.client.Do(httpReq)
 	if err != nil {
+		log.Warn("ollama stream request failed", "model", req.Model, "err", err)
 		return nil, fmt.Errorf("sending request: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+		log.Warn("ollama stream request failed", "model", req.Model, "status", resp.StatusCode, "body", string(respBody))
+		return nil, statusError(req, resp.StatusCode, respBody)
 	}
 
 	ch := make(chan provider.StreamDelta)
@@ -150,43 +249,51 @@ func (o *OllamaProvider) StreamChat(ctx context.Context, req provider.ChatReques
 		defer close(ch)
 		defer resp.Body.Close()
 
+		log.Debug("ollama stream started", "model", req.Model, "wait", time.Since(start))
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			if len(line) == 0 {
 				continue
 			}
+			log.Traffic("ollama response /api/chat", line)
 
 			var chunk ollamaChatResponse
 			if err := json.Unmarshal(line, &chunk); err != nil {
+				log.Warn("ollama stream chunk unreadable", "model", req.Model, "err", err)
 				ch <- provider.StreamDelta{Err: fmt.Errorf("decoding chunk: %w", err)}
 				return
 			}
 
 			if chunk.Done {
+				usage := chunk.usage()
+				log.Info("ollama stream done", "model", req.Model, "duration", time.Since(start),
+					"prompt_tokens", chunk.PromptEvalCount, "completion_tokens", chunk.EvalCount)
 				ch <- provider.StreamDelta{
-					Done: true,
-					Usage: &provider.Usage{
-						PromptTokens:     chunk.PromptEvalCount,
-						CompletionTokens: chunk.EvalCount,
-						TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
-					},
+					Done:  true,
+					Usage: &usage,
 				}
 				return
 			}
 
 			ch <- provider.StreamDelta{
-				Content: chunk.Message.Content,
+				Content:   chunk.Message.Content,
+				ToolCalls: chunk.Message.ToolCalls,
 			}
 		}
 
-		if err := scanner.Err(); err != nil {
-			select {
-			case <-ctx.Done():
-				// Context cancelled, don't send error
-			default:
-				ch <- provider.StreamDelta{Err: fmt.Errorf("reading stream: %w", err)}
-			}
+		err := scanner.Err()
+		if err == nil {
+			// The connection was closed before the done chunk
+			err = io.ErrUnexpectedEOF
+		}
+		select {
+		case <-ctx.Done():
+			// Context cancelled, don't send error
+			log.Debug("ollama stream cancelled", "model", req.Model, "duration", time.Since(start))
+		default:
+			log.Warn("ollama stream broken off", "model", req.Model, "duration", time.Since(start), "err", err)
+			ch <- provider.StreamDelta{Err: fmt.Errorf("reading stream: %w", err)}
 		}
 	}()
 
@@ -210,8 +317,14 @@ func (o *OllamaProvider) ListModels(ctx context.Context) ([]provider.ModelInfo,
 		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading models: %w", err)
+	}
+	log.Traffic("ollama response /api/tags", respBody)
+
 	var modelsResp ollamaModelsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+	if err := json.Unmarshal(respBody, &modelsResp); err != nil {
 		return nil, fmt.Errorf("decoding models: %w", err)
 	}
 
@@ -225,7 +338,52 @@ func (o *OllamaProvider) ListModels(ctx context.Context) ([]provider.ModelInfo,
 	return models, nil
 }
 
+// ollamaEmbedRequest is the request body for /api/embed.
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// Embed returns one embedding per text, computed by model.
+func (o *OllamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	data, err := json.Marshal(ollamaEmbedRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/embed", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Only the request is traced: the embeddings in the response are long
+	// and tell little
+	log.Traffic("ollama request /api/embed", data)
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var embedResp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+	return embedResp.Embeddings, nil
+}
+
 // IsAvailable checks if Ollama is running and reachable.
 func (o *OllamaProvider) IsAvailable(ctx context.Context) error {
-	return Detect(ctx, o.baseURL)
+	return detect(ctx, o.client, o.baseURL)
 }
@@ -3,7 +3,9 @@ package ollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -65,9 +67,9 @@ func TestChat_SimpleResponse(t *testing.T) {
 		}
 
 		json.NewEncoder(w).Encode(ollamaChatResponse{
-			Model:   "qwen3-next",
-			Message: provider.Message{Role: "assistant", Content: "Hello!"},
-			Done:    true,
+			Model:           "qwen3-next",
+			Message:         provider.Message{Role: "assistant", Content: "Hello!"},
+			Done:            true,
 			PromptEvalCount: 10,
 			EvalCount:       5,
 		})
@@ -210,6 +212,44 @@ func TestChat_NumCtxOmittedWhenZero(t *testing.T) {
 	}
 }
 
+func TestChat_SendsSamplingOptions(t *testing.T) {
+	var receivedBody map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Model:   "qwen3:8b",
+			Message: provider.Message{Role: "assistant", Content: "ok"},
+			Done:    true,
+		})
+	}))
+	defer srv.Close()
+
+	var opts provider.Options
+	opts.Set("temperature", "0")
+	opts.Set("seed", "42")
+
+	p := New(srv.URL)
+	p.Chat(context.Background(), provider.ChatRequest{
+		Model:    "qwen3:8b",
+		Messages: []provider.Message{{Role: "user", Content: "Hi"}},
+		Options:  opts,
+	})
+
+	got, ok := receivedBody["options"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected options field in request body")
+	}
+	if got["temperature"] != 0.0 || got["seed"] != 42.0 {
+		t.Errorf("options = %v, want temperature 0 and seed 42", got)
+	}
+	if _, ok := got["top_p"]; ok {
+		t.Error("unset top_p should be omitted")
+	}
+	if _, ok := got["num_ctx"]; ok {
+		t.Error("num_ctx should be omitted when zero")
+	}
+}
+
 func TestStreamChat_TokenByToken(t *testing.T) {
 	tokens := []string{"Hello", " ", "world", "!"}
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -383,3 +423,52 @@ func TestStreamChat_EmptyResponse(t *testing.T) {
 		t.Logf("got %d deltas from empty response (acceptable if 0)", count)
 	}
 }
+
+func TestStreamChat_ClosedBeforeDone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+	}))
+	defer srv.Close()
+
+	ch, err := New(srv.URL).StreamChat(context.Background(), provider.ChatRequest{
+		Model:    "qwen3-next",
+		Messages: []provider.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var last provider.StreamDelta
+	for delta := range ch {
+		last = delta
+	}
+	if !errors.Is(last.Err, io.ErrUnexpectedEOF) {
+		t.Errorf("last delta = %+v, want an unexpected EOF", last)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var got ollamaEmbedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]]}`))
+	}))
+	defer srv.Close()
+
+	vecs, err := New(srv.URL).Embed(context.Background(), "nomic-embed-text", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if got.Model != "nomic-embed-text" || len(got.Input) != 2 {
+		t.Errorf("request = %+v", got)
+	}
+	if len(vecs) != 2 || vecs[1][0] != 0.3 {
+		t.Errorf("Embed() = %v", vecs)
+	}
+
+	if _, err := New(srv.URL).Embed(context.Background(), "nomic-embed-text", []string{"a"}); err == nil {
+		t.Error("a count mismatch should fail")
+	}
+}
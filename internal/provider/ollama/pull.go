@@ -0,0 +1,87 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// pullStatus is one line of the progress /api/pull streams.
+type pullStatus struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+// Pull downloads model from the Ollama library, calling progress for each
+// update. It returns once the model is installed.
+func (o *OllamaProvider) Pull(ctx context.Context, model string, progress func(provider.PullProgress)) error {
+	data, err := json.Marshal(map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/pull", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	log.Traffic("ollama request /api/pull", data)
+	log.Info("ollama pull started", "model", model)
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	last := ""
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var st pullStatus
+		if err := json.Unmarshal(line, &st); err != nil {
+			return fmt.Errorf("decoding progress: %w", err)
+		}
+		if st.Error != "" {
+			log.Warn("ollama pull failed", "model", model, "err", st.Error)
+			return fmt.Errorf("pulling %s: %s", model, st.Error)
+		}
+		// Byte counts change with every line; only status changes are
+		// worth tracing
+		if st.Status != last {
+			log.Traffic("ollama response /api/pull", line)
+			last = st.Status
+		}
+		if progress != nil {
+			progress(provider.PullProgress{Status: st.Status, Completed: st.Completed, Total: st.Total})
+		}
+		if st.Status == "success" {
+			log.Info("ollama pull done", "model", model, "duration", time.Since(start))
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading progress: %w", err)
+	}
+	return errors.New("ollama ended the download without reporting success")
+}
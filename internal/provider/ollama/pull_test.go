@@ -0,0 +1,62 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestPull(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/pull" {
+			http.NotFound(w, r)
+			return
+		}
+		if req.Model != "qwen3:8b" {
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"error":"pull model manifest: file does not exist"}` + "\n"))
+			return
+		}
+		w.Write([]byte(strings.Join([]string{
+			`{"status":"pulling manifest"}`,
+			`{"status":"pulling a3de86cd1c13","digest":"sha256:a3de86cd1c13","total":5200000000,"completed":1300000000}`,
+			`{"status":"pulling a3de86cd1c13","digest":"sha256:a3de86cd1c13","total":5200000000,"completed":5200000000}`,
+			`{"status":"verifying sha256 digest"}`,
+			`{"status":"success"}`,
+		}, "\n") + "\n"))
+	}))
+	defer srv.Close()
+
+	var got []provider.PullProgress
+	err := New(srv.URL).Pull(context.Background(), "qwen3:8b", func(p provider.PullProgress) {
+		got = append(got, p)
+	})
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if len(got) != 5 || got[1].Completed != 1300000000 || got[1].Total != 5200000000 || got[4].Status != "success" {
+		t.Errorf("progress = %+v", got)
+	}
+
+	err = New(srv.URL).Pull(context.Background(), "nosuchmodel", nil)
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("Pull(nosuchmodel) error = %v", err)
+	}
+}
+
+func TestPull_Incomplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"pulling manifest"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	if err := New(srv.URL).Pull(context.Background(), "qwen3:8b", nil); err == nil {
+		t.Error("Pull succeeded without Ollama reporting success")
+	}
+}
@@ -0,0 +1,71 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ShowModel returns the details of model, along with the disk space left
+// for models if Ollama runs on this machine.
+func (o *OllamaProvider) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	data, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/show", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Only the request is traced: the response carries the whole template,
+	// license and parameters of the model
+	log.Traffic("ollama request /api/show", data)
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var showResp struct {
+		Details struct {
+			Family            string `json:"family"`
+			ParameterSize     string `json:"parameter_size"`
+			QuantizationLevel string `json:"quantization_level"`
+		} `json:"details"`
+		ModelInfo    map[string]any `json:"model_info"`
+		Capabilities []string       `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&showResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	d := &provider.ModelDetails{
+		Family:        showResp.Details.Family,
+		ParameterSize: showResp.Details.ParameterSize,
+		Quantization:  showResp.Details.QuantizationLevel,
+		Capabilities:  showResp.Capabilities,
+	}
+	// The context length is keyed by architecture, e.g. "qwen3.context_length"
+	for key, v := range showResp.ModelInfo {
+		if n, ok := v.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			d.ContextLength = int(n)
+		}
+	}
+	if o.local() {
+		d.DiskFree = diskFree(modelsDir())
+	}
+	return d, nil
+}
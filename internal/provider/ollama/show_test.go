@@ -0,0 +1,63 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestShowModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/show" || req.Model != "qwen3:8b" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+			"details": {"family": "qwen3", "parameter_size": "8.2B", "quantization_level": "Q4_K_M"},
+			"model_info": {"general.architecture": "qwen3", "qwen3.context_length": 40960},
+			"capabilities": ["completion", "tools", "thinking"]
+		}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+	d, err := New(srv.URL).ShowModel(context.Background(), "qwen3:8b")
+	if err != nil {
+		t.Fatalf("ShowModel: %v", err)
+	}
+	if d.Family != "qwen3" || d.ContextLength != 40960 {
+		t.Errorf("details = %+v", d)
+	}
+	if got, want := d.String(), "8.2B parameters, Q4_K_M, 40960-token context"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !d.Supports("tools") || d.Supports("vision") {
+		t.Errorf("capabilities = %v", d.Capabilities)
+	}
+
+	if d.DiskFree == 0 {
+		t.Error("DiskFree = 0 for a server on this machine")
+	}
+
+	if _, err := New(srv.URL).ShowModel(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	for url, want := range map[string]bool{
+		"http://localhost:11434":     true,
+		"http://127.0.0.1:11434":     true,
+		"http://[::1]:11434":         true,
+		"http://gpu-box.lan:11434":   false,
+		"https://ollama.example.com": false,
+	} {
+		if got := New(url).local(); got != want {
+			t.Errorf("local(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestStreamChat_ToolCalls(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"web_search","arguments":{"query":"go 1.25"}}}]},"done":false}` + "\n"))
+		w.Write([]byte(`{"done":true,"eval_count":3}` + "\n"))
+	}))
+	defer srv.Close()
+
+	ch, err := New(srv.URL).StreamChat(context.Background(), provider.ChatRequest{
+		Model: "qwen3:8b",
+		Messages: []provider.Message{
+			{Role: "assistant", ToolCalls: []provider.ToolCall{{Name: "time", Arguments: map[string]any{}}}},
+			{Role: "tool", Content: "12:00", ToolName: "time"},
+		},
+		Tools: []provider.Tool{{Name: "web_search", Description: "Search the web.", Parameters: map[string]any{"type": "object"}}},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat: %v", err)
+	}
+	var calls []provider.ToolCall
+	for d := range ch {
+		calls = append(calls, d.ToolCalls...)
+	}
+	if len(calls) != 1 || calls[0].Name != "web_search" || calls[0].Arguments["query"] != "go 1.25" {
+		t.Errorf("calls = %+v", calls)
+	}
+
+	tools := got["tools"].([]any)
+	tool := tools[0].(map[string]any)
+	if tool["type"] != "function" || tool["function"].(map[string]any)["name"] != "web_search" {
+		t.Errorf("tools = %v", tools)
+	}
+	msgs := got["messages"].([]any)
+	call := msgs[0].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)
+	if call["function"].(map[string]any)["name"] != "time" {
+		t.Errorf("tool call sent as %v", call)
+	}
+	if msgs[1].(map[string]any)["tool_name"] != "time" {
+		t.Errorf("tool result sent as %v", msgs[1])
+	}
+}
+
+func TestChat_ToolsUnsupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"registry.ollama.ai/library/gemma3:4b does not support tools"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	req := provider.ChatRequest{Model: "gemma3:4b", Tools: []provider.Tool{{Name: "time"}}}
+	if _, err := New(srv.URL).Chat(context.Background(), req); !errors.Is(err, provider.ErrToolsUnsupported) {
+		t.Errorf("Chat error = %v, want ErrToolsUnsupported", err)
+	}
+	if _, err := New(srv.URL).StreamChat(context.Background(), req); !errors.Is(err, provider.ErrToolsUnsupported) {
+		t.Errorf("StreamChat error = %v, want ErrToolsUnsupported", err)
+	}
+}
@@ -0,0 +1,50 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// warmRequest is a generate request without a prompt, which makes Ollama
+// load the model and answer once it's in memory.
+type warmRequest struct {
+	Model     string `json:"model"`
+	Stream    bool   `json:"stream"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// Warm loads model into memory, so the first message to it doesn't wait
+// the 10 to 30 seconds loading the weights takes. It returns at once if
+// the model is loaded already.
+func (o *OllamaProvider) Warm(ctx context.Context, model string) error {
+	data, err := json.Marshal(warmRequest{Model: model, KeepAlive: o.keepAlive})
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/generate", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	log.Traffic("ollama request /api/generate", data)
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Traffic("ollama response /api/generate", respBody)
+	if resp.StatusCode != http.StatusOK {
+		return &provider.StatusError{Provider: "ollama", Code: resp.StatusCode, Body: string(respBody)}
+	}
+	return nil
+}
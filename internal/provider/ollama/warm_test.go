@@ -0,0 +1,54 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestWarm(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		if got["model"] == "missing" {
+			http.Error(w, `{"error":"model 'missing' not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"model":"qwen3:8b","response":"","done":true,"done_reason":"load"}`))
+	}))
+	defer srv.Close()
+
+	o, err := NewWithSettings(provider.Settings{BaseURL: srv.URL, KeepAlive: 30 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Warm(context.Background(), "qwen3:8b"); err != nil {
+		t.Fatalf("Warm() = %v", err)
+	}
+	if _, ok := got["prompt"]; ok || got["model"] != "qwen3:8b" || got["keep_alive"] != "30m0s" || got["stream"] != false {
+		t.Errorf("request = %v", got)
+	}
+
+	err = o.Warm(context.Background(), "missing")
+	var se *provider.StatusError
+	if !errors.As(err, &se) || se.Code != http.StatusNotFound {
+		t.Errorf("Warm() of a missing model = %v", err)
+	}
+
+	if err := New(srv.URL).Warm(context.Background(), "qwen3:8b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["keep_alive"]; ok {
+		t.Errorf("sent keep_alive without one configured: %v", got)
+	}
+}
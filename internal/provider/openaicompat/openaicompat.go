@@ -0,0 +1,457 @@
+// Package openaicompat is the client shared by providers whose servers offer
+// the OpenAI chat completion API, such as LM Studio, vLLM and OpenRouter.
+package openaicompat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Client sends OpenAI-format requests to one server.
+type Client struct {
+	Name    string // provider name, for logs and errors
+	BaseURL string // up to and including the API version, e.g. http://127.0.0.1:1234/v1
+
+	// Auth, if set, adds credentials to each request. An error fails the
+	// request.
+	Auth func(*http.Request) error
+
+	// Prepare, if set, adapts each chat request to the server's dialect.
+	Prepare func(*ChatRequest)
+
+	// Route, if set, returns the path and query to send a request for
+	// model to instead of path, for servers that serve each model at its
+	// own URL.
+	Route func(path, model string) string
+
+	HTTP *http.Client
+}
+
+// ChatRequest is the OpenAI chat completion request format.
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	StreamOptions  *StreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"` // LM Studio and OpenRouter extension
+	MaxTokens     *int     `json:"max_tokens,omitempty"`
+	Seed          *int     `json:"seed,omitempty"`
+
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"` // vLLM's name for repeat_penalty
+}
+
+// StreamOptions asks for the token usage in the last chunk of a stream.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ResponseFormat constrains the reply to JSON: "json_object" for any
+// object, or "json_schema" for one matching JSONSchema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema names the schema of a "json_schema" response format.
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// ChatMessage is a message in the OpenAI format. Content is a string, or a
+// list of parts for messages with images.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+// contentPart is a text or image part of a message.
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+// NewChatRequest converts req to the OpenAI format. NumCtx is left out:
+// these servers fix the context length when they load a model.
+func NewChatRequest(req provider.ChatRequest, stream bool) ChatRequest {
+	body := ChatRequest{
+		Model:         req.Model,
+		Messages:      make([]ChatMessage, len(req.Messages)),
+		Stream:        stream,
+		Temperature:   req.Options.Temperature,
+		TopP:          req.Options.TopP,
+		RepeatPenalty: req.Options.RepeatPenalty,
+		MaxTokens:     req.Options.NumPredict,
+		Seed:          req.Options.Seed,
+	}
+	if stream {
+		body.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	if f := req.Format; f != nil {
+		body.ResponseFormat = &ResponseFormat{Type: "json_object"}
+		if len(f.Schema) > 0 {
+			body.ResponseFormat = &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchema{Name: "reply", Schema: f.Schema, Strict: true}}
+		}
+	}
+	for i, m := range req.Messages {
+		body.Messages[i] = ChatMessage{Role: m.Role, Content: m.Content}
+		if len(m.Images) == 0 {
+			continue
+		}
+		parts := []contentPart{{Type: "text", Text: m.Content}}
+		for _, img := range m.Images {
+			parts = append(parts, contentPart{Type: "image_url", ImageURL: &imageURL{URL: imageDataURL(img)}})
+		}
+		body.Messages[i].Content = parts
+	}
+	return body
+}
+
+// newChatRequest converts req to the OpenAI format in the server's
+// dialect.
+func (c *Client) newChatRequest(req provider.ChatRequest, stream bool) ChatRequest {
+	body := NewChatRequest(req, stream)
+	if c.Prepare != nil {
+		c.Prepare(&body)
+	}
+	return body
+}
+
+// route returns the path to send a request for model to.
+func (c *Client) route(path, model string) string {
+	if c.Route == nil {
+		return path
+	}
+	return c.Route(path, model)
+}
+
+// imageDataURL wraps a base64-encoded image in a data URL, guessing the
+// type from the first bytes.
+func imageDataURL(b64 string) string {
+	mime := "image/png"
+	switch {
+	case strings.HasPrefix(b64, "/9j/"):
+		mime = "image/jpeg"
+	case strings.HasPrefix(b64, "R0lGOD"):
+		mime = "image/gif"
+	case strings.HasPrefix(b64, "UklGR"):
+		mime = "image/webp"
+	}
+	return "data:" + mime + ";base64," + b64
+}
+
+// chatResponse is a response or stream chunk from /chat/completions.
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+			reasoning
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+			reasoning
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	// Error is set by servers that report a failure inside a 200 response,
+	// e.g. when the upstream model gives up mid-stream.
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reasoning is the thinking of a reasoning model, which vLLM (with a
+// reasoning parser) and OpenRouter send apart from the content, as
+// reasoning_content or reasoning.
+type reasoning struct {
+	ReasoningContent string `json:"reasoning_content"`
+	Reasoning        string `json:"reasoning"`
+}
+
+func (r reasoning) text() string {
+	return r.ReasoningContent + r.Reasoning
+}
+
+// Reasoning goes back into the content in <think> tags, where the rest of
+// stefanclaw expects it from models that think in their content.
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// usage returns the token counts of a response, or a zero Usage if it has
+// none.
+func (r chatResponse) usage() provider.Usage {
+	if r.Usage == nil {
+		return provider.Usage{}
+	}
+	return provider.Usage{
+		PromptTokens:     r.Usage.PromptTokens,
+		CompletionTokens: r.Usage.CompletionTokens,
+		TotalTokens:      r.Usage.TotalTokens,
+	}
+}
+
+// do sends a request to path and returns the response if it is 200 OK.
+// body, if not nil, is sent as JSON.
+func (c *Client) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var data []byte
+	var reader io.Reader
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+		log.Traffic(c.Name+" request "+path, data)
+	}
+	if c.Auth != nil {
+		if err := c.Auth(httpReq); err != nil {
+			return nil, err
+		}
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &provider.StatusError{Provider: c.Name, Code: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+	return resp, nil
+}
+
+// Get fetches path and decodes the JSON response into v.
+func (c *Client) Get(ctx context.Context, path string, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	log.Traffic(c.Name+" response "+path, respBody)
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// Chat sends a non-streaming chat request.
+func (c *Client) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	start := time.Now()
+	log.Debug(c.Name+" chat request", "model", req.Model, "messages", len(req.Messages))
+	resp, err := c.do(ctx, http.MethodPost, c.route("/chat/completions", req.Model), c.newChatRequest(req, false))
+	if err != nil {
+		log.Warn(c.Name+" chat request failed", "model", req.Model, "err", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Warn(c.Name+" chat response unreadable", "model", req.Model, "err", err)
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	log.Traffic(c.Name+" response /chat/completions", respBody)
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		log.Warn(c.Name+" chat response unreadable", "model", req.Model, "err", err)
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("%s: %s", c.Name, chatResp.Error.Message)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("%s returned no choices", c.Name)
+	}
+	usage := chatResp.usage()
+	log.Info(c.Name+" chat done", "model", req.Model, "duration", time.Since(start),
+		"prompt_tokens", usage.PromptTokens, "completion_tokens", usage.CompletionTokens)
+
+	msg := chatResp.Choices[0].Message
+	content := msg.Content
+	if r := msg.text(); r != "" {
+		content = thinkOpen + r + thinkClose + content
+	}
+	return &provider.ChatResponse{
+		Message: provider.Message{Role: msg.Role, Content: content},
+		Model:   chatResp.Model,
+		Usage:   usage,
+	}, nil
+}
+
+// StreamChat sends a streaming chat request and returns a channel of deltas.
+func (c *Client) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	start := time.Now()
+	log.Debug(c.Name+" stream request", "model", req.Model, "messages", len(req.Messages))
+	resp, err := c.do(ctx, http.MethodPost, c.route("/chat/completions", req.Model), c.newChatRequest(req, true))
+	if err != nil {
+		log.Warn(c.Name+" stream request failed", "model", req.Model, "err", err)
+		return nil, err
+	}
+
+	ch := make(chan provider.StreamDelta)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		log.Debug(c.Name+" stream started", "model", req.Model, "wait", time.Since(start))
+		// The usage arrives in a chunk of its own after the last content
+		var usage provider.Usage
+		thinking := false // inside reasoning sent apart from the content
+		done := func() {
+			if thinking {
+				ch <- provider.StreamDelta{Content: thinkClose}
+			}
+			ch <- provider.StreamDelta{Done: true, Usage: &usage}
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue // blank separators and comments
+			}
+			data = strings.TrimSpace(data)
+			log.Traffic(c.Name+" response /chat/completions", []byte(data))
+			if data == "[DONE]" {
+				log.Info(c.Name+" stream done", "model", req.Model, "duration", time.Since(start),
+					"prompt_tokens", usage.PromptTokens, "completion_tokens", usage.CompletionTokens)
+				done()
+				return
+			}
+
+			var chunk chatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				log.Warn(c.Name+" stream chunk unreadable", "model", req.Model, "err", err)
+				ch <- provider.StreamDelta{Err: fmt.Errorf("decoding chunk: %w", err)}
+				return
+			}
+			if chunk.Error != nil {
+				log.Warn(c.Name+" stream failed", "model", req.Model, "err", chunk.Error.Message)
+				ch <- provider.StreamDelta{Err: fmt.Errorf("%s: %s", c.Name, chunk.Error.Message)}
+				return
+			}
+			if chunk.Usage != nil {
+				usage = chunk.usage()
+			}
+			for _, choice := range chunk.Choices {
+				var content string
+				if r := choice.Delta.text(); r != "" {
+					if !thinking {
+						content, thinking = thinkOpen, true
+					}
+					content += r
+				}
+				if choice.Delta.Content != "" {
+					if thinking {
+						content, thinking = content+thinkClose, false
+					}
+					content += choice.Delta.Content
+				}
+				if content != "" {
+					ch <- provider.StreamDelta{Content: content}
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			select {
+			case <-ctx.Done():
+				// Context cancelled, don't send error
+				log.Debug(c.Name+" stream cancelled", "model", req.Model, "duration", time.Since(start))
+			default:
+				log.Warn(c.Name+" stream broken off", "model", req.Model, "duration", time.Since(start), "err", err)
+				ch <- provider.StreamDelta{Err: fmt.Errorf("reading stream: %w", err)}
+			}
+			return
+		}
+		// The server closed the stream without [DONE]
+		done()
+	}()
+
+	return ch, nil
+}
+
+// embedRequest is the request body for /embeddings.
+type embedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// Embed returns one embedding per text, computed by model.
+func (c *Client) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	// Only the request is traced: the embeddings in the response are long
+	// and tell little
+	resp, err := c.do(ctx, http.MethodPost, c.route("/embeddings", model), embedRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embedResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(embedResp.Data) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d texts", c.Name, len(embedResp.Data), len(texts))
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range embedResp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%s returned an embedding for text %d of %d", c.Name, d.Index, len(texts))
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,138 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestNewChatRequest_Images(t *testing.T) {
+	body := NewChatRequest(provider.ChatRequest{
+		Messages: []provider.Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "What's this?", Images: []string{"/9j/4AAQ"}},
+		},
+	}, false)
+
+	data, _ := json.Marshal(body.Messages)
+	want := `[{"role":"system","content":"Be brief."},{"role":"user","content":[{"type":"text","text":"What's this?"},{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,/9j/4AAQ"}}]}]`
+	if string(data) != want {
+		t.Errorf("messages =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestNewChatRequest_Format(t *testing.T) {
+	tests := []struct {
+		format *provider.Format
+		want   string
+	}{
+		{nil, `null`},
+		{&provider.Format{}, `{"type":"json_object"}`},
+		{&provider.Format{Schema: json.RawMessage(`{"type":"object"}`)}, `{"type":"json_schema","json_schema":{"name":"reply","schema":{"type":"object"},"strict":true}}`},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(NewChatRequest(provider.ChatRequest{Format: tt.format}, false).ResponseFormat)
+		if string(data) != tt.want {
+			t.Errorf("response_format = %s, want %s", data, tt.want)
+		}
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, `{"error":{"message":"No auth credentials found"}}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{Name: "test", BaseURL: srv.URL}
+	if _, err := c.Chat(context.Background(), provider.ChatRequest{}); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Errorf("Chat() without credentials error = %v, want status 401", err)
+	}
+
+	c.Auth = func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer sk-test")
+		return nil
+	}
+	if resp, err := c.Chat(context.Background(), provider.ChatRequest{}); err != nil || resp.Message.Content != "ok" {
+		t.Errorf("Chat() = %+v, %v", resp, err)
+	}
+
+	c.Auth = func(*http.Request) error { return errors.New("no key") }
+	if _, err := c.Chat(context.Background(), provider.ChatRequest{}); err == nil || err.Error() != "no key" {
+		t.Errorf("Chat() error = %v, want the Auth error", err)
+	}
+}
+
+func TestStreamChat_ErrorChunk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": PROCESSING\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"error\":{\"message\":\"upstream overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := (&Client{Name: "test", BaseURL: srv.URL}).StreamChat(context.Background(), provider.ChatRequest{})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var content string
+	var streamErr error
+	for d := range ch {
+		content += d.Content
+		if d.Err != nil {
+			streamErr = d.Err
+		}
+	}
+	if content != "Hel" || streamErr == nil || !strings.Contains(streamErr.Error(), "upstream overloaded") {
+		t.Errorf("content = %q, err = %v", content, streamErr)
+	}
+}
+
+func TestStreamChat_Reasoning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"The user \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"greets me.\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi!\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := (&Client{Name: "test", BaseURL: srv.URL}).StreamChat(context.Background(), provider.ChatRequest{})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var content string
+	for d := range ch {
+		content += d.Content
+	}
+	if want := "<think>The user greets me.</think>Hi!"; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestChat_Reasoning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Hi!","reasoning":"A greeting."}}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := (&Client{Name: "test", BaseURL: srv.URL}).Chat(context.Background(), provider.ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<think>A greeting.</think>Hi!"; resp.Message.Content != want {
+		t.Errorf("content = %q, want %q", resp.Message.Content, want)
+	}
+}
@@ -0,0 +1,154 @@
+// Package openrouter talks to OpenRouter, which serves hosted models from
+// many vendors through one OpenAI-compatible API, for when a local machine
+// is too slow for the model you want.
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/openaicompat"
+)
+
+// DefaultBaseURL is OpenRouter's API endpoint.
+const DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+// ErrNoAPIKey is returned by every request when no API key is configured.
+var ErrNoAPIKey = errors.New("no OpenRouter API key: set provider.openrouter.api_key in config.yaml")
+
+// OpenRouterProvider implements the Provider interface for OpenRouter.
+type OpenRouterProvider struct {
+	api *openaicompat.Client
+
+	apiKey  string
+	resolve func(string) (string, error)
+
+	keyOnce sync.Once
+	key     string
+	keyErr  error
+}
+
+func init() {
+	provider.Register("openrouter", func(s provider.Settings) (provider.Provider, error) {
+		return New(s.BaseURL, s.APIKey, s.Resolve), nil
+	})
+}
+
+// New creates a new OpenRouterProvider. apiKey may be a secret reference,
+// which is looked up with resolve on first use; nil resolve takes it as is.
+func New(baseURL, apiKey string, resolve func(string) (string, error)) *OpenRouterProvider {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	o := &OpenRouterProvider{apiKey: apiKey, resolve: resolve}
+	o.api = &openaicompat.Client{
+		Name:    "openrouter",
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Auth:    o.authorize,
+		HTTP:    &http.Client{},
+	}
+	return o
+}
+
+func (o *OpenRouterProvider) Name() string {
+	return "openrouter"
+}
+
+// authorize adds the API key, and the app attribution OpenRouter shows in
+// its dashboards, to a request.
+func (o *OpenRouterProvider) authorize(r *http.Request) error {
+	o.keyOnce.Do(func() {
+		o.key, o.keyErr = o.resolve(o.apiKey)
+		if o.keyErr == nil && strings.TrimSpace(o.key) == "" {
+			o.keyErr = ErrNoAPIKey
+		}
+	})
+	if o.keyErr != nil {
+		return fmt.Errorf("openrouter api key: %w", o.keyErr)
+	}
+	r.Header.Set("Authorization", "Bearer "+strings.TrimSpace(o.key))
+	r.Header.Set("HTTP-Referer", "https://github.com/stefanclaw/stefanclaw")
+	r.Header.Set("X-Title", "stefanclaw")
+	return nil
+}
+
+// Chat sends a non-streaming chat request.
+func (o *OpenRouterProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	return o.api.Chat(ctx, req)
+}
+
+// StreamChat sends a streaming chat request and returns a channel of deltas.
+func (o *OpenRouterProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	return o.api.StreamChat(ctx, req)
+}
+
+// modelsResponse is the response from /models.
+type modelsResponse struct {
+	Data []struct {
+		ID            string `json:"id"`
+		Name          string `json:"name"`
+		ContextLength int    `json:"context_length"`
+		Pricing       struct {
+			Prompt     string `json:"prompt"`
+			Completion string `json:"completion"`
+		} `json:"pricing"`
+	} `json:"data"`
+}
+
+// perMillion converts OpenRouter's price per token, a decimal string, to
+// dollars per million tokens.
+func perMillion(price string) float64 {
+	f, err := strconv.ParseFloat(price, 64)
+	if err != nil || f < 0 {
+		// Negative prices mark variable pricing (e.g. the auto router)
+		return 0
+	}
+	return f * 1e6
+}
+
+// ListModels returns OpenRouter's catalog, sorted by ID, with each model's
+// context length and price.
+func (o *OpenRouterProvider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var modelsResp modelsResponse
+	if err := o.api.Get(ctx, "/models", &modelsResp); err != nil {
+		return nil, fmt.Errorf("listing models: %w", err)
+	}
+
+	models := make([]provider.ModelInfo, len(modelsResp.Data))
+	for i, m := range modelsResp.Data {
+		models[i] = provider.ModelInfo{
+			Name:          m.ID,
+			DisplayName:   m.Name,
+			ContextLength: m.ContextLength,
+			Pricing: &provider.Pricing{
+				Prompt:     perMillion(m.Pricing.Prompt),
+				Completion: perMillion(m.Pricing.Completion),
+			},
+		}
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// IsAvailable checks that OpenRouter is reachable and accepts the API key.
+func (o *OpenRouterProvider) IsAvailable(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var key struct {
+		Data struct {
+			Label string `json:"label"`
+		} `json:"data"`
+	}
+	if err := o.api.Get(ctx, "/key", &key); err != nil {
+		return fmt.Errorf("checking the OpenRouter API key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// newServer fakes OpenRouter, accepting only the key sk-or-test.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-or-test" {
+			http.Error(w, `{"error":{"message":"No auth credentials found","code":401}}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Title") != "stefanclaw" {
+			t.Errorf("X-Title = %q", r.Header.Get("X-Title"))
+		}
+		switch r.URL.Path {
+		case "/models":
+			fmt.Fprint(w, `{"data":[
+				{"id":"qwen/qwen3-235b-a22b","name":"Qwen: Qwen3 235B A22B","context_length":131072,"pricing":{"prompt":"0.00000013","completion":"0.0000006"}},
+				{"id":"meta-llama/llama-3.3-70b-instruct:free","name":"Meta: Llama 3.3 70B Instruct (free)","context_length":65536,"pricing":{"prompt":"0","completion":"0"}},
+				{"id":"openrouter/auto","name":"Auto Router","context_length":2000000,"pricing":{"prompt":"-1","completion":"-1"}}
+			]}`)
+		case "/key":
+			fmt.Fprint(w, `{"data":{"label":"sk-or-...test","usage":0.5,"limit":null}}`)
+		case "/chat/completions":
+			fmt.Fprint(w, `{"model":"qwen/qwen3-235b-a22b","choices":[{"message":{"role":"assistant","content":"Hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestListModels(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	models, err := New(srv.URL, "sk-or-test", nil).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("got %d models", len(models))
+	}
+	// Sorted by ID
+	llama, auto, qwen := models[0], models[1], models[2]
+	if qwen.Name != "qwen/qwen3-235b-a22b" || qwen.DisplayName != "Qwen: Qwen3 235B A22B" || qwen.ContextLength != 131072 {
+		t.Errorf("qwen = %+v", qwen)
+	}
+	if p := qwen.Pricing; p == nil || p.Prompt < 0.1299 || p.Prompt > 0.1301 || p.Completion < 0.5999 || p.Completion > 0.6001 {
+		t.Errorf("qwen pricing = %+v, want 0.13/0.60 per million tokens", qwen.Pricing)
+	}
+	if !llama.Pricing.Free() {
+		t.Errorf("llama :free should be free, got %+v", llama.Pricing)
+	}
+	if auto.Name != "openrouter/auto" || auto.Pricing.Prompt != 0 {
+		t.Errorf("variable pricing should read as 0, got %+v", auto)
+	}
+}
+
+func TestChat_SendsAPIKey(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	resp, err := New(srv.URL, "sk-or-test", nil).Chat(context.Background(), provider.ChatRequest{
+		Model:    "qwen/qwen3-235b-a22b",
+		Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil || resp.Message.Content != "Hi" || resp.Usage.TotalTokens != 6 {
+		t.Errorf("Chat() = %+v, %v", resp, err)
+	}
+}
+
+func TestAPIKey_Resolved(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	lookups := 0
+	resolve := func(v string) (string, error) {
+		lookups++
+		if v != "keyring:openrouter" {
+			return "", errors.New("unexpected reference " + v)
+		}
+		return "sk-or-test", nil
+	}
+	p := New(srv.URL, "keyring:openrouter", resolve)
+	for range 2 {
+		if err := p.IsAvailable(context.Background()); err != nil {
+			t.Fatalf("IsAvailable() error: %v", err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("key looked up %d times, want once", lookups)
+	}
+}
+
+func TestIsAvailable_BadOrMissingKey(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	if err := New(srv.URL, "sk-or-wrong", nil).IsAvailable(context.Background()); err == nil {
+		t.Error("IsAvailable() should fail with a rejected key")
+	}
+	err := New(srv.URL, "", nil).IsAvailable(context.Background())
+	if !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("IsAvailable() without a key = %v, want ErrNoAPIKey", err)
+	}
+}
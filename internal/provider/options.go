@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Options holds sampling parameters for a chat request. Nil fields are left
+// to the provider's own defaults.
+type Options struct {
+	Temperature   *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty" yaml:"top_p,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty" yaml:"repeat_penalty,omitempty"`
+	NumPredict    *int     `json:"num_predict,omitempty" yaml:"num_predict,omitempty"`
+	Seed          *int     `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
+// OptionKeys lists the settable option names in display order.
+var OptionKeys = []string{"temperature", "top_p", "repeat_penalty", "num_predict", "seed"}
+
+// Merge returns o with every field that is set in override replaced.
+func (o Options) Merge(override Options) Options {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		o.TopP = override.TopP
+	}
+	if override.RepeatPenalty != nil {
+		o.RepeatPenalty = override.RepeatPenalty
+	}
+	if override.NumPredict != nil {
+		o.NumPredict = override.NumPredict
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	return o
+}
+
+// IsZero reports whether no option is set.
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// Set parses value and assigns it to the named option. Dashes in key are
+// accepted in place of underscores ("top-p"). An empty value or "default"
+// clears the option.
+func (o *Options) Set(key, value string) error {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	clear := value == "" || value == "default"
+
+	switch key {
+	case "temperature", "top_p", "repeat_penalty":
+		var f *float64
+		if !clear {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || v < 0 {
+				return fmt.Errorf("%s must be a non-negative number, got %q", key, value)
+			}
+			f = &v
+		}
+		switch key {
+		case "temperature":
+			o.Temperature = f
+		case "top_p":
+			o.TopP = f
+		default:
+			o.RepeatPenalty = f
+		}
+	case "num_predict", "seed":
+		var n *int
+		if !clear {
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", key, value)
+			}
+			n = &v
+		}
+		if key == "seed" {
+			o.Seed = n
+		} else {
+			o.NumPredict = n
+		}
+	default:
+		return fmt.Errorf("unknown sampling option %q (valid: %s)", key, strings.Join(OptionKeys, ", "))
+	}
+	return nil
+}
+
+// Get returns the named option formatted for display, or "" if unset.
+func (o Options) Get(key string) string {
+	switch key {
+	case "temperature":
+		return formatFloat(o.Temperature)
+	case "top_p":
+		return formatFloat(o.TopP)
+	case "repeat_penalty":
+		return formatFloat(o.RepeatPenalty)
+	case "num_predict":
+		return formatInt(o.NumPredict)
+	case "seed":
+		return formatInt(o.Seed)
+	}
+	return ""
+}
+
+// String formats the set options as "key=value" pairs.
+func (o Options) String() string {
+	var parts []string
+	for _, k := range OptionKeys {
+		if v := o.Get(k); v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'g', -1, 64)
+}
+
+func formatInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
@@ -1,6 +1,14 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // Provider defines the interface for LLM providers.
 type Provider interface {
@@ -11,10 +19,151 @@ type Provider interface {
 	IsAvailable(ctx context.Context) error
 }
 
+// Embedder is implemented by providers that can compute embeddings, the
+// vectors used to find text by meaning.
+type Embedder interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
+
+// Puller is implemented by providers that can download models, reporting
+// progress to progress as they go.
+type Puller interface {
+	Pull(ctx context.Context, model string, progress func(PullProgress)) error
+}
+
+// PullProgress reports how far a model download got. Models are downloaded
+// in parts, each with its own progress.
+type PullProgress struct {
+	Status    string // e.g. "pulling manifest", "verifying sha256 digest"
+	Completed int64  // bytes of the current part downloaded so far
+	Total     int64  // size of the current part; 0 while not downloading
+}
+
+// ToolCaller is implemented by providers with native tool calling: when
+// CallsTools reports true, they pass ChatRequest.Tools to the model and
+// return its calls in Message.ToolCalls and StreamDelta.ToolCalls. Other
+// providers ignore Tools.
+type ToolCaller interface {
+	CallsTools() bool
+}
+
+// ErrToolsUnsupported is returned by providers with native tool calling
+// for a request with tools to a model that can't call them.
+var ErrToolsUnsupported = errors.New("model does not support tools")
+
+// StatusError is returned when a provider's API answers a request with an
+// error status.
+type StatusError struct {
+	Provider string // e.g. "ollama"
+	Code     int
+	Body     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.Code, e.Body)
+}
+
+// ModelDescriber is implemented by providers that can describe the models
+// their server offers.
+type ModelDescriber interface {
+	ShowModel(ctx context.Context, model string) (*ModelDetails, error)
+}
+
+// Warmer is implemented by providers that load models on first use, so
+// that the first message doesn't wait for the model to load.
+type Warmer interface {
+	// Warm loads model and returns once it's ready.
+	Warm(ctx context.Context, model string) error
+}
+
+// ModelManager is implemented by providers that manage the models installed
+// on their server.
+type ModelManager interface {
+	ModelDescriber
+	DeleteModel(ctx context.Context, model string) error
+}
+
+// ModelDetails describes an installed model.
+type ModelDetails struct {
+	Family        string   // e.g. "qwen3"
+	ParameterSize string   // e.g. "8.2B"
+	Quantization  string   // e.g. "Q4_K_M"
+	ContextLength int      // the longest context the model was trained for; 0 if unknown
+	Capabilities  []string // e.g. "completion", "tools", "vision", "thinking"; nil if unknown
+
+	// DiskFree is the space left on the disk the server keeps its models
+	// on, in bytes; 0 if unknown, e.g. for a remote server.
+	DiskFree uint64
+}
+
+// String summarizes d, e.g. "8.2B parameters, Q4_K_M, 40960-token context".
+func (d ModelDetails) String() string {
+	var parts []string
+	if d.ParameterSize != "" {
+		parts = append(parts, d.ParameterSize+" parameters")
+	}
+	if d.Quantization != "" {
+		parts = append(parts, d.Quantization)
+	}
+	if d.ContextLength > 0 {
+		parts = append(parts, fmt.Sprintf("%d-token context", d.ContextLength))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Supports reports whether the model has capability, such as "tools" or
+// "vision". Models whose provider doesn't list capabilities are assumed to
+// have all of them.
+func (d ModelDetails) Supports(capability string) bool {
+	return d.Capabilities == nil || slices.Contains(d.Capabilities, capability)
+}
+
 // Message represents a chat message.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // base64-encoded, for vision models
+
+	// Native tool calling: the calls of an assistant message, and the tool
+	// a "tool" message is the result of.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+// Tool describes a function the model may call, for providers with native
+// tool calling.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"` // JSON schema of the arguments object
+}
+
+// ToolCall is a function call made by the model.
+type ToolCall struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// MarshalJSON encodes c the way Ollama and OpenAI-style APIs nest it:
+// {"function": {"name": ..., "arguments": ...}}.
+func (c ToolCall) MarshalJSON() ([]byte, error) {
+	type function ToolCall
+	return json.Marshal(struct {
+		Function function `json:"function"`
+	}{function(c)})
+}
+
+// UnmarshalJSON decodes a call encoded by MarshalJSON.
+func (c *ToolCall) UnmarshalJSON(data []byte) error {
+	type function ToolCall
+	var v struct {
+		Function function `json:"function"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = ToolCall(v.Function)
+	return nil
 }
 
 // ChatRequest is the input for a chat completion.
@@ -22,6 +171,14 @@ type ChatRequest struct {
 	Model    string    `json:"model"`
 	Messages []Message `json:"messages"`
 	NumCtx   int       `json:"-"` // Ollama-specific context size, not serialized generically
+	Options  Options   `json:"-"` // sampling parameters; unset fields use provider defaults
+	Tools    []Tool    `json:"tools,omitempty"`
+	Format   *Format   `json:"-"` // constrains the reply to JSON; nil for free text
+}
+
+// Format asks for a reply that is a JSON value, for scripts that parse it.
+type Format struct {
+	Schema json.RawMessage // JSON schema the reply must match; any JSON object if empty
 }
 
 // ChatResponse is the output of a non-streaming chat completion.
@@ -33,21 +190,43 @@ type ChatResponse struct {
 
 // StreamDelta represents a single streaming chunk.
 type StreamDelta struct {
-	Content string
-	Done    bool
-	Usage   *Usage
-	Err     error
+	Content   string
+	ToolCalls []ToolCall
+	Done      bool
+	Usage     *Usage
+	Err       error
 }
 
 // ModelInfo describes an available model.
 type ModelInfo struct {
 	Name string `json:"name"`
 	Size int64  `json:"size"`
+
+	// Hosted providers describe their catalog further; zero if unknown.
+	DisplayName   string   `json:"display_name,omitempty"`
+	ContextLength int      `json:"context_length,omitempty"`
+	Pricing       *Pricing `json:"pricing,omitempty"`
 }
 
-// Usage contains token usage statistics.
+// Pricing is what a hosted model costs, in US dollars per million tokens.
+type Pricing struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Free reports whether both prompt and completion cost nothing.
+func (p Pricing) Free() bool {
+	return p.Prompt == 0 && p.Completion == 0
+}
+
+// Usage contains token usage statistics and, if the provider reports them,
+// timings.
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	LoadDuration   time.Duration `json:"load_duration,omitempty"`   // loading the model
+	PromptDuration time.Duration `json:"prompt_duration,omitempty"` // processing the prompt
+	EvalDuration   time.Duration `json:"eval_duration,omitempty"`   // generating the reply
 }
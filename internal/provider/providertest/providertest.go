@@ -0,0 +1,209 @@
+// Package providertest provides a scriptable provider.Provider for tests:
+// canned replies, streamed in chunks or fed by the test itself, with
+// optional latency and failures.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Reply is the scripted outcome of one Chat or StreamChat call.
+type Reply struct {
+	Content string         // the reply; streamed word by word unless Chunks is set
+	Chunks  []string       // streamed in this order instead of Content
+	Usage   provider.Usage // reported with the final chunk or response
+	Err     error          // returned by the call itself instead of a reply
+
+	// ToolCalls are made natively after the content, e.g. in reply to a
+	// request with Tools to a Fake with NativeTools set.
+	ToolCalls []provider.ToolCall
+
+	// StreamErr is sent after the chunks instead of the final chunk, as if
+	// the connection broke off.
+	StreamErr error
+
+	// Stream, if set, is returned by StreamChat as is, for tests that feed
+	// the deltas themselves. Chat fails for such a reply.
+	Stream chan provider.StreamDelta
+}
+
+// Text returns a reply with content.
+func Text(content string) Reply {
+	return Reply{Content: content}
+}
+
+// Fake is a provider.Provider that answers with its Replies in turn; once
+// they run out, the last one is repeated, and without any replies it
+// answers with an empty message. It records every request and is safe for
+// concurrent use.
+type Fake struct {
+	ProviderName string // returned by Name; "fake" if empty
+	Replies      []Reply
+	Models       []provider.ModelInfo
+	ModelsErr    error
+	Unavailable  error         // returned by IsAvailable
+	Latency      time.Duration // before the reply and between streamed chunks
+	NativeTools  bool          // reported by CallsTools
+
+	mu       sync.Mutex
+	next     int
+	requests []provider.ChatRequest
+}
+
+// New returns a Fake that answers with replies.
+func New(replies ...Reply) *Fake {
+	return &Fake{Replies: replies}
+}
+
+// Name returns ProviderName.
+func (f *Fake) Name() string {
+	if f.ProviderName == "" {
+		return "fake"
+	}
+	return f.ProviderName
+}
+
+// CallsTools returns NativeTools.
+func (f *Fake) CallsTools() bool {
+	return f.NativeTools
+}
+
+// Chat returns the next reply as a whole.
+func (f *Fake) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	r := f.take(req)
+	if err := sleep(ctx, f.Latency); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Stream != nil {
+		return nil, errors.New("providertest: Chat called for a fed stream")
+	}
+	if r.StreamErr != nil {
+		return nil, r.StreamErr
+	}
+	content := r.Content
+	if r.Chunks != nil {
+		content = strings.Join(r.Chunks, "")
+	}
+	return &provider.ChatResponse{
+		Message: provider.Message{Role: "assistant", Content: content, ToolCalls: r.ToolCalls},
+		Model:   req.Model,
+		Usage:   r.Usage,
+	}, nil
+}
+
+// StreamChat streams the next reply. The stream ends early, without a
+// final chunk, when ctx is done.
+func (f *Fake) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	r := f.take(req)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Stream != nil {
+		return r.Stream, nil
+	}
+	chunks := r.Chunks
+	if chunks == nil && r.Content != "" {
+		chunks = strings.SplitAfter(r.Content, " ")
+	}
+
+	ch := make(chan provider.StreamDelta)
+	go func() {
+		defer close(ch)
+		send := func(d provider.StreamDelta) bool {
+			select {
+			case ch <- d:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, c := range chunks {
+			if sleep(ctx, f.Latency) != nil || !send(provider.StreamDelta{Content: c}) {
+				return
+			}
+		}
+		if r.ToolCalls != nil && !send(provider.StreamDelta{ToolCalls: r.ToolCalls}) {
+			return
+		}
+		if r.StreamErr != nil {
+			send(provider.StreamDelta{Err: r.StreamErr})
+			return
+		}
+		usage := r.Usage
+		send(provider.StreamDelta{Done: true, Usage: &usage})
+	}()
+	return ch, nil
+}
+
+// ListModels returns Models and ModelsErr.
+func (f *Fake) ListModels(context.Context) ([]provider.ModelInfo, error) {
+	return f.Models, f.ModelsErr
+}
+
+// IsAvailable returns Unavailable.
+func (f *Fake) IsAvailable(context.Context) error {
+	return f.Unavailable
+}
+
+// Requests returns the requests made so far, oldest first.
+func (f *Fake) Requests() []provider.ChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]provider.ChatRequest(nil), f.requests...)
+}
+
+// LastRequest returns the latest request, or a zero request if none was
+// made.
+func (f *Fake) LastRequest() provider.ChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		return provider.ChatRequest{}
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+// Reset forgets the requests made so far and starts the replies over.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = nil
+	f.next = 0
+}
+
+// take records req and returns the reply to it.
+func (f *Fake) take(req provider.ChatRequest) Reply {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if len(f.Replies) == 0 {
+		return Reply{}
+	}
+	r := f.Replies[min(f.next, len(f.Replies)-1)]
+	f.next++
+	return r
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// collect reads a stream to its end.
+func collect(ch <-chan provider.StreamDelta) (content string, last provider.StreamDelta) {
+	for d := range ch {
+		content += d.Content
+		last = d
+	}
+	return content, last
+}
+
+func TestFake_RepliesInTurn(t *testing.T) {
+	f := New(Text("one"), Reply{Content: "two", Usage: provider.Usage{CompletionTokens: 1}})
+	ctx := context.Background()
+
+	resp, err := f.Chat(ctx, provider.ChatRequest{Model: "m", Messages: []provider.Message{{Role: "user", Content: "hi"}}})
+	if err != nil || resp.Message.Content != "one" || resp.Model != "m" {
+		t.Fatalf("first reply = %+v, %v", resp, err)
+	}
+	ch, err := f.StreamChat(ctx, provider.ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, last := collect(ch)
+	if content != "two" || !last.Done || last.Usage.CompletionTokens != 1 {
+		t.Errorf("second reply = %q, final %+v", content, last)
+	}
+	if resp, _ := f.Chat(ctx, provider.ChatRequest{}); resp.Message.Content != "two" {
+		t.Errorf("after the last reply got %q, want it repeated", resp.Message.Content)
+	}
+
+	if n := len(f.Requests()); n != 3 {
+		t.Errorf("recorded %d requests, want 3", n)
+	}
+	if got := f.Requests()[0].Messages[0].Content; got != "hi" {
+		t.Errorf("first request = %q", got)
+	}
+	f.Reset()
+	if len(f.Requests()) != 0 || f.LastRequest().Model != "" {
+		t.Error("Reset kept requests")
+	}
+}
+
+func TestFake_StreamsChunks(t *testing.T) {
+	f := New(Reply{Content: "Hello there, friend"})
+	ch, _ := f.StreamChat(context.Background(), provider.ChatRequest{})
+	var chunks []string
+	for d := range ch {
+		if d.Content != "" {
+			chunks = append(chunks, d.Content)
+		}
+	}
+	if len(chunks) != 3 || chunks[0] != "Hello " {
+		t.Errorf("chunks = %q, want word by word", chunks)
+	}
+}
+
+func TestFake_Failures(t *testing.T) {
+	offline := errors.New("offline")
+	broken := errors.New("connection reset")
+	f := New(Reply{Err: offline}, Reply{Chunks: []string{"Hal", "f"}, StreamErr: broken})
+	ctx := context.Background()
+
+	if _, err := f.StreamChat(ctx, provider.ChatRequest{}); !errors.Is(err, offline) {
+		t.Errorf("err = %v, want offline", err)
+	}
+	ch, _ := f.StreamChat(ctx, provider.ChatRequest{})
+	content, last := collect(ch)
+	if content != "Half" || !errors.Is(last.Err, broken) || last.Done {
+		t.Errorf("stream = %q, final %+v; want it broken off after the chunks", content, last)
+	}
+}
+
+func TestFake_Latency(t *testing.T) {
+	f := New(Reply{Chunks: []string{"a", "b", "c"}})
+	f.Latency = 20 * time.Millisecond
+
+	start := time.Now()
+	ch, _ := f.StreamChat(context.Background(), provider.ChatRequest{})
+	collect(ch)
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("stream took %v, want at least 60ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, _ = f.StreamChat(ctx, provider.ChatRequest{})
+	<-ch
+	cancel()
+	if _, last := collect(ch); last.Done {
+		t.Error("canceled stream still finished")
+	}
+	if _, err := f.Chat(ctx, provider.ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Chat with a canceled context: err = %v", err)
+	}
+}
+
+func TestFake_FedStream(t *testing.T) {
+	ch := make(chan provider.StreamDelta, 1)
+	f := New(Reply{Stream: ch})
+	got, err := f.StreamChat(context.Background(), provider.ChatRequest{})
+	if err != nil || got != (<-chan provider.StreamDelta)(ch) {
+		t.Errorf("StreamChat = %v, %v; want the fed stream", got, err)
+	}
+	if _, err := f.Chat(context.Background(), provider.ChatRequest{}); err == nil {
+		t.Error("Chat for a fed stream should fail")
+	}
+}
@@ -0,0 +1,296 @@
+// Package queue limits how many chat requests go to providers at once, so
+// that heartbeats, session titles, memory extraction and the user's own
+// messages take turns instead of piling up on a single-slot Ollama. The
+// requests waiting and running can be listed and canceled.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ErrCanceled is the error of a request canceled with Cancel.
+var ErrCanceled = errors.New("request canceled from the queue")
+
+// Job is a request waiting for its turn or running.
+type Job struct {
+	ID      int
+	Label   string // what the request is for, e.g. "chat" or "heartbeat"
+	Model   string
+	Queued  time.Time
+	Started time.Time // zero while waiting
+}
+
+// Running reports whether j has its turn.
+func (j Job) Running() bool {
+	return !j.Started.IsZero()
+}
+
+type labelKey struct{}
+
+// WithLabel returns a context whose requests are listed as label.
+func WithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// label returns the label of requests made with ctx.
+func label(ctx context.Context) string {
+	if l, ok := ctx.Value(labelKey{}).(string); ok {
+		return l
+	}
+	return "request"
+}
+
+// Queue runs a limited number of requests at once and lets the others wait
+// in the order they came in. Providers wrapped with the same Queue share
+// its slots.
+type Queue struct {
+	slots   int
+	now     func() time.Time
+	changed chan struct{}
+
+	mu      sync.Mutex
+	jobs    []*job // in the order they came in
+	running int
+	lastID  int
+}
+
+// job is a Job with what it takes to start and cancel it.
+type job struct {
+	Job
+	ready  chan struct{} // closed when the job gets its turn
+	cancel context.CancelCauseFunc
+}
+
+// New creates a Queue running up to slots requests at once, at least one.
+func New(slots int) *Queue {
+	return &Queue{slots: max(slots, 1), now: time.Now, changed: make(chan struct{}, 1)}
+}
+
+// Slots returns how many requests run at once.
+func (q *Queue) Slots() int {
+	return q.slots
+}
+
+// Jobs returns the requests running and waiting, in the order they came
+// in.
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, len(q.jobs))
+	for i, j := range q.jobs {
+		jobs[i] = j.Job
+	}
+	return jobs
+}
+
+// Changed returns a channel that receives a value after requests were
+// added, started or done. Changes in quick succession may be reported once.
+func (q *Queue) Changed() <-chan struct{} {
+	return q.changed
+}
+
+// Cancel cancels the request with the given ID, waiting or running. It
+// reports whether there was one.
+func (q *Queue) Cancel(id int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.IndexFunc(q.jobs, func(j *job) bool { return j.ID == id })
+	if i < 0 {
+		return false
+	}
+	q.jobs[i].cancel(ErrCanceled)
+	return true
+}
+
+// acquire waits for the turn of a request for model made with ctx. It
+// returns the context to make the request with, which Cancel cancels, and
+// the function to call when the request is done.
+func (q *Queue) acquire(ctx context.Context, model string) (context.Context, func(), error) {
+	jctx, cancel := context.WithCancelCause(ctx)
+	q.mu.Lock()
+	q.lastID++
+	j := &job{
+		Job:    Job{ID: q.lastID, Label: label(ctx), Model: model, Queued: q.now()},
+		ready:  make(chan struct{}),
+		cancel: cancel,
+	}
+	q.jobs = append(q.jobs, j)
+	q.dispatch()
+	q.mu.Unlock()
+
+	done := func() { q.release(j) }
+	select {
+	case <-j.ready:
+		return jctx, done, nil
+	case <-jctx.Done():
+		done()
+		return nil, nil, context.Cause(jctx)
+	}
+}
+
+// release removes j and gives its turn to the next request.
+func (q *Queue) release(j *job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.Index(q.jobs, j)
+	if i < 0 {
+		return
+	}
+	q.jobs = slices.Delete(q.jobs, i, i+1)
+	if j.Running() {
+		q.running--
+	}
+	j.cancel(nil)
+	q.dispatch()
+}
+
+// dispatch starts waiting requests while slots are free and reports the
+// change. q.mu must be held.
+func (q *Queue) dispatch() {
+	for _, j := range q.jobs {
+		if q.running >= q.slots {
+			break
+		}
+		if !j.Running() {
+			j.Started = q.now()
+			q.running++
+			close(j.ready)
+		}
+	}
+	select {
+	case q.changed <- struct{}{}:
+	default:
+	}
+}
+
+// canceled replaces err with ErrCanceled if the request made with ctx
+// was canceled with Cancel.
+func canceled(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrCanceled) {
+		return ErrCanceled
+	}
+	return err
+}
+
+// Wrap returns p with its chat requests run through q. Listing models,
+// health checks, embeddings and model management don't wait.
+func (q *Queue) Wrap(p provider.Provider) provider.Provider {
+	w := &queued{Provider: p, q: q}
+	if emb, ok := p.(provider.Embedder); ok {
+		return &embedderQueued{queued: w, emb: emb}
+	}
+	return w
+}
+
+// queued implements the Provider interface by waiting for the queue.
+type queued struct {
+	provider.Provider
+	q *Queue
+}
+
+// Chat waits for its turn and sends a non-streaming chat request.
+func (p *queued) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	jctx, done, err := p.q.acquire(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	resp, err := p.Provider.Chat(jctx, req)
+	return resp, canceled(jctx, err)
+}
+
+// StreamChat waits for its turn and starts a streaming chat request, which
+// keeps the turn until the stream ends.
+func (p *queued) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	jctx, done, err := p.q.acquire(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := p.Provider.StreamChat(jctx, req)
+	if err != nil {
+		done()
+		return nil, canceled(jctx, err)
+	}
+	out := make(chan provider.StreamDelta)
+	go func() {
+		defer close(out)
+		defer done()
+		failed := false
+		for d := range ch {
+			d.Err = canceled(jctx, d.Err)
+			failed = failed || d.Err != nil
+			select {
+			case out <- d:
+			case <-ctx.Done():
+			}
+		}
+		// A provider may end a canceled stream without an error
+		if !failed && errors.Is(context.Cause(jctx), ErrCanceled) {
+			select {
+			case out <- provider.StreamDelta{Err: ErrCanceled}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// CallsTools reports whether the wrapped provider has native tool calling.
+func (p *queued) CallsTools() bool {
+	tc, ok := p.Provider.(provider.ToolCaller)
+	return ok && tc.CallsTools()
+}
+
+// Pull downloads a model with the wrapped provider.
+func (p *queued) Pull(ctx context.Context, model string, progress func(provider.PullProgress)) error {
+	puller, ok := p.Provider.(provider.Puller)
+	if !ok {
+		return fmt.Errorf("%s can't download models: %w", p.Name(), errors.ErrUnsupported)
+	}
+	return puller.Pull(ctx, model, progress)
+}
+
+// ShowModel describes a model of the wrapped provider.
+func (p *queued) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	md, ok := p.Provider.(provider.ModelDescriber)
+	if !ok {
+		return nil, fmt.Errorf("%s can't describe models: %w", p.Name(), errors.ErrUnsupported)
+	}
+	return md.ShowModel(ctx, model)
+}
+
+// Warm loads a model with the wrapped provider.
+func (p *queued) Warm(ctx context.Context, model string) error {
+	w, ok := p.Provider.(provider.Warmer)
+	if !ok {
+		return fmt.Errorf("%s doesn't load models ahead: %w", p.Name(), errors.ErrUnsupported)
+	}
+	return w.Warm(ctx, model)
+}
+
+// DeleteModel removes a model installed with the wrapped provider.
+func (p *queued) DeleteModel(ctx context.Context, model string) error {
+	mm, ok := p.Provider.(provider.ModelManager)
+	if !ok {
+		return fmt.Errorf("%s can't manage models: %w", p.Name(), errors.ErrUnsupported)
+	}
+	return mm.DeleteModel(ctx, model)
+}
+
+// embedderQueued is a queued provider that computes embeddings.
+type embedderQueued struct {
+	*queued
+	emb provider.Embedder
+}
+
+// Embed computes embeddings with the wrapped provider.
+func (p *embedderQueued) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	return p.emb.Embed(ctx, model, texts)
+}
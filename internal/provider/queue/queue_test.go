@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// waitForJobs waits until q has n requests running or waiting.
+func waitForJobs(t *testing.T, q *Queue, n int) []Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		jobs := q.Jobs()
+		if len(jobs) == n {
+			return jobs
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs = %+v, want %d", jobs, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// chatLater starts a chat request labeled label and returns its error once
+// it is done.
+func chatLater(p provider.Provider, label string) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.Chat(WithLabel(context.Background(), label), provider.ChatRequest{Model: "qwen3:8b"})
+		errc <- err
+	}()
+	return errc
+}
+
+func TestQueue_TakesTurns(t *testing.T) {
+	stream := make(chan provider.StreamDelta)
+	fake := providertest.New(providertest.Reply{Stream: stream}, providertest.Text("title"))
+	q := New(1)
+	p := q.Wrap(fake)
+
+	out, err := p.StreamChat(WithLabel(context.Background(), "chat"), provider.ChatRequest{Model: "qwen3:8b"})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	errc := chatLater(p, "title")
+	jobs := waitForJobs(t, q, 2)
+	if jobs[0].Label != "chat" || !jobs[0].Running() || jobs[1].Label != "title" || jobs[1].Running() {
+		t.Errorf("jobs = %+v, want the chat running and the title waiting", jobs)
+	}
+	if n := len(fake.Requests()); n != 1 {
+		t.Errorf("%d requests reached the provider while the stream ran, want 1", n)
+	}
+
+	stream <- provider.StreamDelta{Content: "Hi"}
+	if d := <-out; d.Content != "Hi" {
+		t.Errorf("delta = %+v", d)
+	}
+	close(stream)
+	for range out {
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Chat() error: %v", err)
+	}
+	waitForJobs(t, q, 0)
+}
+
+func TestQueue_Slots(t *testing.T) {
+	stream := make(chan provider.StreamDelta)
+	q := New(2)
+	p := q.Wrap(providertest.New(providertest.Reply{Stream: stream}, providertest.Reply{Stream: stream}))
+	for range 2 {
+		if _, err := p.StreamChat(context.Background(), provider.ChatRequest{}); err != nil {
+			t.Fatalf("StreamChat() error: %v", err)
+		}
+	}
+	for _, j := range waitForJobs(t, q, 2) {
+		if !j.Running() || j.Label != "request" {
+			t.Errorf("job = %+v, want it running", j)
+		}
+	}
+	close(stream)
+}
+
+func TestQueue_CancelWaiting(t *testing.T) {
+	stream := make(chan provider.StreamDelta)
+	q := New(1)
+	p := q.Wrap(providertest.New(providertest.Reply{Stream: stream}))
+	out, _ := p.StreamChat(context.Background(), provider.ChatRequest{})
+	errc := chatLater(p, "heartbeat")
+	jobs := waitForJobs(t, q, 2)
+
+	if !q.Cancel(jobs[1].ID) {
+		t.Fatal("Cancel() found no request")
+	}
+	if err := <-errc; !errors.Is(err, ErrCanceled) {
+		t.Errorf("Chat() error = %v, want ErrCanceled", err)
+	}
+	if q.Cancel(jobs[1].ID) {
+		t.Error("Cancel() of a canceled request succeeded")
+	}
+	close(stream)
+	for range out {
+	}
+}
+
+func TestQueue_CancelRunning(t *testing.T) {
+	q := New(1)
+	p := q.Wrap(providertest.New(providertest.Text("a long reply that keeps streaming")))
+	out, err := p.StreamChat(context.Background(), provider.ChatRequest{})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	<-out
+	q.Cancel(waitForJobs(t, q, 1)[0].ID)
+
+	var last provider.StreamDelta
+	for d := range out {
+		last = d
+	}
+	if !errors.Is(last.Err, ErrCanceled) {
+		t.Errorf("last delta = %+v, want ErrCanceled", last)
+	}
+	waitForJobs(t, q, 0)
+}
+
+func TestQueue_CallerGivesUp(t *testing.T) {
+	stream := make(chan provider.StreamDelta)
+	q := New(1)
+	p := q.Wrap(providertest.New(providertest.Reply{Stream: stream}))
+	out, _ := p.StreamChat(context.Background(), provider.ChatRequest{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Chat(ctx, provider.ChatRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Chat() error = %v, want the deadline", err)
+	}
+	waitForJobs(t, q, 1)
+	close(stream)
+	for range out {
+	}
+}
+
+func TestWrap_Interfaces(t *testing.T) {
+	p := New(1).Wrap(providertest.New())
+	if _, ok := p.(provider.Embedder); ok {
+		t.Error("wrapped provider computes embeddings its provider can't")
+	}
+	if err := p.(provider.Puller).Pull(context.Background(), "qwen3:8b", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Pull() = %v, want ErrUnsupported", err)
+	}
+	if p.Name() != "fake" {
+		t.Errorf("Name() = %q", p.Name())
+	}
+}
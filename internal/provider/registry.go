@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings configures a provider opened by name.
+type Settings struct {
+	BaseURL string
+	APIKey  string                       // the key or a secret reference, for hosted providers
+	Resolve func(string) (string, error) // looks up secret references; nil takes them as is
+
+	// For a local server behind an authenticating reverse proxy.
+	Username string            // for HTTP basic auth
+	Password string            // the password or a secret reference
+	Headers  map[string]string // sent with every request; values may be secret references
+	TLS      TLS
+
+	KeepAlive time.Duration // how long a server keeps a model loaded after a request; 0 for its default
+
+	// For providers that serve models as deployments they can't list, such
+	// as Azure OpenAI.
+	APIVersion string   // sent as the api-version query parameter
+	Models     []string // the deployments to offer as models
+
+	// For the mock provider.
+	Fixtures string        // directory of canned replies; empty for the built-in ones
+	Delay    time.Duration // between the chunks of a streamed reply
+}
+
+// TLS configures how a provider's certificate is checked.
+type TLS struct {
+	CAFile             string // PEM certificates to trust besides the system's
+	InsecureSkipVerify bool   // accept any certificate
+}
+
+// Factory creates a provider from its settings.
+type Factory func(Settings) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a provider available to Open under name. Provider packages
+// call it from init, so importing one is enough to enable it. It panics if
+// name is registered twice.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("provider: Register called twice for " + name)
+	}
+	registry[name] = f
+}
+
+// Open creates the provider registered under name.
+func Open(name string, s Settings) (Provider, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return f(s)
+}
+
+// Names lists the registered providers in alphabetical order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
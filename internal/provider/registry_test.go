@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type namedProvider struct {
+	Provider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+func TestRegistry_Open(t *testing.T) {
+	var got Settings
+	Register("test-open", func(s Settings) (Provider, error) {
+		got = s
+		return namedProvider{name: "test-open"}, nil
+	})
+
+	p, err := Open("test-open", Settings{BaseURL: "http://example.com"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if p.Name() != "test-open" || got.BaseURL != "http://example.com" {
+		t.Errorf("Open = %q with %+v", p.Name(), got)
+	}
+	if !slices.Contains(Names(), "test-open") {
+		t.Errorf("Names() = %v, want test-open in it", Names())
+	}
+}
+
+func TestRegistry_OpenUnknown(t *testing.T) {
+	_, err := Open("nope", Settings{})
+	if err == nil || !strings.Contains(err.Error(), `unknown provider "nope"`) {
+		t.Errorf("Open(nope) error = %v", err)
+	}
+}
+
+func TestRegistry_RegisterTwicePanics(t *testing.T) {
+	Register("test-twice", func(Settings) (Provider, error) { return nil, nil })
+	defer func() {
+		if recover() == nil {
+			t.Error("second Register didn't panic")
+		}
+	}()
+	Register("test-twice", func(Settings) (Provider, error) { return nil, nil })
+}
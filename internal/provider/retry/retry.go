@@ -0,0 +1,67 @@
+// Package retry repeats provider requests that failed for a reason that
+// may pass, such as Ollama restarting, waiting longer after each attempt.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Policy says how often a request is tried and how long to wait in
+// between.
+type Policy struct {
+	Attempts   int           // including the first; below 2 means no retries
+	Backoff    time.Duration // before the second attempt, doubled for each further one
+	MaxBackoff time.Duration // longest wait between attempts; unlimited if zero
+}
+
+// Transient reports whether err may go away when the request is repeated:
+// the server answered with a 5xx or 429 status, refused or dropped the
+// connection, or timed out.
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *provider.StatusError
+	if errors.As(err, &se) {
+		return se.Code >= 500 || se.Code == http.StatusTooManyRequests
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Do calls f until it succeeds, fails for good, ctx is done or the
+// attempts run out, and returns its last result.
+func Do[T any](ctx context.Context, p Policy, f func() (T, error)) (T, error) {
+	wait := p.Backoff
+	for attempt := 1; ; attempt++ {
+		v, err := f()
+		if err == nil || attempt >= p.Attempts || !Transient(err) {
+			return v, err
+		}
+		log.Warn("provider request failed, retrying", "attempt", attempt, "wait", wait, "err", err)
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return v, err
+		}
+		wait *= 2
+		if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+			wait = p.MaxBackoff
+		}
+	}
+}
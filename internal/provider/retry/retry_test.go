@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&provider.StatusError{Provider: "ollama", Code: 503}, true},
+		{&provider.StatusError{Provider: "openrouter", Code: 429}, true},
+		{&provider.StatusError{Provider: "ollama", Code: 404}, false},
+		{fmt.Errorf("ollama chat: %w", syscall.ECONNRESET), true},
+		{fmt.Errorf("ollama chat: %w", syscall.ECONNREFUSED), true},
+		{io.ErrUnexpectedEOF, true},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{errors.New("model not found"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Transient(tt.err); got != tt.want {
+			t.Errorf("Transient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	v, err := Do(context.Background(), Policy{Attempts: 3, Backoff: time.Millisecond}, func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", &provider.StatusError{Provider: "ollama", Code: 502}
+		}
+		return "ok", nil
+	})
+	if err != nil || v != "ok" {
+		t.Fatalf("Do = %q, %v", v, err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDo_GivesUp(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{"attempts run out", &provider.StatusError{Code: 500}, 2},
+		{"permanent error", &provider.StatusError{Code: 400}, 1},
+	} {
+		calls := 0
+		_, err := Do(context.Background(), Policy{Attempts: 2, Backoff: time.Millisecond}, func() (int, error) {
+			calls++
+			return 0, tt.err
+		})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, calls, tt.calls)
+		}
+	}
+}
+
+func TestDo_StopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	_, err := Do(ctx, Policy{Attempts: 5, Backoff: time.Hour}, func() (int, error) {
+		calls++
+		cancel()
+		return 0, syscall.ECONNREFUSED
+	})
+	if !errors.Is(err, syscall.ECONNREFUSED) || calls != 1 {
+		t.Errorf("Do = %v after %d calls", err, calls)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Do waited out the backoff despite the canceled context")
+	}
+}
@@ -0,0 +1,158 @@
+// Package vllm talks to vLLM's OpenAI-compatible server (vllm serve), as
+// run on homelab and workstation GPUs, through /v1/chat/completions,
+// /v1/models and /v1/embeddings.
+package vllm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/openaicompat"
+)
+
+// DefaultBaseURL is where vllm serve listens by default.
+const DefaultBaseURL = "http://127.0.0.1:8000"
+
+// VLLMProvider implements the Provider interface for vLLM.
+type VLLMProvider struct {
+	api *openaicompat.Client
+
+	apiKey  string
+	resolve func(string) (string, error)
+
+	keyOnce sync.Once
+	key     string
+	keyErr  error
+}
+
+func init() {
+	provider.Register("vllm", func(s provider.Settings) (provider.Provider, error) {
+		return New(s.BaseURL, s.APIKey, s.Resolve), nil
+	})
+}
+
+// New creates a new VLLMProvider. apiKey is the key vllm serve was started
+// with (--api-key), a secret reference looked up with resolve on first use,
+// or empty for a server without one; nil resolve takes it as is.
+func New(baseURL, apiKey string, resolve func(string) (string, error)) *VLLMProvider {
+	if resolve == nil {
+		resolve = func(v string) (string, error) { return v, nil }
+	}
+	v := &VLLMProvider{apiKey: apiKey, resolve: resolve}
+	v.api = &openaicompat.Client{
+		Name:    "vllm",
+		BaseURL: strings.TrimSuffix(baseURL, "/") + "/v1",
+		Auth:    v.authorize,
+		Prepare: prepare,
+		HTTP:    &http.Client{},
+	}
+	return v
+}
+
+func (v *VLLMProvider) Name() string {
+	return "vllm"
+}
+
+// authorize adds the API key, if any, to a request.
+func (v *VLLMProvider) authorize(r *http.Request) error {
+	if v.apiKey == "" {
+		return nil
+	}
+	v.keyOnce.Do(func() {
+		v.key, v.keyErr = v.resolve(v.apiKey)
+	})
+	if v.keyErr != nil {
+		return fmt.Errorf("vllm api key: %w", v.keyErr)
+	}
+	r.Header.Set("Authorization", "Bearer "+strings.TrimSpace(v.key))
+	return nil
+}
+
+// prepare adapts a chat request to vLLM, which ignores repeat_penalty and
+// takes repetition_penalty instead.
+func prepare(req *openaicompat.ChatRequest) {
+	req.RepetitionPenalty, req.RepeatPenalty = req.RepeatPenalty, nil
+}
+
+// Chat sends a non-streaming chat request.
+func (v *VLLMProvider) Chat(ctx context.Context, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	return v.api.Chat(ctx, req)
+}
+
+// StreamChat sends a streaming chat request and returns a channel of deltas.
+func (v *VLLMProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	return v.api.StreamChat(ctx, req)
+}
+
+// modelsResponse is the response from /v1/models. vLLM lists the model it
+// serves and its LoRA adapters, whose parent is the base model.
+type modelsResponse struct {
+	Data []struct {
+		ID          string  `json:"id"`
+		Root        string  `json:"root"` // where the weights came from, e.g. a Hugging Face repo
+		Parent      *string `json:"parent"`
+		MaxModelLen int     `json:"max_model_len"`
+	} `json:"data"`
+}
+
+func (v *VLLMProvider) models(ctx context.Context) (modelsResponse, error) {
+	var resp modelsResponse
+	if err := v.api.Get(ctx, "/models", &resp); err != nil {
+		return resp, fmt.Errorf("listing models: %w", err)
+	}
+	return resp, nil
+}
+
+// ListModels returns the models vLLM serves, each with the context length
+// it was started with.
+func (v *VLLMProvider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	resp, err := v.models(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]provider.ModelInfo, len(resp.Data))
+	for i, m := range resp.Data {
+		models[i] = provider.ModelInfo{Name: m.ID, ContextLength: m.MaxModelLen}
+		if m.Root != "" && m.Root != m.ID {
+			models[i].DisplayName = m.Root
+		}
+	}
+	return models, nil
+}
+
+// ShowModel describes a model vLLM serves. vLLM only tells its context
+// length, which caps the context stefanclaw fits conversations into.
+func (v *VLLMProvider) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	resp, err := v.models(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var served []string
+	for _, m := range resp.Data {
+		if m.ID == model {
+			return &provider.ModelDetails{ContextLength: m.MaxModelLen}, nil
+		}
+		served = append(served, m.ID)
+	}
+	return nil, fmt.Errorf("vllm doesn't serve %s (it serves %s)", model, strings.Join(served, ", "))
+}
+
+// Embed returns one embedding per text, computed by model, which vLLM must
+// have been started with (e.g. vllm serve BAAI/bge-m3).
+func (v *VLLMProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	return v.api.Embed(ctx, model, texts)
+}
+
+// IsAvailable checks that vLLM's server is reachable and accepts the API
+// key.
+func (v *VLLMProvider) IsAvailable(ctx context.Context) error {
+	var resp modelsResponse
+	if err := v.api.Get(ctx, "/models", &resp); err != nil {
+		return fmt.Errorf("vllm is not reachable at %s: %w", strings.TrimSuffix(v.api.BaseURL, "/v1"), err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// modelsJSON is what vllm serve Qwen/Qwen3-8B --served-model-name qwen3-8b
+// --max-model-len 16384 --enable-lora reports.
+const modelsJSON = `{"object":"list","data":[
+{"id":"qwen3-8b","object":"model","created":1760000000,"owned_by":"vllm","root":"Qwen/Qwen3-8B","parent":null,"max_model_len":16384,"permission":[]},
+{"id":"sql-lora","object":"model","created":1760000000,"owned_by":"vllm","root":"/adapters/sql","parent":"qwen3-8b","max_model_len":16384,"permission":[]}]}`
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("path = %s, want /v1/models", r.URL.Path)
+		}
+		fmt.Fprint(w, modelsJSON)
+	}))
+	defer srv.Close()
+
+	models, err := New(srv.URL, "", nil).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if len(models) != 2 || models[0].Name != "qwen3-8b" || models[0].ContextLength != 16384 || models[0].DisplayName != "Qwen/Qwen3-8B" {
+		t.Errorf("models = %+v", models)
+	}
+
+	d, err := New(srv.URL, "", nil).ShowModel(context.Background(), "qwen3-8b")
+	if err != nil || d.ContextLength != 16384 || d.Capabilities != nil {
+		t.Errorf("ShowModel = %+v, %v", d, err)
+	}
+	if _, err := New(srv.URL, "", nil).ShowModel(context.Background(), "llama3"); err == nil || !strings.Contains(err.Error(), "it serves qwen3-8b, sql-lora") {
+		t.Errorf("ShowModel of a model not served = %v", err)
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	penalty := 1.1
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		// vLLM opens with an empty assistant delta, sends the reasoning of
+		// a model run with --reasoning-parser apart, and the usage in a
+		// chunk without choices.
+		for _, chunk := range []string{
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[{"index":0,"delta":{"role":"assistant","content":""},"logprobs":null,"finish_reason":null}]}`,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[{"index":0,"delta":{"reasoning_content":"Short answer."},"logprobs":null,"finish_reason":null}]}`,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[{"index":0,"delta":{"content":"Hi"},"logprobs":null,"finish_reason":null}]}`,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[{"index":0,"delta":{"content":"!"},"logprobs":null,"finish_reason":"stop","stop_reason":null}]}`,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[],"usage":{"prompt_tokens":10,"total_tokens":15,"completion_tokens":5,"prompt_tokens_details":null}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	resolve := func(ref string) (string, error) { return "key-for-" + ref, nil }
+	ch, err := New(srv.URL, "keyring:vllm", resolve).StreamChat(context.Background(), provider.ChatRequest{
+		Model:    "qwen3-8b",
+		Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+		Options:  provider.Options{RepeatPenalty: &penalty},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var content string
+	var usage *provider.Usage
+	for d := range ch {
+		if d.Err != nil {
+			t.Fatal(d.Err)
+		}
+		content += d.Content
+		if d.Done {
+			usage = d.Usage
+		}
+	}
+	if content != "<think>Short answer.</think>Hi!" {
+		t.Errorf("content = %q", content)
+	}
+	if usage == nil || usage.PromptTokens != 10 || usage.CompletionTokens != 5 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if auth != "Bearer key-for-keyring:vllm" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got["repetition_penalty"] != 1.1 || got["repeat_penalty"] != nil {
+		t.Errorf("request = %v, want repetition_penalty instead of repeat_penalty", got)
+	}
+	if opts, _ := got["stream_options"].(map[string]any); opts["include_usage"] != true {
+		t.Errorf("stream_options = %v", got["stream_options"])
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"Unauthorized"}`)
+			return
+		}
+		fmt.Fprint(w, modelsJSON)
+	}))
+	defer srv.Close()
+
+	if err := New(srv.URL, "secret", nil).IsAvailable(context.Background()); err != nil {
+		t.Errorf("IsAvailable() = %v", err)
+	}
+	err := New(srv.URL, "", nil).IsAvailable(context.Background())
+	var se *provider.StatusError
+	if err == nil || !errors.As(err, &se) || se.Code != http.StatusUnauthorized {
+		t.Errorf("IsAvailable() without the key = %v, want a 401", err)
+	}
+}
+
+func TestStreamChat_ErrorMidStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// vLLM reports a failure after the stream started as a chunk of
+		// its own, e.g. when the prompt and reply outgrow max_model_len.
+		fmt.Fprint(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"qwen3-8b","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"error":{"object":"error","message":"This model's maximum context length is 16384 tokens.","type":"BadRequestError","param":null,"code":400}}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := New(srv.URL, "", nil).StreamChat(context.Background(), provider.ChatRequest{
+		Model:    "qwen3-8b",
+		Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	var last provider.StreamDelta
+	for d := range ch {
+		last = d
+	}
+	if last.Err == nil || !strings.Contains(last.Err.Error(), "maximum context length is 16384") {
+		t.Errorf("last delta = %+v, want the error", last)
+	}
+}
@@ -0,0 +1,83 @@
+// Package proxy sends outbound HTTP requests through the proxy set in
+// config.yaml, or the one in HTTPS_PROXY and HTTP_PROXY, for users who can
+// only reach remote endpoints that way.
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Func returns the proxy for a request to a URL, or nil to connect
+// directly.
+type Func func(*url.URL) (*url.URL, error)
+
+var (
+	current atomic.Pointer[Func]
+	install sync.Once
+)
+
+// Set routes the requests of http.DefaultTransport, which every HTTP client
+// in stefanclaw uses, through the proxy at rawURL, except for the hosts in
+// noProxy. See New for what they may be.
+func Set(rawURL, noProxy string) error {
+	fn, err := New(rawURL, noProxy)
+	if err != nil {
+		return err
+	}
+	current.Store(&fn)
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("proxy: default transport is a %T", http.DefaultTransport)
+	}
+	install.Do(func() {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return (*current.Load())(req.URL)
+		}
+	})
+	transport.CloseIdleConnections() // connected through the previous proxy
+	return nil
+}
+
+// New returns the proxy function for rawURL, an http://, https:// or
+// socks5:// URL, optionally with a user and password. If rawURL is empty,
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment apply. noProxy
+// lists hosts to connect to directly, comma-separated, such as
+// "example.com,.corp.example.com,10.0.0.0/8"; it replaces NO_PROXY.
+// Requests to localhost and loopback addresses never go through a proxy,
+// so a local Ollama or LM Studio keeps working.
+func New(rawURL, noProxy string) (Func, error) {
+	cfg := httpproxy.FromEnvironment()
+	if rawURL != "" {
+		if err := Validate(rawURL); err != nil {
+			return nil, err
+		}
+		cfg.HTTPProxy, cfg.HTTPSProxy = rawURL, rawURL
+	}
+	if noProxy != "" {
+		cfg.NoProxy = noProxy
+	}
+	return cfg.ProxyFunc(), nil
+}
+
+// Validate checks that rawURL names a proxy that can be used.
+func Validate(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL %q has no host", rawURL)
+	}
+	return nil
+}
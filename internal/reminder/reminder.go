@@ -0,0 +1,127 @@
+// Package reminder stores reminders and scheduled prompts and reports when
+// they are due.
+package reminder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Kind distinguishes plain reminders from scheduled prompts.
+type Kind string
+
+const (
+	// KindReminder shows the text to the user when due.
+	KindReminder Kind = "reminder"
+	// KindTask sends the text to the model as a prompt when due.
+	KindTask Kind = "task"
+)
+
+// Reminder is a pending reminder or scheduled task.
+type Reminder struct {
+	ID      int       `json:"id"`
+	Kind    Kind      `json:"kind"`
+	Text    string    `json:"text"`
+	Due     time.Time `json:"due"`
+	Created time.Time `json:"created"`
+}
+
+// Store persists reminders in a JSON file.
+type Store struct {
+	path string
+}
+
+// NewStore creates a store backed by the JSON file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns all pending reminders, earliest first.
+func (s *Store) List() ([]Reminder, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Reminder
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Due.Before(list[j].Due) })
+	return list, nil
+}
+
+// Add saves a new reminder and returns it with its ID assigned.
+func (s *Store) Add(kind Kind, text string, due time.Time) (Reminder, error) {
+	list, err := s.List()
+	if err != nil {
+		return Reminder{}, err
+	}
+	r := Reminder{ID: 1, Kind: kind, Text: text, Due: due, Created: time.Now()}
+	for _, other := range list {
+		if other.ID >= r.ID {
+			r.ID = other.ID + 1
+		}
+	}
+	return r, s.save(append(list, r))
+}
+
+// Remove deletes the reminder with the given ID and reports whether it existed.
+func (s *Store) Remove(id int) (bool, error) {
+	list, err := s.List()
+	if err != nil {
+		return false, err
+	}
+	for i, r := range list {
+		if r.ID == id {
+			return true, s.save(append(list[:i], list[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// TakeDue removes and returns the reminders due at or before now.
+func (s *Store) TakeDue(now time.Time) ([]Reminder, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var due, pending []Reminder
+	for _, r := range list {
+		if r.Due.After(now) {
+			pending = append(pending, r)
+		} else {
+			due = append(due, r)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	return due, s.save(pending)
+}
+
+// save writes list atomically.
+func (s *Store) save(list []Reminder) error {
+	if list == nil {
+		list = []Reminder{}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
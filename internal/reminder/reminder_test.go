@@ -0,0 +1,89 @@
+package reminder
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+		rest string
+	}{
+		{"in 2h to stretch", now.Add(2 * time.Hour), "to stretch"},
+		{"in 90 minutes check oven", now.Add(90 * time.Minute), "check oven"},
+		{"in 1 hour and 30 min tea", now.Add(90 * time.Minute), "tea"},
+		{"In 3 Days Call Mom", now.Add(72 * time.Hour), "Call Mom"},
+		{"at 17:30 leave", time.Date(2026, 3, 10, 17, 30, 0, 0, time.Local), "leave"},
+		{"at 9 standup", time.Date(2026, 3, 11, 9, 0, 0, 0, time.Local), "standup"},
+		{"at 5pm gym", time.Date(2026, 3, 10, 17, 0, 0, 0, time.Local), "gym"},
+		{"at 12am backup", time.Date(2026, 3, 11, 0, 0, 0, 0, time.Local), "backup"},
+		{"tomorrow water plants", time.Date(2026, 3, 11, 9, 0, 0, 0, time.Local), "water plants"},
+		{"tomorrow at 8:15 train", time.Date(2026, 3, 11, 8, 15, 0, 0, time.Local), "train"},
+		{"on 2026-04-01 taxes", time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local), "taxes"},
+		{"on 2026-04-01 at 14:00 dentist", time.Date(2026, 4, 1, 14, 0, 0, 0, time.Local), "dentist"},
+	}
+	for _, tt := range tests {
+		got, rest, err := ParseWhen(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseWhen(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || rest != tt.rest {
+			t.Errorf("ParseWhen(%q) = %v, %q; want %v, %q", tt.in, got, rest, tt.want, tt.rest)
+		}
+	}
+}
+
+func TestParseWhen_Errors(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)
+	for _, in := range []string{
+		"stretch", "in 2 fortnights x", "in 0h x", "at 25:00 x", "at 13pm x",
+		"on 2026-02-30 x", "on 2026-03-01 x",
+	} {
+		if _, _, err := ParseWhen(in, now); err == nil {
+			t.Errorf("ParseWhen(%q) should fail", in)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "data", "reminders.json"))
+	now := time.Now()
+
+	if list, err := s.List(); err != nil || list != nil {
+		t.Fatalf("List() on missing file = %v, %v", list, err)
+	}
+	late, _ := s.Add(KindReminder, "later", now.Add(time.Hour))
+	soon, _ := s.Add(KindTask, "soon", now.Add(time.Minute))
+	if late.ID != 1 || soon.ID != 2 {
+		t.Errorf("IDs = %d, %d; want 1, 2", late.ID, soon.ID)
+	}
+
+	list, _ := s.List()
+	if len(list) != 2 || list[0].Text != "soon" {
+		t.Errorf("List() = %+v, want earliest first", list)
+	}
+
+	due, err := s.TakeDue(now.Add(30 * time.Minute))
+	if err != nil || len(due) != 1 || due[0].Kind != KindTask {
+		t.Fatalf("TakeDue() = %+v, %v", due, err)
+	}
+	if list, _ := s.List(); len(list) != 1 || list[0].ID != 1 {
+		t.Errorf("due reminder not removed: %+v", list)
+	}
+
+	if ok, _ := s.Remove(1); !ok {
+		t.Error("Remove(1) should find the reminder")
+	}
+	if ok, _ := s.Remove(1); ok {
+		t.Error("Remove(1) twice should report false")
+	}
+	r, _ := s.Add(KindReminder, "again", now)
+	if r.ID != 1 {
+		t.Errorf("ID after emptying = %d, want 1", r.ID)
+	}
+}
@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHour is used for "tomorrow" and dates given without a time.
+const defaultHour = 9
+
+var (
+	inRe   = regexp.MustCompile(`^in\s+(\d+)\s*([a-z]+)(?:\s+(?:and\s+)?(\d+)\s*([a-z]+))?\b`)
+	atRe   = regexp.MustCompile(`^at\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\b`)
+	dateRe = regexp.MustCompile(`^on\s+(\d{4}-\d{2}-\d{2})\b`)
+)
+
+var units = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// ParseWhen reads a time expression at the start of s and returns the time
+// it refers to and the remaining text. Supported forms:
+//
+//	in 2h, in 90 minutes, in 1 hour 30 minutes, in 3 days
+//	at 17:30, at 5pm            (today, or tomorrow if already past)
+//	tomorrow, tomorrow at 8:15
+//	on 2026-03-01, on 2026-03-01 at 14:00
+func ParseWhen(s string, now time.Time) (when time.Time, rest string, err error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+
+	if m := inRe.FindStringSubmatch(lower); m != nil {
+		d, err := duration(m[1], m[2])
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		if m[3] != "" {
+			d2, err := duration(m[3], m[4])
+			if err != nil {
+				return time.Time{}, "", err
+			}
+			d += d2
+		}
+		return now.Add(d), strings.TrimSpace(s[len(m[0]):]), nil
+	}
+
+	var day time.Time
+	explicitDay := false
+	switch {
+	case strings.HasPrefix(lower, "tomorrow"):
+		day = now.AddDate(0, 0, 1)
+		explicitDay = true
+		s, lower = strings.TrimSpace(s[len("tomorrow"):]), strings.TrimSpace(lower[len("tomorrow"):])
+	case dateRe.MatchString(lower):
+		m := dateRe.FindStringSubmatch(lower)
+		d, err := time.ParseInLocation("2006-01-02", m[1], now.Location())
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid date %q", m[1])
+		}
+		day = d
+		explicitDay = true
+		s, lower = strings.TrimSpace(s[len(m[0]):]), strings.TrimSpace(lower[len(m[0]):])
+	default:
+		day = now
+	}
+
+	hour, minute := defaultHour, 0
+	if m := atRe.FindStringSubmatch(lower); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		if hour > 23 || minute > 59 || (m[3] != "" && (hour < 1 || hour > 12)) {
+			return time.Time{}, "", fmt.Errorf("invalid time %q", strings.TrimSpace(m[0][2:]))
+		}
+		switch {
+		case m[3] == "pm" && hour < 12:
+			hour += 12
+		case m[3] == "am" && hour == 12:
+			hour = 0
+		}
+		s = strings.TrimSpace(s[len(m[0]):])
+	} else if !explicitDay {
+		return time.Time{}, "", fmt.Errorf(`unrecognized time; use e.g. "in 2h", "at 17:30", "tomorrow at 9" or "on 2026-03-01"`)
+	}
+
+	when = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+	if !explicitDay && !when.After(now) {
+		when = when.AddDate(0, 0, 1)
+	}
+	if !when.After(now) {
+		return time.Time{}, "", fmt.Errorf("%s is in the past", when.Format("2006-01-02 15:04"))
+	}
+	return when, s, nil
+}
+
+func duration(n, unit string) (time.Duration, error) {
+	u, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown time unit %q", unit)
+	}
+	v, _ := strconv.Atoi(n)
+	if v <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return time.Duration(v) * u, nil
+}
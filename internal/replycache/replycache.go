@@ -0,0 +1,130 @@
+// Package replycache keeps model replies on disk for a while, so that a
+// request identical to an earlier one, as scripts in shell prompts or cron
+// jobs send over and over, is answered at once without asking the model.
+package replycache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Cache stores replies as one file per request in a directory. Entries
+// older than its TTL are ignored and removed. Several processes may share
+// a directory.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// entry is a cached reply, stored as JSON.
+type entry struct {
+	Model   string    `json:"model"`
+	Reply   string    `json:"reply"`
+	Created time.Time `json:"created"`
+}
+
+// New creates a Cache in dir keeping replies for ttl.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Key identifies req to the named provider by everything that shapes the
+// reply: the model, the messages including the system prompt and any
+// images, the sampling options and the reply format.
+func Key(providerName string, req provider.ChatRequest) string {
+	data, _ := json.Marshal(struct {
+		Provider string             `json:"provider"`
+		Model    string             `json:"model"`
+		Messages []provider.Message `json:"messages"`
+		Options  provider.Options   `json:"options"`
+		Format   *provider.Format   `json:"format,omitempty"`
+	}{providerName, req.Model, req.Messages, req.Options, req.Format})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the reply cached under key, if there is one that hasn't
+// expired.
+func (c *Cache) Get(key string) (string, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+	var e entry
+	if json.Unmarshal(data, &e) != nil {
+		return "", false
+	}
+	if c.expired(e) {
+		os.Remove(c.path(key))
+		return "", false
+	}
+	return e.Reply, true
+}
+
+func (c *Cache) expired(e entry) bool {
+	return c.now().Sub(e.Created) >= c.ttl
+}
+
+// Put caches reply, from model, under key and removes expired entries.
+// The file is replaced in one step, so a concurrent Get never reads half
+// of it.
+func (c *Cache) Put(key, model, reply string) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Model: model, Reply: reply, Created: c.now()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.prune()
+	return nil
+}
+
+// prune removes expired entries.
+func (c *Cache) prune() {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		key, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(c.path(key))
+		if err != nil {
+			continue
+		}
+		var e entry
+		if json.Unmarshal(data, &e) != nil || c.expired(e) {
+			os.Remove(c.path(key))
+		}
+	}
+}
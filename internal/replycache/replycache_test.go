@@ -0,0 +1,89 @@
+package replycache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func request(question string) provider.ChatRequest {
+	return provider.ChatRequest{Model: "qwen3:8b", Messages: []provider.Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: question},
+	}}
+}
+
+func TestKey(t *testing.T) {
+	base := Key("ollama", request("What is 2+2?"))
+	if Key("ollama", request("What is 2+2?")) != base {
+		t.Error("the same request got different keys")
+	}
+
+	temp := 0.2
+	other := request("What is 2+2?")
+	other.Options.Temperature = &temp
+	otherModel := request("What is 2+2?")
+	otherModel.Model = "llama3.1:8b"
+	otherSystem := request("What is 2+2?")
+	otherSystem.Messages[0].Content = "You are terse."
+	withFormat := request("What is 2+2?")
+	withFormat.Format = &provider.Format{}
+	for name, req := range map[string]provider.ChatRequest{
+		"question": request("What is 3+3?"),
+		"options":  other,
+		"model":    otherModel,
+		"system":   otherSystem,
+		"format":   withFormat,
+	} {
+		if Key("ollama", req) == base {
+			t.Errorf("a different %s got the same key", name)
+		}
+	}
+	if Key("mock", request("What is 2+2?")) == base {
+		t.Error("a different provider got the same key")
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	c := New(t.TempDir(), time.Hour)
+	c.now = func() time.Time { return now }
+	key := Key("ollama", request("What is 2+2?"))
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("hit in an empty cache")
+	}
+	if err := c.Put(key, "qwen3:8b", "4"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if reply, ok := c.Get(key); !ok || reply != "4" {
+		t.Errorf("Get() = %q, %v", reply, ok)
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok := c.Get(key); ok {
+		t.Error("hit after the TTL")
+	}
+	if _, err := os.Stat(c.path(key)); !os.IsNotExist(err) {
+		t.Error("expired entry left behind")
+	}
+}
+
+func TestCache_PrunesExpired(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	c := New(dir, time.Minute)
+	c.now = func() time.Time { return now }
+	old := Key("ollama", request("old"))
+	c.Put(old, "qwen3:8b", "old reply")
+
+	now = now.Add(2 * time.Minute)
+	c.Put(Key("ollama", request("new")), "qwen3:8b", "new reply")
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || files[0] != c.path(Key("ollama", request("new"))) {
+		t.Errorf("files = %v, want only the new entry", files)
+	}
+}
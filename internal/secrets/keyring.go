@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service is the keyring service name secrets are stored under.
+const service = "stefanclaw"
+
+// commandRunner runs a keyring CLI; replaced in tests.
+var commandRunner = func(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// lookPath reports whether a command is installed; replaced in tests.
+var lookPath = func(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// osKeyring returns the keyring backend for this platform, or nil when no
+// supported keyring tool is installed. macOS uses the login keychain via
+// security(1); Linux uses the Secret Service via secret-tool(1).
+func osKeyring() backend {
+	switch runtime.GOOS {
+	case "darwin":
+		if lookPath("security") {
+			return macKeychain{}
+		}
+	case "linux", "freebsd", "openbsd":
+		if lookPath("secret-tool") {
+			return secretService{}
+		}
+	}
+	return nil
+}
+
+// macKeychain stores secrets as generic passwords in the login keychain.
+type macKeychain struct{}
+
+func (macKeychain) Name() string { return "macOS keychain" }
+
+func (macKeychain) Get(name string) (string, error) {
+	out, err := commandRunner("", "security", "find-generic-password", "-s", service, "-a", name, "-w")
+	if err != nil {
+		// security exits 44 when the item does not exist
+		if strings.Contains(err.Error(), "could not be found") || strings.Contains(err.Error(), "exit status 44") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+func (macKeychain) Set(name, value string) error {
+	_, err := commandRunner("", "security", "add-generic-password", "-U", "-s", service, "-a", name, "-w", value)
+	return err
+}
+
+func (k macKeychain) Delete(name string) error {
+	if _, err := k.Get(name); err != nil {
+		return err
+	}
+	_, err := commandRunner("", "security", "delete-generic-password", "-s", service, "-a", name)
+	return err
+}
+
+// secretService stores secrets through the freedesktop Secret Service
+// (GNOME Keyring, KWallet).
+type secretService struct{}
+
+func (secretService) Name() string { return "Secret Service keyring" }
+
+func (secretService) Get(name string) (string, error) {
+	out, err := commandRunner("", "secret-tool", "lookup", "service", service, "account", name)
+	if err != nil {
+		// secret-tool exits 1 without output when nothing matches
+		if strings.HasSuffix(err.Error(), "exit status 1") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+func (secretService) Set(name, value string) error {
+	_, err := commandRunner(value, "secret-tool", "store", "--label", service+": "+name, "service", service, "account", name)
+	return err
+}
+
+func (s secretService) Delete(name string) error {
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	_, err := commandRunner("", "secret-tool", "clear", "service", service, "account", name)
+	return err
+}
@@ -0,0 +1,191 @@
+// Package secrets stores API keys and other credentials outside config.yaml.
+// Secrets live in the OS keyring when one is available, with a permission
+// restricted file as fallback. Config values reference them as
+// "keyring:<name>".
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RefPrefix marks a config value as a reference to a stored secret.
+const RefPrefix = "keyring:"
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// backend is a place secrets can be kept.
+type backend interface {
+	Name() string
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// Store reads and writes secrets, preferring the OS keyring and falling back
+// to a file.
+type Store struct {
+	backends []backend
+}
+
+// New creates a Store that uses the OS keyring if available and the file at
+// path otherwise.
+func New(path string) *Store {
+	var backends []backend
+	if kr := osKeyring(); kr != nil {
+		backends = append(backends, kr)
+	}
+	backends = append(backends, &fileBackend{path: path})
+	return &Store{backends: backends}
+}
+
+// NewFileStore creates a Store backed only by the file at path.
+func NewFileStore(path string) *Store {
+	return &Store{backends: []backend{&fileBackend{path: path}}}
+}
+
+// Backend returns the name of the backend new secrets are written to.
+func (s *Store) Backend() string {
+	return s.backends[0].Name()
+}
+
+// Get returns the named secret from the first backend that has it.
+func (s *Store) Get(name string) (string, error) {
+	for _, b := range s.backends {
+		v, err := b.Get(name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("%s: %w", b.Name(), err)
+		}
+	}
+	return "", fmt.Errorf("%q: %w", name, ErrNotFound)
+}
+
+// Set stores a secret in the preferred backend, falling back to the next one
+// if it fails (e.g. no keyring daemon is running).
+func (s *Store) Set(name, value string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	var errs []error
+	for _, b := range s.backends {
+		if err := b.Set(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+			continue
+		}
+		return b.Name(), nil
+	}
+	return "", errors.Join(errs...)
+}
+
+// Delete removes the named secret from every backend.
+func (s *Store) Delete(name string) error {
+	found := false
+	for _, b := range s.backends {
+		err := b.Delete(name)
+		switch {
+		case err == nil:
+			found = true
+		case !errors.Is(err, ErrNotFound):
+			return fmt.Errorf("%s: %w", b.Name(), err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%q: %w", name, ErrNotFound)
+	}
+	return nil
+}
+
+// Resolve returns value unchanged unless it is a "keyring:<name>" reference,
+// in which case the referenced secret is looked up.
+func (s *Store) Resolve(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, RefPrefix)
+	if !ok {
+		return value, nil
+	}
+	return s.Get(name)
+}
+
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n/\\") {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '-', '_' or '.'", name)
+	}
+	return nil
+}
+
+// fileBackend keeps secrets in a JSON file readable only by the owner.
+type fileBackend struct {
+	path string
+}
+
+func (f *fileBackend) Name() string { return "file " + f.path }
+
+func (f *fileBackend) load() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]string{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", f.path, err)
+	}
+	return m, nil
+}
+
+func (f *fileBackend) save(m map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func (f *fileBackend) Get(name string) (string, error) {
+	m, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (f *fileBackend) Set(name, value string) error {
+	m, err := f.load()
+	if err != nil {
+		return err
+	}
+	m[name] = value
+	return f.save(m)
+}
+
+func (f *fileBackend) Delete(name string) error {
+	m, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := m[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m, name)
+	return f.save(m)
+}
@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore_SetGetDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	s := NewFileStore(path)
+
+	if _, err := s.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on empty store error = %v, want ErrNotFound", err)
+	}
+
+	where, err := s.Set("openai", "sk-test")
+	if err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if !strings.HasPrefix(where, "file ") {
+		t.Errorf("Set() stored in %q, want file backend", where)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("secrets file mode = %o, want 600", perm)
+	}
+
+	if v, err := s.Get("openai"); err != nil || v != "sk-test" {
+		t.Errorf("Get() = %q, %v; want sk-test", v, err)
+	}
+
+	if err := s.Delete("openai"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if err := s.Delete("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "secrets.json"))
+	s.Set("openrouter", "sk-or")
+
+	if v, err := s.Resolve("plain-value"); err != nil || v != "plain-value" {
+		t.Errorf("Resolve(plain) = %q, %v", v, err)
+	}
+	if v, err := s.Resolve("keyring:openrouter"); err != nil || v != "sk-or" {
+		t.Errorf("Resolve(ref) = %q, %v; want sk-or", v, err)
+	}
+	if _, err := s.Resolve("keyring:missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSet_InvalidName(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "secrets.json"))
+	if _, err := s.Set("has space", "v"); err == nil {
+		t.Error("expected error for name with a space")
+	}
+}
+
+// failingBackend simulates a keyring whose daemon is not running.
+type failingBackend struct{}
+
+func (failingBackend) Name() string               { return "broken keyring" }
+func (failingBackend) Get(string) (string, error) { return "", ErrNotFound }
+func (failingBackend) Set(string, string) error   { return errors.New("no daemon") }
+func (failingBackend) Delete(string) error        { return ErrNotFound }
+
+func TestStore_FallsBackToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	s := &Store{backends: []backend{failingBackend{}, &fileBackend{path: path}}}
+
+	where, err := s.Set("openai", "sk-test")
+	if err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if !strings.HasPrefix(where, "file ") {
+		t.Errorf("Set() stored in %q, want file fallback", where)
+	}
+	if v, err := s.Get("openai"); err != nil || v != "sk-test" {
+		t.Errorf("Get() = %q, %v; want value from file", v, err)
+	}
+}
+
+func TestSecretService_Commands(t *testing.T) {
+	var calls []string
+	stored := map[string]string{}
+	orig := commandRunner
+	defer func() { commandRunner = orig }()
+	commandRunner = func(stdin, name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		account := args[len(args)-1]
+		switch args[0] {
+		case "store":
+			stored[account] = stdin
+		case "lookup":
+			v, ok := stored[account]
+			if !ok {
+				return "", errors.New("secret-tool: exit status 1")
+			}
+			return v + "\n", nil
+		case "clear":
+			delete(stored, account)
+		}
+		return "", nil
+	}
+
+	kr := secretService{}
+	if err := kr.Set("openai", "sk-test"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if v, err := kr.Get("openai"); err != nil || v != "sk-test" {
+		t.Errorf("Get() = %q, %v; want sk-test", v, err)
+	}
+	if err := kr.Delete("openai"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := kr.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+	if !strings.Contains(calls[0], "service stefanclaw account openai") {
+		t.Errorf("unexpected secret-tool call: %q", calls[0])
+	}
+	// The value goes through stdin, never the command line
+	for _, c := range calls {
+		if strings.Contains(c, "sk-test") {
+			t.Errorf("secret leaked into arguments: %q", c)
+		}
+	}
+}
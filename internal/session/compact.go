@@ -5,16 +5,20 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/stefanclaw/stefanclaw/internal/log"
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
 )
 
 const compactPrompt = `Summarize this conversation concisely. Capture key topics discussed, decisions made, and important context. Write in third person, past tense. Keep it under 200 words.`
 
-// EstimateTokens approximates token count as chars/4.
+// EstimateTokens counts the tokens of the messages' content. Chat template
+// markers around each message are not included.
 func EstimateTokens(messages []provider.Message) int {
 	total := 0
 	for _, m := range messages {
-		total += len(m.Content) / 4
+		total += tokens.Count(m.Content)
 	}
 	return total
 }
@@ -65,10 +69,11 @@ func Compact(ctx context.Context, p provider.Provider, model string, messages []
 		},
 	})
 	if err != nil {
+		log.Warn("compaction failed", "model", model, "err", err)
 		return nil, messages, fmt.Errorf("compacting conversation: %w", err)
 	}
 
-	summary := resp.Message.Content
+	summary := think.Strip(resp.Message.Content)
 
 	// Build new message list: summary + recent
 	compacted := make([]provider.Message, 0, 1+len(recentMessages))
@@ -84,6 +89,7 @@ func Compact(ctx context.Context, p provider.Provider, model string, messages []
 		RemainingCount:  len(compacted),
 		CompactedTokens: EstimateTokens(oldMessages),
 	}
+	log.Info("compacted conversation", "messages", result.OriginalCount, "remaining", result.RemainingCount, "tokens", result.CompactedTokens)
 
 	return result, compacted, nil
 }
@@ -6,31 +6,16 @@ import (
 	"testing"
 
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
 )
 
-type mockProvider struct {
-	chatResp *provider.ChatResponse
-}
-
-func (m *mockProvider) Name() string { return "mock" }
-func (m *mockProvider) Chat(_ context.Context, _ provider.ChatRequest) (*provider.ChatResponse, error) {
-	return m.chatResp, nil
-}
-func (m *mockProvider) StreamChat(_ context.Context, _ provider.ChatRequest) (<-chan provider.StreamDelta, error) {
-	return nil, nil
-}
-func (m *mockProvider) ListModels(_ context.Context) ([]provider.ModelInfo, error) {
-	return nil, nil
-}
-func (m *mockProvider) IsAvailable(_ context.Context) error { return nil }
-
 func TestCompact_ShortConversation_NoChange(t *testing.T) {
 	messages := []provider.Message{
 		{Role: "user", Content: "Hi"},
 		{Role: "assistant", Content: "Hello!"},
 	}
 
-	mp := &mockProvider{}
+	mp := providertest.New()
 	result, compacted, err := Compact(context.Background(), mp, "test", messages, 10000, 4)
 	if err != nil {
 		t.Fatalf("Compact() error: %v", err)
@@ -53,14 +38,7 @@ func TestCompact_LongConversation_Summarized(t *testing.T) {
 		)
 	}
 
-	mp := &mockProvider{
-		chatResp: &provider.ChatResponse{
-			Message: provider.Message{
-				Role:    "assistant",
-				Content: "The user discussed various topics with the assistant.",
-			},
-		},
-	}
+	mp := providertest.New(providertest.Text("The user discussed various topics with the assistant."))
 
 	// Set a low maxTokens to force compaction
 	result, _, err := Compact(context.Background(), mp, "test", messages, 500, 4)
@@ -87,14 +65,7 @@ func TestCompact_PreservesRecentMessages(t *testing.T) {
 	messages[len(messages)-2].Content = "FINAL_USER_MESSAGE"
 	messages[len(messages)-1].Content = "FINAL_ASSISTANT_MESSAGE"
 
-	mp := &mockProvider{
-		chatResp: &provider.ChatResponse{
-			Message: provider.Message{
-				Role:    "assistant",
-				Content: "Summary of conversation.",
-			},
-		},
-	}
+	mp := providertest.New(providertest.Text("Summary of conversation."))
 
 	_, compacted, err := Compact(context.Background(), mp, "test", messages, 500, 4)
 	if err != nil {
@@ -130,14 +101,7 @@ func TestCompact_SummaryFormat(t *testing.T) {
 		)
 	}
 
-	mp := &mockProvider{
-		chatResp: &provider.ChatResponse{
-			Message: provider.Message{
-				Role:    "assistant",
-				Content: "The conversation covered Go programming and project setup.",
-			},
-		},
-	}
+	mp := providertest.New(providertest.Text("The conversation covered Go programming and project setup."))
 
 	result, _, err := Compact(context.Background(), mp, "test", messages, 500, 4)
 	if err != nil {
@@ -154,7 +118,7 @@ func TestCompact_SummaryFormat(t *testing.T) {
 func TestTokenEstimate(t *testing.T) {
 	messages := []provider.Message{
 		{Role: "user", Content: "Hello world"},       // 11 chars = ~2 tokens
-		{Role: "assistant", Content: "Hi there man"},  // 12 chars = ~3 tokens
+		{Role: "assistant", Content: "Hi there man"}, // 12 chars = ~3 tokens
 	}
 	tokens := EstimateTokens(messages)
 	if tokens < 2 || tokens > 10 {
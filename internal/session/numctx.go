@@ -0,0 +1,76 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ContextSizes remembers the context size (num_ctx) last used with each
+// model, so a new run starts where the last one left off instead of
+// reloading the model at a different size. It is safe for concurrent use.
+type ContextSizes struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewContextSizes creates a store backed by the JSON file at path.
+func NewContextSizes(path string) *ContextSizes {
+	return &ContextSizes{path: path}
+}
+
+// Get returns the context size last used with model, or 0 if none was
+// saved or the file can't be read.
+func (c *ContextSizes) Get(model string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sizes, err := c.load()
+	if err != nil {
+		return 0
+	}
+	return sizes[model]
+}
+
+// Set saves numCtx as the context size of model.
+func (c *ContextSizes) Set(model string, numCtx int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sizes, err := c.load()
+	if err != nil {
+		sizes = map[string]int{}
+	}
+	if sizes[model] == numCtx {
+		return nil
+	}
+	sizes[model] = numCtx
+	data, err := json.MarshalIndent(sizes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func (c *ContextSizes) load() (map[string]int, error) {
+	sizes := map[string]int{}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sizes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &sizes); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", c.path, err)
+	}
+	return sizes, nil
+}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContextSizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "context-sizes.json")
+	c := NewContextSizes(path)
+	if got := c.Get("qwen3:4b"); got != 0 {
+		t.Errorf("Get before Set = %d, want 0", got)
+	}
+	if err := c.Set("qwen3:4b", 8192); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("qwen3:8b", 16384); err != nil {
+		t.Fatal(err)
+	}
+	c = NewContextSizes(path)
+	if got := c.Get("qwen3:4b"); got != 8192 {
+		t.Errorf("Get(qwen3:4b) = %d, want 8192", got)
+	}
+	if got := c.Get("qwen3:8b"); got != 16384 {
+		t.Errorf("Get(qwen3:8b) = %d, want 16384", got)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if got := c.Get("qwen3:4b"); got != 0 {
+		t.Errorf("Get from a damaged file = %d, want 0", got)
+	}
+	if err := c.Set("qwen3:4b", 4096); err != nil || c.Get("qwen3:4b") != 4096 {
+		t.Errorf("Set over a damaged file: %v", err)
+	}
+}
@@ -14,11 +14,46 @@ import (
 
 // Session represents a conversation session.
 type Session struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Model     string    `json:"model"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Model     string            `json:"model"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Sampling  *provider.Options `json:"sampling,omitempty"` // per-session sampling overrides
+	State     *State            `json:"state,omitempty"`
+}
+
+// State is the runtime state of a session, kept so that reopening a long
+// session carries on where it left off instead of starting from the
+// smallest context again.
+type State struct {
+	NumCtx    int             `json:"num_ctx,omitempty"`    // context size the session last ran with
+	Summary   string          `json:"summary,omitempty"`    // of the compacted messages
+	Compacted int             `json:"compacted,omitempty"`  // transcript messages the summary replaces
+	LastUsage *provider.Usage `json:"last_usage,omitempty"` // of the last reply
+
+	// Tokens of all replies in the session
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+}
+
+// AddUsage records the usage of a reply: as the last usage and in the
+// totals.
+func (st *State) AddUsage(u provider.Usage) {
+	st.LastUsage = &u
+	st.PromptTokens += u.PromptTokens
+	st.CompletionTokens += u.CompletionTokens
+}
+
+// Resume returns the messages to continue the session with: the
+// compaction summary, if any, followed by the transcript messages it
+// doesn't replace.
+func (s *Session) Resume(transcript []provider.Message) []provider.Message {
+	if s.State == nil || s.State.Summary == "" {
+		return transcript
+	}
+	rest := transcript[min(s.State.Compacted, len(transcript)):]
+	return append([]provider.Message{{Role: "summary", Content: s.State.Summary}}, rest...)
 }
 
 // Store defines the interface for session persistence.
@@ -31,6 +66,9 @@ type Store interface {
 	Current() (*Session, error)
 	SetCurrent(id string) error
 	LoadTranscript(sessionID string) ([]provider.Message, error)
+	UpdateTitle(id, title string) error
+	UpdateSampling(id string, opts provider.Options) error
+	UpdateState(id string, st State) error
 }
 
 // FileStore implements Store using the filesystem.
@@ -190,3 +228,31 @@ func (fs *FileStore) UpdateTitle(id, title string) error {
 	s.UpdatedAt = time.Now()
 	return fs.saveMeta(s)
 }
+
+// UpdateSampling stores per-session sampling overrides. Zero options clear them.
+func (fs *FileStore) UpdateSampling(id string, opts provider.Options) error {
+	s, err := fs.Get(id)
+	if err != nil {
+		return err
+	}
+	s.Sampling = nil
+	if !opts.IsZero() {
+		s.Sampling = &opts
+	}
+	s.UpdatedAt = time.Now()
+	return fs.saveMeta(s)
+}
+
+// UpdateState stores the session's runtime state. A zero state clears it.
+func (fs *FileStore) UpdateState(id string, st State) error {
+	s, err := fs.Get(id)
+	if err != nil {
+		return err
+	}
+	s.State = nil
+	if st != (State{}) {
+		s.State = &st
+	}
+	s.UpdatedAt = time.Now()
+	return fs.saveMeta(s)
+}
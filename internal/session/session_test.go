@@ -127,3 +127,67 @@ func TestDelete(t *testing.T) {
 		t.Error("Get() should fail after delete")
 	}
 }
+
+func TestUpdateSampling(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	s, _ := store.Create("Test", "qwen3-next")
+
+	var opts provider.Options
+	opts.Set("temperature", "0.2")
+	if err := store.UpdateSampling(s.ID, opts); err != nil {
+		t.Fatalf("UpdateSampling() error: %v", err)
+	}
+	got, _ := store.Get(s.ID)
+	if got.Sampling == nil || got.Sampling.Get("temperature") != "0.2" {
+		t.Fatalf("sampling = %v, want temperature=0.2", got.Sampling)
+	}
+
+	store.UpdateSampling(s.ID, provider.Options{})
+	got, _ = store.Get(s.ID)
+	if got.Sampling != nil {
+		t.Errorf("zero options should clear sampling, got %v", got.Sampling)
+	}
+}
+
+func TestUpdateState(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	s, _ := store.Create("Test", "qwen3-next")
+
+	st := State{NumCtx: 16384, Summary: "They planned a trip.", Compacted: 4, LastUsage: &provider.Usage{PromptTokens: 9000}}
+	if err := store.UpdateState(s.ID, st); err != nil {
+		t.Fatalf("UpdateState() error: %v", err)
+	}
+	got, _ := store.Get(s.ID)
+	if got.State == nil || got.State.NumCtx != 16384 || got.State.Summary != st.Summary || got.State.LastUsage.PromptTokens != 9000 {
+		t.Fatalf("state = %+v", got.State)
+	}
+
+	store.UpdateState(s.ID, State{})
+	got, _ = store.Get(s.ID)
+	if got.State != nil {
+		t.Errorf("zero state should clear it, got %+v", got.State)
+	}
+}
+
+func TestResume(t *testing.T) {
+	transcript := []provider.Message{
+		{Role: "user", Content: "one"},
+		{Role: "assistant", Content: "two"},
+		{Role: "user", Content: "three"},
+	}
+	s := &Session{}
+	if got := s.Resume(transcript); len(got) != 3 {
+		t.Errorf("without state, Resume() = %v, want the transcript", got)
+	}
+
+	s.State = &State{Summary: "They counted.", Compacted: 2}
+	got := s.Resume(transcript)
+	if len(got) != 2 || got[0].Role != "summary" || got[0].Content != "They counted." || got[1].Content != "three" {
+		t.Errorf("Resume() = %v, want the summary and the last message", got)
+	}
+
+	s.State.Compacted = 10
+	if got := s.Resume(transcript); len(got) != 1 {
+		t.Errorf("with a transcript shorter than compacted, Resume() = %v, want the summary only", got)
+	}
+}
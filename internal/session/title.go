@@ -0,0 +1,71 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+// DefaultTitle is the title of a session until it is named.
+const DefaultTitle = "New Chat"
+
+const titlePrompt = `Give this conversation a short title of at most six words, in the language it is written in. Reply with the title only: no quotes, no "Title:" and no period at the end.`
+
+// maxTitleLen caps a generated title, in characters, for when the model
+// ignores the prompt and answers at length.
+const maxTitleLen = 60
+
+// maxTitleInput caps how much of each message is sent to name a session;
+// the start of a conversation says what it is about.
+const maxTitleInput = 1000
+
+// GenerateTitle asks model for a short title for a conversation.
+func GenerateTitle(ctx context.Context, p provider.Provider, model string, messages []provider.Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range messages {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		content := m.Content
+		if len(content) > maxTitleInput {
+			content = strings.ToValidUTF8(content[:maxTitleInput], "") + "..."
+		}
+		transcript.WriteString(m.Role + ": " + content + "\n")
+	}
+
+	resp, err := p.Chat(ctx, provider.ChatRequest{
+		Model: model,
+		Messages: []provider.Message{
+			{Role: "system", Content: titlePrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("naming session: %w", err)
+	}
+	title := cleanTitle(think.Strip(resp.Message.Content))
+	if title == "" {
+		return "", fmt.Errorf("naming session: %s gave an empty title", model)
+	}
+	return title, nil
+}
+
+// cleanTitle reduces a model's answer to the title: its first line without
+// a "Title:" label, quotes, Markdown emphasis or a final period.
+func cleanTitle(s string) string {
+	s = strings.TrimSpace(s)
+	s, _, _ = strings.Cut(s, "\n")
+	if label, rest, ok := strings.Cut(s, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "title") {
+		s = rest
+	}
+	s = strings.Trim(strings.TrimSpace(s), `"'*#“”„`)
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	if utf8.RuneCountInString(s) > maxTitleLen {
+		s = strings.TrimSpace(string([]rune(s)[:maxTitleLen-1])) + "…"
+	}
+	return s
+}
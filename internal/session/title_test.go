@@ -0,0 +1,49 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestGenerateTitle(t *testing.T) {
+	mp := providertest.New(providertest.Text("Title: \"Sourdough starter troubleshooting.\"\n\nI picked this because..."))
+	messages := []provider.Message{
+		{Role: "system", Content: "You are stefanclaw."},
+		{Role: "user", Content: "Why is my sourdough starter not rising?"},
+		{Role: "assistant", Content: "It may be too cold."},
+	}
+
+	title, err := GenerateTitle(context.Background(), mp, "small", messages)
+	if err != nil {
+		t.Fatalf("GenerateTitle: %v", err)
+	}
+	if title != "Sourdough starter troubleshooting" {
+		t.Errorf("title = %q", title)
+	}
+
+	req := mp.LastRequest()
+	if req.Model != "small" {
+		t.Errorf("model = %q, want small", req.Model)
+	}
+	if sent := req.Messages[1].Content; strings.Contains(sent, "You are stefanclaw") || !strings.Contains(sent, "user: Why is my sourdough") {
+		t.Errorf("transcript = %q", sent)
+	}
+}
+
+func TestGenerateTitle_Empty(t *testing.T) {
+	mp := providertest.New(providertest.Text(`""`))
+	if _, err := GenerateTitle(context.Background(), mp, "small", nil); err == nil {
+		t.Error("GenerateTitle accepted an empty title")
+	}
+}
+
+func TestCleanTitle_Long(t *testing.T) {
+	title := cleanTitle(strings.Repeat("word ", 30))
+	if n := len([]rune(title)); n != maxTitleLen || !strings.HasSuffix(title, "…") {
+		t.Errorf("title = %q (%d runes)", title, n)
+	}
+}
@@ -11,24 +11,46 @@ import (
 
 // AppendMessage appends a single message as a JSONL line.
 func AppendMessage(path string, msg provider.Message) error {
+	return AppendMessages(path, []provider.Message{msg})
+}
+
+// AppendMessages appends messages as JSONL lines in one write.
+func AppendMessages(path string, msgs []provider.Message) error {
+	var buf []byte
+	for _, msg := range msgs {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("marshaling message: %w", err)
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		return fmt.Errorf("opening transcript: %w", err)
 	}
 	defer f.Close()
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("marshaling message: %w", err)
-	}
-
-	if _, err := f.Write(append(data, '\n')); err != nil {
+	if _, err := f.Write(buf); err != nil {
 		return fmt.Errorf("writing message: %w", err)
 	}
 
 	return nil
 }
 
+// syncTranscript flushes the transcript at path to disk.
+func syncTranscript(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening transcript: %w", err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing transcript: %w", err)
+	}
+	return nil
+}
+
 // ReadTranscript reads all messages from a JSONL transcript file.
 func ReadTranscript(path string) ([]provider.Message, error) {
 	f, err := os.Open(path)
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// writerQueue is how many messages Writer holds before Append waits for the
+// disk.
+const writerQueue = 256
+
+// queued is a message waiting to be written.
+type queued struct {
+	sessionID string
+	msg       provider.Message
+}
+
+// Writer appends messages to a Store in the background, so that callers
+// such as the TUI don't wait for the disk. Messages queued while a write is
+// in progress are written together in the next one. Close flushes the
+// queue and, for a FileStore, syncs the transcripts to disk.
+type Writer struct {
+	store Store
+	queue chan queued
+	errs  chan error
+	done  chan struct{}
+	close sync.Once
+
+	written map[string]bool // sessions appended to, to sync on Close
+	syncErr error
+}
+
+// NewWriter starts a Writer for store.
+func NewWriter(store Store) *Writer {
+	w := &Writer{
+		store:   store,
+		queue:   make(chan queued, writerQueue),
+		errs:    make(chan error, 1),
+		done:    make(chan struct{}),
+		written: make(map[string]bool),
+	}
+	go w.run()
+	return w
+}
+
+// Append queues msg to be appended to the session's transcript. It must
+// not be called after Close.
+func (w *Writer) Append(sessionID string, msg provider.Message) {
+	w.queue <- queued{sessionID, msg}
+}
+
+// Errors delivers errors of failed writes. If errors aren't received as
+// they happen, only the first one is kept. It is closed by Close.
+func (w *Writer) Errors() <-chan error {
+	return w.errs
+}
+
+// Close writes the queued messages, syncs the transcripts and stops w. It
+// returns the errors syncing.
+func (w *Writer) Close() error {
+	w.close.Do(func() { close(w.queue) })
+	<-w.done
+	return w.syncErr
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	defer close(w.errs)
+	for q := range w.queue {
+		batch := []queued{q}
+	drain:
+		for {
+			select {
+			case q, ok := <-w.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, q)
+			default:
+				break drain
+			}
+		}
+		for len(batch) > 0 {
+			// Consecutive messages of a session are written at once
+			n := 1
+			for n < len(batch) && batch[n].sessionID == batch[0].sessionID {
+				n++
+			}
+			msgs := make([]provider.Message, n)
+			for i, q := range batch[:n] {
+				msgs[i] = q.msg
+			}
+			w.report(w.write(batch[0].sessionID, msgs))
+			batch = batch[n:]
+		}
+	}
+
+	fs, ok := w.store.(*FileStore)
+	if !ok {
+		return
+	}
+	var errs []error
+	for id := range w.written {
+		errs = append(errs, syncTranscript(fs.transcriptPath(id)))
+	}
+	w.syncErr = errors.Join(errs...)
+}
+
+func (w *Writer) write(sessionID string, msgs []provider.Message) error {
+	w.written[sessionID] = true
+	if fs, ok := w.store.(*FileStore); ok {
+		return AppendMessages(fs.transcriptPath(sessionID), msgs)
+	}
+	for _, msg := range msgs {
+		if err := w.store.Append(sessionID, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// report passes err on to Errors without blocking.
+func (w *Writer) report(err error) {
+	if err == nil {
+		return
+	}
+	log.Error("saving transcript failed", "err", err)
+	select {
+	case w.errs <- err:
+	default:
+	}
+}
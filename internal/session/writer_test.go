@@ -0,0 +1,59 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+func TestWriter(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	a, _ := store.Create("A", "qwen3-next")
+	b, _ := store.Create("B", "qwen3-next")
+
+	w := NewWriter(store)
+	for i := range 100 {
+		id := a.ID
+		if i%10 == 9 {
+			id = b.ID
+		}
+		w.Append(id, provider.Message{Role: "user", Content: fmt.Sprint(i)})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	got, _ := store.LoadTranscript(a.ID)
+	if len(got) != 90 {
+		t.Fatalf("session A has %d messages, want 90", len(got))
+	}
+	for i, want := 0, 0; i < len(got); i, want = i+1, want+1 {
+		if want%10 == 9 {
+			want++
+		}
+		if got[i].Content != fmt.Sprint(want) {
+			t.Fatalf("message %d = %q, want %d: order not kept", i, got[i].Content, want)
+		}
+	}
+	if got, _ := store.LoadTranscript(b.ID); len(got) != 10 {
+		t.Errorf("session B has %d messages, want 10", len(got))
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	w := NewWriter(NewFileStore(t.TempDir()))
+	defer w.Close()
+
+	// The session's directory doesn't exist
+	w.Append("missing", provider.Message{Role: "user", Content: "Hello"})
+	select {
+	case err := <-w.Errors():
+		if err == nil {
+			t.Error("nil error reported")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed write not reported")
+	}
+}
@@ -0,0 +1,222 @@
+// Package speech reads text aloud with a local text-to-speech engine:
+// piper, macOS say or espeak.
+package speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Backends.
+const (
+	BackendAuto   = "auto"
+	BackendPiper  = "piper"
+	BackendSay    = "say"
+	BackendEspeak = "espeak"
+)
+
+// run runs a command with text on stdin; tests replace it.
+var run = func(ctx context.Context, stdin string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// lookPath finds an executable; tests replace it.
+var lookPath = exec.LookPath
+
+// Speaker speaks queued text one piece after another in the background.
+// It is safe for concurrent use.
+type Speaker struct {
+	speak func(ctx context.Context, text string) error
+
+	mu      sync.Mutex
+	queue   []string
+	busy    bool
+	cancel  context.CancelFunc
+	lastErr error
+}
+
+// New creates a Speaker for cfg. It fails if the backend (or, for "auto",
+// any backend) is not installed.
+func New(cfg config.SpeechConfig) (*Speaker, error) {
+	speak, err := backend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Speaker{speak: speak}, nil
+}
+
+func backend(cfg config.SpeechConfig) (func(context.Context, string) error, error) {
+	name := cfg.Backend
+	if name == "" || name == BackendAuto {
+		switch {
+		case runtime.GOOS == "darwin":
+			name = BackendSay
+		case cfg.PiperModel != "" && installed("piper"):
+			name = BackendPiper
+		default:
+			name = BackendEspeak
+		}
+	}
+
+	switch name {
+	case BackendSay:
+		if !installed("say") {
+			return nil, errors.New("say not found (it comes with macOS)")
+		}
+		args := []string{"-f", "-"}
+		if cfg.Voice != "" {
+			args = append(args, "-v", cfg.Voice)
+		}
+		return func(ctx context.Context, text string) error { return run(ctx, text, "say", args...) }, nil
+
+	case BackendEspeak:
+		bin := "espeak-ng"
+		if !installed(bin) {
+			bin = "espeak"
+		}
+		if !installed(bin) {
+			return nil, errors.New("no text-to-speech engine found: install espeak-ng or piper (with speech.piper_model)")
+		}
+		args := []string{"--stdin"}
+		if cfg.Voice != "" {
+			args = append(args, "-v", cfg.Voice)
+		}
+		return func(ctx context.Context, text string) error { return run(ctx, text, bin, args...) }, nil
+
+	case BackendPiper:
+		if !installed("piper") {
+			return nil, errors.New("piper not found")
+		}
+		if cfg.PiperModel == "" {
+			return nil, errors.New("piper needs a voice model (speech.piper_model)")
+		}
+		player := findPlayer()
+		if player == nil {
+			return nil, errors.New("no audio player found: install aplay (alsa-utils), paplay or ffplay")
+		}
+		home, _ := os.UserHomeDir()
+		model := config.ExpandPath(cfg.PiperModel, home)
+		return func(ctx context.Context, text string) error {
+			f, err := os.CreateTemp("", "stefanclaw-speech-*.wav")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			defer os.Remove(f.Name())
+			if err := run(ctx, text, "piper", "--quiet", "--model", model, "--output_file", f.Name()); err != nil {
+				return err
+			}
+			return run(ctx, "", player[0], append(player[1:], f.Name())...)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown speech backend %q", name)
+}
+
+// findPlayer returns the command line of the first installed WAV player;
+// the file name is appended.
+func findPlayer() []string {
+	for _, p := range [][]string{
+		{"aplay", "-q"},
+		{"paplay"},
+		{"afplay"},
+		{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	} {
+		if installed(p[0]) {
+			return p
+		}
+	}
+	return nil
+}
+
+func installed(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// Say queues text to be spoken after what is already queued.
+func (s *Speaker) Say(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, text)
+	if !s.busy {
+		s.busy = true
+		go s.loop()
+	}
+}
+
+func (s *Speaker) loop() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.busy = false
+			s.cancel = nil
+			s.mu.Unlock()
+			return
+		}
+		text := s.queue[0]
+		s.queue = s.queue[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.mu.Unlock()
+
+		if err := s.speak(ctx, text); err != nil {
+			s.mu.Lock()
+			s.lastErr = err
+			s.mu.Unlock()
+		}
+		cancel()
+	}
+}
+
+// Speaking reports whether anything is being spoken or queued.
+func (s *Speaker) Speaking() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy
+}
+
+// Stop silences the current speech and drops the queue.
+func (s *Speaker) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// Err returns and clears the last error from the engine.
+func (s *Speaker) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.lastErr
+	s.lastErr = nil
+	return err
+}
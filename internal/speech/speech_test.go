@@ -0,0 +1,150 @@
+package speech
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// fakeEngine records what would be spoken by which command.
+type fakeEngine struct {
+	mu     sync.Mutex
+	spoken []string
+	block  chan struct{} // when set, speaking waits for it or cancellation
+}
+
+func (f *fakeEngine) install(t *testing.T, installed ...string) {
+	t.Helper()
+	origRun, origLook := run, lookPath
+	t.Cleanup(func() { run, lookPath = origRun, origLook })
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	run = func(ctx context.Context, stdin, name string, args ...string) error {
+		if f.block != nil {
+			select {
+			case <-f.block:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.spoken = append(f.spoken, strings.TrimSpace(name+" "+strings.Join(args, " ")+" <"+stdin))
+		return nil
+	}
+}
+
+func (f *fakeEngine) got() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.spoken...)
+}
+
+func waitIdle(t *testing.T, s *Speaker) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Speaking() {
+		if time.Now().After(deadline) {
+			t.Fatal("speaker did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSpeaker_Order(t *testing.T) {
+	var f fakeEngine
+	f.install(t, "espeak-ng")
+	s, err := New(config.SpeechConfig{Backend: BackendEspeak, Voice: "de"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	s.Say("Hallo.")
+	s.Say("  ")
+	s.Say("Wie geht's?")
+	waitIdle(t, s)
+	want := []string{"espeak-ng --stdin -v de <Hallo.", "espeak-ng --stdin -v de <Wie geht's?"}
+	if got := f.got(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("spoken = %q, want %q", got, want)
+	}
+}
+
+func TestSpeaker_Stop(t *testing.T) {
+	f := fakeEngine{block: make(chan struct{})}
+	f.install(t, "espeak")
+	s, err := New(config.SpeechConfig{Backend: BackendAuto})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	s.Say("One.")
+	s.Say("Two.")
+	if !s.Speaking() {
+		t.Fatal("speaker should be busy")
+	}
+	s.Stop()
+	waitIdle(t, s)
+	if got := f.got(); len(got) != 0 {
+		t.Errorf("spoken after Stop = %q", got)
+	}
+}
+
+func TestNew_Backends(t *testing.T) {
+	var f fakeEngine
+	f.install(t, "piper", "aplay")
+
+	if _, err := New(config.SpeechConfig{Backend: BackendEspeak}); err == nil {
+		t.Error("espeak should fail when it is not installed")
+	}
+	if _, err := New(config.SpeechConfig{Backend: BackendPiper}); err == nil {
+		t.Error("piper without a model should fail")
+	}
+	s, err := New(config.SpeechConfig{Backend: BackendPiper, PiperModel: "/voices/en.onnx"})
+	if err != nil {
+		t.Fatalf("New(piper) error: %v", err)
+	}
+	s.Say("Hi.")
+	waitIdle(t, s)
+	got := f.got()
+	if len(got) != 2 || !strings.HasPrefix(got[0], "piper --quiet --model /voices/en.onnx --output_file ") || !strings.HasPrefix(got[1], "aplay -q ") {
+		t.Errorf("piper commands = %q", got)
+	}
+}
+
+func TestSpeakable(t *testing.T) {
+	reply := "## Result\n\nSee **the [docs](https://go.dev/doc)** at https://go.dev now.\n\n```go\nfmt.Println(1)\n```\n- Use `go vet`.\n<tool_call>{\"name\": \"x\"}</tool_call>"
+	want := " Result\n\nSee the docs at  now.\n\nUse go vet.\n"
+	if got := Speakable(reply); got != want {
+		t.Errorf("Speakable() = %q, want %q", got, want)
+	}
+	if got := Speakable("Look:\n```sh\nrm -rf"); got != "Look:\n" {
+		t.Errorf("unfinished code block: %q", got)
+	}
+}
+
+func TestSentenceEnd(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"Hello there", 0},
+		{"Hello. There", 7},
+		{"Hi! How are you? I am", 17},
+		{"Version 1.2 is out", 0},
+		{"List:\nitem", 6},
+	}
+	for _, tt := range tests {
+		if got := SentenceEnd(tt.text); got != tt.want {
+			t.Errorf("SentenceEnd(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package speech
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRe   = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	urlRe    = regexp.MustCompile(`https?://\S+`)
+	markupRe = regexp.MustCompile("[*_#>`|~]+")
+	bulletRe = regexp.MustCompile(`(?m)^[ \t]*(?:[-+]|\d+\.)[ \t]+`)
+)
+
+// Speakable turns a Markdown reply into text worth reading aloud: code
+// blocks (also unfinished ones), tool calls, URLs and formatting are
+// dropped, links keep their text. The result only grows as reply does, so
+// streamed replies can be spoken incrementally.
+func Speakable(reply string) string {
+	if i := strings.Index(reply, "<tool_call"); i >= 0 {
+		reply = reply[:i]
+	}
+	var b strings.Builder
+	inCode := false
+	for _, line := range strings.SplitAfter(reply, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			b.WriteString(line)
+		}
+	}
+	text := linkRe.ReplaceAllString(b.String(), "$1")
+	text = urlRe.ReplaceAllString(text, "")
+	text = bulletRe.ReplaceAllString(text, "")
+	return markupRe.ReplaceAllString(text, "")
+}
+
+// SentenceEnd returns the length of the complete sentences at the start of
+// text: up to the last ".", "!", "?" or ":" followed by white space, or the
+// last line break. It returns 0 if no sentence is complete yet.
+func SentenceEnd(text string) int {
+	for i := len(text) - 1; i > 0; i-- {
+		if text[i] == '\n' {
+			return i + 1
+		}
+		if text[i] == ' ' && strings.ContainsRune(".!?:", rune(text[i-1])) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+// Package terminal captures what the user was just doing in their terminal:
+// the output of the last tmux pane or, outside tmux, recent shell history.
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sources of a capture.
+const (
+	SourcePane    = "pane"
+	SourceHistory = "history"
+)
+
+// ErrNoTmux is returned when a pane is requested outside tmux.
+var ErrNoTmux = errors.New("not running inside tmux")
+
+// Snapshot is captured terminal text.
+type Snapshot struct {
+	Source string // SourcePane or SourceHistory
+	Text   string
+}
+
+// Capture returns the last n lines of source. An empty source uses the pane
+// inside tmux and the shell history otherwise.
+func Capture(ctx context.Context, source string, n int) (Snapshot, error) {
+	if source == "" {
+		source = SourceHistory
+		if os.Getenv("TMUX") != "" {
+			source = SourcePane
+		}
+	}
+	var text string
+	var err error
+	switch source {
+	case SourcePane:
+		text, err = Pane(ctx, n)
+	case SourceHistory:
+		var lines []string
+		lines, err = History(n)
+		text = strings.Join(lines, "\n")
+	default:
+		return Snapshot{}, fmt.Errorf("unknown source %q (want %q or %q)", source, SourcePane, SourceHistory)
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Source: source, Text: text}, nil
+}
+
+// Pane returns the last n lines of the previously active tmux pane, the one
+// the user switched away from to ask stefanclaw. Long lines wrapped by tmux
+// are joined.
+func Pane(ctx context.Context, n int) (string, error) {
+	if os.Getenv("TMUX") == "" {
+		return "", ErrNoTmux
+	}
+	cmd := exec.CommandContext(ctx, "tmux", "capture-pane", "-p", "-J", "-t", "{last}", "-S", strconv.Itoa(-n))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tmux: %s (is there another pane in this window?)", msg)
+		}
+		return "", fmt.Errorf("tmux: %w", err)
+	}
+	return tail(strings.TrimRight(stdout.String(), "\n "), n), nil
+}
+
+// History returns the last n commands from the shell history file: $HISTFILE
+// if set, else the default file of $SHELL (bash, zsh or fish).
+func History(n int) ([]string, error) {
+	path, format := historyFile()
+	if path == "" {
+		return nil, errors.New("no shell history file found (set HISTFILE)")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading shell history: %w", err)
+	}
+	defer f.Close()
+
+	var cmds []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch format {
+		case "zsh":
+			// Extended history: ": 1700000000:0;command"
+			if strings.HasPrefix(line, ": ") {
+				if _, cmd, ok := strings.Cut(line, ";"); ok {
+					line = cmd
+				}
+			}
+		case "fish":
+			cmd, ok := strings.CutPrefix(line, "- cmd: ")
+			if !ok {
+				continue
+			}
+			line = cmd
+		default:
+			// Bash timestamps: "#1700000000"
+			if strings.HasPrefix(line, "#") && isDigits(line[1:]) {
+				continue
+			}
+		}
+		if strings.TrimSpace(line) != "" {
+			cmds = append(cmds, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading shell history: %w", err)
+	}
+	if len(cmds) > n {
+		cmds = cmds[len(cmds)-n:]
+	}
+	return cmds, nil
+}
+
+// historyFile returns the history file and its format ("bash", "zsh" or
+// "fish"), or "" if there is none.
+func historyFile() (string, string) {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	format := "bash"
+	if shell == "zsh" || shell == "fish" {
+		format = shell
+	}
+	if path := os.Getenv("HISTFILE"); path != "" {
+		return path, format
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", ""
+	}
+	candidates := map[string]string{
+		"bash": filepath.Join(home, ".bash_history"),
+		"zsh":  filepath.Join(home, ".zsh_history"),
+		"fish": filepath.Join(home, ".local", "share", "fish", "fish_history"),
+	}
+	if _, err := os.Stat(candidates[format]); err == nil {
+		return candidates[format], format
+	}
+	// $SHELL may not be the shell in use; try the others
+	for _, f := range []string{"zsh", "bash", "fish"} {
+		if _, err := os.Stat(candidates[f]); err == nil {
+			return candidates[f], f
+		}
+	}
+	return "", ""
+}
+
+func tail(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeHistory(t *testing.T, shell, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELL", "/bin/"+shell)
+	t.Setenv("HISTFILE", path)
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		shell   string
+		content string
+		want    []string
+	}{
+		{"bash", "ls\n#1700000000\nmake test\n\ngit push\n", []string{"make test", "git push"}},
+		{"zsh", ": 1700000000:0;ls\n: 1700000001:0;make test\ngit push\n", []string{"make test", "git push"}},
+		{"fish", "- cmd: ls\n  when: 1700000000\n- cmd: make test\n  when: 1700000001\n- cmd: git push\n", []string{"make test", "git push"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			writeHistory(t, tt.shell, tt.content)
+			got, err := History(2)
+			if err != nil {
+				t.Fatalf("History() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("History() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory_DefaultFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("HISTFILE", "")
+	t.Setenv("SHELL", "/bin/bash")
+	if _, err := History(5); err == nil {
+		t.Error("History() without a history file should fail")
+	}
+
+	// $SHELL says bash but only zsh history exists
+	os.WriteFile(filepath.Join(home, ".zsh_history"), []byte(": 1700000000:0;make\n"), 0o600)
+	got, err := History(5)
+	if err != nil || !reflect.DeepEqual(got, []string{"make"}) {
+		t.Errorf("History() = %q, %v, want the zsh history", got, err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	writeHistory(t, "bash", "go test ./...\n")
+
+	t.Setenv("TMUX", "")
+	snap, err := Capture(context.Background(), "", 10)
+	if err != nil || snap.Source != SourceHistory || snap.Text != "go test ./..." {
+		t.Errorf("Capture() outside tmux = %+v, %v, want the history", snap, err)
+	}
+	if _, err := Capture(context.Background(), SourcePane, 10); !errors.Is(err, ErrNoTmux) {
+		t.Errorf("Capture(pane) outside tmux error = %v, want ErrNoTmux", err)
+	}
+	if _, err := Capture(context.Background(), "screen", 10); err == nil {
+		t.Error("Capture() with an unknown source should fail")
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("a\nb\nc", 2); got != "b\nc" {
+		t.Errorf("tail() = %q", got)
+	}
+	if got := tail("a", 5); got != "a" {
+		t.Errorf("tail() = %q", got)
+	}
+}
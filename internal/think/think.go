@@ -0,0 +1,37 @@
+// Package think separates the reasoning that models such as qwen3 and
+// deepseek-r1 write in <think> tags before their answer from the answer
+// itself.
+package think
+
+import "strings"
+
+const (
+	openTag  = "<think>"
+	closeTag = "</think>"
+)
+
+// Split returns the reasoning at the start of content and the answer after
+// it. While a reply is still streaming the block may not be closed yet;
+// then everything after <think> is reasoning and done is false. Content
+// without a leading <think> block is all answer.
+func Split(content string) (thoughts, answer string, done bool) {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	rest, ok := strings.CutPrefix(trimmed, openTag)
+	if !ok {
+		if trimmed != "" && strings.HasPrefix(openTag, trimmed) {
+			return "", "", false // the tag itself is still arriving
+		}
+		return "", content, true
+	}
+	thoughts, answer, ok = strings.Cut(rest, closeTag)
+	if !ok {
+		return strings.TrimSpace(rest), "", false
+	}
+	return strings.TrimSpace(thoughts), strings.TrimLeft(answer, " \t\r\n"), true
+}
+
+// Strip returns content without its reasoning.
+func Strip(content string) string {
+	_, answer, _ := Split(content)
+	return answer
+}
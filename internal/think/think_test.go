@@ -0,0 +1,29 @@
+package think
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		content, thoughts, answer string
+		done                      bool
+	}{
+		{"Hello!", "", "Hello!", true},
+		{"<think>\nThe user greets me.\n</think>\n\nHello!", "The user greets me.", "Hello!", true},
+		{"<think>\n\n</think>\n\nHello!", "", "Hello!", true},
+		{"\n<think>The user", "The user", "", false},
+		{"<thi", "", "", false},
+		{"I <think> so", "", "I <think> so", true},
+	}
+	for _, tt := range tests {
+		thoughts, answer, done := Split(tt.content)
+		if thoughts != tt.thoughts || answer != tt.answer || done != tt.done {
+			t.Errorf("Split(%q) = %q, %q, %t; want %q, %q, %t", tt.content, thoughts, answer, done, tt.thoughts, tt.answer, tt.done)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	if got := Strip("<think>hmm</think>42"); got != "42" {
+		t.Errorf("Strip = %q, want 42", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package todo keeps the user's task list in TASKS.md, a Markdown checklist
+// that can also be edited by hand.
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPromptItems caps the open tasks listed in the system prompt.
+const maxPromptItems = 20
+
+// ErrNotFound is returned by Done when no open task matches.
+var ErrNotFound = errors.New("no matching open task")
+
+var (
+	itemRe  = regexp.MustCompile(`^\s*[-*] \[([ xX])\] (.*)$`)
+	addedRe = regexp.MustCompile(`\s*\(added (\d{4}-\d{2}-\d{2})\)$`)
+)
+
+// Item is one task.
+type Item struct {
+	Text  string
+	Done  bool
+	Added time.Time // zero if unknown, e.g. for tasks added by hand
+
+	line int // index in the file
+}
+
+// Store manages the TASKS.md file. It is safe for concurrent use.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a store for the TASKS.md file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// read returns the lines of the file and the tasks in it.
+func (s *Store) read() ([]string, []Item, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{"# Tasks", ""}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var items []Item
+	for i, line := range lines {
+		m := itemRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		item := Item{Text: strings.TrimSpace(m[2]), Done: m[1] != " ", line: i}
+		if a := addedRe.FindStringSubmatch(item.Text); a != nil {
+			item.Added, _ = time.ParseInLocation("2006-01-02", a[1], time.Local)
+			item.Text = strings.TrimSpace(item.Text[:len(item.Text)-len(a[0])])
+		}
+		items = append(items, item)
+	}
+	return lines, items, nil
+}
+
+// Items returns all tasks, open and done, in file order.
+func (s *Store) Items() ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, items, err := s.read()
+	return items, err
+}
+
+// Open returns the open tasks in file order.
+func (s *Store) Open() ([]Item, error) {
+	items, err := s.Items()
+	if err != nil {
+		return nil, err
+	}
+	var open []Item
+	for _, it := range items {
+		if !it.Done {
+			open = append(open, it)
+		}
+	}
+	return open, nil
+}
+
+// Add appends an open task.
+func (s *Store) Add(text string, now time.Time) (Item, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return Item{}, errors.New("task text is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines, _, err := s.read()
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{Text: text, Added: now}
+	lines = append(lines, fmt.Sprintf("- [ ] %s (added %s)", text, now.Format("2006-01-02")))
+	return item, s.write(lines)
+}
+
+// Done checks off the open task ref refers to: its number in the list of
+// open tasks, or text that is part of exactly one open task.
+func (s *Store) Done(ref string) (Item, error) {
+	ref = strings.TrimSpace(ref)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines, items, err := s.read()
+	if err != nil {
+		return Item{}, err
+	}
+	var open []Item
+	for _, it := range items {
+		if !it.Done {
+			open = append(open, it)
+		}
+	}
+
+	var matches []Item
+	if n, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		if n < 1 || n > len(open) {
+			return Item{}, fmt.Errorf("%w: there are %d open tasks", ErrNotFound, len(open))
+		}
+		matches = open[n-1 : n]
+	} else {
+		for _, it := range open {
+			if ref != "" && strings.Contains(strings.ToLower(it.Text), strings.ToLower(ref)) {
+				matches = append(matches, it)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Item{}, fmt.Errorf("%w for %q", ErrNotFound, ref)
+	case 1:
+	default:
+		var names []string
+		for _, it := range matches {
+			names = append(names, fmt.Sprintf("%q", it.Text))
+		}
+		return Item{}, fmt.Errorf("%q matches %d open tasks (%s); be more specific or use the number", ref, len(matches), strings.Join(names, ", "))
+	}
+
+	item := matches[0]
+	lines[item.line] = strings.Replace(lines[item.line], "[ ]", "[x]", 1)
+	item.Done = true
+	return item, s.write(lines)
+}
+
+// write saves lines atomically.
+func (s *Store) write(lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Format lists tasks numbered from 1, with their age.
+func Format(items []Item, now time.Time) string {
+	var b strings.Builder
+	for i, it := range items {
+		fmt.Fprintf(&b, "%d. %s", i+1, it.Text)
+		if !it.Added.IsZero() {
+			fmt.Fprintf(&b, " (added %s)", age(it.Added, now))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// age describes how long ago t was, in days.
+func age(t, now time.Time) string {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	days := int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	switch {
+	case days <= 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	}
+	return fmt.Sprintf("%d days ago", days)
+}
+
+// PromptSection lists the open tasks for the system prompt, or returns ""
+// if there are none.
+func PromptSection(open []Item, now time.Time) string {
+	if len(open) == 0 {
+		return ""
+	}
+	shown := open
+	if len(shown) > maxPromptItems {
+		shown = shown[:maxPromptItems]
+	}
+	count := "1 open task"
+	if len(open) != 1 {
+		count = fmt.Sprintf("%d open tasks", len(open))
+	}
+	s := fmt.Sprintf("# Open Tasks\n\nThe user's to-do list has %s. Use the todo tools to add or check off tasks when the user asks.\n\n%s",
+		count, Format(shown, now))
+	if len(open) > len(shown) {
+		s += fmt.Sprintf("\n(%d more not shown)", len(open)-len(shown))
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "TASKS.md")
+	s := NewStore(path)
+	day := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+
+	if open, err := s.Open(); err != nil || len(open) != 0 {
+		t.Fatalf("Open() on a missing file = %v, %v", open, err)
+	}
+	for _, text := range []string{"Call the dentist", "  Renew   passport ", "Call mum"} {
+		if _, err := s.Add(text, day); err != nil {
+			t.Fatalf("Add(%q) error: %v", text, err)
+		}
+	}
+	if _, err := s.Add(" ", day); err == nil {
+		t.Error("Add() of empty text should fail")
+	}
+
+	if _, err := s.Done("call"); err == nil || !strings.Contains(err.Error(), "matches 2 open tasks") {
+		t.Errorf("ambiguous Done() error = %v", err)
+	}
+	if _, err := s.Done("groceries"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Done(no match) error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Done("4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Done(out of range) error = %v, want ErrNotFound", err)
+	}
+	if item, err := s.Done("2"); err != nil || item.Text != "Renew passport" {
+		t.Fatalf("Done(2) = %+v, %v", item, err)
+	}
+	if item, err := s.Done("MUM"); err != nil || item.Text != "Call mum" {
+		t.Fatalf("Done(MUM) = %+v, %v", item, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "# Tasks\n\n- [ ] Call the dentist (added 2026-10-12)\n- [x] Renew passport (added 2026-10-12)\n- [x] Call mum (added 2026-10-12)\n"
+	if string(data) != want {
+		t.Errorf("TASKS.md =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestStore_HandEdited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TASKS.md")
+	os.WriteFile(path, []byte("# My list\n\nSome notes.\n\n* [X] Old thing\n- [ ] Water plants\n  - [ ] Buy soil\n"), 0o644)
+	s := NewStore(path)
+
+	open, err := s.Open()
+	if err != nil || len(open) != 2 || open[0].Text != "Water plants" || !open[0].Added.IsZero() {
+		t.Fatalf("Open() = %+v, %v", open, err)
+	}
+	if _, err := s.Done("soil"); err != nil {
+		t.Fatalf("Done(soil) error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "Some notes.\n") || !strings.Contains(string(data), "  - [x] Buy soil") {
+		t.Errorf("hand-written content was not preserved:\n%s", data)
+	}
+}
+
+func TestPromptSection(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local)
+	if got := PromptSection(nil, now); got != "" {
+		t.Errorf("PromptSection(nil) = %q, want empty", got)
+	}
+	open := []Item{
+		{Text: "Call the dentist", Added: now.AddDate(0, 0, -3)},
+		{Text: "Water plants", Added: now.Add(-time.Hour)},
+		{Text: "Fix bike"},
+	}
+	got := PromptSection(open, now)
+	want := "The user's to-do list has 3 open tasks."
+	if !strings.Contains(got, want) || !strings.HasSuffix(got, "1. Call the dentist (added 3 days ago)\n2. Water plants (added today)\n3. Fix bike") {
+		t.Errorf("PromptSection() =\n%s", got)
+	}
+}
@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"Hello world", 2, 2},
+		{"The quick brown fox jumps over the lazy dog.", 10, 10},
+		// Code has far more tokens than chars/4 suggests
+		{`if err != nil { return fmt.Errorf("x: %w", err) }`, 17, 22},
+		// And so do scripts other than Latin
+		{"東京は日本の首都です。", 8, 16},
+		{"Привет, как дела?", 6, 10},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("Count(%q) = %d, want %d-%d", tt.text, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestCount_SpecialTokensAreText(t *testing.T) {
+	// Chat template markers in a message are counted as the text they are
+	if got := Count("<|endoftext|>"); got < 2 {
+		t.Errorf("Count(<|endoftext|>) = %d, want it split like ordinary text", got)
+	}
+}
+
+func TestCount_Long(t *testing.T) {
+	text := strings.Repeat("stefanclaw is a local assistant. ", 1000)
+	if got := Count(text); got < 9000 || got > 11000 {
+		t.Errorf("Count of a long text = %d, want 9000-11000", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog."
+	if got := Truncate(text, 100); got != text {
+		t.Errorf("Truncate within budget = %q, want the text", got)
+	}
+	if got := Truncate(text, 4); got != "The quick brown fox" {
+		t.Errorf("Truncate(4) = %q, want the first four tokens", got)
+	}
+	if got := Truncate(text, 0); got != "" {
+		t.Errorf("Truncate(0) = %q", got)
+	}
+	// Characters spread over several tokens aren't cut in half
+	for n := 1; n < Count("東京は日本の首都です。"); n++ {
+		if got := Truncate("東京は日本の首都です。", n); !utf8.ValidString(got) || Count(got) > n {
+			t.Errorf("Truncate(%d) = %q (%d tokens)", n, got, Count(got))
+		}
+	}
+}
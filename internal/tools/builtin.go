@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+)
+
+// Fetch returns a tool that fetches a web page as markdown.
+func Fetch(c *fetch.Client) Tool {
+	return Tool{
+		Name:        "fetch",
+		Description: "Fetch a web page and return its content as markdown.",
+		Params:      []Param{{Name: "url", Description: "full http(s) URL", Required: true}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			return c.Fetch(ctx, args["url"])
+		},
+	}
+}
+
+// WebSearch returns a tool that searches the web.
+func WebSearch(c *fetch.Client) Tool {
+	return Tool{
+		Name:        "web_search",
+		Description: "Search the web and return the top results with links.",
+		Params:      []Param{{Name: "query", Description: "search terms", Required: true}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			return c.Search(ctx, args["query"])
+		},
+	}
+}
+
+// Remember returns a tool that saves a fact about the user to memory.
+func Remember(s *memory.Store) Tool {
+	return Tool{
+		Name:        "memory_remember",
+		Description: "Save a lasting fact about the user (preference, decision, personal detail) to long-term memory.",
+		Params:      []Param{{Name: "fact", Description: "one short sentence", Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			if err := s.Append([]string{args["fact"]}); err != nil {
+				return "", err
+			}
+			return "Saved to memory.", nil
+		},
+	}
+}
+
+// MemorySearch returns a tool that searches long-term memory.
+func MemorySearch(s *memory.Store) Tool {
+	return Tool{
+		Name:        "memory_search",
+		Description: "Search long-term memory for facts containing a keyword.",
+		Params:      []Param{{Name: "keyword", Description: "word to look for", Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			matches, err := s.Search(args["keyword"])
+			if err != nil {
+				return "", err
+			}
+			if len(matches) == 0 {
+				return "No matching memories.", nil
+			}
+			return strings.Join(matches, "\n"), nil
+		},
+	}
+}
+
+// ReadFile returns a tool that reads text files under the allowed
+// directories dirs, which must be absolute. Relative paths are resolved
+// against the first directory and symlinks may not lead outside dirs. Files
+// larger than maxSize or that look binary are refused.
+func ReadFile(dirs []string, maxSize int64) Tool {
+	return Tool{
+		Name:        "read_file",
+		Description: "Read a text file. Allowed directories: " + strings.Join(dirs, ", ") + ".",
+		Params:      []Param{{Name: "path", Description: "absolute path, ~/path, or path relative to " + dirs[0], Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			path, err := filepath.EvalSymlinks(config.ExpandPath(args["path"], dirs[0]))
+			if err != nil {
+				return "", err
+			}
+			if !insideAny(path, dirs) {
+				return "", fmt.Errorf("%s is outside the allowed directories (%s)", args["path"], strings.Join(dirs, ", "))
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				return "", err
+			}
+			if info.IsDir() {
+				return "", fmt.Errorf("%s is a directory", args["path"])
+			}
+			if info.Size() > maxSize {
+				return "", fmt.Errorf("%s is too large (%d bytes, limit %d)", args["path"], info.Size(), maxSize)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			if isBinary(data) {
+				return "", fmt.Errorf("%s is a binary file", args["path"])
+			}
+			return string(data), nil
+		},
+	}
+}
+
+// insideAny reports whether path lies in one of dirs, following symlinks in
+// the directories themselves.
+func insideAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary reports whether data looks like a binary file: it contains a NUL
+// byte or is not valid UTF-8 within the first 8KB.
+func isBinary(data []byte) bool {
+	if len(data) > 8192 {
+		data = data[:8192]
+		// Don't count a multi-byte character cut off at the boundary
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) != -1 || !utf8.Valid(data)
+}
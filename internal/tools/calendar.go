@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+)
+
+// maxCalendarDays caps how far ahead the calendar tool lists events.
+const maxCalendarDays = 31
+
+// Calendar returns a tool that lists the user's events from c. now is the
+// clock, which tests replace.
+func Calendar(c *calendar.Cache, now func() time.Time) Tool {
+	return Tool{
+		Name:        "calendar",
+		Description: "List the user's calendar events for a day or range of days.",
+		Params: []Param{
+			{Name: "date", Description: "first day as YYYY-MM-DD; default today"},
+			{Name: "days", Description: fmt.Sprintf("number of days to list, 1-%d; default 1", maxCalendarDays)},
+		},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			t := now()
+			start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			if d := args["date"]; d != "" {
+				var err error
+				if start, err = time.ParseInLocation("2006-01-02", d, t.Location()); err != nil {
+					return "", fmt.Errorf("date must be YYYY-MM-DD, got %q", d)
+				}
+			}
+			days := 1
+			if d := args["days"]; d != "" {
+				n, err := strconv.Atoi(d)
+				if err != nil || n < 1 || n > maxCalendarDays {
+					return "", fmt.Errorf("days must be between 1 and %d", maxCalendarDays)
+				}
+				days = n
+			}
+
+			cal, err := c.Get(ctx)
+			if cal == nil {
+				return "", err
+			}
+			events := cal.Between(start, start.AddDate(0, 0, days))
+			if len(events) == 0 {
+				return "No events.", nil
+			}
+			var b strings.Builder
+			if err != nil {
+				fmt.Fprintf(&b, "(some calendars could not be loaded: %v)\n", err)
+			}
+			for _, e := range events {
+				fmt.Fprintf(&b, "%s %s\n", e.Start.In(t.Location()).Format("Mon 2006-01-02"), e)
+			}
+			return strings.TrimRight(b.String(), "\n"), nil
+		},
+	}
+}
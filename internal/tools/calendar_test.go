@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+)
+
+func TestCalendar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cal.ics")
+	os.WriteFile(path, []byte("BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20260302T090000Z\nDURATION:PT15M\nRRULE:FREQ=DAILY\nEND:VEVENT\n"), 0o644)
+	now := func() time.Time { return time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC) }
+	tool := Calendar(calendar.NewCache([]string{path}, time.Hour), now)
+
+	out, err := tool.Run(context.Background(), map[string]string{})
+	if err != nil || out != "Tue 2026-03-03 09:00–09:15 Standup" {
+		t.Errorf("today = %q, %v", out, err)
+	}
+	out, _ = tool.Run(context.Background(), map[string]string{"date": "2026-03-10", "days": "2"})
+	if lines := strings.Split(out, "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "Wed 2026-03-11") {
+		t.Errorf("range = %q", out)
+	}
+	if _, err := tool.Run(context.Background(), map[string]string{"days": "90"}); err == nil {
+		t.Error("days out of range should fail")
+	}
+	if out, _ := tool.Run(context.Background(), map[string]string{"date": "2026-03-01"}); out != "No events." {
+		t.Errorf("day before the first event = %q", out)
+	}
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time returns a tool that tells the current date and time, here or in
+// another time zone. now is the clock, which tests replace.
+func Time(now func() time.Time) Tool {
+	return Tool{
+		Name:        "time",
+		Description: "Get the current date, time and time zone. Use it instead of guessing.",
+		Params: []Param{{
+			Name:        "timezone",
+			Description: `IANA time zone such as "America/New_York" or "Asia/Tokyo"; default: the user's`,
+		}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			t := now()
+			if tz := strings.TrimSpace(args["timezone"]); tz != "" {
+				loc, err := time.LoadLocation(tz)
+				if err != nil {
+					return "", fmt.Errorf(`unknown time zone %q; use an IANA name such as "Europe/Berlin"`, tz)
+				}
+				t = t.In(loc)
+			}
+			_, week := t.ISOWeek()
+			return fmt.Sprintf("%s, %s (%s, UTC%s), week %d",
+				t.Format("Monday"), t.Format("2006-01-02 15:04"), t.Location(), t.Format("-07:00"), week), nil
+		},
+	}
+}
+
+var periodRe = regexp.MustCompile(`([+-]?)\s*(\d+)\s*([a-z]+)`)
+
+// period is a calendar-aware offset: months and years keep the day of the
+// month, days keep the time of day.
+type period struct {
+	years, months, days int
+	clock               time.Duration
+}
+
+// parsePeriod reads offsets such as "3 days", "-2w", "1 month 10 days" or
+// "+36h". A sign applies to the following terms until the next sign.
+func parsePeriod(s string) (period, error) {
+	var p period
+	s = strings.ToLower(strings.TrimSpace(s))
+	rest := periodRe.ReplaceAllString(s, "")
+	rest = strings.NewReplacer("and", "", ",", "").Replace(rest)
+	if strings.TrimSpace(rest) != "" || s == "" {
+		return p, fmt.Errorf(`unrecognized period %q; use e.g. "3 days", "-2 weeks" or "1 month 10 days"`, s)
+	}
+	sign := 1
+	for _, m := range periodRe.FindAllStringSubmatch(s, -1) {
+		switch m[1] {
+		case "-":
+			sign = -1
+		case "+":
+			sign = 1
+		}
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return p, fmt.Errorf("number too large: %s", m[2])
+		}
+		n *= sign
+		switch m[3] {
+		case "y", "yr", "yrs", "year", "years":
+			p.years += n
+		case "mo", "month", "months":
+			p.months += n
+		case "w", "wk", "wks", "week", "weeks":
+			p.days += 7 * n
+		case "d", "day", "days":
+			p.days += n
+		case "h", "hr", "hrs", "hour", "hours":
+			p.clock += time.Duration(n) * time.Hour
+		case "m", "min", "mins", "minute", "minutes":
+			p.clock += time.Duration(n) * time.Minute
+		default:
+			return p, fmt.Errorf("unknown unit %q in period", m[3])
+		}
+	}
+	return p, nil
+}
+
+func (p period) addTo(t time.Time) time.Time {
+	return t.AddDate(p.years, p.months, p.days).Add(p.clock)
+}
+
+// parseDate reads "YYYY-MM-DD" or "YYYY-MM-DD HH:MM"; empty, "now" and
+// "today" mean now. withTime reports whether a time of day was given.
+func parseDate(s string, now time.Time) (t time.Time, withTime bool, err error) {
+	switch s = strings.TrimSpace(strings.ToLower(s)); s {
+	case "", "now":
+		return now, true, nil
+	case "today":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), false, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", strings.Replace(s, "t", " ", 1), now.Location()); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf(`date must be YYYY-MM-DD or "YYYY-MM-DD HH:MM", got %q`, s)
+}
+
+func formatDate(t time.Time, withTime bool) string {
+	_, week := t.ISOWeek()
+	layout := "Monday, 2006-01-02"
+	if withTime {
+		layout += " 15:04"
+	}
+	return fmt.Sprintf("%s (week %d)", t.Format(layout), week)
+}
+
+// DateCalc returns a tool that does date arithmetic: adding a period to a
+// date, or counting the time between two dates. now is the clock, which
+// tests replace.
+func DateCalc(now func() time.Time) Tool {
+	return Tool{
+		Name:        "date_calc",
+		Description: "Calculate dates: add or subtract a period, or count the days between two dates. Gives the weekday too.",
+		Params: []Param{
+			{Name: "date", Description: `start date as YYYY-MM-DD or "YYYY-MM-DD HH:MM"; default now`},
+			{Name: "add", Description: `period to add, e.g. "3 days", "-2 weeks", "1 month 10 days" or "36h"`},
+			{Name: "until", Description: "end date as YYYY-MM-DD or \"YYYY-MM-DD HH:MM\", to count the time between date and it"},
+		},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			start, withTime, err := parseDate(args["date"], now())
+			if err != nil {
+				return "", err
+			}
+			// Without a date, whole-day arithmetic starts today rather than now
+			switch {
+			case args["add"] != "":
+				p, err := parsePeriod(args["add"])
+				if err != nil {
+					return "", err
+				}
+				if args["date"] == "" && p.clock == 0 {
+					start, withTime, _ = parseDate("today", now())
+				}
+				withTime = withTime || p.clock != 0
+				return fmt.Sprintf("%s %s = %s", formatDate(start, withTime), args["add"], formatDate(p.addTo(start), withTime)), nil
+
+			case args["until"] != "":
+				end, endTime, err := parseDate(args["until"], now())
+				if err != nil {
+					return "", err
+				}
+				if args["date"] == "" && !endTime {
+					start, withTime, _ = parseDate("today", now())
+				}
+				return fmt.Sprintf("From %s to %s: %s", formatDate(start, withTime || endTime), formatDate(end, withTime || endTime), between(start, end, withTime || endTime)), nil
+			}
+			return formatDate(start, withTime), nil
+		},
+	}
+}
+
+// between describes the time from start to end in days (and weeks), or in
+// days, hours and minutes when times of day matter.
+func between(start, end time.Time, withTime bool) string {
+	sign := ""
+	if end.Before(start) {
+		start, end, sign = end, start, "-"
+	}
+	if withTime {
+		d := end.Sub(start).Round(time.Minute)
+		days := int(d / (24 * time.Hour))
+		d -= time.Duration(days) * 24 * time.Hour
+		return fmt.Sprintf("%s%d days %d hours %d minutes", sign, days, int(d.Hours()), int(d.Minutes())%60)
+	}
+	// Count calendar days, so a DST change doesn't make a day short
+	a := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(b.Sub(a).Hours() / 24)
+	out := fmt.Sprintf("%s%d days", sign, days)
+	if days >= 7 {
+		out += fmt.Sprintf(" (%d weeks %d days)", days/7, days%7)
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	now := func() time.Time { return time.Date(2026, 10, 15, 14, 3, 0, 0, berlin) }
+	tool := Time(now)
+
+	out, err := tool.Run(context.Background(), map[string]string{})
+	if want := "Thursday, 2026-10-15 14:03 (Europe/Berlin, UTC+02:00), week 42"; err != nil || out != want {
+		t.Errorf("time = %q, %v, want %q", out, err, want)
+	}
+	out, err = tool.Run(context.Background(), map[string]string{"timezone": "Asia/Tokyo"})
+	if want := "Thursday, 2026-10-15 21:03 (Asia/Tokyo, UTC+09:00), week 42"; err != nil || out != want {
+		t.Errorf("time in Tokyo = %q, %v, want %q", out, err, want)
+	}
+	if _, err := tool.Run(context.Background(), map[string]string{"timezone": "Mars/Olympus"}); err == nil {
+		t.Error("unknown time zone should fail")
+	}
+}
+
+func TestDateCalc(t *testing.T) {
+	now := func() time.Time { return time.Date(2026, 10, 15, 14, 3, 0, 0, time.UTC) }
+	tool := DateCalc(now)
+	tests := []struct {
+		args map[string]string
+		want string
+	}{
+		{map[string]string{}, "Thursday, 2026-10-15 14:03 (week 42)"},
+		{map[string]string{"add": "3 weeks"}, "Thursday, 2026-10-15 (week 42) 3 weeks = Thursday, 2026-11-05 (week 45)"},
+		{map[string]string{"date": "2026-01-31", "add": "1 month"}, "Saturday, 2026-01-31 (week 5) 1 month = Tuesday, 2026-03-03 (week 10)"},
+		{map[string]string{"date": "2026-12-24", "add": "-1 month 10 days"}, "Thursday, 2026-12-24 (week 52) -1 month 10 days = Saturday, 2026-11-14 (week 46)"},
+		{map[string]string{"add": "36h"}, "Thursday, 2026-10-15 14:03 (week 42) 36h = Saturday, 2026-10-17 02:03 (week 42)"},
+		{map[string]string{"until": "2026-12-24"}, "From Thursday, 2026-10-15 (week 42) to Thursday, 2026-12-24 (week 52): 70 days (10 weeks 0 days)"},
+		{map[string]string{"date": "2026-10-20", "until": "2026-10-15"}, "From Tuesday, 2026-10-20 (week 43) to Thursday, 2026-10-15 (week 42): -5 days"},
+		{map[string]string{"until": "2026-10-16 09:00"}, "From Thursday, 2026-10-15 14:03 (week 42) to Friday, 2026-10-16 09:00 (week 42): 0 days 18 hours 57 minutes"},
+	}
+	for _, tt := range tests {
+		out, err := tool.Run(context.Background(), tt.args)
+		if err != nil || out != tt.want {
+			t.Errorf("date_calc(%v) = %q, %v\nwant %q", tt.args, out, err, tt.want)
+		}
+	}
+
+	for _, args := range []map[string]string{
+		{"date": "15.10.2026"},
+		{"add": "3 fortnights"},
+		{"add": "soon"},
+	} {
+		if _, err := tool.Run(context.Background(), args); err == nil {
+			t.Errorf("date_calc(%v) should fail", args)
+		}
+	}
+}
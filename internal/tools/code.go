@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// codeMemoryLimitKB caps the virtual memory of a code snippet (1 GiB).
+const codeMemoryLimitKB = 1 << 20
+
+// FindPython returns the path of the Python 3 interpreter, resolving version
+// manager shims to the real binary, or "" if none is installed.
+func FindPython() string {
+	for _, name := range []string{"python3", "python"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command(path, "-c", "import sys; print(sys.version_info[0], sys.executable)").Output()
+		if err != nil {
+			continue
+		}
+		major, exe, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+		if major == "3" && exe != "" {
+			return exe
+		}
+	}
+	return ""
+}
+
+// RunCode returns a tool that runs Python snippets with the interpreter at
+// python. Each run starts in isolated mode in an empty temporary directory
+// with the restricted environment of run_command, no stdin, and CPU time and
+// memory limits; it is killed after timeout. The limits are no sandbox: the
+// code can still read and write the user's files and reach the network, so
+// every run needs the user's approval and the tool is never offered where
+// nobody watches, such as jobs and heartbeats.
+func RunCode(python string, timeout time.Duration) Tool {
+	return Tool{
+		Name:        "run_code",
+		Description: "Run a short Python 3 program and return what it prints. Use it for arithmetic, date calculations and data transformations instead of guessing. Only the standard library is guaranteed; print the results you need.",
+		Params:      []Param{{Name: "code", Description: "Python 3 source", Required: true}},
+		Confirm:     true,
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			dir, err := os.MkdirTemp("", "stefanclaw-code-")
+			if err != nil {
+				return "", err
+			}
+			defer os.RemoveAll(dir)
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			// ulimit caps CPU seconds and memory for the interpreter; -I
+			// ignores PYTHON* variables and the user's site-packages
+			cpu := int(math.Ceil(timeout.Seconds()))
+			limits := fmt.Sprintf(`ulimit -t %d; ulimit -v %d 2>/dev/null; exec "$0" -I -c "$1"`, cpu, codeMemoryLimitKB)
+			cmd := exec.CommandContext(ctx, "/bin/sh", "-c", limits, python, args["code"])
+			cmd.Dir = dir
+			cmd.Env = append(restrictedEnv(), "HOME="+dir, "TMPDIR="+dir)
+			cmd.WaitDelay = time.Second
+			var stdout, stderr limitedBuffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			err = cmd.Run()
+			if ctx.Err() == context.DeadlineExceeded {
+				return "", fmt.Errorf("code timed out after %s", timeout)
+			}
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				return "", err
+			}
+			return formatCommandOutput(stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()), nil
+		},
+	}
+}
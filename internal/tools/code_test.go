@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pythonOrSkip(t *testing.T) string {
+	t.Helper()
+	python := FindPython()
+	if python == "" {
+		t.Skip("python3 not installed")
+	}
+	return python
+}
+
+func TestRunCode(t *testing.T) {
+	tool := RunCode(pythonOrSkip(t), 10*time.Second)
+	if !tool.Confirm {
+		t.Error("run_code should always require approval")
+	}
+
+	out, err := tool.Run(context.Background(), map[string]string{"code": "import datetime\nprint(2**64)\nprint(datetime.date(2026, 3, 1) - datetime.date(2026, 2, 1))"})
+	if err != nil || out != "exit code: 0\nstdout:\n18446744073709551616\n28 days, 0:00:00" {
+		t.Errorf("Run() = %q, %v", out, err)
+	}
+
+	out, err = tool.Run(context.Background(), map[string]string{"code": "1/0"})
+	if err != nil || !strings.Contains(out, "exit code: 1") || !strings.Contains(out, "ZeroDivisionError") {
+		t.Errorf("Run(1/0) = %q, %v", out, err)
+	}
+}
+
+func TestRunCode_Sandbox(t *testing.T) {
+	t.Setenv("STEFANCLAW_TEST_TOKEN", "secret")
+	tool := RunCode(pythonOrSkip(t), 10*time.Second)
+
+	out, err := tool.Run(context.Background(), map[string]string{"code": "import os\nprint('STEFANCLAW_TEST_TOKEN' in os.environ, os.getcwd() == os.environ['HOME'])"})
+	if err != nil || !strings.Contains(out, "False True") {
+		t.Errorf("Run() = %q, %v", out, err)
+	}
+}
+
+func TestRunCode_Timeout(t *testing.T) {
+	tool := RunCode(pythonOrSkip(t), 300*time.Millisecond)
+	_, err := tool.Run(context.Background(), map[string]string{"code": "while True: pass"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandEnv lists the environment variables passed through to commands.
+// Everything else, including API keys and tokens, is withheld.
+var commandEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR"}
+
+// RunCommand returns a tool that runs a shell command in dir. The command
+// gets a minimal environment, no stdin, and is killed after timeout. It
+// requires the user's confirmation before every run.
+func RunCommand(dir string, timeout time.Duration) Tool {
+	return Tool{
+		Name:        "run_command",
+		Description: fmt.Sprintf("Run a shell command in %s and return its output. The user must approve each command.", dir),
+		Params:      []Param{{Name: "command", Description: "a single sh command line", Required: true}},
+		Confirm:     true,
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, "/bin/sh", "-c", args["command"])
+			cmd.Dir = dir
+			cmd.Env = restrictedEnv()
+			cmd.WaitDelay = time.Second
+			var stdout, stderr limitedBuffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			err := cmd.Run()
+			if ctx.Err() == context.DeadlineExceeded {
+				return "", fmt.Errorf("command timed out after %s", timeout)
+			}
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				return "", err
+			}
+			return formatCommandOutput(stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()), nil
+		},
+	}
+}
+
+// restrictedEnv returns the allow-listed subset of the current environment.
+func restrictedEnv() []string {
+	env := []string{"TERM=dumb"}
+	for _, k := range commandEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// limitedBuffer keeps the first MaxResultSize bytes written to it and drops
+// the rest, so a command printing without end cannot exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	// One byte over the limit lets String cut on a rune boundary
+	if room := MaxResultSize + 1 - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the output, marked if some of it was dropped.
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return cut(b.buf.String(), MaxResultSize) + "\n[truncated]"
+	}
+	return b.buf.String()
+}
+
+func formatCommandOutput(stdout, stderr string, code int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "exit code: %d\n", code)
+	if s := strings.TrimRight(stdout, "\n"); s != "" {
+		b.WriteString("stdout:\n" + s + "\n")
+	}
+	if s := strings.TrimRight(stderr, "\n"); s != "" {
+		b.WriteString("stderr:\n" + s + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	tool := RunCommand(dir, 5*time.Second)
+	if !tool.Confirm {
+		t.Fatal("run_command must require confirmation")
+	}
+
+	out, err := tool.Run(context.Background(), map[string]string{"command": "pwd; echo oops >&2; exit 3"})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	for _, want := range []string{"exit code: 3", "stdout:\n" + dir, "stderr:\noops"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCommand_LimitsOutput(t *testing.T) {
+	tool := RunCommand(t.TempDir(), 5*time.Second)
+
+	out, err := tool.Run(context.Background(), map[string]string{"command": "yes é | head -c 1000000; echo done >&2"})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(out) > 2*MaxResultSize+100 || !utf8.ValidString(out) {
+		t.Errorf("output not limited to valid UTF-8 (len %d)", len(out))
+	}
+	if !strings.Contains(out, "[truncated]\nstderr:\ndone") {
+		t.Errorf("output lacks the truncation marker or stderr:\n...%s", out[len(out)-40:])
+	}
+}
+
+func TestRunCommand_RestrictedEnv(t *testing.T) {
+	t.Setenv("STEFANCLAW_TEST_TOKEN", "secret")
+	tool := RunCommand(t.TempDir(), 5*time.Second)
+
+	out, err := tool.Run(context.Background(), map[string]string{"command": "env"})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if strings.Contains(out, "STEFANCLAW_TEST_TOKEN") {
+		t.Errorf("environment not restricted:\n%s", out)
+	}
+	if !strings.Contains(out, "TERM=dumb") {
+		t.Errorf("expected TERM=dumb in:\n%s", out)
+	}
+}
+
+func TestRunCommand_Timeout(t *testing.T) {
+	tool := RunCommand(t.TempDir(), 100*time.Millisecond)
+	_, err := tool.Run(context.Background(), map[string]string{"command": "sleep 5"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
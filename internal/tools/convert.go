@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/units"
+)
+
+// Convert returns a tool that converts between units and, if rates is not
+// nil, between currencies.
+func Convert(rates *units.Rates) Tool {
+	desc := "Convert a value between units (length, mass, volume, area, speed, temperature, time, data, energy, pressure)"
+	if rates != nil {
+		desc += " or currencies (ECB reference rates)"
+	}
+	return Tool{
+		Name:        "convert",
+		Description: desc + ". Use it instead of converting in your head.",
+		Params: []Param{
+			{Name: "value", Description: "number to convert, e.g. 12.5", Required: true},
+			{Name: "from", Description: `unit or ISO currency code, e.g. "mi", "°F", "cups", "USD"`, Required: true},
+			{Name: "to", Description: `unit or currency code to convert to, e.g. "km", "C", "ml", "EUR"`, Required: true},
+		},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(args["value"]), 64)
+			if err != nil {
+				return "", fmt.Errorf("value must be a number such as 12.5, got %q", args["value"])
+			}
+			from, to := strings.TrimSpace(args["from"]), strings.TrimSpace(args["to"])
+
+			// Three-letter codes are currencies unless both are units ("cup" to "gal")
+			if units.IsCurrency(from) && units.IsCurrency(to) && !(units.Known(from) && units.Known(to)) {
+				if rates == nil {
+					return "", fmt.Errorf("currency conversion needs web access, which is disabled")
+				}
+				result, date, err := rates.Convert(ctx, value, from, to)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s %s = %s %s (ECB reference rate of %s)",
+					units.Format(value), strings.ToUpper(from), strconv.FormatFloat(result, 'f', 2, 64), strings.ToUpper(to), date), nil
+			}
+
+			result, err := units.Convert(value, from, to)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %s = %s %s", units.Format(value), from, units.Format(result), to), nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/units"
+)
+
+func TestConvertTool(t *testing.T) {
+	// Fresh rates in the cache file, so nothing is fetched
+	path := filepath.Join(t.TempDir(), "rates.json")
+	data, _ := json.Marshal(map[string]any{"base": "EUR", "date": "2026-10-14", "rates": map[string]float64{"USD": 1.25, "CUP": 30}, "fetched": time.Now()})
+	os.WriteFile(path, data, 0o644)
+	tool := Convert(units.NewRates(path))
+
+	tests := []struct {
+		value, from, to string
+		want            string
+	}{
+		{"26.2", "mi", "km", "26.2 mi = 42.164813 km"},
+		{"2", "cup", "gal", "2 cup = 0.125 gal"},
+		{"20", "usd", "EUR", "20 USD = 16.00 EUR (ECB reference rate of 2026-10-14)"},
+		{"10", "EUR", "CUP", "10 EUR = 300.00 CUP (ECB reference rate of 2026-10-14)"},
+	}
+	for _, tt := range tests {
+		out, err := tool.Run(context.Background(), map[string]string{"value": tt.value, "from": tt.from, "to": tt.to})
+		if err != nil || out != tt.want {
+			t.Errorf("convert(%s %s→%s) = %q, %v, want %q", tt.value, tt.from, tt.to, out, err, tt.want)
+		}
+	}
+
+	if _, err := tool.Run(context.Background(), map[string]string{"value": "1,5", "from": "kg", "to": "lb"}); err == nil {
+		t.Error("a malformed value should fail")
+	}
+	offline := Convert(nil)
+	if strings.Contains(offline.Description, "currencies") {
+		t.Error("without rates the tool should not offer currencies")
+	}
+	if _, err := offline.Run(context.Background(), map[string]string{"value": "1", "from": "USD", "to": "EUR"}); err == nil {
+		t.Error("currency conversion without rates should fail")
+	}
+}
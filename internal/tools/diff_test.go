@@ -0,0 +1,45 @@
+package tools
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name, old, new, want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"new file", "", "one\ntwo\n", "--- /dev/null\n+++ b/notes.md\n@@ -0,0 +1,2 @@\n+one\n+two\n"},
+		{
+			"change in middle",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- a/notes.md\n+++ b/notes.md\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"separate hunks",
+			"a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			"A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			"--- a/notes.md\n+++ b/notes.md\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			"append",
+			"x\ny\n",
+			"x\ny\nz\n",
+			"--- a/notes.md\n+++ b/notes.md\n@@ -1,2 +1,3 @@\n x\n y\n+z\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("notes.md", tt.old, tt.new); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	diff := UnifiedDiff("notes.md", "--- a\nkeep\nold\n", "keep\nnew\nmore\n")
+	added, removed := DiffStat(diff)
+	if added != 2 || removed != 2 {
+		t.Errorf("DiffStat() = +%d -%d, want +2 -2\n%s", added, removed, diff)
+	}
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stefanclaw/stefanclaw/internal/git"
+)
+
+// Git returns a tool that reads the state of the repository r: its status,
+// unstaged or staged diff, or recent history. It never changes the
+// repository.
+func Git(r *git.Repo) Tool {
+	return Tool{
+		Name:        "git",
+		Description: fmt.Sprintf("Inspect the git repository %s (read-only).", r.Name()),
+		Params: []Param{{
+			Name:        "what",
+			Description: `"status", "diff" (unstaged changes), "staged" or "log" (last 20 commits)`,
+			Required:    true,
+		}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			var out string
+			var err error
+			switch args["what"] {
+			case "status":
+				out, err = r.Status(ctx)
+			case "diff", "staged":
+				out, err = r.Diff(ctx, args["what"] == "staged")
+				if err == nil && out == "" {
+					out = "No changes."
+				}
+			case "log":
+				out, err = r.Log(ctx, 20)
+			default:
+				return "", fmt.Errorf(`what must be "status", "diff", "staged" or "log"`)
+			}
+			return out, err
+		},
+	}
+}
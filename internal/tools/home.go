@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+)
+
+// HomeState returns a tool that reads the state of the allowlisted Home
+// Assistant entities.
+func HomeState(c *homeassistant.Client, now func() time.Time) Tool {
+	return Tool{
+		Name:        "home_state",
+		Description: "Read the state of devices and sensors in the user's home (Home Assistant).",
+		Params: []Param{{
+			Name:        "entity",
+			Description: "entity ID such as binary_sensor.front_door; omit to list every visible entity",
+		}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			if id := args["entity"]; id != "" {
+				s, err := c.State(ctx, id)
+				if err != nil {
+					return "", err
+				}
+				return s.Describe(now()), nil
+			}
+			states, err := c.States(ctx)
+			if err != nil {
+				return "", err
+			}
+			if len(states) == 0 {
+				return "No entities are visible.", nil
+			}
+			lines := make([]string, len(states))
+			for i, s := range states {
+				lines[i] = s.Describe(now())
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	}
+}
+
+// HomeControl returns a tool that switches a controllable Home Assistant
+// entity. It requires the user's confirmation before every call.
+func HomeControl(c *homeassistant.Client) Tool {
+	return Tool{
+		Name:        "home_control",
+		Description: "Switch a device in the user's home (Home Assistant). The user must approve each call.",
+		Params: []Param{
+			{Name: "entity", Description: "entity ID such as light.kitchen", Required: true},
+			{Name: "service", Description: strings.Join(homeassistant.Services, ", "), Required: true},
+		},
+		Confirm: true,
+		// Calls that would be refused anyway are rejected without asking
+		Preview: func(args map[string]string) (string, error) {
+			if !c.CanControl(args["entity"]) {
+				return "", fmt.Errorf("%s is not in home_assistant.control", args["entity"])
+			}
+			if !slices.Contains(homeassistant.Services, args["service"]) {
+				return "", fmt.Errorf("unsupported service %q", args["service"])
+			}
+			return "", nil
+		},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			if err := c.Call(ctx, args["entity"], args["service"]); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Called %s on %s.", args["service"], args["entity"]), nil
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+)
+
+func TestHome(t *testing.T) {
+	changed := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	var called string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			called = r.URL.Path
+			w.Write([]byte("[]"))
+			return
+		}
+		json.NewEncoder(w).Encode([]homeassistant.State{
+			{EntityID: "light.kitchen", State: "on", LastChanged: changed},
+			{EntityID: "lock.front_door", State: "unlocked", LastChanged: changed},
+		})
+	}))
+	defer srv.Close()
+	c := homeassistant.New(config.HomeConfig{URL: srv.URL, Token: "t", Entities: []string{"lock.*"}, Control: []string{"light.kitchen"}}, nil)
+	now := func() time.Time { return changed.Add(30 * time.Minute) }
+
+	out, err := HomeState(c, now).Run(context.Background(), map[string]string{})
+	if want := "light.kitchen (light.kitchen): on for 30m\nlock.front_door (lock.front_door): unlocked for 30m"; err != nil || out != want {
+		t.Errorf("home_state = %q, %v, want %q", out, err, want)
+	}
+
+	control := HomeControl(c)
+	if !control.Confirm {
+		t.Error("home_control must require confirmation")
+	}
+	if _, err := control.Preview(map[string]string{"entity": "lock.front_door", "service": "unlock"}); err == nil {
+		t.Error("preview should reject an entity that is not controllable")
+	}
+	if _, err := control.Preview(map[string]string{"entity": "light.kitchen", "service": "turn_off"}); err != nil {
+		t.Errorf("preview error: %v", err)
+	}
+	if _, err := control.Run(context.Background(), map[string]string{"entity": "light.kitchen", "service": "turn_off"}); err != nil || called != "/api/services/light/turn_off" {
+		t.Errorf("home_control called %q, %v", called, err)
+	}
+}
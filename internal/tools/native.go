@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// Native tool calling passes the tools to providers that support it as
+// function specs instead of describing them in the system prompt. Calls the
+// model makes that way are turned into <tool_call> blocks, so everything
+// after the request handles them like calls made with the text protocol.
+
+// Specs describes the tools as functions for native tool calling. All
+// arguments are strings, as with the text protocol.
+func (r *Registry) Specs() []provider.Tool {
+	specs := make([]provider.Tool, len(r.tools))
+	for i, t := range r.tools {
+		props := make(map[string]any, len(t.Params))
+		required := []string{}
+		for _, p := range t.Params {
+			props[p.Name] = map[string]any{"type": "string", "description": p.Description}
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		specs[i] = provider.Tool{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  map[string]any{"type": "object", "properties": props, "required": required},
+		}
+	}
+	return specs
+}
+
+// Native rewrites req, built for the text protocol, for native tool
+// calling: the tools are passed as specs instead of in the system prompt,
+// and the calls and results in the history become tool calls and tool
+// messages.
+func (r *Registry) Native(req provider.ChatRequest) provider.ChatRequest {
+	instructions := r.SystemPrompt()
+	msgs := make([]provider.Message, 0, len(req.Messages))
+	var lastCall string
+	for _, m := range req.Messages {
+		switch {
+		case m.Role == "system":
+			m.Content = strings.TrimSpace(strings.Replace(m.Content, instructions, "", 1))
+			m.Content = strings.ReplaceAll(m.Content, "\n\n\n\n", "\n\n")
+			if m.Content == "" {
+				continue
+			}
+		case m.Role == "assistant" && HasCall(m.Content):
+			call, before, _, err := ParseCall(m.Content)
+			if err != nil {
+				break
+			}
+			m.Content = before
+			m.ToolCalls = []provider.ToolCall{{Name: call.Name, Arguments: call.Arguments}}
+			lastCall = call.Name
+		case (m.Role == "user" || m.Role == "tool") && IsResult(m.Content) && lastCall != "":
+			m.Role, m.ToolName = "tool", lastCall
+			lastCall = ""
+		}
+		msgs = append(msgs, m)
+	}
+	req.Messages = msgs
+	req.Tools = r.Specs()
+	return req
+}
+
+// FormatCalls formats the first of the calls a model made natively as a
+// <tool_call> block; like the text protocol, a reply makes one call at a
+// time. It returns "" without calls.
+func FormatCalls(calls []provider.ToolCall) string {
+	if len(calls) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(Call{Name: calls[0].Name, Arguments: calls[0].Arguments})
+	if err != nil {
+		return ""
+	}
+	return callOpen + string(data) + callClose
+}
+
+// native reports whether requests with r's tools go to p natively.
+func (r *Registry) native(p provider.Provider) bool {
+	tc, ok := p.(provider.ToolCaller)
+	return r != nil && r.Len() > 0 && ok && tc.CallsTools()
+}
+
+// Chat sends req, built for the text protocol, to p, passing r's tools
+// natively if p supports it. Models that can't call tools get req as it
+// is. A call made natively is appended to the reply as a <tool_call> block.
+// r may be nil.
+func (r *Registry) Chat(ctx context.Context, p provider.Provider, req provider.ChatRequest) (*provider.ChatResponse, error) {
+	if !r.native(p) {
+		return p.Chat(ctx, req)
+	}
+	resp, err := p.Chat(ctx, r.Native(req))
+	if errors.Is(err, provider.ErrToolsUnsupported) {
+		log.Info("model can't call tools natively, describing them in the prompt", "model", req.Model)
+		return p.Chat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Message.Content += FormatCalls(resp.Message.ToolCalls)
+	resp.Message.ToolCalls = nil
+	return resp, nil
+}
+
+// StreamChat is Chat for streaming requests: a call made natively arrives
+// as a delta holding its <tool_call> block.
+func (r *Registry) StreamChat(ctx context.Context, p provider.Provider, req provider.ChatRequest) (<-chan provider.StreamDelta, error) {
+	if !r.native(p) {
+		return p.StreamChat(ctx, req)
+	}
+	in, err := p.StreamChat(ctx, r.Native(req))
+	if errors.Is(err, provider.ErrToolsUnsupported) {
+		log.Info("model can't call tools natively, describing them in the prompt", "model", req.Model)
+		return p.StreamChat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan provider.StreamDelta)
+	go func() {
+		defer close(out)
+		for delta := range in {
+			delta.Content += FormatCalls(delta.ToolCalls)
+			delta.ToolCalls = nil
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// textRequest builds a request for the text protocol with one tool call and
+// its result in the history.
+func textRequest(r *Registry) provider.ChatRequest {
+	return provider.ChatRequest{Model: "qwen3", Messages: []provider.Message{
+		{Role: "system", Content: "You are helpful.\n\n" + r.SystemPrompt()},
+		{Role: "user", Content: "Say hi"},
+		{Role: "assistant", Content: "Sure.\n" + `<tool_call>{"name": "echo", "arguments": {"text": "hi"}}</tool_call>`},
+		{Role: "user", Content: FormatResult("echo", "hi", nil)},
+	}}
+}
+
+func TestRegistry_Specs(t *testing.T) {
+	specs := NewRegistry(echoTool()).Specs()
+	if len(specs) != 1 || specs[0].Name != "echo" || specs[0].Description != "Echo the text back." {
+		t.Fatalf("specs = %+v", specs)
+	}
+	params := specs[0].Parameters
+	props := params["properties"].(map[string]any)
+	if params["type"] != "object" || props["text"].(map[string]any)["type"] != "string" {
+		t.Errorf("parameters = %v", params)
+	}
+	if req := params["required"].([]string); len(req) != 1 || req[0] != "text" {
+		t.Errorf("required = %v", req)
+	}
+}
+
+func TestRegistry_Native(t *testing.T) {
+	r := NewRegistry(echoTool())
+	req := r.Native(textRequest(r))
+
+	if len(req.Tools) != 1 {
+		t.Errorf("tools = %+v", req.Tools)
+	}
+	msgs := req.Messages
+	if len(msgs) != 4 {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	if msgs[0].Content != "You are helpful." {
+		t.Errorf("system prompt = %q, want it without the tool instructions", msgs[0].Content)
+	}
+	if call := msgs[2].ToolCalls; msgs[2].Content != "Sure." || len(call) != 1 || call[0].Name != "echo" || call[0].Arguments["text"] != "hi" {
+		t.Errorf("assistant message = %+v", msgs[2])
+	}
+	if msgs[3].Role != "tool" || msgs[3].ToolName != "echo" {
+		t.Errorf("result message = %+v", msgs[3])
+	}
+}
+
+func TestRegistry_ChatNative(t *testing.T) {
+	r := NewRegistry(echoTool())
+	p := &providertest.Fake{NativeTools: true, Replies: []providertest.Reply{
+		{ToolCalls: []provider.ToolCall{{Name: "echo", Arguments: map[string]any{"text": "hi"}}}},
+	}}
+
+	resp, err := r.Chat(context.Background(), p, textRequest(r))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	call, _, found, err := ParseCall(resp.Message.Content)
+	if !found || err != nil || call.Name != "echo" || call.Args()["text"] != "hi" {
+		t.Errorf("reply = %q, want the call as a <tool_call> block", resp.Message.Content)
+	}
+	if len(p.LastRequest().Tools) != 1 {
+		t.Error("tools were not passed natively")
+	}
+
+	ch, err := r.StreamChat(context.Background(), p, textRequest(r))
+	if err != nil {
+		t.Fatalf("StreamChat: %v", err)
+	}
+	var content strings.Builder
+	for d := range ch {
+		content.WriteString(d.Content)
+	}
+	if !HasCall(content.String()) {
+		t.Errorf("streamed reply = %q, want the call as a <tool_call> block", content.String())
+	}
+}
+
+func TestRegistry_ChatFallsBackToText(t *testing.T) {
+	r := NewRegistry(echoTool())
+	unsupported := fmt.Errorf("gemma3: %w", provider.ErrToolsUnsupported)
+	p := &providertest.Fake{NativeTools: true, Replies: []providertest.Reply{{Err: unsupported}, providertest.Text("Hi!")}}
+
+	resp, err := r.Chat(context.Background(), p, textRequest(r))
+	if err != nil || resp.Message.Content != "Hi!" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	retry := p.LastRequest()
+	if len(retry.Tools) != 0 || !strings.Contains(retry.Messages[0].Content, "# Tools") {
+		t.Errorf("retry = %+v, want the text protocol", retry)
+	}
+
+	// Without native tool calling the request goes out as it is
+	p = &providertest.Fake{Replies: []providertest.Reply{{Err: errors.New("down")}}}
+	if _, err := r.Chat(context.Background(), p, textRequest(r)); err == nil || len(p.Requests()) != 1 || len(p.LastRequest().Tools) != 0 {
+		t.Errorf("requests = %+v, err = %v", p.Requests(), err)
+	}
+}
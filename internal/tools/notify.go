@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+// Notify returns a tool that lets the model notify the user. Each
+// notification must name one of the configured categories, and at most
+// cfg.PerHour pass limiter per hour. send delivers the event.
+func Notify(cfg config.NotifyToolConfig, limiter *notify.Limiter, now func() time.Time, send func(context.Context, notify.Event) error) Tool {
+	return Tool{
+		Name:        "notify",
+		Description: "Send the user a notification that reaches them away from this chat. Use it sparingly, only for things they would want to be interrupted for.",
+		Params: []Param{
+			{Name: "title", Description: "a few words", Required: true},
+			{Name: "body", Description: "one or two sentences", Required: true},
+			{Name: "category", Description: "one of: " + strings.Join(cfg.Categories, ", "), Required: true},
+		},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			title, body := strings.TrimSpace(args["title"]), strings.TrimSpace(args["body"])
+			if title == "" || body == "" {
+				return "", fmt.Errorf("title and body are required")
+			}
+			if !slices.Contains(cfg.Categories, args["category"]) {
+				return "", fmt.Errorf("category %q is not allowed; use one of: %s", args["category"], strings.Join(cfg.Categories, ", "))
+			}
+			if !limiter.Allow(cfg.PerHour, now()) {
+				return "", fmt.Errorf("not sent: the limit of %d notifications per hour is reached", cfg.PerHour)
+			}
+			ev := notify.Event{Kind: notify.EventAssistant, Title: title, Message: body, Time: now()}
+			if err := send(ctx, ev); err != nil {
+				return "", err
+			}
+			return "Notification sent.", nil
+		},
+	}
+}
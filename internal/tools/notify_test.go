@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+func TestNotify(t *testing.T) {
+	var sent []notify.Event
+	now := func() time.Time { return time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC) }
+	tool := Notify(config.NotifyToolConfig{Categories: []string{"alert"}, PerHour: 1}, &notify.Limiter{}, now,
+		func(ctx context.Context, ev notify.Event) error {
+			sent = append(sent, ev)
+			return nil
+		})
+	if tool.Confirm {
+		t.Error("notify should not need confirmation, so heartbeats can use it")
+	}
+
+	if _, err := tool.Run(context.Background(), map[string]string{"title": "Hi", "body": "Chat?", "category": "gossip"}); err == nil {
+		t.Error("an unapproved category should be refused")
+	}
+	out, err := tool.Run(context.Background(), map[string]string{"title": "Door", "body": "The front door is open.", "category": "alert"})
+	if err != nil || out != "Notification sent." {
+		t.Fatalf("notify = %q, %v", out, err)
+	}
+	if len(sent) != 1 || sent[0].Kind != notify.EventAssistant || sent[0].Title != "Door" {
+		t.Errorf("sent = %+v", sent)
+	}
+	_, err = tool.Run(context.Background(), map[string]string{"title": "Door", "body": "Still open.", "category": "alert"})
+	if err == nil || !strings.Contains(err.Error(), "per hour") {
+		t.Errorf("second notification within the hour: %v, want the rate limit", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("rate-limited notification was sent")
+	}
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
+)
+
+// OCR returns a tool that reads the text in an image under dirs, which must
+// be absolute, or in the newest image in the screenshot directories shots.
+func OCR(r *ocr.Reader, dirs, shots []string) Tool {
+	allowed := append(append([]string(nil), dirs...), shots...)
+	return Tool{
+		Name:        "ocr",
+		Description: "Read the text in an image, e.g. an error dialog the user took a screenshot of.",
+		Params: []Param{{
+			Name:        "path",
+			Description: "image file (absolute or ~/path); default: the user's latest screenshot",
+		}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			path := args["path"]
+			if path == "" {
+				latest, err := ocr.Latest(shots)
+				if err != nil {
+					return "", err
+				}
+				path = latest
+			} else {
+				base := ""
+				if len(allowed) > 0 {
+					base = allowed[0]
+				}
+				resolved, err := filepath.EvalSymlinks(config.ExpandPath(path, base))
+				if err != nil {
+					return "", err
+				}
+				if !insideAny(resolved, allowed) {
+					return "", fmt.Errorf("%s is outside the allowed directories (%s)", path, strings.Join(allowed, ", "))
+				}
+				if !ocr.IsImage(resolved) {
+					return "", fmt.Errorf("%s is not an image", path)
+				}
+				path = resolved
+			}
+
+			text, err := r.Read(ctx, path)
+			if err != nil {
+				return "", err
+			}
+			if text == "" {
+				return "No text found in " + path + ".", nil
+			}
+			return "Text in " + path + ":\n" + text, nil
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
+)
+
+func TestOCR(t *testing.T) {
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "tesseract"), []byte("#!/bin/sh\necho \"text of $1\"\n"), 0o755)
+	t.Setenv("PATH", bin)
+
+	docs, shots, other := t.TempDir(), t.TempDir(), t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	os.WriteFile(filepath.Join(shots, "old.png"), nil, 0o644)
+	os.Chtimes(filepath.Join(shots, "old.png"), old, old)
+	os.WriteFile(filepath.Join(shots, "new.png"), nil, 0o644)
+	os.WriteFile(filepath.Join(docs, "scan.jpg"), nil, 0o644)
+	os.WriteFile(filepath.Join(docs, "notes.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(other, "secret.png"), nil, 0o644)
+
+	tool := OCR(ocr.New(config.OCRConfig{Engine: "auto"}, nil), []string{docs}, []string{shots})
+	run := func(path string) (string, error) {
+		return tool.Run(context.Background(), map[string]string{"path": path})
+	}
+
+	if out, err := run(""); err != nil || !strings.HasSuffix(out, "text of "+filepath.Join(shots, "new.png")) {
+		t.Errorf("latest screenshot = %q, %v", out, err)
+	}
+	if out, err := run("scan.jpg"); err != nil || !strings.Contains(out, "scan.jpg") {
+		t.Errorf("relative path = %q, %v", out, err)
+	}
+	if _, err := run(filepath.Join(other, "secret.png")); err == nil || !strings.Contains(err.Error(), "outside the allowed directories") {
+		t.Errorf("outside path error = %v", err)
+	}
+	if _, err := run(filepath.Join(docs, "notes.txt")); err == nil || !strings.Contains(err.Error(), "not an image") {
+		t.Errorf("text file error = %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/git"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+	"github.com/stefanclaw/stefanclaw/internal/units"
+)
+
+// Setup is what Build assembles the tools from: the settings, and the
+// clients and stores the tools share with the rest of the program. A nil
+// client or store leaves out the tools that need it.
+type Setup struct {
+	Agent   config.AgentConfig
+	Privacy config.PrivacyConfig
+	Notify  config.NotifyToolConfig
+	WorkDir string // git, run_command and relative file tool directories need it
+
+	Provider      provider.Provider // reads images for the ocr tool
+	Fetch         *fetch.Client
+	Rates         *units.Rates // nil converts without currencies
+	Memory        *memory.Store
+	Todos         *todo.Store
+	Calendar      *calendar.Cache
+	Home          *homeassistant.Client
+	Notifier      *notify.Notifier
+	NotifyLimiter *notify.Limiter
+	Plugins       []Tool // built-in tools of the same name take precedence
+	Now           func() time.Time
+	FindPython    func() string // FindPython if nil
+}
+
+// Build assembles the tools the model may call, honoring the agent and
+// privacy settings. With the agent disabled it returns nil. The TUI and the
+// unattended runner of jobs and chat bridges share it; the runner leaves
+// out the tools that need approval, and chat bridges keep only a few.
+func Build(s Setup) *Registry {
+	if !s.Agent.Enabled {
+		return nil
+	}
+	now := s.Now
+	if now == nil {
+		now = time.Now
+	}
+	r := NewRegistry()
+	r.Register(Time(now))
+	r.Register(DateCalc(now))
+	r.Register(Convert(s.Rates))
+	if !s.Privacy.DisableWeb && s.Fetch != nil {
+		r.Register(WebSearch(s.Fetch))
+		r.Register(Fetch(s.Fetch))
+	}
+	if s.Memory != nil {
+		r.Register(MemorySearch(s.Memory))
+		if !s.Privacy.DisableAutoMemory {
+			r.Register(Remember(s.Memory))
+		}
+	}
+	if s.Todos != nil {
+		r.Register(TodoList(s.Todos, now))
+		r.Register(TodoAdd(s.Todos, now))
+		r.Register(TodoDone(s.Todos))
+	}
+	if s.Calendar != nil {
+		r.Register(Calendar(s.Calendar, now))
+	}
+	if nt := s.Notify; nt.Enabled && (nt.Desktop || s.Notifier.Wants(notify.EventAssistant)) {
+		n := s.Notifier
+		r.Register(Notify(nt, s.NotifyLimiter, now, func(ctx context.Context, ev notify.Event) error {
+			return n.Deliver(ctx, ev, nt.Desktop)
+		}))
+	}
+	if home := s.Home; home.Configured() {
+		r.Register(HomeState(home, now))
+		if home.Controls() {
+			r.Register(HomeControl(home))
+		}
+	}
+	if s.WorkDir != "" {
+		if repo, err := git.Open(s.WorkDir); err == nil {
+			r.Register(Git(repo))
+		}
+	}
+	if t := s.Agent.Terminal; t.Enabled {
+		r.Register(Terminal(t.Lines))
+	}
+	readDirs := ExpandDirs(s.Agent.ReadFile.AllowedDirs, s.WorkDir)
+	if o := s.Agent.OCR; o.Enabled {
+		r.Register(OCR(ocr.New(o, s.Provider), readDirs, ocr.ScreenshotDirs(o.ScreenshotDir)))
+	}
+	if len(readDirs) > 0 {
+		maxSize := int64(s.Agent.ReadFile.MaxSize)
+		if maxSize <= 0 {
+			maxSize = 64 * 1024
+		}
+		r.Register(ReadFile(readDirs, maxSize))
+	}
+	if dirs := ExpandDirs(s.Agent.WriteFile.AllowedDirs, s.WorkDir); s.Agent.WriteFile.Enabled && len(dirs) > 0 {
+		r.Register(WriteFile(dirs))
+		r.Register(EditFile(dirs))
+	}
+	for _, p := range s.Plugins {
+		if _, ok := r.Get(p.Name); !ok {
+			r.Register(p)
+		}
+	}
+	if s.WorkDir != "" {
+		if rc := s.Agent.RunCommand; rc.Enabled {
+			timeout, _ := time.ParseDuration(rc.Timeout)
+			if timeout <= 0 {
+				timeout = 30 * time.Second
+			}
+			r.Register(RunCommand(s.WorkDir, timeout))
+		}
+	}
+	if rc := s.Agent.RunCode; rc.Enabled {
+		find := s.FindPython
+		if find == nil {
+			find = FindPython
+		}
+		if python := find(); python != "" {
+			timeout, _ := time.ParseDuration(rc.Timeout)
+			if timeout <= 0 {
+				timeout = 10 * time.Second
+			}
+			r.Register(RunCode(python, timeout))
+		}
+	}
+	return r
+}
+
+// ExpandDirs turns configured directories into absolute paths. Relative
+// entries need a start directory and are skipped without one.
+func ExpandDirs(list []string, workDir string) []string {
+	var dirs []string
+	for _, d := range list {
+		if d == "" || (workDir == "" && !filepath.IsAbs(d) && !strings.HasPrefix(d, "~")) {
+			continue
+		}
+		dirs = append(dirs, config.ExpandPath(d, workDir))
+	}
+	return dirs
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+func TestBuild(t *testing.T) {
+	if r := Build(Setup{}); r != nil {
+		t.Errorf("Build() with the agent off = %v, want nil", r.List())
+	}
+
+	s := Setup{
+		Agent: config.AgentConfig{
+			Enabled:   true,
+			Terminal:  config.TerminalConfig{Enabled: true, Lines: 50},
+			ReadFile:  config.ReadFileConfig{AllowedDirs: []string{"."}},
+			WriteFile: config.WriteFileConfig{Enabled: true, AllowedDirs: []string{"."}},
+			RunCode:   config.RunCodeConfig{Enabled: true},
+		},
+		WorkDir:    t.TempDir(),
+		Plugins:    []Tool{{Name: "time", Description: "a plugin"}, {Name: "weather"}},
+		FindPython: func() string { return "/usr/bin/python3" },
+	}
+	r := Build(s)
+	for _, name := range []string{"time", "convert", "terminal", "read_file", "write_file", "edit_file", "weather", "run_code"} {
+		if _, ok := r.Get(name); !ok {
+			t.Errorf("Build() lacks %s", name)
+		}
+	}
+	if tool, _ := r.Get("time"); tool.Description == "a plugin" {
+		t.Error("a plugin replaced the built-in time tool")
+	}
+	for _, name := range []string{"web_search", "git", "run_command"} {
+		if _, ok := r.Get(name); ok {
+			t.Errorf("Build() offers %s without what it needs", name)
+		}
+	}
+
+	// Relative directories need a start directory
+	s.WorkDir = ""
+	if _, ok := Build(s).Get("read_file"); ok {
+		t.Error("read_file offered without a directory to read")
+	}
+}
+
+func TestExpandDirs(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	list := []string{".", "~", "/srv/notes"}
+	if got, want := ExpandDirs(list, "/work"), []string{"/work", home, "/srv/notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandDirs() = %v, want %v", got, want)
+	}
+	// Without a start directory, relative entries are dropped
+	if got, want := ExpandDirs(list, ""), []string{home, "/srv/notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandDirs() without a start directory = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/stefanclaw/stefanclaw/internal/terminal"
+)
+
+// Terminal returns a tool that reads the last lines of the user's other tmux
+// pane or their shell history, so the model can see what a command printed.
+func Terminal(lines int) Tool {
+	return Tool{
+		Name:        "terminal",
+		Description: "Read the user's recent terminal output, e.g. to see why a command failed.",
+		Params: []Param{{
+			Name:        "source",
+			Description: `"pane" (output of the user's other tmux pane) or "history" (recent shell commands); default: pane inside tmux, else history`,
+		}},
+		Run: func(ctx context.Context, args map[string]string) (string, error) {
+			snap, err := terminal.Capture(ctx, args["source"], lines)
+			if err != nil {
+				return "", err
+			}
+			if snap.Text == "" {
+				return "The " + snap.Source + " is empty.", nil
+			}
+			return snap.Text, nil
+		},
+	}
+}
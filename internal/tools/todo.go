@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+)
+
+// TodoList returns a tool that lists the user's open tasks. now is the
+// clock, which tests replace.
+func TodoList(s *todo.Store, now func() time.Time) Tool {
+	return Tool{
+		Name:        "todo_list",
+		Description: "List the open tasks on the user's to-do list, numbered.",
+		Run: func(context.Context, map[string]string) (string, error) {
+			open, err := s.Open()
+			if err != nil {
+				return "", err
+			}
+			if len(open) == 0 {
+				return "No open tasks.", nil
+			}
+			return todo.Format(open, now()), nil
+		},
+	}
+}
+
+// TodoAdd returns a tool that adds a task to the user's to-do list.
+func TodoAdd(s *todo.Store, now func() time.Time) Tool {
+	return Tool{
+		Name:        "todo_add",
+		Description: "Add a task to the user's to-do list when they ask you to note something they need to do.",
+		Params:      []Param{{Name: "task", Description: "short imperative, e.g. \"Call the dentist\"", Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			item, err := s.Add(args["task"], now())
+			if err != nil {
+				return "", err
+			}
+			return "Added: " + item.Text, nil
+		},
+	}
+}
+
+// TodoDone returns a tool that checks off a task on the user's to-do list.
+func TodoDone(s *todo.Store) Tool {
+	return Tool{
+		Name:        "todo_done",
+		Description: "Check off a task on the user's to-do list once they say it is done.",
+		Params:      []Param{{Name: "task", Description: "number from todo_list or words from the task", Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			item, err := s.Done(args["task"])
+			if err != nil {
+				return "", err
+			}
+			return "Done: " + item.Text, nil
+		},
+	}
+}
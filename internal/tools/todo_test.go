@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+)
+
+func TestTodo(t *testing.T) {
+	s := todo.NewStore(filepath.Join(t.TempDir(), "TASKS.md"))
+	now := func() time.Time { return time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local) }
+	ctx := context.Background()
+
+	if out, err := TodoList(s, now).Run(ctx, nil); err != nil || out != "No open tasks." {
+		t.Errorf("todo_list = %q, %v", out, err)
+	}
+	if out, err := TodoAdd(s, now).Run(ctx, map[string]string{"task": "Book flights"}); err != nil || out != "Added: Book flights" {
+		t.Errorf("todo_add = %q, %v", out, err)
+	}
+	if out, err := TodoList(s, now).Run(ctx, nil); err != nil || out != "1. Book flights (added today)" {
+		t.Errorf("todo_list = %q, %v", out, err)
+	}
+	if out, err := TodoDone(s).Run(ctx, map[string]string{"task": "flights"}); err != nil || out != "Done: Book flights" {
+		t.Errorf("todo_done = %q, %v", out, err)
+	}
+	if _, err := TodoDone(s).Run(ctx, map[string]string{"task": "flights"}); err == nil {
+		t.Error("todo_done on a finished task should fail")
+	}
+}
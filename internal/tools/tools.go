@@ -0,0 +1,248 @@
+// Package tools defines the capabilities the model can invoke on its own
+// during a conversation and the text protocol used to call them.
+//
+// Tool calls are requested in the model's reply as a single block:
+//
+//	<tool_call>{"name": "web_search", "arguments": {"query": "go 1.25 release"}}</tool_call>
+//
+// stefanclaw runs the tool and answers with a <tool_result> message.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxResultSize caps the characters of tool output fed back to the model.
+const MaxResultSize = 8000
+
+// Param describes one argument of a tool.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Tool is a capability the model can call. Tools with Confirm set act on the
+// user's machine and only run after the user approves the call. Preview, if
+// set, describes what a call would do (e.g. a diff) for the approval prompt;
+// an error rejects the call without asking.
+type Tool struct {
+	Name        string
+	Description string
+	Params      []Param
+	Confirm     bool
+	Preview     func(args map[string]string) (string, error)
+	Run         func(ctx context.Context, args map[string]string) (string, error)
+}
+
+// Call is a tool invocation requested by the model.
+type Call struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// Args returns the call's arguments as strings.
+func (c Call) Args() map[string]string {
+	args := make(map[string]string, len(c.Arguments))
+	for k, v := range c.Arguments {
+		if s, ok := v.(string); ok {
+			args[k] = s
+		} else {
+			args[k] = fmt.Sprint(v)
+		}
+	}
+	return args
+}
+
+// String formats the call for display, e.g. `web_search(query="go")`.
+func (c Call) String() string {
+	keys := make([]string, 0, len(c.Arguments))
+	for k := range c.Arguments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := c.Args()
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		v := args[k]
+		if len(v) > 60 {
+			v = cut(v, 57) + "..."
+		}
+		parts[i] = fmt.Sprintf("%s=%q", k, v)
+	}
+	return c.Name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// Registry holds the tools available to the model.
+type Registry struct {
+	tools []Tool
+}
+
+// NewRegistry creates a registry with the given tools.
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{}
+	for _, t := range tools {
+		r.Register(t)
+	}
+	return r
+}
+
+// Register adds a tool, replacing any tool with the same name.
+func (r *Registry) Register(t Tool) {
+	for i := range r.tools {
+		if r.tools[i].Name == t.Name {
+			r.tools[i] = t
+			return
+		}
+	}
+	r.tools = append(r.tools, t)
+}
+
+// Get returns the named tool.
+func (r *Registry) Get(name string) (Tool, bool) {
+	for _, t := range r.tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tool{}, false
+}
+
+// List returns the registered tools in registration order.
+func (r *Registry) List() []Tool {
+	return append([]Tool(nil), r.tools...)
+}
+
+// Filter returns a registry holding the tools for which keep returns true.
+func (r *Registry) Filter(keep func(Tool) bool) *Registry {
+	f := &Registry{}
+	for _, t := range r.tools {
+		if keep(t) {
+			f.tools = append(f.tools, t)
+		}
+	}
+	return f
+}
+
+// Len returns the number of registered tools.
+func (r *Registry) Len() int {
+	return len(r.tools)
+}
+
+// SystemPrompt returns the instructions that teach the model the tool call
+// protocol and list the available tools. It is empty when there are no tools.
+func (r *Registry) SystemPrompt() string {
+	if len(r.tools) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# Tools\n\n")
+	b.WriteString("You can use tools to look things up or act for the user. To call a tool, reply with exactly one tool call and nothing after it:\n\n")
+	b.WriteString(`<tool_call>{"name": "TOOL_NAME", "arguments": {"ARGUMENT": "VALUE"}}</tool_call>`)
+	b.WriteString("\n\nThe result arrives in a <tool_result> message. Use tools only when they help answer the user; otherwise answer directly. You may call several tools in a row, one per reply, then give your final answer without a tool call.\n\nAvailable tools:\n")
+	for _, t := range r.tools {
+		names := make([]string, len(t.Params))
+		for i, p := range t.Params {
+			names[i] = p.Name
+			if !p.Required {
+				names[i] += "?"
+			}
+		}
+		fmt.Fprintf(&b, "- %s(%s): %s\n", t.Name, strings.Join(names, ", "), t.Description)
+		for _, p := range t.Params {
+			fmt.Fprintf(&b, "    %s: %s\n", p.Name, p.Description)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Execute validates and runs a call. Output longer than MaxResultSize is
+// truncated.
+func (r *Registry) Execute(ctx context.Context, call Call) (string, error) {
+	t, ok := r.Get(call.Name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+	args := call.Args()
+	for _, p := range t.Params {
+		if p.Required && strings.TrimSpace(args[p.Name]) == "" {
+			return "", fmt.Errorf("missing required argument %q", p.Name)
+		}
+	}
+	out, err := t.Run(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	if len(out) > MaxResultSize {
+		out = cut(out, MaxResultSize) + "\n[truncated]"
+	}
+	return out, nil
+}
+
+// cut shortens s to at most n bytes without splitting a UTF-8 sequence.
+func cut(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+const (
+	callOpen  = "<tool_call>"
+	callClose = "</tool_call>"
+)
+
+// ParseCall looks for a tool call in a model reply. It returns the call, the
+// text the model wrote before it, and whether a call was found. A block whose
+// JSON cannot be parsed is reported as an error so the model can correct it.
+func ParseCall(content string) (call Call, before string, found bool, err error) {
+	start := strings.Index(content, callOpen)
+	if start == -1 {
+		return Call{}, content, false, nil
+	}
+	before = strings.TrimSpace(content[:start])
+	body := content[start+len(callOpen):]
+	if end := strings.Index(body, callClose); end != -1 {
+		body = body[:end]
+	}
+	body = strings.TrimSpace(body)
+	// Tolerate models that wrap the JSON in a code fence
+	body = strings.TrimPrefix(body, "```json")
+	body = strings.Trim(body, "`\n ")
+
+	if err := json.Unmarshal([]byte(body), &call); err != nil {
+		return Call{}, before, true, fmt.Errorf("invalid tool call JSON: %w", err)
+	}
+	if call.Name == "" {
+		return Call{}, before, true, fmt.Errorf("tool call has no name")
+	}
+	return call, before, true, nil
+}
+
+// HasCall reports whether content contains a tool call block.
+func HasCall(content string) bool {
+	return strings.Contains(content, callOpen)
+}
+
+const resultOpen = "<tool_result"
+
+// FormatResult wraps a tool's output (or error) for the model.
+func FormatResult(name, output string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("<tool_result name=%q error=\"true\">\n%v\n</tool_result>", name, err)
+	}
+	return fmt.Sprintf("<tool_result name=%q>\n%s\n</tool_result>", name, output)
+}
+
+// IsResult reports whether content is a formatted tool result.
+func IsResult(content string) bool {
+	return strings.HasPrefix(content, resultOpen)
+}
@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func echoTool() Tool {
+	return Tool{
+		Name:        "echo",
+		Description: "Echo the text back.",
+		Params:      []Param{{Name: "text", Description: "text to echo", Required: true}},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			return args["text"], nil
+		},
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		found   bool
+		wantErr bool
+		tool    string
+		before  string
+	}{
+		{"plain reply", "Just an answer.", false, false, "", "Just an answer."},
+		{"call", `<tool_call>{"name": "echo", "arguments": {"text": "hi"}}</tool_call>`, true, false, "echo", ""},
+		{"text before", "Checking.\n<tool_call>{\"name\": \"echo\"}</tool_call>", true, false, "echo", "Checking."},
+		{"unclosed", `<tool_call>{"name": "echo"}`, true, false, "echo", ""},
+		{"fenced", "<tool_call>\n```json\n{\"name\": \"echo\"}\n```\n</tool_call>", true, false, "echo", ""},
+		{"bad json", `<tool_call>{name: echo}</tool_call>`, true, true, "", ""},
+		{"no name", `<tool_call>{"arguments": {}}</tool_call>`, true, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call, before, found, err := ParseCall(tt.content)
+			if found != tt.found || (err != nil) != tt.wantErr {
+				t.Fatalf("found=%t err=%v, want found=%t wantErr=%t", found, err, tt.found, tt.wantErr)
+			}
+			if call.Name != tt.tool || before != tt.before {
+				t.Errorf("call=%q before=%q, want %q, %q", call.Name, before, tt.tool, tt.before)
+			}
+		})
+	}
+}
+
+func TestCallArgsAndString(t *testing.T) {
+	call := Call{Name: "fetch", Arguments: map[string]any{"url": "https://x.io", "depth": 2.0}}
+	args := call.Args()
+	if args["depth"] != "2" || args["url"] != "https://x.io" {
+		t.Errorf("Args() = %v", args)
+	}
+	if got := call.String(); got != `fetch(depth="2", url="https://x.io")` {
+		t.Errorf("String() = %s", got)
+	}
+}
+
+func TestRegistry_Execute(t *testing.T) {
+	r := NewRegistry(echoTool())
+
+	out, err := r.Execute(context.Background(), Call{Name: "echo", Arguments: map[string]any{"text": "hi"}})
+	if err != nil || out != "hi" {
+		t.Errorf("Execute() = %q, %v", out, err)
+	}
+	if _, err := r.Execute(context.Background(), Call{Name: "echo"}); err == nil || !strings.Contains(err.Error(), "missing required") {
+		t.Errorf("missing argument error = %v", err)
+	}
+	if _, err := r.Execute(context.Background(), Call{Name: "nope"}); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+
+	long := strings.Repeat("x", MaxResultSize+10)
+	out, _ = r.Execute(context.Background(), Call{Name: "echo", Arguments: map[string]any{"text": long}})
+	if !strings.HasSuffix(out, "[truncated]") || len(out) > MaxResultSize+20 {
+		t.Errorf("long output not truncated (len %d)", len(out))
+	}
+}
+
+func TestTruncation_NonASCII(t *testing.T) {
+	r := NewRegistry(echoTool())
+	// MaxResultSize bytes end inside a two-byte rune
+	long := "x" + strings.Repeat("é", MaxResultSize)
+	out, _ := r.Execute(context.Background(), Call{Name: "echo", Arguments: map[string]any{"text": long}})
+	if !utf8.ValidString(out) || !strings.HasSuffix(out, "é\n[truncated]") {
+		t.Errorf("Execute() split a rune: ...%q", out[len(out)-20:])
+	}
+
+	call := Call{Name: "echo", Arguments: map[string]any{"text": strings.Repeat("é", 40)}}
+	if s := call.String(); !utf8.ValidString(s) || !strings.HasSuffix(s, `é...")`) {
+		t.Errorf("String() = %q, want valid UTF-8", s)
+	}
+}
+
+func TestRegistry_SystemPrompt(t *testing.T) {
+	if got := NewRegistry().SystemPrompt(); got != "" {
+		t.Errorf("empty registry prompt = %q", got)
+	}
+	r := NewRegistry(echoTool())
+	r.Register(echoTool()) // replaces, does not duplicate
+	p := r.SystemPrompt()
+	if strings.Count(p, "- echo(text)") != 1 || !strings.Contains(p, "<tool_call>") {
+		t.Errorf("unexpected prompt:\n%s", p)
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	ok := FormatResult("echo", "hi", nil)
+	if !IsResult(ok) || !strings.Contains(ok, "hi") || strings.Contains(ok, "error") {
+		t.Errorf("FormatResult() = %q", ok)
+	}
+	failed := FormatResult("echo", "", errors.New("boom"))
+	if !strings.Contains(failed, `error="true"`) || !strings.Contains(failed, "boom") {
+		t.Errorf("FormatResult(err) = %q", failed)
+	}
+}
+
+func TestReadFile_AllowedDirs(t *testing.T) {
+	root, notes, outside := t.TempDir(), t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(root, "todo.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(root, "big.txt"), make([]byte, 101), 0o644)
+	os.WriteFile(filepath.Join(root, "image.png"), []byte("\x89PNG\r\n\x1a\n\x00\x00"), 0o644)
+	os.WriteFile(filepath.Join(notes, "ideas.md"), []byte("# Ideas"), 0o644)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt"))
+	tool := ReadFile([]string{root, notes}, 100)
+
+	for path, want := range map[string]string{
+		"todo.txt":                        "hello",
+		filepath.Join(notes, "ideas.md"):  "# Ideas",
+		filepath.Join(root, "./todo.txt"): "hello",
+	} {
+		out, err := tool.Run(context.Background(), map[string]string{"path": path})
+		if err != nil || out != want {
+			t.Errorf("read %s = %q, %v; want %q", path, out, err, want)
+		}
+	}
+
+	for path, want := range map[string]string{
+		filepath.Join(outside, "secret.txt"): "outside",
+		"../" + filepath.Base(outside):       "outside",
+		"link.txt":                           "outside",
+		"big.txt":                            "too large",
+		"image.png":                          "binary",
+		".":                                  "directory",
+		"missing.txt":                        "no such file",
+	} {
+		_, err := tool.Run(context.Background(), map[string]string{"path": path})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("read %s: error = %v, want %q", path, err, want)
+		}
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	long := []byte(strings.Repeat("a", 8191) + "é")
+	tests := []struct {
+		data []byte
+		want bool
+	}{
+		{[]byte("plain text\n"), false},
+		{[]byte("grüße"), false},
+		{long, false}, // multi-byte character split at the 8KB boundary
+		{[]byte{'a', 0, 'b'}, true},
+		{[]byte{0xff, 0xfe, 'a'}, true},
+	}
+	for _, tt := range tests {
+		if got := isBinary(tt.data); got != tt.want {
+			t.Errorf("isBinary(%q) = %t, want %t", tt.data[:min(len(tt.data), 16)], got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// maxWriteSize is the largest file write_file and edit_file will produce.
+const maxWriteSize = 1 << 20
+
+// WriteFile returns a tool that creates or replaces a file under the allowed
+// directories dirs, which must be absolute. The user sees a diff of the change
+// and must approve it.
+func WriteFile(dirs []string) Tool {
+	var previews previewed
+	return Tool{
+		Name:        "write_file",
+		Description: "Create a file or replace its entire content. The user reviews a diff and must approve it. Allowed directories: " + strings.Join(dirs, ", ") + ".",
+		Params: []Param{
+			{Name: "path", Description: "absolute path, ~/path, or path relative to " + dirs[0], Required: true},
+			{Name: "content", Description: "the complete new file content", Required: true},
+		},
+		Confirm: true,
+		Preview: func(args map[string]string) (string, error) {
+			path, old, err := loadForWrite(dirs, args["path"])
+			if err != nil {
+				return "", err
+			}
+			diff, err := changeDiff(dirs, path, old, args["content"])
+			if err == nil {
+				previews.remember(args, path, old, args["content"])
+			}
+			return diff, err
+		},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			if c, ok := previews.take(args); ok {
+				return c.apply()
+			}
+			path, old, err := loadForWrite(dirs, args["path"])
+			if err != nil {
+				return "", err
+			}
+			return applyWrite(path, old, args["content"])
+		},
+	}
+}
+
+// EditFile returns a tool that replaces one exact passage in an existing file
+// under dirs. The user sees a diff of the change and must approve it.
+func EditFile(dirs []string) Tool {
+	edit := func(args map[string]string) (path, old, updated string, err error) {
+		path, old, err = loadForWrite(dirs, args["path"])
+		if err != nil {
+			return "", "", "", err
+		}
+		if _, statErr := os.Stat(path); statErr != nil {
+			return "", "", "", fmt.Errorf("%s does not exist; use write_file to create it", args["path"])
+		}
+		switch n := strings.Count(old, args["old_text"]); n {
+		case 0:
+			return "", "", "", fmt.Errorf("old_text was not found in %s", args["path"])
+		case 1:
+		default:
+			return "", "", "", fmt.Errorf("old_text appears %d times in %s; include more surrounding text", n, args["path"])
+		}
+		return path, old, strings.Replace(old, args["old_text"], args["new_text"], 1), nil
+	}
+	var previews previewed
+
+	return Tool{
+		Name:        "edit_file",
+		Description: "Replace one exact passage of an existing file. The user reviews a diff and must approve it. Allowed directories: " + strings.Join(dirs, ", ") + ".",
+		Params: []Param{
+			{Name: "path", Description: "absolute path, ~/path, or path relative to " + dirs[0], Required: true},
+			{Name: "old_text", Description: "text to replace, copied exactly and occurring once", Required: true},
+			{Name: "new_text", Description: "replacement text (may be empty to delete)"},
+		},
+		Confirm: true,
+		Preview: func(args map[string]string) (string, error) {
+			path, old, updated, err := edit(args)
+			if err != nil {
+				return "", err
+			}
+			diff, err := changeDiff(dirs, path, old, updated)
+			if err == nil {
+				previews.remember(args, path, old, updated)
+			}
+			return diff, err
+		},
+		Run: func(_ context.Context, args map[string]string) (string, error) {
+			if c, ok := previews.take(args); ok {
+				return c.apply()
+			}
+			path, old, updated, err := edit(args)
+			if err != nil {
+				return "", err
+			}
+			return applyWrite(path, old, updated)
+		},
+	}
+}
+
+// previewed remembers the change each preview showed, so that the approved
+// call writes exactly that change rather than recomputing it from a file
+// that may have changed in the meantime. It is safe for concurrent use.
+type previewed struct {
+	mu      sync.Mutex
+	changes map[string]change // by call arguments
+}
+
+// change is a previewed write: the new content and a hash of the file it
+// was computed from.
+type change struct {
+	path, old, updated string
+	sum                [sha256.Size]byte
+}
+
+func (p *previewed) remember(args map[string]string, path, old, updated string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.changes == nil {
+		p.changes = make(map[string]change)
+	}
+	p.changes[argsKey(args)] = change{path: path, old: old, updated: updated, sum: sha256.Sum256([]byte(old))}
+}
+
+// take returns and forgets the change previewed for args.
+func (p *previewed) take(args map[string]string) (change, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := argsKey(args)
+	c, ok := p.changes[key]
+	delete(p.changes, key)
+	return c, ok
+}
+
+func argsKey(args map[string]string) string {
+	key, _ := json.Marshal(args) // sorts the keys
+	return string(key)
+}
+
+// apply writes the previewed content, refusing if the file no longer has
+// the content the preview was computed from.
+func (c change) apply() (string, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if sha256.Sum256(data) != c.sum {
+		return "", fmt.Errorf("%s changed since the preview; nothing was written", c.path)
+	}
+	return applyWrite(c.path, c.old, c.updated)
+}
+
+// loadForWrite resolves a path for writing and returns its current content,
+// which is empty for a new file.
+func loadForWrite(dirs []string, arg string) (path, content string, err error) {
+	path, err = resolveWritable(config.ExpandPath(arg, dirs[0]))
+	if err != nil {
+		return "", "", err
+	}
+	if !insideAny(path, dirs) {
+		return "", "", fmt.Errorf("%s is outside the allowed directories (%s)", arg, strings.Join(dirs, ", "))
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return path, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if info.IsDir() {
+		return "", "", fmt.Errorf("%s is a directory", arg)
+	}
+	if info.Size() > maxWriteSize {
+		return "", "", fmt.Errorf("%s is too large to edit (%d bytes, limit %d)", arg, info.Size(), maxWriteSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	if isBinary(data) {
+		return "", "", fmt.Errorf("%s is a binary file", arg)
+	}
+	return path, string(data), nil
+}
+
+// resolveWritable follows symlinks in path, including for files and
+// directories that do not exist yet, by resolving the nearest existing
+// ancestor.
+func resolveWritable(path string) (string, error) {
+	var missing []string
+	dir := path
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(dir))
+		dir = parent
+	}
+}
+
+// changeDiff returns the diff shown to the user, labeled with the path
+// relative to the allowed directory containing it.
+func changeDiff(dirs []string, path, old, updated string) (string, error) {
+	if len(updated) > maxWriteSize {
+		return "", fmt.Errorf("new content is too large (%d bytes, limit %d)", len(updated), maxWriteSize)
+	}
+	if old == updated {
+		return "", fmt.Errorf("the change leaves %s unchanged", path)
+	}
+	return UnifiedDiff(displayPath(dirs, path), old, updated), nil
+}
+
+func displayPath(dirs []string, path string) string {
+	for _, dir := range dirs {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// applyWrite writes content to path atomically, keeping the mode of an
+// existing file, and summarizes the change.
+func applyWrite(path, old, content string) (string, error) {
+	if len(content) > maxWriteSize {
+		return "", fmt.Errorf("new content is too large (%d bytes, limit %d)", len(content), maxWriteSize)
+	}
+	mode := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	created := os.IsNotExist(err)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	added, removed := DiffStat(UnifiedDiff(filepath.Base(path), old, content))
+	if created {
+		return fmt.Sprintf("Created %s (%d lines).", path, added), nil
+	}
+	return fmt.Sprintf("Updated %s (+%d -%d lines).", path, added, removed), nil
+}
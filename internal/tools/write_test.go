@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	root := t.TempDir()
+	tool := WriteFile([]string{root})
+	if !tool.Confirm || tool.Preview == nil {
+		t.Fatal("write_file must require confirmation with a preview")
+	}
+
+	args := map[string]string{"path": "notes/todo.md", "content": "- milk\n"}
+	preview, err := tool.Preview(args)
+	if err != nil || !strings.Contains(preview, "+++ b/notes/todo.md") || !strings.Contains(preview, "+- milk") {
+		t.Fatalf("Preview() = %q, %v", preview, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "notes")); !os.IsNotExist(err) {
+		t.Fatal("Preview must not touch the file system")
+	}
+
+	out, err := tool.Run(context.Background(), args)
+	if err != nil || !strings.HasPrefix(out, "Created") {
+		t.Fatalf("Run() = %q, %v", out, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "notes", "todo.md"))
+	if string(data) != "- milk\n" {
+		t.Errorf("file content = %q", data)
+	}
+
+	os.Chmod(filepath.Join(root, "notes", "todo.md"), 0o600)
+	out, err = tool.Run(context.Background(), map[string]string{"path": "notes/todo.md", "content": "- milk\n- eggs\n"})
+	if err != nil || out != "Updated "+filepath.Join(root, "notes", "todo.md")+" (+1 -0 lines)." {
+		t.Errorf("Run() = %q, %v", out, err)
+	}
+	if info, _ := os.Stat(filepath.Join(root, "notes", "todo.md")); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+}
+
+func TestWriteFile_Sandbox(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	os.Symlink(outside, filepath.Join(root, "escape"))
+	os.WriteFile(filepath.Join(root, "same.txt"), []byte("x"), 0o644)
+	tool := WriteFile([]string{root})
+
+	for path, want := range map[string]string{
+		filepath.Join(outside, "a.txt"): "outside",
+		"../" + filepath.Base(outside):  "outside",
+		"escape/new/file.txt":           "outside",
+		".":                             "directory",
+		"same.txt":                      "unchanged",
+	} {
+		_, err := tool.Preview(map[string]string{"path": path, "content": "x"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("preview %s: error = %v, want %q", path, err, want)
+		}
+	}
+}
+
+func TestEditFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "notes.md")
+	os.WriteFile(path, []byte("# Notes\nbuy milk\nbuy milk later\n"), 0o644)
+	tool := EditFile([]string{root})
+
+	for args, want := range map[[2]string]string{
+		{"missing.md", "x"}:  "does not exist",
+		{"notes.md", "eggs"}: "not found",
+		{"notes.md", "buy"}:  "appears 2 times",
+	} {
+		_, err := tool.Preview(map[string]string{"path": args[0], "old_text": args[1], "new_text": "y"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("preview %v: error = %v, want %q", args, err, want)
+		}
+	}
+
+	args := map[string]string{"path": "notes.md", "old_text": "buy milk\n", "new_text": "buy oat milk\n"}
+	preview, err := tool.Preview(args)
+	if err != nil || !strings.Contains(preview, "-buy milk\n+buy oat milk\n") {
+		t.Fatalf("Preview() = %q, %v", preview, err)
+	}
+	if _, err := tool.Run(context.Background(), args); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "# Notes\nbuy oat milk\nbuy milk later\n" {
+		t.Errorf("file content = %q", data)
+	}
+}
+
+func TestEditFile_ChangedSincePreview(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "notes.md")
+	os.WriteFile(path, []byte("buy milk\n"), 0o644)
+	tool := EditFile([]string{root})
+
+	args := map[string]string{"path": "notes.md", "old_text": "buy milk\n", "new_text": "buy oat milk\n"}
+	if _, err := tool.Preview(args); err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	// The edit still applies, but not to the file the user reviewed
+	if err := os.WriteFile(path, []byte("buy milk\nbuy bread\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Run(context.Background(), args); err == nil || !strings.Contains(err.Error(), "changed since the preview") {
+		t.Errorf("Run() error = %v, want changed since the preview", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "buy milk\nbuy bread\n" {
+		t.Errorf("file content = %q, want it untouched", data)
+	}
+}
@@ -0,0 +1,347 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// ToolResultMsg carries the outcome of a tool call made by the model.
+type ToolResultMsg struct {
+	Call   tools.Call
+	Output string
+	Err    error
+}
+
+// buildTools assembles the tools the model may call, honoring the agent and
+// privacy settings. With the agent disabled the model gets no tools.
+func (m *Model) buildTools() {
+	plugins := make([]tools.Tool, len(m.options.Plugins))
+	for i, p := range m.options.Plugins {
+		plugins[i] = p.Tool()
+	}
+	m.tools = tools.Build(tools.Setup{
+		Agent:         m.options.Agent,
+		Privacy:       m.options.Privacy,
+		Notify:        m.options.Notify.Tool,
+		WorkDir:       m.options.WorkDir,
+		Provider:      m.options.Provider,
+		Fetch:         m.fetchClient,
+		Rates:         m.rates,
+		Memory:        m.options.MemoryStore,
+		Todos:         m.options.TodoStore,
+		Calendar:      m.calendar,
+		Home:          m.options.Home,
+		Notifier:      m.options.Notifier,
+		NotifyLimiter: m.notifyLimiter,
+		Plugins:       plugins,
+		Now:           m.options.Now,
+		FindPython:    findPython,
+	})
+}
+
+// findPython locates the interpreter for run_code. It is a variable so tests
+// can stub it.
+var findPython = tools.FindPython
+
+// unattendedTools returns the tools that may run while nobody watches, e.g.
+// in automation jobs: all but those that need the user's approval.
+func (m *Model) unattendedTools() *tools.Registry {
+	if m.tools == nil {
+		return nil
+	}
+	return m.tools.Filter(func(t tools.Tool) bool { return !t.Confirm })
+}
+
+// heartbeatTools returns the tools offered during heartbeat check-ins and
+// reminders: the unattended tools listed in heartbeat.tools.
+func (m *Model) heartbeatTools() *tools.Registry {
+	r := m.unattendedTools()
+	if r == nil {
+		return nil
+	}
+	return r.Filter(func(t tools.Tool) bool { return slices.Contains(m.options.Heartbeat.Tools, t.Name) })
+}
+
+// nativeTools returns r if its tools may be passed to providers with native
+// tool calling (agent.native_tools), nil if they're only described in the
+// system prompt.
+func (m *Model) nativeTools(r *tools.Registry) *tools.Registry {
+	if !m.options.Agent.NativeTools || !m.supports("tools") {
+		return nil
+	}
+	return r
+}
+
+// systemPrompt returns the system prompt with tool instructions appended.
+func (m *Model) systemPrompt() string {
+	return m.promptWithTools(m.tools)
+}
+
+func (m *Model) promptWithTools(r *tools.Registry) string {
+	var parts []string
+	for _, p := range []string{m.options.SystemPrompt, m.calendarPrompt(), m.todoPrompt()} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if r != nil && r.Len() > 0 {
+		parts = append(parts, r.SystemPrompt())
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// handleToolCall checks a finished reply for a tool call. If there is one,
+// the reply is recorded and the tool is run, or the user is asked to approve
+// it first; the returned command delivers a ToolResultMsg. handled is false
+// for ordinary replies.
+func (m *Model) handleToolCall(content string) (cmd tea.Cmd, handled bool) {
+	if m.tools == nil || m.tools.Len() == 0 || !tools.HasCall(content) {
+		return nil, false
+	}
+
+	maxSteps := m.options.Agent.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 5
+	}
+	if m.agentSteps > maxSteps {
+		// The model ignored the step limit; show the reply as-is
+		return nil, false
+	}
+
+	m.messages = append(m.messages, displayMessage{role: "tool_call", content: content})
+	m.appendTranscript("assistant", content)
+	m.agentSteps++
+
+	call, _, _, err := tools.ParseCall(content)
+	if err == nil && m.agentSteps > maxSteps {
+		err = fmt.Errorf("tool step limit (%d) reached; answer the user now without calling tools", maxSteps)
+	}
+	if _, ok := m.heartbeatTools().Get(call.Name); err == nil && m.agentHeartbeat && !ok {
+		err = fmt.Errorf("%s is not available during heartbeat check-ins", call.Name)
+	}
+	if t, ok := m.tools.Get(call.Name); err == nil && ok && t.Confirm {
+		return m.askApproval(t, call), true
+	}
+	if err != nil {
+		return func() tea.Msg { return ToolResultMsg{Call: call, Err: err} }, true
+	}
+	return m.runTool(call), true
+}
+
+// runTool executes call in the background; the returned command delivers a
+// ToolResultMsg.
+func (m *Model) runTool(call tools.Call) tea.Cmd {
+	m.streaming = true
+	m.waiting = true
+	m.toolRunning = call.Name
+	ctx, cancel := context.WithCancel(context.Background())
+	m.streamCancelFn = cancel
+
+	registry := m.tools
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		start := time.Now()
+		log.Debug("running tool", "tool", call.Name)
+		out, err := registry.Execute(ctx, call)
+		if err != nil {
+			log.Warn("tool failed", "tool", call.Name, "duration", time.Since(start), "err", err)
+		} else {
+			log.Info("tool done", "tool", call.Name, "duration", time.Since(start))
+		}
+		return ToolResultMsg{Call: call, Output: out, Err: err}
+	})
+}
+
+// askApproval pauses the turn until the user approves or declines call. The
+// prompt shows the tool's preview if it has one, otherwise the arguments. A
+// failing preview is reported to the model without asking.
+func (m *Model) askApproval(t tools.Tool, call tools.Call) tea.Cmd {
+	args := call.Args()
+	var preview string
+	if t.Preview != nil {
+		var err error
+		if preview, err = t.Preview(args); err != nil {
+			return func() tea.Msg { return ToolResultMsg{Call: call, Err: err} }
+		}
+	}
+
+	m.pendingCall = &call
+	m.streaming = false
+	m.waiting = false
+
+	const keysHint = "Press y to allow, n to decline, or Ctrl+C to stop."
+	header := m.tr.Sprintf("The assistant wants to use %s:", call.Name)
+	if preview != "" {
+		m.messages = append(m.messages,
+			displayMessage{role: "system", content: header},
+			displayMessage{role: "diff", content: preview},
+			displayMessage{role: "system", content: m.tr.T(keysHint)},
+		)
+	} else {
+		lines := []string{header}
+		for _, p := range t.Params {
+			for _, l := range strings.Split(args[p.Name], "\n") {
+				lines = append(lines, "    "+l)
+			}
+		}
+		lines = append(lines, m.tr.T(keysHint))
+		m.messages = append(m.messages, displayMessage{role: "system", content: strings.Join(lines, "\n")})
+	}
+	m.updateViewport()
+	return nil
+}
+
+// handleConfirmKey answers a pending approval prompt. y runs the call, n
+// tells the model it was declined, and Ctrl+C ends the turn. Other keys are
+// ignored while the prompt is open.
+func (m *Model) handleConfirmKey(msg tea.KeyMsg) tea.Cmd {
+	call := *m.pendingCall
+	switch {
+	case msg.String() == "y" || msg.String() == "Y":
+		m.pendingCall = nil
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Allowed.")})
+		m.updateViewport()
+		return m.runTool(call)
+	case msg.String() == "n" || msg.String() == "N" || msg.String() == "esc":
+		m.pendingCall = nil
+		return m.handleToolResult(ToolResultMsg{Call: call, Err: fmt.Errorf("the user declined to run this")})
+	case key.Matches(msg, m.keys.Stop):
+		m.pendingCall = nil
+		m.toolRunning = call.Name // recorded as cancelled, without continuing
+		return m.handleToolResult(ToolResultMsg{Call: call})
+	}
+	return nil
+}
+
+// handleToolResult feeds a tool's result back to the model and continues the
+// conversation. Results that arrive after the user stopped the turn are
+// recorded but not sent.
+func (m *Model) handleToolResult(msg ToolResultMsg) tea.Cmd {
+	cancelled := m.toolRunning != "" && !m.streaming
+	m.toolRunning = ""
+
+	err := msg.Err
+	if cancelled {
+		err = fmt.Errorf("cancelled by the user")
+	}
+	result := tools.FormatResult(msg.Call.Name, msg.Output, err)
+	m.messages = append(m.messages, displayMessage{role: "tool", content: result})
+	m.appendTranscript("tool", result)
+
+	if cancelled {
+		m.heartbeatTurn = nil
+		m.updateViewport()
+		return nil
+	}
+
+	m.streaming = true
+	m.waiting = true
+	m.streamContent = ""
+	m.updateViewport()
+
+	if m.heartbeatTurn != nil {
+		m.heartbeatStream = true
+		m.heartbeatTurn = append(m.heartbeatTurn, provider.Message{Role: "user", Content: result})
+		return tea.Batch(m.streamHeartbeat(), m.spinner.Tick)
+	}
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "chat"))
+	m.streamCancelFn = cancel
+	return tea.Batch(m.startStream(ctx, ""), m.spinner.Tick)
+}
+
+// appendTranscript saves a message to the current session, if any.
+func (m *Model) appendTranscript(role, content string) {
+	m.recordExchange(role, content)
+	if m.options.Session != nil && m.transcript != nil {
+		m.transcript.Append(m.options.Session.ID, provider.Message{
+			Role:    role,
+			Content: content,
+		})
+	}
+}
+
+// displayRole maps a stored message to its display role, recognizing tool
+// calls and results among ordinary assistant and user messages.
+func displayRole(role, content string) string {
+	switch {
+	case role == "assistant" && tools.HasCall(content):
+		return "tool_call"
+	case role == "tool", role == "user" && tools.IsResult(content):
+		return "tool"
+	}
+	return role
+}
+
+// renderToolCall renders a tool call reply: any text before the call as a
+// normal assistant message, followed by a one-line summary of the call.
+func (m *Model) renderToolCall(content string) string {
+	call, before, _, err := tools.ParseCall(content)
+	var lines []string
+	if before != "" {
+		lines = append(lines, assistantLabelStyle.Render(m.tr.T("Assistant: "))+m.renderMarkdown(before))
+	}
+	if err != nil {
+		lines = append(lines, errorMsgStyle.Render("⚙ "+err.Error()))
+	} else {
+		lines = append(lines, systemMsgStyle.Render("⚙ "+call.String()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDiff colors the added and removed lines of a unified diff.
+func renderDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"), strings.HasPrefix(l, "@@"):
+			lines[i] = systemMsgStyle.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = lipgloss.NewStyle().Foreground(successColor).Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = errorMsgStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderToolResult renders a one-line summary of a tool result.
+func renderToolResult(content string) string {
+	body := content
+	if i := strings.Index(body, "\n"); i != -1 {
+		body = body[i+1:]
+	}
+	body = strings.TrimSuffix(strings.TrimSpace(body), "</tool_result>")
+	body = strings.TrimSpace(body)
+
+	first := body
+	if i := strings.Index(first, "\n"); i != -1 {
+		first = first[:i]
+	}
+	if len(first) > 80 {
+		cut := 77
+		for cut > 0 && !utf8.RuneStart(first[cut]) {
+			cut--
+		}
+		first = first[:cut] + "..."
+	}
+	summary := "↳ " + first
+	if n := strings.Count(body, "\n") + 1; n > 1 {
+		summary += fmt.Sprintf(" (%d lines)", n)
+	}
+	if strings.Contains(content, `error="true"`) {
+		return errorMsgStyle.Render(summary)
+	}
+	return systemMsgStyle.Render(summary)
+}
@@ -0,0 +1,426 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// collectMsgs runs cmd and any batched commands, returning the messages.
+func collectMsgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, collectMsgs(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func newAgentModel(t *testing.T, mp *providertest.Fake) Model {
+	t.Helper()
+	store := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	store.Append([]string{"User drinks green tea"})
+
+	return newTestModel(t, Options{
+		Provider:     mp,
+		SystemPrompt: "You are helpful.",
+		MemoryStore:  store,
+		Agent:        config.AgentConfig{Enabled: true, MaxSteps: 2},
+	})
+}
+
+func TestAgent_RunsToolAndContinues(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := newAgentModel(t, mp)
+
+	m.messages = append(m.messages, displayMessage{role: "user", content: "What do I drink?"})
+	m.streaming = true
+	m.streamContent = `Let me check. <tool_call>{"name": "memory_search", "arguments": {"keyword": "tea"}}</tool_call>`
+
+	newM, cmd := m.Update(StreamDoneMsg{})
+	model := newM.(Model)
+	if !model.streaming || model.toolRunning != "memory_search" {
+		t.Fatalf("tool should be running, streaming=%t tool=%q", model.streaming, model.toolRunning)
+	}
+	if last := model.messages[len(model.messages)-1]; last.role != "tool_call" {
+		t.Errorf("last message role = %q, want tool_call", last.role)
+	}
+
+	var result ToolResultMsg
+	for _, msg := range collectMsgs(cmd) {
+		if r, ok := msg.(ToolResultMsg); ok {
+			result = r
+		}
+	}
+	if result.Err != nil || !strings.Contains(result.Output, "green tea") {
+		t.Fatalf("tool result = %+v, want memory match", result)
+	}
+
+	newM, cmd = model.Update(result)
+	model = newM.(Model)
+	if last := model.messages[len(model.messages)-1]; last.role != "tool" {
+		t.Errorf("last message role = %q, want tool", last.role)
+	}
+	collectMsgs(cmd)
+
+	// The continuation request carries the tool protocol and the result
+	msgs := mp.LastRequest().Messages
+	if !strings.Contains(msgs[0].Content, "# Tools") || !strings.Contains(msgs[0].Content, "memory_search(keyword)") {
+		t.Errorf("system prompt lacks tool instructions: %q", msgs[0].Content)
+	}
+	tail := msgs[len(msgs)-2:]
+	if tail[0].Role != "assistant" || tail[1].Role != "user" || !strings.Contains(tail[1].Content, "<tool_result") {
+		t.Errorf("unexpected tail of continuation request: %+v", tail)
+	}
+}
+
+func TestAgent_NativeTools(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", NativeTools: true, Replies: []providertest.Reply{
+		{ToolCalls: []provider.ToolCall{{Name: "memory_search", Arguments: map[string]any{"keyword": "tea"}}}},
+		providertest.Text("Green tea."),
+	}}
+	m := newAgentModel(t, mp)
+	m.options.Agent.NativeTools = true
+
+	// Run the turn until the model answers without a call
+	cmd := m.sendMessage("What do I drink?")
+	for i := 0; cmd != nil && i < 50; i++ {
+		var next []tea.Cmd
+		for _, msg := range collectMsgs(cmd) {
+			newM, c := m.Update(msg)
+			m = newM.(Model)
+			next = append(next, c)
+		}
+		cmd = tea.Batch(next...)
+		if !m.streaming {
+			break
+		}
+	}
+
+	reqs := mp.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want the question and the continuation", len(reqs))
+	}
+	if len(reqs[0].Tools) == 0 || strings.Contains(reqs[0].Messages[0].Content, "# Tools") {
+		t.Errorf("first request should pass the tools natively, system prompt %q", reqs[0].Messages[0].Content)
+	}
+	msgs := reqs[1].Messages
+	if last := msgs[len(msgs)-1]; last.Role != "tool" || last.ToolName != "memory_search" || !strings.Contains(last.Content, "green tea") {
+		t.Errorf("tool result = %+v", last)
+	}
+	if got := lastMessage(&m); got.role != "assistant" || got.content != "Green tea." {
+		t.Errorf("last message = %+v, want the answer", got)
+	}
+}
+
+func TestAgent_StepLimit(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := newAgentModel(t, mp)
+	m.agentSteps = 2 // MaxSteps already used
+
+	m.streaming = true
+	m.streamContent = `<tool_call>{"name": "memory_search", "arguments": {"keyword": "tea"}}</tool_call>`
+	newM, cmd := m.Update(StreamDoneMsg{})
+	model := newM.(Model)
+
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want a single ToolResultMsg", len(msgs))
+	}
+	result := msgs[0].(ToolResultMsg)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "step limit") {
+		t.Errorf("expected step limit error, got %v", result.Err)
+	}
+
+	// A further call past the limit is shown as a plain reply
+	model.streaming = true
+	model.streamContent = `<tool_call>{"name": "memory_search", "arguments": {"keyword": "tea"}}</tool_call>`
+	newM, _ = model.Update(StreamDoneMsg{})
+	model = newM.(Model)
+	if last := model.messages[len(model.messages)-1]; last.role != "assistant" {
+		t.Errorf("call past the limit should be a plain reply, got role %q", last.role)
+	}
+}
+
+func TestAgent_DisabledIgnoresToolCalls(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", SystemPrompt: "You are helpful."})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	if got := m.systemPrompt(); got != "You are helpful." {
+		t.Errorf("system prompt without agent = %q", got)
+	}
+	m.streaming = true
+	m.streamContent = `<tool_call>{"name": "web_search", "arguments": {"query": "x"}}</tool_call>`
+	newM, _ := m.Update(StreamDoneMsg{})
+	if last := newM.(Model).messages[0]; last.role != "assistant" {
+		t.Errorf("role = %q, want assistant", last.role)
+	}
+}
+
+func TestAgent_PrivacyLimitsTools(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	store := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	m := New(Options{
+		Provider:    mp,
+		Model:       "test-model",
+		MemoryStore: store,
+		Agent:       config.AgentConfig{Enabled: true, MaxSteps: 5},
+		Privacy:     config.PrivacyConfig{DisableWeb: true, DisableAutoMemory: true},
+	})
+
+	for _, name := range []string{"web_search", "fetch", "memory_remember"} {
+		if _, ok := m.tools.Get(name); ok {
+			t.Errorf("%s should not be offered with privacy settings", name)
+		}
+	}
+	if _, ok := m.tools.Get("memory_search"); !ok {
+		t.Error("memory_search should remain available")
+	}
+}
+
+func TestDisplayRole(t *testing.T) {
+	tests := []struct {
+		role, content, want string
+	}{
+		{"assistant", "Hello", "assistant"},
+		{"assistant", `<tool_call>{"name":"fetch"}</tool_call>`, "tool_call"},
+		{"tool", "<tool_result name=\"fetch\">\nok\n</tool_result>", "tool"},
+		{"user", "<tool_result name=\"fetch\">\nok\n</tool_result>", "tool"},
+		{"user", "Hi", "user"},
+	}
+	for _, tt := range tests {
+		if got := displayRole(tt.role, tt.content); got != tt.want {
+			t.Errorf("displayRole(%q, %q) = %q, want %q", tt.role, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRenderToolResult_NonASCII(t *testing.T) {
+	// 77 bytes falls inside a two-byte rune
+	got := renderToolResult("<tool_result name=\"fetch\">\n" + strings.Repeat("é", 60) + "\n</tool_result>")
+	if !utf8.ValidString(got) || !strings.Contains(got, "é...") {
+		t.Errorf("renderToolResult() = %q, want valid UTF-8 ending in é...", got)
+	}
+}
+
+func newCommandModel(t *testing.T, mp *providertest.Fake) Model {
+	t.Helper()
+	m := newTestModel(t, Options{
+		Provider: mp,
+		WorkDir:  t.TempDir(),
+		Agent: config.AgentConfig{
+			Enabled:    true,
+			MaxSteps:   5,
+			RunCommand: config.RunCommandConfig{Enabled: true, Timeout: "5s"},
+		},
+	})
+	m.streaming = true
+	m.streamContent = `<tool_call>{"name": "run_command", "arguments": {"command": "echo hi"}}</tool_call>`
+	return m
+}
+
+func TestAgent_RunCommandNeedsApproval(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := newCommandModel(t, mp)
+
+	newM, cmd := m.Update(StreamDoneMsg{})
+	model := newM.(Model)
+	if cmd != nil || model.pendingCall == nil || model.streaming {
+		t.Fatalf("command should wait for approval (pending=%v, streaming=%t)", model.pendingCall, model.streaming)
+	}
+	if last := model.messages[len(model.messages)-1]; !strings.Contains(last.content, "    echo hi") {
+		t.Errorf("approval prompt should show the command, got %q", last.content)
+	}
+
+	// Other keys are ignored while the prompt is open
+	newM, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	model = newM.(Model)
+	if model.pendingCall == nil {
+		t.Fatal("unrelated key should not answer the prompt")
+	}
+
+	newM, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	model = newM.(Model)
+	if model.pendingCall != nil || model.toolRunning != "run_command" {
+		t.Fatalf("approved command should run (tool=%q)", model.toolRunning)
+	}
+	var result ToolResultMsg
+	for _, msg := range collectMsgs(cmd) {
+		if r, ok := msg.(ToolResultMsg); ok {
+			result = r
+		}
+	}
+	if result.Err != nil || !strings.Contains(result.Output, "stdout:\nhi") {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestAgent_RunCommandDeclined(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := newCommandModel(t, mp)
+
+	newM, _ := m.Update(StreamDoneMsg{})
+	newM, cmd := newM.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	model := newM.(Model)
+	if !model.streaming {
+		t.Fatal("declining should let the model continue")
+	}
+	if last := model.messages[len(model.messages)-1]; last.role != "tool" || !strings.Contains(last.content, "declined") {
+		t.Errorf("last message = %+v, want declined tool result", last)
+	}
+	collectMsgs(cmd)
+	if got := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content; !strings.Contains(got, "declined") {
+		t.Errorf("model was not told about the decline: %q", got)
+	}
+}
+
+func TestAgent_RunCommandNeverInHeartbeat(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := newCommandModel(t, mp)
+
+	if strings.Contains(m.promptWithTools(m.heartbeatTools()), "run_command") {
+		t.Error("heartbeat prompt should not offer run_command")
+	}
+
+	m.agentHeartbeat = true
+	newM, cmd := m.Update(StreamDoneMsg{})
+	if newM.(Model).pendingCall != nil {
+		t.Fatal("heartbeat must not ask to run commands")
+	}
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want a single ToolResultMsg", len(msgs))
+	}
+	if r := msgs[0].(ToolResultMsg); r.Err == nil || !strings.Contains(r.Err.Error(), "heartbeat") {
+		t.Errorf("expected heartbeat refusal, got %v", r.Err)
+	}
+}
+
+func TestAgent_ReadDirs(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	agent := config.AgentConfig{
+		Enabled:  true,
+		MaxSteps: 5,
+		ReadFile: config.ReadFileConfig{AllowedDirs: []string{".", "/srv/notes"}, MaxSize: 1024},
+	}
+
+	m := New(Options{Provider: mp, Model: "test-model", Agent: agent, WorkDir: "/work"})
+	if _, ok := m.tools.Get("read_file"); !ok {
+		t.Error("read_file should be offered with allowed directories")
+	}
+
+	agent.ReadFile.AllowedDirs = nil
+	m = New(Options{Provider: mp, Model: "test-model", Agent: agent, WorkDir: "/work"})
+	if _, ok := m.tools.Get("read_file"); ok {
+		t.Error("read_file should not be offered without allowed directories")
+	}
+}
+
+func TestAgent_WriteFileShowsDiff(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("old line\n"), 0o644)
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Provider: mp,
+		Model:    "test-model",
+		WorkDir:  dir,
+		Agent: config.AgentConfig{
+			Enabled:   true,
+			MaxSteps:  5,
+			WriteFile: config.WriteFileConfig{Enabled: true, AllowedDirs: []string{"."}},
+		},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	m.streaming = true
+	m.streamContent = `<tool_call>{"name": "write_file", "arguments": {"path": "notes.md", "content": "new line\n"}}</tool_call>`
+
+	newM, _ := m.Update(StreamDoneMsg{})
+	model := newM.(Model)
+	if model.pendingCall == nil {
+		t.Fatal("write_file should wait for approval")
+	}
+	var diff string
+	for _, msg := range model.messages {
+		if msg.role == "diff" {
+			diff = msg.content
+		}
+	}
+	if !strings.Contains(diff, "-old line\n+new line") {
+		t.Errorf("approval should show a diff, got %q", diff)
+	}
+
+	newM, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	collectMsgs(cmd)
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.md")); string(data) != "new line\n" {
+		t.Errorf("file content = %q, want the approved change", data)
+	}
+
+	// A change that cannot be previewed is rejected without asking
+	model = newM.(Model)
+	model.streaming = true
+	model.streamContent = `<tool_call>{"name": "edit_file", "arguments": {"path": "notes.md", "old_text": "absent", "new_text": "x"}}</tool_call>`
+	newM, cmd = model.Update(StreamDoneMsg{})
+	if newM.(Model).pendingCall != nil {
+		t.Fatal("invalid edit should not ask for approval")
+	}
+	if msgs := collectMsgs(cmd); len(msgs) != 1 || msgs[0].(ToolResultMsg).Err == nil {
+		t.Errorf("expected an error result, got %v", msgs)
+	}
+}
+
+func TestAgent_RunCodeTool(t *testing.T) {
+	findPython = func() string { return "/usr/bin/python3" }
+	t.Cleanup(func() { findPython = tools.FindPython })
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	agent := config.AgentConfig{Enabled: true, MaxSteps: 5}
+	m := New(Options{Provider: mp, Model: "test-model", Agent: agent})
+	if _, ok := m.tools.Get("run_code"); ok {
+		t.Error("run_code should be off unless enabled")
+	}
+
+	agent.RunCode = config.RunCodeConfig{Enabled: true, Timeout: "10s", Confirm: false}
+	m = New(Options{Provider: mp, Model: "test-model", Agent: agent})
+	tool, ok := m.tools.Get("run_code")
+	if !ok || !tool.Confirm {
+		t.Fatalf("run_code should be offered and require approval (ok=%t)", ok)
+	}
+	m.options.Heartbeat.Tools = []string{"run_code"}
+	if strings.Contains(m.promptWithTools(m.heartbeatTools()), "run_code") {
+		t.Error("heartbeats should never offer run_code, even if listed")
+	}
+	if _, ok := m.unattendedTools().Get("run_code"); ok {
+		t.Error("jobs should never get run_code")
+	}
+
+	findPython = func() string { return "" }
+	m = New(Options{Provider: mp, Model: "test-model", Agent: agent})
+	if _, ok := m.tools.Get("run_code"); ok {
+		t.Error("run_code should not be offered without python")
+	}
+}
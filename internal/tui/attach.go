@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
+)
+
+// attachment is an image waiting to be sent with the next message.
+type attachment struct {
+	name string
+	data string // base64-encoded
+}
+
+// handleAttach adds an image to the next message, for vision models to
+// look at. "screenshot" attaches the latest screenshot.
+func handleAttach(m *Model, args string) (tea.Model, tea.Cmd) {
+	switch args {
+	case "":
+		if len(m.attachments) == 0 {
+			m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Usage: /attach <image>|screenshot|clear")})
+		} else {
+			m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Attached to your next message: %s", m.attachmentNames())})
+		}
+	case "clear":
+		m.attachments = nil
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Attachments removed.")})
+	default:
+		path := config.ExpandPath(args, m.options.WorkDir)
+		var err error
+		if args == "screenshot" {
+			path, err = ocr.Latest(ocr.ScreenshotDirs(m.options.Agent.OCR.ScreenshotDir))
+		}
+		var data string
+		if err == nil {
+			data, err = ocr.EncodeImage(path)
+		}
+		if err != nil {
+			m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Error attaching image: %v", err)})
+			break
+		}
+		m.attachments = append(m.attachments, attachment{name: filepath.Base(path), data: data})
+		content := m.tr.Sprintf("Attached %s; it goes with your next message. Use a vision model such as llava or qwen2.5vl to have it looked at.", filepath.Base(path))
+		if !m.supports("vision") {
+			content = m.tr.Sprintf("Attached %s, but %s can't look at images; switch to a vision model such as llava or qwen2.5vl with /model.", filepath.Base(path), m.options.Model)
+		}
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// attachmentNames lists the images waiting for the next message.
+func (m *Model) attachmentNames() string {
+	names := make([]string, len(m.attachments))
+	for i, a := range m.attachments {
+		names[i] = a.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// takeAttachments returns the images waiting for the next message, which
+// then has none.
+func (m *Model) takeAttachments() []string {
+	if len(m.attachments) == 0 {
+		return nil
+	}
+	images := make([]string, len(m.attachments))
+	for i, a := range m.attachments {
+		images[i] = a.data
+	}
+	m.attachments = nil
+	return images
+}
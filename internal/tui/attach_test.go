@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestAttach_SendsImageWithNextMessage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "photo.png"), []byte("\x89PNG"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mp := providertest.New(providertest.Text("A cat."), providertest.Text("Grey."))
+	m := New(Options{Provider: mp, Model: "llava", WorkDir: dir})
+	m.width, m.height, m.ready = 80, 24, true
+
+	handleAttach(&m, "photo.png")
+	if len(m.attachments) != 1 {
+		t.Fatalf("attachments = %d, want 1 (last message %q)", len(m.attachments), lastMessage(&m).content)
+	}
+
+	collectMsgs(m.sendMessage("What is this?"))
+	want := base64.StdEncoding.EncodeToString([]byte("\x89PNG"))
+	msgs := mp.LastRequest().Messages
+	if images := msgs[len(msgs)-1].Images; len(images) != 1 || images[0] != want {
+		t.Errorf("images = %q, want the encoded photo", images)
+	}
+	if len(m.attachments) != 0 {
+		t.Error("attachments should go with one message only")
+	}
+
+	// The image stays in the conversation for follow-up questions
+	m.streaming = false
+	m.messages = append(m.messages, displayMessage{role: "assistant", content: "A cat."})
+	collectMsgs(m.sendMessage("What colour is it?"))
+	msgs = mp.LastRequest().Messages
+	if len(msgs[len(msgs)-1].Images) != 0 || len(msgs[len(msgs)-3].Images) != 1 {
+		t.Errorf("follow-up messages = %+v, want the image on the first question only", msgs)
+	}
+}
+
+func TestAttach_Errors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0o644)
+	m := New(Options{Provider: providertest.New(), Model: "llava", WorkDir: dir})
+
+	for _, args := range []string{"notes.txt", "missing.png"} {
+		handleAttach(&m, args)
+		if got := lastMessage(&m); got.role != "error" {
+			t.Errorf("/attach %s: last message = %+v, want an error", args, got)
+		}
+	}
+	if len(m.attachments) != 0 {
+		t.Errorf("attachments = %d, want none", len(m.attachments))
+	}
+
+	handleAttach(&m, "")
+	if got := lastMessage(&m).content; got != "Usage: /attach <image>|screenshot|clear" {
+		t.Errorf("usage = %q", got)
+	}
+}
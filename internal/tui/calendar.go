@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"context"
+	"math"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+// CalendarTickMsg signals it is time to refresh the calendar and announce
+// events that are about to start.
+type CalendarTickMsg struct{}
+
+// CalendarLoadedMsg reports the result of a calendar refresh.
+type CalendarLoadedMsg struct {
+	Err error
+}
+
+func (m *Model) scheduleCalendarCheck() tea.Cmd {
+	m.calendarTicking = true
+	return tea.Tick(m.options.CheckInterval, func(time.Time) tea.Msg {
+		return CalendarTickMsg{}
+	})
+}
+
+// checkCalendar refreshes the calendar in the background if it is stale
+// and announces events starting within calendar.remind. Checking stops
+// when no calendar is configured.
+func (m *Model) checkCalendar() tea.Cmd {
+	cache := m.calendar
+	if cache == nil {
+		m.calendarTicking = false
+		return nil
+	}
+	cmds := []tea.Cmd{
+		m.scheduleCalendarCheck(),
+		func() tea.Msg {
+			_, err := cache.Get(context.Background())
+			return CalendarLoadedMsg{Err: err}
+		},
+	}
+
+	lead, err := time.ParseDuration(m.options.Calendar.Remind)
+	if err != nil || lead <= 0 {
+		return tea.Batch(cmds...)
+	}
+	if m.calendarAnnounced == nil {
+		m.calendarAnnounced = make(map[string]bool)
+	}
+	t := m.now()
+	for _, e := range cache.Current().Upcoming(t, lead) {
+		key := e.Summary + "@" + e.Start.String()
+		if m.calendarAnnounced[key] {
+			continue
+		}
+		m.calendarAnnounced[key] = true
+		mins := int(math.Ceil(e.Start.Sub(t).Minutes()))
+		format := "%s starts in %d minutes (%s)."
+		if mins == 1 {
+			format = "%s starts in %d minute (%s)."
+		}
+		text := m.tr.Sprintf(format, e.Summary, mins, e.Start.In(t.Location()).Format("15:04"))
+		m.messages = append(m.messages, displayMessage{role: "system", content: "📅 " + text})
+		cmds = append(cmds, m.notify(notify.Event{Kind: notify.EventReminder, Title: m.tr.T("Upcoming event"), Message: text}))
+	}
+	m.updateViewport()
+	return tea.Batch(cmds...)
+}
+
+// handleCalendarLoaded reports a failed refresh, once per distinct error.
+func (m *Model) handleCalendarLoaded(msg CalendarLoadedMsg) {
+	errText := ""
+	if msg.Err != nil {
+		errText = msg.Err.Error()
+	}
+	if errText == m.calendarErr {
+		return
+	}
+	m.calendarErr = errText
+	if errText != "" {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Calendar: %s", errText)})
+		m.updateViewport()
+	}
+}
+
+// calendarPrompt returns today's schedule for the system prompt, or "" if
+// no calendar has been loaded.
+func (m *Model) calendarPrompt() string {
+	cal := m.calendar.Current()
+	if cal == nil {
+		return ""
+	}
+	return cal.PromptSection(m.now())
+}
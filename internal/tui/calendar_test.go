@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// calendarOptions sets up a calendar with a standup and lunch on Tuesday,
+// 10 March 2026, at 09:50.
+func calendarOptions(t *testing.T, remind string) Options {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "work.ics")
+	ics := "BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20260310T100000\nDURATION:PT15M\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Lunch\nDTSTART:20260310T123000\nDURATION:PT1H\nEND:VEVENT\n"
+	if err := os.WriteFile(path, []byte(ics), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Options{
+		SystemPrompt: "You are helpful.",
+		Agent:        config.AgentConfig{Enabled: true},
+		Heartbeat:    config.HeartbeatConfig{Tools: []string{"calendar"}},
+		Calendar:     config.CalendarConfig{Sources: []string{path}, Remind: remind, Refresh: "15m"},
+		Now:          (&testClock{t: time.Date(2026, 3, 10, 9, 50, 0, 0, time.Local)}).Now,
+	}
+}
+
+// tickCalendar runs one calendar check, loading the calendar if needed.
+func tickCalendar(m Model) Model {
+	newM, cmd := m.Update(CalendarTickMsg{})
+	m = newM.(Model)
+	for _, msg := range collectMsgs(cmd) {
+		if loaded, ok := msg.(CalendarLoadedMsg); ok {
+			newM, _ = m.Update(loaded)
+			m = newM.(Model)
+		}
+	}
+	return m
+}
+
+func TestCalendar_AnnouncesUpcomingEvents(t *testing.T) {
+	m := newTestModel(t, calendarOptions(t, "15m"))
+	m = tickCalendar(m) // loads the calendar
+	m = tickCalendar(m)
+	m = tickCalendar(m)
+
+	var announced []string
+	for _, msg := range m.messages {
+		if strings.HasPrefix(msg.content, "📅") {
+			announced = append(announced, msg.content)
+		}
+	}
+	if len(announced) != 1 || announced[0] != "📅 Standup starts in 10 minutes (10:00)." {
+		t.Errorf("announcements = %q, want the standup once", announced)
+	}
+}
+
+func TestCalendar_PromptAndTool(t *testing.T) {
+	m := newTestModel(t, calendarOptions(t, "0s"))
+	if strings.Contains(m.systemPrompt(), "## Calendar") {
+		t.Error("calendar section before the calendar was loaded")
+	}
+	m = tickCalendar(m)
+
+	prompt := m.systemPrompt()
+	for _, want := range []string{"You are helpful.", "It is now Tuesday, 10 March 2026, 09:50.", "- 10:00–10:15 Standup", "- 12:30–13:30 Lunch", "calendar"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("system prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if _, ok := m.heartbeatTools().Get("calendar"); !ok {
+		t.Error("calendar tool should be offered during heartbeats")
+	}
+	for _, msg := range m.messages {
+		if strings.HasPrefix(msg.content, "📅") {
+			t.Errorf("announced %q with reminders off", msg.content)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ModelCapsMsg carries what the provider knows about Model: its longest
+// context and whether it can call tools or look at images.
+type ModelCapsMsg struct {
+	Model   string
+	Details *provider.ModelDetails
+	Err     error
+}
+
+// capsTimeout bounds how long looking up a model's capabilities may take.
+const capsTimeout = 10 * time.Second
+
+// detectCapabilities looks up the capabilities of the current model, if
+// the provider can describe its models. Until they arrive, the model is
+// assumed to have all of them.
+func (m *Model) detectCapabilities() tea.Cmd {
+	m.modelCaps = nil
+	md, ok := m.options.Provider.(provider.ModelDescriber)
+	if !ok {
+		return nil
+	}
+	model := m.options.Model
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), capsTimeout)
+		defer cancel()
+		d, err := md.ShowModel(ctx, model)
+		return ModelCapsMsg{Model: model, Details: d, Err: err}
+	}
+}
+
+// handleModelCaps records the capabilities of the current model and moves
+// the context down to the largest tier it can handle.
+func (m *Model) handleModelCaps(msg ModelCapsMsg) {
+	if msg.Model != m.options.Model {
+		return // switched models since
+	}
+	if msg.Err != nil {
+		log.Debug("model capabilities unknown", "model", msg.Model, "err", msg.Err)
+		return
+	}
+	m.modelCaps = msg.Details
+	log.Info("model capabilities", "model", msg.Model, "context_length", msg.Details.ContextLength, "capabilities", msg.Details.Capabilities)
+
+	limit := m.ctxLimit()
+	if m.currentNumCtx <= limit {
+		return
+	}
+	numCtx := limit
+	for _, tier := range ctxTiers {
+		if tier <= limit {
+			numCtx = tier
+		}
+	}
+	m.setNumCtx(numCtx, fmt.Sprintf("%s handles at most %d tokens of context; using %d.", msg.Model, msg.Details.ContextLength, numCtx))
+	m.updateViewport()
+}
+
+// ctxLimit is the largest context adaptive scaling may grow to: the
+// configured maximum, or the longest context the model handles if that is
+// shorter.
+func (m *Model) ctxLimit() int {
+	if c := m.modelCaps; c != nil && c.ContextLength > 0 && c.ContextLength < m.maxNumCtx {
+		return c.ContextLength
+	}
+	return m.maxNumCtx
+}
+
+// supports reports whether the current model has capability, such as
+// "tools" or "vision"; it is assumed to while that's unknown.
+func (m *Model) supports(capability string) bool {
+	return m.modelCaps == nil || m.modelCaps.Supports(capability)
+}
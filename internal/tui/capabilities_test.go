@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// describingProvider describes every model with details.
+type describingProvider struct {
+	providertest.Fake
+	details provider.ModelDetails
+}
+
+func (p *describingProvider) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	d := p.details
+	return &d, nil
+}
+
+func TestModelCaps_CapsContext(t *testing.T) {
+	p := &describingProvider{details: provider.ModelDetails{ContextLength: 8192, Capabilities: []string{"completion"}}}
+	m := New(Options{Provider: p, Model: "gemma:2b", MaxNumCtx: 32768})
+	m.width, m.height, m.ready = 80, 24, true
+	m.currentNumCtx = 16384
+
+	newM, _ := m.Update(m.detectCapabilities()())
+	m = newM.(Model)
+	if m.currentNumCtx != 8192 {
+		t.Errorf("currentNumCtx = %d, want 8192", m.currentNumCtx)
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "gemma:2b handles at most 8192 tokens") {
+		t.Errorf("notice = %q", got)
+	}
+	if m.ctxLimit() != 8192 {
+		t.Errorf("ctxLimit = %d, want 8192", m.ctxLimit())
+	}
+
+	m.growContext(8000)
+	if m.currentNumCtx != 8192 {
+		t.Errorf("grew past the model's context to %d", m.currentNumCtx)
+	}
+}
+
+func TestModelCaps_LongerThanConfigured(t *testing.T) {
+	p := &describingProvider{details: provider.ModelDetails{ContextLength: 131072}}
+	m := New(Options{Provider: p, Model: "qwen3:8b", MaxNumCtx: 32768})
+	newM, _ := m.Update(m.detectCapabilities()())
+	m = newM.(Model)
+	if m.ctxLimit() != 32768 {
+		t.Errorf("ctxLimit = %d, want the configured 32768", m.ctxLimit())
+	}
+}
+
+func TestModelCaps_Features(t *testing.T) {
+	p := &describingProvider{details: provider.ModelDetails{Capabilities: []string{"completion"}}}
+	m := New(Options{Provider: p, Model: "llama2"})
+	m.options.Agent.NativeTools = true
+	r := tools.NewRegistry()
+
+	if m.nativeTools(r) == nil {
+		t.Error("native tools off before the capabilities are known")
+	}
+	newM, _ := m.Update(m.detectCapabilities()())
+	m = newM.(Model)
+	if m.nativeTools(r) != nil {
+		t.Error("native tools used with a model that can't call tools")
+	}
+	if m.supports("vision") {
+		t.Error("supports vision, want not")
+	}
+}
+
+func TestModelCaps_IgnoresStale(t *testing.T) {
+	p := &describingProvider{details: provider.ModelDetails{ContextLength: 4096}}
+	m := New(Options{Provider: p, Model: "gemma:2b"})
+	msg := m.detectCapabilities()()
+	m.options.Model = "qwen3:8b"
+
+	newM, _ := m.Update(msg)
+	m = newM.(Model)
+	if m.modelCaps != nil {
+		t.Errorf("kept the capabilities of the previous model: %+v", m.modelCaps)
+	}
+}
+
+func TestModelCaps_WithoutModelManager(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{}, Model: "gpt-4o"})
+	if cmd := m.detectCapabilities(); cmd != nil {
+		t.Error("looked up capabilities without a model manager")
+	}
+	if !m.supports("tools") || !m.supports("vision") {
+		t.Error("unknown capabilities assumed missing")
+	}
+}
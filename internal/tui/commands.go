@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
+	"github.com/stefanclaw/stefanclaw/internal/log"
 )
 
 // Command represents a parsed slash command.
@@ -63,16 +66,28 @@ func init() {
 		},
 		{
 			Name:        "models",
-			Description: "List available models",
-			Usage:       "/models",
+			Description: "List available models, optionally filtered",
+			Usage:       "/models [<filter>]",
 			Handler:     handleModels,
 		},
 		{
 			Name:        "model",
-			Description: "Switch to a different model",
-			Usage:       "/model <name>",
+			Description: "Switch to a different model, describe or delete one",
+			Usage:       "/model <name>|info [<name>]|rm <name>",
 			Handler:     handleModel,
 		},
+		{
+			Name:        "pull",
+			Description: "Download a model with Ollama",
+			Usage:       "/pull <model>|stop",
+			Handler:     handlePull,
+		},
+		{
+			Name:        "provider",
+			Description: "Show or switch the model provider",
+			Usage:       "/provider [<name>]",
+			Handler:     handleProvider,
+		},
 		{
 			Name:        "session",
 			Description: "Start a new session or list sessions",
@@ -106,15 +121,130 @@ func init() {
 		{
 			Name:        "language",
 			Description: "Show or change response language",
-			Usage:       "/language [<name>]",
+			Usage:       "/language [<name>|list]",
 			Handler:     handleLanguage,
 		},
 		{
 			Name:        "heartbeat",
 			Description: "Manage heartbeat check-ins",
-			Usage:       "/heartbeat [on|off|<interval>]",
+			Usage:       "/heartbeat [on|off|<interval>|list]",
 			Handler:     handleHeartbeat,
 		},
+		{
+			Name:        "ack",
+			Description: "Acknowledge the last check-in message",
+			Usage:       "/ack",
+			Handler:     handleAck,
+		},
+		{
+			Name:        "snooze",
+			Description: "Pause heartbeat check-ins for a while",
+			Usage:       "/snooze <duration>|off",
+			Handler:     handleSnooze,
+		},
+		{
+			Name:        "remind",
+			Description: "Set a reminder",
+			Usage:       "/remind [me] <when> [to] <text>",
+			Handler:     handleRemind,
+		},
+		{
+			Name:        "todo",
+			Description: "Manage your to-do list",
+			Usage:       "/todo [list|add <task>|done <n>]",
+			Handler:     handleTodo,
+		},
+		{
+			Name:        "schedule",
+			Description: "List or cancel reminders, or schedule a prompt",
+			Usage:       "/schedule [cancel <id>|<when> <task>]",
+			Handler:     handleSchedule,
+		},
+		{
+			Name:        "jobs",
+			Description: "List automation jobs or run one now",
+			Usage:       "/jobs [run <name>]",
+			Handler:     handleJobs,
+		},
+		{
+			Name:        "queue",
+			Description: "List or cancel requests waiting for the provider",
+			Usage:       "/queue [cancel <n>]",
+			Handler:     handleQueue,
+		},
+		{
+			Name:        "usage",
+			Description: "Show the tokens used per session, day and model",
+			Usage:       "/usage [days]",
+			Handler:     handleUsage,
+		},
+		{
+			Name:        "git",
+			Description: "Summarize changes or history, draft a commit message",
+			Usage:       "/git diff [--staged]|commit|log [<n>]",
+			Handler:     handleGit,
+		},
+		{
+			Name:        "terminal",
+			Description: "Ask about your last tmux pane or shell history",
+			Usage:       "/terminal [history] [<question>]",
+			Handler:     handleTerminal,
+		},
+		{
+			Name:        "think",
+			Description: "Show or collapse the reasoning of thinking models",
+			Usage:       "/think [show|hide]",
+			Handler:     handleThink,
+		},
+		{
+			Name:        "continue",
+			Description: "Continue the reply that broke off",
+			Usage:       "/continue",
+			Handler:     handleContinue,
+		},
+		{
+			Name:        "compare",
+			Description: "Send one prompt to two models and compare their replies",
+			Usage:       "/compare <model-a> <model-b> [<prompt>]|stop",
+			Handler:     handleCompare,
+		},
+		{
+			Name:        "attach",
+			Description: "Send an image or screenshot with your next message",
+			Usage:       "/attach <image>|screenshot|clear",
+			Handler:     handleAttach,
+		},
+		{
+			Name:        "ocr",
+			Description: "Ask about the text in an image or screenshot",
+			Usage:       "/ocr [<image>] [<question>]",
+			Handler:     handleOCR,
+		},
+		{
+			Name:        "kb",
+			Description: "Search your notes: status, index, ask",
+			Usage:       "/kb [index|ask <question>|on|off]",
+			Handler:     handleKB,
+		},
+		{
+			Name:        "speak",
+			Description: "Read replies aloud",
+			Usage:       "/speak [on|off]",
+			Handler:     handleSpeak,
+		},
+		{
+			Name:        "sampling",
+			Aliases:     []string{"set"},
+			Description: "Show or override sampling options for this session",
+			Usage:       "/sampling [<option> <value>|reset]",
+			Handler:     handleSampling,
+		},
+		{
+			Name:        "save",
+			Description: "Save model, language and heartbeat settings to config",
+			Usage:       "/save",
+			Handler:     handleSave,
+		},
 		{
 			Name:        "fetch",
 			Description: "Fetch a web page and display as markdown",
@@ -133,6 +263,18 @@ func init() {
 			Usage:       "/personality edit",
 			Handler:     handlePersonality,
 		},
+		{
+			Name:        "prompt",
+			Description: "Show the size of each part of the system prompt",
+			Usage:       "/prompt stats",
+			Handler:     handlePrompt,
+		},
+		{
+			Name:        "plugins",
+			Description: "List loaded plugins",
+			Usage:       "/plugins",
+			Handler:     handlePlugins,
+		},
 		{
 			Name:        "update",
 			Aliases:     []string{"upgrade"},
@@ -140,21 +282,39 @@ func init() {
 			Usage:       "/update",
 			Handler:     handleUpdate,
 		},
+		{
+			Name:        "restart",
+			Description: "Restart stefanclaw, e.g. after /update",
+			Usage:       "/restart",
+			Handler:     handleRestart,
+		},
+		{
+			Name:        "debug",
+			Description: "Log the raw requests to and responses from the provider",
+			Usage:       "/debug [on|off|pane]",
+			Handler:     handleDebug,
+		},
 	}
 }
 
 // HelpText returns the help message for all slash commands.
 func HelpText() string {
+	return helpText(nil)
+}
+
+// helpText is HelpText in the language of tr.
+func helpText(tr *i18n.Translator) string {
 	var b strings.Builder
-	b.WriteString("Available commands:\n")
+	b.WriteString(tr.T("Available commands:"))
+	b.WriteString("\n")
 	for _, def := range registry {
-		line := fmt.Sprintf("  %-38s %s", def.Usage, def.Description)
+		line := fmt.Sprintf("  %-38s %s", def.Usage, tr.T(def.Description))
 		if len(def.Aliases) > 0 {
 			aliases := make([]string, len(def.Aliases))
 			for i, a := range def.Aliases {
 				aliases[i] = "/" + a
 			}
-			line = fmt.Sprintf("  %-38s %s (aliases: %s)", def.Usage, def.Description, strings.Join(aliases, ", "))
+			line += " " + tr.Sprintf("(aliases: %s)", strings.Join(aliases, ", "))
 		}
 		b.WriteString(line)
 		b.WriteString("\n")
@@ -162,8 +322,24 @@ func HelpText() string {
 	return strings.TrimRight(b.String(), "\n")
 }
 
+// isBuiltinCommand reports whether name is a registered command or alias.
+func isBuiltinCommand(name string) bool {
+	for _, def := range registry {
+		if name == def.Name {
+			return true
+		}
+		for _, alias := range def.Aliases {
+			if name == alias {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // handleCommand dispatches a parsed command to the matching handler.
 func (m *Model) handleCommand(cmd *Command) (tea.Model, tea.Cmd) {
+	log.Debug("command", "name", cmd.Name)
 	for _, def := range registry {
 		if cmd.Name == def.Name {
 			return def.Handler(m, cmd.Args)
@@ -175,9 +351,13 @@ func (m *Model) handleCommand(cmd *Command) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if p, ok := m.pluginCommand(cmd.Name); ok {
+		return m.runPluginCommand(p, cmd.Args)
+	}
+
 	m.messages = append(m.messages, displayMessage{
 		role:    "system",
-		content: fmt.Sprintf("Unknown command: /%s. Type /help for available commands.", cmd.Name),
+		content: m.tr.Sprintf("Unknown command: /%s. Type /help for available commands.", cmd.Name),
 	})
 	m.updateViewport()
 	return m, nil
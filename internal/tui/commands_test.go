@@ -1,6 +1,14 @@
 package tui
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
 
 func TestParseSlashCommand(t *testing.T) {
 	tests := []struct {
@@ -50,7 +58,7 @@ func TestParseSlashCommand_NotACommand(t *testing.T) {
 
 func TestHelpText(t *testing.T) {
 	help := HelpText()
-	commands := []string{"/help", "/quit", "/bye", "/exit", "/models", "/model", "/session", "/clear", "/memory", "/remember", "/forget", "/language", "/heartbeat", "/fetch", "/search", "/personality", "/update", "/upgrade"}
+	commands := []string{"/help", "/quit", "/bye", "/exit", "/models", "/model", "/session", "/clear", "/memory", "/remember", "/forget", "/language", "/heartbeat", "/fetch", "/search", "/personality", "/update", "/upgrade", "/save", "/sampling", "/plugins", "/remind", "/todo", "/schedule", "/jobs", "/git", "/terminal", "/ocr", "/kb", "/speak"}
 	for _, cmd := range commands {
 		if !contains(help, cmd) {
 			t.Errorf("help text missing command: %s", cmd)
@@ -150,10 +158,11 @@ func TestParseSearchCommand(t *testing.T) {
 
 func TestRegistryCoversAllCommands(t *testing.T) {
 	expected := []string{
-		"quit", "help", "clear", "models", "model",
+		"quit", "help", "clear", "models", "model", "provider",
 		"session", "memory", "remember", "forget",
 		"language", "heartbeat", "fetch", "search", "personality",
-		"update",
+		"update", "save", "sampling", "plugins", "remind", "todo", "schedule",
+		"jobs", "git", "terminal", "think", "continue", "compare", "attach", "ocr", "kb", "speak", "prompt",
 	}
 	for _, name := range expected {
 		found := false
@@ -215,7 +224,7 @@ func TestHelpTextAutoGenerated(t *testing.T) {
 }
 
 func TestRegistryDispatchUnknown(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -294,3 +303,18 @@ func containsSubstring(s, sub string) bool {
 	}
 	return false
 }
+
+func TestHelpText_GermanCatalogComplete(t *testing.T) {
+	tr := i18n.For("Deutsch")
+	for _, def := range registry {
+		if tr.T(def.Description) == def.Description {
+			t.Errorf("de catalog is missing the description of /%s: %q", def.Name, def.Description)
+		}
+	}
+	k := newKeyMap(config.KeybindingsConfig{})
+	for _, kb := range []key.Binding{k.Submit, k.Newline, k.Stop, k.Palette, k.Picker, k.HalfPageUp, k.HalfPageDown, k.Yank, k.Voice} {
+		if desc := kb.Help().Desc; tr.T(desc) == desc {
+			t.Errorf("de catalog is missing the key help %q", desc)
+		}
+	}
+}
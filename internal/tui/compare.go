@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+)
+
+// /compare sends one prompt to two models and shows their replies one after
+// the other, each with how long it took. The models answer in turn rather
+// than at once, so neither is slowed down by the other sharing the GPU. The
+// replies stay out of the conversation.
+
+// CompareStartedMsg carries the channel /compare reports on.
+type CompareStartedMsg struct {
+	Ch <-chan tea.Msg
+}
+
+// CompareModelMsg says which model is answering now.
+type CompareModelMsg struct {
+	Model string
+}
+
+// CompareDeltaMsg carries the next part of the current model's reply.
+type CompareDeltaMsg struct {
+	Content string
+}
+
+// CompareReplyMsg reports the end of one model's reply.
+type CompareReplyMsg struct {
+	Model      string
+	Elapsed    time.Duration // from sending the prompt to the end of the reply
+	FirstToken time.Duration // until the first part of the reply arrived
+	Usage      *provider.Usage
+	Err        error
+}
+
+// CompareDoneMsg reports the end of /compare.
+type CompareDoneMsg struct{}
+
+// handleCompare sends a prompt, or the last message you sent, to two
+// models.
+func handleCompare(m *Model, args string) (tea.Model, tea.Cmd) {
+	if args == "stop" {
+		if m.compareCancel != nil {
+			m.compareCancel()
+		}
+		return m, nil
+	}
+	a, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	b, prompt, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		prompt = m.lastUserMessage()
+	}
+	var content string
+	switch {
+	case a == "" || b == "":
+		content = m.tr.T("Usage: /compare <model-a> <model-b> [<prompt>]")
+	case m.compareCancel != nil:
+		content = m.tr.T("Already comparing; Esc or /compare stop cancels it.")
+	case m.streaming:
+		content = m.tr.T("Wait for the reply to finish before comparing.")
+	case prompt == "":
+		content = m.tr.T("Nothing to compare yet: add a prompt, e.g. /compare qwen3:8b llama3.1:8b Explain DNS in two sentences.")
+	default:
+		return m, m.startCompare([]string{a, b}, prompt)
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// lastUserMessage returns the last message you sent, or "" if there is
+// none.
+func (m *Model) lastUserMessage() string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].role == "user" {
+			return m.messages[i].content
+		}
+	}
+	return ""
+}
+
+// startCompare streams the replies of models to prompt in turn. Each model
+// gets the system prompt, without tools, and its own sampling options.
+func (m *Model) startCompare(models []string, prompt string) tea.Cmd {
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "compare"))
+	m.compareCancel = cancel
+	m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Comparing %s and %s for: %s", models[0], models[1], prompt)})
+	m.updateViewport()
+
+	var msgs []provider.Message
+	if sys := m.promptWithTools(nil); sys != "" {
+		msgs = append(msgs, provider.Message{Role: "system", Content: sys})
+	}
+	msgs = append(msgs, provider.Message{Role: "user", Content: prompt})
+	reqs := make([]provider.ChatRequest, len(models))
+	for i, model := range models {
+		reqs[i] = provider.ChatRequest{
+			Model:    model,
+			Messages: msgs,
+			NumCtx:   m.currentNumCtx,
+			Options:  m.samplingOptionsFor(model),
+		}
+	}
+	prov := m.options.Provider
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 1)
+		go func() {
+			for _, req := range reqs {
+				ch <- CompareModelMsg{Model: req.Model}
+				ch <- compareReply(ctx, prov, req, ch)
+				if ctx.Err() != nil {
+					break
+				}
+			}
+			ch <- CompareDoneMsg{}
+		}()
+		return CompareStartedMsg{Ch: ch}
+	}
+}
+
+// compareReply streams the reply to req to ch and reports how it went.
+func compareReply(ctx context.Context, prov provider.Provider, req provider.ChatRequest, ch chan<- tea.Msg) CompareReplyMsg {
+	start := time.Now()
+	reply := CompareReplyMsg{Model: req.Model}
+	stream, err := prov.StreamChat(ctx, req)
+	if err != nil {
+		reply.Err = err
+		return reply
+	}
+	for delta := range stream {
+		if delta.Err != nil {
+			reply.Err = delta.Err
+			break
+		}
+		if delta.Content != "" {
+			if reply.FirstToken == 0 {
+				reply.FirstToken = time.Since(start)
+			}
+			ch <- CompareDeltaMsg{Content: delta.Content}
+		}
+		if delta.Done {
+			reply.Usage = delta.Usage
+		}
+	}
+	reply.Elapsed = time.Since(start)
+	return reply
+}
+
+// handleCompareModel starts showing the reply of the next model.
+func (m *Model) handleCompareModel(msg CompareModelMsg) {
+	m.messages = append(m.messages,
+		displayMessage{role: "system", content: "── " + msg.Model + " ──"},
+		displayMessage{role: "compare"},
+	)
+	m.compareMsg = len(m.messages) - 1
+	m.updateViewport()
+}
+
+// handleCompareDelta adds to the reply shown last.
+func (m *Model) handleCompareDelta(msg CompareDeltaMsg) {
+	if m.compareMsg < len(m.messages) {
+		m.messages[m.compareMsg].content += msg.Content
+	}
+	m.updateViewport()
+}
+
+// handleCompareReply shows how long the reply of a model took.
+func (m *Model) handleCompareReply(msg CompareReplyMsg) {
+	var content string
+	switch {
+	case errors.Is(msg.Err, context.Canceled):
+		content = m.tr.Sprintf("%s stopped.", msg.Model)
+	case msg.Err != nil:
+		content = m.tr.Sprintf("%s failed: %v", msg.Model, msg.Err)
+	default:
+		content = m.compareStats(msg)
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+}
+
+// compareStats describes how fast a model answered, e.g. "qwen3:8b: 4.2s,
+// first token after 0.8s, 38.1 tokens/s".
+func (m *Model) compareStats(msg CompareReplyMsg) string {
+	stats := []string{formatSeconds(msg.Elapsed)}
+	if msg.FirstToken > 0 {
+		stats = append(stats, m.tr.Sprintf("first token after %s", formatSeconds(msg.FirstToken)))
+	}
+	if u := msg.Usage; u != nil && u.CompletionTokens > 0 {
+		gen := u.EvalDuration
+		if gen <= 0 {
+			gen = msg.Elapsed - msg.FirstToken
+		}
+		if gen > 0 {
+			stats = append(stats, m.tr.Sprintf("%.1f tokens/s", float64(u.CompletionTokens)/gen.Seconds()))
+		}
+	}
+	return msg.Model + ": " + strings.Join(stats, ", ")
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// handleCompareDone ends /compare.
+func (m *Model) handleCompareDone() {
+	m.compareCh = nil
+	m.compareCancel()
+	m.compareCancel = nil
+	m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Switch to the one you prefer with /model <name>.")})
+	m.updateViewport()
+}
+
+// renderCompare renders a reply /compare got, with its reasoning collapsed
+// like that of replies in the conversation.
+func (m *Model) renderCompare(content string) string {
+	return m.withThinking(content, m.renderMarkdown)
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// runCompare runs a /compare command until it's done.
+func runCompare(m *Model, args string) {
+	_, cmd := m.handleCommand(&Command{Name: "compare", Args: args})
+	for i := 0; cmd != nil && i < 100; i++ {
+		newM, next := m.Update(cmd())
+		*m = newM.(Model)
+		cmd = next
+	}
+}
+
+func TestCompare(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Chunks: []string{"DNS maps ", "names to addresses."}, Usage: provider.Usage{CompletionTokens: 40, EvalDuration: 2 * time.Second}},
+		{Content: "It's the internet's phone book."},
+	}}
+	m := newAgentModel(t, mp)
+	temp := 0.2
+	m.options.Sampling.Models = map[string]provider.Options{"llama3.1:8b": {Temperature: &temp}}
+
+	runCompare(&m, "qwen3:8b llama3.1:8b Explain DNS.")
+
+	reqs := mp.Requests()
+	if len(reqs) != 2 || reqs[0].Model != "qwen3:8b" || reqs[1].Model != "llama3.1:8b" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if last.Role != "user" || last.Content != "Explain DNS." {
+		t.Errorf("prompt = %+v", last)
+	}
+	if reqs[0].Options.Temperature != nil || reqs[1].Options.Temperature == nil {
+		t.Error("the models didn't get their own sampling options")
+	}
+
+	var replies, stats []string
+	for _, msg := range m.messages {
+		switch {
+		case msg.role == "compare":
+			replies = append(replies, msg.content)
+		case msg.role == "system" && strings.Contains(msg.content, "s, first token after"):
+			stats = append(stats, msg.content)
+		case msg.role == "user" || msg.role == "assistant":
+			t.Errorf("/compare added to the conversation: %+v", msg)
+		}
+	}
+	if len(replies) != 2 || replies[0] != "DNS maps names to addresses." || replies[1] != "It's the internet's phone book." {
+		t.Errorf("replies = %q", replies)
+	}
+	if len(stats) != 2 || !strings.HasPrefix(stats[0], "qwen3:8b: ") || !strings.HasSuffix(stats[0], "20.0 tokens/s") {
+		t.Errorf("stats = %q", stats)
+	}
+	if m.compareCancel != nil {
+		t.Error("still comparing")
+	}
+}
+
+func TestCompare_LastMessage(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := newAgentModel(t, mp)
+
+	runCompare(&m, "a b")
+	if got := lastMessage(&m).content; !strings.Contains(got, "Nothing to compare yet") {
+		t.Errorf("without a prompt = %q", got)
+	}
+
+	m.messages = append(m.messages, displayMessage{role: "user", content: "What's a monad?"})
+	runCompare(&m, "a b")
+	reqs := mp.Requests()
+	if len(reqs) != 2 || reqs[0].Messages[len(reqs[0].Messages)-1].Content != "What's a monad?" {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestCompare_Errors(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Err: errors.New(`model "nope" not found`)},
+		{Content: "Fine."},
+	}}
+	m := newAgentModel(t, mp)
+
+	runCompare(&m, "nope qwen3:8b Hi")
+	var failed bool
+	for _, msg := range m.messages {
+		failed = failed || strings.HasPrefix(msg.content, `nope failed: model "nope" not found`)
+	}
+	if !failed || len(mp.Requests()) != 2 {
+		t.Errorf("a failing model should be reported and the other still asked; messages: %+v", m.messages)
+	}
+}
+
+func TestCompare_BlocksMessages(t *testing.T) {
+	m := newAgentModel(t, &providertest.Fake{})
+	m.compareCancel = func() {}
+	m.textarea.SetValue("hello")
+
+	m.handleSubmit()
+	if m.textarea.Value() != "hello" || m.streaming {
+		t.Error("sent a message while comparing")
+	}
+
+	_, cmd := m.handleCommand(&Command{Name: "compare", Args: "a b c"})
+	if cmd != nil || !strings.Contains(lastMessage(&m).content, "Already comparing") {
+		t.Errorf("started a second comparison: %q", lastMessage(&m).content)
+	}
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+// crashState records a crash of the TUI.
+type crashState struct {
+	report string // path of the crash report; empty if none could be written
+	err    error  // why the report couldn't be written
+	draft  string // unsent input
+}
+
+// Update handles msg. A panic while doing so doesn't take the terminal and
+// the conversation down with it: the conversation is saved, a crash report
+// is written, and the TUI quits cleanly.
+func (m Model) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.recoverCrash(r, msg)
+			m.quitting = true
+			model, cmd = m, tea.Quit
+		}
+	}()
+	return m.update(msg)
+}
+
+// View renders the TUI. A panic while rendering is handled like one in
+// Update, then passed on for Bubble Tea to restore the terminal: View can't
+// quit the program itself.
+func (m Model) View() string {
+	defer func() {
+		if r := recover(); r != nil {
+			m.recoverCrash(r, "rendering")
+			panic(r)
+		}
+	}()
+	return m.view()
+}
+
+// recoverCrash saves the conversation as far as it got, including a reply
+// still streaming, and writes a crash report about the panic r, which
+// happened handling msg.
+func (m *Model) recoverCrash(r any, msg any) {
+	stack := debug.Stack()
+	if m.crash == nil {
+		m.crash = &crashState{}
+	}
+	if m.streamCancelFn != nil {
+		m.streamCancelFn()
+	}
+	if reply := think.Strip(m.streamContent); m.streaming && reply != "" {
+		m.appendTranscript("assistant", reply+"\n\n[Reply cut short by a crash]")
+	}
+	if m.options.SessionStore != nil && m.options.Session != nil {
+		m.options.SessionStore.SetCurrent(m.options.Session.ID)
+	}
+	m.crash.draft = strings.TrimSpace(m.textarea.Value())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "stefanclaw %s crashed at %s\n", m.options.Version, m.now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "%s/%s, %s, model %s\n\n", runtime.GOOS, runtime.GOARCH, runtime.Version(), m.options.Model)
+	fmt.Fprintf(&b, "panic: %v\nwhile handling: %T\n\n%s", r, msg, stack)
+	m.crash.report, m.crash.err = writeCrashReport(b.String(), m.now())
+	log.Error("crashed", "panic", r, "report", m.crash.report)
+}
+
+// writeCrashReport saves report in the crash reports directory and returns
+// its path.
+func writeCrashReport(report string, t time.Time) (string, error) {
+	dir := config.CrashReportsDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "crash-"+t.Format("20060102-150405")+".txt")
+	return path, os.WriteFile(path, []byte(report), 0o644)
+}
+
+// Crashed describes a crash of the TUI for the user, or returns "" if it
+// didn't crash. Call it once the TUI has quit.
+func (m Model) Crashed() string {
+	if m.crash == nil || (m.crash.report == "" && m.crash.err == nil) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("stefanclaw crashed. Your conversation was saved and continues at the next start.\n")
+	if m.crash.err != nil {
+		b.WriteString(m.tr.Sprintf("The crash report couldn't be written: %v", m.crash.err) + "\n")
+	} else {
+		b.WriteString(m.tr.Sprintf("Please attach the crash report to a bug report: %s", m.crash.report) + "\n")
+	}
+	if m.crash.draft != "" {
+		b.WriteString(m.tr.Sprintf("Your unsent message was:\n\n%s", m.crash.draft) + "\n")
+	}
+	return b.String()
+}
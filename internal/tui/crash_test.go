@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+// crashingProvider panics when asked for its name once crash is set.
+type crashingProvider struct {
+	providertest.Fake
+	crash bool
+}
+
+func (p *crashingProvider) Name() string {
+	if p.crash {
+		panic("boom")
+	}
+	return p.ProviderName
+}
+
+func newCrashModel(t *testing.T) (Model, *crashingProvider, *session.FileStore, *session.Session) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+	store := session.NewFileStore(config.SessionsDir())
+	sess, _ := store.Create("Test", "test-model")
+	p := &crashingProvider{Fake: providertest.Fake{ProviderName: "test"}}
+	m := New(Options{Provider: p, Model: "test-model", Version: "1.2.3", SessionStore: store, Session: sess})
+	newM, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	return newM.(Model), p, store, sess
+}
+
+func TestCrash_InUpdate(t *testing.T) {
+	m, p, store, sess := newCrashModel(t)
+	p.crash = true
+	m.textarea.SetValue("/debug on")
+
+	newM, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Error("crash didn't quit")
+	}
+	m.Close()
+
+	crash := m.Crashed()
+	if !strings.Contains(crash, "stefanclaw crashed") || !strings.Contains(crash, "Your unsent message was:\n\n/debug on") {
+		t.Errorf("Crashed() = %q", crash)
+	}
+	if cur, _ := store.Current(); cur == nil || cur.ID != sess.ID {
+		t.Error("crashed session isn't resumed at the next start")
+	}
+	data, err := os.ReadFile(m.crash.report)
+	if err != nil {
+		t.Fatalf("reading crash report: %v", err)
+	}
+	for _, want := range []string{"stefanclaw 1.2.3 crashed", "panic: boom", "while handling: tea.KeyMsg", "crashingProvider"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("crash report lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestCrash_InView(t *testing.T) {
+	m, p, store, sess := newCrashModel(t)
+	m.streaming = true
+	m.streamContent = "Half a rep"
+	p.crash = true
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want View's panic passed on", r)
+			}
+		}()
+		m.View()
+	}()
+	m.Close()
+
+	if m.Crashed() == "" {
+		t.Error("crash in View not reported")
+	}
+	transcript, _ := store.LoadTranscript(sess.ID)
+	if len(transcript) != 1 || !strings.HasPrefix(transcript[0].Content, "Half a rep") {
+		t.Errorf("transcript = %v, want the partial reply", transcript)
+	}
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+)
+
+// debugPaneHeight is the height of the provider traffic pane, title
+// included.
+const debugPaneHeight = 8
+
+// handleDebug turns debug mode, which logs the exact requests to and
+// responses from the provider, on or off, and shows or hides the pane with
+// the latest of them.
+func handleDebug(m *Model, args string) (tea.Model, tea.Cmd) {
+	var content string
+	switch strings.TrimSpace(args) {
+	case "":
+		state := "off"
+		if log.Debugging() {
+			state = "on"
+		}
+		content = m.tr.Sprintf("Debug mode is %s. Usage: /debug on|off|pane", m.tr.T(state))
+	case "on":
+		log.SetDebug(true)
+		content = m.tr.Sprintf("Debug mode on: requests to and responses from %s are logged to %s, with secrets redacted. /debug pane shows them here.", m.options.Provider.Name(), config.LogFile())
+	case "off":
+		log.SetDebug(false)
+		m.setDebugPane(false)
+		content = m.tr.T("Debug mode off.")
+	case "pane":
+		if !m.debugPane {
+			log.SetDebug(true)
+		}
+		m.setDebugPane(!m.debugPane)
+		content = m.tr.T("Debug pane hidden.")
+		if m.debugPane {
+			content = m.tr.T("Debug pane shown: the latest provider traffic appears above the input. /debug pane hides it.")
+		}
+	default:
+		content = m.tr.T("Usage: /debug on|off|pane")
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// setDebugPane shows or hides the debug pane, making room for it in the
+// viewport.
+func (m *Model) setDebugPane(on bool) {
+	m.debugPane = on
+	m.layout()
+}
+
+// debugPaneView renders the latest provider traffic, one record per line.
+func (m Model) debugPaneView() string {
+	lines := []string{systemMsgStyle.Render(m.tr.Sprintf("Provider traffic (full bodies in %s)", config.LogFile()))}
+	records := log.RecentTraffic()
+	if n := debugPaneHeight - 1; len(records) > n {
+		records = records[len(records)-n:]
+	}
+	for _, r := range records {
+		lines = append(lines, fmt.Sprintf("%s %s %s", r.Time.Format("15:04:05"), r.What, r.Body))
+	}
+	for len(lines) < debugPaneHeight {
+		lines = append(lines, "")
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(strings.Join(lines, "\n"))
+}
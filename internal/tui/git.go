@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/git"
+)
+
+// GitDoneMsg carries what /git read from the repository: the prompt to send
+// to the model, or a note to show when there is nothing to send.
+type GitDoneMsg struct {
+	Prompt string
+	Note   string
+	Err    error
+}
+
+const gitUsage = "Usage: /git diff [--staged] | commit | log [<n>]"
+
+// gitPrompts are the instructions sent with each /git subcommand.
+var gitPrompts = map[string]string{
+	"diff":   "Summarize these uncommitted changes to %s: what changed and why it might matter. Point out anything that looks like a mistake.",
+	"commit": "Write a commit message for these staged changes to %s: a subject line of at most 50 characters in the imperative mood, a blank line, then a short body explaining what changed and why. Reply with the message only.",
+	"log":    "Summarize this recent history of %s in a few bullets: the main threads of work and anything notable.",
+}
+
+func handleGit(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return reply(m.tr.T(gitUsage))
+	}
+	sub, staged, n := fields[0], false, 10
+	switch {
+	case sub == "diff" && len(fields) == 1:
+	case sub == "diff" && len(fields) == 2 && (fields[1] == "--staged" || fields[1] == "--cached"):
+		staged = true
+	case sub == "commit" && len(fields) == 1:
+		staged = true
+	case sub == "log" && len(fields) <= 2:
+		if len(fields) == 2 {
+			v, err := strconv.Atoi(fields[1])
+			if err != nil || v < 1 || v > 100 {
+				return reply(m.tr.T("The number of commits must be between 1 and 100."))
+			}
+			n = v
+		}
+	default:
+		return reply(m.tr.T(gitUsage))
+	}
+
+	dir := m.options.WorkDir
+	if dir == "" {
+		dir = "."
+	}
+	return m, func() tea.Msg {
+		repo, err := git.Open(dir)
+		if err != nil {
+			return GitDoneMsg{Err: err}
+		}
+		ctx := context.Background()
+		var out, lang string
+		if sub == "log" {
+			out, err = repo.Log(ctx, n)
+		} else {
+			out, err = repo.Diff(ctx, staged)
+			lang = "diff"
+		}
+		switch {
+		case err != nil:
+			return GitDoneMsg{Err: err}
+		case out == "" && staged:
+			return GitDoneMsg{Note: m.tr.T("No staged changes. Stage them with git add first.")}
+		case out == "":
+			return GitDoneMsg{Note: m.tr.T("No uncommitted changes.")}
+		}
+		prompt := fmt.Sprintf(gitPrompts[sub], repo.Name())
+		return GitDoneMsg{Prompt: fmt.Sprintf("%s\n\n```%s\n%s\n```", prompt, lang, out)}
+	}
+}
+
+// handleGitDone sends what /git read to the model, unless a reply is still
+// streaming.
+func (m *Model) handleGitDone(msg GitDoneMsg) tea.Cmd {
+	switch {
+	case msg.Err != nil:
+		m.messages = append(m.messages, displayMessage{role: "error", content: fmt.Sprintf("/git failed: %v", msg.Err)})
+	case msg.Note != "":
+		m.messages = append(m.messages, displayMessage{role: "system", content: msg.Note})
+	case m.streaming:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Still answering; try /git again when the reply is done.")})
+	default:
+		return m.sendMessage(msg.Prompt)
+	}
+	m.updateViewport()
+	return nil
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestGit_SummarizesDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	gitRun("init", "-q", "-b", "main")
+	gitRun("config", "user.name", "Ada")
+	gitRun("config", "user.email", "ada@example.com")
+	os.WriteFile(filepath.Join(dir, "plan.txt"), []byte("draft\n"), 0o644)
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "Add plan")
+	os.WriteFile(filepath.Join(dir, "plan.txt"), []byte("final\n"), 0o644)
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{Provider: mp, Model: "test-model", WorkDir: dir})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// Nothing is staged yet
+	_, cmd := handleGit(&m, "commit")
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, _ := m.Update(msgs[0])
+	m = newM.(Model)
+	if got := lastMessage(&m); !strings.Contains(got.content, "No staged changes") {
+		t.Errorf("last message = %+v, want the no staged changes note", got)
+	}
+
+	_, cmd = handleGit(&m, "diff")
+	msgs = collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, cmd = m.Update(msgs[0])
+	m = newM.(Model)
+	if !m.streaming {
+		t.Fatal("the diff should be sent to the model")
+	}
+	collectMsgs(cmd)
+	sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.Contains(sent, "Summarize these uncommitted changes") || !strings.Contains(sent, "+final") {
+		t.Errorf("sent prompt = %q, want the instructions and the patch", sent)
+	}
+
+	if _, cmd := handleGit(&m, "log many"); cmd != nil {
+		t.Error("an invalid count should not run git")
+	}
+	if got := lastMessage(&m); !strings.Contains(got.content, "between 1 and 100") {
+		t.Errorf("last message = %+v, want the count error", got)
+	}
+}
@@ -8,67 +8,114 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/session"
 	"github.com/stefanclaw/stefanclaw/internal/update"
 )
 
 func handleQuit(m *Model, args string) (tea.Model, tea.Cmd) {
+	m.speaker.Stop()
 	m.quitting = true
 	return m, tea.Quit
 }
 
+// handleRestart quits and has stefanclaw start again in the same session,
+// e.g. to run the version /update installed.
+func handleRestart(m *Model, args string) (tea.Model, tea.Cmd) {
+	if m.streaming || m.pendingCall != nil || m.updateCh != nil {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Wait for the reply, tool call or update to finish before restarting.")})
+		m.updateViewport()
+		return m, nil
+	}
+	if m.options.SessionStore != nil && m.options.Session != nil {
+		m.options.SessionStore.SetCurrent(m.options.Session.ID)
+	}
+	m.restart = true
+	return handleQuit(m, "")
+}
+
+// Close finishes saving the conversation. Call it once the TUI has quit.
+func (m Model) Close() error {
+	if m.transcript == nil {
+		return nil
+	}
+	return m.transcript.Close()
+}
+
+// RestartRequested reports whether the TUI quit with /restart, asking the
+// caller to start stefanclaw again.
+func (m Model) RestartRequested() bool {
+	return m.restart
+}
+
 func handleHelp(m *Model, args string) (tea.Model, tea.Cmd) {
 	m.messages = append(m.messages, displayMessage{
 		role:    "system",
-		content: HelpText(),
+		content: helpText(m.tr) + m.pluginHelpText() + "\n\n" + m.keys.helpText(m.tr),
 	})
 	m.updateViewport()
 	return m, nil
 }
 
 func handleClear(m *Model, args string) (tea.Model, tea.Cmd) {
+	m.settleInterrupted()
 	m.messages = nil
+	m.pending = false
+	m.shrinkContext()
 	m.updateViewport()
 	return m, nil
 }
 
 func handleModels(m *Model, args string) (tea.Model, tea.Cmd) {
-	return m, m.listModels()
+	return m, m.listModels(args)
 }
 
 func handleModel(m *Model, args string) (tea.Model, tea.Cmd) {
+	sub, name, _ := strings.Cut(args, " ")
+	switch sub {
+	case "info":
+		return handleModelInfo(m, strings.TrimSpace(name))
+	case "rm":
+		return handleModelRm(m, strings.TrimSpace(name))
+	}
+	var cmd tea.Cmd
 	if args == "" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Current model: %s\nUsage: /model <name>", m.options.Model),
+			content: m.tr.Sprintf("Current model: %s\nUsage: /model <name> | /model info [<name>] | /model rm <name>", m.options.Model),
 		})
 	} else {
 		m.options.Model = args
+		cmd = tea.Batch(m.detectCapabilities(), m.warmModel())
+		m.currentNumCtx = m.savedNumCtx(args)
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Switched to model: %s", args),
+			content: m.tr.Sprintf("Switched to model: %s", args) + m.settingsChanged(),
 		})
 	}
 	m.updateViewport()
-	return m, nil
+	return m, cmd
 }
 
 func handleSession(m *Model, args string) (tea.Model, tea.Cmd) {
 	switch args {
 	case "new":
 		if m.options.SessionStore != nil {
-			s, err := m.options.SessionStore.Create("New Chat", m.options.Model)
+			s, err := m.options.SessionStore.Create(session.DefaultTitle, m.options.Model)
 			if err != nil {
 				m.messages = append(m.messages, displayMessage{
 					role:    "system",
-					content: fmt.Sprintf("Error creating session: %v", err),
+					content: m.tr.Sprintf("Error creating session: %v", err),
 				})
 			} else {
 				m.options.Session = s
 				m.options.SessionStore.SetCurrent(s.ID)
 				m.messages = nil
+				m.state = session.State{}
+				m.shrinkContext()
 				m.messages = append(m.messages, displayMessage{
 					role:    "system",
-					content: fmt.Sprintf("New session: %s", s.ID),
+					content: m.tr.Sprintf("New session: %s", s.ID),
 				})
 			}
 		}
@@ -78,16 +125,16 @@ func handleSession(m *Model, args string) (tea.Model, tea.Cmd) {
 			if err != nil {
 				m.messages = append(m.messages, displayMessage{
 					role:    "system",
-					content: fmt.Sprintf("Error listing sessions: %v", err),
+					content: m.tr.Sprintf("Error listing sessions: %v", err),
 				})
 			} else if len(sessions) == 0 {
 				m.messages = append(m.messages, displayMessage{
 					role:    "system",
-					content: "No sessions found.",
+					content: m.tr.T("No sessions found."),
 				})
 			} else {
 				var lines []string
-				lines = append(lines, "Sessions:")
+				lines = append(lines, m.tr.T("Sessions:"))
 				for _, s := range sessions {
 					marker := "  "
 					if m.options.Session != nil && s.ID == m.options.Session.ID {
@@ -105,7 +152,7 @@ func handleSession(m *Model, args string) (tea.Model, tea.Cmd) {
 	default:
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /session new | /session list",
+			content: m.tr.T("Usage: /session new | /session list"),
 		})
 	}
 	m.updateViewport()
@@ -116,7 +163,7 @@ func handleMemory(m *Model, args string) (tea.Model, tea.Cmd) {
 	if m.options.MemoryStore == nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Memory system not configured.",
+			content: m.tr.T("Memory system not configured."),
 		})
 		m.updateViewport()
 		return m, nil
@@ -126,17 +173,17 @@ func handleMemory(m *Model, args string) (tea.Model, tea.Cmd) {
 	if err != nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Error reading memory: %v", err),
+			content: m.tr.Sprintf("Error reading memory: %v", err),
 		})
 	} else if len(entries) == 0 {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "No memory entries yet.",
+			content: m.tr.T("No memory entries yet."),
 		})
 	} else {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Memory:\n" + strings.Join(entries, "\n"),
+			content: m.tr.T("Memory:") + "\n" + strings.Join(entries, "\n"),
 		})
 	}
 	m.updateViewport()
@@ -147,7 +194,7 @@ func handleRemember(m *Model, args string) (tea.Model, tea.Cmd) {
 	if args == "" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /remember <fact>",
+			content: m.tr.T("Usage: /remember <fact>"),
 		})
 		m.updateViewport()
 		return m, nil
@@ -156,7 +203,7 @@ func handleRemember(m *Model, args string) (tea.Model, tea.Cmd) {
 	if m.options.MemoryStore == nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Memory system not configured.",
+			content: m.tr.T("Memory system not configured."),
 		})
 		m.updateViewport()
 		return m, nil
@@ -165,12 +212,12 @@ func handleRemember(m *Model, args string) (tea.Model, tea.Cmd) {
 	if err := m.options.MemoryStore.Append([]string{args}); err != nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Error saving memory: %v", err),
+			content: m.tr.Sprintf("Error saving memory: %v", err),
 		})
 	} else {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Remembered: %s", args),
+			content: m.tr.Sprintf("Remembered: %s", args),
 		})
 	}
 	m.updateViewport()
@@ -181,7 +228,7 @@ func handleForget(m *Model, args string) (tea.Model, tea.Cmd) {
 	if args == "" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /forget <keyword>",
+			content: m.tr.T("Usage: /forget <keyword>"),
 		})
 		m.updateViewport()
 		return m, nil
@@ -190,7 +237,7 @@ func handleForget(m *Model, args string) (tea.Model, tea.Cmd) {
 	if m.options.MemoryStore == nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Memory system not configured.",
+			content: m.tr.T("Memory system not configured."),
 		})
 		m.updateViewport()
 		return m, nil
@@ -200,17 +247,17 @@ func handleForget(m *Model, args string) (tea.Model, tea.Cmd) {
 	if err != nil {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Error: %v", err),
+			content: m.tr.Sprintf("Error: %v", err),
 		})
 	} else if removed == 0 {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("No memory entries matching %q found.", args),
+			content: m.tr.Sprintf("No memory entries matching %q found.", args),
 		})
 	} else {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Forgot %d entries matching %q.", removed, args),
+			content: m.tr.Sprintf("Forgot %d entries matching %q.", removed, args),
 		})
 	}
 	m.updateViewport()
@@ -218,19 +265,36 @@ func handleForget(m *Model, args string) (tea.Model, tea.Cmd) {
 }
 
 func handleLanguage(m *Model, args string) (tea.Model, tea.Cmd) {
-	if args == "" {
+	switch args {
+	case "":
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Current language: %s\nUsage: /language <name>", m.options.Language),
+			content: m.tr.Sprintf("Current language: %s\nUsage: /language <name>|list", m.options.Language),
 		})
-	} else {
-		m.options.Language = args
+	case "list":
+		var b strings.Builder
+		b.WriteString(m.tr.T("Known languages (any other name works too):"))
+		for _, l := range config.Languages() {
+			fmt.Fprintf(&b, "\n  %-4s %s", l.Code, l.Name)
+			if l.English != l.Name {
+				fmt.Fprintf(&b, " (%s)", l.English)
+			}
+		}
+		m.messages = append(m.messages, displayMessage{role: "system", content: b.String()})
+	default:
+		lang, known := config.NormalizeLanguage(args)
+		m.options.Language = lang
+		m.setTranslator(lang)
 		if m.options.PromptAsm != nil {
-			m.options.SystemPrompt = m.options.PromptAsm.BuildSystemPromptWithLanguage(args)
+			m.options.SystemPrompt = m.options.PromptAsm.BuildSystemPromptWithLanguage(lang)
+		}
+		content := m.tr.Sprintf("Language changed to: %s", lang)
+		if !known {
+			content += m.tr.T(" (not a known language; passed to the model as written)")
 		}
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Language changed to: %s", args),
+			content: content + m.settingsChanged(),
 		})
 	}
 	m.updateViewport()
@@ -238,22 +302,36 @@ func handleLanguage(m *Model, args string) (tea.Model, tea.Cmd) {
 }
 
 func handleHeartbeat(m *Model, args string) (tea.Model, tea.Cmd) {
+	if sub, rest, _ := strings.Cut(args, " "); sub == "list" || sub == "add" || sub == "remove" {
+		return handleHeartbeatSchedules(m, sub, strings.TrimSpace(rest))
+	} else if sub == "stats" {
+		return handleHeartbeatStats(m, strings.TrimSpace(rest))
+	}
+	if m.options.Privacy.DisableHeartbeat && args != "" && args != "off" {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T(heartbeatDisabledMsg),
+		})
+		m.updateViewport()
+		return m, nil
+	}
+
 	switch args {
 	case "":
-		status := "disabled"
+		status := m.tr.T("disabled")
 		if m.heartbeatEnabled {
-			status = "enabled"
+			status = m.tr.T("enabled")
 		}
 		m.messages = append(m.messages, displayMessage{
 			role: "system",
-			content: fmt.Sprintf("Heartbeat: %s\nInterval: %s",
-				status, m.heartbeatInterval),
+			content: m.tr.Sprintf("Heartbeat: %s\nInterval: %s",
+				status, m.heartbeatInterval) + m.heartbeatQuietRules() + m.heartbeatToolStatus() + m.heartbeatScheduleStatus() + m.heartbeatTaskStatus(),
 		})
 	case "on":
 		m.heartbeatEnabled = true
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Heartbeat enabled (every %s)", m.heartbeatInterval),
+			content: m.tr.Sprintf("Heartbeat enabled (every %s)", m.heartbeatInterval) + m.heartbeatChanged(),
 		})
 		m.updateViewport()
 		return m, m.scheduleHeartbeat()
@@ -261,20 +339,20 @@ func handleHeartbeat(m *Model, args string) (tea.Model, tea.Cmd) {
 		m.heartbeatEnabled = false
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Heartbeat disabled.",
+			content: m.tr.T("Heartbeat disabled.") + m.heartbeatChanged(),
 		})
 	default:
 		dur, err := time.ParseDuration(args)
 		if err != nil {
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: fmt.Sprintf("Invalid interval: %s\nUsage: /heartbeat [on|off|<duration>]", args),
+				content: m.tr.Sprintf("Invalid interval: %s\nUsage: /heartbeat [on|off|<duration>|list|add|remove|stats]", args),
 			})
 		} else {
 			m.heartbeatInterval = dur
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: fmt.Sprintf("Heartbeat interval set to %s", dur),
+				content: m.tr.Sprintf("Heartbeat interval set to %s", dur) + m.heartbeatChanged(),
 			})
 			if m.heartbeatEnabled {
 				m.updateViewport()
@@ -290,7 +368,7 @@ func handleFetch(m *Model, args string) (tea.Model, tea.Cmd) {
 	if args == "" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /fetch <url>",
+			content: m.tr.T("Usage: /fetch <url>"),
 		})
 		m.updateViewport()
 		return m, nil
@@ -298,7 +376,7 @@ func handleFetch(m *Model, args string) (tea.Model, tea.Cmd) {
 
 	m.messages = append(m.messages, displayMessage{
 		role:    "system",
-		content: fmt.Sprintf("Fetching %s...", args),
+		content: m.tr.Sprintf("Fetching %s...", args),
 	})
 	m.updateViewport()
 
@@ -316,7 +394,7 @@ func handleSearch(m *Model, args string) (tea.Model, tea.Cmd) {
 	if args == "" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /search <query>",
+			content: m.tr.T("Usage: /search <query>"),
 		})
 		m.updateViewport()
 		return m, nil
@@ -324,7 +402,7 @@ func handleSearch(m *Model, args string) (tea.Model, tea.Cmd) {
 
 	m.messages = append(m.messages, displayMessage{
 		role:    "system",
-		content: fmt.Sprintf("Searching for %q...", args),
+		content: m.tr.Sprintf("Searching for %q...", args),
 	})
 	m.updateViewport()
 
@@ -338,26 +416,64 @@ func handleSearch(m *Model, args string) (tea.Model, tea.Cmd) {
 	}
 }
 
+// managed is update.Managed, replaced in tests.
+var managed = update.Managed
+
 func handleUpdate(m *Model, args string) (tea.Model, tea.Cmd) {
 	version := m.options.Version
 	if version == "" || version == "dev" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Auto-update is not available for development builds.",
+			content: m.tr.T("Auto-update is not available for development builds."),
+		})
+		m.updateViewport()
+		return m, nil
+	}
+
+	if pm, ok := managed(); ok {
+		m.messages = append(m.messages, displayMessage{role: "system", content: pm.Advice()})
+		m.updateViewport()
+		return m, nil
+	}
+
+	if m.options.Privacy.DisableWeb {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T(updateDisabledMsg),
 		})
 		m.updateViewport()
 		return m, nil
 	}
 
+	channel := m.options.Update.Channel
+	checking := m.tr.T("Checking for updates...")
+	if channel != "" && channel != update.ChannelStable {
+		checking = m.tr.Sprintf("Checking for updates (%s channel)...", channel)
+	}
 	m.messages = append(m.messages, displayMessage{
 		role:    "system",
-		content: "Checking for updates...",
+		content: checking,
 	})
+	m.updateMsg = len(m.messages) - 1
 	m.updateViewport()
 
 	return m, func() tea.Msg {
-		res, err := update.Apply(context.Background(), version)
-		return UpdateApplyMsg{Result: res, Err: err}
+		ch := make(chan tea.Msg, 1)
+		go func() {
+			res, err := update.Apply(context.Background(), version, channel, func(p update.Progress) {
+				if p.Stage == update.StageDownload {
+					// Drop download progress the TUI is still behind on
+					select {
+					case ch <- UpdateProgressMsg{Progress: p}:
+					default:
+					}
+					return
+				}
+				ch <- UpdateProgressMsg{Progress: p}
+			})
+			ch <- UpdateApplyMsg{Result: res, Err: err}
+		}()
+		return UpdateStartedMsg{Ch: ch}
 	}
 }
 
@@ -365,15 +481,31 @@ func handlePersonality(m *Model, args string) (tea.Model, tea.Cmd) {
 	if args == "edit" {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Open your personality files at:\n  %s", m.options.PersonalityDir),
+			content: m.tr.Sprintf("Open your personality files at:\n  %s", m.options.PersonalityDir),
 		})
 	} else {
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: "Usage: /personality edit",
+			content: m.tr.T("Usage: /personality edit"),
 		})
 	}
 	m.updateViewport()
 	return m, nil
 }
 
+func handleSave(m *Model, args string) (tea.Model, tea.Cmd) {
+	if err := m.persistSettings(); err != nil {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Error saving settings: %v", err),
+		})
+	} else {
+		m.messages = append(m.messages, displayMessage{
+			role: "system",
+			content: m.tr.Sprintf("Settings saved to %s (model: %s, language: %s, heartbeat: %t every %s)",
+				config.ConfigFile(), m.options.Model, m.options.Language, m.heartbeatEnabled, formatInterval(m.heartbeatInterval)),
+		})
+	}
+	m.updateViewport()
+	return m, nil
+}
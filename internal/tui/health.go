@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/retry"
+)
+
+// healthCheckTimeout bounds a single check.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthTickMsg signals it is time to check the provider.
+type HealthTickMsg struct{}
+
+// HealthMsg carries the result of checking whether Provider is reachable.
+type HealthMsg struct {
+	Provider provider.Provider
+	Err      error
+}
+
+func (m *Model) scheduleHealthCheck() tea.Cmd {
+	return tea.Tick(m.options.HealthInterval, func(time.Time) tea.Msg {
+		return HealthTickMsg{}
+	})
+}
+
+// checkHealth asks the provider whether it is reachable.
+func (m *Model) checkHealth() tea.Cmd {
+	prov := m.options.Provider
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+		return HealthMsg{Provider: prov, Err: prov.IsAvailable(ctx)}
+	}
+}
+
+// handleHealth records the result of a check. Once the provider is back,
+// the messages queued while it was unreachable are sent.
+func (m *Model) handleHealth(msg HealthMsg) tea.Cmd {
+	if msg.Provider != m.options.Provider {
+		return nil // checked before a /provider switch
+	}
+	wasDown := m.healthChecked && m.healthErr != nil
+	m.healthChecked = true
+	m.healthErr = msg.Err
+	switch {
+	case msg.Err != nil && !wasDown:
+		log.Warn("provider unreachable", "provider", msg.Provider.Name(), "err", msg.Err)
+	case msg.Err == nil && wasDown:
+		log.Info("provider reachable again", "provider", msg.Provider.Name())
+	}
+	if msg.Err != nil || !m.pending || m.streaming {
+		return nil
+	}
+	m.pending = false
+	m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("%s is back; sending your message.", msg.Provider.Name())})
+	return m.streamReply()
+}
+
+// providerDown reports whether the last check found the provider
+// unreachable.
+func (m *Model) providerDown() bool {
+	return m.healthChecked && m.healthErr != nil
+}
+
+// queueMessage keeps a message typed while the provider is unreachable in
+// the conversation, to be answered once it's back, and checks again right
+// away.
+func (m *Model) queueMessage(input string) tea.Cmd {
+	m.addUserMessage(input)
+	m.pending = true
+	m.messages = append(m.messages, displayMessage{
+		role:    "system",
+		content: m.tr.Sprintf("%s isn't reachable; your message is queued and goes out once it's back.", m.options.Provider.Name()),
+	})
+	m.updateViewport()
+	return m.checkHealth()
+}
+
+// unreachable queues the turn whose reply couldn't be started because the
+// provider can't be reached, until a health check finds it back. It reports
+// whether err was of that kind.
+func (m *Model) unreachable(err error) bool {
+	var se *provider.StatusError
+	if m.streamContent != "" || m.heartbeatStream || m.bootstrapStream || errors.As(err, &se) || !retry.Transient(err) {
+		return false
+	}
+	if len(m.messages) == 0 || m.messages[len(m.messages)-1].role != "user" {
+		return false
+	}
+	m.healthChecked, m.healthErr = true, err
+	m.pending = true
+	m.messages = append(m.messages, displayMessage{
+		role:    "system",
+		content: m.tr.Sprintf("%s isn't reachable; your message is queued and goes out once it's back.", m.options.Provider.Name()),
+	})
+	return true
+}
+
+// healthIndicator shows in the status bar whether the provider is
+// reachable; it is empty before the first check.
+func (m *Model) healthIndicator() string {
+	switch {
+	case !m.healthChecked:
+		return ""
+	case m.healthErr != nil:
+		return " " + lipgloss.NewStyle().Foreground(errorColor).Background(primaryColor).Render("● "+m.tr.T("unreachable"))
+	default:
+		return " " + lipgloss.NewStyle().Foreground(successColor).Background(primaryColor).Render("●")
+	}
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestHealth_QueuesWhileUnreachable(t *testing.T) {
+	down := errors.New("connection refused")
+	mp := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{providertest.Text("Hi!")}}
+	m := newAgentModel(t, mp)
+
+	if m.healthIndicator() != "" {
+		t.Error("the status bar should show no health before the first check")
+	}
+	m.handleHealth(HealthMsg{Provider: mp, Err: down})
+	if !strings.Contains(m.healthIndicator(), "unreachable") {
+		t.Errorf("indicator = %q, want it to say unreachable", m.healthIndicator())
+	}
+
+	m.textarea.SetValue("Hello?")
+	m.handleSubmit()
+	m.textarea.SetValue("Anyone there?")
+	m.handleSubmit()
+	if len(mp.Requests()) != 0 || !m.pending || m.streaming {
+		t.Fatalf("messages should be queued, requests=%d pending=%t", len(mp.Requests()), m.pending)
+	}
+
+	runTurn(&m, m.handleHealth(HealthMsg{Provider: mp}))
+	reqs := mp.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests once the provider was back, want 1", len(reqs))
+	}
+	msgs := reqs[0].Messages
+	if n := len(msgs); msgs[n-2].Content != "Hello?" || msgs[n-1].Content != "Anyone there?" {
+		t.Errorf("request ends with %+v, want both queued messages", msgs[n-2:])
+	}
+	if got := lastMessage(&m).content; got != "Hi!" || m.pending {
+		t.Errorf("last message = %q, pending = %t", got, m.pending)
+	}
+}
+
+func TestHealth_RefusedReplyIsQueued(t *testing.T) {
+	refused := fmt.Errorf("ollama chat: %w", syscall.ECONNREFUSED)
+	mp := &providertest.Fake{ProviderName: "ollama", Replies: []providertest.Reply{{Err: refused}, providertest.Text("Hi!")}}
+	m := newAgentModel(t, mp)
+
+	runTurn(&m, m.sendMessage("Hello?"))
+	if !m.pending || !m.providerDown() {
+		t.Fatalf("pending = %t, down = %t after a refused connection", m.pending, m.providerDown())
+	}
+	if got := lastMessage(&m); got.role != "system" || !strings.Contains(got.content, "queued") {
+		t.Errorf("last message = %+v, want the queued notice", got)
+	}
+
+	runTurn(&m, m.handleHealth(HealthMsg{Provider: mp}))
+	if got := lastMessage(&m).content; got != "Hi!" {
+		t.Errorf("last message = %q, want the reply once the provider was back", got)
+	}
+}
+
+func TestHealth_IgnoresCheckOfPreviousProvider(t *testing.T) {
+	old := &providertest.Fake{ProviderName: "ollama"}
+	m := newAgentModel(t, &providertest.Fake{ProviderName: "openrouter"})
+
+	m.handleHealth(HealthMsg{Provider: old, Err: errors.New("connection refused")})
+	if m.healthChecked {
+		t.Error("a check of the provider switched away from should be ignored")
+	}
+}
@@ -0,0 +1,367 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// onBattery reports whether the machine runs on battery; tests replace it.
+var onBattery = heartbeat.OnBattery
+
+// heartbeatTasks returns the tasks listed in HEARTBEAT.md.
+func (m *Model) heartbeatTasks() []heartbeat.Task {
+	if m.options.PromptAsm == nil {
+		return nil
+	}
+	return heartbeat.Parse(m.options.PromptAsm.Section(prompt.SectionHeartbeat))
+}
+
+// heartbeatQuiet returns why a check-in shouldn't run now: snoozed, outside
+// activeHours, right after the user typed, or on battery. It is empty if the
+// check-in may run.
+func (m *Model) heartbeatQuiet(activeHours string) string {
+	cfg := m.options.Heartbeat
+	t := m.now()
+	if t.Before(m.heartbeatSnoozed) {
+		return "snoozed until " + formatDue(m.heartbeatSnoozed, t)
+	}
+	if hours, err := heartbeat.ParseHours(activeHours); err == nil && !hours.Contains(t) {
+		return "outside active hours " + hours.String()
+	}
+	if d, err := time.ParseDuration(cfg.SkipIfActive); err == nil && !m.lastActivity.IsZero() && t.Sub(m.lastActivity) < d {
+		return "you were active in the last " + formatInterval(d)
+	}
+	if cfg.SkipOnBattery && onBattery() {
+		return "on battery"
+	}
+	return ""
+}
+
+// heartbeatQuietRules describes the settings that hold check-ins back, for
+// /heartbeat.
+func (m *Model) heartbeatQuietRules() string {
+	cfg := m.options.Heartbeat
+	var rules []string
+	if hours, err := heartbeat.ParseHours(cfg.ActiveHours); err == nil && cfg.ActiveHours != "" {
+		rules = append(rules, "active hours "+hours.String())
+	}
+	if d, err := time.ParseDuration(cfg.SkipIfActive); err == nil && d > 0 {
+		rules = append(rules, "skipped within "+formatInterval(d)+" of typing")
+	}
+	if cfg.SkipOnBattery {
+		rules = append(rules, "skipped on battery")
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "\nQuiet: " + strings.Join(rules, ", ")
+}
+
+// heartbeatToolStatus names the tools check-ins may use, for /heartbeat.
+func (m *Model) heartbeatToolStatus() string {
+	if m.tools == nil || m.tools.Len() == 0 {
+		return ""
+	}
+	var names []string
+	for _, t := range m.heartbeatTools().List() {
+		names = append(names, t.Name)
+	}
+	if len(names) == 0 {
+		return "\nTools: none"
+	}
+	return "\nTools: " + strings.Join(names, ", ")
+}
+
+// dueHeartbeatTasks picks the HEARTBEAT.md tasks for the next check-in. skip
+// is true when tasks are defined but none is due, so there is nothing to ask
+// the model. Without tasks, or if their state can't be read, the check-in
+// uses the generic prompt.
+func (m *Model) dueHeartbeatTasks() (due []heartbeat.Task, skip bool) {
+	tasks := m.heartbeatTasks()
+	if len(tasks) == 0 || m.options.HeartbeatStore == nil {
+		return nil, false
+	}
+	due, err := m.options.HeartbeatStore.Due(tasks, m.now())
+	if err != nil {
+		return nil, false
+	}
+	return due, len(due) == 0
+}
+
+// heartbeatTasksDone records that the tasks of the finished check-in ran,
+// whether or not the model had something to say.
+func (m *Model) heartbeatTasksDone() {
+	due := m.heartbeatDue
+	m.heartbeatDue = nil
+	if len(due) == 0 || m.options.HeartbeatStore == nil {
+		return
+	}
+	if err := m.options.HeartbeatStore.Done(due, m.heartbeatTasks(), m.now()); err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Saving heartbeat tasks: %v", err)})
+	}
+}
+
+// heartbeatTaskStatus lists the HEARTBEAT.md tasks and when each is due
+// next, for /heartbeat.
+func (m *Model) heartbeatTaskStatus() string {
+	tasks := m.heartbeatTasks()
+	if len(tasks) == 0 {
+		return ""
+	}
+	var last map[string]time.Time
+	if m.options.HeartbeatStore != nil {
+		last, _ = m.options.HeartbeatStore.LastRuns()
+	}
+	var b strings.Builder
+	b.WriteString("\nTasks:")
+	for _, t := range tasks {
+		state := "due"
+		switch next := t.Next(last[t.Text]); {
+		case t.Paused:
+			state = "paused"
+		case next.After(m.now()):
+			state = "next " + formatDue(next, m.now())
+		}
+		fmt.Fprintf(&b, "\n  %s: %s (%s)", t.Label, t.Text, state)
+	}
+	return b.String()
+}
+
+// heartbeatConversationTokens bounds the conversation excerpt sent with a
+// check-in.
+const heartbeatConversationTokens = 1500
+
+// heartbeatContext returns what a check-in needs beyond the system prompt:
+// MEMORY.md as it is now, within memory.max_prompt_tokens, an excerpt of the
+// active session and the check-in messages the user acknowledged or snoozed.
+func (m *Model) heartbeatContext() string {
+	var parts []string
+	if m.options.MemoryStore != nil && m.options.Memory.MaxPromptTokens > 0 {
+		if mem, err := m.options.MemoryStore.ForPrompt(m.options.Memory.MaxPromptTokens); err == nil && mem != "" {
+			parts = append(parts, strings.TrimSpace(mem))
+		}
+	}
+	if conv := m.conversationExcerpt(heartbeatConversationTokens); conv != "" {
+		parts = append(parts, "# Conversation so far\n\n"+conv)
+	}
+	if m.options.HeartbeatFeedback != nil {
+		if fb, err := m.options.HeartbeatFeedback.Current(m.now()); err == nil {
+			if section := heartbeat.FeedbackSection(fb); section != "" {
+				parts = append(parts, section)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// conversationExcerpt condenses the session for a check-in: the summary of
+// compacted turns, if any, followed by as many of the latest turns as fit in
+// maxTokens. Tool calls and results are left out.
+func (m *Model) conversationExcerpt(maxTokens int) string {
+	var summary string
+	var turns []string
+	budget := maxTokens
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		dm := m.messages[i]
+		switch dm.role {
+		case "summary":
+			summary = "Earlier: " + strings.TrimSpace(dm.content)
+		case "user", "assistant":
+			if budget <= 0 {
+				continue
+			}
+			line := strings.ToUpper(dm.role[:1]) + dm.role[1:] + ": " + strings.TrimSpace(dm.content)
+			n := tokens.Count(line)
+			if n > budget {
+				line = tokens.Truncate(line, budget) + "…"
+				n = budget
+			}
+			budget -= n
+			turns = append(turns, line)
+		}
+		if summary != "" {
+			break
+		}
+	}
+	slices.Reverse(turns)
+	if summary != "" {
+		turns = append([]string{summary}, turns...)
+	}
+	return strings.Join(turns, "\n")
+}
+
+// heartbeatReplyWindow is how soon after a check-in a message from the user
+// counts as a reply to it.
+const heartbeatReplyWindow = 30 * time.Minute
+
+// recordHeartbeat counts what came of the named check-in, or the idle one if
+// name is empty, for /heartbeat stats. With heartbeat.auto_tune, idle
+// check-ins that keep having nothing to say lengthen the interval.
+func (m *Model) recordHeartbeat(name, outcome string) {
+	if name == "" {
+		name = heartbeat.Idle
+	}
+	if outcome == heartbeat.OutcomeProduced {
+		m.heartbeatReply = name
+		m.heartbeatReplyBy = m.now().Add(heartbeatReplyWindow)
+	}
+	if m.options.HeartbeatStats == nil {
+		return
+	}
+	c, err := m.options.HeartbeatStats.Record(name, outcome, m.now())
+	if err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Saving heartbeat stats: %v", err)})
+		return
+	}
+	if name != heartbeat.Idle || !m.options.Heartbeat.AutoTune {
+		return
+	}
+	if d, ok := heartbeat.Tune(m.heartbeatInterval, c); ok {
+		m.heartbeatInterval = d
+		m.options.HeartbeatStats.ResetStreak(name)
+		m.messages = append(m.messages, displayMessage{
+			role: "system",
+			content: m.tr.Sprintf("The last %d check-ins had nothing to say, so heartbeats now run every %s.",
+				heartbeat.TuneSkips, formatInterval(d)) + m.heartbeatChanged(),
+		})
+	}
+}
+
+// heartbeatReplied counts a message sent soon after a check-in as a reply
+// to it.
+func (m *Model) heartbeatReplied() {
+	name := m.heartbeatReply
+	m.heartbeatReply = ""
+	if name == "" || m.now().After(m.heartbeatReplyBy) || m.options.HeartbeatStats == nil {
+		return
+	}
+	if _, err := m.options.HeartbeatStats.Record(name, heartbeat.OutcomeReplied, m.now()); err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Saving heartbeat stats: %v", err)})
+	}
+}
+
+// handleHeartbeatStats handles /heartbeat stats [reset].
+func handleHeartbeatStats(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	if m.options.HeartbeatStats == nil {
+		return reply(m.tr.T("Heartbeat stats are not available."))
+	}
+	switch args {
+	case "":
+	case "reset":
+		if err := m.options.HeartbeatStats.Reset(); err != nil {
+			return reply(m.tr.Sprintf("Resetting heartbeat stats: %v", err))
+		}
+		return reply(m.tr.T("Heartbeat stats reset."))
+	default:
+		return reply(m.tr.T("Usage: /heartbeat stats [reset]"))
+	}
+
+	stats, err := m.options.HeartbeatStats.Stats()
+	if err != nil {
+		return reply(m.tr.Sprintf("Reading heartbeat stats: %v", err))
+	}
+	if len(stats) == 0 {
+		return reply(m.tr.T("No heartbeat check-ins recorded yet."))
+	}
+	names := slices.Sorted(maps.Keys(stats))
+	if i := slices.Index(names, heartbeat.Idle); i > 0 {
+		names = slices.Concat([]string{heartbeat.Idle}, names[:i], names[i+1:])
+	}
+	lines := []string{m.tr.T("Heartbeat stats:")}
+	for _, name := range names {
+		c := stats[name]
+		lines = append(lines, fmt.Sprintf("  %-12s %s (since %s)", name, c, c.Since.Format("Jan 2")))
+	}
+	idle := stats[heartbeat.Idle]
+	switch {
+	case m.options.Heartbeat.AutoTune:
+		lines = append(lines, "", m.tr.Sprintf("Auto-tune is on: %d skipped check-ins in a row double the interval, up to %s.",
+			heartbeat.TuneSkips, formatInterval(heartbeat.MaxTunedInterval)))
+	case idle.Total() >= 4 && idle.Skipped*4 >= idle.Total()*3:
+		lines = append(lines, "", m.tr.T("Most check-ins had nothing to say. Try a longer interval, or heartbeat.auto_tune: true in config.yaml."))
+	}
+	lines = append(lines, m.tr.T("Reset with /heartbeat stats reset."))
+	return reply(strings.Join(lines, "\n"))
+}
+
+// snoozeHint follows a check-in message to offer the ways to answer it.
+const snoozeHint = "Reply, /ack to let it rest, or /snooze 2h to hear about it later."
+
+// handleAck handles /ack: later check-ins are told that the user has seen
+// the last check-in message and don't bring it up again.
+func handleAck(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	if m.options.HeartbeatFeedback == nil {
+		return reply(m.tr.T("Heartbeat feedback is not available."))
+	}
+	if m.heartbeatLast == "" {
+		return reply(m.tr.T("There is no check-in message to acknowledge."))
+	}
+	if err := m.options.HeartbeatFeedback.Add(heartbeat.Feedback{Message: m.heartbeatLast, At: m.now()}, m.now()); err != nil {
+		return reply(m.tr.Sprintf("Saving heartbeat feedback: %v", err))
+	}
+	m.heartbeatLast = ""
+	return reply(m.tr.T("Acknowledged; check-ins won't bring it up again."))
+}
+
+// handleSnooze handles /snooze [<duration>|off]: no check-ins run until the
+// snooze ends, and the next ones are told the last check-in message was
+// snoozed rather than answered.
+func handleSnooze(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	store := m.options.HeartbeatFeedback
+	if store == nil {
+		return reply(m.tr.T("Heartbeat feedback is not available."))
+	}
+	t := m.now()
+	switch args {
+	case "":
+		if t.Before(m.heartbeatSnoozed) {
+			return reply(m.tr.Sprintf("Check-ins are snoozed until %s. End the snooze with /snooze off.", formatDue(m.heartbeatSnoozed, t)))
+		}
+		return reply(m.tr.T("Usage: /snooze <duration>|off, e.g. /snooze 2h"))
+	case "off":
+		if !t.Before(m.heartbeatSnoozed) {
+			return reply(m.tr.T("Check-ins aren't snoozed."))
+		}
+		if err := store.Wake(t); err != nil {
+			return reply(m.tr.Sprintf("Saving heartbeat feedback: %v", err))
+		}
+		m.heartbeatSnoozed = time.Time{}
+		return reply(m.tr.T("Snooze ended; check-ins run again."))
+	}
+
+	d, err := time.ParseDuration(args)
+	if err != nil || d <= 0 {
+		return reply(m.tr.Sprintf("Invalid duration %q. Usage: /snooze <duration>|off, e.g. /snooze 2h", args))
+	}
+	until := t.Add(d)
+	if err := store.Add(heartbeat.Feedback{Message: m.heartbeatLast, At: t, Until: until}, t); err != nil {
+		return reply(m.tr.Sprintf("Saving heartbeat feedback: %v", err))
+	}
+	m.heartbeatLast = ""
+	m.heartbeatSnoozed = until
+	return reply(m.tr.Sprintf("Snoozed check-ins until %s.", formatDue(until, t)))
+}
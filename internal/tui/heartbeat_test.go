@@ -0,0 +1,510 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/daemon"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestHeartbeat_OnlyDueTasks(t *testing.T) {
+	fixed := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	dir := t.TempDir()
+	tasks := "# Heartbeat\n\n- Keep it short\n- daily: ask how I slept\n- every 4h: remind me to drink water\n"
+	if err := os.WriteFile(filepath.Join(dir, prompt.SectionHeartbeat), []byte(tasks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	asm := prompt.NewAssembler(dir)
+	asm.LoadFiles()
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Now:            func() time.Time { return fixed },
+		Provider:       mp,
+		Model:          "test-model",
+		PromptAsm:      asm,
+		HeartbeatStore: heartbeat.NewStore(filepath.Join(dir, "heartbeat.json")),
+		Heartbeat:      config.HeartbeatConfig{Enabled: true, Interval: "1h"},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// tick runs a heartbeat and returns the check-in request, if any
+	tick := func() string {
+		mp.Reset()
+		newM, cmd := m.Update(HeartbeatTickMsg{})
+		m = newM.(Model)
+		if !m.streaming {
+			return ""
+		}
+		collectMsgs(cmd)
+		m.streamContent = "HEARTBEAT_SKIP"
+		newM, _ = m.Update(StreamDoneMsg{})
+		m = newM.(Model)
+		return mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	}
+
+	first := tick()
+	if !strings.Contains(first, "- ask how I slept\n- remind me to drink water") {
+		t.Errorf("first check-in should ask about both tasks:\n%s", first)
+	}
+	if strings.Contains(asm.BuildSystemPrompt(), "slept") {
+		t.Error("tasks should not be part of the system prompt")
+	}
+
+	fixed = fixed.Add(time.Hour)
+	if req := tick(); req != "" {
+		t.Errorf("nothing is due after an hour, but the model was asked:\n%s", req)
+	}
+
+	fixed = fixed.Add(3 * time.Hour)
+	if req := tick(); !strings.Contains(req, "drink water") || strings.Contains(req, "slept") {
+		t.Errorf("after 4h only the water task is due:\n%s", req)
+	}
+
+	handleHeartbeat(&m, "")
+	status := lastMessage(&m).content
+	if !strings.Contains(status, "daily: ask how I slept (next Wed Mar 11 09:00)") || !strings.Contains(status, "every 4h: remind me to drink water (next 17:00)") {
+		t.Errorf("/heartbeat should list the tasks:\n%s", status)
+	}
+}
+
+func TestHeartbeat_GenericWithoutTasks(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{Provider: mp, Model: "test-model", Language: "Deutsch"})
+
+	collectMsgs(m.triggerHeartbeat())
+	req := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.HasPrefix(req, "[Heartbeat check-in] Review") || !strings.HasSuffix(req, "Respond in Deutsch.") {
+		t.Errorf("unexpected check-in prompt: %q", req)
+	}
+}
+
+func TestHeartbeat_IncludesMemoryAndConversation(t *testing.T) {
+	dir := t.TempDir()
+	store := memory.NewStore(filepath.Join(dir, "MEMORY.md"))
+	if err := store.Append([]string{"Has a dentist appointment on Friday"}); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Provider:     mp,
+		Model:        "test-model",
+		SystemPrompt: "You are stefanclaw.",
+		MemoryStore:  store,
+		Memory:       config.MemoryConfig{Enabled: true, MaxPromptTokens: 500},
+	})
+	m.messages = []displayMessage{
+		{role: "summary", content: "They planned a trip to Lisbon."},
+		{role: "user", content: strings.Repeat("old ", 2000)},
+		{role: "tool", content: "[tool result] secret"},
+		{role: "user", content: "Which flight should I book?"},
+		{role: "assistant", content: "The morning one."},
+	}
+
+	collectMsgs(m.triggerHeartbeat())
+	sys := mp.LastRequest().Messages[0].Content
+	for _, want := range []string{"You are stefanclaw.", "Has a dentist appointment on Friday", "Earlier: They planned a trip to Lisbon.", "User: Which flight should I book?\nAssistant: The morning one."} {
+		if !strings.Contains(sys, want) {
+			t.Errorf("check-in context misses %q:\n%s", want, sys)
+		}
+	}
+	if strings.Contains(sys, "secret") {
+		t.Error("tool results should be left out of the check-in context")
+	}
+	if len(sys) > 8000 {
+		t.Errorf("conversation excerpt not budgeted: %d chars", len(sys))
+	}
+}
+
+func TestHeartbeat_QuietRules(t *testing.T) {
+	fixed := time.Date(2026, 3, 10, 23, 0, 0, 0, time.Local)
+	battery := false
+	onBattery = func() bool { return battery }
+	t.Cleanup(func() { onBattery = heartbeat.OnBattery })
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Now:      func() time.Time { return fixed },
+		Provider: mp,
+		Model:    "test-model",
+		Heartbeat: config.HeartbeatConfig{
+			Enabled:       true,
+			Interval:      "1h",
+			ActiveHours:   "08:00-22:00",
+			SkipIfActive:  "15m",
+			SkipOnBattery: true,
+		},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	tick := func() bool {
+		newM, cmd := m.Update(HeartbeatTickMsg{})
+		m = newM.(Model)
+		if cmd == nil {
+			t.Fatal("a held-back heartbeat should be rescheduled")
+		}
+		return m.streaming
+	}
+
+	if tick() {
+		t.Error("no heartbeat at 23:00 with active hours 08:00–22:00")
+	}
+
+	fixed = time.Date(2026, 3, 11, 9, 0, 0, 0, time.Local)
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = newM.(Model)
+	if tick() {
+		t.Error("no heartbeat right after typing")
+	}
+
+	fixed = fixed.Add(20 * time.Minute)
+	battery = true
+	if tick() {
+		t.Error("no heartbeat on battery")
+	}
+
+	handleHeartbeat(&m, "")
+	if status := lastMessage(&m).content; !strings.Contains(status, "Quiet: active hours 08:00–22:00, skipped within 15m of typing, skipped on battery") {
+		t.Errorf("/heartbeat should show the quiet rules:\n%s", status)
+	}
+
+	battery = false
+	if !tick() {
+		t.Error("heartbeat should run in active hours, idle and on mains")
+	}
+}
+
+func TestHeartbeat_Schedules(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+	fixed := time.Date(2026, 3, 10, 7, 50, 0, 0, time.Local)
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Now:       func() time.Time { return fixed },
+		Provider:  mp,
+		Model:     "test-model",
+		Heartbeat: config.HeartbeatConfig{Enabled: true, Interval: "4h"},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	submit := func(input string) string {
+		m.textarea.SetValue(input)
+		m.handleSubmit()
+		return lastMessage(&m).content
+	}
+
+	if got := submit("/heartbeat add morning 0 8 * * *: It's {{.weekday}}. Brief me on my day."); !strings.Contains(got, "Added heartbeat schedule morning, next") {
+		t.Fatalf("add: %q", got)
+	}
+	if got := submit("/heartbeat add nudge 1h 10:00-17:00: Nudge me to stretch."); !strings.Contains(got, "Added heartbeat schedule nudge") {
+		t.Fatalf("add with hours: %q", got)
+	}
+	if got := submit("/heartbeat add nudge 2h: Again"); !strings.Contains(got, "already exists") {
+		t.Errorf("duplicate: %q", got)
+	}
+	if got := submit("/heartbeat add broken soon: Hi"); !strings.Contains(got, "Invalid schedule") {
+		t.Errorf("bad schedule: %q", got)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Heartbeat.Schedules) != 2 || cfg.Heartbeat.Schedules[1].ActiveHours != "10:00-17:00" || cfg.Heartbeat.Schedules[0].Schedule != "0 8 * * *" {
+		t.Errorf("saved schedules = %+v", cfg.Heartbeat.Schedules)
+	}
+	if got := submit("/heartbeat list"); !strings.Contains(got, "morning") || !strings.Contains(got, "1h 10:00-17:00") {
+		t.Errorf("list: %q", got)
+	}
+
+	// At 08:50 the briefing is due; the nudge is outside its hours
+	fixed = fixed.Add(time.Hour)
+	collectMsgs(m.checkSchedules())
+	if !m.streaming {
+		t.Fatal("the morning check-in should have started")
+	}
+	req := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.HasPrefix(req, "[Heartbeat check-in: morning] It's Tuesday. Brief me on my day.") {
+		t.Errorf("check-in prompt = %q", req)
+	}
+	m.streaming = false
+	m.heartbeatStream = false
+	if cmd := m.checkSchedules(); cmd != nil {
+		t.Error("nothing else should be due")
+	}
+
+	if got := submit("/heartbeat remove morning"); !strings.Contains(got, "Removed heartbeat schedule morning") {
+		t.Errorf("remove: %q", got)
+	}
+	if cfg, _ := config.Load(); len(cfg.Heartbeat.Schedules) != 1 || cfg.Heartbeat.Schedules[0].Name != "nudge" {
+		t.Errorf("saved schedules after remove = %+v", cfg.Heartbeat.Schedules)
+	}
+}
+
+func TestHeartbeat_StatsAndAutoTune(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+	fixed := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+
+	stats := heartbeat.NewStatsStore(filepath.Join(t.TempDir(), "heartbeat-stats.json"))
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Now:            func() time.Time { return fixed },
+		Provider:       mp,
+		Model:          "test-model",
+		HeartbeatStats: stats,
+		Heartbeat:      config.HeartbeatConfig{Enabled: true, Interval: "4h", AutoTune: true},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// checkIn finishes a check-in with the given answer
+	checkIn := func(answer string) {
+		ch := make(chan provider.StreamDelta)
+		close(ch)
+		mp.Replies = []providertest.Reply{{Stream: ch}}
+		m.triggerHeartbeat()
+		m.streamContent = answer
+		newM, _ := m.Update(StreamDoneMsg{})
+		m = newM.(Model)
+	}
+
+	checkIn("Did you sleep well?")
+	fixed = fixed.Add(10 * time.Minute)
+	m.textarea.SetValue("Not really.")
+	m.handleSubmit()
+	m.streaming = false
+	for range 3 {
+		checkIn(heartbeat.Skip)
+	}
+
+	if m.heartbeatInterval != 8*time.Hour {
+		t.Errorf("interval = %s, want 8h after three skips in a row", m.heartbeatInterval)
+	}
+	if cfg, _ := config.Load(); cfg.Heartbeat.Interval != "8h" {
+		t.Errorf("saved interval = %q, want 8h", cfg.Heartbeat.Interval)
+	}
+
+	m.textarea.SetValue("/heartbeat stats")
+	m.handleSubmit()
+	got := lastMessage(&m).content
+	if !strings.Contains(got, "idle         4 check-ins: 3 skipped (75%), 1 with a message, 1 replied to (since Mar 10)") ||
+		!strings.Contains(got, "Auto-tune is on") {
+		t.Errorf("/heartbeat stats = %q", got)
+	}
+
+	m.textarea.SetValue("/heartbeat stats reset")
+	m.handleSubmit()
+	m.textarea.SetValue("/heartbeat stats")
+	m.handleSubmit()
+	if got := lastMessage(&m).content; got != "No heartbeat check-ins recorded yet." {
+		t.Errorf("after reset: %q", got)
+	}
+}
+
+func TestInbox_ShownAtLaunch(t *testing.T) {
+	fixed := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+	inbox := daemon.NewInbox(filepath.Join(t.TempDir(), "inbox.jsonl"))
+	inbox.Add(daemon.Entry{Time: fixed.Add(-3 * time.Hour), Kind: "heartbeat", Title: "stefanclaw check-in", Text: "How did you sleep?"})
+	inbox.Add(daemon.Entry{Time: fixed.Add(-time.Hour), Kind: "job", Title: "Job digest", Text: "Three headlines."})
+
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Inbox: inbox, Now: func() time.Time { return fixed }})
+	got := lastMessage(&m).content
+	want := "While you were away (2 updates, kept in the Background session):\n\nstefanclaw check-in · 09:00\nHow did you sleep?\n\nJob digest · 11:00\nThree headlines."
+	if got != want {
+		t.Errorf("launch message = %q, want %q", got, want)
+	}
+
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Inbox: inbox, Now: func() time.Time { return fixed }})
+	if len(m.messages) != 0 {
+		t.Errorf("the inbox should be shown once, got %+v", m.messages)
+	}
+}
+
+func TestHeartbeat_ListedTools(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	store := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	store.Append([]string{"User drinks green tea"})
+	m := New(Options{
+		Provider:    mp,
+		Model:       "test-model",
+		MemoryStore: store,
+		Heartbeat:   config.HeartbeatConfig{Enabled: true, Interval: "1h", Tools: []string{"memory_search"}},
+		Agent:       config.AgentConfig{Enabled: true, MaxSteps: 3},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	m.messages = append(m.messages, displayMessage{role: "user", content: "What's the weather?"})
+
+	if sys := m.promptWithTools(m.heartbeatTools()); !strings.Contains(sys, "memory_search") || strings.Contains(sys, "date_calc") {
+		t.Errorf("heartbeat tools should be memory_search only:\n%s", sys)
+	}
+	if status := m.heartbeatToolStatus(); status != "\nTools: memory_search" {
+		t.Errorf("tool status = %q", status)
+	}
+
+	// step finishes the running stream with answer and runs any tool call
+	step := func(answer string) ToolResultMsg {
+		m.streamContent = answer
+		newM, cmd := m.Update(StreamDoneMsg{})
+		m = newM.(Model)
+		var result ToolResultMsg
+		for _, msg := range collectMsgs(cmd) {
+			if r, ok := msg.(ToolResultMsg); ok {
+				result = r
+			}
+		}
+		return result
+	}
+
+	collectMsgs(m.triggerHeartbeat())
+	result := step(`<tool_call>{"name": "memory_search", "arguments": {"keyword": "tea"}}</tool_call>`)
+	if result.Err != nil || !strings.Contains(result.Output, "green tea") {
+		t.Fatalf("tool result = %+v, want memory match", result)
+	}
+	newM, cmd := m.Update(result)
+	m = newM.(Model)
+	collectMsgs(cmd)
+
+	// The follow-up stays within the check-in rather than the conversation
+	msgs := mp.LastRequest().Messages
+	if len(msgs) != 4 || !strings.HasPrefix(msgs[1].Content, "[Heartbeat check-in]") ||
+		msgs[2].Role != "assistant" || !strings.Contains(msgs[3].Content, "<tool_result") {
+		t.Fatalf("follow-up request = %+v, want the check-in and its tool step", msgs)
+	}
+	if !m.heartbeatStream {
+		t.Error("follow-up should still count as a heartbeat")
+	}
+
+	// Tools outside the list are refused
+	result = step(`<tool_call>{"name": "date_calc", "arguments": {"expression": "today + 1d"}}</tool_call>`)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "not available during heartbeat check-ins") {
+		t.Errorf("unlisted tool result = %+v, want a refusal", result)
+	}
+	newM, cmd = m.Update(result)
+	m = newM.(Model)
+	collectMsgs(cmd)
+
+	m.streamContent = heartbeat.Skip
+	newM, _ = m.Update(StreamDoneMsg{}) // schedules the next check-in
+	m = newM.(Model)
+	if m.heartbeatStream || m.heartbeatTurn != nil {
+		t.Error("check-in should be over")
+	}
+	if last := lastMessage(&m); last.role == "assistant" {
+		t.Errorf("skipped check-in was shown: %q", last.content)
+	}
+}
+
+func TestHeartbeat_AckAndSnooze(t *testing.T) {
+	fixed := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+
+	feedback := heartbeat.NewFeedbackStore(filepath.Join(t.TempDir(), "heartbeat-feedback.json"))
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Now:               func() time.Time { return fixed },
+		Provider:          mp,
+		Model:             "test-model",
+		HeartbeatFeedback: feedback,
+		Heartbeat:         config.HeartbeatConfig{Enabled: true, Interval: "1h"},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// checkIn finishes a check-in with the given answer
+	checkIn := func(answer string) {
+		ch := make(chan provider.StreamDelta)
+		close(ch)
+		mp.Replies = []providertest.Reply{{Stream: ch}}
+		collectMsgs(m.triggerHeartbeat())
+		m.streamContent = answer
+		newM, _ := m.Update(StreamDoneMsg{})
+		m = newM.(Model)
+	}
+	command := func(input string) string {
+		cmd := ParseCommand(input)
+		newM, _ := m.handleCommand(cmd)
+		m = *newM.(*Model)
+		return lastMessage(&m).content
+	}
+
+	if got := command("/ack"); !strings.Contains(got, "no check-in message") {
+		t.Errorf("/ack without a check-in = %q", got)
+	}
+	checkIn("Drink some water.")
+	if got := lastMessage(&m).content; got != snoozeHint {
+		t.Errorf("check-in message should be followed by the hint, got %q", got)
+	}
+	if got := command("/ack"); !strings.HasPrefix(got, "Acknowledged") {
+		t.Errorf("/ack = %q", got)
+	}
+
+	checkIn("Time to stretch?")
+	if got := command("/snooze 2h"); got != "Snoozed check-ins until 11:00." {
+		t.Errorf("/snooze 2h = %q", got)
+	}
+	if got := m.heartbeatStatus(); got != "heartbeat snoozed until 11:00" {
+		t.Errorf("status = %q", got)
+	}
+	if got := m.heartbeatQuiet(""); got != "snoozed until 11:00" {
+		t.Errorf("heartbeatQuiet = %q", got)
+	}
+
+	// The snooze survives a restart
+	m2 := New(Options{Provider: mp, Model: "test-model", HeartbeatFeedback: feedback, Now: func() time.Time { return fixed }})
+	if !m2.heartbeatSnoozed.Equal(fixed.Add(2 * time.Hour)) {
+		t.Errorf("snooze after restart = %v", m2.heartbeatSnoozed)
+	}
+
+	// Once it is over, check-ins hear about both answers
+	fixed = fixed.Add(3 * time.Hour)
+	if m.heartbeatQuiet("") != "" {
+		t.Error("snooze should be over")
+	}
+	checkIn(heartbeat.Skip)
+	sys := mp.LastRequest().Messages[0].Content
+	for _, want := range []string{"# Your earlier check-ins", `"Drink some water.": the user acknowledged this at 09:00`, `"Time to stretch?": the user snoozed this until`} {
+		if !strings.Contains(sys, want) {
+			t.Errorf("check-in prompt lacks %q:\n%s", want, sys)
+		}
+	}
+
+	command("/snooze 1h")
+	if got := command("/snooze off"); !strings.HasPrefix(got, "Snooze ended") || m.heartbeatQuiet("") != "" {
+		t.Errorf("/snooze off = %q", got)
+	}
+	if got := command("/snooze soon"); !strings.HasPrefix(got, `Invalid duration "soon"`) {
+		t.Errorf("/snooze soon = %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestHome_HeartbeatSeesStates(t *testing.T) {
+	fixed := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]homeassistant.State{{
+			EntityID:    "binary_sensor.front_door",
+			State:       "on",
+			Attributes:  map[string]any{"friendly_name": "Front Door"},
+			LastChanged: fixed.Add(-time.Hour),
+		}})
+	}))
+	defer srv.Close()
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Now:       func() time.Time { return fixed },
+		Provider:  mp,
+		Model:     "test-model",
+		Heartbeat: config.HeartbeatConfig{Enabled: true, Interval: "1h", Tools: []string{"home_state", "home_control"}},
+		Agent:     config.AgentConfig{Enabled: true, MaxSteps: 2},
+		Home: homeassistant.New(config.HomeConfig{
+			URL:      srv.URL,
+			Token:    "t",
+			Entities: []string{"binary_sensor.*"},
+			Control:  []string{"light.kitchen"},
+		}, nil),
+	})
+
+	heartbeat := m.promptWithTools(m.heartbeatTools())
+	if !strings.Contains(heartbeat, "home_state") || strings.Contains(heartbeat, "home_control") {
+		t.Errorf("heartbeat tools should include home_state only:\n%s", heartbeat)
+	}
+	if !strings.Contains(m.systemPrompt(), "home_control") {
+		t.Error("chat tools should include home_control")
+	}
+
+	collectMsgs(m.triggerHeartbeat())
+	sys := mp.LastRequest().Messages[0].Content
+	if !strings.Contains(sys, "## Home") || !strings.HasSuffix(sys, "Front Door (binary_sensor.front_door): on for 1h") {
+		t.Errorf("heartbeat system prompt lacks the home state:\n%s", sys)
+	}
+}
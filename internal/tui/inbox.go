@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+// showInbox shows what `stefanclaw daemon` did while the TUI was closed.
+// The entries stay in the Background session. The last check-in among them
+// can be answered with /ack and /snooze.
+func (m *Model) showInbox() {
+	if m.options.Inbox == nil {
+		return
+	}
+	entries, err := m.options.Inbox.Take()
+	if err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Reading the daemon inbox: %v", err)})
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	var b strings.Builder
+	format := "While you were away (%d updates, kept in the Background session):"
+	if len(entries) == 1 {
+		format = "While you were away (%d update, kept in the Background session):"
+	}
+	b.WriteString(m.tr.Sprintf(format, len(entries)))
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n\n%s · %s\n%s", e.Title, formatDue(e.Time, m.now()), e.Text)
+		if e.Kind == notify.EventHeartbeat && m.options.HeartbeatFeedback != nil {
+			m.heartbeatLast = e.Text
+		}
+	}
+	if m.heartbeatLast != "" {
+		b.WriteString("\n\n" + m.tr.T(snoozeHint))
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: b.String()})
+}
@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/agent"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/jobs"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+)
+
+// JobTickMsg signals it is time to look for due jobs and heartbeat schedules.
+type JobTickMsg struct{}
+
+// JobDoneMsg carries the answer of a job that ran in the background.
+type JobDoneMsg struct {
+	Job    config.JobConfig
+	Answer string
+	Err    error
+}
+
+func (m *Model) scheduleJobCheck() tea.Cmd {
+	m.jobsTicking = true
+	return tea.Tick(m.options.CheckInterval, func(time.Time) tea.Msg {
+		return JobTickMsg{}
+	})
+}
+
+// planJobs computes the next run of each configured job. Jobs that were
+// already planned with the same settings keep their time.
+func (m *Model) planJobs(old []config.JobConfig) {
+	next := make(map[string]time.Time, len(m.options.Jobs))
+	for _, job := range m.options.Jobs {
+		if prev, ok := jobs.Find(old, job.Name); ok && reflect.DeepEqual(prev, job) {
+			if t, ok := m.jobNext[job.Name]; ok {
+				next[job.Name] = t
+				continue
+			}
+		}
+		next[job.Name] = jobs.Next(job, m.now())
+	}
+	m.jobNext = next
+}
+
+// checkJobs starts every job that is due. Jobs run in the background, next
+// to the conversation, and report back with a JobDoneMsg. A due heartbeat
+// schedule is started as well. Checking stops while neither is configured.
+func (m *Model) checkJobs() tea.Cmd {
+	if len(m.options.Jobs) == 0 && len(m.options.Heartbeat.Schedules) == 0 {
+		m.jobsTicking = false
+		return nil
+	}
+	cmds := []tea.Cmd{m.scheduleJobCheck()}
+	t := m.now()
+	for _, job := range m.options.Jobs {
+		due := m.jobNext[job.Name]
+		if due.IsZero() || t.Before(due) {
+			continue
+		}
+		m.jobNext[job.Name] = jobs.Next(job, t)
+		cmds = append(cmds, m.runJob(job))
+	}
+	cmds = append(cmds, m.checkSchedules())
+	return tea.Batch(cmds...)
+}
+
+// runJob runs job in the background with the tools that need no approval.
+func (m *Model) runJob(job config.JobConfig) tea.Cmd {
+	runner := &agent.Runner{
+		Provider:     m.options.Provider,
+		Model:        m.options.Model,
+		SystemPrompt: m.options.SystemPrompt,
+		Tools:        m.unattendedTools(),
+		NativeTools:  m.options.Agent.NativeTools,
+		MaxSteps:     m.options.Agent.MaxSteps,
+		Options:      m.samplingOptions(),
+		NumCtx:       m.currentNumCtx,
+	}
+	targets := jobs.Targets{Memory: m.options.MemoryStore, Mail: m.options.Mailer}
+	return func() tea.Msg {
+		answer, err := jobs.Run(queue.WithLabel(context.Background(), "job "+job.Name), runner, job)
+		if err == nil {
+			err = jobs.Deliver(job, answer, m.now(), targets)
+		}
+		return JobDoneMsg{Job: job, Answer: answer, Err: err}
+	}
+}
+
+// handleJobDone shows a job's answer, or where it was delivered, and passes
+// it on to the webhooks.
+func (m *Model) handleJobDone(msg JobDoneMsg) tea.Cmd {
+	title := m.tr.Sprintf("Job %s", msg.Job.Name)
+	var text string
+	switch {
+	case msg.Err != nil:
+		text = m.tr.Sprintf("Job %s failed: %v", msg.Job.Name, msg.Err)
+		m.messages = append(m.messages, displayMessage{role: "error", content: text})
+	case msg.Job.Output == "" || msg.Job.Output == jobs.OutputNotification:
+		text = msg.Answer
+		m.messages = append(m.messages,
+			displayMessage{role: "system", content: title + ":"},
+			displayMessage{role: "job", content: msg.Answer},
+		)
+	default:
+		text = jobs.Summary(msg.Job)
+		m.messages = append(m.messages, displayMessage{role: "system", content: text})
+	}
+	m.updateViewport()
+	return m.notify(notify.Event{Kind: notify.EventJob, Title: title, Message: text})
+}
+
+func handleJobs(m *Model, args string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(args)
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+
+	if len(fields) == 2 && fields[0] == "run" {
+		job, ok := jobs.Find(m.options.Jobs, fields[1])
+		if !ok {
+			return reply(m.tr.Sprintf("No job named %q. See /jobs.", fields[1]))
+		}
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Running job %s...", job.Name)})
+		m.updateViewport()
+		return m, m.runJob(job)
+	}
+	if len(fields) != 0 {
+		return reply(m.tr.T("Usage: /jobs [run <name>]"))
+	}
+
+	if len(m.options.Jobs) == 0 {
+		return reply(m.tr.T("No jobs configured. Add them under jobs: in config.yaml."))
+	}
+	lines := []string{"Jobs:"}
+	for _, job := range m.options.Jobs {
+		next := "never"
+		if t := m.jobNext[job.Name]; !t.IsZero() {
+			next = formatDue(t, m.now())
+		}
+		lines = append(lines, fmt.Sprintf("  %-16s %-12s next %-16s → %s", job.Name, job.Schedule, next, jobs.Describe(job)))
+	}
+	lines = append(lines, "", m.tr.T("Run one now with /jobs run <name>."))
+	return reply(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestJobs_RunWhenDue(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("Three things happened.")}}
+	path := filepath.Join(t.TempDir(), "digest.md")
+	clock := &testClock{t: time.Date(2026, 3, 10, 7, 59, 0, 0, time.Local)}
+	m := newTestModel(t, Options{Provider: mp, Now: clock.Now, Jobs: []config.JobConfig{
+		{Name: "digest", Schedule: "0 8 * * *", Prompt: "Summarize the news."},
+		{Name: "log", Schedule: "0 9 * * *", Prompt: "Write a log line.", Output: "file", Path: path},
+	}})
+	m.planJobs(nil)
+
+	// Nothing is due at 07:59
+	newM, cmd := m.Update(JobTickMsg{})
+	m = newM.(Model)
+	for _, msg := range collectMsgs(cmd) {
+		if _, ok := msg.(JobDoneMsg); ok {
+			t.Fatal("job ran before it was due")
+		}
+	}
+
+	clock.t = time.Date(2026, 3, 10, 8, 0, 30, 0, time.Local)
+	newM, cmd = m.Update(JobTickMsg{})
+	m = newM.(Model)
+	var done []JobDoneMsg
+	for _, msg := range collectMsgs(cmd) {
+		if d, ok := msg.(JobDoneMsg); ok {
+			done = append(done, d)
+		}
+	}
+	if len(done) != 1 || done[0].Job.Name != "digest" || done[0].Err != nil {
+		t.Fatalf("done = %+v, want the digest job only", done)
+	}
+	if want := time.Date(2026, 3, 11, 8, 0, 0, 0, time.Local); !m.jobNext["digest"].Equal(want) {
+		t.Errorf("next digest run = %v, want %v", m.jobNext["digest"], want)
+	}
+
+	newM, _ = m.Update(done[0])
+	m = newM.(Model)
+	if got := lastMessage(&m); got.role != "job" || got.content != "Three things happened." {
+		t.Errorf("last message = %+v, want the job's answer", got)
+	}
+
+	// A job writing to a file only reports where its answer went
+	_, cmd = handleJobs(&m, "run log")
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, _ = m.Update(msgs[0])
+	m = newM.(Model)
+	if got := lastMessage(&m).content; !strings.Contains(got, "Job log finished; output saved to file "+path) {
+		t.Errorf("last message = %q", got)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "Three things happened.") {
+		t.Errorf("file = %q, %v", data, err)
+	}
+}
+
+func TestJobs_List(t *testing.T) {
+	m := newTestModel(t, Options{Now: (&testClock{t: time.Date(2026, 3, 10, 7, 59, 0, 0, time.Local)}).Now, Jobs: []config.JobConfig{
+		{Name: "digest", Schedule: "0 8 * * *", Prompt: "Summarize the news.", Output: "memory"},
+	}})
+	m.planJobs(nil)
+
+	handleJobs(&m, "")
+	list := lastMessage(&m).content
+	if !strings.Contains(list, "digest") || !strings.Contains(list, "next 08:00") || !strings.Contains(list, "memory") {
+		t.Errorf("list = %q", list)
+	}
+
+	handleJobs(&m, "run nope")
+	if got := lastMessage(&m).content; got != `No job named "nope". See /jobs.` {
+		t.Errorf("unknown job = %q", got)
+	}
+}
+
+func TestJobs_ReloadKeepsPlannedRuns(t *testing.T) {
+	job := config.JobConfig{Name: "digest", Schedule: "0 8 * * *", Prompt: "Summarize the news."}
+	m := newTestModel(t, Options{Now: (&testClock{t: time.Date(2026, 3, 10, 7, 59, 0, 0, time.Local)}).Now, Jobs: []config.JobConfig{job}})
+	m.planJobs(nil)
+	planned := m.jobNext["digest"]
+
+	cfg := config.Defaults()
+	cfg.Jobs = []config.JobConfig{job, {Name: "weekly", Schedule: "@weekly", Prompt: "Review the week."}}
+	changes := m.applyConfig(cfg)
+	if len(changes) != 1 || changes[0] != "jobs: 2 configured" {
+		t.Errorf("changes = %v", changes)
+	}
+	if !m.jobNext["digest"].Equal(planned) {
+		t.Errorf("digest was replanned: %v", m.jobNext["digest"])
+	}
+	if m.jobNext["weekly"].IsZero() {
+		t.Error("new job was not planned")
+	}
+}
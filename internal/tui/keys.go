@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
+)
+
+// keyMap holds the configurable TUI key bindings.
+type keyMap struct {
+	Submit       key.Binding
+	Newline      key.Binding
+	Stop         key.Binding
+	Palette      key.Binding
+	Picker       key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	Yank         key.Binding
+	Voice        key.Binding
+}
+
+// newKeyMap builds key bindings from config, using the default keys for any
+// action that isn't configured.
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	bind := func(keys []string, defaults []string, help string) key.Binding {
+		if len(keys) == 0 {
+			keys = defaults
+		}
+		return key.NewBinding(
+			key.WithKeys(keys...),
+			key.WithHelp(strings.Join(keys, "/"), help),
+		)
+	}
+	return keyMap{
+		Submit:       bind(cfg.Submit, []string{"enter"}, "send message"),
+		Newline:      bind(cfg.Newline, []string{"alt+enter"}, "insert newline"),
+		Stop:         bind(cfg.Stop, []string{"ctrl+c"}, "stop response / quit"),
+		Palette:      bind(cfg.Palette, []string{"ctrl+p"}, "command palette"),
+		Picker:       bind(cfg.Picker, []string{"ctrl+o"}, "model picker"),
+		HalfPageUp:   bind(cfg.HalfPageUp, []string{"ctrl+u"}, "scroll half page up"),
+		HalfPageDown: bind(cfg.HalfPageDown, []string{"ctrl+d"}, "scroll half page down"),
+		Yank:         bind(cfg.Yank, []string{"ctrl+y"}, "copy last response"),
+		Voice:        bind(cfg.Voice, []string{"ctrl+r"}, "start/stop voice input"),
+	}
+}
+
+// helpText lists the key bindings for /help in the language of tr.
+func (k keyMap) helpText(tr *i18n.Translator) string {
+	var b strings.Builder
+	b.WriteString(tr.T("Keys:"))
+	for _, kb := range []key.Binding{k.Submit, k.Newline, k.Stop, k.Palette, k.Picker, k.HalfPageUp, k.HalfPageDown, k.Yank, k.Voice} {
+		h := kb.Help()
+		fmt.Fprintf(&b, "\n  %-38s %s", h.Key, tr.T(h.Desc))
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/knowledge"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// KnowledgeIndexedMsg reports the end of a /kb index run.
+type KnowledgeIndexedMsg struct {
+	Report knowledge.Report
+	Err    error
+}
+
+const kbUsage = "Usage: /kb [index|ask <question>|on|off]"
+
+// setKnowledge creates the knowledge base for the current config; it stays
+// nil without an index file.
+func (m *Model) setKnowledge() {
+	m.kb = nil
+	if m.options.KnowledgeIndex == "" {
+		return
+	}
+	emb, _ := m.options.Provider.(provider.Embedder)
+	m.kb = knowledge.New(m.options.Knowledge, m.options.KnowledgeIndex, emb)
+}
+
+func handleKB(m *Model, args string) (tea.Model, tea.Cmd) {
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+	if m.kb == nil {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("The knowledge base is not available.")})
+		m.updateViewport()
+		return m, nil
+	}
+
+	var content string
+	switch sub {
+	case "", "status":
+		content = m.kbStatus()
+	case "index":
+		if len(m.options.Knowledge.Dirs) == 0 {
+			content = knowledge.ErrNoDirs.Error() + " in config.yaml."
+			break
+		}
+		kb := m.kb
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Indexing %s with %s…", strings.Join(m.options.Knowledge.Dirs, ", "), m.options.Knowledge.EmbedModel),
+		})
+		m.updateViewport()
+		return m, func() tea.Msg {
+			r, err := kb.Index(context.Background(), nil, nil)
+			return KnowledgeIndexedMsg{Report: r, Err: err}
+		}
+	case "ask":
+		if rest == "" {
+			content = m.tr.T("Usage: /kb ask <question>")
+			break
+		}
+		if m.streaming {
+			content = m.tr.T("Still answering; ask again when the reply is done.")
+			break
+		}
+		m.kbAsk = true
+		return m, m.sendMessage(rest)
+	case "on", "off":
+		m.kbAuto = sub == "on"
+		content = m.tr.T("Automatic retrieval off; use /kb ask to answer from your notes.")
+		if m.kbAuto {
+			content = m.tr.T("Every message now searches your notes for passages that help answer it.")
+		}
+	default:
+		content = m.tr.T(kbUsage)
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// kbStatus describes the knowledge base for /kb.
+func (m *Model) kbStatus() string {
+	var b strings.Builder
+	cfg := m.options.Knowledge
+	if len(cfg.Dirs) == 0 {
+		b.WriteString(m.tr.T("No knowledge directories configured; add them under knowledge.dirs in config.yaml.") + "\n")
+	} else {
+		b.WriteString(m.tr.Sprintf("Directories: %s", strings.Join(cfg.Dirs, ", ")) + "\n")
+	}
+	st, err := m.kb.Stats()
+	switch {
+	case err != nil:
+		b.WriteString(m.tr.Sprintf("Index: %v", err) + "\n")
+	case st.Updated.IsZero():
+		b.WriteString(m.tr.T("Index: empty; build it with /kb index") + "\n")
+	default:
+		b.WriteString(m.tr.Sprintf("Index: %s, %s, updated %s", st.Size(), st.Model, st.Updated.Format("2006-01-02 15:04")) + "\n")
+		if st.Model != cfg.EmbedModel {
+			b.WriteString(m.tr.Sprintf("The index was made with %s; run /kb index to use %s", st.Model, cfg.EmbedModel) + "\n")
+		}
+	}
+	auto := "off"
+	if m.kbAuto {
+		auto = "on"
+	}
+	b.WriteString(m.tr.Sprintf("Automatic retrieval: %s", m.tr.T(auto)) + "\n" + m.tr.T(kbUsage))
+	return b.String()
+}
+
+// handleKnowledgeIndexed reports the end of indexing.
+func (m *Model) handleKnowledgeIndexed(msg KnowledgeIndexedMsg) {
+	if msg.Err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: fmt.Sprintf("/kb index failed: %v", msg.Err)})
+	} else {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Knowledge base updated: %s.", msg.Report.Summary()),
+		})
+	}
+	m.updateViewport()
+}
+
+// retrieveKnowledge adds the passages from the knowledge base that match
+// question. For /kb ask (required), failing to search is an error and the
+// model is told when nothing matched; otherwise question is returned as is.
+func retrieveKnowledge(ctx context.Context, kb *knowledge.Base, question string, required bool) (string, error) {
+	augmented, results, err := kb.Augment(ctx, question)
+	switch {
+	case !required:
+		return augmented, nil
+	case err != nil:
+		if errors.Is(err, knowledge.ErrNotIndexed) {
+			return "", err
+		}
+		return "", fmt.Errorf("searching the knowledge base: %w", err)
+	case len(results) == 0:
+		return question + "\n\n(No passages in my notes match this question; say so if you can't answer it without them.)", nil
+	}
+	return augmented, nil
+}
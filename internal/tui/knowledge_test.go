@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// embedProvider adds embeddings to the fake provider: texts about tomatoes point
+// one way, everything else another.
+type embedProvider struct {
+	*providertest.Fake
+}
+
+func (embedProvider) Embed(_ context.Context, _ string, texts []string) ([][]float32, error) {
+	var out [][]float32
+	for _, t := range texts {
+		if strings.Contains(strings.ToLower(t), "tomato") {
+			out = append(out, []float32{1, 0})
+		} else {
+			out = append(out, []float32{0, 1})
+		}
+	}
+	return out, nil
+}
+
+// kbOptions sets up a knowledge base of one note for p.
+func kbOptions(t *testing.T, p provider.Provider) Options {
+	t.Helper()
+	notes := t.TempDir()
+	if err := os.WriteFile(filepath.Join(notes, "garden.md"), []byte("Water the tomatoes every morning.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Defaults().Knowledge
+	cfg.Dirs = []string{notes}
+	return Options{
+		Provider:       p,
+		Knowledge:      cfg,
+		KnowledgeIndex: filepath.Join(t.TempDir(), "knowledge.gob"),
+	}
+}
+
+func TestKB_IndexAndAsk(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := newTestModel(t, kbOptions(t, embedProvider{mp}))
+
+	handleKB(&m, "")
+	if got := lastMessage(&m); !strings.Contains(got.content, "/kb index") {
+		t.Errorf("status = %q, want a hint to index", got.content)
+	}
+
+	_, cmd := handleKB(&m, "index")
+	for _, msg := range collectMsgs(cmd) {
+		newM, _ := m.Update(msg)
+		m = newM.(Model)
+	}
+	if got := lastMessage(&m); got.content != "Knowledge base updated: 1 new, 0 changed, 0 removed, 0 unchanged; 1 file in 1 passage." {
+		t.Fatalf("last message = %+v, want the index summary", got)
+	}
+
+	_, cmd = handleKB(&m, "ask when do the tomatoes need water?")
+	if !m.streaming || lastMessage(&m).content != "when do the tomatoes need water?" {
+		t.Fatalf("the question should be sent, last message = %+v", lastMessage(&m))
+	}
+	collectMsgs(cmd)
+	sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.HasPrefix(sent, "when do the tomatoes need water?") || !strings.Contains(sent, "garden.md:1") ||
+		!strings.Contains(sent, "Water the tomatoes every morning.") {
+		t.Errorf("sent prompt = %q, want the question with the passage", sent)
+	}
+
+	// Without /kb ask or automatic retrieval, messages go out unchanged
+	m.streaming = false
+	collectMsgs(m.sendMessage("tomatoes?"))
+	if sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content; sent != "tomatoes?" {
+		t.Errorf("sent = %q, want no retrieval", sent)
+	}
+	handleKB(&m, "on")
+	m.streaming = false
+	collectMsgs(m.sendMessage("tomatoes?"))
+	if sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content; !strings.Contains(sent, "garden.md") {
+		t.Errorf("sent = %q, want automatic retrieval", sent)
+	}
+	m.streaming = false
+	collectMsgs(m.sendMessage("what's the weather?"))
+	if sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content; sent != "what's the weather?" {
+		t.Errorf("sent = %q, want no passages without a match", sent)
+	}
+}
+
+func TestKB_AskFailsWithoutIndex(t *testing.T) {
+	m := newTestModel(t, kbOptions(t, embedProvider{&providertest.Fake{ProviderName: "test"}}))
+	_, cmd := handleKB(&m, "ask anything?")
+	for _, msg := range collectMsgs(cmd) {
+		newM, _ := m.Update(msg)
+		m = newM.(Model)
+	}
+	if got := lastMessage(&m); got.role != "error" || !strings.Contains(got.content, "/kb index") {
+		t.Errorf("last message = %+v, want an error suggesting /kb index", got)
+	}
+}
+
+func TestKB_IndexNeedsEmbeddings(t *testing.T) {
+	m := newTestModel(t, kbOptions(t, &providertest.Fake{ProviderName: "test"}))
+	_, cmd := handleKB(&m, "index")
+	for _, msg := range collectMsgs(cmd) {
+		newM, _ := m.Update(msg)
+		m = newM.(Model)
+	}
+	if got := lastMessage(&m); got.role != "error" || !strings.Contains(got.content, "embeddings") {
+		t.Errorf("last message = %+v, want an error about embeddings", got)
+	}
+}
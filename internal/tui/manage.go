@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ModelDetailsMsg carries the details /model info asked for.
+type ModelDetailsMsg struct {
+	Model   string
+	Details *provider.ModelDetails
+	Err     error
+}
+
+// ModelDeletedMsg reports the end of /model rm.
+type ModelDeletedMsg struct {
+	Model string
+	Err   error
+}
+
+// manageTimeout bounds how long describing or deleting a model may take.
+const manageTimeout = 30 * time.Second
+
+// handleModelInfo describes model, or the current one if model is empty.
+func handleModelInfo(m *Model, model string) (tea.Model, tea.Cmd) {
+	md, ok := m.options.Provider.(provider.ModelDescriber)
+	if !ok {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("%s can't describe its models.", m.options.Provider.Name())})
+		m.updateViewport()
+		return m, nil
+	}
+	if model == "" {
+		model = m.options.Model
+	}
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), manageTimeout)
+		defer cancel()
+		d, err := md.ShowModel(ctx, model)
+		return ModelDetailsMsg{Model: model, Details: d, Err: err}
+	}
+}
+
+// handleModelRm deletes an installed model other than the current one.
+func handleModelRm(m *Model, model string) (tea.Model, tea.Cmd) {
+	mm, ok := m.options.Provider.(provider.ModelManager)
+	switch {
+	case model == "":
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Usage: /model rm <name>")})
+	case !ok:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("%s can't delete models.", m.options.Provider.Name())})
+	case model == m.options.Model:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("%s is the current model; switch to another one with /model first.", model)})
+	default:
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), manageTimeout)
+			defer cancel()
+			return ModelDeletedMsg{Model: model, Err: mm.DeleteModel(ctx, model)}
+		}
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// handleModelDetails shows what /model info found out.
+func (m *Model) handleModelDetails(msg ModelDetailsMsg) {
+	if msg.Err != nil {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Error describing %s: %v", msg.Model, msg.Err)})
+		m.updateViewport()
+		return
+	}
+	d := msg.Details
+	lines := []string{msg.Model + ":"}
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", m.tr.T(label), value))
+		}
+	}
+	add("Family", d.Family)
+	add("Parameters", d.ParameterSize)
+	add("Quantization", d.Quantization)
+	if d.ContextLength > 0 {
+		add("Context window", m.tr.Sprintf("%s tokens", formatContextLength(d.ContextLength)))
+	}
+	add("Capabilities", strings.Join(d.Capabilities, ", "))
+	if d.DiskFree > 0 {
+		add("Free disk space", fmt.Sprintf("%.1f GB", gigabytes(int64(d.DiskFree))))
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: strings.Join(lines, "\n")})
+	m.updateViewport()
+}
+
+// handleModelDeleted reports the end of /model rm.
+func (m *Model) handleModelDeleted(msg ModelDeletedMsg) {
+	content := m.tr.Sprintf("Deleted %s.", msg.Model)
+	if msg.Err != nil {
+		content = m.tr.Sprintf("Error deleting %s: %v", msg.Model, msg.Err)
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+}
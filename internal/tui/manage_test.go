@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// managingProvider describes every model the same way and records the
+// ones it deleted.
+type managingProvider struct {
+	providertest.Fake
+	deleted []string
+}
+
+func (p *managingProvider) ShowModel(ctx context.Context, model string) (*provider.ModelDetails, error) {
+	return &provider.ModelDetails{Family: "qwen3", ParameterSize: "8.2B", Quantization: "Q4_K_M", ContextLength: 40960, DiskFree: 123400000000}, nil
+}
+
+func (p *managingProvider) DeleteModel(ctx context.Context, model string) error {
+	p.deleted = append(p.deleted, model)
+	return nil
+}
+
+// runModel runs a /model command, including what it does in the
+// background.
+func runModel(m Model, args string) Model {
+	_, cmd := m.handleCommand(&Command{Name: "model", Args: args})
+	if cmd != nil {
+		newM, _ := m.Update(cmd())
+		m = newM.(Model)
+	}
+	return m
+}
+
+func TestModelInfo(t *testing.T) {
+	m := New(Options{Provider: &managingProvider{}, Model: "qwen3:8b"})
+	m.width, m.height, m.ready = 80, 24, true
+
+	m = runModel(m, "info")
+	want := strings.Join([]string{
+		"qwen3:8b:",
+		"  Family: qwen3",
+		"  Parameters: 8.2B",
+		"  Quantization: Q4_K_M",
+		"  Context window: 40k tokens",
+		"  Free disk space: 123.4 GB",
+	}, "\n")
+	if got := lastMessage(&m).content; got != want {
+		t.Errorf("info = %q, want %q", got, want)
+	}
+
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "openrouter"}, Model: "qwen3:8b"})
+	m = runModel(m, "info qwen3:8b")
+	if got := lastMessage(&m).content; got != "openrouter can't describe its models." {
+		t.Errorf("info without a model manager = %q", got)
+	}
+}
+
+func TestModelRm(t *testing.T) {
+	p := &managingProvider{}
+	m := New(Options{Provider: p, Model: "qwen3:8b"})
+	m.width, m.height, m.ready = 80, 24, true
+
+	m = runModel(m, "rm qwen3:8b")
+	if len(p.deleted) != 0 || !strings.Contains(lastMessage(&m).content, "is the current model") {
+		t.Errorf("deleting the current model: deleted %v, said %q", p.deleted, lastMessage(&m).content)
+	}
+
+	m = runModel(m, "rm qwen3:14b")
+	if len(p.deleted) != 1 || p.deleted[0] != "qwen3:14b" {
+		t.Errorf("deleted = %v", p.deleted)
+	}
+	if got := lastMessage(&m).content; got != "Deleted qwen3:14b." {
+		t.Errorf("message = %q", got)
+	}
+	if m.options.Model != "qwen3:8b" {
+		t.Errorf("model = %q, want it unchanged", m.options.Model)
+	}
+}
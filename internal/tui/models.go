@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// maxModelNameWidth caps the name column of /models, so that one long ID
+// doesn't push the details of every other model off screen.
+const maxModelNameWidth = 48
+
+// matchModel reports whether model matches every word of filter, in its
+// ID or display name. The word "free" also matches models that cost nothing.
+func matchModel(model provider.ModelInfo, filter string) bool {
+	text := strings.ToLower(model.Name + " " + model.DisplayName)
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		if word == "free" && model.Pricing != nil && model.Pricing.Free() {
+			continue
+		}
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatContextLength shortens a context length to thousands of tokens,
+// e.g. 131072 -> "128k", 200000 -> "200k".
+func formatContextLength(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n%1024 == 0:
+		return fmt.Sprintf("%dk", n/1024)
+	default:
+		return fmt.Sprintf("%dk", n/1000)
+	}
+}
+
+// formatPrice formats a price in dollars per million tokens.
+func formatPrice(p float64) string {
+	if p > 0 && p < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", p)
+}
+
+// modelDetails describes the context length and price of a hosted model,
+// or returns "" for a local one.
+func (m *Model) modelDetails(model provider.ModelInfo) string {
+	var parts []string
+	if model.ContextLength > 0 {
+		parts = append(parts, m.tr.Sprintf("%s context", formatContextLength(model.ContextLength)))
+	}
+	if p := model.Pricing; p != nil {
+		if p.Free() {
+			parts = append(parts, m.tr.T("free"))
+		} else {
+			parts = append(parts, m.tr.Sprintf("%s in / %s out per 1M tokens", formatPrice(p.Prompt), formatPrice(p.Completion)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatModelList renders the result of /models, marking the current model
+// and keeping only those matching filter.
+func (m *Model) formatModelList(models []provider.ModelInfo, filter string) string {
+	var shown []provider.ModelInfo
+	width := 0
+	for _, model := range models {
+		if matchModel(model, filter) {
+			shown = append(shown, model)
+			width = max(width, min(len(model.Name), maxModelNameWidth))
+		}
+	}
+
+	var lines []string
+	switch {
+	case filter == "":
+		lines = append(lines, m.tr.T("Available models:"))
+	case len(shown) == 0:
+		return m.tr.Sprintf("None of %d models match %q.", len(models), filter)
+	default:
+		lines = append(lines, m.tr.Sprintf("Models matching %q (%d of %d):", filter, len(shown), len(models)))
+	}
+	for _, model := range shown {
+		marker := "  "
+		if model.Name == m.options.Model {
+			marker = "* "
+		}
+		line := marker + model.Name
+		if details := m.modelDetails(model); details != "" {
+			line = fmt.Sprintf("%s%-*s  %s", marker, width, model.Name, details)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+var catalog = []provider.ModelInfo{
+	{Name: "qwen/qwen3-8b", DisplayName: "Qwen: Qwen3 8B", ContextLength: 131072, Pricing: &provider.Pricing{Prompt: 0.035, Completion: 0.138}},
+	{Name: "qwen/qwen3-8b:free", DisplayName: "Qwen: Qwen3 8B (free)", ContextLength: 40960, Pricing: &provider.Pricing{}},
+	{Name: "anthropic/claude-sonnet-4", DisplayName: "Anthropic: Claude Sonnet 4", ContextLength: 200000, Pricing: &provider.Pricing{Prompt: 3, Completion: 15}},
+}
+
+func TestModelList_ShowsDetails(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{}, Model: "qwen/qwen3-8b"})
+	m = listModels(m, ModelListMsg{Models: catalog})
+
+	got := lastMessage(&m).content
+	for _, want := range []string{
+		"Available models:",
+		"* qwen/qwen3-8b              128k context, $0.04 in / $0.14 out per 1M tokens",
+		"  qwen/qwen3-8b:free         40k context, free",
+		"200k context, $3.00 in / $15.00 out per 1M tokens",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("model list missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestModelList_LocalModelsHaveNoDetails(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{}, Model: "llama3"})
+	m = listModels(m, ModelListMsg{Models: []provider.ModelInfo{{Name: "llama3"}, {Name: "qwen3:8b"}}})
+
+	want := "Available models:\n* llama3\n  qwen3:8b"
+	if got := lastMessage(&m).content; got != want {
+		t.Errorf("model list = %q, want %q", got, want)
+	}
+}
+
+func TestModelList_Filter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"qwen", []string{"qwen/qwen3-8b", "qwen/qwen3-8b:free"}},
+		{"QWEN free", []string{"qwen/qwen3-8b:free"}},
+		{"sonnet", []string{"anthropic/claude-sonnet-4"}},
+		{"free", []string{"qwen/qwen3-8b:free"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, model := range catalog {
+			if matchModel(model, tt.filter) {
+				got = append(got, model.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("filter %q matched %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	m := New(Options{Provider: &providertest.Fake{}})
+	m = listModels(m, ModelListMsg{Models: catalog, Filter: "sonnet"})
+	if got := lastMessage(&m).content; !strings.HasPrefix(got, `Models matching "sonnet" (1 of 3):`) {
+		t.Errorf("filtered list = %q", got)
+	}
+	m = listModels(m, ModelListMsg{Models: catalog, Filter: "gpt"})
+	if got := lastMessage(&m).content; got != `None of 3 models match "gpt".` {
+		t.Errorf("empty filtered list = %q", got)
+	}
+}
+
+func listModels(m Model, msg ModelListMsg) Model {
+	newM, _ := m.Update(msg)
+	return newM.(Model)
+}
+
+func TestFormatContextLength(t *testing.T) {
+	for n, want := range map[int]string{512: "512", 8192: "8k", 131072: "128k", 200000: "200k"} {
+		if got := formatContextLength(n); got != want {
+			t.Errorf("formatContextLength(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+// NotifyErrMsg reports a webhook that could not be reached.
+type NotifyErrMsg struct {
+	Err error
+}
+
+// notify sends ev to the webhooks in the background. Nothing is sent while
+// the terminal has focus if only_when_unfocused is set.
+func (m *Model) notify(ev notify.Event) tea.Cmd {
+	n := m.options.Notifier
+	if !n.Wants(ev.Kind) || (m.options.Notify.OnlyWhenUnfocused && !m.blurred) {
+		return nil
+	}
+	if ev.Time.IsZero() {
+		ev.Time = m.now()
+	}
+	return func() tea.Msg {
+		if err := n.Send(context.Background(), ev); err != nil {
+			return NotifyErrMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+// notifyReply sends the event for a finished reply: heartbeat check-ins
+// always, and replies to the user when they took at least long_response.
+func (m *Model) notifyReply(content string) tea.Cmd {
+	switch m.turnEvent {
+	case notify.EventHeartbeat:
+		return tea.Batch(
+			m.notify(notify.Event{Kind: notify.EventHeartbeat, Title: "stefanclaw check-in", Message: content}),
+			m.mailHeartbeat(content),
+		)
+	case notify.EventResponse:
+		threshold, err := time.ParseDuration(m.options.Notify.LongResponse)
+		if err != nil || m.now().Sub(m.turnStart) < threshold {
+			return nil
+		}
+		return m.notify(notify.Event{Kind: notify.EventResponse, Title: "stefanclaw replied", Message: content})
+	}
+	return nil
+}
+
+// mailHeartbeat emails a heartbeat check-in if email.heartbeats is set.
+// Unlike webhooks, mail is sent whether or not the terminal has focus.
+func (m *Model) mailHeartbeat(content string) tea.Cmd {
+	mailer := m.options.Mailer
+	if !mailer.Heartbeats() {
+		return nil
+	}
+	subject := "stefanclaw check-in — " + m.now().Format("Mon 2006-01-02 15:04")
+	return func() tea.Msg {
+		if err := mailer.Send(subject, content); err != nil {
+			return NotifyErrMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+func (m *Model) handleNotifyErr(msg NotifyErrMsg) {
+	m.messages = append(m.messages, displayMessage{
+		role:    "error",
+		content: m.tr.Sprintf("Notification failed: %v", msg.Err),
+	})
+	m.updateViewport()
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+)
+
+// webhookRecorder collects the events posted to a generic JSON webhook.
+type webhookRecorder struct {
+	mu     sync.Mutex
+	events []map[string]string
+}
+
+func (r *webhookRecorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var got []string
+	for _, ev := range r.events {
+		got = append(got, ev["event"]+": "+ev["message"])
+	}
+	return got
+}
+
+// notifyOptions sets up cfg with a webhook that records what it receives,
+// read from clock.
+func notifyOptions(t *testing.T, cfg config.NotifyConfig, clock *testClock) (Options, *webhookRecorder) {
+	t.Helper()
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev map[string]string
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &ev)
+		rec.mu.Lock()
+		rec.events = append(rec.events, ev)
+		rec.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Webhooks = []config.WebhookConfig{{URL: srv.URL}}
+	return Options{Notify: cfg, Notifier: notify.New(cfg, nil), Now: clock.Now}, rec
+}
+
+// finishReply completes the current turn with content after elapsed time.
+func finishReply(m Model, clock *testClock, content string, elapsed time.Duration) Model {
+	clock.t = m.turnStart.Add(elapsed)
+	m.streaming = true
+	m.streamContent = content
+	newM, cmd := m.Update(StreamDoneMsg{})
+	collectMsgs(cmd)
+	return newM.(Model)
+}
+
+func TestNotify_LongResponse(t *testing.T) {
+	clock := &testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}
+	opts, rec := notifyOptions(t, config.NotifyConfig{LongResponse: "1m"}, clock)
+	m := newTestModel(t, opts)
+
+	m.sendMessage("quick question")
+	m = finishReply(m, clock, "Quick answer.", 10*time.Second)
+	m.sendMessage("hard question")
+	m = finishReply(m, clock, "Slow answer.", 2*time.Minute)
+
+	got := rec.received()
+	if len(got) != 1 || got[0] != "response: Slow answer." {
+		t.Errorf("events = %q, want only the slow answer", got)
+	}
+}
+
+func TestNotify_Heartbeat(t *testing.T) {
+	clock := &testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}
+	opts, rec := notifyOptions(t, config.NotifyConfig{LongResponse: "1m"}, clock)
+	m := newTestModel(t, opts)
+
+	m.triggerHeartbeat()
+	m = finishReply(m, clock, "HEARTBEAT_SKIP", 0)
+	m.triggerHeartbeat()
+	m = finishReply(m, clock, "Don't forget your 3pm call.", 0)
+
+	got := rec.received()
+	if len(got) != 1 || got[0] != "heartbeat: Don't forget your 3pm call." {
+		t.Errorf("events = %q, want the one check-in", got)
+	}
+}
+
+func TestNotify_OnlyWhenUnfocused(t *testing.T) {
+	clock := &testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}
+	opts, rec := notifyOptions(t, config.NotifyConfig{LongResponse: "0s", OnlyWhenUnfocused: true}, clock)
+	m := newTestModel(t, opts)
+
+	m.sendMessage("first")
+	m = finishReply(m, clock, "Seen.", 0)
+
+	newM, _ := m.Update(tea.BlurMsg{})
+	m = newM.(Model)
+	m.sendMessage("second")
+	m = finishReply(m, clock, "Away.", 0)
+
+	got := rec.received()
+	if len(got) != 1 || got[0] != "response: Away." {
+		t.Errorf("events = %q, want only the reply while unfocused", got)
+	}
+}
+
+func TestNotify_JobDone(t *testing.T) {
+	clock := &testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}
+	opts, rec := notifyOptions(t, config.NotifyConfig{LongResponse: "1m"}, clock)
+	m := newTestModel(t, opts)
+
+	newM, cmd := m.Update(JobDoneMsg{Job: config.JobConfig{Name: "digest"}, Answer: "Three things happened."})
+	m = newM.(Model)
+	collectMsgs(cmd)
+
+	got := rec.received()
+	if len(got) != 1 || got[0] != "job: Three things happened." {
+		t.Errorf("events = %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+package tui
+
+// Thresholds of adaptive context scaling. The context grows once a prompt
+// takes more than growAt of it, and after compaction or /clear shrinks only
+// to a tier the conversation takes less than shrinkBelow of. The gap
+// between them keeps a conversation near a tier boundary from reloading the
+// model back and forth.
+const (
+	growAt      = 0.6
+	shrinkBelow = 0.3
+)
+
+// savedNumCtx returns the context size last used with model if it is still
+// a tier within ctxLimit, or the smallest tier.
+func (m *Model) savedNumCtx(model string) int {
+	if m.options.ContextSizes != nil {
+		n := m.options.ContextSizes.Get(model)
+		for _, tier := range ctxTiers {
+			if tier == n && tier <= m.ctxLimit() {
+				return n
+			}
+		}
+	}
+	return ctxTiers[0]
+}
+
+// setNumCtx switches the context to numCtx, announcing why, and saves it
+// for the current model and session.
+func (m *Model) setNumCtx(numCtx int, notice string) {
+	m.currentNumCtx = numCtx
+	m.messages = append(m.messages, displayMessage{role: "system", content: notice})
+	if m.options.ContextSizes != nil {
+		m.options.ContextSizes.Set(m.options.Model, numCtx)
+	}
+	m.state.NumCtx = numCtx
+	m.saveState()
+}
+
+// growContext moves to the next tier when the last prompt, as reported by
+// the provider, took more than growAt of the context.
+func (m *Model) growContext(promptTokens int) {
+	if promptTokens <= int(float64(m.currentNumCtx)*growAt) {
+		return
+	}
+	for _, tier := range ctxTiers {
+		if tier > m.currentNumCtx && tier <= m.ctxLimit() {
+			m.setNumCtx(tier, m.tr.Sprintf("Context expanded to %d tokens (conversation is growing). The next response may take a moment while the model reloads.", tier))
+			return
+		}
+	}
+}
+
+// shrinkContext moves to the smallest tier the conversation takes less than
+// shrinkBelow of, if that is smaller than the current one. It is called
+// when the conversation got shorter: after compaction or /clear.
+func (m *Model) shrinkContext() {
+	need := promptTokens(m.buildMessages(""))
+	for _, tier := range ctxTiers {
+		if tier >= m.currentNumCtx {
+			return
+		}
+		if need < int(float64(tier)*shrinkBelow) {
+			m.setNumCtx(tier, m.tr.Sprintf("Context reduced to %d tokens (conversation is shorter now) to free memory. The next response may take a moment while the model reloads.", tier))
+			return
+		}
+	}
+}
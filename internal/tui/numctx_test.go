@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+func TestContext_GrowsWithHysteresis(t *testing.T) {
+	m := newOverflowModel(32768, &providertest.Fake{ProviderName: "test"})
+	m.growContext(2000)
+	if m.currentNumCtx != 4096 {
+		t.Fatalf("context = %d after a prompt under 60%%, want 4096", m.currentNumCtx)
+	}
+	m.growContext(3000)
+	if m.currentNumCtx != 8192 {
+		t.Fatalf("context = %d after a prompt over 60%%, want 8192", m.currentNumCtx)
+	}
+
+	// 2000 tokens would not grow a 4096 context again, but are too many
+	// to shrink back to it.
+	m.messages = []displayMessage{{role: "user", content: words(2000)}}
+	m.shrinkContext()
+	if m.currentNumCtx != 8192 {
+		t.Errorf("context = %d for a conversation near the boundary, want 8192 kept", m.currentNumCtx)
+	}
+}
+
+func TestContext_ShrinksAfterClear(t *testing.T) {
+	m := newOverflowModel(32768, &providertest.Fake{ProviderName: "test"})
+	m.currentNumCtx = 32768
+	m.messages = []displayMessage{{role: "user", content: words(3000)}}
+
+	m.handleCommand(&Command{Name: "clear"})
+	if m.currentNumCtx != 4096 {
+		t.Errorf("context = %d after /clear, want 4096", m.currentNumCtx)
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "Context reduced to 4096") {
+		t.Errorf("last message = %q, want the context reduction announced", got)
+	}
+}
+
+func TestContext_ShrinksAfterCompaction(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("Summary.")}}
+	m := newOverflowModel(32768, mp)
+	m.currentNumCtx = 16384
+	for i := range 7 {
+		content := "Hi"
+		if i < 4 {
+			content = words(3500)
+		}
+		m.messages = append(m.messages,
+			displayMessage{role: "user", content: content},
+			displayMessage{role: "assistant", content: "ok"})
+	}
+	m.streaming = true
+
+	newM, _ := m.Update(StreamDoneMsg{Usage: &provider.Usage{PromptTokens: 8000}})
+	m = newM.(Model)
+	if m.currentNumCtx != 4096 {
+		t.Errorf("context = %d after compaction, want 4096", m.currentNumCtx)
+	}
+}
+
+func TestContext_SavedPerModel(t *testing.T) {
+	sizes := session.NewContextSizes(filepath.Join(t.TempDir(), "context-sizes.json"))
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "small", MaxNumCtx: 32768, ContextSizes: sizes})
+	m.growContext(3000)
+	if got := sizes.Get("small"); got != 8192 {
+		t.Fatalf("saved context = %d, want 8192", got)
+	}
+
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "small", MaxNumCtx: 32768, ContextSizes: sizes})
+	if m.currentNumCtx != 8192 {
+		t.Errorf("context at start = %d, want the saved 8192", m.currentNumCtx)
+	}
+	m.handleCommand(&Command{Name: "model", Args: "other"})
+	if m.currentNumCtx != 4096 {
+		t.Errorf("context after switching to a new model = %d, want 4096", m.currentNumCtx)
+	}
+
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "small", MaxNumCtx: 4096, ContextSizes: sizes})
+	if m.currentNumCtx != 4096 {
+		t.Errorf("context = %d, want the saved size capped by max_num_ctx", m.currentNumCtx)
+	}
+}
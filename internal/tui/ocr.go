@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/ocr"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+)
+
+// OCRDoneMsg carries the text read from an image, ready to send.
+type OCRDoneMsg struct {
+	Prompt string
+	Err    error
+}
+
+const defaultOCRQuestion = "What does this say? If it is an error, explain it and how to fix it."
+
+// handleOCR reads the text in an image (by default the latest screenshot)
+// and asks about it.
+func handleOCR(m *Model, args string) (tea.Model, tea.Cmd) {
+	cfg := m.options.Agent.OCR
+	if !cfg.Enabled {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T("OCR is off. Set agent.ocr.enabled: true in config.yaml to let stefanclaw read the text in images and screenshots."),
+		})
+		m.updateViewport()
+		return m, nil
+	}
+
+	path, question := splitImagePath(args)
+	if question == "" {
+		question = defaultOCRQuestion
+	}
+	if path != "" {
+		path = config.ExpandPath(path, m.options.WorkDir)
+	}
+	reader := ocr.New(cfg, m.options.Provider)
+	return m, func() tea.Msg {
+		if path == "" {
+			latest, err := ocr.Latest(ocr.ScreenshotDirs(cfg.ScreenshotDir))
+			if err != nil {
+				return OCRDoneMsg{Err: err}
+			}
+			path = latest
+		}
+		text, err := reader.Read(queue.WithLabel(context.Background(), "ocr"), path)
+		if err != nil {
+			return OCRDoneMsg{Err: err}
+		}
+		if text == "" {
+			return OCRDoneMsg{Err: fmt.Errorf("no text found in %s", filepath.Base(path))}
+		}
+		return OCRDoneMsg{Prompt: fmt.Sprintf("This is the text in the image %s:\n\n```\n%s\n```\n\n%s", filepath.Base(path), text, question)}
+	}
+}
+
+// splitImagePath splits "/ocr" arguments into a leading image path, which
+// may contain spaces, and the question after it.
+func splitImagePath(args string) (path, rest string) {
+	words := strings.Fields(args)
+	for i := range words {
+		if candidate := strings.Join(words[:i+1], " "); ocr.IsImage(candidate) {
+			return candidate, strings.Join(words[i+1:], " ")
+		}
+	}
+	return "", strings.TrimSpace(args)
+}
+
+// handleOCRDone sends the image text to the model, unless a reply is still
+// streaming.
+func (m *Model) handleOCRDone(msg OCRDoneMsg) tea.Cmd {
+	switch {
+	case msg.Err != nil:
+		m.messages = append(m.messages, displayMessage{role: "error", content: fmt.Sprintf("/ocr failed: %v", msg.Err)})
+	case m.streaming:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Still answering; try /ocr again when the reply is done.")})
+	default:
+		return m.sendMessage(msg.Prompt)
+	}
+	m.updateViewport()
+	return nil
+}
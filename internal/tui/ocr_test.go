@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestOCR_SendsScreenshotText(t *testing.T) {
+	bin, shots := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(bin, "tesseract"), []byte("#!/bin/sh\necho 'Permission denied (publickey)'\n"), 0o755)
+	t.Setenv("PATH", bin)
+	os.WriteFile(filepath.Join(shots, "Screenshot.png"), nil, 0o644)
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// Off by default
+	if _, cmd := handleOCR(&m, ""); cmd != nil {
+		t.Fatal("/ocr should not read anything while disabled")
+	}
+	if got := lastMessage(&m); !strings.Contains(got.content, "agent.ocr.enabled") {
+		t.Errorf("last message = %+v, want how to enable it", got)
+	}
+
+	m.options.Agent.OCR = config.OCRConfig{Enabled: true, Engine: "tesseract", ScreenshotDir: shots}
+	_, cmd := handleOCR(&m, "why can't I push?")
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, cmd := m.Update(msgs[0])
+	m = newM.(Model)
+	if !m.streaming {
+		t.Fatalf("the text should be sent to the model, last message = %+v", lastMessage(&m))
+	}
+	collectMsgs(cmd)
+	sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.Contains(sent, "Screenshot.png") || !strings.Contains(sent, "Permission denied") || !strings.HasSuffix(sent, "why can't I push?") {
+		t.Errorf("sent prompt = %q, want the image text and the question", sent)
+	}
+}
+
+func TestSplitImagePath(t *testing.T) {
+	tests := []struct {
+		args, path, rest string
+	}{
+		{"", "", ""},
+		{"what is this?", "", "what is this?"},
+		{"error.png", "error.png", ""},
+		{"~/Pictures/Screenshot from 2026.PNG what does it say", "~/Pictures/Screenshot from 2026.PNG", "what does it say"},
+	}
+	for _, tt := range tests {
+		path, rest := splitImagePath(tt.args)
+		if path != tt.path || rest != tt.rest {
+			t.Errorf("splitImagePath(%q) = %q, %q; want %q, %q", tt.args, path, rest, tt.path, tt.rest)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// replyReserve is how many tokens of the context are kept free for the
+// reply; small contexts keep a quarter.
+const replyReserve = 1024
+
+// messageOverhead approximates the tokens the chat template adds around
+// each message.
+const messageOverhead = 4
+
+// promptBudget is how many tokens a prompt may take in a numCtx context.
+func promptBudget(numCtx int) int {
+	return numCtx - min(replyReserve, numCtx/4)
+}
+
+// promptTokens estimates the tokens of msgs as the model sees them.
+func promptTokens(msgs []provider.Message) int {
+	return session.EstimateTokens(msgs) + messageOverhead*len(msgs)
+}
+
+// fitContext builds the messages of the next request and makes sure they
+// fit the context before they are sent, rather than letting Ollama cut off
+// the start of the prompt. A prompt that is too long first grows the
+// context to a larger tier, then has the conversation compacted, and last
+// has its oldest messages left out of the request. Each step is announced.
+func (m *Model) fitContext(userInput string) []provider.Message {
+	msgs := m.buildMessages(userInput)
+	need := promptTokens(msgs)
+	if need <= promptBudget(m.currentNumCtx) {
+		return msgs
+	}
+
+	grown := m.currentNumCtx
+	for _, tier := range ctxTiers {
+		if tier > m.currentNumCtx && tier <= m.ctxLimit() {
+			grown = tier
+			if need <= promptBudget(tier) {
+				break
+			}
+		}
+	}
+	if grown != m.currentNumCtx {
+		m.setNumCtx(grown, m.tr.Sprintf("Context expanded to %d tokens to fit the conversation. The response may take a moment while the model reloads.", grown))
+		if need <= promptBudget(grown) {
+			return msgs
+		}
+	}
+
+	budget := promptBudget(m.currentNumCtx)
+	if m.compact(budget) {
+		msgs = m.buildMessages(userInput)
+		if promptTokens(msgs) <= budget {
+			return msgs
+		}
+	}
+
+	msgs, dropped := trimToFit(msgs, budget)
+	shortened := truncateLast(msgs, budget)
+	var notice string
+	switch {
+	case dropped > 0 && shortened:
+		notice = m.tr.Sprintf("The conversation is too long for the %d-token context: the %d oldest messages were left out of this request and your message was shortened.", m.currentNumCtx, dropped)
+	case dropped > 0:
+		notice = m.tr.Sprintf("The conversation is too long for the %d-token context: the %d oldest messages were left out of this request.", m.currentNumCtx, dropped)
+	case shortened:
+		notice = m.tr.Sprintf("Your message is too long for the %d-token context and was shortened.", m.currentNumCtx)
+	}
+	if notice != "" {
+		m.messages = append(m.messages, displayMessage{role: "system", content: notice})
+	}
+	return msgs
+}
+
+// compact summarizes the older part of the conversation when the prompt
+// would take more than 80% of maxTokens, replacing it in the display too.
+// The summary is saved with the session, so reopening it resumes from
+// there. It reports whether it did.
+func (m *Model) compact(maxTokens int) bool {
+	msgs := m.buildMessages("")
+	result, compacted, err := session.Compact(
+		queue.WithLabel(context.Background(), "compaction"),
+		m.options.Provider,
+		m.taskModel(m.options.TaskModels.Compact),
+		msgs,
+		maxTokens,
+		6, // keep 3 user + 3 assistant turns
+	)
+	if err != nil || result == nil {
+		return false
+	}
+	// All but the system prompt and the kept messages came from the
+	// transcript, after what earlier summaries replace
+	summarized := result.OriginalCount - (result.RemainingCount - 1)
+	if msgs[0].Role == "system" {
+		summarized--
+	}
+	m.state.Summary = result.Summary
+	m.state.Compacted += summarized
+	m.saveState()
+
+	// Replace in-memory display messages with compacted set
+	var newMessages []displayMessage
+	for _, cm := range compacted {
+		if cm.Role == "system" {
+			continue // skip system prompt
+		}
+		newMessages = append(newMessages, displayMessage{
+			role:    displayRole(cm.Role, cm.Content),
+			content: cm.Content,
+		})
+	}
+	m.messages = newMessages
+	m.messages = append(m.messages, displayMessage{
+		role:    "system",
+		content: m.tr.Sprintf("Conversation compacted: %d messages summarized to keep context manageable.", result.OriginalCount-result.RemainingCount),
+	})
+	return true
+}
+
+// trimToFit leaves out the oldest messages after the system prompt until
+// msgs fit budget. The last message is always kept. It returns the
+// remaining messages and how many were left out.
+func trimToFit(msgs []provider.Message, budget int) ([]provider.Message, int) {
+	first := 0
+	if len(msgs) > 0 && msgs[0].Role == "system" {
+		first = 1
+	}
+	need := promptTokens(msgs)
+	drop := 0
+	for first+drop < len(msgs)-1 && need > budget {
+		need -= promptTokens(msgs[first+drop : first+drop+1])
+		drop++
+	}
+	if drop == 0 {
+		return msgs, 0
+	}
+	trimmed := append(append([]provider.Message(nil), msgs[:first]...), msgs[first+drop:]...)
+	return trimmed, drop
+}
+
+// truncateLast shortens the content of the last message so that msgs fit
+// budget, and reports whether it had to.
+func truncateLast(msgs []provider.Message, budget int) bool {
+	if len(msgs) == 0 {
+		return false
+	}
+	over := promptTokens(msgs) - budget
+	if over <= 0 {
+		return false
+	}
+	last := &msgs[len(msgs)-1]
+	keep := max(tokens.Count(last.Content)-over, 0)
+	last.Content = tokens.Truncate(last.Content, keep)
+	return true
+}
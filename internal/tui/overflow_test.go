@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// words returns text of about n tokens.
+func words(n int) string {
+	return strings.Repeat("hello ", n)
+}
+
+func newOverflowModel(maxNumCtx int, mp *providertest.Fake) Model {
+	m := New(Options{Provider: mp, Model: "test-model", MaxNumCtx: maxNumCtx})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	return m
+}
+
+func TestFitContext_Fits(t *testing.T) {
+	m := newOverflowModel(4096, &providertest.Fake{ProviderName: "test"})
+	m.messages = []displayMessage{{role: "user", content: "Hi"}}
+	if msgs := m.fitContext("Hi"); len(msgs) != 1 || m.currentNumCtx != 4096 {
+		t.Errorf("fitting request changed: %d messages, context %d", len(msgs), m.currentNumCtx)
+	}
+}
+
+func TestFitContext_GrowsContext(t *testing.T) {
+	m := newOverflowModel(32768, &providertest.Fake{ProviderName: "test"})
+	m.messages = []displayMessage{
+		{role: "user", content: words(3000)},
+		{role: "assistant", content: words(3000)},
+		{role: "user", content: "And now?"},
+	}
+	msgs := m.fitContext("And now?")
+	if m.currentNumCtx != 8192 {
+		t.Errorf("context = %d, want the smallest tier that fits, 8192", m.currentNumCtx)
+	}
+	if len(msgs) != 3 {
+		t.Errorf("sent %d messages, want all 3", len(msgs))
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "Context expanded to 8192") {
+		t.Errorf("notice = %q", got)
+	}
+}
+
+func TestFitContext_TrimsWhenCompactionFails(t *testing.T) {
+	m := newOverflowModel(4096, &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Err: errors.New("offline")}}})
+	for range 4 {
+		m.messages = append(m.messages,
+			displayMessage{role: "user", content: words(500)},
+			displayMessage{role: "assistant", content: words(500)})
+	}
+	m.messages = append(m.messages, displayMessage{role: "user", content: "Latest question"})
+
+	msgs := m.fitContext("Latest question")
+	if n := promptTokens(msgs); n > promptBudget(4096) {
+		t.Errorf("request has %d tokens, more than the budget of %d", n, promptBudget(4096))
+	}
+	if msgs[len(msgs)-1].Content != "Latest question" {
+		t.Error("latest message left out")
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "oldest messages were left out") {
+		t.Errorf("notice = %q", got)
+	}
+	if len(m.messages) != 10 {
+		t.Errorf("display has %d messages, want the 9 kept and the notice", len(m.messages))
+	}
+}
+
+func TestTruncateLast(t *testing.T) {
+	msgs := []provider.Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: words(5000)}}
+	if !truncateLast(msgs, 3000) {
+		t.Fatal("long message not shortened")
+	}
+	if n := promptTokens(msgs); n > 3000 {
+		t.Errorf("%d tokens after shortening, want at most 3000", n)
+	}
+	if tokens.Count(msgs[1].Content) < 2500 {
+		t.Error("shortened more than needed")
+	}
+	if truncateLast(msgs, 3000) {
+		t.Error("fitting message shortened again")
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/plugin"
+)
+
+// PluginDoneMsg carries the result of a plugin run as a slash command.
+type PluginDoneMsg struct {
+	Name   string
+	Output string
+	Err    error
+}
+
+// pluginCommands returns the plugins usable as slash commands. Plugins named
+// like a built-in command or alias are left out.
+func (m *Model) pluginCommands() []plugin.Plugin {
+	var cmds []plugin.Plugin
+	for _, p := range m.options.Plugins {
+		if !isBuiltinCommand(p.Name) {
+			cmds = append(cmds, p)
+		}
+	}
+	return cmds
+}
+
+func (m *Model) pluginCommand(name string) (plugin.Plugin, bool) {
+	for _, p := range m.pluginCommands() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return plugin.Plugin{}, false
+}
+
+// pluginHelpText lists plugin commands for /help, or returns "" if there are
+// none.
+func (m *Model) pluginHelpText() string {
+	cmds := m.pluginCommands()
+	if len(cmds) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nPlugin commands:")
+	for _, p := range cmds {
+		fmt.Fprintf(&b, "\n  %-38s %s", p.Usage(), p.Description)
+	}
+	return b.String()
+}
+
+// runPluginCommand invokes a plugin with the text after its slash command.
+func (m *Model) runPluginCommand(p plugin.Plugin, args string) (tea.Model, tea.Cmd) {
+	if len(p.Params) > 0 && p.Params[0].Required && args == "" {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Usage: %s", p.Usage()),
+		})
+		m.updateViewport()
+		return m, nil
+	}
+
+	m.messages = append(m.messages, displayMessage{
+		role:    "system",
+		content: m.tr.Sprintf("Running /%s...", p.Name),
+	})
+	m.updateViewport()
+
+	return m, func() tea.Msg {
+		out, err := p.Invoke(context.Background(), p.CommandArgs(args))
+		return PluginDoneMsg{Name: p.Name, Output: out, Err: err}
+	}
+}
+
+func (m *Model) handlePluginDone(msg PluginDoneMsg) {
+	if msg.Err != nil {
+		m.messages = append(m.messages, displayMessage{
+			role:    "error",
+			content: fmt.Sprintf("/%s failed: %v", msg.Name, msg.Err),
+		})
+	} else {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: strings.TrimRight(msg.Output, "\n"),
+		})
+	}
+	m.updateViewport()
+}
+
+func handlePlugins(m *Model, args string) (tea.Model, tea.Cmd) {
+	var lines []string
+	if len(m.options.Plugins) == 0 {
+		lines = append(lines, m.tr.T("No plugins loaded."))
+	} else {
+		lines = append(lines, "Plugins:")
+		for _, p := range m.options.Plugins {
+			line := fmt.Sprintf("  %-20s %s", p.Name, p.Description)
+			if p.IsWasm() {
+				line += " [wasm]"
+			}
+			if isBuiltinCommand(p.Name) {
+				line += " (tool only: name clashes with a built-in command)"
+			}
+			lines = append(lines, line)
+		}
+	}
+	lines = append(lines, "", m.tr.Sprintf("Plugin directory: %s", config.PluginsDir()))
+	m.messages = append(m.messages, displayMessage{
+		role:    "system",
+		content: strings.Join(lines, "\n"),
+	})
+	m.updateViewport()
+	return m, nil
+}
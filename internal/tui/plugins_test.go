@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/plugin"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func loadTestPlugin(t *testing.T, name string) plugin.Plugin {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	script := `#!/bin/sh
+if [ "$1" = describe ]; then
+  echo '{"name": "` + name + `", "description": "Echo test plugin", "params": [{"name": "text", "required": true}]}'
+else
+  cat | sed 's/.*"text":"\([^"]*\)".*/{"output": "echo: \1"}/'
+fi
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p, err := plugin.Load(path)
+	if err != nil {
+		t.Fatalf("loading plugin: %v", err)
+	}
+	return p
+}
+
+func TestPlugin_SlashCommand(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", Plugins: []plugin.Plugin{loadTestPlugin(t, "echo")}})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	newM, _ := m.handleCommand(&Command{Name: "echo"})
+	model := newM.(*Model)
+	if last := model.messages[len(model.messages)-1]; last.content != "Usage: /echo <text>" {
+		t.Errorf("missing argument message = %q", last.content)
+	}
+
+	newM, cmd := model.handleCommand(&Command{Name: "echo", Args: "hello"})
+	model = newM.(*Model)
+	msg := cmd().(PluginDoneMsg)
+	if msg.Err != nil || msg.Output != "echo: hello" {
+		t.Fatalf("PluginDoneMsg = %+v", msg)
+	}
+	updated, _ := model.Update(msg)
+	if last := updated.(Model).messages; last[len(last)-1].content != "echo: hello" {
+		t.Errorf("plugin output not shown: %+v", last[len(last)-1])
+	}
+
+	help, _ := handleHelp(model, "")
+	if msgs := help.(*Model).messages; !strings.Contains(msgs[len(msgs)-1].content, "/echo <text>") {
+		t.Error("help should list plugin commands")
+	}
+}
+
+func TestPlugin_AgentTool(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider: mp,
+		Model:    "test-model",
+		Agent:    config.AgentConfig{Enabled: true, MaxSteps: 5},
+		Plugins:  []plugin.Plugin{loadTestPlugin(t, "echo"), loadTestPlugin(t, "fetch")},
+	})
+
+	if _, ok := m.tools.Get("echo"); !ok {
+		t.Error("plugin should be offered as an agent tool")
+	}
+	if tool, _ := m.tools.Get("fetch"); tool.Description == "Echo test plugin" {
+		t.Error("built-in fetch tool should take precedence over a plugin")
+	}
+}
+
+func TestPlugin_BuiltinNameIsToolOnly(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", Plugins: []plugin.Plugin{loadTestPlugin(t, "clear")}})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	m.messages = []displayMessage{{role: "user", content: "hi"}}
+
+	newM, _ := m.handleCommand(&Command{Name: "clear"})
+	if len(newM.(*Model).messages) != 0 {
+		t.Error("/clear should still run the built-in command")
+	}
+	newM, _ = handlePlugins(&m, "")
+	if msgs := newM.(*Model).messages; !strings.Contains(msgs[len(msgs)-1].content, "tool only") {
+		t.Errorf("/plugins should flag the clash: %q", msgs[len(msgs)-1].content)
+	}
+}
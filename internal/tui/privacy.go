@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/units"
+)
+
+// Messages shown when a command needs a capability switched off in config.
+const (
+	heartbeatDisabledMsg = "Heartbeats are disabled (privacy.disable_heartbeat in config.yaml)."
+	updateDisabledMsg    = "Update checks are disabled with web access (privacy.disable_web in config.yaml)."
+)
+
+// newFetchClient returns the web client for the given privacy settings. With
+// web access disabled every fetch and search fails with fetch.ErrDisabled.
+func newFetchClient(p config.PrivacyConfig) *fetch.Client {
+	if p.DisableWeb {
+		return fetch.Disabled()
+	}
+	return fetch.New()
+}
+
+// applyPrivacy switches capabilities off (or back on) for the running TUI.
+func (m *Model) applyPrivacy(p config.PrivacyConfig) {
+	m.options.Privacy = p
+	m.fetchClient = newFetchClient(p)
+	m.rates = nil
+	if !p.DisableWeb {
+		m.rates = units.NewRates(config.RatesFile())
+	}
+	if p.DisableHeartbeat {
+		m.heartbeatEnabled = false
+	}
+	m.calendar = calendar.FromConfig(m.options.Calendar, p)
+	m.options.Notifier = m.options.Notifier.WithPrivacy(p)
+	m.options.Mailer = m.options.Mailer.WithPrivacy(p)
+	m.buildTools()
+}
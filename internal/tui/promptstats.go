@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/tokens"
+)
+
+// handlePrompt handles /prompt stats, which shows what the system prompt is
+// made of and how much of the context it takes.
+func handlePrompt(m *Model, args string) (tea.Model, tea.Cmd) {
+	content := m.tr.T("Usage: /prompt stats")
+	if strings.TrimSpace(args) == "stats" {
+		content = m.promptStats()
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// promptStats reports the tokens of each part of the system prompt.
+func (m *Model) promptStats() string {
+	var b strings.Builder
+	line := func(name string, n int, note string) {
+		fmt.Fprintf(&b, "  %-14s %6d%s\n", name, n, note)
+	}
+
+	b.WriteString(m.tr.T("System prompt (tokens):") + "\n")
+	asm := m.options.PromptAsm
+	if asm != nil {
+		for _, s := range asm.Stats() {
+			switch {
+			case s.Sent == 0:
+				line(s.Name, s.Tokens, " "+m.tr.T("(left out)"))
+			case s.Trimmed():
+				line(s.Name, s.Sent, " "+m.tr.Sprintf("(trimmed from %d)", s.Tokens))
+			default:
+				line(s.Name, s.Tokens, "")
+			}
+		}
+		language := m.options.Language
+		if language == "" {
+			language = "English"
+		}
+		line(m.tr.T("Language"), tokens.Count(prompt.LanguageInstruction(language)), "")
+	}
+	line(m.tr.T("Calendar"), tokens.Count(m.calendarPrompt()), "")
+	line(m.tr.T("To-do list"), tokens.Count(m.todoPrompt()), "")
+	if m.tools != nil && m.tools.Len() > 0 {
+		line(m.tr.T("Tools"), tokens.Count(m.tools.SystemPrompt()), "")
+	}
+
+	total := tokens.Count(m.systemPrompt())
+	fmt.Fprintf(&b, "  %-14s %6d, %s\n", m.tr.T("Total"), total, m.tr.Sprintf("%d%% of the %d-token context", total*100/m.currentNumCtx, m.currentNumCtx))
+	if u := m.state.LastUsage; u != nil && u.PromptTokens > 0 {
+		b.WriteString(m.tr.Sprintf("The last request took %d tokens with the conversation, and the reply %d.", u.PromptTokens, u.CompletionTokens) + "\n")
+		b.WriteString(m.tr.Sprintf("This session has used %d prompt and %d reply tokens so far.", m.state.PromptTokens, m.state.CompletionTokens) + "\n")
+	}
+	if asm != nil {
+		if budget := asm.Budget(); budget > 0 {
+			b.WriteString(m.tr.Sprintf("Personality files are trimmed beyond %d tokens (personality.max_share of max_num_ctx), BOOT.md first and IDENTITY.md last.", budget))
+		} else {
+			b.WriteString(m.tr.T("Personality files are not trimmed (personality.max_share is 0)."))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
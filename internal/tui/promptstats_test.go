@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestPromptStats(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, prompt.SectionIdentity), []byte("# Identity\nI am Stefan."), 0o644)
+	os.WriteFile(filepath.Join(dir, prompt.SectionMemory), []byte(strings.Repeat("- a fact worth keeping\n", 200)), 0o644)
+	asm := prompt.NewAssembler(dir)
+	asm.LoadFiles()
+	asm.SetBudget(1000)
+
+	m := New(Options{
+		Provider:     &providertest.Fake{ProviderName: "test"},
+		Model:        "test-model",
+		PromptAsm:    asm,
+		SystemPrompt: asm.BuildSystemPromptWithLanguage("Deutsch"),
+		Language:     "Deutsch",
+	})
+	m.handleCommand(&Command{Name: "prompt", Args: "stats"})
+	got := lastMessage(&m).content
+	for _, want := range []string{"IDENTITY.md", "MEMORY.md", "(gekürzt von", "Sprache", "Gesamt", "Kontexts von 4096 Tokens", "über 1000 Tokens"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stats lack %q:\n%s", want, got)
+		}
+	}
+
+	m.handleCommand(&Command{Name: "prompt"})
+	if got := lastMessage(&m).content; !strings.Contains(got, "Verwendung: /prompt stats") {
+		t.Errorf("/prompt without stats = %q, want usage", got)
+	}
+}
@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ProviderSwitchMsg carries a provider opened by /provider, once it answered.
+type ProviderSwitchMsg struct {
+	Provider provider.Provider
+	HasModel bool // whether it offers the current model
+	Err      error
+}
+
+// handleProvider shows the current provider or switches to another one.
+func handleProvider(m *Model, args string) (tea.Model, tea.Cmd) {
+	name := strings.ToLower(args)
+	switch {
+	case name == "":
+		m.messages = append(m.messages, displayMessage{
+			role: "system",
+			content: m.tr.Sprintf("Current provider: %s\nAvailable: %s\nUsage: /provider <name>",
+				m.options.Provider.Name(), strings.Join(provider.Names(), ", ")),
+		})
+	case name == m.options.Provider.Name():
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Already using %s.", name)})
+	case m.options.OpenProvider == nil:
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.T("Switching providers isn't available here.")})
+	default:
+		open, model := m.options.OpenProvider, m.options.Model
+		return m, func() tea.Msg {
+			prov, err := open(name)
+			if err != nil {
+				return ProviderSwitchMsg{Err: err}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := prov.IsAvailable(ctx); err != nil {
+				return ProviderSwitchMsg{Err: err}
+			}
+			models, err := prov.ListModels(ctx)
+			hasModel := err != nil || slices.ContainsFunc(models, func(mi provider.ModelInfo) bool { return mi.Name == model })
+			return ProviderSwitchMsg{Provider: prov, HasModel: hasModel}
+		}
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// handleProviderSwitch makes the provider opened by /provider the current
+// one, for chat as well as for the tools that use it.
+func (m *Model) handleProviderSwitch(msg ProviderSwitchMsg) {
+	if msg.Err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Error switching provider: %v", msg.Err)})
+		m.updateViewport()
+		return
+	}
+	m.options.Provider = msg.Provider
+	m.buildTools()
+	content := m.tr.Sprintf("Switched to provider: %s", msg.Provider.Name())
+	if !msg.HasModel {
+		content += "\n" + m.tr.Sprintf("It doesn't offer %s; see /models and pick one with /model.", m.options.Model)
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+}
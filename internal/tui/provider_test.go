@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// providerOptions starts on a local provider and can switch to hosted as
+// openrouter.
+func providerOptions(hosted *providertest.Fake) Options {
+	return Options{
+		Provider: &providertest.Fake{ProviderName: "ollama"},
+		Model:    "qwen3:8b",
+		OpenProvider: func(name string) (provider.Provider, error) {
+			if name != "openrouter" {
+				return nil, errors.New("unknown provider")
+			}
+			return hosted, nil
+		},
+	}
+}
+
+func switchProvider(t *testing.T, m Model, name string) Model {
+	t.Helper()
+	_, cmd := m.handleCommand(&Command{Name: "provider", Args: name})
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("/provider %s returned %d messages", name, len(msgs))
+	}
+	newM, _ := m.Update(msgs[0])
+	return newM.(Model)
+}
+
+func TestProvider_Switch(t *testing.T) {
+	hosted := &providertest.Fake{ProviderName: "openrouter", Models: []provider.ModelInfo{{Name: "qwen/qwen3-8b"}}}
+	m := switchProvider(t, newTestModel(t, providerOptions(hosted)), "openrouter")
+
+	if m.options.Provider != hosted {
+		t.Fatalf("provider = %s, want openrouter", m.options.Provider.Name())
+	}
+	got := lastMessage(&m).content
+	if !strings.Contains(got, "Switched to provider: openrouter") || !strings.Contains(got, "doesn't offer qwen3:8b") {
+		t.Errorf("message = %q", got)
+	}
+}
+
+func TestProvider_SwitchKeepsModelItOffers(t *testing.T) {
+	hosted := &providertest.Fake{ProviderName: "openrouter", Models: []provider.ModelInfo{{Name: "qwen3:8b"}}}
+	m := switchProvider(t, newTestModel(t, providerOptions(hosted)), "openrouter")
+
+	if got := lastMessage(&m).content; got != "Switched to provider: openrouter" {
+		t.Errorf("message = %q", got)
+	}
+}
+
+func TestProvider_Unavailable(t *testing.T) {
+	hosted := &providertest.Fake{ProviderName: "openrouter", Unavailable: errors.New("401 unauthorized")}
+	m := switchProvider(t, newTestModel(t, providerOptions(hosted)), "openrouter")
+
+	if m.options.Provider.Name() != "ollama" {
+		t.Errorf("provider = %s, want ollama kept", m.options.Provider.Name())
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "401 unauthorized") {
+		t.Errorf("message = %q", got)
+	}
+}
+
+func TestProvider_ShowsCurrent(t *testing.T) {
+	m := newTestModel(t, providerOptions(nil))
+	m.handleCommand(&Command{Name: "provider"})
+	if got := lastMessage(&m).content; !strings.HasPrefix(got, "Current provider: ollama\n") {
+		t.Errorf("message = %q", got)
+	}
+
+	m.handleCommand(&Command{Name: "provider", Args: "Ollama"})
+	if got := lastMessage(&m).content; got != "Already using ollama." {
+		t.Errorf("message = %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"context"
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// PullStartedMsg carries the channel /pull reports its progress and result
+// on.
+type PullStartedMsg struct {
+	Ch <-chan tea.Msg
+}
+
+// PullProgressMsg reports how far /pull got.
+type PullProgressMsg struct {
+	Progress provider.PullProgress
+}
+
+// PullDoneMsg reports the end of a download started with /pull.
+type PullDoneMsg struct {
+	Model string
+	Err   error
+}
+
+// handlePull downloads a model, showing its progress in one message that
+// is updated as it goes.
+func handlePull(m *Model, args string) (tea.Model, tea.Cmd) {
+	puller, ok := m.options.Provider.(provider.Puller)
+	switch {
+	case args == "stop" && m.pullCancel != nil:
+		m.pullCancel()
+		return m, nil
+	case args == "" || args == "stop":
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Usage: /pull <model> | /pull stop")})
+	case !ok:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("%s can't download models.", m.options.Provider.Name())})
+	case m.pullCancel != nil:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Already downloading %s; /pull stop cancels it.", m.pulling)})
+	default:
+		ctx, cancel := context.WithCancel(context.Background())
+		m.pullCancel, m.pulling = cancel, args
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("Downloading %s...", args)})
+		m.pullMsg = len(m.messages) - 1
+		m.updateViewport()
+		model := args
+		return m, func() tea.Msg {
+			ch := make(chan tea.Msg, 1)
+			go func() {
+				err := puller.Pull(ctx, model, func(p provider.PullProgress) {
+					if p.Total > 0 {
+						// Drop byte counts the TUI is still behind on
+						select {
+						case ch <- PullProgressMsg{Progress: p}:
+						default:
+						}
+						return
+					}
+					ch <- PullProgressMsg{Progress: p}
+				})
+				ch <- PullDoneMsg{Model: model, Err: err}
+			}()
+			return PullStartedMsg{Ch: ch}
+		}
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// pullProgress describes how far a download got.
+func (m *Model) pullProgress(p provider.PullProgress) string {
+	if p.Total > 0 {
+		return m.tr.Sprintf("Downloading %s... %d%% (%.1f of %.1f GB)", m.pulling, p.Completed*100/p.Total, gigabytes(p.Completed), gigabytes(p.Total))
+	}
+	return m.tr.Sprintf("Downloading %s: %s", m.pulling, p.Status)
+}
+
+func gigabytes(n int64) float64 {
+	return float64(n) / 1e9
+}
+
+// handlePullDone reports the end of a download in place of its progress.
+func (m *Model) handlePullDone(msg PullDoneMsg) {
+	m.pullCh = nil
+	m.pullCancel()
+	m.pullCancel, m.pulling = nil, ""
+	var content string
+	switch {
+	case msg.Err == nil:
+		content = m.tr.Sprintf("Downloaded %s. Switch to it with /model %s", msg.Model, msg.Model)
+	case errors.Is(msg.Err, context.Canceled):
+		content = m.tr.Sprintf("Download of %s stopped.", msg.Model)
+	default:
+		content = m.tr.Sprintf("Download of %s failed: %v", msg.Model, msg.Err)
+	}
+	if m.pullMsg < len(m.messages) {
+		m.messages[m.pullMsg].content = content
+	} else {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	}
+	m.updateViewport()
+}
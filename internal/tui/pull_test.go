@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// pullingProvider downloads models instantly, reporting progress twice.
+type pullingProvider struct {
+	providertest.Fake
+	err error
+}
+
+func (p *pullingProvider) Pull(ctx context.Context, model string, progress func(provider.PullProgress)) error {
+	progress(provider.PullProgress{Status: "pulling manifest"})
+	progress(provider.PullProgress{Status: "pulling a3de86cd1c13", Completed: 1300000000, Total: 5200000000})
+	return p.err
+}
+
+// runPull runs /pull until it is done, returning the model and the progress
+// shown on the way.
+func runPull(t *testing.T, m Model, args string) (Model, []string) {
+	t.Helper()
+	_, cmd := m.handleCommand(&Command{Name: "pull", Args: args})
+	var shown []string
+	for cmd != nil {
+		newM, next := m.Update(cmd())
+		m = newM.(Model)
+		shown = append(shown, lastMessage(&m).content)
+		cmd = next
+	}
+	return m, shown
+}
+
+func TestPull_ShowsProgress(t *testing.T) {
+	m := New(Options{Provider: &pullingProvider{}, Model: "qwen3:8b"})
+	m.width, m.height, m.ready = 80, 24, true
+
+	m, shown := runPull(t, m, "qwen3:4b")
+	if len(shown) < 3 || shown[1] != "Downloading qwen3:4b: pulling manifest" {
+		t.Fatalf("progress = %q", shown)
+	}
+	want := "Downloaded qwen3:4b. Switch to it with /model qwen3:4b"
+	if got := shown[len(shown)-1]; got != want {
+		t.Errorf("final message = %q, want %q", got, want)
+	}
+	if m.pullCancel != nil {
+		t.Error("download still marked as running")
+	}
+
+	m.pulling = "qwen3:4b"
+	got := m.pullProgress(provider.PullProgress{Status: "pulling a3de86cd1c13", Completed: 1300000000, Total: 5200000000})
+	if want := "Downloading qwen3:4b... 25% (1.3 of 5.2 GB)"; got != want {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+}
+
+func TestPull_Failed(t *testing.T) {
+	m := New(Options{Provider: &pullingProvider{err: errors.New("file does not exist")}})
+	m, shown := runPull(t, m, "nosuchmodel")
+	if got := shown[len(shown)-1]; got != "Download of nosuchmodel failed: file does not exist" {
+		t.Errorf("final message = %q", got)
+	}
+	if m.pullCancel != nil || m.pulling != "" {
+		t.Error("download still marked as running")
+	}
+}
+
+func TestPull_NeedsPuller(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "lmstudio"}})
+	_, cmd := m.handleCommand(&Command{Name: "pull", Args: "qwen3:8b"})
+	if cmd != nil {
+		t.Error("download started with a provider that can't download")
+	}
+	if got := lastMessage(&m).content; got != "lmstudio can't download models." {
+		t.Errorf("message = %q", got)
+	}
+}
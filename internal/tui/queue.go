@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+)
+
+// QueueChangedMsg reports that requests to the provider were queued,
+// started or done.
+type QueueChangedMsg struct{}
+
+// waitForQueue waits for the next change of the request queue.
+func waitForQueue(q *queue.Queue) tea.Cmd {
+	if q == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		<-q.Changed()
+		return QueueChangedMsg{}
+	}
+}
+
+// queueIndicator shows in the status bar how many requests wait for the
+// provider; it is empty if none do.
+func (m *Model) queueIndicator() string {
+	if m.options.Queue == nil {
+		return ""
+	}
+	waiting := 0
+	for _, j := range m.options.Queue.Jobs() {
+		if !j.Running() {
+			waiting++
+		}
+	}
+	if waiting == 0 {
+		return ""
+	}
+	return " · " + m.tr.Sprintf("%d queued", waiting)
+}
+
+// handleQueue lists the requests running and waiting for the provider, or
+// cancels one.
+func handleQueue(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	q := m.options.Queue
+	if q == nil {
+		return reply(m.tr.T("Requests aren't queued: provider.parallel is 0, so they all go to the provider at once."))
+	}
+
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+	case fields[0] == "cancel" && len(fields) == 2:
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		if err != nil {
+			return reply(m.tr.T("Usage: /queue [cancel <n>]"))
+		}
+		if !q.Cancel(id) {
+			return reply(m.tr.Sprintf("No request #%d is running or waiting.", id))
+		}
+		return reply(m.tr.Sprintf("Cancelled #%d.", id))
+	default:
+		return reply(m.tr.T("Usage: /queue [cancel <n>]"))
+	}
+
+	jobs := q.Jobs()
+	if len(jobs) == 0 {
+		return reply(m.tr.T("No requests are running or waiting."))
+	}
+	var b strings.Builder
+	b.WriteString(m.tr.Sprintf("Requests to the provider, %d at a time:", q.Slots()))
+	t := m.now()
+	for _, j := range jobs {
+		b.WriteString("\n")
+		if j.Running() {
+			b.WriteString(m.tr.Sprintf("  #%d %s (%s), running for %s", j.ID, j.Label, j.Model, t.Sub(j.Started).Round(time.Second)))
+		} else {
+			b.WriteString(m.tr.Sprintf("  #%d %s (%s), waiting for %s", j.ID, j.Label, j.Model, t.Sub(j.Queued).Round(time.Second)))
+		}
+	}
+	b.WriteString("\n" + m.tr.T("Cancel one with /queue cancel <n>."))
+	return reply(b.String())
+}
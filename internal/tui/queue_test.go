@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+)
+
+func TestQueue_ListAndCancel(t *testing.T) {
+	stream := make(chan provider.StreamDelta)
+	q := queue.New(1)
+	p := q.Wrap(providertest.New(providertest.Reply{Stream: stream}, providertest.Text("Tea")))
+	m := New(Options{Provider: p, Model: "qwen3:8b", Queue: q})
+	m.width, m.height, m.ready = 80, 24, true
+
+	out, err := p.StreamChat(queue.WithLabel(context.Background(), "chat"), provider.ChatRequest{Model: "qwen3:8b"})
+	if err != nil {
+		t.Fatalf("StreamChat() error: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.Chat(queue.WithLabel(context.Background(), "title"), provider.ChatRequest{Model: "gemma3:1b"})
+		errc <- err
+	}()
+	for len(q.Jobs()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := m.queueIndicator(); got != " · 1 queued" {
+		t.Errorf("status = %q", got)
+	}
+	handleQueue(&m, "")
+	got := lastMessage(&m).content
+	for _, want := range []string{"1 at a time", "#1 chat (qwen3:8b), running for", "#2 title (gemma3:1b), waiting for"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("/queue = %q, want %q", got, want)
+		}
+	}
+
+	handleQueue(&m, "cancel 2")
+	if got := lastMessage(&m).content; got != "Cancelled #2." {
+		t.Errorf("/queue cancel = %q", got)
+	}
+	if err := <-errc; !errors.Is(err, queue.ErrCanceled) {
+		t.Errorf("title request error = %v", err)
+	}
+	handleQueue(&m, "cancel 2")
+	if got := lastMessage(&m).content; got != "No request #2 is running or waiting." {
+		t.Errorf("/queue cancel again = %q", got)
+	}
+
+	close(stream)
+	for range out {
+	}
+	handleQueue(&m, "")
+	if got := lastMessage(&m).content; got != "No requests are running or waiting." {
+		t.Errorf("/queue when idle = %q", got)
+	}
+}
+
+func TestQueue_Off(t *testing.T) {
+	m := New(Options{Provider: providertest.New(), Model: "qwen3:8b"})
+	m.width, m.height, m.ready = 80, 24, true
+	handleQueue(&m, "")
+	if got := lastMessage(&m).content; !strings.Contains(got, "provider.parallel is 0") {
+		t.Errorf("/queue = %q", got)
+	}
+	if m.queueIndicator() != "" {
+		t.Error("status shows a queue")
+	}
+}
@@ -0,0 +1,28 @@
+package tui
+
+import "strings"
+
+// showReleaseNotes shows the release notes of an update installed with
+// `stefanclaw --update`, at the first launch of the new version.
+func (m *Model) showReleaseNotes() {
+	if m.options.ReleaseNotes == nil || m.options.Version == "" || m.options.Version == "dev" {
+		return
+	}
+	notes, err := m.options.ReleaseNotes.Take(m.options.Version)
+	if err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Reading the release notes: %v", err)})
+		return
+	}
+	m.addReleaseNotes(strings.TrimPrefix(m.options.Version, "v"), notes)
+}
+
+// addReleaseNotes shows the markdown release notes of version, if any.
+func (m *Model) addReleaseNotes(version, notes string) {
+	if strings.TrimSpace(notes) == "" {
+		return
+	}
+	m.messages = append(m.messages,
+		displayMessage{role: "system", content: m.tr.Sprintf("What's new in v%s:", version)},
+		displayMessage{role: "notes", content: notes},
+	)
+}
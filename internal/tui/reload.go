@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/proxy"
+)
+
+// configPollInterval is how often config.yaml is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// ConfigReloadMsg carries the result of polling config.yaml. Config is nil
+// when the file has not changed since the last poll.
+type ConfigReloadMsg struct {
+	Config  *config.Config
+	ModTime time.Time
+	Err     error
+}
+
+// newMarkdownRenderer creates a glamour renderer for the given theme. "auto"
+// (or an empty theme) picks a style based on the terminal background; any
+// other value names a glamour standard style such as "dark" or "dracula".
+func newMarkdownRenderer(theme string, wrap int) (*glamour.TermRenderer, error) {
+	styleOpt := glamour.WithAutoStyle()
+	if theme != "" && theme != "auto" {
+		styleOpt = glamour.WithStandardStyle(theme)
+	}
+	return glamour.NewTermRenderer(styleOpt, glamour.WithWordWrap(wrap))
+}
+
+// snapshotConfig records the on-disk config as the baseline for detecting
+// edits, so runtime-only changes aren't overwritten by unrelated edits.
+func (m *Model) snapshotConfig() {
+	info, err := os.Stat(config.ConfigFile())
+	if err != nil {
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	m.configModTime = info.ModTime()
+	m.configBaseline = &cfg
+}
+
+// watchConfig schedules the next poll of config.yaml.
+func (m *Model) watchConfig() tea.Cmd {
+	last := m.configModTime
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg {
+		info, err := os.Stat(config.ConfigFile())
+		if err != nil || !info.ModTime().After(last) {
+			return ConfigReloadMsg{ModTime: last}
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return ConfigReloadMsg{ModTime: info.ModTime(), Err: err}
+		}
+		return ConfigReloadMsg{Config: &cfg, ModTime: info.ModTime()}
+	})
+}
+
+// applyConfig applies the safe settings that differ from the last seen
+// config and returns a description of each change.
+func (m *Model) applyConfig(cfg config.Config) []string {
+	old := config.Defaults()
+	if m.configBaseline != nil {
+		old = *m.configBaseline
+	}
+	m.configBaseline = &cfg
+
+	var changes []string
+	if cfg.Model.Default != old.Model.Default && cfg.Model.Default != "" {
+		m.options.Model = cfg.Model.Default
+		changes = append(changes, "model: "+cfg.Model.Default)
+	}
+	if cfg.Model.Tasks != old.Model.Tasks {
+		m.options.TaskModels = cfg.Model.Tasks
+		changes = append(changes, "task models")
+	}
+	if cfg.Proxy != old.Proxy {
+		if err := proxy.Set(cfg.Proxy.URL, cfg.Proxy.NoProxy); err != nil {
+			log.Warn("proxy not changed", "err", err)
+		} else {
+			changes = append(changes, "proxy")
+		}
+	}
+	if cfg.Language != old.Language && cfg.Language != "" {
+		m.options.Language = cfg.Language
+		m.setTranslator(cfg.Language)
+		if m.options.PromptAsm != nil {
+			m.options.SystemPrompt = m.options.PromptAsm.BuildSystemPromptWithLanguage(cfg.Language)
+		}
+		changes = append(changes, "language: "+cfg.Language)
+	}
+	if cfg.SystemPromptBudget() != old.SystemPromptBudget() && m.options.PromptAsm != nil {
+		m.options.PromptAsm.SetBudget(cfg.SystemPromptBudget())
+		m.options.SystemPrompt = m.options.PromptAsm.BuildSystemPromptWithLanguage(m.options.Language)
+		changes = append(changes, fmt.Sprintf("personality budget: %d tokens", cfg.SystemPromptBudget()))
+	}
+	if cfg.Heartbeat.Interval != old.Heartbeat.Interval {
+		if d, err := time.ParseDuration(cfg.Heartbeat.Interval); err == nil && d > 0 {
+			m.heartbeatInterval = d
+			changes = append(changes, "heartbeat interval: "+formatInterval(d))
+		}
+	}
+	if cfg.Heartbeat.Enabled != old.Heartbeat.Enabled {
+		m.heartbeatEnabled = cfg.Heartbeat.Enabled && !m.options.Privacy.DisableHeartbeat
+		changes = append(changes, fmt.Sprintf("heartbeat enabled: %t", cfg.Heartbeat.Enabled))
+	}
+	if cfg.Heartbeat.ActiveHours != old.Heartbeat.ActiveHours || cfg.Heartbeat.SkipIfActive != old.Heartbeat.SkipIfActive ||
+		cfg.Heartbeat.SkipOnBattery != old.Heartbeat.SkipOnBattery {
+		m.options.Heartbeat.ActiveHours = cfg.Heartbeat.ActiveHours
+		m.options.Heartbeat.SkipIfActive = cfg.Heartbeat.SkipIfActive
+		m.options.Heartbeat.SkipOnBattery = cfg.Heartbeat.SkipOnBattery
+		changes = append(changes, "heartbeat quiet rules")
+	}
+	if cfg.Heartbeat.AutoTune != old.Heartbeat.AutoTune {
+		m.options.Heartbeat.AutoTune = cfg.Heartbeat.AutoTune
+		changes = append(changes, fmt.Sprintf("heartbeat auto-tune: %t", cfg.Heartbeat.AutoTune))
+	}
+	if !slices.Equal(cfg.Heartbeat.Tools, old.Heartbeat.Tools) {
+		m.options.Heartbeat.Tools = cfg.Heartbeat.Tools
+		changes = append(changes, "heartbeat tools: "+strings.Join(cfg.Heartbeat.Tools, ", "))
+	}
+	if !reflect.DeepEqual(cfg.Heartbeat.Schedules, old.Heartbeat.Schedules) {
+		prev := m.options.Heartbeat.Schedules
+		m.options.Heartbeat.Schedules = cfg.Heartbeat.Schedules
+		m.planSchedules(prev)
+		changes = append(changes, fmt.Sprintf("heartbeat schedules: %d configured", len(cfg.Heartbeat.Schedules)))
+	}
+	if cfg.TUI.Theme != old.TUI.Theme {
+		if r, err := newMarkdownRenderer(cfg.TUI.Theme, 76); err == nil {
+			m.mdRenderer = r
+			m.rendered = nil
+			changes = append(changes, "theme: "+cfg.TUI.Theme)
+		}
+	}
+	if cfg.TUI.Colors != old.TUI.Colors || cfg.TUI.UserLabel != old.TUI.UserLabel || cfg.TUI.AssistantLabel != old.TUI.AssistantLabel {
+		applyPalette(cfg.TUI)
+		m.textarea.Prompt = inputPromptStyle.Render("> ")
+		m.spinner.Style = assistantLabelStyle
+		m.rendered = nil
+		changes = append(changes, "colors")
+	}
+	if cfg.TUI.ShowThinking != old.TUI.ShowThinking {
+		m.showThinking = cfg.TUI.ShowThinking
+		m.rendered = nil
+		changes = append(changes, fmt.Sprintf("show thinking: %t", cfg.TUI.ShowThinking))
+	}
+	if !reflect.DeepEqual(cfg.Sampling, old.Sampling) {
+		m.options.Sampling = cfg.Sampling
+		changes = append(changes, "sampling: "+cfg.Sampling.For(m.options.Model).String())
+	}
+	if cfg.Privacy != old.Privacy {
+		m.applyPrivacy(cfg.Privacy)
+		if old.Privacy.DisableHeartbeat && !cfg.Privacy.DisableHeartbeat {
+			m.heartbeatEnabled = cfg.Heartbeat.Enabled
+		}
+		changes = append(changes, fmt.Sprintf("privacy: web off %t, auto-memory off %t, heartbeat off %t",
+			cfg.Privacy.DisableWeb, cfg.Privacy.DisableAutoMemory, cfg.Privacy.DisableHeartbeat))
+	}
+	if !reflect.DeepEqual(cfg.Agent, old.Agent) {
+		m.options.Agent = cfg.Agent
+		m.buildTools()
+		changes = append(changes, fmt.Sprintf("agent: enabled %t, max steps %d", cfg.Agent.Enabled, cfg.Agent.MaxSteps))
+	}
+	if cfg.Reminders != old.Reminders {
+		m.options.Reminders = cfg.Reminders
+		changes = append(changes, fmt.Sprintf("reminders: phrase with model %t", cfg.Reminders.PhraseWithModel))
+	}
+	if !reflect.DeepEqual(cfg.Jobs, old.Jobs) {
+		prev := m.options.Jobs
+		m.options.Jobs = cfg.Jobs
+		m.planJobs(prev)
+		changes = append(changes, fmt.Sprintf("jobs: %d configured", len(cfg.Jobs)))
+	}
+	if !reflect.DeepEqual(cfg.Notify, old.Notify) {
+		m.options.Notify = cfg.Notify
+		m.options.Notifier = m.options.Notifier.WithConfig(cfg.Notify)
+		m.buildTools()
+		changes = append(changes, fmt.Sprintf("notify: %d webhook(s)", len(cfg.Notify.Webhooks)))
+	}
+	if cfg.Email != old.Email {
+		m.options.Mailer = m.options.Mailer.WithConfig(cfg.Email)
+		via := "off"
+		if cfg.Email.SMTPHost != "" {
+			via = "via " + cfg.Email.SMTPHost
+		}
+		changes = append(changes, "email: "+via)
+	}
+	if !reflect.DeepEqual(cfg.Calendar, old.Calendar) {
+		m.options.Calendar = cfg.Calendar
+		m.calendar = calendar.FromConfig(cfg.Calendar, m.options.Privacy)
+		m.buildTools()
+		changes = append(changes, fmt.Sprintf("calendar: %d source(s)", len(cfg.Calendar.Sources)))
+	}
+	if !reflect.DeepEqual(cfg.Home, old.Home) {
+		m.options.Home = m.options.Home.WithConfig(cfg.Home)
+		m.buildTools()
+		changes = append(changes, fmt.Sprintf("home assistant: %d entities, %d controllable", len(cfg.Home.Entities), len(cfg.Home.Control)))
+	}
+	if cfg.Voice != old.Voice {
+		m.options.Voice = cfg.Voice
+		changes = append(changes, fmt.Sprintf("voice: enabled %t", cfg.Voice.Enabled))
+	}
+	if cfg.Speech != old.Speech {
+		m.options.Speech = cfg.Speech
+		// A changed engine applies right away if speech is on
+		on := cfg.Speech.Enabled || (m.speaker != nil && cfg.Speech.Enabled == old.Speech.Enabled)
+		if err := m.setSpeech(on); err != nil {
+			changes = append(changes, fmt.Sprintf("speech: unavailable (%v)", err))
+		} else {
+			changes = append(changes, fmt.Sprintf("speech: on %t", on))
+		}
+	}
+	if !reflect.DeepEqual(cfg.Knowledge, old.Knowledge) {
+		m.options.Knowledge = cfg.Knowledge
+		m.setKnowledge()
+		if cfg.Knowledge.AutoRetrieve != old.Knowledge.AutoRetrieve {
+			m.kbAuto = cfg.Knowledge.AutoRetrieve
+		}
+		changes = append(changes, fmt.Sprintf("knowledge: %d dir(s), auto retrieval %t", len(cfg.Knowledge.Dirs), m.kbAuto))
+	}
+	if cfg.Settings.Autosave != old.Settings.Autosave {
+		m.options.Autosave = cfg.Settings.Autosave
+		changes = append(changes, fmt.Sprintf("autosave: %t", cfg.Settings.Autosave))
+	}
+	if cfg.Update.Channel != old.Update.Channel {
+		m.options.Update = cfg.Update
+		changes = append(changes, "update channel: "+cfg.Update.Channel)
+	}
+	return changes
+}
+
+// handleConfigReload processes a config poll result and schedules the next poll.
+func (m *Model) handleConfigReload(msg ConfigReloadMsg) tea.Cmd {
+	m.configModTime = msg.ModTime
+	cmds := []tea.Cmd{m.watchConfig()}
+
+	switch {
+	case msg.Err != nil:
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Config reload failed, keeping current settings: %v", msg.Err),
+		})
+		m.updateViewport()
+	case msg.Config != nil:
+		wasEnabled := m.heartbeatEnabled
+		oldInterval := m.heartbeatInterval
+		oldModel := m.options.Model
+		changes := m.applyConfig(*msg.Config)
+		if len(changes) == 0 {
+			break
+		}
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Config reloaded: %s", strings.Join(changes, ", ")),
+		})
+		m.updateViewport()
+		if m.heartbeatEnabled && (!wasEnabled || m.heartbeatInterval != oldInterval) {
+			cmds = append(cmds, m.scheduleHeartbeat())
+		}
+		if (len(m.options.Jobs) > 0 || len(m.options.Heartbeat.Schedules) > 0) && !m.jobsTicking {
+			cmds = append(cmds, m.scheduleJobCheck())
+		}
+		if m.calendar != nil && !m.calendarTicking {
+			m.calendarTicking = true
+			cmds = append(cmds, func() tea.Msg { return CalendarTickMsg{} })
+		}
+		if m.options.Model != oldModel {
+			cmds = append(cmds, m.detectCapabilities(), m.warmModel())
+		}
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+)
+
+// ReminderTickMsg signals it is time to look for due reminders.
+type ReminderTickMsg struct{}
+
+func (m *Model) scheduleReminderCheck() tea.Cmd {
+	return tea.Tick(m.options.CheckInterval, func(time.Time) tea.Msg {
+		return ReminderTickMsg{}
+	})
+}
+
+// checkReminders delivers due reminders and queues due tasks. A task runs as
+// soon as no response is streaming, one at a time.
+func (m *Model) checkReminders() tea.Cmd {
+	cmds := []tea.Cmd{m.scheduleReminderCheck()}
+	if m.streaming || m.pendingCall != nil {
+		return tea.Batch(cmds...)
+	}
+
+	due, err := m.options.ReminderStore.TakeDue(m.now())
+	if err != nil {
+		m.messages = append(m.messages, displayMessage{
+			role:    "error",
+			content: m.tr.Sprintf("Error reading reminders: %v", err),
+		})
+	}
+	var texts []string
+	for _, r := range due {
+		if r.Kind == reminder.KindTask {
+			m.dueTasks = append(m.dueTasks, r)
+			continue
+		}
+		content := "⏰ Reminder: " + r.Text
+		if late := m.now().Sub(r.Due); late > time.Minute {
+			content += fmt.Sprintf(" (due %s)", formatDue(r.Due, m.now()))
+		}
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		texts = append(texts, r.Text)
+		cmds = append(cmds, m.notify(notify.Event{Kind: notify.EventReminder, Title: "Reminder", Message: r.Text}))
+	}
+	m.updateViewport()
+
+	switch {
+	case len(texts) > 0 && m.options.Reminders.PhraseWithModel:
+		cmds = append(cmds, m.phraseReminders(texts), m.spinner.Tick)
+	case len(m.dueTasks) > 0:
+		task := m.dueTasks[0]
+		m.dueTasks = m.dueTasks[1:]
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Running scheduled task #%d.", task.ID),
+		})
+		cmds = append(cmds, m.sendMessage(task.Text))
+	}
+	return tea.Batch(cmds...)
+}
+
+// phraseReminders asks the model to deliver reminders in its own words. Like
+// a heartbeat, it runs outside the conversation and may not use tools that
+// need approval.
+func (m *Model) phraseReminders(texts []string) tea.Cmd {
+	m.streaming = true
+	m.waiting = true
+	m.streamContent = ""
+	m.agentSteps = 0
+	m.agentHeartbeat = true
+	m.turnEvent = "" // the reminders were already sent as notifications
+
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "reminder"))
+	m.streamCancelFn = cancel
+
+	prompt := "[Reminder] The user asked to be reminded now of: " + strings.Join(texts, "; ") + ". Remind them briefly and naturally."
+	if lang := m.options.Language; lang != "" && lang != "English" {
+		prompt += " Respond in " + lang + "."
+	}
+	var msgs []provider.Message
+	if sys := m.promptWithTools(m.heartbeatTools()); sys != "" {
+		msgs = append(msgs, provider.Message{Role: "system", Content: sys})
+	}
+	msgs = append(msgs, provider.Message{Role: "user", Content: prompt})
+
+	prov := m.options.Provider
+	native := m.nativeTools(m.heartbeatTools())
+	req := provider.ChatRequest{
+		Model:    m.options.Model,
+		Messages: msgs,
+		NumCtx:   m.currentNumCtx,
+		Options:  m.samplingOptions(),
+	}
+	return func() tea.Msg {
+		ch, err := native.StreamChat(ctx, prov, req)
+		if err != nil {
+			return StreamErrMsg{Err: err}
+		}
+		return StreamStartedMsg{Ch: ch}
+	}
+}
+
+// formatDue formats a due time relative to now: just the time for today,
+// otherwise with the date.
+func formatDue(due, now time.Time) string {
+	if due.Year() == now.Year() && due.YearDay() == now.YearDay() {
+		return due.Format("15:04")
+	}
+	return due.Format("Mon Jan 2 15:04")
+}
+
+func handleRemind(m *Model, args string) (tea.Model, tea.Cmd) {
+	text := strings.TrimSpace(args)
+	text = strings.TrimPrefix(text, "me ")
+	return m.addReminder(reminder.KindReminder, text, "/remind [me] <when> [to] <text>")
+}
+
+func handleSchedule(m *Model, args string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0 || fields[0] == "list":
+		m.listReminders()
+	case fields[0] == "cancel":
+		m.cancelReminder(fields[1:])
+	default:
+		return m.addReminder(reminder.KindTask, args, "/schedule <when> <prompt>")
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// addReminder parses "<when> [to] <text>" and stores a reminder or task.
+func (m *Model) addReminder(kind reminder.Kind, args, usage string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	if m.options.ReminderStore == nil {
+		return reply(m.tr.T("Reminders are not available."))
+	}
+	if args == "" {
+		return reply(m.tr.Sprintf("Usage: %s", usage) + "\n" + m.tr.T("Examples: in 2h, at 17:30, tomorrow at 9, on 2026-03-01 at 14:00"))
+	}
+
+	due, text, err := reminder.ParseWhen(args, m.now())
+	if err != nil {
+		return reply(m.tr.Sprintf("Could not schedule: %v", err))
+	}
+	text = strings.TrimSpace(strings.TrimPrefix(text, "to "))
+	if text == "" {
+		return reply(m.tr.Sprintf("Usage: %s", usage))
+	}
+
+	r, err := m.options.ReminderStore.Add(kind, text, due)
+	if err != nil {
+		return reply(m.tr.Sprintf("Error saving reminder: %v", err))
+	}
+	what := "Reminder"
+	if kind == reminder.KindTask {
+		what = "Task"
+	}
+	return reply(fmt.Sprintf("%s #%d set for %s: %s", what, r.ID, formatDue(r.Due, m.now()), r.Text))
+}
+
+func (m *Model) listReminders() {
+	if m.options.ReminderStore == nil {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Reminders are not available.")})
+		return
+	}
+	list, err := m.options.ReminderStore.List()
+	if err != nil {
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Error reading reminders: %v", err)})
+		return
+	}
+	if len(list) == 0 {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T("Nothing scheduled. Use /remind me in 2h to ... or /schedule tomorrow at 8 <prompt>."),
+		})
+		return
+	}
+	lines := []string{"Scheduled:"}
+	for _, r := range list {
+		lines = append(lines, fmt.Sprintf("  #%-3d %-16s %-8s %s", r.ID, formatDue(r.Due, m.now()), r.Kind, r.Text))
+	}
+	lines = append(lines, "", m.tr.T("Cancel with /schedule cancel <id>."))
+	m.messages = append(m.messages, displayMessage{role: "system", content: strings.Join(lines, "\n")})
+}
+
+func (m *Model) cancelReminder(args []string) {
+	reply := func(content string) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	}
+	if len(args) != 1 {
+		reply(m.tr.T("Usage: /schedule cancel <id>"))
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		reply(m.tr.T("Usage: /schedule cancel <id>"))
+		return
+	}
+	if m.options.ReminderStore == nil {
+		reply(m.tr.T("Reminders are not available."))
+		return
+	}
+	switch ok, err := m.options.ReminderStore.Remove(id); {
+	case err != nil:
+		reply(m.tr.Sprintf("Error cancelling #%d: %v", id, err))
+	case !ok:
+		reply(m.tr.Sprintf("No reminder #%d.", id))
+	default:
+		reply(m.tr.Sprintf("Cancelled #%d.", id))
+	}
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
+)
+
+func lastMessage(m *Model) displayMessage {
+	return m.messages[len(m.messages)-1]
+}
+
+func TestRemind_AddAndList(t *testing.T) {
+	store := reminder.NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	m := newTestModel(t, Options{ReminderStore: store, Now: (&testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}).Now})
+
+	handleRemind(&m, "me in 2h to stretch")
+	if got := lastMessage(&m).content; got != "Reminder #1 set for 16:00: stretch" {
+		t.Errorf("confirmation = %q", got)
+	}
+	handleSchedule(&m, "tomorrow at 8 summarize my memory")
+	if got := lastMessage(&m).content; got != "Task #2 set for Wed Mar 11 08:00: summarize my memory" {
+		t.Errorf("confirmation = %q", got)
+	}
+	handleRemind(&m, "me sometime to relax")
+	if got := lastMessage(&m).content; !strings.HasPrefix(got, "Could not schedule: unrecognized time") {
+		t.Errorf("bad time message = %q", got)
+	}
+
+	handleSchedule(&m, "")
+	list := lastMessage(&m).content
+	if !strings.Contains(list, "#1") || !strings.Contains(list, "stretch") || !strings.Contains(list, "task") {
+		t.Errorf("list = %q", list)
+	}
+
+	handleSchedule(&m, "cancel #1")
+	if got := lastMessage(&m).content; got != "Cancelled #1." {
+		t.Errorf("cancel = %q", got)
+	}
+	if rs, _ := store.List(); len(rs) != 1 || rs[0].ID != 2 {
+		t.Errorf("store after cancel = %+v", rs)
+	}
+}
+
+func TestReminderTick_DeliversDue(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: make(chan provider.StreamDelta)}}}
+	store := reminder.NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	m := newTestModel(t, Options{Provider: mp, ReminderStore: store, Now: (&testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}).Now})
+	store.Add(reminder.KindReminder, "stretch", m.now().Add(-2*time.Hour))
+	store.Add(reminder.KindTask, "what's the weather?", m.now().Add(-time.Minute))
+	store.Add(reminder.KindReminder, "later", m.now().Add(time.Hour))
+
+	newM, cmd := m.Update(ReminderTickMsg{})
+	model := newM.(Model)
+	collectMsgs(cmd)
+
+	var shown []string
+	for _, msg := range model.messages {
+		shown = append(shown, msg.role+": "+msg.content)
+	}
+	joined := strings.Join(shown, "\n")
+	for _, want := range []string{
+		"system: ⏰ Reminder: stretch (due 12:00)",
+		"system: Running scheduled task #2.",
+		"user: what's the weather?",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("messages missing %q:\n%s", want, joined)
+		}
+	}
+	if !model.streaming {
+		t.Error("due task should be sent to the model")
+	}
+	if rs, _ := store.List(); len(rs) != 1 || rs[0].Text != "later" {
+		t.Errorf("pending after tick = %+v", rs)
+	}
+}
+
+func TestReminderTick_WaitsWhileStreaming(t *testing.T) {
+	store := reminder.NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	m := newTestModel(t, Options{ReminderStore: store, Now: (&testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}).Now})
+	store.Add(reminder.KindReminder, "stretch", m.now().Add(-time.Minute))
+	m.streaming = true
+
+	newM, _ := m.Update(ReminderTickMsg{})
+	if len(newM.(Model).messages) != 0 {
+		t.Error("reminders should wait until the response finishes")
+	}
+	if rs, _ := store.List(); len(rs) != 1 {
+		t.Error("reminder should stay pending")
+	}
+}
+
+func TestReminderTick_PhraseWithModel(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: make(chan provider.StreamDelta)}}}
+	store := reminder.NewStore(filepath.Join(t.TempDir(), "reminders.json"))
+	m := newTestModel(t, Options{Provider: mp, ReminderStore: store, Now: (&testClock{t: time.Date(2026, 3, 10, 14, 0, 0, 0, time.Local)}).Now})
+	m.options.Reminders.PhraseWithModel = true
+	store.Add(reminder.KindReminder, "stretch", m.now())
+
+	newM, cmd := m.Update(ReminderTickMsg{})
+	collectMsgs(cmd)
+	if !newM.(Model).streaming {
+		t.Fatal("reminder should be phrased by the model")
+	}
+	msgs := mp.LastRequest().Messages
+	if last := msgs[len(msgs)-1].Content; !strings.Contains(last, "[Reminder]") || !strings.Contains(last, "stretch") {
+		t.Errorf("phrasing prompt = %q", last)
+	}
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/provider/retry"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+// maxResumes is how often a reply that breaks off for a passing reason is
+// continued without asking; after that, /continue or Enter does it.
+const maxResumes = 2
+
+// continuePrompt asks the model to go on with its reply that broke off.
+const continuePrompt = "Your previous reply was cut off. Continue it exactly where it stopped, without repeating anything or commenting on the interruption."
+
+// interrupt keeps the part of a reply that arrived before its stream
+// failed with err, so it can be continued. It reports whether the reply is
+// being continued right away, which happens when err may pass.
+func (m *Model) interrupt(err error) (tea.Cmd, bool) {
+	partial := m.streamContent
+	if partial == "" || m.heartbeatStream || errors.Is(err, context.Canceled) {
+		return nil, false
+	}
+	m.interrupted = partial
+	m.messages = append(m.messages, displayMessage{role: "assistant", content: partial})
+	if retry.Transient(err) && m.resumes < maxResumes {
+		m.resumes++
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.Sprintf("The reply broke off (%v); continuing where it stopped.", err)})
+		return m.resume(), true
+	}
+	return nil, false
+}
+
+// handleContinue continues the reply that broke off last.
+func handleContinue(m *Model, args string) (tea.Model, tea.Cmd) {
+	if m.interrupted == "" {
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("There's no reply that broke off to continue.")})
+		m.updateViewport()
+		return m, nil
+	}
+	m.resumes = 0
+	return m, m.resume()
+}
+
+// resume asks the model to go on with the interrupted reply. The partial
+// reply leaves the conversation and becomes the start of the streamed one,
+// so the two halves are shown and saved as one message.
+func (m *Model) resume() tea.Cmd {
+	partial := m.interrupted
+	m.interrupted = ""
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].role == "assistant" && m.messages[i].content == partial {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+			break
+		}
+	}
+
+	msgs := m.fitContext("")
+	msgs = append(msgs,
+		provider.Message{Role: "assistant", Content: partial},
+		provider.Message{Role: "user", Content: continuePrompt},
+	)
+	m.streaming = true
+	m.waiting = true
+	m.streamContent = partial
+	m.updateViewport()
+
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "chat"))
+	m.streamCancelFn = cancel
+	req := provider.ChatRequest{
+		Model:    m.options.Model,
+		Messages: msgs,
+		NumCtx:   m.currentNumCtx,
+		Options:  m.samplingOptions(),
+	}
+	prov, native := m.options.Provider, m.nativeTools(m.tools)
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		ch, err := native.StreamChat(ctx, prov, req)
+		if err != nil {
+			return StreamErrMsg{Err: err}
+		}
+		return StreamStartedMsg{Ch: ch, Resumed: true}
+	})
+}
+
+// settleInterrupted keeps the interrupted reply as it is once the
+// conversation moves on without continuing it.
+func (m *Model) settleInterrupted() {
+	reply := strings.TrimSpace(think.Strip(m.interrupted))
+	if reply != "" {
+		m.appendTranscript("assistant", reply+"\n\n[Reply broke off]")
+	}
+	m.interrupted = ""
+}
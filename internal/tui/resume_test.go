@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// runTurn feeds the messages of cmd back into m until the reply is done.
+func runTurn(m *Model, cmd tea.Cmd) {
+	for i := 0; cmd != nil && i < 50; i++ {
+		var next []tea.Cmd
+		for _, msg := range collectMsgs(cmd) {
+			newM, c := m.Update(msg)
+			*m = newM.(Model)
+			next = append(next, c)
+		}
+		cmd = tea.Batch(next...)
+		if !m.streaming {
+			break
+		}
+	}
+}
+
+func TestResume_ContinuesBrokenStream(t *testing.T) {
+	reset := fmt.Errorf("reading stream: %w", syscall.ECONNRESET)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Chunks: []string{"Green ", "tea "}, StreamErr: reset},
+		{Chunks: []string{"every ", "morning."}},
+	}}
+	m := newAgentModel(t, mp)
+
+	runTurn(&m, m.sendMessage("What do I drink?"))
+
+	reqs := mp.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want the question and the continuation", len(reqs))
+	}
+	msgs := reqs[1].Messages
+	if n := len(msgs); n < 2 || msgs[n-2].Role != "assistant" || msgs[n-2].Content != "Green tea " || msgs[n-1].Content != continuePrompt {
+		t.Errorf("continuation request ends with %+v", msgs[max(0, len(msgs)-2):])
+	}
+	assistant := 0
+	for _, dm := range m.messages {
+		if dm.role == "assistant" {
+			assistant++
+		}
+	}
+	if got := lastMessage(&m); assistant != 1 || got.content != "Green tea every morning." {
+		t.Errorf("last message = %+v with %d replies, want the halves stitched together", got, assistant)
+	}
+	if m.interrupted != "" || m.resumes != 0 {
+		t.Errorf("interrupted = %q, resumes = %d after the reply was done", m.interrupted, m.resumes)
+	}
+}
+
+func TestResume_OffersContinue(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Chunks: []string{"Green "}, StreamErr: errors.New("decoding chunk: bad JSON")},
+		providertest.Text("tea."),
+	}}
+	m := newAgentModel(t, mp)
+
+	runTurn(&m, m.sendMessage("What do I drink?"))
+	if len(mp.Requests()) != 1 {
+		t.Fatal("a reply that broke off for good should not be continued without asking")
+	}
+	if m.interrupted != "Green " || lastMessage(&m).role != "system" {
+		t.Fatalf("interrupted = %q, last message %+v", m.interrupted, lastMessage(&m))
+	}
+
+	// Enter on an empty line continues it
+	_, cmd := m.handleSubmit()
+	runTurn(&m, cmd)
+	if got := lastMessage(&m).content; got != "Green tea." {
+		t.Errorf("last message = %q, want the stitched reply", got)
+	}
+
+	m.handleCommand(&Command{Name: "continue"})
+	if got := lastMessage(&m).content; got != "There's no reply that broke off to continue." {
+		t.Errorf("/continue without a broken reply: %q", got)
+	}
+}
+
+func TestResume_MovingOnKeepsPartialReply(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Chunks: []string{"Green "}, StreamErr: errors.New("decoding chunk: bad JSON")},
+		providertest.Text("Fine."),
+	}}
+	m := newAgentModel(t, mp)
+
+	runTurn(&m, m.sendMessage("What do I drink?"))
+	runTurn(&m, m.sendMessage("Never mind."))
+
+	if m.interrupted != "" {
+		t.Errorf("interrupted = %q after moving on", m.interrupted)
+	}
+	msgs := mp.Requests()[1].Messages
+	if got := msgs[len(msgs)-2]; got.Role != "assistant" || got.Content != "Green " {
+		t.Errorf("the partial reply should stay in the conversation, got %+v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// samplingLayer is one source of sampling options.
+type samplingLayer struct {
+	source string
+	opts   provider.Options
+}
+
+// samplingLayers returns the sampling option layers for the current model in
+// increasing precedence: config defaults, per-model config, session, flags.
+func (m *Model) samplingLayers() []samplingLayer {
+	return m.samplingLayersFor(m.options.Model)
+}
+
+// samplingLayersFor returns the sampling option layers for model.
+func (m *Model) samplingLayersFor(model string) []samplingLayer {
+	var session provider.Options
+	if m.options.Session != nil && m.options.Session.Sampling != nil {
+		session = *m.options.Session.Sampling
+	}
+	return []samplingLayer{
+		{"config", m.options.Sampling.Options},
+		{"model", m.options.Sampling.Models[model]},
+		{"session", session},
+		{"flag", m.options.SamplingFlags},
+	}
+}
+
+// samplingOptions returns the effective sampling options for the next request.
+func (m *Model) samplingOptions() provider.Options {
+	return m.samplingOptionsFor(m.options.Model)
+}
+
+// samplingOptionsFor returns the effective sampling options for a request
+// to model.
+func (m *Model) samplingOptionsFor(model string) provider.Options {
+	var opts provider.Options
+	for _, l := range m.samplingLayersFor(model) {
+		opts = opts.Merge(l.opts)
+	}
+	return opts
+}
+
+func handleSampling(m *Model, args string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(args)
+	var content string
+
+	switch {
+	case len(fields) == 0:
+		content = m.samplingSummary()
+	case len(fields) == 1 && fields[0] == "reset":
+		content = m.tr.T("Session sampling overrides cleared.")
+		if err := m.setSessionSampling(provider.Options{}); err != nil {
+			content = m.tr.Sprintf("Error saving session: %v", err)
+		}
+	case len(fields) == 2:
+		var opts provider.Options
+		if m.options.Session != nil && m.options.Session.Sampling != nil {
+			opts = *m.options.Session.Sampling
+		}
+		if err := opts.Set(fields[0], fields[1]); err != nil {
+			content = m.tr.Sprintf("Error: %v", err)
+			break
+		}
+		content = m.tr.Sprintf("Sampling %s set to %s for this session.", fields[0], fields[1])
+		if err := m.setSessionSampling(opts); err != nil {
+			content = m.tr.Sprintf("Error saving session: %v", err)
+		}
+	default:
+		content = m.tr.T("Usage: /sampling [<option> <value>|reset]")
+	}
+
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// setSessionSampling stores opts as the current session's overrides.
+func (m *Model) setSessionSampling(opts provider.Options) error {
+	if m.options.Session == nil {
+		return fmt.Errorf("no active session")
+	}
+	m.options.Session.Sampling = nil
+	if !opts.IsZero() {
+		m.options.Session.Sampling = &opts
+	}
+	if m.options.SessionStore == nil {
+		return nil
+	}
+	return m.options.SessionStore.UpdateSampling(m.options.Session.ID, opts)
+}
+
+// samplingSummary lists each option's effective value and where it came from.
+func (m *Model) samplingSummary() string {
+	layers := m.samplingLayers()
+	var b strings.Builder
+	b.WriteString(m.tr.Sprintf("Sampling for %s:", m.options.Model) + "\n")
+	for _, k := range provider.OptionKeys {
+		value, source := "default", "provider"
+		for _, l := range layers {
+			if v := l.opts.Get(k); v != "" {
+				value, source = v, l.source
+			}
+		}
+		fmt.Fprintf(&b, "  %-15s %-8s (%s)\n", k, value, source)
+	}
+	b.WriteString(m.tr.T("Usage: /sampling <option> <value>|default, /sampling reset"))
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+)
+
+const scheduleUsage = "Usage: /heartbeat add <name> <interval|cron> [HH:MM-HH:MM]: <prompt>"
+
+// planSchedules computes the next run of each named heartbeat schedule.
+// Schedules that were already planned with the same settings keep their
+// time.
+func (m *Model) planSchedules(old []config.HeartbeatSchedule) {
+	next := make(map[string]time.Time, len(m.options.Heartbeat.Schedules))
+	for _, s := range m.options.Heartbeat.Schedules {
+		if i := slices.IndexFunc(old, func(o config.HeartbeatSchedule) bool { return o.Name == s.Name }); i >= 0 && reflect.DeepEqual(old[i], s) {
+			if t, ok := m.scheduleNext[s.Name]; ok {
+				next[s.Name] = t
+				continue
+			}
+		}
+		if when, err := heartbeat.ParseWhen(s.Schedule); err == nil {
+			next[s.Name] = when.Next(m.now())
+		}
+	}
+	m.scheduleNext = next
+}
+
+// checkSchedules starts the first named check-in that is due. A due
+// check-in waits while a reply streams; one that is due while heartbeats
+// are off or quiet is skipped until its next run.
+func (m *Model) checkSchedules() tea.Cmd {
+	t := m.now()
+	for _, s := range m.options.Heartbeat.Schedules {
+		due, ok := m.scheduleNext[s.Name]
+		if !ok || due.IsZero() || t.Before(due) {
+			continue
+		}
+		if m.streaming || m.pendingCall != nil {
+			return nil
+		}
+		when, _ := heartbeat.ParseWhen(s.Schedule)
+		m.scheduleNext[s.Name] = when.Next(t)
+
+		hours := s.ActiveHours
+		if hours == "" {
+			hours = m.options.Heartbeat.ActiveHours
+		}
+		if !m.heartbeatEnabled || m.options.Privacy.DisableHeartbeat || m.heartbeatQuiet(hours) != "" {
+			continue
+		}
+		text, err := heartbeat.RenderPrompt(s.Name, s.Prompt, t)
+		if err != nil {
+			m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Heartbeat %s: %v", s.Name, err)})
+			m.updateViewport()
+			continue
+		}
+		m.heartbeatPrompt = heartbeat.SchedulePrompt(s.Name, text, m.options.Language)
+		m.heartbeatName = s.Name
+		return m.triggerHeartbeat()
+	}
+	return nil
+}
+
+// heartbeatScheduleStatus names the heartbeat schedules, for /heartbeat.
+func (m *Model) heartbeatScheduleStatus() string {
+	var names []string
+	for _, s := range m.options.Heartbeat.Schedules {
+		names = append(names, fmt.Sprintf("%s (%s)", s.Name, s.Schedule))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "\nSchedules: " + strings.Join(names, ", ")
+}
+
+// handleHeartbeatSchedules handles /heartbeat list, add and remove.
+func handleHeartbeatSchedules(m *Model, sub, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+
+	switch sub {
+	case "list":
+		return reply(m.listSchedules())
+	case "remove":
+		schedules := m.options.Heartbeat.Schedules
+		i := slices.IndexFunc(schedules, func(s config.HeartbeatSchedule) bool { return s.Name == args })
+		if i < 0 {
+			return reply(m.tr.Sprintf("No heartbeat schedule named %q. See /heartbeat list.", args))
+		}
+		m.options.Heartbeat.Schedules = slices.Concat(schedules[:i], schedules[i+1:])
+		delete(m.scheduleNext, args)
+		return reply(m.tr.Sprintf("Removed heartbeat schedule %s.", args) + m.heartbeatChanged())
+	}
+
+	if m.options.Privacy.DisableHeartbeat {
+		return reply(heartbeatDisabledMsg)
+	}
+	s, err := parseSchedule(args, m.now())
+	if err != nil {
+		return reply(m.tr.Sprintf("Invalid schedule: %v\n%s", err, m.tr.T(scheduleUsage)))
+	}
+	if slices.ContainsFunc(m.options.Heartbeat.Schedules, func(o config.HeartbeatSchedule) bool { return o.Name == s.Name }) {
+		return reply(m.tr.Sprintf("A heartbeat schedule named %q already exists; remove it first.", s.Name))
+	}
+	m.options.Heartbeat.Schedules = append(slices.Clone(m.options.Heartbeat.Schedules), s)
+	when, _ := heartbeat.ParseWhen(s.Schedule)
+	next := when.Next(m.now())
+	if m.scheduleNext == nil {
+		m.scheduleNext = make(map[string]time.Time)
+	}
+	m.scheduleNext[s.Name] = next
+	content := m.tr.Sprintf("Added heartbeat schedule %s, next %s.", s.Name, formatDue(next, m.now())) + m.heartbeatChanged()
+	if !m.heartbeatEnabled {
+		content += "\nHeartbeats are off; turn them on with /heartbeat on."
+	}
+	model, _ := reply(content)
+	if m.jobsTicking {
+		return model, nil
+	}
+	return model, m.scheduleJobCheck()
+}
+
+// parseSchedule reads "<name> <interval|cron> [HH:MM-HH:MM]: <prompt>".
+func parseSchedule(args string, now time.Time) (config.HeartbeatSchedule, error) {
+	head, prompt, ok := strings.Cut(args, ": ")
+	fields := strings.Fields(head)
+	if !ok || len(fields) < 2 || strings.TrimSpace(prompt) == "" {
+		return config.HeartbeatSchedule{}, fmt.Errorf("missing name, schedule or prompt")
+	}
+	s := config.HeartbeatSchedule{Name: fields[0], Prompt: strings.TrimSpace(prompt)}
+	fields = fields[1:]
+	if last := fields[len(fields)-1]; len(fields) > 1 && strings.Contains(last, ":") {
+		if _, err := heartbeat.ParseHours(last); err != nil {
+			return config.HeartbeatSchedule{}, err
+		}
+		s.ActiveHours = last
+		fields = fields[:len(fields)-1]
+	}
+	s.Schedule = strings.Join(fields, " ")
+	if _, err := heartbeat.ParseWhen(s.Schedule); err != nil {
+		return config.HeartbeatSchedule{}, err
+	}
+	if _, err := heartbeat.RenderPrompt(s.Name, s.Prompt, now); err != nil {
+		return config.HeartbeatSchedule{}, err
+	}
+	return s, nil
+}
+
+// listSchedules describes the heartbeat schedules for /heartbeat list.
+func (m *Model) listSchedules() string {
+	if len(m.options.Heartbeat.Schedules) == 0 {
+		return m.tr.T("No heartbeat schedules. Add one with /heartbeat add, e.g.\n  /heartbeat add morning 0 8 * * *: Brief me on my day.")
+	}
+	lines := []string{m.tr.T("Heartbeat schedules:")}
+	for _, s := range m.options.Heartbeat.Schedules {
+		next := "never"
+		if t := m.scheduleNext[s.Name]; !t.IsZero() {
+			next = formatDue(t, m.now())
+		}
+		when := s.Schedule
+		if s.ActiveHours != "" {
+			when += " " + s.ActiveHours
+		}
+		lines = append(lines, fmt.Sprintf("  %-12s %-20s next %-16s %s", s.Name, when, next, s.Prompt))
+	}
+	if !m.heartbeatEnabled {
+		lines = append(lines, "", m.tr.T("Heartbeats are off; schedules run once you turn them on with /heartbeat on."))
+	}
+	lines = append(lines, "", m.tr.T("Remove one with /heartbeat remove <name>."))
+	return strings.Join(lines, "\n")
+}
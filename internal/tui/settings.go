@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
+)
+
+// persistSettings writes the runtime model, language and heartbeat settings
+// to config.yaml and the language to USER.md. Only the settings that differ
+// from config.yaml are written, so "language: auto" stays unless the
+// language was changed.
+func (m *Model) persistSettings() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	settings := m.heartbeatSettings(cfg)
+	if m.options.Model != cfg.Model.Default {
+		settings = append(settings, config.Setting{Key: "model.default", Value: m.options.Model})
+	}
+	if m.options.Language != cfg.Language {
+		settings = append(settings, config.Setting{Key: "language", Value: m.options.Language})
+	}
+	if err := config.Set(settings...); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+	if m.options.WatchConfig {
+		m.snapshotConfig()
+	}
+
+	if m.options.PromptAsm != nil && m.options.Language != "" {
+		if err := m.options.PromptAsm.SetUserField("Language", m.options.Language); err != nil {
+			return fmt.Errorf("updating USER.md: %w", err)
+		}
+	}
+	m.settingsDirty = false
+	return nil
+}
+
+// settingsChanged records a runtime settings change. With autosave enabled the
+// settings are written immediately; otherwise a hint about /save is returned
+// for appending to the confirmation message.
+func (m *Model) settingsChanged() string {
+	m.settingsDirty = true
+	if !m.options.Autosave {
+		return m.tr.T(" (use /save to keep this setting)")
+	}
+	if err := m.persistSettings(); err != nil {
+		return m.tr.Sprintf(" (not saved: %v)", err)
+	}
+	return ""
+}
+
+// heartbeatChanged saves a runtime heartbeat change to config.yaml right
+// away, so /heartbeat survives restarts without /save. Only the heartbeat
+// settings are written unless autosave is on. The result is appended to the
+// confirmation message.
+func (m *Model) heartbeatChanged() string {
+	if m.options.Autosave {
+		return m.settingsChanged()
+	}
+	cfg, err := config.Load()
+	if err == nil {
+		err = config.Set(m.heartbeatSettings(cfg)...)
+	}
+	if err != nil {
+		return m.tr.Sprintf(" (not saved: %v)", err)
+	}
+	if m.options.WatchConfig {
+		m.snapshotConfig()
+	}
+	return ""
+}
+
+// heartbeatSettings returns the runtime heartbeat settings that differ
+// from cfg, as loaded from config.yaml.
+func (m *Model) heartbeatSettings(cfg config.Config) []config.Setting {
+	var settings []config.Setting
+	if m.heartbeatEnabled != cfg.Heartbeat.Enabled {
+		settings = append(settings, config.Setting{Key: "heartbeat.enabled", Value: m.heartbeatEnabled})
+	}
+	if d, err := time.ParseDuration(cfg.Heartbeat.Interval); err != nil || d != m.heartbeatInterval {
+		settings = append(settings, config.Setting{Key: "heartbeat.interval", Value: formatInterval(m.heartbeatInterval)})
+	}
+	if !slices.Equal(m.options.Heartbeat.Schedules, cfg.Heartbeat.Schedules) {
+		settings = append(settings, config.Setting{Key: "heartbeat.schedules", Value: m.options.Heartbeat.Schedules})
+	}
+	return settings
+}
+
+// formatInterval renders a duration in its shortest config form, e.g. "2h"
+// rather than "2h0m0s".
+func formatInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// setTranslator switches the interface text to language and re-renders the
+// messages, whose labels are translated too.
+func (m *Model) setTranslator(language string) {
+	m.tr = i18n.For(language)
+	m.textarea.Placeholder = m.tr.T(inputPlaceholder)
+	m.rendered = nil
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+)
+
+// exchangesKept is how many of the latest user and assistant messages
+// Shutdown extracts memories from.
+const exchangesKept = 10
+
+// recordExchange keeps a message of the conversation for Shutdown. Tool
+// calls and results are left out.
+func (m *Model) recordExchange(role, content string) {
+	if (role != "user" && role != "assistant") || tools.HasCall(content) || tools.IsResult(content) {
+		return
+	}
+	m.exchanges = append(m.exchanges, provider.Message{Role: role, Content: content})
+	if len(m.exchanges) > exchangesKept {
+		m.exchanges = m.exchanges[len(m.exchanges)-exchangesKept:]
+	}
+}
+
+// Shutdown finishes a session once the TUI has quit, e.g. with /quit or on
+// SIGTERM. It stops a reply still streaming and saves what arrived of it,
+// adds facts worth remembering from the last exchanges to memory, saves
+// changed settings if autosave is on, and flushes the transcript. Memory
+// extraction stops when ctx is done; progress is reported to out.
+func (m Model) Shutdown(ctx context.Context, out io.Writer) error {
+	var errs []error
+	if m.streamCancelFn != nil {
+		m.streamCancelFn()
+	}
+	if reply := think.Strip(m.streamContent); m.streaming && reply != "" && !m.heartbeatStream {
+		m.appendTranscript("assistant", reply+"\n\n[Reply stopped at exit]")
+	}
+	m.settleInterrupted()
+
+	if m.options.MemoryStore != nil && !m.options.Privacy.DisableAutoMemory && len(m.exchanges) > 0 {
+		fmt.Fprintln(out, m.tr.T("Remembering this conversation (ctrl+c to skip)..."))
+		facts, err := memory.NewExtractor(m.options.Provider, m.taskModel(m.options.TaskModels.Memory)).Extract(queue.WithLabel(ctx, "memory"), m.exchanges)
+		switch {
+		case ctx.Err() != nil:
+			log.Info("memory extraction at exit skipped", "err", ctx.Err())
+		case err != nil:
+			errs = append(errs, fmt.Errorf("extracting memories: %w", err))
+		case len(facts) > 0:
+			if err := m.options.MemoryStore.Append(facts); err != nil {
+				errs = append(errs, fmt.Errorf("saving memories: %w", err))
+			} else {
+				log.Info("memories extracted at exit", "facts", len(facts))
+			}
+		}
+	}
+
+	if m.settingsDirty && m.options.Autosave {
+		if err := m.persistSettings(); err != nil {
+			errs = append(errs, fmt.Errorf("saving settings: %w", err))
+		}
+	}
+
+	if err := m.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("saving the conversation: %w", err))
+	}
+	return errors.Join(errs...)
+}
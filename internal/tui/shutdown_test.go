@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+func TestShutdown(t *testing.T) {
+	dir := t.TempDir()
+	store := session.NewFileStore(filepath.Join(dir, "sessions"))
+	sess, _ := store.Create("Test", "test-model")
+	mem := memory.NewStore(filepath.Join(dir, "MEMORY.md"))
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("- Drinks green tea")}}
+	m := New(Options{Provider: mp, Model: "test-model", SessionStore: store, Session: sess, MemoryStore: mem})
+
+	m.appendTranscript("user", "I only drink green tea")
+	m.appendTranscript("assistant", "Noted!")
+	m.appendTranscript("user", "Tell me about oolong")
+	cancelled := false
+	m.streaming = true
+	m.streamContent = "Oolong is"
+	m.streamCancelFn = func() { cancelled = true }
+
+	if err := m.Shutdown(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !cancelled {
+		t.Error("stream not cancelled")
+	}
+	transcript, _ := store.LoadTranscript(sess.ID)
+	if len(transcript) != 4 || !strings.HasPrefix(transcript[3].Content, "Oolong is") {
+		t.Errorf("transcript = %v, want the partial reply last", transcript)
+	}
+	if entries, _ := mem.Entries(); len(entries) != 1 || !strings.Contains(entries[0], "green tea") {
+		t.Errorf("memory = %v, want the extracted fact", entries)
+	}
+}
+
+func TestShutdown_AutoMemoryDisabled(t *testing.T) {
+	mem := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("- A fact")}}
+	m := New(Options{Provider: mp, Model: "test-model", MemoryStore: mem, Privacy: config.PrivacyConfig{DisableAutoMemory: true}})
+	m.appendTranscript("user", "Hello")
+
+	if err := m.Shutdown(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if entries, _ := mem.Entries(); len(entries) != 0 {
+		t.Errorf("memory = %v, want nothing extracted", entries)
+	}
+}
+
+func TestShutdown_MemoryTaskModel(t *testing.T) {
+	mem := memory.NewStore(filepath.Join(t.TempDir(), "MEMORY.md"))
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("NONE")}}
+	m := New(Options{Provider: mp, Model: "big-model", TaskModels: config.TaskModelsConfig{Memory: "small-model"}, MemoryStore: mem})
+	m.appendTranscript("user", "Hello")
+
+	if err := m.Shutdown(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := mp.LastRequest().Model; got != "small-model" {
+		t.Errorf("extraction model = %q, want small-model", got)
+	}
+}
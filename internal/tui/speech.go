@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/speech"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+func handleSpeak(m *Model, args string) (tea.Model, tea.Cmd) {
+	var content string
+	switch strings.TrimSpace(args) {
+	case "":
+		state := "off"
+		if m.speaker != nil {
+			state = "on"
+		}
+		content = m.tr.Sprintf("Speech is %s. Usage: /speak on|off", m.tr.T(state))
+	case "on":
+		if err := m.setSpeech(true); err != nil {
+			content = m.tr.Sprintf("Speech unavailable: %v", err)
+		} else {
+			content = m.tr.T("Replies will be read aloud. Ctrl+C stops the current one.")
+		}
+	case "off":
+		m.setSpeech(false)
+		content = m.tr.T("Speech off.")
+	default:
+		content = m.tr.T("Usage: /speak on|off")
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.updateViewport()
+	return m, nil
+}
+
+// setSpeech starts or stops reading replies aloud.
+func (m *Model) setSpeech(on bool) error {
+	m.speaker.Stop()
+	m.speaker = nil
+	if !on {
+		return nil
+	}
+	s, err := speech.New(m.options.Speech)
+	if err != nil {
+		return err
+	}
+	m.speaker = s
+	return nil
+}
+
+// speakStream reads the sentences of the streaming reply that are complete
+// and not yet spoken; final speaks the rest. Heartbeat check-ins are only
+// spoken once they turn out to have something to say.
+func (m *Model) speakStream(final bool) {
+	if m.speaker == nil || (m.heartbeatStream && !final) {
+		return
+	}
+	text := speech.Speakable(think.Strip(m.streamContent))
+	if !strings.HasPrefix(text, m.spoken) {
+		// Formatting completed after a sentence was spoken; move on
+		m.spoken = text[:speech.SentenceEnd(text)]
+	}
+	rest := text[len(m.spoken):]
+	n := len(rest)
+	if !final {
+		n = speech.SentenceEnd(rest)
+	}
+	if n > 0 {
+		m.speaker.Say(rest[:n])
+		m.spoken += rest[:n]
+	}
+	if final {
+		m.spoken = ""
+		if err := m.speaker.Err(); err != nil {
+			m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Speech failed: %v", err)})
+		}
+	}
+}
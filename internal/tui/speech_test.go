@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestSpeak_ReadsSentencesWhileStreaming(t *testing.T) {
+	// A fake espeak-ng that appends every utterance to a file
+	dir := t.TempDir()
+	out := filepath.Join(dir, "spoken")
+	script := "#!/bin/sh\n{ cat; echo; } >> " + out + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "espeak-ng"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Speech: config.SpeechConfig{Backend: "espeak"}})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	handleSpeak(&m, "on")
+	if m.speaker == nil {
+		t.Fatalf("/speak on failed: %+v", lastMessage(&m))
+	}
+
+	for _, delta := range []string{"Sure thing. Run", " `make`:\n```sh\nmake\n```\nThat's", " all"} {
+		newM, _ := m.Update(StreamDeltaMsg{Content: delta})
+		m = newM.(Model)
+	}
+	m.speakStream(true)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.speaker.Speaking() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got, _ := os.ReadFile(out)
+	want := "Sure thing.\nRun make:\nThat's all\n"
+	if string(got) != want {
+		t.Errorf("spoken = %q, want %q", got, want)
+	}
+
+	handleSpeak(&m, "off")
+	if m.speaker != nil {
+		t.Error("/speak off should stop reading replies")
+	}
+	handleSpeak(&m, "")
+	if got := lastMessage(&m); !strings.Contains(got.content, "Speech is off") {
+		t.Errorf("last message = %+v, want the state", got)
+	}
+}
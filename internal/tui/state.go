@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"slices"
+
+	"github.com/stefanclaw/stefanclaw/internal/chat"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// restoreState picks up the runtime state saved with the session, so that a
+// reopened session keeps the context size it last ran with instead of
+// reloading the model at the smallest tier and growing again.
+func (m *Model) restoreState() {
+	if m.options.Session == nil || m.options.Session.State == nil {
+		return
+	}
+	m.state = *m.options.Session.State
+	if n := m.state.NumCtx; slices.Contains(ctxTiers, n) && n <= m.ctxLimit() {
+		m.currentNumCtx = n
+	}
+}
+
+// pipeline returns the request pipeline shared with pipe mode and the chat
+// bridges, set up with the current settings.
+func (m *Model) pipeline() *chat.Pipeline {
+	return &chat.Pipeline{
+		Prompt:   m.options.PromptAsm,
+		Language: m.options.Language,
+		Fetch:    m.fetchClient,
+		Usage:    m.options.Usage,
+	}
+}
+
+// saveState stores the runtime state with the current session, if any.
+func (m *Model) saveState() {
+	if m.options.Session == nil || m.options.SessionStore == nil {
+		return
+	}
+	if err := m.options.SessionStore.UpdateState(m.options.Session.ID, m.state); err != nil {
+		log.Warn("saving session state failed", "session", m.options.Session.ID, "err", err)
+	}
+}
+
+// recordUsage adds the usage of a reply of the chat model to the usage log.
+func (m *Model) recordUsage(u provider.Usage) {
+	id := ""
+	if m.options.Session != nil {
+		id = m.options.Session.ID
+	}
+	if err := m.pipeline().RecordUsage(id, m.options.Model, u); err != nil {
+		log.Warn("recording usage failed", "err", err)
+	}
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+func TestState_SavedAndResumed(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	sess, _ := store.Create("Test", "test-model")
+	mp := providertest.New(providertest.Text("They talked at length."))
+	m := New(Options{Provider: mp, Model: "test-model", MaxNumCtx: 32768, SessionStore: store, Session: sess})
+	m.currentNumCtx = 16384
+	for i := range 7 {
+		content := "Hi"
+		if i < 4 {
+			content = words(3500)
+		}
+		m.messages = append(m.messages,
+			displayMessage{role: "user", content: content},
+			displayMessage{role: "assistant", content: "ok"})
+		m.appendTranscript("user", content)
+		m.appendTranscript("assistant", "ok")
+	}
+	m.streaming = true
+
+	newM, _ := m.Update(StreamDoneMsg{Usage: &provider.Usage{PromptTokens: 9000, CompletionTokens: 20}})
+	m = newM.(Model)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := store.Get(sess.ID)
+	st := got.State
+	if st == nil || st.Summary != "They talked at length." || st.Compacted != 8 || st.NumCtx != 4096 || st.LastUsage.PromptTokens != 9000 || st.CompletionTokens != 20 {
+		t.Fatalf("saved state = %+v", st)
+	}
+
+	transcript, _ := store.LoadTranscript(sess.ID)
+	m = New(Options{Provider: mp, Model: "test-model", MaxNumCtx: 32768, SessionStore: store, Session: got, History: got.Resume(transcript)})
+	if len(m.messages) != 7 || m.messages[0].role != "summary" || m.messages[1].content != "Hi" {
+		t.Errorf("resumed with %d messages, want the summary and the 6 kept", len(m.messages))
+	}
+	if stats := m.promptStats(); !strings.Contains(stats, "The last request took 9000 tokens") {
+		t.Errorf("prompt stats lack the last usage:\n%s", stats)
+	}
+}
+
+func TestState_RestoresContextSize(t *testing.T) {
+	sess := &session.Session{ID: "s", State: &session.State{NumCtx: 16384}}
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", MaxNumCtx: 32768, Session: sess})
+	if m.currentNumCtx != 16384 {
+		t.Errorf("context = %d, want the session's 16384", m.currentNumCtx)
+	}
+
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", MaxNumCtx: 8192, Session: sess})
+	if m.currentNumCtx != 4096 {
+		t.Errorf("context = %d, want the smallest tier when the saved one exceeds max_num_ctx", m.currentNumCtx)
+	}
+}
+
+func TestPipeline_PicksUpNewMemory(t *testing.T) {
+	dir := t.TempDir()
+	memFile := filepath.Join(dir, "MEMORY.md")
+	asm := prompt.NewAssembler(dir)
+	asm.SetSectionPath(prompt.SectionMemory, memFile)
+	asm.LoadFiles()
+	mp := providertest.New(providertest.Text("Noted."))
+	m := New(Options{
+		Provider:     mp,
+		Model:        "test-model",
+		PromptAsm:    asm,
+		SystemPrompt: asm.BuildSystemPromptWithLanguage(""),
+		MemoryStore:  memory.NewStore(memFile),
+	})
+
+	m.handleCommand(&Command{Name: "remember", Args: "Has a cat named Tom"})
+	collectMsgs(m.sendMessage("What's my cat called?"))
+	if sys := mp.LastRequest().Messages[0].Content; !strings.Contains(sys, "Has a cat named Tom") {
+		t.Errorf("system prompt lacks the fact remembered this session:\n%s", sys)
+	}
+}
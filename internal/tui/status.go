@@ -2,8 +2,27 @@ package tui
 
 import "fmt"
 
-// StatusBar renders the top status bar.
-func StatusBar(model, providerName string, width int) string {
-	text := fmt.Sprintf("  stefanclaw - %s via %s  ", model, providerName)
-	return statusBarStyle.Width(width).Render(text)
+// StatusBar renders the top status bar. A non-empty profile is shown next to
+// the app name and a non-empty heartbeat state after the model.
+func StatusBar(model, providerName, profile, heartbeat string, width int) string {
+	name := "stefanclaw"
+	if profile != "" {
+		name += " [" + profile + "]"
+	}
+	text := fmt.Sprintf("  %s - %s via %s", name, model, providerName)
+	if heartbeat != "" {
+		text += " · " + heartbeat
+	}
+	return statusBarStyle.Width(width).Render(text + "  ")
+}
+
+// heartbeatStatus describes the heartbeat for the status bar.
+func (m *Model) heartbeatStatus() string {
+	if !m.heartbeatEnabled {
+		return m.tr.T("heartbeat off")
+	}
+	if t := m.now(); t.Before(m.heartbeatSnoozed) {
+		return m.tr.Sprintf("heartbeat snoozed until %s", formatDue(m.heartbeatSnoozed, t))
+	}
+	return m.tr.Sprintf("heartbeat every %s", formatInterval(m.heartbeatInterval))
 }
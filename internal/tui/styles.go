@@ -1,12 +1,16 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
 
 var (
 	// Colors
 	primaryColor   = lipgloss.Color("#7C3AED")
 	secondaryColor = lipgloss.Color("#6B7280")
 	successColor   = lipgloss.Color("#10B981")
+	errorColor     = lipgloss.Color("#EF4444")
 
 	// Status bar
 	statusBarStyle = lipgloss.NewStyle().
@@ -27,7 +31,46 @@ var (
 			Foreground(secondaryColor).
 			Italic(true)
 
+	errorMsgStyle = lipgloss.NewStyle().
+			Foreground(errorColor)
+
 	// Input area
 	inputPromptStyle = lipgloss.NewStyle().
 				Foreground(primaryColor)
 )
+
+// applyPalette rebuilds the package styles from the tui config. Empty colors
+// keep the built-in defaults, so a zero TUIConfig restores the stock look.
+func applyPalette(t config.TUIConfig) {
+	primaryColor = colorOr(t.Colors.Primary, "#7C3AED")
+	secondaryColor = colorOr(t.Colors.Secondary, "#6B7280")
+	successColor = colorOr(t.Colors.Success, "#10B981")
+	errorColor = colorOr(t.Colors.Error, "#EF4444")
+
+	statusBarStyle = statusBarStyle.Background(primaryColor)
+	userLabelStyle = labelStyle(t.UserLabel, primaryColor)
+	assistantLabelStyle = labelStyle(t.AssistantLabel, successColor)
+	systemMsgStyle = systemMsgStyle.Foreground(secondaryColor)
+	errorMsgStyle = errorMsgStyle.Foreground(errorColor)
+	inputPromptStyle = inputPromptStyle.Foreground(primaryColor)
+}
+
+// labelStyle builds a role label style, falling back to the palette color.
+// A zero LabelStyle keeps the default bold label.
+func labelStyle(l config.LabelStyle, fallback lipgloss.Color) lipgloss.Style {
+	if l == (config.LabelStyle{}) {
+		l.Bold = true
+	}
+	return lipgloss.NewStyle().
+		Foreground(colorOr(l.Color, string(fallback))).
+		Bold(l.Bold).
+		Italic(l.Italic).
+		Underline(l.Underline)
+}
+
+func colorOr(c, def string) lipgloss.Color {
+	if c == "" {
+		return lipgloss.Color(def)
+	}
+	return lipgloss.Color(c)
+}
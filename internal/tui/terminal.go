@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/terminal"
+)
+
+// TerminalDoneMsg carries the captured terminal text, ready to send.
+type TerminalDoneMsg struct {
+	Prompt string
+	Err    error
+}
+
+const defaultTerminalQuestion = "What happened here? If a command failed, explain why and how to fix it."
+
+// handleTerminal captures the other tmux pane (or, with "history" or
+// outside tmux, the shell history) and asks about it.
+func handleTerminal(m *Model, args string) (tea.Model, tea.Cmd) {
+	cfg := m.options.Agent.Terminal
+	if !cfg.Enabled {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T("Terminal capture is off. Set agent.terminal.enabled: true in config.yaml to let stefanclaw read your last tmux pane or shell history."),
+		})
+		m.updateViewport()
+		return m, nil
+	}
+
+	source := ""
+	if first, rest, _ := strings.Cut(args, " "); first == terminal.SourcePane || first == terminal.SourceHistory {
+		source, args = first, strings.TrimSpace(rest)
+	}
+	question := args
+	if question == "" {
+		question = defaultTerminalQuestion
+	}
+	return m, func() tea.Msg {
+		snap, err := terminal.Capture(context.Background(), source, cfg.Lines)
+		if err != nil {
+			return TerminalDoneMsg{Err: err}
+		}
+		what := "the output in my terminal"
+		if snap.Source == terminal.SourceHistory {
+			what = "my recent shell history"
+		}
+		return TerminalDoneMsg{Prompt: fmt.Sprintf("This is %s:\n\n```\n%s\n```\n\n%s", what, snap.Text, question)}
+	}
+}
+
+// handleTerminalDone sends the captured text to the model, unless a reply
+// is still streaming.
+func (m *Model) handleTerminalDone(msg TerminalDoneMsg) tea.Cmd {
+	switch {
+	case msg.Err != nil:
+		m.messages = append(m.messages, displayMessage{role: "error", content: fmt.Sprintf("/terminal failed: %v", msg.Err)})
+	case m.streaming:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Still answering; try /terminal again when the reply is done.")})
+	default:
+		return m.sendMessage(msg.Prompt)
+	}
+	m.updateViewport()
+	return nil
+}
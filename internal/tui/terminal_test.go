@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestTerminal_SendsHistory(t *testing.T) {
+	hist := filepath.Join(t.TempDir(), "history")
+	os.WriteFile(hist, []byte("make deploy\n"), 0o600)
+	t.Setenv("HISTFILE", hist)
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("TMUX", "")
+
+	ch := make(chan provider.StreamDelta)
+	close(ch)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// Off by default
+	if _, cmd := handleTerminal(&m, ""); cmd != nil {
+		t.Fatal("/terminal should not capture anything while disabled")
+	}
+	if got := lastMessage(&m); !strings.Contains(got.content, "agent.terminal.enabled") {
+		t.Errorf("last message = %+v, want how to enable it", got)
+	}
+
+	m.options.Agent.Terminal = config.TerminalConfig{Enabled: true, Lines: 10}
+	_, cmd := handleTerminal(&m, "history why did it fail?")
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, cmd := m.Update(msgs[0])
+	m = newM.(Model)
+	if !m.streaming {
+		t.Fatal("the history should be sent to the model")
+	}
+	collectMsgs(cmd)
+	sent := mp.LastRequest().Messages[len(mp.LastRequest().Messages)-1].Content
+	if !strings.Contains(sent, "make deploy") || !strings.HasSuffix(sent, "why did it fail?") {
+		t.Errorf("sent prompt = %q, want the history and the question", sent)
+	}
+}
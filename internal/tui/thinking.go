@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stefanclaw/stefanclaw/internal/think"
+)
+
+// The reasoning models write in <think> tags before their answer is shown
+// apart from it, dimmed, and collapsed to one line unless /think expands
+// it. It is kept out of requests, transcripts and memory extraction.
+
+// addThinking shows the reasoning of the reply about to be added.
+func (m *Model) addThinking(thoughts string) {
+	if thoughts != "" {
+		m.messages = append(m.messages, displayMessage{role: "thinking", content: thoughts})
+	}
+}
+
+// renderThinking renders reasoning; done is false while it is still
+// streaming.
+func (m *Model) renderThinking(thoughts string, done bool) string {
+	style := systemMsgStyle.Faint(true).Italic(true)
+	words := len(strings.Fields(thoughts))
+	header := m.tr.Sprintf("💭 Thought it over (%d words)", words)
+	if !done {
+		header = m.tr.Sprintf("💭 Thinking… (%d words)", words)
+	}
+	if !m.showThinking {
+		return style.Render(header + " · " + m.tr.T("/think to show"))
+	}
+	return style.Render(header) + "\n" + style.Width(m.width).Render(thoughts)
+}
+
+// renderAssistant renders a reply, with the reasoning of one that broke off
+// while thinking shown apart.
+func (m *Model) renderAssistant(content string, markdown bool) string {
+	label := assistantLabelStyle.Render(m.tr.T("Assistant: "))
+	return m.withThinking(content, func(answer string) string {
+		if markdown {
+			return label + m.renderMarkdown(answer)
+		}
+		return lipgloss.NewStyle().Width(m.width).Render(label + answer + "▌")
+	})
+}
+
+// withThinking renders the reasoning in content apart from the answer,
+// which render renders.
+func (m *Model) withThinking(content string, render func(answer string) string) string {
+	thoughts, answer, done := think.Split(content)
+	var out string
+	if thoughts != "" || !done {
+		out = m.renderThinking(thoughts, done)
+		if answer == "" {
+			return out
+		}
+		out += "\n"
+	}
+	return out + render(answer)
+}
+
+// handleThink expands or collapses the reasoning of replies.
+func handleThink(m *Model, args string) (tea.Model, tea.Cmd) {
+	switch args {
+	case "":
+		m.showThinking = !m.showThinking
+	case "show", "on":
+		m.showThinking = true
+	case "hide", "off":
+		m.showThinking = false
+	default:
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Usage: /think [show|hide]")})
+		m.updateViewport()
+		return m, nil
+	}
+	content := m.tr.T("Reasoning is collapsed.")
+	if m.showThinking {
+		content = m.tr.T("Reasoning is shown.")
+	}
+	m.messages = append(m.messages, displayMessage{role: "system", content: content})
+	m.rendered = nil // thinking renders differently now
+	m.updateViewport()
+	return m, nil
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+func TestThinking_KeptApartFromReply(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{
+		{Chunks: []string{"<think>\nThe user ", "asks about tea.\n</think>\n\n", "Green tea."}},
+		providertest.Text("Yes."),
+	}}
+	m := newAgentModel(t, mp)
+
+	runTurn(&m, m.sendMessage("What do I drink?"))
+	n := len(m.messages)
+	if m.messages[n-2].role != "thinking" || m.messages[n-2].content != "The user asks about tea." {
+		t.Errorf("reasoning shown as %+v", m.messages[n-2])
+	}
+	if got := lastMessage(&m); got.role != "assistant" || got.content != "Green tea." {
+		t.Errorf("reply = %+v", got)
+	}
+
+	runTurn(&m, m.sendMessage("Sure?"))
+	for _, msg := range mp.LastRequest().Messages {
+		if strings.Contains(msg.Content, "asks about tea") {
+			t.Errorf("reasoning sent back to the model: %+v", msg)
+		}
+	}
+}
+
+func TestThinking_Toggle(t *testing.T) {
+	m := newAgentModel(t, &providertest.Fake{ProviderName: "test"})
+	thoughts := "The user asks about tea."
+
+	if got := m.renderThinking(thoughts, true); !strings.Contains(got, "5 words") || strings.Contains(got, thoughts) {
+		t.Errorf("collapsed reasoning = %q", got)
+	}
+	m.handleCommand(&Command{Name: "think"})
+	if !m.showThinking || !strings.Contains(m.renderThinking(thoughts, true), thoughts) {
+		t.Error("/think should expand the reasoning")
+	}
+	m.handleCommand(&Command{Name: "think", Args: "hide"})
+	if m.showThinking {
+		t.Error("/think hide should collapse the reasoning")
+	}
+}
+
+func TestThinking_WhileStreaming(t *testing.T) {
+	m := newAgentModel(t, &providertest.Fake{ProviderName: "test"})
+
+	if got := m.renderAssistant("<think>Hmm, let me", false); !strings.Contains(got, "Thinking") || strings.Contains(got, "Assistant") {
+		t.Errorf("unfinished reasoning rendered as %q", got)
+	}
+	if got := m.renderAssistant("<think>Hmm</think>Hi", false); !strings.Contains(got, "Assistant") || !strings.HasSuffix(strings.TrimSpace(got), "Hi▌") {
+		t.Errorf("reply after reasoning rendered as %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+// SessionTitledMsg carries the title generated for a session.
+type SessionTitledMsg struct {
+	SessionID string
+	Title     string
+	Err       error
+}
+
+// taskModel returns the model routed to a background task, or the chat
+// model if the task has none.
+func (m *Model) taskModel(model string) string {
+	return cmp.Or(model, m.options.Model)
+}
+
+// nameSession generates a title for a session still called "New Chat"
+// with model.tasks.title, if one is set.
+func (m *Model) nameSession() tea.Cmd {
+	s, model := m.options.Session, m.options.TaskModels.Title
+	if model == "" || s == nil || s.Title != session.DefaultTitle || m.options.SessionStore == nil || m.naming == s.ID {
+		return nil
+	}
+	m.naming = s.ID
+	prov, msgs := m.options.Provider, slices.Clone(m.exchanges)
+	return func() tea.Msg {
+		title, err := session.GenerateTitle(queue.WithLabel(context.Background(), "title"), prov, model, msgs)
+		return SessionTitledMsg{SessionID: s.ID, Title: title, Err: err}
+	}
+}
+
+// handleSessionTitled saves a generated session title.
+func (m *Model) handleSessionTitled(msg SessionTitledMsg) {
+	m.naming = ""
+	if msg.Err != nil {
+		log.Warn("naming session failed", "session", msg.SessionID, "err", msg.Err)
+		return
+	}
+	if err := m.options.SessionStore.UpdateTitle(msg.SessionID, msg.Title); err != nil {
+		log.Warn("saving session title failed", "session", msg.SessionID, "err", err)
+		return
+	}
+	if s := m.options.Session; s != nil && s.ID == msg.SessionID {
+		s.Title = msg.Title
+	}
+}
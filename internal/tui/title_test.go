@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+)
+
+func newTitleModel(t *testing.T, titleModel string, mp *providertest.Fake) (Model, *session.FileStore) {
+	t.Helper()
+	store := session.NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+	sess, err := store.Create(session.DefaultTitle, "big-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(Options{
+		Provider:     mp,
+		Model:        "big-model",
+		TaskModels:   config.TaskModelsConfig{Title: titleModel},
+		SessionStore: store,
+		Session:      sess,
+	})
+	m.appendTranscript("user", "Why won't my sourdough rise?")
+	m.appendTranscript("assistant", "Your kitchen may be too cold.")
+	return m, store
+}
+
+func TestNameSession(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{providertest.Text("Sourdough troubleshooting")}}
+	m, store := newTitleModel(t, "small-model", mp)
+
+	cmd := m.nameSession()
+	if m.nameSession() != nil {
+		t.Error("session named twice while the first title is pending")
+	}
+	msgs := collectMsgs(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	newM, _ := m.Update(msgs[0])
+	m = newM.(Model)
+
+	if got := mp.LastRequest().Model; got != "small-model" {
+		t.Errorf("title model = %q, want small-model", got)
+	}
+	if m.options.Session.Title != "Sourdough troubleshooting" {
+		t.Errorf("session title = %q", m.options.Session.Title)
+	}
+	if s, _ := store.Get(m.options.Session.ID); s.Title != "Sourdough troubleshooting" {
+		t.Errorf("saved title = %q", s.Title)
+	}
+	if m.nameSession() != nil {
+		t.Error("named session named again")
+	}
+}
+
+func TestNameSession_NeedsTitleModel(t *testing.T) {
+	m, _ := newTitleModel(t, "", &providertest.Fake{ProviderName: "test"})
+	if m.nameSession() != nil {
+		t.Error("session named without model.tasks.title")
+	}
+}
+
+func TestTaskModel(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{}, Model: "big-model", TaskModels: config.TaskModelsConfig{Compact: "small-model"}})
+	if got := m.taskModel(m.options.TaskModels.Compact); got != "small-model" {
+		t.Errorf("compact model = %q", got)
+	}
+	if got := m.taskModel(m.options.TaskModels.Memory); got != "big-model" {
+		t.Errorf("memory model = %q, want the chat model", got)
+	}
+}
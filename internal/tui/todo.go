@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+)
+
+// handleTodo manages the to-do list: /todo [list], /todo add <task> and
+// /todo done <number or words>.
+func handleTodo(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(role, content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: role, content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	store := m.options.TodoStore
+	if store == nil {
+		return reply("system", m.tr.T("The to-do list is not available."))
+	}
+
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+	switch {
+	case sub == "" || sub == "list":
+		open, err := store.Open()
+		if err != nil {
+			return reply("error", m.tr.Sprintf("Error reading tasks: %v", err))
+		}
+		if len(open) == 0 {
+			return reply("system", m.tr.T("No open tasks. Add one with /todo add <task>."))
+		}
+		return reply("system", m.tr.T("Open tasks:")+"\n"+todo.Format(open, m.now())+"\n\n"+m.tr.T("Check one off with /todo done <number>."))
+
+	case sub == "add" && rest != "":
+		item, err := store.Add(rest, m.now())
+		if err != nil {
+			return reply("error", m.tr.Sprintf("Error saving task: %v", err))
+		}
+		return reply("system", m.tr.Sprintf("Added: %s", item.Text))
+
+	case sub == "done" && rest != "":
+		item, err := store.Done(rest)
+		if err != nil {
+			return reply("error", err.Error())
+		}
+		return reply("system", m.tr.Sprintf("Done: %s", item.Text))
+	}
+	return reply("system", m.tr.T("Usage: /todo [list] | add <task> | done <n>"))
+}
+
+// todoPrompt returns the open tasks for the system prompt, or "" if there
+// are none.
+func (m *Model) todoPrompt() string {
+	if m.options.TodoStore == nil {
+		return ""
+	}
+	open, err := m.options.TodoStore.Open()
+	if err != nil {
+		return ""
+	}
+	return todo.PromptSection(open, m.now())
+}
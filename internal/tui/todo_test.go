@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+)
+
+func TestTodo_Command(t *testing.T) {
+	store := todo.NewStore(filepath.Join(t.TempDir(), "TASKS.md"))
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", TodoStore: store, Agent: config.AgentConfig{Enabled: true}})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	handleTodo(&m, "add Pay the electricity bill")
+	handleTodo(&m, "add Return library books")
+	if got := lastMessage(&m); got.content != "Added: Return library books" {
+		t.Errorf("last message = %+v", got)
+	}
+
+	// Open tasks reach the system prompt, heartbeats included
+	if p := m.promptWithTools(m.heartbeatTools()); !strings.Contains(p, "2 open tasks") || !strings.Contains(p, "Pay the electricity bill") {
+		t.Errorf("system prompt lacks the open tasks:\n%s", p)
+	}
+
+	handleTodo(&m, "done 1")
+	if got := lastMessage(&m); got.content != "Done: Pay the electricity bill" {
+		t.Errorf("last message = %+v", got)
+	}
+	handleTodo(&m, "")
+	if got := lastMessage(&m); !strings.Contains(got.content, "1. Return library books") || strings.Contains(got.content, "electricity") {
+		t.Errorf("list = %q", got.content)
+	}
+	handleTodo(&m, "done")
+	if got := lastMessage(&m); !strings.HasPrefix(got.content, "Usage:") {
+		t.Errorf("last message = %+v, want usage", got)
+	}
+	if _, ok := m.tools.Get("todo_add"); !ok {
+		t.Error("the todo tools should be offered")
+	}
+}
@@ -3,9 +3,12 @@ package tui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -13,38 +16,98 @@ import (
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/stefanclaw/stefanclaw/internal/calendar"
 	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/daemon"
+	"github.com/stefanclaw/stefanclaw/internal/email"
 	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/heartbeat"
+	"github.com/stefanclaw/stefanclaw/internal/homeassistant"
+	"github.com/stefanclaw/stefanclaw/internal/i18n"
+	"github.com/stefanclaw/stefanclaw/internal/knowledge"
+	"github.com/stefanclaw/stefanclaw/internal/log"
 	"github.com/stefanclaw/stefanclaw/internal/memory"
+	"github.com/stefanclaw/stefanclaw/internal/notify"
+	"github.com/stefanclaw/stefanclaw/internal/plugin"
 	"github.com/stefanclaw/stefanclaw/internal/prompt"
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/queue"
+	"github.com/stefanclaw/stefanclaw/internal/reminder"
 	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/speech"
+	"github.com/stefanclaw/stefanclaw/internal/think"
+	"github.com/stefanclaw/stefanclaw/internal/todo"
+	"github.com/stefanclaw/stefanclaw/internal/tools"
+	"github.com/stefanclaw/stefanclaw/internal/units"
 	"github.com/stefanclaw/stefanclaw/internal/update"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
 )
 
 // Options configures the TUI.
 type Options struct {
-	Provider       provider.Provider
-	SessionStore   session.Store
-	MemoryStore    *memory.Store
-	PromptAsm      *prompt.Assembler
-	SystemPrompt   string
-	Model          string
-	Session        *session.Session
-	PersonalityDir string
-	Language       string
-	Heartbeat      config.HeartbeatConfig
-	MaxNumCtx      int
-	Version        string
-	History        []provider.Message
+	Provider          provider.Provider
+	OpenProvider      func(name string) (provider.Provider, error) // opens a configured provider for /provider; nil turns switching off
+	SessionStore      session.Store
+	MemoryStore       *memory.Store
+	PromptAsm         *prompt.Assembler
+	SystemPrompt      string
+	Model             string
+	TaskModels        config.TaskModelsConfig // models for compaction, fact extraction and session titles
+	Session           *session.Session
+	PersonalityDir    string
+	Language          string
+	Heartbeat         config.HeartbeatConfig
+	Memory            config.MemoryConfig // memory excerpt budget for heartbeat check-ins
+	MaxNumCtx         int
+	Warm              bool // load the model ahead of the first message
+	Version           string
+	History           []provider.Message
+	Autosave          bool
+	TUI               config.TUIConfig // markdown theme, palette and label styles
+	Profile           string
+	Keybindings       config.KeybindingsConfig
+	WatchConfig       bool // poll config.yaml and apply safe changes live
+	Sampling          config.SamplingConfig
+	SamplingFlags     provider.Options // command-line overrides, applied last
+	Privacy           config.PrivacyConfig
+	Update            config.UpdateConfig // release channel for the update check and /update
+	Agent             config.AgentConfig
+	WorkDir           string // relative file tool directories are resolved against it
+	Plugins           []plugin.Plugin
+	ReminderStore     *reminder.Store
+	TodoStore         *todo.Store              // to-do list for /todo, the todo tools and the prompt; may be nil
+	HeartbeatStore    *heartbeat.Store         // when HEARTBEAT.md tasks last ran; nil ignores the tasks
+	HeartbeatStats    *heartbeat.StatsStore    // outcomes of check-ins for /heartbeat stats; may be nil
+	HeartbeatFeedback *heartbeat.FeedbackStore // check-in messages acknowledged or snoozed; may be nil
+	Inbox             *daemon.Inbox            // results of stefanclaw daemon, shown at launch; may be nil
+	ReleaseNotes      *update.Notes            // notes of an update installed with --update, shown once it runs; may be nil
+	ContextSizes      *session.ContextSizes    // context size last used per model; may be nil
+	Reminders         config.RemindersConfig
+	Jobs              []config.JobConfig
+	Notify            config.NotifyConfig
+	Notifier          *notify.Notifier // nil sends no notifications
+	Mailer            *email.Sender    // for output: email and heartbeat mail; may be nil
+	Calendar          config.CalendarConfig
+	Home              *homeassistant.Client // Home Assistant tools and heartbeat context; may be nil
+	Voice             config.VoiceConfig
+	Speech            config.SpeechConfig
+	Knowledge         config.KnowledgeConfig
+	KnowledgeIndex    string           // knowledge base index file; empty turns /kb off
+	Queue             *queue.Queue     // requests waiting for the provider, for /queue; nil if they aren't limited
+	Usage             *usage.Log       // where the usage of replies is recorded, for /usage; may be nil
+	Now               func() time.Time // clock for reminders, jobs, heartbeats and the calendar; time.Now if nil
+	CheckInterval     time.Duration    // how often reminders, jobs and calendar events are looked for; 30s if zero
+	HealthInterval    time.Duration    // how often the provider is checked for being reachable; 15s if zero
 }
 
 // ctxTiers defines the adaptive context size tiers.
-var ctxTiers = []int{4096, 8192, 16384, 32768}
+var ctxTiers = []int{4096, 8192, 16384, 32768, 65536, 131072}
 
 // StreamStartedMsg carries the channel after the stream connection is established.
 type StreamStartedMsg struct {
-	Ch <-chan provider.StreamDelta
+	Ch      <-chan provider.StreamDelta
+	Notice  string // shown before the reply, e.g. that the request was shortened
+	Resumed bool   // the stream continues the interrupted reply in streamContent
 }
 
 // StreamDeltaMsg carries a streaming token.
@@ -65,6 +128,7 @@ type StreamErrMsg struct {
 // ModelListMsg carries the result of listing models.
 type ModelListMsg struct {
 	Models []provider.ModelInfo
+	Filter string // only models matching it are shown
 	Err    error
 }
 
@@ -105,60 +169,178 @@ type UpdateApplyMsg struct {
 	Err    error
 }
 
+// UpdateStartedMsg carries the channel /update reports its progress and
+// result on.
+type UpdateStartedMsg struct {
+	Ch <-chan tea.Msg
+}
+
+// UpdateProgressMsg reports how far /update got.
+type UpdateProgressMsg struct {
+	Progress update.Progress
+}
+
+// TranscriptErrMsg reports that messages couldn't be saved to the session.
+type TranscriptErrMsg struct {
+	Err error
+}
+
 // Model is the Bubble Tea model for the chat TUI.
 type Model struct {
 	options  Options
 	viewport viewport.Model
 	textarea textarea.Model
 	spinner  spinner.Model
+	keys     keyMap
 	messages []displayMessage
 	width    int
 	height   int
 
-	streaming       bool
-	streamContent   string
-	streamCancelFn  context.CancelFunc
-	streamCh        <-chan provider.StreamDelta
-	waiting         bool // true while waiting for first token
-
-	mdRenderer  *glamour.TermRenderer
-	err         error
-	ready       bool
-	quitting    bool
+	streaming      bool
+	streamContent  string
+	streamCancelFn context.CancelFunc
+	streamCh       <-chan provider.StreamDelta
+	waiting        bool // true while waiting for first token
+
+	interrupted  string // reply whose stream broke off, for /continue
+	resumes      int    // times the current reply was continued without asking
+	showThinking bool   // reasoning in <think> tags is expanded
+
+	// Provider health
+	healthChecked bool  // a health check has finished
+	healthErr     error // result of the last health check
+	pending       bool  // the conversation ends with messages queued while the provider was unreachable
+
+	mdRenderer      *glamour.TermRenderer
+	err             error
+	ready           bool
+	quitting        bool
 	autoGreet       bool // trigger LLM greeting on first window size
 	bootstrapStream bool // true when current stream is the first-run greeting
 
 	heartbeatInterval time.Duration
 	heartbeatEnabled  bool
-	heartbeatStream   bool // true when current stream is a heartbeat check-in
+	heartbeatStream   bool             // true when current stream is a heartbeat check-in
+	heartbeatDue      []heartbeat.Task // HEARTBEAT.md tasks asked about in the running check-in
+	heartbeatPrompt   string           // request of the named check-in about to run; empty for the idle one
+	heartbeatName     string           // named schedule of the running check-in; empty for the idle one
+	heartbeatReply    string           // check-in whose message a reply would answer, for stats
+	heartbeatReplyBy  time.Time        // end of the reply window of heartbeatReply
+	heartbeatLast     string           // last check-in message, for /ack and /snooze
+	heartbeatSnoozed  time.Time        // no check-ins before this; set with /snooze
+	lastActivity      time.Time        // last key press, for heartbeat.skip_if_active
 
-	currentNumCtx int // Current adaptive context size
-	maxNumCtx     int // Upper limit from config
+	settingsDirty  bool // runtime settings changed since the last save
+	configModTime  time.Time
+	configBaseline *config.Config // last config seen on disk, for hot reload
+
+	currentNumCtx int           // Current adaptive context size
+	maxNumCtx     int           // Upper limit from config
+	state         session.State // saved with the session, see state.go
+	naming        string        // ID of the session a title is being generated for
+
+	modelCaps *provider.ModelDetails // capabilities of the current model; nil while unknown
+
+	warming string // model being loaded ahead of the first message
 
 	fetchClient *fetch.Client
+	rates       *units.Rates // exchange rates for the convert tool; nil while web access is off
+
+	// Agent loop state
+	tools          *tools.Registry
+	agentSteps     int                // tool calls made for the current user message
+	toolRunning    string             // name of the tool being executed, if any
+	pendingCall    *tools.Call        // tool call awaiting the user's approval
+	agentHeartbeat bool               // current turn was started by a heartbeat
+	heartbeatTurn  []provider.Message // the running check-in and its tool steps so far
+
+	dueTasks    []reminder.Reminder  // scheduled tasks waiting for the model to be free
+	jobNext     map[string]time.Time // next run of each automation job, by name
+	jobsTicking bool                 // a JobTickMsg is scheduled
+
+	scheduleNext map[string]time.Time // next run of each named heartbeat schedule
+
+	// Calendar
+	calendar          *calendar.Cache // nil without calendar sources
+	calendarTicking   bool            // a CalendarTickMsg is scheduled
+	calendarAnnounced map[string]bool // events already announced, by summary and start
+	calendarErr       string          // last refresh error shown
+
+	// Notifications
+	blurred       bool            // the terminal reported losing focus
+	turnEvent     string          // notify event for the current turn's final reply, if any
+	turnStart     time.Time       // when the current turn started
+	notifyLimiter *notify.Limiter // rate limit of the notify tool, kept across tool rebuilds
+
+	// Voice input
+	recording    voiceRecording // push-to-talk recording in progress
+	transcribing bool           // a recording is being transcribed
+
+	// Speech output
+	speaker *speech.Speaker // nil while speech is off
+	spoken  string          // speakable text of the current reply already queued
+
+	// Knowledge base
+	kb     *knowledge.Base // nil without an index file
+	kbAuto bool            // search the knowledge base for every message
+	kbAsk  bool            // search it for the message being sent (/kb ask)
+
+	// Self-update
+	updateCh  <-chan tea.Msg // progress and result of the running /update
+	updateMsg int            // index of the message showing /update's progress
+	restart   bool           // quit so that main starts the (new) binary again
+
+	// Model download
+	pullCh     <-chan tea.Msg     // progress and result of the running /pull
+	pullMsg    int                // index of the message showing /pull's progress
+	pulling    string             // the model /pull is downloading
+	pullCancel context.CancelFunc // stops the running /pull; nil if none
+
+	compareCh     <-chan tea.Msg     // replies and results of the running /compare
+	compareMsg    int                // index of the message showing the reply /compare gets now
+	compareCancel context.CancelFunc // stops the running /compare; nil if none
+
+	attachments []attachment // images to send with the next message
+
+	transcript *session.Writer    // saves messages to the session in the background
+	crash      *crashState        // shared by all copies, so main sees a crash in View
+	exchanges  []provider.Message // latest user and assistant messages, for memory at exit
+
+	// Viewport
+	rendered  map[renderKey]string // messages as last rendered; nil after a style change
+	viewFrom  int                  // first message in the viewport
+	debugPane bool                 // show provider traffic above the input
+
+	tr *i18n.Translator // interface text in the configured language; nil for English
 }
 
 type displayMessage struct {
 	role    string
 	content string
+	images  []string // base64-encoded, attached with /attach
 }
 
+// inputPlaceholder is shown in the empty input.
+const inputPlaceholder = "Type a message... (Alt+Enter for newline)"
+
 // New creates a new TUI model.
 func New(opts Options) Model {
 	ta := textarea.New()
-	ta.Placeholder = "Type a message... (Alt+Enter for newline)"
+	tr := i18n.For(opts.Language)
+	ta.Placeholder = tr.T(inputPlaceholder)
 	ta.Focus()
 	ta.CharLimit = 4096
 	ta.SetHeight(1)
 	ta.ShowLineNumbers = false
 	ta.Prompt = inputPromptStyle.Render("> ")
 
+	keys := newKeyMap(opts.Keybindings)
 	vp := viewport.New(80, 20)
+	vp.KeyMap.HalfPageUp = keys.HalfPageUp
+	vp.KeyMap.HalfPageDown = keys.HalfPageDown
 
-	renderer, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(76),
-	)
+	applyPalette(opts.TUI)
+	renderer, _ := newMarkdownRenderer(opts.TUI.Theme, 76)
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
@@ -176,75 +358,149 @@ func New(opts Options) Model {
 		maxCtx = 32768
 	}
 
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = 30 * time.Second
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 15 * time.Second
+	}
+
 	// Convert history into display messages
 	var history []displayMessage
 	for _, m := range opts.History {
-		if m.Role == "user" || m.Role == "assistant" || m.Role == "summary" {
+		if m.Role == "user" || m.Role == "assistant" || m.Role == "summary" || m.Role == "tool" {
 			history = append(history, displayMessage{
-				role:    m.Role,
+				role:    displayRole(m.Role, m.Content),
 				content: m.Content,
 			})
 		}
 	}
 
-	return Model{
+	m := Model{
 		options:           opts,
 		textarea:          ta,
 		viewport:          vp,
 		spinner:           sp,
+		keys:              keys,
 		messages:          history,
 		mdRenderer:        renderer,
 		autoGreet:         isFirstRun,
 		heartbeatEnabled:  opts.Heartbeat.Enabled,
 		heartbeatInterval: heartbeatInterval,
-		currentNumCtx:     ctxTiers[0],
 		maxNumCtx:         maxCtx,
-		fetchClient:       fetch.New(),
+		showThinking:      opts.TUI.ShowThinking,
+		notifyLimiter:     &notify.Limiter{},
+		tr:                tr,
+	}
+	if opts.SessionStore != nil {
+		m.transcript = session.NewWriter(opts.SessionStore)
+	}
+	m.crash = &crashState{}
+	m.currentNumCtx = m.savedNumCtx(opts.Model)
+	m.restoreState()
+	m.applyPrivacy(opts.Privacy)
+	m.setKnowledge()
+	m.kbAuto = opts.Knowledge.AutoRetrieve
+	if opts.Speech.Enabled {
+		if err := m.setSpeech(true); err != nil {
+			m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Speech unavailable: %v", err)})
+		}
 	}
+	if opts.HeartbeatFeedback != nil {
+		m.heartbeatSnoozed, _ = opts.HeartbeatFeedback.SnoozedUntil(m.now())
+	}
+	m.showInbox()
+	m.showReleaseNotes()
+	if opts.WatchConfig {
+		m.snapshotConfig()
+	}
+	return m
+}
+
+// now reads the clock in Options.
+func (m *Model) now() time.Time {
+	return m.options.Now()
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(textarea.Blink, m.spinner.Tick)
 }
 
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
+		m.lastActivity = m.now()
+		if m.pendingCall != nil {
+			return m, m.handleConfirmKey(msg)
+		}
+		switch {
+		case key.Matches(msg, m.keys.Stop):
+			if m.compareCancel != nil {
+				m.compareCancel()
+				return m, nil
+			}
 			if m.streaming && m.streamCancelFn != nil {
+				log.Info("reply stopped by the user")
 				m.streamCancelFn()
 				m.streaming = false
+				m.speaker.Stop()
+				return m, nil
+			}
+			if m.speaker.Speaking() {
+				m.speaker.Stop()
+				return m, nil
+			}
+			if m.recording != nil {
+				m.cancelRecording()
 				return m, nil
 			}
 			m.quitting = true
 			return m, tea.Quit
 
-		case tea.KeyEnter:
+		case key.Matches(msg, m.keys.Newline):
 			if m.streaming {
 				return m, nil
 			}
-			if msg.Alt {
-				m.textarea.InsertString("\n")
+			m.textarea.InsertString("\n")
+			return m, nil
+
+		case key.Matches(msg, m.keys.Submit):
+			if m.streaming {
 				return m, nil
 			}
 			return m.handleSubmit()
+
+		case key.Matches(msg, m.keys.Palette):
+			if m.streaming {
+				return m, nil
+			}
+			m.textarea.SetValue("/")
+			m.textarea.CursorEnd()
+			return handleHelp(&m, "")
+
+		case key.Matches(msg, m.keys.Picker):
+			if m.streaming {
+				return m, nil
+			}
+			return m, m.listModels("")
+
+		case key.Matches(msg, m.keys.Yank):
+			m.yankLastResponse()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Voice):
+			return m, m.toggleVoice()
 		}
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		statusH := 1
-		inputH := 3
-		viewH := m.height - statusH - inputH
-		if viewH < 1 {
-			viewH = 1
-		}
-		m.viewport.Width = m.width
-		m.viewport.Height = viewH
-		m.textarea.SetWidth(m.width)
+		m.layout()
 
 		if !m.ready {
 			m.ready = true
@@ -253,15 +509,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.autoGreet = false
 				m.messages = append(m.messages, displayMessage{
 					role:    "system",
-					content: "Starting up... waiting for model to respond.",
+					content: m.tr.T("Starting up... waiting for model to respond."),
 				})
 				initCmds = append(initCmds, m.triggerAutoGreet())
 			}
 			if m.heartbeatEnabled {
 				initCmds = append(initCmds, m.scheduleHeartbeat())
 			}
-			// Background update check (only for release builds)
-			if v := m.options.Version; v != "" && v != "dev" {
+			if m.options.WatchConfig {
+				initCmds = append(initCmds, m.watchConfig())
+			}
+			if m.options.ReminderStore != nil {
+				// Deliver reminders that fell due while stefanclaw was closed
+				initCmds = append(initCmds, func() tea.Msg { return ReminderTickMsg{} })
+			}
+			if len(m.options.Jobs) > 0 || len(m.options.Heartbeat.Schedules) > 0 {
+				m.planJobs(nil)
+				m.planSchedules(nil)
+				initCmds = append(initCmds, m.scheduleJobCheck())
+			}
+			if m.calendar != nil {
+				m.calendarTicking = true
+				initCmds = append(initCmds, func() tea.Msg { return CalendarTickMsg{} })
+			}
+			initCmds = append(initCmds, func() tea.Msg { return HealthTickMsg{} }, m.detectCapabilities(), m.warmModel())
+			if m.options.Queue != nil {
+				initCmds = append(initCmds, waitForQueue(m.options.Queue))
+			}
+			if m.transcript != nil {
+				initCmds = append(initCmds, waitForTranscriptErr(m.transcript))
+			}
+			// Background update check (only for release builds), at most once a day
+			if v := m.options.Version; v != "" && v != "dev" && m.options.Update.Check && !m.options.Privacy.DisableWeb {
 				initCmds = append(initCmds, m.checkForUpdate())
 			}
 			if len(initCmds) > 0 {
@@ -274,21 +553,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case StreamStartedMsg:
 		m.streamCh = msg.Ch
+		if !msg.Resumed {
+			m.spoken = ""
+		}
 		m.waiting = true
+		if msg.Notice != "" {
+			m.messages = append(m.messages, displayMessage{role: "system", content: msg.Notice})
+		}
 		m.updateViewport()
 		return m, tea.Batch(waitForDelta(m.streamCh), m.spinner.Tick)
 
 	case StreamDeltaMsg:
 		m.waiting = false
 		m.streamContent += msg.Content
+		m.speakStream(false)
 		m.updateViewport()
 		return m, waitForDelta(m.streamCh)
 
 	case StreamDoneMsg:
 		m.streaming = false
 		m.waiting = false
+		m.resumes = 0
 		wasHeartbeat := m.heartbeatStream
 		m.heartbeatStream = false
+		heartbeatName := m.heartbeatName
+		m.heartbeatName = ""
+		heartbeatTurn := m.heartbeatTurn
+		m.heartbeatTurn = nil
+		if wasHeartbeat {
+			m.heartbeatTasksDone()
+		}
 
 		// Delete BOOTSTRAP.md after first greeting so auto-greet doesn't fire again
 		if m.bootstrapStream {
@@ -299,54 +593,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Adaptive context scaling: check if we need to grow
+		if msg.Usage != nil {
+			m.state.AddUsage(*msg.Usage)
+			m.saveState()
+			m.recordUsage(*msg.Usage)
+		}
 		if msg.Usage != nil && msg.Usage.PromptTokens > 0 {
-			threshold := int(float64(m.currentNumCtx) * 0.6)
-			if msg.Usage.PromptTokens > threshold {
-				for _, tier := range ctxTiers {
-					if tier > m.currentNumCtx && tier <= m.maxNumCtx {
-						m.currentNumCtx = tier
-						m.messages = append(m.messages, displayMessage{
-							role: "system",
-							content: fmt.Sprintf("Context expanded to %d tokens (conversation is growing). The next response may take a moment while the model reloads.", tier),
-						})
-						break
-					}
-				}
-			}
+			m.growContext(msg.Usage.PromptTokens)
 		}
 
-		// Conversation compaction: summarize old messages when context is getting full
-		providerMsgs := m.buildMessages("")
-		compactResult, compacted, compactErr := session.Compact(
-			context.Background(),
-			m.options.Provider,
-			m.options.Model,
-			providerMsgs,
-			m.currentNumCtx,
-			6, // keep 3 user + 3 assistant turns
-		)
-		if compactErr == nil && compactResult != nil {
-			// Replace in-memory display messages with compacted set
-			var newMessages []displayMessage
-			for _, cm := range compacted {
-				if cm.Role == "system" {
-					continue // skip system prompt
-				}
-				newMessages = append(newMessages, displayMessage{
-					role:    cm.Role,
-					content: cm.Content,
-				})
-			}
-			m.messages = newMessages
-			m.messages = append(m.messages, displayMessage{
-				role: "system",
-				content: fmt.Sprintf("Conversation compacted: %d messages summarized to keep context manageable.", compactResult.OriginalCount-compactResult.RemainingCount),
-			})
+		// Conversation compaction: summarize old messages when context is
+		// getting full; the shorter conversation may fit a smaller context
+		if m.compact(m.currentNumCtx) {
+			m.shrinkContext()
 		}
 
+		// Reasoning is shown apart from the reply and not kept with it
+		thoughts, answer, _ := think.Split(m.streamContent)
+		m.streamContent = answer
+
+		var notifyCmd tea.Cmd
 		if m.streamContent != "" {
 			// Heartbeat skip: discard silently
-			if wasHeartbeat && strings.Contains(m.streamContent, "HEARTBEAT_SKIP") {
+			if wasHeartbeat && strings.Contains(m.streamContent, heartbeat.Skip) {
+				m.recordHeartbeat(heartbeatName, heartbeat.OutcomeSkipped)
 				m.streamContent = ""
 				m.updateViewport()
 				if m.heartbeatEnabled {
@@ -354,17 +624,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			}
+			m.speakStream(true)
+			m.addThinking(thoughts)
+
+			if cmd, ok := m.handleToolCall(m.streamContent); ok {
+				if wasHeartbeat {
+					// The check-in goes on with the tool's result
+					m.heartbeatName = heartbeatName
+					m.heartbeatTurn = append(heartbeatTurn, provider.Message{Role: "assistant", Content: m.streamContent})
+				}
+				m.streamContent = ""
+				m.updateViewport()
+				return m, cmd
+			}
 
 			m.messages = append(m.messages, displayMessage{
 				role:    "assistant",
 				content: m.streamContent,
 			})
+			notifyCmd = m.notifyReply(m.streamContent)
+			if wasHeartbeat {
+				m.recordHeartbeat(heartbeatName, heartbeat.OutcomeProduced)
+				if m.options.HeartbeatFeedback != nil {
+					m.heartbeatLast = m.streamContent
+					m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T(snoozeHint)})
+				}
+			}
 			// Save to transcript
-			if m.options.Session != nil && m.options.SessionStore != nil {
-				m.options.SessionStore.Append(m.options.Session.ID, provider.Message{
-					Role:    "assistant",
-					Content: m.streamContent,
-				})
+			m.appendTranscript("assistant", m.streamContent)
+			if !wasHeartbeat {
+				notifyCmd = tea.Batch(notifyCmd, m.nameSession())
 			}
 		}
 		m.streamContent = ""
@@ -372,32 +661,147 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Reschedule heartbeat after a response completes
 		if wasHeartbeat && m.heartbeatEnabled {
-			return m, m.scheduleHeartbeat()
+			return m, tea.Batch(m.scheduleHeartbeat(), notifyCmd)
 		}
-		return m, nil
+		return m, notifyCmd
 
 	case StreamErrMsg:
+		log.Warn("reply failed", "model", m.options.Model, "err", msg.Err)
 		m.streaming = false
 		m.waiting = false
+		m.heartbeatDue = nil
+		m.heartbeatName = ""
+		m.heartbeatTurn = nil
 		m.err = msg.Err
+		if cmd, ok := m.interrupt(msg.Err); ok {
+			return m, cmd
+		}
+		if m.unreachable(msg.Err) {
+			m.updateViewport()
+			return m, nil
+		}
 		m.messages = append(m.messages, displayMessage{
-			role:    "system",
-			content: fmt.Sprintf("Error: %v", msg.Err),
+			role:    "error",
+			content: m.tr.Sprintf("Error: %v", msg.Err),
 		})
+		if m.interrupted != "" {
+			m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Press Enter or type /continue to pick the reply up where it stopped.")})
+		}
 		m.streamContent = ""
 		m.updateViewport()
 		return m, nil
 
+	case ToolResultMsg:
+		return m, m.handleToolResult(msg)
+
+	case PluginDoneMsg:
+		m.handlePluginDone(msg)
+		return m, nil
+
+	case ConfigReloadMsg:
+		return m, m.handleConfigReload(msg)
+
+	case ReminderTickMsg:
+		if m.options.ReminderStore == nil {
+			return m, nil
+		}
+		return m, m.checkReminders()
+
+	case JobTickMsg:
+		return m, m.checkJobs()
+
+	case JobDoneMsg:
+		return m, m.handleJobDone(msg)
+
+	case GitDoneMsg:
+		return m, m.handleGitDone(msg)
+
+	case TerminalDoneMsg:
+		return m, m.handleTerminalDone(msg)
+
+	case OCRDoneMsg:
+		return m, m.handleOCRDone(msg)
+
+	case ProviderSwitchMsg:
+		m.handleProviderSwitch(msg)
+		return m, tea.Batch(m.checkHealth(), m.detectCapabilities(), m.warmModel())
+
+	case HealthTickMsg:
+		return m, tea.Batch(m.checkHealth(), m.scheduleHealthCheck())
+
+	case HealthMsg:
+		cmd := m.handleHealth(msg)
+		m.updateViewport()
+		return m, cmd
+
+	case ModelCapsMsg:
+		m.handleModelCaps(msg)
+		return m, nil
+
+	case ModelWarmedMsg:
+		m.handleModelWarmed(msg)
+		return m, nil
+
+	case ModelDetailsMsg:
+		m.handleModelDetails(msg)
+		return m, nil
+
+	case ModelDeletedMsg:
+		m.handleModelDeleted(msg)
+		return m, nil
+
+	case SessionTitledMsg:
+		m.handleSessionTitled(msg)
+		return m, nil
+
+	case QueueChangedMsg:
+		return m, waitForQueue(m.options.Queue)
+
+	case KnowledgeIndexedMsg:
+		m.handleKnowledgeIndexed(msg)
+		return m, nil
+
+	case VoiceDoneMsg:
+		m.handleVoiceDone(msg)
+		return m, nil
+
+	case CalendarTickMsg:
+		return m, m.checkCalendar()
+
+	case CalendarLoadedMsg:
+		m.handleCalendarLoaded(msg)
+		return m, nil
+
+	case NotifyErrMsg:
+		m.handleNotifyErr(msg)
+		return m, nil
+
+	case tea.FocusMsg:
+		m.blurred = false
+		return m, nil
+
+	case tea.BlurMsg:
+		m.blurred = true
+		return m, nil
+
 	case HeartbeatTickMsg:
-		if m.streaming || !m.heartbeatEnabled {
+		if m.streaming || m.pendingCall != nil || !m.heartbeatEnabled || m.options.Privacy.DisableHeartbeat {
 			return m, nil
 		}
+		if m.heartbeatQuiet(m.options.Heartbeat.ActiveHours) != "" {
+			return m, m.scheduleHeartbeat()
+		}
+		due, skip := m.dueHeartbeatTasks()
+		if skip {
+			return m, m.scheduleHeartbeat()
+		}
+		m.heartbeatDue = due
 		return m, m.triggerHeartbeat()
 
 	case FetchDoneMsg:
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Fetched %s:\n\n%s", msg.URL, msg.Content),
+			content: m.tr.Sprintf("Fetched %s:\n\n%s", msg.URL, msg.Content),
 		})
 		m.updateViewport()
 		return m, nil
@@ -405,7 +809,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case FetchErrMsg:
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Fetch error: %v", msg.Err),
+			content: m.tr.Sprintf("Fetch error: %v", msg.Err),
 		})
 		m.updateViewport()
 		return m, nil
@@ -413,7 +817,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SearchDoneMsg:
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Search results for %q:\n\n%s", msg.Query, msg.Content),
+			content: m.tr.Sprintf("Search results for %q:\n\n%s", msg.Query, msg.Content),
 		})
 		m.updateViewport()
 		return m, nil
@@ -421,7 +825,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SearchErrMsg:
 		m.messages = append(m.messages, displayMessage{
 			role:    "system",
-			content: fmt.Sprintf("Search error: %v", msg.Err),
+			content: m.tr.Sprintf("Search error: %v", msg.Err),
 		})
 		m.updateViewport()
 		return m, nil
@@ -429,28 +833,84 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case UpdateCheckMsg:
 		if msg.Err == nil && msg.Result != nil && msg.Result.UpdateAvailable {
 			m.messages = append(m.messages, displayMessage{
-				role: "system",
-				content: fmt.Sprintf("Update available: v%s → v%s. Run /update to upgrade.", msg.Result.CurrentVersion, msg.Result.LatestVersion),
+				role:    "system",
+				content: fmt.Sprintf("v%s available — %s to upgrade", msg.Result.LatestVersion, upgradeCommand()),
 			})
 			m.updateViewport()
 		}
 		return m, nil
 
+	case TranscriptErrMsg:
+		m.messages = append(m.messages, displayMessage{
+			role:    "error",
+			content: m.tr.Sprintf("Couldn't save the conversation: %v", msg.Err),
+		})
+		m.updateViewport()
+		return m, waitForTranscriptErr(m.transcript)
+
+	case UpdateStartedMsg:
+		m.updateCh = msg.Ch
+		return m, waitForUpdate(m.updateCh)
+
+	case PullStartedMsg:
+		m.pullCh = msg.Ch
+		return m, waitForUpdate(m.pullCh)
+
+	case PullProgressMsg:
+		if m.pullMsg < len(m.messages) {
+			m.messages[m.pullMsg].content = m.pullProgress(msg.Progress)
+		}
+		m.updateViewport()
+		return m, waitForUpdate(m.pullCh)
+
+	case PullDoneMsg:
+		m.handlePullDone(msg)
+		return m, nil
+
+	case CompareStartedMsg:
+		m.compareCh = msg.Ch
+		return m, waitForUpdate(m.compareCh)
+
+	case CompareModelMsg:
+		m.handleCompareModel(msg)
+		return m, waitForUpdate(m.compareCh)
+
+	case CompareDeltaMsg:
+		m.handleCompareDelta(msg)
+		return m, waitForUpdate(m.compareCh)
+
+	case CompareReplyMsg:
+		m.handleCompareReply(msg)
+		return m, waitForUpdate(m.compareCh)
+
+	case CompareDoneMsg:
+		m.handleCompareDone()
+		return m, nil
+
+	case UpdateProgressMsg:
+		if m.updateMsg < len(m.messages) {
+			m.messages[m.updateMsg].content = msg.Progress.String()
+		}
+		m.updateViewport()
+		return m, waitForUpdate(m.updateCh)
+
 	case UpdateApplyMsg:
+		m.updateCh = nil
 		if msg.Err != nil {
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: fmt.Sprintf("Update failed: %v", msg.Err),
+				content: m.tr.Sprintf("Update failed: %v", msg.Err),
 			})
 		} else if msg.Result.Applied {
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: fmt.Sprintf("Updated to v%s. Restart stefanclaw to use the new version.", msg.Result.LatestVersion),
+				content: m.tr.Sprintf("Updated to v%s. Type /restart to switch to it now; your session carries over.", msg.Result.LatestVersion),
 			})
+			m.addReleaseNotes(msg.Result.LatestVersion, msg.Result.ReleaseNotes)
 		} else {
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: "Already running the latest version.",
+				content: m.tr.T("Already running the latest version."),
 			})
 		}
 		m.updateViewport()
@@ -460,21 +920,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Err != nil {
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: fmt.Sprintf("Error listing models: %v", msg.Err),
+				content: m.tr.Sprintf("Error listing models: %v", msg.Err),
 			})
 		} else {
-			var lines []string
-			lines = append(lines, "Available models:")
-			for _, model := range msg.Models {
-				marker := "  "
-				if model.Name == m.options.Model {
-					marker = "* "
-				}
-				lines = append(lines, fmt.Sprintf("%s%s", marker, model.Name))
-			}
 			m.messages = append(m.messages, displayMessage{
 				role:    "system",
-				content: strings.Join(lines, "\n"),
+				content: m.formatModelList(msg.Models, msg.Filter),
 			})
 		}
 		m.updateViewport()
@@ -500,35 +951,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var vpCmd tea.Cmd
 	m.viewport, vpCmd = m.viewport.Update(msg)
 	cmds = append(cmds, vpCmd)
+	m.scrollBack()
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m Model) View() string {
+func (m Model) view() string {
 	if m.quitting {
-		return "Goodbye!\n"
+		return m.tr.T("Goodbye!") + "\n"
 	}
 	if !m.ready {
-		return "Initializing..."
+		return m.tr.T("Initializing...")
 	}
 
-	status := StatusBar(m.options.Model, m.options.Provider.Name(), m.width)
+	status := StatusBar(m.options.Model, m.options.Provider.Name()+m.healthIndicator()+m.queueIndicator(), m.options.Profile, m.heartbeatStatus(), m.width)
 	separator := lipgloss.NewStyle().
 		Foreground(secondaryColor).
 		Width(m.width).
 		Render(strings.Repeat("─", m.width))
 
+	view := m.viewport.View()
+	if m.debugPane {
+		view += "\n" + m.debugPaneView()
+	}
 	return fmt.Sprintf("%s\n%s\n%s\n%s",
 		status,
-		m.viewport.View(),
+		view,
 		separator,
 		m.textarea.View(),
 	)
 }
 
+// layout sizes the viewport and input to the terminal.
+func (m *Model) layout() {
+	statusH := 1
+	inputH := 3
+	viewH := m.height - statusH - inputH
+	if m.debugPane {
+		viewH -= debugPaneHeight
+	}
+	if viewH < 1 {
+		viewH = 1
+	}
+	m.viewport.Width = m.width
+	m.viewport.Height = viewH
+	m.textarea.SetWidth(m.width)
+}
+
 func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 	input := strings.TrimSpace(m.textarea.Value())
 	if input == "" {
+		if m.interrupted != "" {
+			return m, m.resume()
+		}
 		return m, nil
 	}
 	m.textarea.Reset()
@@ -537,16 +1012,51 @@ func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 	if cmd := ParseCommand(input); cmd != nil {
 		return m.handleCommand(cmd)
 	}
+	if m.compareCancel != nil {
+		m.textarea.SetValue(input)
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Wait for /compare to finish, or stop it with Esc.")})
+		m.updateViewport()
+		return m, nil
+	}
+	m.heartbeatReplied()
+	if m.providerDown() {
+		return m, m.queueMessage(input)
+	}
+	return m, m.sendMessage(input)
+}
+
+// sendMessage adds a user message to the conversation and streams the reply.
+func (m *Model) sendMessage(input string) tea.Cmd {
+	m.addUserMessage(input)
+	return m.streamReply()
+}
+
+// addUserMessage adds a user message to the conversation and starts a new
+// turn with it.
+func (m *Model) addUserMessage(input string) {
+	m.settleInterrupted()
+	m.resumes = 0
 
 	// Add user message
-	m.messages = append(m.messages, displayMessage{role: "user", content: input})
+	m.messages = append(m.messages, displayMessage{role: "user", content: input, images: m.takeAttachments()})
+	m.agentSteps = 0
+	m.agentHeartbeat = false
+	m.heartbeatTurn = nil
+	m.turnEvent = notify.EventResponse
+	m.turnStart = m.now()
 
 	// Save to transcript
-	if m.options.Session != nil && m.options.SessionStore != nil {
-		m.options.SessionStore.Append(m.options.Session.ID, provider.Message{
-			Role:    "user",
-			Content: input,
-		})
+	m.appendTranscript("user", input)
+}
+
+// streamReply streams the reply to the conversation so far.
+func (m *Model) streamReply() tea.Cmd {
+	m.pending = false
+
+	// Pick up facts saved since the system prompt was built
+	pipeline := m.pipeline()
+	if pipeline.RefreshMemory() {
+		m.options.SystemPrompt = pipeline.SystemPrompt()
 	}
 
 	// Start streaming
@@ -557,36 +1067,49 @@ func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 	// Update viewport after setting waiting=true so the spinner renders immediately
 	m.updateViewport()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "chat"))
 	m.streamCancelFn = cancel
 
 	var cmds []tea.Cmd
-	cmds = append(cmds, m.startStream(ctx, input), m.spinner.Tick)
+	cmds = append(cmds, m.startStream(ctx, ""), m.spinner.Tick)
 
 	// Reset heartbeat timer on user activity
 	if m.heartbeatEnabled {
 		cmds = append(cmds, m.scheduleHeartbeat())
 	}
 
-	return m, tea.Batch(cmds...)
+	return tea.Batch(cmds...)
 }
 
 func (m *Model) buildMessages(userInput string) []provider.Message {
 	var msgs []provider.Message
 
-	if m.options.SystemPrompt != "" {
+	if sys := m.systemPrompt(); sys != "" {
 		msgs = append(msgs, provider.Message{
 			Role:    "system",
-			Content: m.options.SystemPrompt,
+			Content: sys,
 		})
 	}
 
-	// Add conversation history
+	// Add conversation history. Tool calls are the assistant's turns; tool
+	// results go back as user turns so any model can follow the exchange.
 	for _, dm := range m.messages {
-		if dm.role == "user" || dm.role == "assistant" {
+		role := dm.role
+		switch role {
+		case "tool_call":
+			role = "assistant"
+		case "tool":
+			role = "user"
+		}
+		if role == "user" || role == "assistant" {
+			content := dm.content
+			if role == "assistant" {
+				content = think.Strip(content)
+			}
 			msgs = append(msgs, provider.Message{
-				Role:    dm.role,
-				Content: dm.content,
+				Role:    role,
+				Content: content,
+				Images:  dm.images,
 			})
 		}
 	}
@@ -598,24 +1121,46 @@ func (m *Model) startStream(ctx context.Context, userInput string) tea.Cmd {
 	// Capture what we need — the closure must not rely on m fields surviving
 	model := m.options.Model
 	prov := m.options.Provider
-	msgs := m.buildMessages(userInput)
+	msgs := m.fitContext(userInput)
 	numCtx := m.currentNumCtx
-	fetchClient := m.fetchClient
+	sampling := m.samplingOptions()
+	pipeline := m.pipeline()
+	native := m.nativeTools(m.tools)
+	var kb *knowledge.Base
+	kbRequired := m.kbAsk
+	if m.kbAuto || m.kbAsk {
+		kb = m.kb
+	}
+	m.kbAsk = false
 
 	return func() tea.Msg {
-		// Auto-fetch URLs found in the user's message
-		last := &msgs[len(msgs)-1]
-		last.Content = fetch.AugmentWithWebContent(ctx, fetchClient, last.Content)
+		// Auto-fetch URLs found in the user's message (not in tool results)
+		if last := &msgs[len(msgs)-1]; last.Role == "user" && !tools.IsResult(last.Content) {
+			if kb != nil {
+				content, err := retrieveKnowledge(ctx, kb, last.Content, kbRequired)
+				if err != nil {
+					return StreamErrMsg{Err: err}
+				}
+				last.Content = content
+			}
+			last.Content = pipeline.Augment(ctx, last.Content)
+		}
+		// Fetched pages and notes can make the message itself too long
+		var notice string
+		if truncateLast(msgs, promptBudget(numCtx)) {
+			notice = m.tr.Sprintf("Your message with the fetched pages or notes was too long for the %d-token context and was shortened.", numCtx)
+		}
 
-		ch, err := prov.StreamChat(ctx, provider.ChatRequest{
+		ch, err := native.StreamChat(ctx, prov, provider.ChatRequest{
 			Model:    model,
 			Messages: msgs,
 			NumCtx:   numCtx,
+			Options:  sampling,
 		})
 		if err != nil {
 			return StreamErrMsg{Err: err}
 		}
-		return StreamStartedMsg{Ch: ch}
+		return StreamStartedMsg{Ch: ch, Notice: notice}
 	}
 }
 
@@ -625,7 +1170,7 @@ func (m *Model) triggerAutoGreet() tea.Cmd {
 	m.streamContent = ""
 	m.bootstrapStream = true
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "chat"))
 	m.streamCancelFn = cancel
 
 	// Capture values for the closure
@@ -634,6 +1179,7 @@ func (m *Model) triggerAutoGreet() tea.Cmd {
 	prov := m.options.Provider
 	lang := m.options.Language
 	numCtx := m.currentNumCtx
+	sampling := m.samplingOptions()
 
 	return func() tea.Msg {
 		var msgs []provider.Message
@@ -658,6 +1204,7 @@ func (m *Model) triggerAutoGreet() tea.Cmd {
 			Model:    model,
 			Messages: msgs,
 			NumCtx:   numCtx,
+			Options:  sampling,
 		})
 		if err != nil {
 			return StreamErrMsg{Err: err}
@@ -666,6 +1213,36 @@ func (m *Model) triggerAutoGreet() tea.Cmd {
 	}
 }
 
+// upgradeCommand is how the user upgrades stefanclaw: /update, or the
+// command of the package manager that installed it.
+func upgradeCommand() string {
+	if pm, ok := managed(); ok {
+		return pm.Upgrade
+	}
+	return "/update"
+}
+
+// waitForUpdate reads the next progress report or the result of /update.
+func waitForUpdate(ch <-chan tea.Msg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// waitForTranscriptErr reads the next error saving messages.
+func waitForTranscriptErr(w *session.Writer) tea.Cmd {
+	return func() tea.Msg {
+		err, ok := <-w.Errors()
+		if !ok {
+			return nil
+		}
+		return TranscriptErrMsg{Err: err}
+	}
+}
+
 // waitForDelta reads the next item from a stream channel.
 func waitForDelta(ch <-chan provider.StreamDelta) tea.Cmd {
 	if ch == nil {
@@ -697,49 +1274,173 @@ func (m *Model) renderMarkdown(content string) string {
 	return strings.TrimSpace(rendered)
 }
 
+// renderKey identifies a rendered message in Model.rendered.
+type renderKey struct {
+	role, content string
+	width         int
+}
+
+// renderMessage renders one message for the viewport. ok is false for
+// roles that aren't shown.
+func (m *Model) renderMessage(msg displayMessage) (out string, ok bool) {
+	switch msg.role {
+	case "user":
+		label := userLabelStyle.Render(m.tr.T("You: "))
+		return lipgloss.NewStyle().Width(m.width).Render(label + msg.content), true
+	case "assistant":
+		return m.renderAssistant(msg.content, true), true
+	case "thinking":
+		return m.renderThinking(msg.content, true), true
+	case "system":
+		return systemMsgStyle.Render(msg.content), true
+	case "error":
+		return errorMsgStyle.Render(msg.content), true
+	case "tool_call":
+		return m.renderToolCall(msg.content), true
+	case "tool":
+		return renderToolResult(msg.content), true
+	case "diff":
+		return renderDiff(msg.content), true
+	case "job", "notes":
+		return m.renderMarkdown(msg.content), true
+	case "compare":
+		return m.renderCompare(msg.content), true
+	}
+	return "", false
+}
+
+// viewportPages is how many screens of messages the viewport holds. Earlier
+// messages are rendered when the user scrolls up to them.
+const viewportPages = 3
+
 func (m *Model) updateViewport() {
+	m.viewFrom = m.firstShown(len(m.messages))
+	m.setViewportContent()
+	m.viewport.GotoBottom()
+}
+
+// scrollBack adds earlier messages to the viewport once the user scrolls
+// within a screen of its top, keeping the visible lines in place.
+func (m *Model) scrollBack() {
+	if m.viewFrom == 0 || m.viewFrom > len(m.messages) || m.viewport.YOffset >= m.viewport.Height {
+		return
+	}
+	offset := m.viewport.YOffset
+	before := m.viewport.TotalLineCount()
+	m.viewFrom = m.firstShown(m.viewFrom)
+	m.setViewportContent()
+	m.viewport.SetYOffset(offset + m.viewport.TotalLineCount() - before)
+}
+
+// firstShown returns the index of the earliest message needed to fill
+// viewportPages screens with the messages before end.
+func (m *Model) firstShown(end int) int {
+	need := max(m.viewport.Height, 1) * viewportPages
+	i := end
+	for i > 0 && need > 0 {
+		i--
+		out, _ := m.cachedRender(m.messages[i])
+		need -= lipgloss.Height(out) + 1
+	}
+	return i
+}
+
+// cachedRender is renderMessage reusing the rendering of an unchanged
+// message.
+func (m *Model) cachedRender(msg displayMessage) (string, bool) {
+	key := renderKey{msg.role, msg.content, m.width}
+	if out, ok := m.rendered[key]; ok {
+		return out, true
+	}
+	out, ok := m.renderMessage(msg)
+	if ok {
+		if m.rendered == nil {
+			m.rendered = make(map[renderKey]string)
+		}
+		m.rendered[key] = out
+	}
+	return out, ok
+}
+
+// setViewportContent fills the viewport with the messages from viewFrom on.
+// Earlier messages aren't rendered, so long sessions stay responsive.
+func (m *Model) setViewportContent() {
+	// Messages rendered before are reused; only new and changed ones go
+	// through glamour and lipgloss. Entries of messages that are gone, or
+	// outside the viewport, are dropped.
+	rendered := make(map[renderKey]string, len(m.messages)-m.viewFrom)
 	var lines []string
-	for _, msg := range m.messages {
-		switch msg.role {
-		case "user":
-			label := userLabelStyle.Render("You: ")
-			lines = append(lines, lipgloss.NewStyle().Width(m.width).Render(label+msg.content))
-		case "assistant":
-			label := assistantLabelStyle.Render("Assistant: ")
-			rendered := m.renderMarkdown(msg.content)
-			lines = append(lines, label+rendered)
-		case "system":
-			lines = append(lines, systemMsgStyle.Render(msg.content))
+	for _, msg := range m.messages[m.viewFrom:] {
+		if out, ok := m.cachedRender(msg); ok {
+			rendered[renderKey{msg.role, msg.content, m.width}] = out
+			lines = append(lines, out)
 		}
 		lines = append(lines, "")
 	}
+	m.rendered = rendered
 
 	// Show spinner while waiting for LLM response
 	if m.streaming && m.streamContent == "" {
-		lines = append(lines, m.spinner.View()+" Thinking...")
+		status := " " + m.tr.T("Thinking...")
+		if m.warming != "" && m.warming == m.options.Model {
+			status = " " + m.tr.Sprintf("Loading %s...", m.warming)
+		}
+		if m.toolRunning != "" {
+			status = " " + m.tr.Sprintf("Running %s...", m.toolRunning)
+		}
+		lines = append(lines, m.spinner.View()+status)
 		lines = append(lines, "")
 	}
 
 	// Show streaming content (no markdown rendering during streaming for speed)
 	if m.streaming && m.streamContent != "" {
-		label := assistantLabelStyle.Render("Assistant: ")
-		lines = append(lines, lipgloss.NewStyle().Width(m.width).Render(label+m.streamContent+"▌"))
+		lines = append(lines, m.renderAssistant(m.streamContent, false))
 		lines = append(lines, "")
 	}
 
-	content := strings.Join(lines, "\n")
-	m.viewport.SetContent(content)
-	m.viewport.GotoBottom()
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// yankLastResponse copies the most recent assistant message to the clipboard.
+func (m *Model) yankLastResponse() {
+	content := ""
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].role == "assistant" {
+			content = m.messages[i].content
+			break
+		}
+	}
+	if content == "" {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T("No response to copy yet."),
+		})
+	} else if err := clipboard.WriteAll(content); err != nil {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.Sprintf("Copy failed: %v", err),
+		})
+	} else {
+		m.messages = append(m.messages, displayMessage{
+			role:    "system",
+			content: m.tr.T("Copied last response to clipboard."),
+		})
+	}
+	m.updateViewport()
 }
 
-func (m *Model) listModels() tea.Cmd {
+// listModels lists the provider's models matching filter.
+func (m *Model) listModels(filter string) tea.Cmd {
 	return func() tea.Msg {
 		models, err := m.options.Provider.ListModels(context.Background())
-		return ModelListMsg{Models: models, Err: err}
+		return ModelListMsg{Models: models, Filter: filter, Err: err}
 	}
 }
 
 func (m *Model) scheduleHeartbeat() tea.Cmd {
+	if m.options.Privacy.DisableHeartbeat {
+		return nil
+	}
 	d := m.heartbeatInterval
 	return tea.Tick(d, func(time.Time) tea.Msg {
 		return HeartbeatTickMsg{}
@@ -748,8 +1449,9 @@ func (m *Model) scheduleHeartbeat() tea.Cmd {
 
 func (m *Model) checkForUpdate() tea.Cmd {
 	version := m.options.Version
+	channel := m.options.Update.Channel
 	return func() tea.Msg {
-		res, err := update.Check(context.Background(), version)
+		res, err := update.CheckDaily(context.Background(), config.UpdateCheckFile(), version, channel, m.now())
 		return UpdateCheckMsg{Result: res, Err: err}
 	}
 }
@@ -758,17 +1460,45 @@ func (m *Model) triggerHeartbeat() tea.Cmd {
 	m.streaming = true
 	m.streamContent = ""
 	m.heartbeatStream = true
+	m.agentSteps = 0
+	m.agentHeartbeat = true
+	m.turnEvent = notify.EventHeartbeat
+
+	checkIn := m.heartbeatPrompt
+	m.heartbeatPrompt = ""
+	if checkIn == "" {
+		checkIn = heartbeat.Prompt(m.heartbeatDue, m.options.Language)
+	}
+	m.heartbeatTurn = []provider.Message{{Role: "user", Content: checkIn}}
+	return m.streamHeartbeat()
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
+// streamHeartbeat streams the next reply of the running check-in, which sees
+// the heartbeat tools and context but not the conversation itself.
+func (m *Model) streamHeartbeat() tea.Cmd {
+	ctx, cancel := context.WithCancel(queue.WithLabel(context.Background(), "heartbeat"))
 	m.streamCancelFn = cancel
 
-	sysProm := m.options.SystemPrompt
+	sysProm := m.promptWithTools(m.heartbeatTools())
+	native := m.nativeTools(m.heartbeatTools())
 	model := m.options.Model
 	prov := m.options.Provider
-	lang := m.options.Language
 	numCtx := m.currentNumCtx
+	sampling := m.samplingOptions()
+	home := m.options.Home
+	turn := slices.Clone(m.heartbeatTurn)
+	if extra := m.heartbeatContext(); extra != "" {
+		sysProm = strings.TrimSpace(sysProm + "\n\n" + extra)
+	}
 
 	return func() tea.Msg {
+		// A snapshot of the home lets the check-in notice e.g. an open door
+		if home.Configured() {
+			if states, err := home.States(ctx); err == nil && len(states) > 0 {
+				sysProm = strings.TrimSpace(sysProm + "\n\n" + homeassistant.PromptSection(states, m.now()))
+			}
+		}
+
 		var msgs []provider.Message
 		if sysProm != "" {
 			msgs = append(msgs, provider.Message{
@@ -776,22 +1506,13 @@ func (m *Model) triggerHeartbeat() tea.Cmd {
 				Content: sysProm,
 			})
 		}
+		msgs = append(msgs, turn...)
 
-		// Include conversation context
-		heartbeatPrompt := "[Heartbeat check-in] Review the user's memory and conversation context. If there's something relevant to say, say it briefly. If not, respond with exactly 'HEARTBEAT_SKIP'."
-		if lang != "" && lang != "English" {
-			heartbeatPrompt += " Respond in " + lang + "."
-		}
-
-		msgs = append(msgs, provider.Message{
-			Role:    "user",
-			Content: heartbeatPrompt,
-		})
-
-		ch, err := prov.StreamChat(ctx, provider.ChatRequest{
+		ch, err := native.StreamChat(ctx, prov, provider.ChatRequest{
 			Model:    model,
 			Messages: msgs,
 			NumCtx:   numCtx,
+			Options:  sampling,
 		})
 		if err != nil {
 			return StreamErrMsg{Err: err}
@@ -799,4 +1520,3 @@ func (m *Model) triggerHeartbeat() tea.Cmd {
 		return StreamStartedMsg{Ch: ch}
 	}
 }
-
@@ -2,46 +2,56 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/fetch"
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/prompt"
 	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/update"
 )
 
-// mockProvider implements provider.Provider for testing.
-type mockProvider struct {
-	name       string
-	chatResp   *provider.ChatResponse
-	chatErr    error
-	streamCh   chan provider.StreamDelta
-	streamErr  error
-	models     []provider.ModelInfo
-	modelsErr  error
-	available  error
-}
-
-func (m *mockProvider) Name() string { return m.name }
+// testClock is a clock tests move by hand.
+type testClock struct{ t time.Time }
 
-func (m *mockProvider) Chat(_ context.Context, _ provider.ChatRequest) (*provider.ChatResponse, error) {
-	return m.chatResp, m.chatErr
-}
+func (c *testClock) Now() time.Time { return c.t }
 
-func (m *mockProvider) StreamChat(_ context.Context, _ provider.ChatRequest) (<-chan provider.StreamDelta, error) {
-	if m.streamErr != nil {
-		return nil, m.streamErr
+// newTestModel returns a sized, ready Model for opts. Provider and Model
+// default to a fake and "test-model", and reminders, jobs and the calendar
+// are checked every millisecond.
+func newTestModel(t *testing.T, opts Options) Model {
+	t.Helper()
+	if opts.Provider == nil {
+		opts.Provider = &providertest.Fake{ProviderName: "test"}
 	}
-	return m.streamCh, nil
-}
-
-func (m *mockProvider) ListModels(_ context.Context) ([]provider.ModelInfo, error) {
-	return m.models, m.modelsErr
-}
-
-func (m *mockProvider) IsAvailable(_ context.Context) error {
-	return m.available
+	if opts.Model == "" {
+		opts.Model = "test-model"
+	}
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = time.Millisecond
+	}
+	m := New(opts)
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	return m
 }
 
 func TestInitialView(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -58,10 +68,7 @@ func TestSendMessage(t *testing.T) {
 	ch <- provider.StreamDelta{Content: "Hello!"}
 	ch <- provider.StreamDelta{Done: true}
 
-	mp := &mockProvider{
-		name:     "test",
-		streamCh: ch,
-	}
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -93,7 +100,7 @@ func TestSendMessage(t *testing.T) {
 }
 
 func TestStreamingResponse(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -134,7 +141,7 @@ func TestStreamingResponse(t *testing.T) {
 }
 
 func TestStreamingError(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -157,7 +164,7 @@ func TestStreamingError(t *testing.T) {
 }
 
 func TestInputDisabledDuringStreaming(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -178,7 +185,7 @@ func TestInputDisabledDuringStreaming(t *testing.T) {
 }
 
 func TestQuitCommand(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -197,7 +204,7 @@ func TestQuitCommand(t *testing.T) {
 }
 
 func TestUnknownCommand(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -218,7 +225,7 @@ func TestUnknownCommand(t *testing.T) {
 }
 
 func TestResponseDisplay(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -240,7 +247,7 @@ func TestResponseDisplay(t *testing.T) {
 }
 
 func TestHelpCommand(t *testing.T) {
-	mp := &mockProvider{name: "test"}
+	mp := &providertest.Fake{ProviderName: "test"}
 	m := New(Options{
 		Provider: mp,
 		Model:    "test-model",
@@ -259,3 +266,762 @@ func TestHelpCommand(t *testing.T) {
 		t.Error("help should produce system message")
 	}
 }
+
+func TestSaveCommand_PersistsSettings(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider: mp,
+		Model:    "test-model",
+		Language: "English",
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.textarea.SetValue("/model llama3")
+	m.handleSubmit()
+	if !m.settingsDirty {
+		t.Error("settings should be dirty after /model without autosave")
+	}
+	if !config.IsFirstRun() {
+		t.Error("config should not be written without autosave")
+	}
+
+	m.textarea.SetValue("/heartbeat 2h")
+	m.handleSubmit()
+	m.textarea.SetValue("/save")
+	m.handleSubmit()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Model.Default != "llama3" {
+		t.Errorf("saved model = %q, want llama3", cfg.Model.Default)
+	}
+	if cfg.Heartbeat.Interval != "2h" {
+		t.Errorf("saved interval = %q, want 2h", cfg.Heartbeat.Interval)
+	}
+	if m.settingsDirty {
+		t.Error("settings should be clean after /save")
+	}
+}
+
+func TestSaveCommand_KeepsConfigFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	path := filepath.Join(tmp, "config.yaml")
+	os.WriteFile(path, []byte("# Mine\nlanguage: English\nheartbeat:\n  interval: 1h # hourly\n"), 0o644)
+
+	cfg, _ := config.Load()
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: cfg.Model.Default, Language: cfg.Language})
+	m.width, m.height, m.ready = 80, 24, true
+	m.textarea.SetValue("/heartbeat 2h")
+	m.handleSubmit()
+	m.textarea.SetValue("/save")
+	m.handleSubmit()
+
+	data, _ := os.ReadFile(path)
+	if got := string(data); got != "# Mine\nlanguage: English\nheartbeat:\n  interval: 2h # hourly\n" {
+		t.Errorf("config.yaml =\n%s", got)
+	}
+}
+
+func TestAutosave_PersistsLanguage(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+
+	asm := prompt.NewAssembler(config.PersonalityDir())
+	asm.LoadFiles()
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider:  mp,
+		Model:     "test-model",
+		PromptAsm: asm,
+		Autosave:  true,
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.textarea.SetValue("/language Deutsch")
+	m.handleSubmit()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Language != "Deutsch" {
+		t.Errorf("saved language = %q, want Deutsch", cfg.Language)
+	}
+	data, err := os.ReadFile(filepath.Join(config.PersonalityDir(), prompt.SectionUser))
+	if err != nil {
+		t.Fatalf("USER.md not written: %v", err)
+	}
+	if !strings.Contains(string(data), "- Language: Deutsch") {
+		t.Errorf("USER.md = %q, want language line", data)
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	tests := map[time.Duration]string{
+		2 * time.Hour:              "2h",
+		30 * time.Minute:           "30m",
+		90 * time.Minute:           "1h30m",
+		45 * time.Second:           "45s",
+		time.Hour + 30*time.Second: "1h0m30s",
+	}
+	for d, want := range tests {
+		if got := formatInterval(d); got != want {
+			t.Errorf("formatInterval(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestConfigReload_AppliesChangedSettings(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+	if err := config.Save(config.Defaults()); err != nil {
+		t.Fatal(err)
+	}
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider:    mp,
+		Model:       "runtime-model",
+		WatchConfig: true,
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	cfg := config.Defaults()
+	cfg.Heartbeat.Enabled = true
+	cfg.Heartbeat.Interval = "30m"
+	newM, cmd := m.Update(ConfigReloadMsg{Config: &cfg, ModTime: time.Now()})
+	model := newM.(Model)
+
+	if cmd == nil {
+		t.Error("reload should schedule the next poll")
+	}
+	if !model.heartbeatEnabled || model.heartbeatInterval != 30*time.Minute {
+		t.Errorf("heartbeat = %t/%s, want true/30m", model.heartbeatEnabled, model.heartbeatInterval)
+	}
+	// The model wasn't edited in the file, so the runtime choice is kept
+	if model.options.Model != "runtime-model" {
+		t.Errorf("model = %q, want runtime-model (unchanged in file)", model.options.Model)
+	}
+	last := model.messages[len(model.messages)-1]
+	if !strings.Contains(last.content, "Config reloaded") || !strings.Contains(last.content, "30m") {
+		t.Errorf("unexpected reload message: %q", last.content)
+	}
+}
+
+func TestConfigReload_ErrorKeepsSettings(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	newM, _ := m.Update(ConfigReloadMsg{Err: context.Canceled, ModTime: time.Now()})
+	model := newM.(Model)
+
+	if model.options.Model != "test-model" {
+		t.Errorf("model changed on failed reload: %q", model.options.Model)
+	}
+	last := model.messages[len(model.messages)-1]
+	if !strings.Contains(last.content, "Config reload failed") {
+		t.Errorf("expected reload failure message, got %q", last.content)
+	}
+}
+
+func TestStatusBar_ShowsProfile(t *testing.T) {
+	bar := StatusBar("qwen3:8b", "ollama", "work", "", 80)
+	if !strings.Contains(bar, "[work]") {
+		t.Errorf("status bar %q should show profile", bar)
+	}
+	if strings.Contains(StatusBar("qwen3:8b", "ollama", "", "", 80), "[") {
+		t.Error("default profile should not be shown")
+	}
+}
+
+func TestHeartbeat_PersistsWithoutSave(t *testing.T) {
+	t.Setenv("STEFANCLAW_CONFIG_DIR", t.TempDir())
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 120
+	m.height = 24
+	m.ready = true
+
+	m.textarea.SetValue("/model llama3")
+	m.handleSubmit()
+	m.textarea.SetValue("/heartbeat 90m")
+	m.handleSubmit()
+	m.textarea.SetValue("/heartbeat on")
+	m.handleSubmit()
+
+	if last := m.messages[len(m.messages)-1].content; strings.Contains(last, "/save") {
+		t.Errorf("heartbeat change should not ask for /save: %q", last)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.Heartbeat.Enabled || cfg.Heartbeat.Interval != "1h30m" {
+		t.Errorf("saved heartbeat = %t/%q, want true/1h30m", cfg.Heartbeat.Enabled, cfg.Heartbeat.Interval)
+	}
+	// Only the heartbeat is written; /model still waits for /save
+	if cfg.Model.Default == "llama3" {
+		t.Error("/model should not be saved along with the heartbeat")
+	}
+	if !m.settingsDirty {
+		t.Error("the /model change should still be unsaved")
+	}
+	if v := m.View(); !strings.Contains(v, "heartbeat every 1h30m") {
+		t.Errorf("status bar should show the heartbeat:\n%s", v)
+	}
+
+	m.textarea.SetValue("/heartbeat off")
+	m.handleSubmit()
+	if cfg, _ := config.Load(); cfg.Heartbeat.Enabled {
+		t.Error("/heartbeat off should be saved")
+	}
+	if v := m.View(); !strings.Contains(v, "heartbeat off") {
+		t.Errorf("status bar should show the heartbeat is off:\n%s", v)
+	}
+}
+
+func TestPalette_FromConfig(t *testing.T) {
+	defer applyPalette(config.TUIConfig{})
+
+	mp := &providertest.Fake{ProviderName: "test"}
+	New(Options{Provider: mp, Model: "test-model", TUI: config.TUIConfig{
+		Colors:    config.ColorsConfig{Primary: "#112233", Error: "160"},
+		UserLabel: config.LabelStyle{Color: "#ABCDEF", Italic: true},
+	}})
+
+	if primaryColor != "#112233" || errorColor != "160" {
+		t.Errorf("palette = %s/%s, want #112233/160", primaryColor, errorColor)
+	}
+	if successColor != "#10B981" {
+		t.Errorf("unset success color = %s, want default", successColor)
+	}
+	if fg := userLabelStyle.GetForeground(); fg != lipgloss.Color("#ABCDEF") {
+		t.Errorf("user label color = %v, want #ABCDEF", fg)
+	}
+	if !userLabelStyle.GetItalic() || userLabelStyle.GetBold() {
+		t.Error("user label should be italic and not bold")
+	}
+	if fg := assistantLabelStyle.GetForeground(); fg != successColor {
+		t.Errorf("assistant label color = %v, want success color", fg)
+	}
+}
+
+func TestStreamError_UsesErrorRole(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	newM, _ := m.Update(StreamErrMsg{Err: context.Canceled})
+	model := newM.(Model)
+	if last := model.messages[len(model.messages)-1]; last.role != "error" {
+		t.Errorf("stream error role = %q, want error", last.role)
+	}
+}
+
+func TestSampling_Precedence(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	sess, _ := store.Create("Test", "small")
+
+	var defaults, model, flags provider.Options
+	defaults.Set("temperature", "0.8")
+	defaults.Set("top_p", "0.9")
+	model.Set("temperature", "0.3")
+	flags.Set("seed", "7")
+
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Provider:      mp,
+		Model:         "small",
+		SessionStore:  store,
+		Session:       sess,
+		Sampling:      config.SamplingConfig{Options: defaults, Models: map[string]provider.Options{"small": model}},
+		SamplingFlags: flags,
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.handleCommand(&Command{Name: "sampling", Args: "top_p 0.5"})
+	opts := m.samplingOptions()
+	if opts.String() != "temperature=0.3 top_p=0.5 seed=7" {
+		t.Errorf("effective sampling = %q", opts.String())
+	}
+
+	// The session override is persisted
+	saved, _ := store.Get(sess.ID)
+	if saved.Sampling == nil || saved.Sampling.Get("top_p") != "0.5" {
+		t.Errorf("session sampling not saved: %v", saved.Sampling)
+	}
+
+	m.handleCommand(&Command{Name: "sampling", Args: ""})
+	last := m.messages[len(m.messages)-1].content
+	if !strings.Contains(last, "(session)") || !strings.Contains(last, "(model)") || !strings.Contains(last, "(flag)") {
+		t.Errorf("summary should show sources, got %q", last)
+	}
+
+	m.handleCommand(&Command{Name: "sampling", Args: "reset"})
+	if got := m.samplingOptions().Get("top_p"); got != "0.9" {
+		t.Errorf("top_p after reset = %s, want 0.9", got)
+	}
+
+	// /set is the same command, spelled as in Ollama's CLI
+	m.handleCommand(&Command{Name: "set", Args: "temperature 0.2"})
+	if got := m.samplingOptions().Get("temperature"); got != "0.2" {
+		t.Errorf("temperature after /set = %s, want 0.2", got)
+	}
+}
+
+func TestSampling_InvalidValue(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", Session: &session.Session{ID: "x"}})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.handleCommand(&Command{Name: "sampling", Args: "warmth 1"})
+	last := m.messages[len(m.messages)-1].content
+	if !strings.Contains(last, "unknown sampling option") {
+		t.Errorf("expected unknown option error, got %q", last)
+	}
+}
+
+func TestPrivacy_DisableHeartbeat(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider:  mp,
+		Model:     "test-model",
+		Heartbeat: config.HeartbeatConfig{Enabled: true, Interval: "1h"},
+		Privacy:   config.PrivacyConfig{DisableHeartbeat: true},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	if m.heartbeatEnabled {
+		t.Error("heartbeat should be off when disabled by privacy settings")
+	}
+	if m.scheduleHeartbeat() != nil {
+		t.Error("scheduleHeartbeat should not schedule when disabled")
+	}
+
+	m.handleCommand(&Command{Name: "heartbeat", Args: "on"})
+	if m.heartbeatEnabled {
+		t.Error("/heartbeat on should be refused")
+	}
+	if last := m.messages[len(m.messages)-1].content; last != heartbeatDisabledMsg {
+		t.Errorf("unexpected message: %q", last)
+	}
+}
+
+func TestPrivacy_DisableWeb(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{
+		Provider: mp,
+		Model:    "test-model",
+		Version:  "1.0.0",
+		Privacy:  config.PrivacyConfig{DisableWeb: true},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	_, cmd := m.handleCommand(&Command{Name: "fetch", Args: "https://example.com"})
+	if msg, ok := cmd().(FetchErrMsg); !ok || !errors.Is(msg.Err, fetch.ErrDisabled) {
+		t.Errorf("fetch result = %#v, want FetchErrMsg with ErrDisabled", msg)
+	}
+
+	m.handleCommand(&Command{Name: "update"})
+	if last := m.messages[len(m.messages)-1].content; last != updateDisabledMsg {
+		t.Errorf("unexpected update message: %q", last)
+	}
+}
+
+func TestLanguage_NormalizeAndList(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", Language: "English"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.handleCommand(&Command{Name: "language", Args: "german"})
+	if m.options.Language != "Deutsch" {
+		t.Errorf("language = %q, want Deutsch", m.options.Language)
+	}
+
+	m.handleCommand(&Command{Name: "language", Args: "Pirate English"})
+	if m.options.Language != "Pirate English" {
+		t.Errorf("arbitrary language = %q, want it kept as written", m.options.Language)
+	}
+	if last := m.messages[len(m.messages)-1].content; !strings.Contains(last, "not a known language") {
+		t.Errorf("expected unknown-language note, got %q", last)
+	}
+
+	m.handleCommand(&Command{Name: "language", Args: "list"})
+	last := m.messages[len(m.messages)-1].content
+	if !strings.Contains(last, "Svenska (Swedish)") || m.options.Language != "Pirate English" {
+		t.Errorf("/language list should list languages without changing the setting, got %q", last)
+	}
+}
+
+func TestLanguage_TranslatesInterface(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model", Language: "Deutsch"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.handleCommand(&Command{Name: "help"})
+	help := lastMessage(&m).content
+	for _, want := range []string{"Verfügbare Befehle:", "Diese Hilfe anzeigen", "(Aliase: /h)", "Tasten:", "letzte Antwort kopieren"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("German /help missing %q:\n%s", want, help)
+		}
+	}
+	if m.textarea.Placeholder != "Nachricht eingeben... (Alt+Enter für neue Zeile)" {
+		t.Errorf("placeholder = %q", m.textarea.Placeholder)
+	}
+
+	m.handleCommand(&Command{Name: "language", Args: "English"})
+	if last := lastMessage(&m).content; !strings.HasPrefix(last, "Language changed to: English") {
+		t.Errorf("switching to English should switch the interface, got %q", last)
+	}
+	m.handleCommand(&Command{Name: "help"})
+	if help := lastMessage(&m).content; !strings.Contains(help, "Available commands:") {
+		t.Errorf("/help after switching to English:\n%s", help)
+	}
+}
+
+func TestKeybindings_CustomSubmit(t *testing.T) {
+	ch := make(chan provider.StreamDelta)
+	mp := &providertest.Fake{ProviderName: "test", Replies: []providertest.Reply{{Stream: ch}}}
+	m := New(Options{
+		Provider:    mp,
+		Model:       "test-model",
+		Keybindings: config.KeybindingsConfig{Submit: []string{"ctrl+s"}},
+	})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.textarea.SetValue("hello")
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model, ok := newM.(Model); ok && model.streaming {
+		t.Error("enter should no longer submit when submit is rebound")
+	}
+
+	newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model := newM.(*Model)
+	if !model.streaming {
+		t.Error("ctrl+s should submit")
+	}
+}
+
+func TestKeybindings_Defaults(t *testing.T) {
+	k := newKeyMap(config.KeybindingsConfig{})
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyEnter}, k.Submit) {
+		t.Error("enter should submit by default")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyEnter, Alt: true}, k.Newline) {
+		t.Error("alt+enter should insert a newline by default")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlC}, k.Stop) {
+		t.Error("ctrl+c should stop by default")
+	}
+	if !strings.Contains(k.helpText(nil), "copy last response") {
+		t.Error("help text should describe yank")
+	}
+}
+
+func TestYank_NoResponse(t *testing.T) {
+	mp := &providertest.Fake{ProviderName: "test"}
+	m := New(Options{Provider: mp, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.yankLastResponse()
+	last := m.messages[len(m.messages)-1]
+	if last.content != "No response to copy yet." {
+		t.Errorf("unexpected message: %q", last.content)
+	}
+}
+
+func TestUpdateCheck_Notice(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Version: "1.3.0"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	newM, _ := m.Update(UpdateCheckMsg{Result: &update.Result{CurrentVersion: "1.3.0", LatestVersion: "1.4.0", UpdateAvailable: true}})
+	m = newM.(Model)
+	if got := lastMessage(&m).content; got != "v1.4.0 available — /update to upgrade" {
+		t.Errorf("notice = %q", got)
+	}
+
+	n := len(m.messages)
+	newM, _ = m.Update(UpdateCheckMsg{Result: &update.Result{CurrentVersion: "1.4.0", LatestVersion: "1.4.0"}})
+	m = newM.(Model)
+	newM, _ = m.Update(UpdateCheckMsg{Err: errors.New("offline")})
+	m = newM.(Model)
+	if len(m.messages) != n {
+		t.Errorf("messages shown without an update: %v", m.messages[n:])
+	}
+}
+
+func TestReleaseNotes(t *testing.T) {
+	notes := update.NewNotes(filepath.Join(t.TempDir(), "release-notes.json"))
+	if err := notes.Save("1.4.0", "## Fixes\n- a bug"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shown at the first launch of the new version only
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Version: "1.4.0", ReleaseNotes: notes})
+	if n := len(m.messages); n < 2 || m.messages[n-2].content != "What's new in v1.4.0:" || m.messages[n-1].role != "notes" {
+		t.Fatalf("messages = %v, want the release notes", m.messages)
+	}
+	m = New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Version: "1.4.0", ReleaseNotes: notes})
+	if len(m.messages) > 0 && lastMessage(&m).role == "notes" {
+		t.Error("release notes shown twice")
+	}
+
+	// /update shows them right away
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	newM, _ := m.Update(UpdateApplyMsg{Result: &update.Result{LatestVersion: "1.5.0", Applied: true, ReleaseNotes: "- new things"}})
+	m = newM.(Model)
+	if got := lastMessage(&m); got.role != "notes" || got.content != "- new things" {
+		t.Errorf("last message = %+v, want the release notes", got)
+	}
+}
+
+func TestUpdate_ProgressAndRestart(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Version: "1.3.0"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// This build has no release key, so the update stops before any download
+	_, cmd := m.handleCommand(&Command{Name: "update"})
+	progress := len(m.messages) - 1
+	started, ok := cmd().(UpdateStartedMsg)
+	if !ok {
+		t.Fatalf("/update should start reporting progress")
+	}
+	newM, cmd := m.Update(started)
+	m = newM.(Model)
+
+	newM, _ = m.Update(UpdateProgressMsg{Progress: update.Progress{Stage: update.StageDownload, Version: "1.4.0", Done: 2_500_000, Total: 5_000_000}})
+	m = newM.(Model)
+	if got := m.messages[progress].content; got != "Downloading v1.4.0... 50% (2.5 of 5.0 MB)" {
+		t.Errorf("progress = %q", got)
+	}
+
+	newM, _ = m.Update(cmd())
+	m = newM.(Model)
+	if got := lastMessage(&m).content; !strings.Contains(got, "Update failed") || !strings.Contains(got, "release signing key") {
+		t.Errorf("result = %q, want the missing key", got)
+	}
+
+	m.streaming = true
+	model, cmd := m.handleCommand(&Command{Name: "restart"})
+	if cmd != nil || model.(*Model).RestartRequested() {
+		t.Error("/restart should wait for the reply")
+	}
+	m.streaming = false
+	model, cmd = m.handleCommand(&Command{Name: "restart"})
+	if cmd == nil || !model.(*Model).RestartRequested() {
+		t.Error("/restart should quit and ask to be started again")
+	}
+}
+
+func TestUpdate_PackageManager(t *testing.T) {
+	managed = func() (update.Manager, bool) {
+		return update.Manager{Name: "Homebrew", Upgrade: "brew upgrade stefanclaw"}, true
+	}
+	t.Cleanup(func() { managed = update.Managed })
+
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", Version: "1.3.0"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	if _, cmd := m.handleCommand(&Command{Name: "update"}); cmd != nil {
+		t.Error("/update shouldn't replace a binary Homebrew manages")
+	}
+	if got := lastMessage(&m).content; !strings.Contains(got, "installed with Homebrew") || !strings.Contains(got, "brew upgrade stefanclaw") {
+		t.Errorf("/update = %q, want the brew command", got)
+	}
+
+	newM, _ := m.Update(UpdateCheckMsg{Result: &update.Result{CurrentVersion: "1.3.0", LatestVersion: "1.4.0", UpdateAvailable: true}})
+	m = newM.(Model)
+	if got := lastMessage(&m).content; got != "v1.4.0 available — brew upgrade stefanclaw to upgrade" {
+		t.Errorf("notice = %q", got)
+	}
+}
+
+func TestUpdateViewport_RenderCache(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	m.messages = []displayMessage{
+		{role: "user", content: "Hi"},
+		{role: "assistant", content: "**Hello** there"},
+	}
+	m.updateViewport()
+	if len(m.rendered) != 2 {
+		t.Fatalf("cached %d messages, want 2", len(m.rendered))
+	}
+
+	// Rendered messages are reused rather than rendered again
+	key := renderKey{"assistant", "**Hello** there", 80}
+	m.rendered[key] = "CACHED"
+	m.updateViewport()
+	if !strings.Contains(m.viewport.View(), "CACHED") {
+		t.Error("cached rendering wasn't used")
+	}
+
+	// A changed message or width is rendered anew, and stale entries go
+	m.messages[1].content = "**Hello** again"
+	m.width = 60
+	m.updateViewport()
+	if strings.Contains(m.viewport.View(), "CACHED") {
+		t.Error("changed message shown from the cache")
+	}
+	if _, ok := m.rendered[key]; ok || len(m.rendered) != 2 {
+		t.Errorf("cache = %v, want only the two current messages", m.rendered)
+	}
+}
+
+func TestUpdateViewport_RendersOnlyNearbyMessages(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+	m.viewport.Height = 10
+	for i := range 500 {
+		m.messages = append(m.messages, displayMessage{role: "user", content: fmt.Sprintf("message %d", i)})
+	}
+	m.updateViewport()
+	if len(m.rendered) > 20 {
+		t.Errorf("rendered %d of 500 messages, want only those near the bottom", len(m.rendered))
+	}
+	if !strings.Contains(m.viewport.View(), "message 499") {
+		t.Error("last message not shown")
+	}
+
+	// Scrolling up renders earlier messages without moving the view
+	from := m.viewFrom
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m = newM.(Model)
+	for range 10 {
+		newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+		m = newM.(Model)
+	}
+	if m.viewFrom >= from {
+		t.Fatalf("viewFrom = %d after scrolling up, want less than %d", m.viewFrom, from)
+	}
+	view := m.viewport.View()
+	newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m = newM.(Model)
+	if m.viewport.View() == view {
+		t.Error("scrolling up didn't move the view")
+	}
+	for m.viewFrom > 0 {
+		newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+		m = newM.(Model)
+	}
+	m.viewport.GotoTop()
+	if !strings.Contains(m.viewport.View(), "message 0 ") {
+		t.Errorf("first message not reachable:\n%s", m.viewport.View())
+	}
+}
+
+func TestTranscript_SavedInBackground(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	sess, _ := store.Create("Test", "test-model")
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model", SessionStore: store, Session: sess})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	m.appendTranscript("user", "Hello")
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if got, _ := store.LoadTranscript(sess.ID); len(got) != 1 || got[0].Content != "Hello" {
+		t.Errorf("transcript = %v, want the message", got)
+	}
+
+	newM, _ := m.Update(TranscriptErrMsg{Err: errors.New("disk full")})
+	m = newM.(Model)
+	if got := lastMessage(&m); got.role != "error" || !strings.Contains(got.content, "disk full") {
+		t.Errorf("last message = %+v, want the error", got)
+	}
+}
+
+func TestDebug_Pane(t *testing.T) {
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model"})
+	newM, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = newM.(Model)
+	t.Cleanup(func() { log.SetDebug(false) })
+	height := m.viewport.Height
+
+	m.handleCommand(&Command{Name: "debug", Args: "pane"})
+	if !log.Debugging() || !m.debugPane {
+		t.Fatal("/debug pane didn't turn debug mode and the pane on")
+	}
+	if m.viewport.Height != height-debugPaneHeight {
+		t.Errorf("viewport height = %d, want %d", m.viewport.Height, height-debugPaneHeight)
+	}
+	log.Traffic("test request", []byte(`{"token":"abc"}`))
+	if view := m.View(); !strings.Contains(view, `test request {"token":"[redacted]"}`) {
+		t.Errorf("pane doesn't show the traffic:\n%s", view)
+	}
+
+	m.handleCommand(&Command{Name: "debug", Args: "off"})
+	if log.Debugging() || m.debugPane || m.viewport.Height != height {
+		t.Errorf("/debug off: debugging = %t, pane = %t, height = %d", log.Debugging(), m.debugPane, m.viewport.Height)
+	}
+}
+
+func TestHeartbeat_KeepsConfigFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("STEFANCLAW_CONFIG_DIR", tmp)
+	path := filepath.Join(tmp, "config.yaml")
+	os.WriteFile(path, []byte("# Mine\nlanguage: auto\nheartbeat:\n  interval: 1h\n"), 0o644)
+
+	cfg, _ := config.Load()
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: cfg.Model.Default, Language: cfg.Language, Autosave: true})
+	m.width, m.height, m.ready = 80, 24, true
+	m.textarea.SetValue("/heartbeat on")
+	m.handleSubmit()
+	m.textarea.SetValue("/heartbeat off")
+	m.handleSubmit()
+	m.textarea.SetValue("/heartbeat 2h")
+	m.handleSubmit()
+
+	data, _ := os.ReadFile(path)
+	if got := string(data); got != "# Mine\nlanguage: auto\nheartbeat:\n  interval: 2h\n  enabled: false\n" {
+		t.Errorf("config.yaml =\n%s", got)
+	}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+// handleUsage reports the tokens used in this session, per day of the
+// last days (seven unless given) and per model.
+func handleUsage(m *Model, args string) (tea.Model, tea.Cmd) {
+	reply := func(content string) (tea.Model, tea.Cmd) {
+		m.messages = append(m.messages, displayMessage{role: "system", content: content})
+		m.updateViewport()
+		return m, nil
+	}
+	if m.options.Usage == nil {
+		return reply(m.tr.T("Usage isn't recorded."))
+	}
+	days := 7
+	if args = strings.TrimSpace(args); args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 1 {
+			return reply(m.tr.T("Usage: /usage [days]"))
+		}
+		days = n
+	}
+
+	t := m.now()
+	since := time.Date(t.Year(), t.Month(), t.Day()-days+1, 0, 0, 0, 0, time.Local)
+	records, err := m.options.Usage.Records(since)
+	if err != nil {
+		return reply(m.tr.Sprintf("Reading usage failed: %v", err))
+	}
+	if len(records) == 0 {
+		return reply(m.tr.Sprintf("No replies in the last %d days.", days))
+	}
+
+	var session []usage.Record
+	for _, r := range records {
+		if m.options.Session != nil && r.Session == m.options.Session.ID {
+			session = append(session, r)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(m.tr.T("This session:") + " " + m.usageTotals(usage.Sum(session)))
+	b.WriteString("\n\n" + m.tr.Sprintf("Last %d days:", days))
+	for _, g := range usage.ByDay(records) {
+		b.WriteString("\n  " + g.Key + "  " + m.usageTotals(g.Totals))
+	}
+	b.WriteString("\n\n" + m.tr.T("By model:"))
+	for _, g := range usage.ByModel(records) {
+		b.WriteString("\n  " + g.Key + "  " + m.usageTotals(g.Totals))
+	}
+	return reply(b.String())
+}
+
+// usageTotals summarizes t in the user's language.
+func (m *Model) usageTotals(t usage.Totals) string {
+	s := m.tr.Sprintf("%d replies, %d prompt and %d completion tokens", t.Replies, t.PromptTokens, t.CompletionTokens)
+	if speed := t.Speed(); speed > 0 {
+		s += ", " + m.tr.Sprintf("%.1f tokens/s", speed)
+	}
+	return s
+}
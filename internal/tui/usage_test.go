@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+	"github.com/stefanclaw/stefanclaw/internal/session"
+	"github.com/stefanclaw/stefanclaw/internal/usage"
+)
+
+func TestUsage_RecordAndReport(t *testing.T) {
+	log := usage.NewLog(filepath.Join(t.TempDir(), "usage.jsonl"))
+	log.Add(usage.Record{Time: time.Now().AddDate(0, 0, -1), Session: "old", Model: "gemma3:1b", PromptTokens: 50, CompletionTokens: 5})
+	log.Add(usage.Record{Time: time.Now().AddDate(0, 0, -30), Model: "gemma3:1b", PromptTokens: 1000})
+	m := New(Options{Provider: providertest.New(), Model: "qwen3:8b", Session: &session.Session{ID: "s1"}, Usage: log})
+	m.width, m.height, m.ready = 80, 24, true
+	m.streaming = true
+
+	newM, _ := m.Update(StreamDoneMsg{Usage: &provider.Usage{PromptTokens: 200, CompletionTokens: 40, EvalDuration: 2 * time.Second}})
+	m = newM.(Model)
+	records, _ := log.Records(time.Time{})
+	if r := records[len(records)-1]; r.Session != "s1" || r.Model != "qwen3:8b" || r.CompletionTokens != 40 {
+		t.Errorf("recorded %+v", r)
+	}
+
+	handleUsage(&m, "")
+	got := lastMessage(&m).content
+	for _, want := range []string{
+		"This session: 1 replies, 200 prompt and 40 completion tokens, 20.0 tokens/s",
+		"Last 7 days:",
+		time.Now().Format(time.DateOnly) + "  1 replies",
+		"qwen3:8b  1 replies",
+		"gemma3:1b  1 replies, 50 prompt and 5 completion tokens",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("/usage = %q, want %q", got, want)
+		}
+	}
+	if strings.Contains(got, "1000 prompt") {
+		t.Errorf("/usage = %q, includes a reply from a month ago", got)
+	}
+
+	handleUsage(&m, "0")
+	if got := lastMessage(&m).content; got != "Usage: /usage [days]" {
+		t.Errorf("/usage 0 = %q", got)
+	}
+}
+
+func TestUsage_Off(t *testing.T) {
+	m := New(Options{Provider: providertest.New(), Model: "qwen3:8b"})
+	m.width, m.height, m.ready = 80, 24, true
+	handleUsage(&m, "")
+	if got := lastMessage(&m).content; got != "Usage isn't recorded." {
+		t.Errorf("/usage = %q", got)
+	}
+}
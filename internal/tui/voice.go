@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/voice"
+)
+
+// VoiceDoneMsg carries the transcription of a voice recording.
+type VoiceDoneMsg struct {
+	Text string
+	Err  error
+}
+
+// record starts a recording; tests replace it.
+var record = func(recorder string) (voiceRecording, error) {
+	return voice.Record(recorder)
+}
+
+// voiceRecording is a recording in progress (a *voice.Recording).
+type voiceRecording interface {
+	Stop() (string, error)
+	Cancel()
+}
+
+// toggleVoice starts push-to-talk recording, or stops it and transcribes
+// what was said in the background.
+func (m *Model) toggleVoice() tea.Cmd {
+	say := func(role, content string) tea.Cmd {
+		m.messages = append(m.messages, displayMessage{role: role, content: content})
+		m.updateViewport()
+		return nil
+	}
+	cfg := m.options.Voice
+	switch {
+	case !cfg.Enabled:
+		return say("system", m.tr.T("Voice input is off. Set voice.enabled: true in config.yaml and point voice.url at a Whisper server."))
+	case m.transcribing:
+		return nil
+	case m.recording == nil:
+		r, err := record(cfg.Recorder)
+		if err != nil {
+			return say("error", m.tr.Sprintf("Recording failed: %v", err))
+		}
+		m.recording = r
+		return say("system", fmt.Sprintf("🎙 Recording... press %s again to stop.", m.keys.Voice.Help().Key))
+	}
+
+	path, err := m.recording.Stop()
+	m.recording = nil
+	if err != nil {
+		return say("error", m.tr.Sprintf("Recording failed: %v", err))
+	}
+	m.transcribing = true
+	say("system", "Transcribing...")
+	t := voice.NewTranscriber(cfg)
+	return func() tea.Msg {
+		defer os.Remove(path)
+		text, err := t.Transcribe(context.Background(), path)
+		return VoiceDoneMsg{Text: text, Err: err}
+	}
+}
+
+// cancelRecording discards a recording in progress.
+func (m *Model) cancelRecording() {
+	m.recording.Cancel()
+	m.recording = nil
+	m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("Recording discarded.")})
+	m.updateViewport()
+}
+
+// handleVoiceDone puts the transcription into the input box so it can be
+// corrected before sending.
+func (m *Model) handleVoiceDone(msg VoiceDoneMsg) {
+	m.transcribing = false
+	switch {
+	case msg.Err != nil:
+		m.messages = append(m.messages, displayMessage{role: "error", content: m.tr.Sprintf("Transcription failed: %v", msg.Err)})
+	case msg.Text == "":
+		m.messages = append(m.messages, displayMessage{role: "system", content: m.tr.T("No speech recognized.")})
+	default:
+		if m.textarea.Value() != "" {
+			m.textarea.InsertString(" ")
+		}
+		m.textarea.InsertString(msg.Text)
+	}
+	m.updateViewport()
+}
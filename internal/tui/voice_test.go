@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+type fakeRecording struct {
+	path      string
+	cancelled bool
+}
+
+func (r *fakeRecording) Stop() (string, error) { return r.path, nil }
+func (r *fakeRecording) Cancel()               { r.cancelled = true }
+
+func TestVoice_PushToTalk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"text": "what's on today"})
+	}))
+	defer srv.Close()
+
+	var rec *fakeRecording
+	orig := record
+	t.Cleanup(func() { record = orig })
+	record = func(string) (voiceRecording, error) {
+		path := filepath.Join(t.TempDir(), "speech.wav")
+		os.WriteFile(path, []byte("RIFF"), 0o644)
+		rec = &fakeRecording{path: path}
+		return rec, nil
+	}
+
+	m := New(Options{Provider: &providertest.Fake{ProviderName: "test"}, Model: "test-model"})
+	m.width = 80
+	m.height = 24
+	m.ready = true
+
+	// Off by default
+	if cmd := m.toggleVoice(); cmd != nil || m.recording != nil {
+		t.Fatal("voice input should be off by default")
+	}
+	if got := lastMessage(&m); !strings.Contains(got.content, "voice.enabled") {
+		t.Errorf("last message = %+v, want how to enable it", got)
+	}
+
+	m.options.Voice = config.VoiceConfig{Enabled: true, Backend: "whisper.cpp", URL: srv.URL}
+	m.toggleVoice()
+	if m.recording == nil {
+		t.Fatal("first press should start recording")
+	}
+	if got := lastMessage(&m); !strings.Contains(got.content, "ctrl+r") {
+		t.Errorf("last message = %+v, want the stop key", got)
+	}
+
+	m.textarea.SetValue("Hey,")
+	msgs := collectMsgs(m.toggleVoice())
+	if m.recording != nil || !m.transcribing || len(msgs) != 1 {
+		t.Fatalf("second press should stop and transcribe, got %d messages", len(msgs))
+	}
+	newM, _ := m.Update(msgs[0])
+	m = newM.(Model)
+	if got := m.textarea.Value(); got != "Hey, what's on today" {
+		t.Errorf("input = %q, want the transcription appended for review", got)
+	}
+	if _, err := os.Stat(rec.path); !os.IsNotExist(err) {
+		t.Error("the recording should be removed after transcription")
+	}
+
+	// Ctrl+C discards a recording instead of quitting
+	m.toggleVoice()
+	newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = newM.(Model)
+	if m.quitting || m.recording != nil || !rec.cancelled {
+		t.Errorf("ctrl+c should cancel the recording (quitting=%t)", m.quitting)
+	}
+}
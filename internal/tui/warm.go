@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanclaw/stefanclaw/internal/log"
+	"github.com/stefanclaw/stefanclaw/internal/provider"
+)
+
+// ModelWarmedMsg reports that the provider loaded Model ahead of the first
+// message, or failed to.
+type ModelWarmedMsg struct {
+	Model   string
+	Elapsed time.Duration
+	Err     error
+}
+
+// warmTimeout bounds loading a model; a large one from a slow disk takes
+// minutes.
+const warmTimeout = 5 * time.Minute
+
+// warmModel loads the current model in the background, if warming is on
+// and the provider loads models on first use, so that the first message
+// doesn't wait for it. A message sent meanwhile waits for the same load.
+func (m *Model) warmModel() tea.Cmd {
+	w, ok := m.options.Provider.(provider.Warmer)
+	if !m.options.Warm || !ok {
+		return nil
+	}
+	model := m.options.Model
+	m.warming = model
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), warmTimeout)
+		defer cancel()
+		start := time.Now()
+		err := w.Warm(ctx, model)
+		return ModelWarmedMsg{Model: model, Elapsed: time.Since(start), Err: err}
+	}
+}
+
+// handleModelWarmed records that a model finished loading. Failures are
+// only logged: the first message reports them if they last.
+func (m *Model) handleModelWarmed(msg ModelWarmedMsg) {
+	if msg.Model == m.warming {
+		m.warming = ""
+	}
+	switch {
+	case errors.Is(msg.Err, errors.ErrUnsupported):
+	case msg.Err != nil:
+		log.Warn("loading model ahead failed", "model", msg.Model, "err", msg.Err)
+	default:
+		log.Info("model loaded ahead", "model", msg.Model, "duration", msg.Elapsed)
+	}
+}
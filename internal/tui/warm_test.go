@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stefanclaw/stefanclaw/internal/provider/providertest"
+)
+
+// warmingProvider records the models it was asked to load.
+type warmingProvider struct {
+	providertest.Fake
+	mu     sync.Mutex
+	warmed []string
+}
+
+func (p *warmingProvider) Warm(ctx context.Context, model string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.warmed = append(p.warmed, model)
+	return nil
+}
+
+func TestWarmModel(t *testing.T) {
+	p := &warmingProvider{}
+	m := New(Options{Provider: p, Model: "qwen3:8b", Warm: true})
+	m.width, m.height, m.ready = 80, 24, true
+
+	cmd := m.warmModel()
+	if cmd == nil || m.warming != "qwen3:8b" {
+		t.Fatalf("not warming: %q", m.warming)
+	}
+	m.streaming = true
+	m.updateViewport()
+	if !strings.Contains(m.viewport.View(), "Loading qwen3:8b...") {
+		t.Error("a message sent while loading doesn't say so")
+	}
+
+	newM, _ := m.Update(cmd())
+	m = newM.(Model)
+	if m.warming != "" || len(p.warmed) != 1 || p.warmed[0] != "qwen3:8b" {
+		t.Errorf("warming = %q, warmed = %v", m.warming, p.warmed)
+	}
+}
+
+func TestWarmModel_OnSwitch(t *testing.T) {
+	p := &warmingProvider{}
+	m := New(Options{Provider: p, Model: "qwen3:8b", Warm: true})
+	m.width, m.height, m.ready = 80, 24, true
+
+	_, cmd := m.handleCommand(&Command{Name: "model", Args: "llama3.1:8b"})
+	for _, msg := range collectMsgs(cmd) {
+		newM, _ := m.Update(msg)
+		m = newM.(Model)
+	}
+	if len(p.warmed) != 1 || p.warmed[0] != "llama3.1:8b" {
+		t.Errorf("warmed = %v, want the model switched to", p.warmed)
+	}
+}
+
+func TestWarmModel_Off(t *testing.T) {
+	m := New(Options{Provider: &warmingProvider{}, Model: "qwen3:8b"})
+	if m.warmModel() != nil {
+		t.Error("warming with provider.ollama.warm off")
+	}
+	m = New(Options{Provider: &providertest.Fake{}, Model: "qwen3:8b", Warm: true})
+	if m.warmModel() != nil {
+		t.Error("warming with a provider that can't")
+	}
+}
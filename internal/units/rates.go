@@ -0,0 +1,155 @@
+package units
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ratesURL serves the European Central Bank's daily reference rates.
+const ratesURL = "https://api.frankfurter.dev/v1/latest"
+
+// ratesTTL is how long fetched rates are used before asking again; the ECB
+// publishes once per working day.
+const ratesTTL = 12 * time.Hour
+
+// rateTable is the API response, also stored as the cache file.
+type rateTable struct {
+	Base    string             `json:"base"`
+	Date    string             `json:"date"`
+	Rates   map[string]float64 `json:"rates"`
+	Fetched time.Time          `json:"fetched"`
+}
+
+// Rates converts between currencies. Rates are fetched at most every 12
+// hours and cached in a file, which is also used when the API can't be
+// reached. It is safe for concurrent use.
+type Rates struct {
+	path   string
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	table *rateTable
+}
+
+// NewRates creates Rates cached in the file at path.
+func NewRates(path string) *Rates {
+	return &Rates{path: path, url: ratesURL, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// IsCurrency reports whether code looks like an ISO 4217 currency code.
+func IsCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range strings.ToUpper(code) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Convert converts amount from one currency to another and returns the
+// result with the date of the rates used.
+func (r *Rates) Convert(ctx context.Context, amount float64, from, to string) (float64, string, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	t, err := r.get(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	rate := func(code string) (float64, error) {
+		if code == t.Base {
+			return 1, nil
+		}
+		if v, ok := t.Rates[code]; ok && v > 0 {
+			return v, nil
+		}
+		return 0, fmt.Errorf("no exchange rate for %s", code)
+	}
+	fr, err := rate(from)
+	if err != nil {
+		return 0, "", err
+	}
+	tr, err := rate(to)
+	if err != nil {
+		return 0, "", err
+	}
+	return amount / fr * tr, t.Date, nil
+}
+
+// get returns fresh rates, falling back to older ones if fetching fails.
+func (r *Rates) get(ctx context.Context) (*rateTable, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.table == nil {
+		r.table = r.load()
+	}
+	if r.table != nil && time.Since(r.table.Fetched) < ratesTTL {
+		return r.table, nil
+	}
+	t, err := r.fetch(ctx)
+	if err != nil {
+		if r.table != nil {
+			return r.table, nil
+		}
+		return nil, fmt.Errorf("fetching exchange rates: %w", err)
+	}
+	r.table = t
+	r.save(t)
+	return t, nil
+}
+
+func (r *Rates) fetch(ctx context.Context) (*rateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var t rateTable
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	if t.Base == "" || len(t.Rates) == 0 {
+		return nil, fmt.Errorf("no rates in response")
+	}
+	t.Fetched = time.Now()
+	return &t, nil
+}
+
+func (r *Rates) load() *rateTable {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil
+	}
+	var t rateTable
+	if json.Unmarshal(data, &t) != nil || len(t.Rates) == 0 {
+		return nil
+	}
+	return &t
+}
+
+// save writes the cache file; failing to cache is not an error.
+func (r *Rates) save(t *rateTable) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(r.path), 0o755) == nil {
+		os.WriteFile(r.path, data, 0o644)
+	}
+}
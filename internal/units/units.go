@@ -0,0 +1,209 @@
+// Package units converts between units of measurement and, with exchange
+// rates from the European Central Bank, between currencies.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownUnit is returned for a unit that is not in the table.
+var ErrUnknownUnit = errors.New("unknown unit")
+
+type unit struct {
+	dim    string
+	factor float64 // size in the dimension's base unit
+}
+
+// table maps unit names and abbreviations, lowercase and singular, to their
+// size. Temperatures are handled separately.
+var table = map[string]unit{}
+
+func define(dim string, factor float64, names ...string) {
+	for _, n := range names {
+		table[n] = unit{dim, factor}
+	}
+}
+
+func init() {
+	define("length", 1e-3, "mm", "millimeter", "millimetre")
+	define("length", 1e-2, "cm", "centimeter", "centimetre")
+	define("length", 1, "m", "meter", "metre")
+	define("length", 1e3, "km", "kilometer", "kilometre")
+	define("length", 0.0254, "in", "inch", `"`)
+	define("length", 0.3048, "ft", "foot", "feet", "'")
+	define("length", 0.9144, "yd", "yard")
+	define("length", 1609.344, "mi", "mile")
+	define("length", 1852, "nmi", "nautical mile")
+
+	define("mass", 1e-6, "mg", "milligram")
+	define("mass", 1e-3, "g", "gram", "gramme")
+	define("mass", 1, "kg", "kilogram", "kilo")
+	define("mass", 1e3, "t", "tonne", "metric ton")
+	define("mass", 0.028349523125, "oz", "ounce")
+	define("mass", 0.45359237, "lb", "lbs", "pound")
+	define("mass", 6.35029318, "st", "stone")
+
+	define("volume", 1e-3, "ml", "milliliter", "millilitre")
+	define("volume", 1e-2, "cl", "centiliter", "centilitre")
+	define("volume", 0.1, "dl", "deciliter", "decilitre")
+	define("volume", 1, "l", "liter", "litre")
+	define("volume", 1e3, "m3", "cubic meter", "cubic metre")
+	define("volume", 0.00492892159375, "tsp", "teaspoon")
+	define("volume", 0.01478676478125, "tbsp", "tablespoon")
+	define("volume", 0.0295735295625, "fl oz", "floz", "fluid ounce")
+	define("volume", 0.2365882365, "cup")
+	define("volume", 0.473176473, "pt", "pint")
+	define("volume", 0.946352946, "qt", "quart")
+	define("volume", 3.785411784, "gal", "gallon")
+
+	define("area", 1e-4, "cm2", "square centimeter", "square centimetre")
+	define("area", 1, "m2", "square meter", "square metre")
+	define("area", 1e4, "ha", "hectare")
+	define("area", 1e6, "km2", "square kilometer", "square kilometre")
+	define("area", 0.09290304, "ft2", "sq ft", "square foot", "square feet")
+	define("area", 4046.8564224, "acre")
+	define("area", 2589988.110336, "mi2", "sq mi", "square mile")
+
+	define("speed", 1, "m/s", "mps", "meter per second", "metre per second")
+	define("speed", 1/3.6, "km/h", "kmh", "kph", "kilometer per hour", "kilometre per hour")
+	define("speed", 0.44704, "mph", "mile per hour")
+	define("speed", 1852.0/3600, "kn", "kt", "knot")
+
+	define("time", 1e-3, "ms", "millisecond")
+	define("time", 1, "s", "sec", "second")
+	define("time", 60, "min", "minute")
+	define("time", 3600, "h", "hr", "hour")
+	define("time", 86400, "d", "day")
+	define("time", 604800, "wk", "week")
+	define("time", 31557600, "yr", "year") // Julian year
+
+	define("data", 1.0/8, "bit")
+	define("data", 1, "b", "byte")
+	define("data", 1e3, "kb", "kilobyte")
+	define("data", 1e6, "mb", "megabyte")
+	define("data", 1e9, "gb", "gigabyte")
+	define("data", 1e12, "tb", "terabyte")
+	define("data", 1<<10, "kib", "kibibyte")
+	define("data", 1<<20, "mib", "mebibyte")
+	define("data", 1<<30, "gib", "gibibyte")
+	define("data", 1<<40, "tib", "tebibyte")
+
+	define("energy", 1, "j", "joule")
+	define("energy", 1e3, "kj", "kilojoule")
+	define("energy", 4.184, "cal", "calorie")
+	define("energy", 4184, "kcal", "kilocalorie")
+	define("energy", 3600, "wh", "watt hour")
+	define("energy", 3.6e6, "kwh", "kilowatt hour")
+
+	define("pressure", 1, "pa", "pascal")
+	define("pressure", 100, "hpa", "hectopascal", "mbar", "millibar")
+	define("pressure", 1e3, "kpa", "kilopascal")
+	define("pressure", 1e5, "bar")
+	define("pressure", 6894.757293168, "psi")
+	define("pressure", 101325, "atm", "atmosphere")
+}
+
+// temperatures maps temperature names to their scale.
+var temperatures = map[string]string{
+	"c": "C", "°c": "C", "celsius": "C", "degree celsius": "C",
+	"f": "F", "°f": "F", "fahrenheit": "F", "degree fahrenheit": "F",
+	"k": "K", "kelvin": "K",
+}
+
+// normalize lowercases a unit name and removes a plural "s".
+func normalize(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	name = strings.ReplaceAll(name, "²", "2")
+	name = strings.ReplaceAll(name, "³", "3")
+	if _, ok := table[name]; ok {
+		return name
+	}
+	if _, ok := temperatures[name]; ok {
+		return name
+	}
+	for _, suffix := range []string{"s", "es"} {
+		if base := strings.TrimSuffix(name, suffix); base != name {
+			if _, ok := table[base]; ok {
+				return base
+			}
+			if _, ok := temperatures[base]; ok {
+				return base
+			}
+		}
+	}
+	// "miles per hour", "degrees celsius"
+	if first, rest, ok := strings.Cut(name, " "); ok && strings.HasSuffix(first, "s") {
+		if base := strings.TrimSuffix(first, "s") + " " + rest; table[base] != (unit{}) || temperatures[base] != "" {
+			return base
+		}
+	}
+	return name
+}
+
+// Known reports whether name is a unit Convert understands.
+func Known(name string) bool {
+	name = normalize(name)
+	_, ok := table[name]
+	return ok || temperatures[name] != ""
+}
+
+// Convert converts value from one unit to another of the same kind, e.g.
+// Convert(5, "miles", "km").
+func Convert(value float64, from, to string) (float64, error) {
+	f, t := normalize(from), normalize(to)
+	if fs, ts := temperatures[f], temperatures[t]; fs != "" || ts != "" {
+		if fs == "" || ts == "" {
+			return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+		}
+		return fromKelvin(toKelvin(value, fs), ts), nil
+	}
+	fu, ok := table[f]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownUnit, from)
+	}
+	tu, ok := table[t]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownUnit, to)
+	}
+	if fu.dim != tu.dim {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, fu.dim, to, tu.dim)
+	}
+	return value * fu.factor / tu.factor, nil
+}
+
+func toKelvin(v float64, scale string) float64 {
+	switch scale {
+	case "C":
+		return v + 273.15
+	case "F":
+		return (v-32)*5/9 + 273.15
+	}
+	return v
+}
+
+func fromKelvin(v float64, scale string) float64 {
+	switch scale {
+	case "C":
+		return v - 273.15
+	case "F":
+		return (v-273.15)*9/5 + 32
+	}
+	return v
+}
+
+// Format formats a converted value without floating point noise, to at most
+// eight significant digits.
+func Format(v float64) string {
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	if a := math.Abs(v); a >= 1e15 || a < 1e-6 {
+		return strconv.FormatFloat(v, 'g', 8, 64)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 8, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -0,0 +1,142 @@
+package units
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     string
+	}{
+		{5, "miles", "km", "8.04672"},
+		{1, "Kilometres", "m", "1000"},
+		{6, "feet", "in", "72"},
+		{100, "°F", "C", "37.777778"},
+		{-40, "degrees Celsius", "fahrenheit", "-40"},
+		{0, "C", "K", "273.15"},
+		{2, "cups", "ml", "473.17647"},
+		{1, "gallon", "l", "3.7854118"},
+		{10, "kg", "lbs", "22.046226"},
+		{100, "km/h", "mph", "62.137119"},
+		{90, "minutes", "hours", "1.5"},
+		{1, "GiB", "MB", "1073.7418"},
+		{1, "kWh", "kcal", "860.42065"},
+		{1013.25, "hPa", "atm", "1"},
+		{3, "m²", "ft2", "32.291731"},
+		{0.1 + 0.2, "l", "l", "0.3"},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.value, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %q) error: %v", tt.value, tt.from, tt.to, err)
+			continue
+		}
+		if s := Format(got); s != tt.want {
+			t.Errorf("Convert(%v, %q, %q) = %s, want %s", tt.value, tt.from, tt.to, s, tt.want)
+		}
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	if _, err := Convert(1, "kg", "m"); err == nil || !strings.Contains(err.Error(), "mass") {
+		t.Errorf("kg to m error = %v, want a dimension mismatch", err)
+	}
+	if _, err := Convert(1, "C", "m"); err == nil {
+		t.Error("C to m should fail")
+	}
+	if _, err := Convert(1, "furlong", "m"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("furlong error = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[float64]string{
+		0:           "0",
+		1234567.891: "1234567.9",
+		1e20:        "1e+20",
+		0.000000123: "1.23e-07",
+		-2.5:        "-2.5",
+	}
+	for v, want := range tests {
+		if got := Format(v); got != want {
+			t.Errorf("Format(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestRates(t *testing.T) {
+	requests := 0
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !up {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"amount":1.0,"base":"EUR","date":"2026-10-14","rates":{"USD":1.25,"JPY":160}}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cache", "rates.json")
+	r := NewRates(path)
+	r.url = srv.URL
+
+	got, date, err := r.Convert(context.Background(), 100, "usd", "EUR")
+	if err != nil || got != 80 || date != "2026-10-14" {
+		t.Fatalf("Convert(USD→EUR) = %v, %q, %v", got, date, err)
+	}
+	if got, _, _ := r.Convert(context.Background(), 10, "USD", "JPY"); math.Abs(got-1280) > 1e-9 {
+		t.Errorf("Convert(USD→JPY) = %v, want 1280", got)
+	}
+	if _, _, err := r.Convert(context.Background(), 1, "EUR", "XYZ"); err == nil {
+		t.Error("unknown currency should fail")
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1 while the rates are fresh", requests)
+	}
+
+	// A new instance uses the cache file; stale rates are used while the
+	// API is down
+	var cached rateTable
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &cached)
+	cached.Fetched = time.Now().Add(-48 * time.Hour)
+	data, _ = json.Marshal(cached)
+	os.WriteFile(path, data, 0o644)
+	up = false
+
+	r = NewRates(path)
+	r.url = srv.URL
+	if got, date, err := r.Convert(context.Background(), 5, "EUR", "USD"); err != nil || got != 6.25 || date != "2026-10-14" {
+		t.Errorf("stale Convert = %v, %q, %v", got, date, err)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want a refresh attempt for stale rates", requests)
+	}
+
+	r = NewRates(filepath.Join(t.TempDir(), "rates.json"))
+	r.url = srv.URL
+	if _, _, err := r.Convert(context.Background(), 1, "EUR", "USD"); err == nil {
+		t.Error("Convert without rates should fail")
+	}
+}
+
+func TestIsCurrency(t *testing.T) {
+	for code, want := range map[string]bool{"USD": true, "eur": true, "US$": false, "EURO": false, "": false} {
+		if got := IsCurrency(code); got != want {
+			t.Errorf("IsCurrency(%q) = %t, want %t", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CheckInterval is how often CheckDaily asks GitHub.
+const CheckInterval = 24 * time.Hour
+
+// check is Check, replaced in tests.
+var check = Check
+
+// checkCache is the outcome of the last check, stored in CheckDaily's file.
+type checkCache struct {
+	Checked time.Time `json:"checked"`
+	Channel string    `json:"channel"`
+	Latest  string    `json:"latest"`
+}
+
+// CheckDaily is Check rate-limited to once per CheckInterval. Until then it
+// answers from the latest version cached in the file at path, so a release
+// found earlier is still reported. Failed checks aren't cached and are
+// retried on the next call.
+func CheckDaily(ctx context.Context, path, currentVersion, channel string, now time.Time) (*Result, error) {
+	if c := loadCheckCache(path); c != nil && c.Channel == channel && now.Sub(c.Checked) < CheckInterval {
+		res := &Result{CurrentVersion: currentVersion, LatestVersion: c.Latest}
+		res.UpdateAvailable = c.Latest != "" && newer(c.Latest, currentVersion)
+		return res, nil
+	}
+	res, err := check(ctx, currentVersion, channel)
+	if err != nil {
+		return nil, err
+	}
+	saveCheckCache(path, &checkCache{Checked: now, Channel: channel, Latest: res.LatestVersion})
+	return res, nil
+}
+
+// newer reports whether latest is newer than current. Any release is newer
+// than a version that isn't semver (e.g. "dev").
+func newer(latest, current string) bool {
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+	v, err := semver.NewVersion(latest)
+	return err == nil && v.GreaterThan(cur)
+}
+
+func loadCheckCache(path string) *checkCache {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var c checkCache
+	if json.Unmarshal(data, &c) != nil {
+		return nil
+	}
+	return &c
+}
+
+// saveCheckCache writes the cache file; failing to cache is not an error.
+func saveCheckCache(path string, c *checkCache) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+		os.WriteFile(path, data, 0o644)
+	}
+}
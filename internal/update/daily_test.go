@@ -0,0 +1,78 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckDaily(t *testing.T) {
+	calls := 0
+	latest := "1.4.0"
+	var fail error
+	check = func(ctx context.Context, currentVersion, channel string) (*Result, error) {
+		calls++
+		if fail != nil {
+			return nil, fail
+		}
+		return &Result{CurrentVersion: currentVersion, LatestVersion: latest, UpdateAvailable: newer(latest, currentVersion)}, nil
+	}
+	t.Cleanup(func() { check = Check })
+
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	res, err := CheckDaily(context.Background(), path, "1.3.0", ChannelStable, start)
+	if err != nil || !res.UpdateAvailable || res.LatestVersion != "1.4.0" || calls != 1 {
+		t.Fatalf("first check = %+v, %v after %d calls, want 1.4.0 available after 1", res, err, calls)
+	}
+
+	// Within a day the cached result answers, even after updating.
+	latest = "1.5.0"
+	res, err = CheckDaily(context.Background(), path, "1.3.0", ChannelStable, start.Add(23*time.Hour))
+	if err != nil || !res.UpdateAvailable || res.LatestVersion != "1.4.0" || calls != 1 {
+		t.Errorf("cached check = %+v, %v after %d calls, want cached 1.4.0", res, err, calls)
+	}
+	res, _ = CheckDaily(context.Background(), path, "1.4.0", ChannelStable, start.Add(time.Hour))
+	if res.UpdateAvailable {
+		t.Error("cached release reported although it is installed")
+	}
+
+	// Another channel isn't answered from the cache.
+	if CheckDaily(context.Background(), path, "1.3.0", ChannelBeta, start.Add(time.Hour)); calls != 2 {
+		t.Errorf("calls = %d after switching channel, want 2", calls)
+	}
+
+	// A day later GitHub is asked again; failures aren't cached.
+	fail = errors.New("offline")
+	day := start.Add(25 * time.Hour)
+	if _, err := CheckDaily(context.Background(), path, "1.3.0", ChannelBeta, day); err == nil {
+		t.Error("expected the check's error")
+	}
+	fail = nil
+	res, err = CheckDaily(context.Background(), path, "1.3.0", ChannelBeta, day)
+	if err != nil || res.LatestVersion != "1.5.0" || calls != 4 {
+		t.Errorf("next day = %+v, %v after %d calls, want 1.5.0 after 4", res, err, calls)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"1.4.0", "1.3.0", true},
+		{"1.3.0", "1.3.0", false},
+		{"1.2.0", "1.3.0", false},
+		{"1.4.0-beta.1", "1.3.0", true},
+		{"1.4.0", "dev", true},
+		{"garbage", "1.3.0", false},
+	}
+	for _, tt := range tests {
+		if got := newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("newer(%q, %q) = %t, want %t", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// managedBy names the package manager a build is made for ("homebrew",
+// "aur" or "nix"). Packagers set it with
+// -ldflags "-X github.com/stefanclaw/stefanclaw/internal/update.managedBy=...";
+// other builds recognize managed installs by their path.
+var managedBy string
+
+// Manager is a package manager that installed stefanclaw. Its binary must
+// not be replaced by Apply: the package manager would not know about it.
+type Manager struct {
+	Name    string // e.g. "Homebrew"
+	Upgrade string // command that updates stefanclaw
+}
+
+var managers = map[string]Manager{
+	"homebrew": {Name: "Homebrew", Upgrade: "brew upgrade stefanclaw"},
+	"aur":      {Name: "the AUR", Upgrade: "yay -Syu stefanclaw"},
+	"nix":      {Name: "Nix", Upgrade: "nix profile upgrade stefanclaw"},
+}
+
+// Managed reports which package manager installed the running binary, if
+// any.
+func Managed() (Manager, bool) {
+	exe, err := os.Executable()
+	if err != nil {
+		return Manager{}, false
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	_, err = os.Stat("/etc/arch-release")
+	return detectManager(managedBy, exe, err == nil)
+}
+
+// detectManager finds the package manager of the binary at exe: the one a
+// build was made for, or else one whose install path exe is in. Binaries in
+// /usr/bin on Arch Linux are taken to come from the AUR, as stefanclaw isn't
+// in the official repositories.
+func detectManager(managedBy, exe string, archLinux bool) (Manager, bool) {
+	if m, ok := managers[managedBy]; ok {
+		return m, true
+	}
+	exe = filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(exe, "/Cellar/"), strings.HasPrefix(exe, "/opt/homebrew/"), strings.HasPrefix(exe, "/home/linuxbrew/"):
+		return managers["homebrew"], true
+	case strings.HasPrefix(exe, "/nix/store/"):
+		return managers["nix"], true
+	case archLinux && strings.HasPrefix(exe, "/usr/bin/"):
+		return managers["aur"], true
+	}
+	return Manager{}, false
+}
+
+// Advice tells the user to update through m instead of stefanclaw.
+func (m Manager) Advice() string {
+	return "stefanclaw was installed with " + m.Name + "; update it there instead:\n  " + m.Upgrade
+}
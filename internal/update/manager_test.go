@@ -0,0 +1,31 @@
+package update
+
+import "testing"
+
+func TestDetectManager(t *testing.T) {
+	tests := []struct {
+		managedBy, exe string
+		arch           bool
+		want           string
+	}{
+		{"", "/opt/homebrew/bin/stefanclaw", false, "Homebrew"},
+		{"", "/usr/local/Cellar/stefanclaw/1.4.0/bin/stefanclaw", false, "Homebrew"},
+		{"", "/home/linuxbrew/.linuxbrew/bin/stefanclaw", false, "Homebrew"},
+		{"", "/nix/store/abc123-stefanclaw-1.4.0/bin/stefanclaw", false, "Nix"},
+		{"", "/usr/bin/stefanclaw", true, "the AUR"},
+		{"", "/usr/bin/stefanclaw", false, ""},
+		{"", "/usr/local/bin/stefanclaw", true, ""},
+		{"", "/home/me/bin/stefanclaw", false, ""},
+		{"nix", "/home/me/bin/stefanclaw", false, "Nix"},
+		{"unknown", "/home/me/bin/stefanclaw", false, ""},
+	}
+	for _, tt := range tests {
+		m, ok := detectManager(tt.managedBy, tt.exe, tt.arch)
+		if ok != (tt.want != "") || m.Name != tt.want {
+			t.Errorf("detectManager(%q, %q, %t) = %q, %t, want %q", tt.managedBy, tt.exe, tt.arch, m.Name, ok, tt.want)
+		}
+	}
+	if m, _ := detectManager("homebrew", "", false); m.Upgrade != "brew upgrade stefanclaw" {
+		t.Errorf("Homebrew upgrade = %q", m.Upgrade)
+	}
+}
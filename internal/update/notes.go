@@ -0,0 +1,81 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// savedNotes is the content of a Notes file.
+type savedNotes struct {
+	Version string `json:"version"`
+	Notes   string `json:"notes"`
+}
+
+// Notes keeps the release notes of an installed update until the new
+// version first starts, in a JSON file.
+type Notes struct {
+	path string
+}
+
+// NewNotes creates notes backed by the file at path.
+func NewNotes(path string) *Notes {
+	return &Notes{path: path}
+}
+
+// Save keeps the release notes of version, replacing any saved earlier.
+// Empty notes aren't saved.
+func (n *Notes) Save(version, notes string) error {
+	if notes == "" {
+		return nil
+	}
+	data, err := json.Marshal(savedNotes{Version: version, Notes: notes})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(n.path, data, 0o644)
+}
+
+// Take returns the release notes saved for currentVersion and forgets them.
+// Notes of a version that isn't running yet, such as one installed by a
+// stefanclaw that hasn't restarted, are kept; notes of older versions are
+// dropped.
+func (n *Notes) Take(currentVersion string) (string, error) {
+	data, err := os.ReadFile(n.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var s savedNotes
+	if json.Unmarshal(data, &s) != nil {
+		return "", os.Remove(n.path)
+	}
+	if newer(s.Version, currentVersion) {
+		return "", nil
+	}
+	if err := os.Remove(n.path); err != nil {
+		return "", err
+	}
+	if !sameVersion(s.Version, currentVersion) {
+		return "", nil
+	}
+	return s.Notes, nil
+}
+
+// sameVersion reports whether a and b name the same semantic version, with
+// or without a leading "v".
+func sameVersion(a, b string) bool {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return false
+	}
+	vb, err := semver.NewVersion(b)
+	return err == nil && va.Equal(vb)
+}
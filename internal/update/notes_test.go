@@ -0,0 +1,46 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release-notes.json")
+	n := NewNotes(path)
+
+	if notes, err := n.Take("1.4.0"); err != nil || notes != "" {
+		t.Fatalf("Take without notes = %q, %v", notes, err)
+	}
+
+	if err := n.Save("1.4.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("empty notes were saved")
+	}
+
+	if err := n.Save("1.4.0", "## Fixes\n- a bug"); err != nil {
+		t.Fatal(err)
+	}
+	// The old version that installed the update keeps them for the new one.
+	if notes, _ := n.Take("1.3.0"); notes != "" {
+		t.Errorf("old version took %q", notes)
+	}
+	if notes, err := n.Take("v1.4.0"); err != nil || notes != "## Fixes\n- a bug" {
+		t.Errorf("Take = %q, %v", notes, err)
+	}
+	if notes, _ := n.Take("1.4.0"); notes != "" {
+		t.Errorf("notes shown twice: %q", notes)
+	}
+
+	// Notes of a version that was skipped over are dropped.
+	n.Save("1.4.0", "old news")
+	if notes, _ := n.Take("1.5.0"); notes != "" {
+		t.Errorf("newer version took %q", notes)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("stale notes were kept")
+	}
+}
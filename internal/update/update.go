@@ -3,7 +3,9 @@ package update
 import (
 	"context"
 	"fmt"
+	"io"
 	"runtime"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/creativeprojects/go-selfupdate"
@@ -11,28 +13,175 @@ import (
 
 const repo = "stefanclaw/stefanclaw"
 
-// Result holds the outcome of an update check or apply.
-type Result struct {
-	CurrentVersion string
-	LatestVersion  string
-	UpdateAvailable bool
-	Applied         bool
+// Release channels, set with update.channel.
+const (
+	ChannelStable  = "stable"  // releases only
+	ChannelBeta    = "beta"    // also betas and release candidates
+	ChannelNightly = "nightly" // every build
+)
+
+// InChannel reports whether the release tagged tag is offered on channel.
+// Releases without a pre-release suffix are on every channel; betas and
+// release candidates such as v1.2.0-beta.1 or v1.2.0-rc.1 are on beta and
+// nightly, and other pre-releases such as v1.2.0-nightly.20260310 only on
+// nightly. Tags that aren't semantic versions are never offered.
+func InChannel(channel, tag string) bool {
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return false
+	}
+	pre := v.Prerelease()
+	switch {
+	case pre == "", channel == ChannelNightly:
+		return true
+	case channel == ChannelBeta:
+		return strings.HasPrefix(pre, "beta") || strings.HasPrefix(pre, "rc")
+	}
+	return false
+}
+
+// Stages of Apply, reported to its progress function.
+const (
+	StageDownload = "download" // reported repeatedly as the archive arrives
+	StageVerify   = "verify"   // checking the archive's checksum and signature
+	StageInstall  = "install"  // replacing the binary
+)
+
+// Progress tells how far Apply got.
+type Progress struct {
+	Stage   string
+	Version string // the release being installed
+	Done    int64  // bytes of the archive downloaded so far
+	Total   int64  // size of the archive; 0 if unknown
+}
+
+// String describes p for a progress line, e.g. "Downloading v1.4.0... 45%
+// (3.2 of 7.1 MB)".
+func (p Progress) String() string {
+	switch p.Stage {
+	case StageDownload:
+		if p.Total > 0 {
+			return fmt.Sprintf("Downloading v%s... %d%% (%.1f of %.1f MB)", p.Version, p.Done*100/p.Total, megabytes(p.Done), megabytes(p.Total))
+		}
+		return fmt.Sprintf("Downloading v%s... %.1f MB", p.Version, megabytes(p.Done))
+	case StageVerify:
+		return fmt.Sprintf("Verifying v%s's checksum and signature...", p.Version)
+	case StageInstall:
+		return fmt.Sprintf("Installing v%s...", p.Version)
+	}
+	return p.Stage
+}
+
+func megabytes(n int64) float64 {
+	return float64(n) / 1e6
+}
+
+// channelSource hides the releases that aren't on channel, and reports the
+// progress of downloads to progress if it is set.
+type channelSource struct {
+	selfupdate.Source
+	channel  string
+	progress func(Progress)
+}
+
+func (s channelSource) ListReleases(ctx context.Context, repository selfupdate.Repository) ([]selfupdate.SourceRelease, error) {
+	releases, err := s.Source.ListReleases(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	var kept []selfupdate.SourceRelease
+	for _, rel := range releases {
+		if InChannel(s.channel, rel.GetTagName()) {
+			kept = append(kept, rel)
+		}
+	}
+	return kept, nil
 }
 
-// Check queries GitHub for the latest release and reports whether an update is
-// available. It does not download or replace anything.
-func Check(ctx context.Context, currentVersion string) (*Result, error) {
-	source, err := selfupdate.NewGitHubSource(selfupdate.GitHubConfig{})
+func (s channelSource) DownloadReleaseAsset(ctx context.Context, rel *selfupdate.Release, assetID int64) (io.ReadCloser, error) {
+	rc, err := s.Source.DownloadReleaseAsset(ctx, rel, assetID)
+	if err != nil || s.progress == nil {
+		return rc, err
+	}
+	p := Progress{Version: rel.Version()}
+	switch {
+	case assetID == rel.AssetID:
+		// The archive is verified once it has arrived
+		p.Stage, p.Total = StageDownload, int64(rel.AssetByteSize)
+		return &progressReader{ReadCloser: rc, progress: s.progress, p: p, next: StageVerify}, nil
+	case len(rel.ValidationChain) > 0 && assetID == rel.ValidationChain[len(rel.ValidationChain)-1].ValidationAssetID:
+		// The last file checked is the signature, after which the
+		// archive is installed
+		return &progressReader{ReadCloser: rc, progress: s.progress, p: p, next: StageInstall}, nil
+	}
+	return rc, nil
+}
+
+// progressReader reports the bytes read while p.Stage is StageDownload, and
+// the next stage once everything has been read.
+type progressReader struct {
+	io.ReadCloser
+	progress func(Progress)
+	p        Progress
+	next     string
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if r.p.Stage == StageDownload && n > 0 {
+		r.p.Done += int64(n)
+		r.progress(r.p)
+	}
+	if err == io.EOF && r.next != "" {
+		r.progress(Progress{Stage: r.next, Version: r.p.Version})
+		r.next = ""
+	}
+	return n, err
+}
+
+// newSource returns where releases come from, replaced in tests.
+var newSource = func() (selfupdate.Source, error) {
+	return selfupdate.NewGitHubSource(selfupdate.GitHubConfig{})
+}
+
+// newUpdater returns an updater that only sees the releases on channel. An
+// empty channel is stable. A nil validator skips verifying downloads, and a
+// nil progress reports nothing.
+func newUpdater(channel string, validator selfupdate.Validator, progress func(Progress)) (*selfupdate.Updater, error) {
+	source, err := newSource()
 	if err != nil {
 		return nil, fmt.Errorf("creating github source: %w", err)
 	}
 
 	updater, err := selfupdate.NewUpdater(selfupdate.Config{
-		Source: source,
+		Source:     channelSource{Source: source, channel: channel, progress: progress},
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Prerelease: channel == ChannelBeta || channel == ChannelNightly,
+		Validator:  validator,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating updater: %w", err)
 	}
+	return updater, nil
+}
+
+// Result holds the outcome of an update check or apply.
+type Result struct {
+	CurrentVersion  string
+	LatestVersion   string
+	UpdateAvailable bool
+	Applied         bool
+	ReleaseNotes    string // markdown changelog of the applied release
+}
+
+// Check queries GitHub for the latest release on channel and reports whether
+// an update is available. It does not download or replace anything.
+func Check(ctx context.Context, currentVersion, channel string) (*Result, error) {
+	updater, err := newUpdater(channel, nil, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	latest, found, err := updater.DetectLatest(ctx, selfupdate.ParseSlug(repo))
 	if err != nil {
@@ -54,21 +203,18 @@ func Check(ctx context.Context, currentVersion string) (*Result, error) {
 	return res, nil
 }
 
-// Apply downloads and installs the latest release, replacing the current
-// binary in-place.
-func Apply(ctx context.Context, currentVersion string) (*Result, error) {
-	source, err := selfupdate.NewGitHubSource(selfupdate.GitHubConfig{})
+// Apply downloads and installs the latest release on channel, replacing the
+// current binary in-place. The download must match the release's signed
+// checksums; Apply refuses it otherwise, and in builds without a release key.
+// progress, if not nil, is called from Apply's goroutine as it goes.
+func Apply(ctx context.Context, currentVersion, channel string, progress func(Progress)) (*Result, error) {
+	v, err := validator(releaseKey)
 	if err != nil {
-		return nil, fmt.Errorf("creating github source: %w", err)
+		return nil, err
 	}
-
-	updater, err := selfupdate.NewUpdater(selfupdate.Config{
-		Source:    source,
-		OS:       runtime.GOOS,
-		Arch:     runtime.GOARCH,
-	})
+	updater, err := newUpdater(channel, v, progress)
 	if err != nil {
-		return nil, fmt.Errorf("creating updater: %w", err)
+		return nil, err
 	}
 
 	latest, found, err := updater.DetectLatest(ctx, selfupdate.ParseSlug(repo))
@@ -102,5 +248,6 @@ func Apply(ctx context.Context, currentVersion string) (*Result, error) {
 	res.LatestVersion = latest.Version()
 	res.UpdateAvailable = true
 	res.Applied = true
+	res.ReleaseNotes = latest.ReleaseNotes
 	return res, nil
 }
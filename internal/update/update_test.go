@@ -2,7 +2,11 @@ package update
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
+
+	"github.com/creativeprojects/go-selfupdate"
 )
 
 func TestCheckDevVersion(t *testing.T) {
@@ -10,7 +14,7 @@ func TestCheckDevVersion(t *testing.T) {
 	// newer. We just verify Check doesn't panic or return a hard error for
 	// network-independent reasons. In CI without network this may fail, so
 	// we skip on error.
-	res, err := Check(context.Background(), "dev")
+	res, err := Check(context.Background(), "dev", ChannelStable)
 	if err != nil {
 		t.Skipf("skipping (likely no network): %v", err)
 	}
@@ -27,7 +31,7 @@ func TestCheckDevVersion(t *testing.T) {
 
 func TestCheckValidVersion(t *testing.T) {
 	// Use a very old version to verify the update-available logic.
-	res, err := Check(context.Background(), "0.0.1")
+	res, err := Check(context.Background(), "0.0.1", ChannelStable)
 	if err != nil {
 		t.Skipf("skipping (likely no network): %v", err)
 	}
@@ -38,3 +42,68 @@ func TestCheckValidVersion(t *testing.T) {
 		t.Errorf("CurrentVersion = %q, want 0.0.1", res.CurrentVersion)
 	}
 }
+
+func TestInChannel(t *testing.T) {
+	tests := []struct {
+		tag                   string
+		stable, beta, nightly bool
+	}{
+		{"v1.2.0", true, true, true},
+		{"1.2.0", true, true, true},
+		{"v1.3.0-beta.1", false, true, true},
+		{"v1.3.0-rc.2", false, true, true},
+		{"v1.3.0-alpha.1", false, false, true},
+		{"v1.3.0-nightly.20260310", false, false, true},
+		{"latest", false, false, false},
+	}
+	for _, tt := range tests {
+		for channel, want := range map[string]bool{ChannelStable: tt.stable, ChannelBeta: tt.beta, ChannelNightly: tt.nightly} {
+			if got := InChannel(channel, tt.tag); got != want {
+				t.Errorf("InChannel(%q, %q) = %t, want %t", channel, tt.tag, got, want)
+			}
+		}
+	}
+}
+
+// fakeRelease is a release with just a tag.
+type fakeRelease struct{ tag string }
+
+func (r fakeRelease) GetID() int64                        { return 0 }
+func (r fakeRelease) GetTagName() string                  { return r.tag }
+func (r fakeRelease) GetDraft() bool                      { return false }
+func (r fakeRelease) GetPrerelease() bool                 { return false }
+func (r fakeRelease) GetPublishedAt() time.Time           { return time.Time{} }
+func (r fakeRelease) GetReleaseNotes() string             { return "" }
+func (r fakeRelease) GetName() string                     { return r.tag }
+func (r fakeRelease) GetURL() string                      { return "" }
+func (r fakeRelease) GetAssets() []selfupdate.SourceAsset { return nil }
+
+// fakeSource lists fixed releases.
+type fakeSource struct{ tags []string }
+
+func (s fakeSource) ListReleases(context.Context, selfupdate.Repository) ([]selfupdate.SourceRelease, error) {
+	var releases []selfupdate.SourceRelease
+	for _, tag := range s.tags {
+		releases = append(releases, fakeRelease{tag})
+	}
+	return releases, nil
+}
+
+func (s fakeSource) DownloadReleaseAsset(context.Context, *selfupdate.Release, int64) (io.ReadCloser, error) {
+	return nil, io.EOF
+}
+
+func TestChannelSource(t *testing.T) {
+	source := channelSource{Source: fakeSource{tags: []string{"v1.2.0", "v1.3.0-rc.1", "v1.3.0-nightly.20260310"}}, channel: ChannelBeta}
+	releases, err := source.ListReleases(context.Background(), selfupdate.ParseSlug(repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for _, rel := range releases {
+		tags = append(tags, rel.GetTagName())
+	}
+	if len(tags) != 2 || tags[0] != "v1.2.0" || tags[1] != "v1.3.0-rc.1" {
+		t.Errorf("beta channel releases = %q, want v1.2.0 and v1.3.0-rc.1", tags)
+	}
+}
@@ -0,0 +1,113 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/creativeprojects/go-selfupdate"
+	binary "github.com/creativeprojects/go-selfupdate/update"
+)
+
+// checksumsFile lists the SHA-256 sums of a release's archives. It is signed
+// with the release key in checksumsFile + ".sig".
+const checksumsFile = "checksums.txt"
+
+// releaseKey is the base64-encoded DER (PKIX) ECDSA public key releases are
+// signed with. Release builds set it with
+// -ldflags "-X github.com/stefanclaw/stefanclaw/internal/update.releaseKey=...".
+var releaseKey string
+
+// ErrNoReleaseKey is returned by Apply in builds that can't verify updates.
+var ErrNoReleaseKey = errors.New("this build has no release signing key to verify updates with; download the new version from GitHub instead")
+
+// validator checks a downloaded archive against checksums.txt, and
+// checksums.txt against its signature made with key. Downloads without a
+// checksum or signature, or that don't match, are refused.
+func validator(key string) (selfupdate.Validator, error) {
+	if key == "" {
+		return nil, ErrNoReleaseKey
+	}
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decoding release key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing release key: %w", err)
+	}
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("release key is not an ECDSA key")
+	}
+	return new(selfupdate.PatternValidator).
+		Add(checksumsFile, &selfupdate.ECDSAValidator{PublicKey: ecKey}).
+		Add("*", &selfupdate.ChecksumValidator{UniqueFilename: checksumsFile}).
+		SkipValidation("*.sig"), nil
+}
+
+// ApplyFile installs the release archive at path, e.g. one downloaded on
+// another machine for a computer that can't reach GitHub. The release's
+// checksums.txt and checksums.txt.sig must be next to the archive; like
+// Apply, ApplyFile refuses an archive they don't vouch for.
+func ApplyFile(path string) error {
+	exe, err := selfupdate.ExecutablePath()
+	if err != nil {
+		return fmt.Errorf("finding executable path: %w", err)
+	}
+	return applyFile(path, exe, releaseKey)
+}
+
+// applyFile verifies the archive at path with key and replaces exe with the
+// binary in it.
+func applyFile(path, exe, key string) error {
+	v, err := validator(key)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	if platform := runtime.GOOS + "_" + runtime.GOARCH; !strings.Contains(name, platform) {
+		return fmt.Errorf("%s isn't a release for this computer; use the archive with %s in its name", name, platform)
+	}
+	read := func(name string) ([]byte, error) {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s (download it from the same release as the archive): %w", name, err)
+		}
+		return data, nil
+	}
+	checksums, err := read(checksumsFile)
+	if err != nil {
+		return err
+	}
+	sig, err := read(checksumsFile + ".sig")
+	if err != nil {
+		return err
+	}
+	archive, err := read(name)
+	if err != nil {
+		return err
+	}
+	if err := v.Validate(checksumsFile, checksums, sig); err != nil {
+		return fmt.Errorf("verifying %s: %w", checksumsFile, err)
+	}
+	if err := v.Validate(name, archive, checksums); err != nil {
+		return fmt.Errorf("verifying %s: %w", name, err)
+	}
+
+	bin, err := selfupdate.DecompressCommand(bytes.NewReader(archive), name, filepath.Base(exe), runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return fmt.Errorf("unpacking %s: %w", name, err)
+	}
+	if err := binary.Apply(bin, binary.Options{TargetPath: exe}); err != nil {
+		return fmt.Errorf("applying update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/go-selfupdate"
+)
+
+// testKey returns a fresh signing key and its public half encoded the way
+// releaseKey is.
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, base64.StdEncoding.EncodeToString(der)
+}
+
+// sign signs data like the release workflow's openssl dgst -sha256 -sign.
+func sign(t *testing.T, priv *ecdsa.PrivateKey, data []byte) []byte {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestValidator(t *testing.T) {
+	priv, key := testKey(t)
+	v, err := validator(key)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+
+	archive := []byte("the release archive")
+	sum := sha256.Sum256(archive)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  stefanclaw_linux_amd64.tar.gz\n")
+
+	if got := v.GetValidationAssetName("stefanclaw_linux_amd64.tar.gz"); got != checksumsFile {
+		t.Errorf("archive is validated by %q, want %q", got, checksumsFile)
+	}
+	if got := v.GetValidationAssetName(checksumsFile); got != checksumsFile+".sig" {
+		t.Errorf("checksums are validated by %q, want %q", got, checksumsFile+".sig")
+	}
+
+	if err := v.Validate("stefanclaw_linux_amd64.tar.gz", archive, checksums); err != nil {
+		t.Errorf("matching archive refused: %v", err)
+	}
+	if err := v.Validate("stefanclaw_linux_amd64.tar.gz", []byte("tampered"), checksums); err == nil {
+		t.Error("tampered archive accepted")
+	}
+	if err := v.Validate("stefanclaw_darwin_arm64.tar.gz", archive, checksums); err == nil {
+		t.Error("archive missing from checksums accepted")
+	}
+
+	if err := v.Validate(checksumsFile, checksums, sign(t, priv, checksums)); err != nil {
+		t.Errorf("signed checksums refused: %v", err)
+	}
+	if err := v.Validate(checksumsFile, []byte("tampered"), sign(t, priv, checksums)); err == nil {
+		t.Error("tampered checksums accepted")
+	}
+	other, _ := testKey(t)
+	if err := v.Validate(checksumsFile, checksums, sign(t, other, checksums)); err == nil {
+		t.Error("checksums signed with another key accepted")
+	}
+	if err := v.Validate(checksumsFile, checksums, nil); err == nil {
+		t.Error("unsigned checksums accepted")
+	}
+}
+
+func TestValidator_BadKey(t *testing.T) {
+	if _, err := validator(""); !errors.Is(err, ErrNoReleaseKey) {
+		t.Errorf("empty key: err = %v, want ErrNoReleaseKey", err)
+	}
+	if _, err := validator("not base64!"); err == nil {
+		t.Error("expected an error for a key that isn't base64")
+	}
+	if _, err := validator(base64.StdEncoding.EncodeToString([]byte("not a key"))); err == nil {
+		t.Error("expected an error for a key that isn't DER")
+	}
+}
+
+func TestApply_NoReleaseKey(t *testing.T) {
+	old := releaseKey
+	releaseKey = ""
+	t.Cleanup(func() { releaseKey = old })
+
+	if _, err := Apply(context.Background(), "0.0.1", ChannelStable, nil); !errors.Is(err, ErrNoReleaseKey) {
+		t.Errorf("err = %v, want ErrNoReleaseKey", err)
+	}
+}
+
+// assetRelease is a release with assets.
+type assetRelease struct {
+	fakeRelease
+	assets []selfupdate.SourceAsset
+}
+
+func (r assetRelease) GetAssets() []selfupdate.SourceAsset { return r.assets }
+
+// fakeAsset is a release asset served from memory.
+type fakeAsset struct {
+	id   int64
+	name string
+	data []byte
+}
+
+func (a fakeAsset) GetID() int64                  { return a.id }
+func (a fakeAsset) GetName() string               { return a.name }
+func (a fakeAsset) GetSize() int                  { return len(a.data) }
+func (a fakeAsset) GetBrowserDownloadURL() string { return "https://example.com/" + a.name }
+
+// assetSource serves one release.
+type assetSource struct{ rel assetRelease }
+
+func (s assetSource) ListReleases(context.Context, selfupdate.Repository) ([]selfupdate.SourceRelease, error) {
+	return []selfupdate.SourceRelease{s.rel}, nil
+}
+
+func (s assetSource) DownloadReleaseAsset(_ context.Context, _ *selfupdate.Release, id int64) (io.ReadCloser, error) {
+	for _, a := range s.rel.assets {
+		if a.GetID() == id {
+			return io.NopCloser(bytes.NewReader(a.(fakeAsset).data)), nil
+		}
+	}
+	return nil, fmt.Errorf("no asset %d", id)
+}
+
+// signedRelease returns a release of binary signed with priv; tamper
+// changes the archive after it was signed.
+func signedRelease(t *testing.T, priv *ecdsa.PrivateKey, binary []byte, tamper bool) assetSource {
+	name := fmt.Sprintf("stefanclaw_%s_%s", runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(binary)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	if tamper {
+		binary = append(binary, " and more"...)
+	}
+	return assetSource{assetRelease{fakeRelease: fakeRelease{"v1.4.0"}, assets: []selfupdate.SourceAsset{
+		fakeAsset{1, name, binary},
+		fakeAsset{2, checksumsFile, checksums},
+		fakeAsset{3, checksumsFile + ".sig", sign(t, priv, checksums)},
+	}}}
+}
+
+func TestUpdateTo_VerifiesAndReportsProgress(t *testing.T) {
+	priv, key := testKey(t)
+	v, err := validator(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := bytes.Repeat([]byte("new binary "), 10000)
+	old := newSource
+	t.Cleanup(func() { newSource = old })
+
+	for _, tamper := range []bool{false, true} {
+		src := signedRelease(t, priv, binary, tamper)
+		newSource = func() (selfupdate.Source, error) { return src, nil }
+
+		var stages []string
+		var last Progress
+		updater, err := newUpdater(ChannelStable, v, func(p Progress) {
+			if len(stages) == 0 || stages[len(stages)-1] != p.Stage {
+				stages = append(stages, p.Stage)
+			}
+			if p.Stage == StageDownload {
+				last = p
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, found, err := updater.DetectLatest(context.Background(), selfupdate.ParseSlug(repo))
+		if err != nil || !found {
+			t.Fatalf("DetectLatest = %v, %v", found, err)
+		}
+
+		exe := filepath.Join(t.TempDir(), "stefanclaw")
+		if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		err = updater.UpdateTo(context.Background(), rel, exe)
+		got, _ := os.ReadFile(exe)
+
+		if tamper {
+			if err == nil || string(got) != "old binary" {
+				t.Errorf("tampered archive: err = %v, binary replaced = %t", err, string(got) != "old binary")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("UpdateTo: %v", err)
+		}
+		if !bytes.Equal(got, binary) {
+			t.Error("binary not replaced")
+		}
+		if want := []string{StageDownload, StageVerify, StageInstall}; !slices.Equal(stages, want) {
+			t.Errorf("stages = %v, want %v", stages, want)
+		}
+		if last.Version != "1.4.0" || last.Total != int64(len(binary)) || last.Done != last.Total {
+			t.Errorf("last download progress = %+v, want all %d bytes of 1.4.0", last, len(binary))
+		}
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	priv, key := testKey(t)
+	binary := []byte("new binary")
+	src := signedRelease(t, priv, binary, false)
+
+	dir := t.TempDir()
+	for _, a := range src.rel.assets {
+		if err := os.WriteFile(filepath.Join(dir, a.GetName()), a.(fakeAsset).data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(dir, src.rel.assets[0].GetName())
+	exe := filepath.Join(t.TempDir(), "stefanclaw")
+	os.WriteFile(exe, []byte("old binary"), 0o755)
+
+	_, otherKey := testKey(t)
+	if err := applyFile(archive, exe, otherKey); err == nil {
+		t.Error("archive signed with another key installed")
+	}
+	wrong := filepath.Join(dir, "stefanclaw_plan9_mips")
+	os.WriteFile(wrong, binary, 0o644)
+	if err := applyFile(wrong, exe, key); err == nil || !strings.Contains(err.Error(), "isn't a release for this computer") {
+		t.Errorf("archive for another platform: err = %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+		t.Fatalf("binary replaced by a refused archive: %q", got)
+	}
+
+	if err := applyFile(archive, exe, key); err != nil {
+		t.Fatalf("applyFile: %v", err)
+	}
+	if got, _ := os.ReadFile(exe); !bytes.Equal(got, binary) {
+		t.Errorf("binary = %q, want the archive's", got)
+	}
+
+	os.WriteFile(archive, []byte("tampered"), 0o644)
+	if err := applyFile(archive, exe, key); err == nil {
+		t.Error("tampered archive installed")
+	}
+	os.Remove(filepath.Join(dir, checksumsFile+".sig"))
+	if err := applyFile(archive, exe, key); err == nil || !strings.Contains(err.Error(), checksumsFile+".sig") {
+		t.Errorf("missing signature: err = %v", err)
+	}
+}
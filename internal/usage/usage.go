@@ -0,0 +1,202 @@
+// Package usage keeps a record of the tokens every reply took and how fast
+// it was generated, so that /usage and `stefanclaw usage` can report them
+// per day, model and session.
+package usage
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record is the usage of one reply.
+type Record struct {
+	Time             time.Time     `json:"time"`
+	Session          string        `json:"session,omitempty"` // empty outside a session, e.g. in pipe mode
+	Model            string        `json:"model"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	EvalDuration     time.Duration `json:"eval_duration,omitempty"` // generating the reply; zero if not reported
+}
+
+// Log keeps records in a JSON lines file. It is safe for concurrent use
+// within a process; the TUI, pipe mode and the chat bridges all append to
+// it.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog creates a log backed by the file at path.
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Add appends r.
+func (l *Log) Add(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Records returns the records made at or after since, oldest first. Lines
+// that can't be parsed are skipped.
+func (l *Log) Records(since time.Time) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Record
+		if json.Unmarshal(sc.Bytes(), &r) == nil && !r.Time.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records, sc.Err()
+}
+
+// Totals sums the usage of several replies.
+type Totals struct {
+	Replies          int
+	PromptTokens     int
+	CompletionTokens int
+
+	timedTokens  int // completion tokens of the replies with a duration
+	evalDuration time.Duration
+}
+
+// Add counts r.
+func (t *Totals) Add(r Record) {
+	t.Replies++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	if r.EvalDuration > 0 {
+		t.timedTokens += r.CompletionTokens
+		t.evalDuration += r.EvalDuration
+	}
+}
+
+// Speed returns the tokens generated per second, over the replies whose
+// provider reported how long they took; 0 if none did.
+func (t Totals) Speed() float64 {
+	if t.evalDuration <= 0 {
+		return 0
+	}
+	return float64(t.timedTokens) / t.evalDuration.Seconds()
+}
+
+// String summarizes t, e.g. "12 replies, 15230 prompt and 3410 completion
+// tokens, 41.2 tokens/s".
+func (t Totals) String() string {
+	s := fmt.Sprintf("%d replies, %d prompt and %d completion tokens", t.Replies, t.PromptTokens, t.CompletionTokens)
+	if speed := t.Speed(); speed > 0 {
+		s += fmt.Sprintf(", %.1f tokens/s", speed)
+	}
+	return s
+}
+
+// Sum returns the totals of records.
+func Sum(records []Record) Totals {
+	var t Totals
+	for _, r := range records {
+		t.Add(r)
+	}
+	return t
+}
+
+// Group is the totals of the records that share a key.
+type Group struct {
+	Key string
+	Totals
+}
+
+// group sums records by key, in the order the keys first appear.
+func group(records []Record, key func(Record) string) []Group {
+	var groups []Group
+	index := map[string]int{}
+	for _, r := range records {
+		k := key(r)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{Key: k})
+		}
+		groups[i].Add(r)
+	}
+	return groups
+}
+
+// byTokens sorts groups with the most tokens first.
+func byTokens(groups []Group) []Group {
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return cmp.Compare(b.PromptTokens+b.CompletionTokens, a.PromptTokens+a.CompletionTokens)
+	})
+	return groups
+}
+
+// ByDay sums records per local day, e.g. "2026-10-15", earliest first.
+func ByDay(records []Record) []Group {
+	groups := group(records, func(r Record) string { return r.Time.Local().Format(time.DateOnly) })
+	slices.SortFunc(groups, func(a, b Group) int { return cmp.Compare(a.Key, b.Key) })
+	return groups
+}
+
+// ByModel sums records per model, the most used first.
+func ByModel(records []Record) []Group {
+	return byTokens(group(records, func(r Record) string { return r.Model }))
+}
+
+// BySession sums the records of sessions per session ID, the most used
+// first. Records made outside a session are left out.
+func BySession(records []Record) []Group {
+	records = slices.DeleteFunc(slices.Clone(records), func(r Record) bool { return r.Session == "" })
+	return byTokens(group(records, func(r Record) string { return r.Session }))
+}
+
+// Print writes groups as a table headed by heading, the name of what the
+// keys are, e.g. "DAY".
+func Print(w io.Writer, heading string, groups []Group) {
+	fmt.Fprintf(w, "%-24s %7s %10s %10s %7s\n", heading, "REPLIES", "PROMPT", "COMPLETION", "TOK/S")
+	for _, g := range groups {
+		key := g.Key
+		if r := []rune(key); len(r) > 24 {
+			key = string(r[:23]) + "…"
+		}
+		speed := "?"
+		if s := g.Speed(); s > 0 {
+			speed = fmt.Sprintf("%.1f", s)
+		}
+		fmt.Fprintf(w, "%-24s %7d %10d %10d %7s\n", key, g.Replies, g.PromptTokens, g.CompletionTokens, speed)
+	}
+}
@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(d, hour int) time.Time {
+	return time.Date(2026, 10, d, hour, 0, 0, 0, time.Local)
+}
+
+func TestLog_AddAndRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "usage.jsonl")
+	l := NewLog(path)
+	if records, err := l.Records(time.Time{}); err != nil || records != nil {
+		t.Fatalf("Records() of a missing log = %v, %v", records, err)
+	}
+	l.Add(Record{Time: day(13, 9), Model: "gemma3:1b", PromptTokens: 10})
+	l.Add(Record{Time: day(14, 9), Session: "s1", Model: "qwen3:8b", PromptTokens: 100, CompletionTokens: 20, EvalDuration: time.Second})
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("not json\n")
+	f.Close()
+
+	records, err := l.Records(day(14, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Session != "s1" || records[0].EvalDuration != time.Second {
+		t.Errorf("records = %+v, want the one of the 14th", records)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	var tot Totals
+	if tot.Speed() != 0 || tot.String() != "0 replies, 0 prompt and 0 completion tokens" {
+		t.Errorf("zero totals = %q", tot)
+	}
+	tot.Add(Record{PromptTokens: 100, CompletionTokens: 40, EvalDuration: 2 * time.Second})
+	tot.Add(Record{PromptTokens: 50, CompletionTokens: 60}) // no timing, left out of the speed
+	if got := tot.String(); got != "2 replies, 150 prompt and 100 completion tokens, 20.0 tokens/s" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	records := []Record{
+		{Time: day(14, 9), Session: "s1", Model: "gemma3:1b", PromptTokens: 10},
+		{Time: day(13, 9), Session: "s2", Model: "qwen3:8b", PromptTokens: 100},
+		{Time: day(14, 18), Model: "qwen3:8b", PromptTokens: 100},
+		{Time: day(14, 19), Session: "s1", Model: "gemma3:1b", PromptTokens: 10},
+	}
+
+	days := ByDay(records)
+	if len(days) != 2 || days[0].Key != "2026-10-13" || days[1].Key != "2026-10-14" || days[1].Replies != 3 {
+		t.Errorf("ByDay() = %+v", days)
+	}
+	models := ByModel(records)
+	if len(models) != 2 || models[0].Key != "qwen3:8b" || models[0].PromptTokens != 200 {
+		t.Errorf("ByModel() = %+v, want the most used first", models)
+	}
+	sessions := BySession(records)
+	if len(sessions) != 2 || sessions[0].Key != "s2" || sessions[1].Key != "s1" || sessions[1].Replies != 2 {
+		t.Errorf("BySession() = %+v, want the sessions only", sessions)
+	}
+	if len(records) != 4 || records[2].Session != "" {
+		t.Error("BySession() changed the records")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var b strings.Builder
+	Print(&b, "MODEL", []Group{
+		{Key: "qwen3:8b", Totals: Sum([]Record{{PromptTokens: 100, CompletionTokens: 40, EvalDuration: 2 * time.Second}})},
+		{Key: "hf.co/unsloth/a-very-long-model-name:Q4_K_M", Totals: Sum([]Record{{PromptTokens: 5}})},
+	})
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "MODEL") {
+		t.Fatalf("table =\n%s", b.String())
+	}
+	if f := strings.Fields(lines[1]); strings.Join(f, " ") != "qwen3:8b 1 100 40 20.0" {
+		t.Errorf("row = %q", lines[1])
+	}
+	if f := strings.Fields(lines[2]); f[0] != "hf.co/unsloth/a-very-lo…" || f[len(f)-1] != "?" {
+		t.Errorf("row = %q, want the model cut and no speed", lines[2])
+	}
+}
@@ -0,0 +1,200 @@
+// Package voice records speech from the microphone with an external
+// recorder and transcribes it with a local Whisper server.
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+// Backends for transcription.
+const (
+	BackendWhisperCpp = "whisper.cpp" // whisper.cpp's server, POST /inference
+	BackendOpenAI     = "openai"      // OpenAI-compatible POST /v1/audio/transcriptions
+)
+
+// recorders are tried in order when voice.recorder is not set. {file} is
+// replaced by the WAV file to write; each stops cleanly on SIGINT.
+var recorders = [][]string{
+	{"arecord", "-q", "-f", "S16_LE", "-r", "16000", "-c", "1", "{file}"},
+	{"rec", "-q", "-r", "16000", "-c", "1", "-b", "16", "{file}"},
+	{"ffmpeg", "-loglevel", "error", "-f", ffmpegInput(), "-i", ffmpegDevice(), "-ar", "16000", "-ac", "1", "-y", "{file}"},
+}
+
+func ffmpegInput() string {
+	if runtime.GOOS == "darwin" {
+		return "avfoundation"
+	}
+	return "pulse"
+}
+
+func ffmpegDevice() string {
+	if runtime.GOOS == "darwin" {
+		return ":0"
+	}
+	return "default"
+}
+
+// recorderCommand returns the recording command line for file: the
+// configured one, or the first known recorder that is installed.
+func recorderCommand(configured, file string) ([]string, error) {
+	candidates := recorders
+	if configured != "" {
+		candidates = [][]string{strings.Fields(configured)}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			if configured != "" {
+				return nil, fmt.Errorf("recorder %q not found", c[0])
+			}
+			continue
+		}
+		args := make([]string, len(c))
+		for i, a := range c {
+			args[i] = strings.ReplaceAll(a, "{file}", file)
+		}
+		return args, nil
+	}
+	return nil, errors.New("no audio recorder found: install arecord (alsa-utils), sox or ffmpeg, or set voice.recorder")
+}
+
+// Recording is a recording in progress.
+type Recording struct {
+	cmd    *exec.Cmd
+	path   string
+	stderr bytes.Buffer
+}
+
+// Record starts recording to a temporary WAV file with the configured
+// recorder, or the first one found.
+func Record(recorder string) (*Recording, error) {
+	f, err := os.CreateTemp("", "stefanclaw-voice-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	args, err := recorderCommand(recorder, f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	r := &Recording{cmd: exec.Command(args[0], args[1:]...), path: f.Name()}
+	r.cmd.Stderr = &r.stderr
+	if err := r.cmd.Start(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("starting %s: %w", args[0], err)
+	}
+	return r, nil
+}
+
+// Stop ends the recording and returns the WAV file, which the caller
+// removes. Recorders are interrupted so they can finish the file header.
+func (r *Recording) Stop() (string, error) {
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill() // Windows can't interrupt
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		r.cmd.Process.Kill()
+		<-done
+	}
+	// Recorders exit non-zero when interrupted, so judge by the file
+	info, err := os.Stat(r.path)
+	if err != nil || info.Size() <= 44 {
+		os.Remove(r.path)
+		if msg := strings.TrimSpace(r.stderr.String()); msg != "" {
+			return "", fmt.Errorf("nothing was recorded: %s", msg)
+		}
+		return "", errors.New("nothing was recorded")
+	}
+	return r.path, nil
+}
+
+// Cancel ends the recording and discards it.
+func (r *Recording) Cancel() {
+	r.cmd.Process.Kill()
+	r.cmd.Wait()
+	os.Remove(r.path)
+}
+
+// Transcriber turns recordings into text.
+type Transcriber struct {
+	cfg    config.VoiceConfig
+	client *http.Client
+}
+
+// NewTranscriber creates a Transcriber for cfg.
+func NewTranscriber(cfg config.VoiceConfig) *Transcriber {
+	return &Transcriber{cfg: cfg, client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// Transcribe uploads the audio file and returns the recognized text.
+func (t *Transcriber) Transcribe(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", err
+	}
+	endpoint := strings.TrimRight(t.cfg.URL, "/")
+	if t.cfg.Backend == BackendOpenAI {
+		endpoint += "/v1/audio/transcriptions"
+		w.WriteField("model", t.cfg.Model)
+	} else {
+		endpoint += "/inference"
+		w.WriteField("temperature", "0")
+	}
+	w.WriteField("response_format", "json")
+	if t.cfg.Language != "" {
+		w.WriteField("language", t.cfg.Language)
+	}
+	w.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("transcription server: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("transcription server: decoding response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
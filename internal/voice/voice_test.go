@@ -0,0 +1,102 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stefanclaw/stefanclaw/internal/config"
+)
+
+func TestRecord(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	// A fake recorder that writes some audio and runs until interrupted
+	script := filepath.Join(t.TempDir(), "rec.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\nprintf '%0100d' 0 > \"$1\"\ntrap 'exit 0' INT\nwhile :; do sleep 0.05; done\n"), 0o755)
+
+	r, err := Record(script + " {file}")
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	// Let it start before stopping, as a speaker would
+	for i := 0; i < 100; i++ {
+		if info, err := os.Stat(r.path); err == nil && info.Size() > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	path, err := r.Stop()
+	if err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	defer os.Remove(path)
+	if info, err := os.Stat(path); err != nil || info.Size() != 100 {
+		t.Errorf("recording = %v, %v, want 100 bytes", info, err)
+	}
+
+	if _, err := Record("no-such-recorder {file}"); err == nil {
+		t.Error("Record() with a missing recorder should fail")
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	var got struct{ path, model, language, audio string }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(f)
+		got.path, got.model, got.language, got.audio = r.URL.Path, r.FormValue("model"), r.FormValue("language"), string(audio)
+		json.NewEncoder(w).Encode(map[string]string{"text": " Remind me to call Anna. \n"})
+	}))
+	defer srv.Close()
+
+	audio := filepath.Join(t.TempDir(), "speech.wav")
+	os.WriteFile(audio, []byte("RIFF...."), 0o644)
+
+	tests := []struct {
+		cfg      config.VoiceConfig
+		wantPath string
+	}{
+		{config.VoiceConfig{Backend: BackendWhisperCpp, URL: srv.URL + "/"}, "/inference"},
+		{config.VoiceConfig{Backend: BackendOpenAI, URL: srv.URL, Model: "whisper-1", Language: "en"}, "/v1/audio/transcriptions"},
+	}
+	for _, tt := range tests {
+		text, err := NewTranscriber(tt.cfg).Transcribe(context.Background(), audio)
+		if err != nil {
+			t.Fatalf("%s: Transcribe() error: %v", tt.cfg.Backend, err)
+		}
+		if text != "Remind me to call Anna." {
+			t.Errorf("%s: text = %q", tt.cfg.Backend, text)
+		}
+		if got.path != tt.wantPath || got.model != tt.cfg.Model || got.language != tt.cfg.Language || got.audio != "RIFF...." {
+			t.Errorf("%s: request = %+v", tt.cfg.Backend, got)
+		}
+	}
+}
+
+func TestTranscribe_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	audio := filepath.Join(t.TempDir(), "speech.wav")
+	os.WriteFile(audio, []byte("RIFF"), 0o644)
+
+	_, err := NewTranscriber(config.VoiceConfig{URL: srv.URL}).Transcribe(context.Background(), audio)
+	if err == nil || err.Error() != "transcription server: HTTP 500: model not loaded" {
+		t.Errorf("Transcribe() error = %v", err)
+	}
+}